@@ -55,7 +55,7 @@ require (
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
 	github.com/golang/protobuf v1.3.2
 	github.com/google/go-cmp v0.3.1
-	github.com/google/go-containerregistry v0.0.0-20190317040536-ebbba8469d06 // indirect
+	github.com/google/go-containerregistry v0.0.0-20190317040536-ebbba8469d06
 	github.com/google/go-github v17.0.0+incompatible
 	github.com/google/uuid v1.1.1
 	github.com/hashicorp/go-sockaddr v1.0.2 // indirect
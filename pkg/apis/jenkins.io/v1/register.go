@@ -74,6 +74,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&TeamList{},
 		&User{},
 		&UserList{},
+		&WebhookEvent{},
+		&WebhookEventList{},
 		&Workflow{},
 		&WorkflowList{},
 	)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -59,6 +60,26 @@ type EnvironmentSpec struct {
 // EnvironmentStatus is the status for an Environment resource
 type EnvironmentStatus struct {
 	Version string `json:"version,omitempty"`
+	// Conditions the latest observations of the state of the Environment such as whether the cluster has drifted from
+	// the environment git repository
+	Conditions []EnvironmentCondition `json:"conditions,omitempty"`
+}
+
+// EnvironmentConditionType is a type of condition of an Environment
+type EnvironmentConditionType string
+
+const (
+	// EnvironmentDrifted the resources in the cluster do not match the resources rendered from the environment git repository
+	EnvironmentDrifted EnvironmentConditionType = "Drifted"
+)
+
+// EnvironmentCondition is an observation of the state of an Environment
+type EnvironmentCondition struct {
+	Type               EnvironmentConditionType `json:"type"`
+	Status             corev1.ConditionStatus   `json:"status"`
+	LastTransitionTime *metav1.Time             `json:"lastTransitionTime,omitempty"`
+	Reason             string                   `json:"reason,omitempty"`
+	Message            string                   `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -250,6 +271,9 @@ type TeamSettings struct {
 
 	// DeployOptions configures options for how to deploy applications by default such as using canary rollouts (progressive delivery) or using horizontal pod autoscaler
 	DeployOptions *DeployOptions `json:"deployOptions,omitempty" protobuf:"bytes,32,opt,name=deployOptions"`
+
+	// QuickstartCatalogs are the git repositories containing an index of additional quickstarts for the team
+	QuickstartCatalogs []QuickstartCatalog `json:"quickstartCatalogs,omitempty" protobuf:"bytes,33,opt,name=quickstartCatalogs"`
 }
 
 // StorageLocation
@@ -269,6 +293,20 @@ type QuickStartLocation struct {
 	Excludes []string `json:"excludes,omitempty" protobuf:"bytes,5,opt,name=excludes"`
 }
 
+// QuickstartCatalog a git repository containing a quickstarts.yml index of quickstarts
+type QuickstartCatalog struct {
+	// Name the name of the catalog
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// GitURL the git clone URL of the catalog repository
+	GitURL string `json:"gitUrl,omitempty" protobuf:"bytes,2,opt,name=gitUrl"`
+	// GitKind the kind of git server of the catalog repository
+	GitKind string `json:"gitKind,omitempty" protobuf:"bytes,3,opt,name=gitKind"`
+	// Ref the git branch or tag of the catalog repository. Defaults to master
+	Ref string `json:"ref,omitempty" protobuf:"bytes,4,opt,name=ref"`
+	// Path the path of the index file in the catalog repository. Defaults to quickstarts.yml
+	Path string `json:"path,omitempty" protobuf:"bytes,5,opt,name=path"`
+}
+
 // PreviewGitSpec is the preview git branch/pull request details
 type PreviewGitSpec struct {
 	Name            string   `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
@@ -525,6 +525,16 @@ type SourceRepositorySpec struct {
 	HTTPCloneURL string `json:"httpCloneURL,omitempty" protobuf:"bytes,9,opt,name=httpCloneURL"`
 	// Scheduler a reference to a custom scheduler otherwise we default to the Team's Scededuler
 	Scheduler ResourceReference `json:"scheduler,omitempty" protobuf:"bytes,10,opt,name=scheduler"`
+	// Apps the applications in the subdirectories of a monorepo which are built and released separately
+	Apps []SourceRepositoryApp `json:"apps,omitempty" protobuf:"bytes,11,rep,name=apps"`
+}
+
+// SourceRepositoryApp an application in a subdirectory of a monorepo
+type SourceRepositoryApp struct {
+	// Name the name of the application which is used for its pipeline context, chart and release tags
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Path the directory of the application relative to the root of the repository
+	Path string `json:"path" protobuf:"bytes,2,opt,name=path"`
 }
 
 // AppSpec provides details of the metadata for an App
@@ -67,6 +67,8 @@ type PipelineActivitySpec struct {
 	BatchPipelineActivity BatchPipelineActivity `json:"batchPipelineActivity,omitempty" protobuf:"bytes,25,opt,name=batchPipelineActivity"`
 	Context               string                `json:"context,omitempty" protobuf:"bytes,26,opt,name=context"`
 	BaseSHA               string                `json:"baseSHA,omitempty" protobuf:"bytes,27,opt,name=baseSHA"`
+	SBOMs                 []SBOM                `json:"sboms,omitempty" protobuf:"bytes,28,opt,name=sboms"`
+	ImageScans            []ImageScan           `json:"imageScans,omitempty" protobuf:"bytes,29,opt,name=imageScans"`
 }
 
 // BatchPipelineActivity contains information about a batch build, used by both the batch build and its comprising PRs for linking them together
@@ -208,6 +210,33 @@ type Attachment struct {
 	URLs []string `json:"urls,omitempty"  protobuf:"bytes,2,opt,name=urls"`
 }
 
+// SBOM contains the summary of the software bill of materials of an image built by the pipeline
+type SBOM struct {
+	Image    string `json:"image,omitempty" protobuf:"bytes,1,opt,name=image"`
+	Format   string `json:"format,omitempty" protobuf:"bytes,2,opt,name=format"`
+	Packages int    `json:"packages,omitempty" protobuf:"varint,3,opt,name=packages"`
+	// ReleaseAssetURL the URL of the SBOM attached to the git release
+	ReleaseAssetURL string `json:"releaseAssetURL,omitempty" protobuf:"bytes,4,opt,name=releaseAssetURL"`
+	// Referrer the digest of the OCI artifact of the SBOM referring to the image in the registry
+	Referrer string `json:"referrer,omitempty" protobuf:"bytes,5,opt,name=referrer"`
+}
+
+// ImageScan contains the summary of the vulnerability scan of an image built by the pipeline
+type ImageScan struct {
+	Image   string `json:"image,omitempty" protobuf:"bytes,1,opt,name=image"`
+	Scanner string `json:"scanner,omitempty" protobuf:"bytes,2,opt,name=scanner"`
+	// Severity the lowest severity of the vulnerabilities which fail the scan
+	Severity string `json:"severity,omitempty" protobuf:"bytes,3,opt,name=severity"`
+	Passed   bool   `json:"passed,omitempty" protobuf:"varint,4,opt,name=passed"`
+	Critical int    `json:"critical,omitempty" protobuf:"varint,5,opt,name=critical"`
+	High     int    `json:"high,omitempty" protobuf:"varint,6,opt,name=high"`
+	Medium   int    `json:"medium,omitempty" protobuf:"varint,7,opt,name=medium"`
+	Low      int    `json:"low,omitempty" protobuf:"varint,8,opt,name=low"`
+	Unknown  int    `json:"unknown,omitempty" protobuf:"varint,9,opt,name=unknown"`
+	// Blocking the IDs of the vulnerabilities which failed the scan
+	Blocking []string `json:"blocking,omitempty" protobuf:"bytes,10,rep,name=blocking"`
+}
+
 // IsTerminated returns true if this activity has stopped executing
 func (s ActivityStatusType) IsTerminated() bool {
 	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted
@@ -68,6 +68,8 @@ type SchedulerSpec struct {
 	Welcome       []*Welcome                 `json:"welcome,omitempty" protobuf:"bytes,12,opt,name=welcome"`
 	Periodics     *Periodics                 `json:"periodics,omitempty" protobuf:"bytes,13,opt,name=periodics"`
 	Attachments   []*Attachment              `json:"attachments,omitempty" protobuf:"bytes,13,opt,name=attachments"`
+	// ChatOpsCommands are the custom ChatOps commands which trigger pipelines from Pull Request comments
+	ChatOpsCommands *ChatOpsCommands `json:"chatOpsCommands,omitempty" protobuf:"bytes,14,opt,name=chatOpsCommands"`
 }
 
 // ConfigMapSpec contains configuration options for the configMap being updated
@@ -303,6 +305,45 @@ type Periodic struct {
 	Tags *ReplaceableSliceOfStrings `json:"tags,omitempty"`
 }
 
+// ChatOpsCommands is a list of custom ChatOps commands that can optionally completely replace the ChatOps commands in
+// the parent scheduler
+type ChatOpsCommands struct {
+	// Items are the ChatOps commands
+	Items []*ChatOpsCommand `json:"entries,omitempty" protobuf:"bytes,1,opt,name=entries"`
+	// Replace the existing entries
+	Replace bool `json:"replace,omitempty" protobuf:"bytes,2,opt,name=replace"`
+}
+
+// ChatOpsCommand defines a custom ChatOps command, such as `/loadtest`, which triggers the pipeline in the
+// `jenkins-x-<name>.yml` file of the repository when a Pull Request comment matches its trigger phrase
+type ChatOpsCommand struct {
+	// Name of the command which is also the job name and the context of the pipeline
+	Name *string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Trigger is the regular expression of the comments which trigger the pipeline. It cannot be used with Arguments
+	// e.g. `(?m)^/loadtest( now)?\s*$`
+	// (Default: `(?m)^/<name>\s*$`)
+	Trigger *string `json:"trigger,omitempty" protobuf:"bytes,2,opt,name=trigger"`
+	// Command is the trigger phrase to give users. Must match Trigger. It cannot be used with Arguments
+	// (Default: `/<name>`)
+	Command *string `json:"command,omitempty" protobuf:"bytes,3,opt,name=command"`
+	// Parameters are the environment variables of the pipeline
+	Parameters *ReplaceableMapOfStringString `json:"parameters,omitempty" protobuf:"bytes,4,opt,name=parameters"`
+	// Report enables reporting the pipeline status on the git provider. By default true.
+	Report *bool `json:"report,omitempty" protobuf:"bytes,5,opt,name=report"`
+	// Arguments are the arguments of the command in the comment, e.g. the environment of `/deploy-to staging`, whose
+	// values are passed to the pipeline as environment variables. A job named `<name>-<value>...` is triggered by
+	// `/<name> <value>...` for each combination of the values of the arguments
+	Arguments []*ChatOpsArgument `json:"arguments,omitempty" protobuf:"bytes,6,rep,name=arguments"`
+}
+
+// ChatOpsArgument is an argument of a custom ChatOps command
+type ChatOpsArgument struct {
+	// Name is the name of the environment variable of the argument
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Values are the values of the argument which can be used in comments
+	Values []string `json:"values" protobuf:"bytes,2,rep,name=values"`
+}
+
 // Query is turned into a Git Provider search query. See the docs for details:
 // https://help.github.com/articles/searching-issues-and-pull-requests/
 type Query struct {
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+
+// WebhookEvent represents a webhook delivery received by a controller so that redeliveries can be ignored,
+// events can be replayed and the events whose handler failed are kept for debugging
+type WebhookEvent struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Spec   WebhookEventSpec   `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	Status WebhookEventStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// WebhookEventSpec is the specification of a webhook delivery
+type WebhookEventSpec struct {
+	// Source the name of the controller which received the event
+	Source string `json:"source,omitempty" protobuf:"bytes,1,opt,name=source"`
+	// EventType the type of the event such as push
+	EventType string `json:"eventType,omitempty" protobuf:"bytes,2,opt,name=eventType"`
+	// DeliveryID the unique ID of the delivery from the git provider
+	DeliveryID string `json:"deliveryID,omitempty" protobuf:"bytes,3,opt,name=deliveryID"`
+	// PayloadDigest the SHA256 digest of the body of the webhook request. The body itself is not stored as it can be
+	// larger than a resource can be
+	PayloadDigest string `json:"payloadDigest,omitempty" protobuf:"bytes,4,opt,name=payloadDigest"`
+	// ReceivedTimestamp when the event was first received
+	ReceivedTimestamp *metav1.Time `json:"receivedTimestamp,omitempty" protobuf:"bytes,5,opt,name=receivedTimestamp"`
+	// Revision the git commit of the event, e.g. the commit pushed by a push event, so that replays process the same
+	// commit rather than the current head of the branch
+	Revision string `json:"revision,omitempty" protobuf:"bytes,6,opt,name=revision"`
+}
+
+// WebhookEventStatus is the status of the processing of a webhook delivery
+type WebhookEventStatus struct {
+	Phase WebhookEventPhase `json:"phase,omitempty" protobuf:"bytes,1,opt,name=phase"`
+	// Message the error of the last failed attempt
+	Message  string                `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
+	Attempts []WebhookEventAttempt `json:"attempts,omitempty" protobuf:"bytes,3,opt,name=attempts"`
+	// ClaimedTimestamp when the event was last claimed by a controller to process it. An event which is still running
+	// long after it was claimed can be claimed again as its controller most likely stopped while processing it
+	ClaimedTimestamp *metav1.Time `json:"claimedTimestamp,omitempty" protobuf:"bytes,4,opt,name=claimedTimestamp"`
+}
+
+// WebhookEventAttempt represents an invocation of the handler of a webhook event
+type WebhookEventAttempt struct {
+	StartedTimestamp   *metav1.Time `json:"startedTimestamp,omitempty" protobuf:"bytes,1,opt,name=startedTimestamp"`
+	CompletedTimestamp *metav1.Time `json:"completedTimestamp,omitempty" protobuf:"bytes,2,opt,name=completedTimestamp"`
+	// Replay true if the attempt was a replay of the event rather than a delivery from the git provider
+	Replay bool   `json:"replay,omitempty" protobuf:"bytes,3,opt,name=replay"`
+	Error  string `json:"error,omitempty" protobuf:"bytes,4,opt,name=error"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebhookEventList is a list of WebhookEvent resources
+type WebhookEventList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WebhookEvent `json:"items"`
+}
+
+// WebhookEventPhase is the phase of the processing of a webhook event
+type WebhookEventPhase string
+
+const (
+	// WebhookEventPhasePending the event is waiting to be processed, e.g. a replay has been requested
+	WebhookEventPhasePending WebhookEventPhase = "Pending"
+	// WebhookEventPhaseRunning the handler of the event is running
+	WebhookEventPhaseRunning WebhookEventPhase = "Running"
+	// WebhookEventPhaseSucceeded the handler of the event succeeded
+	WebhookEventPhaseSucceeded WebhookEventPhase = "Succeeded"
+	// WebhookEventPhaseFailed the handler of the event failed so the event is in the dead letter queue
+	WebhookEventPhaseFailed WebhookEventPhase = "Failed"
+)
@@ -365,6 +365,106 @@ func (in *ChartRef) DeepCopy() *ChartRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChatOpsArgument) DeepCopyInto(out *ChatOpsArgument) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChatOpsArgument.
+func (in *ChatOpsArgument) DeepCopy() *ChatOpsArgument {
+	if in == nil {
+		return nil
+	}
+	out := new(ChatOpsArgument)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChatOpsCommand) DeepCopyInto(out *ChatOpsCommand) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Trigger != nil {
+		in, out := &in.Trigger, &out.Trigger
+		*out = new(string)
+		**out = **in
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = new(string)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = new(ReplaceableMapOfStringString)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Report != nil {
+		in, out := &in.Report, &out.Report
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make([]*ChatOpsArgument, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChatOpsArgument)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChatOpsCommand.
+func (in *ChatOpsCommand) DeepCopy() *ChatOpsCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(ChatOpsCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChatOpsCommands) DeepCopyInto(out *ChatOpsCommands) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]*ChatOpsCommand, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChatOpsCommand)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChatOpsCommands.
+func (in *ChatOpsCommands) DeepCopy() *ChatOpsCommands {
+	if in == nil {
+		return nil
+	}
+	out := new(ChatOpsCommands)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommitStatus) DeepCopyInto(out *CommitStatus) {
 	*out = *in
@@ -735,7 +835,7 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -757,6 +857,26 @@ func (in *Environment) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentCondition) DeepCopyInto(out *EnvironmentCondition) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentCondition.
+func (in *EnvironmentCondition) DeepCopy() *EnvironmentCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentFilter) DeepCopyInto(out *EnvironmentFilter) {
 	*out = *in
@@ -960,6 +1080,13 @@ func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]EnvironmentCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -1608,6 +1735,27 @@ func (in *GlobalProtectionPolicy) DeepCopy() *GlobalProtectionPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScan) DeepCopyInto(out *ImageScan) {
+	*out = *in
+	if in.Blocking != nil {
+		in, out := &in.Blocking, &out.Blocking
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScan.
+func (in *ImageScan) DeepCopy() *ImageScan {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IssueLabel) DeepCopyInto(out *IssueLabel) {
 	*out = *in
@@ -2008,6 +2156,18 @@ func (in *PipelineActivitySpec) DeepCopyInto(out *PipelineActivitySpec) {
 		}
 	}
 	in.BatchPipelineActivity.DeepCopyInto(&out.BatchPipelineActivity)
+	if in.SBOMs != nil {
+		in, out := &in.SBOMs, &out.SBOMs
+		*out = make([]SBOM, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageScans != nil {
+		in, out := &in.ImageScans, &out.ImageScans
+		*out = make([]ImageScan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2791,6 +2951,22 @@ func (in *QuickStartLocation) DeepCopy() *QuickStartLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuickstartCatalog) DeepCopyInto(out *QuickstartCatalog) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuickstartCatalog.
+func (in *QuickstartCatalog) DeepCopy() *QuickstartCatalog {
+	if in == nil {
+		return nil
+	}
+	out := new(QuickstartCatalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegexpChangeMatcher) DeepCopyInto(out *RegexpChangeMatcher) {
 	*out = *in
@@ -3139,6 +3315,22 @@ func (in *ReviewPolicy) DeepCopy() *ReviewPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SBOM) DeepCopyInto(out *SBOM) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SBOM.
+func (in *SBOM) DeepCopy() *SBOM {
+	if in == nil {
+		return nil
+	}
+	out := new(SBOM)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Scheduler) DeepCopyInto(out *Scheduler) {
 	*out = *in
@@ -3305,6 +3497,11 @@ func (in *SchedulerSpec) DeepCopyInto(out *SchedulerSpec) {
 			}
 		}
 	}
+	if in.ChatOpsCommands != nil {
+		in, out := &in.ChatOpsCommands, &out.ChatOpsCommands
+		*out = new(ChatOpsCommands)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -3323,7 +3520,7 @@ func (in *SourceRepository) DeepCopyInto(out *SourceRepository) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
@@ -3345,6 +3542,22 @@ func (in *SourceRepository) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourceRepositoryApp) DeepCopyInto(out *SourceRepositoryApp) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceRepositoryApp.
+func (in *SourceRepositoryApp) DeepCopy() *SourceRepositoryApp {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceRepositoryApp)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceRepositoryGroup) DeepCopyInto(out *SourceRepositoryGroup) {
 	*out = *in
@@ -3466,6 +3679,11 @@ func (in *SourceRepositoryList) DeepCopyObject() runtime.Object {
 func (in *SourceRepositorySpec) DeepCopyInto(out *SourceRepositorySpec) {
 	*out = *in
 	out.Scheduler = in.Scheduler
+	if in.Apps != nil {
+		in, out := &in.Apps, &out.Apps
+		*out = make([]SourceRepositoryApp, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -3634,6 +3852,11 @@ func (in *TeamSettings) DeepCopyInto(out *TeamSettings) {
 		*out = new(DeployOptions)
 		**out = **in
 	}
+	if in.QuickstartCatalogs != nil {
+		in, out := &in.QuickstartCatalogs, &out.QuickstartCatalogs
+		*out = make([]QuickstartCatalog, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -3822,6 +4045,138 @@ func (in *UserSpec) DeepCopy() *UserSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEvent) DeepCopyInto(out *WebhookEvent) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEvent.
+func (in *WebhookEvent) DeepCopy() *WebhookEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookEvent) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEventAttempt) DeepCopyInto(out *WebhookEventAttempt) {
+	*out = *in
+	if in.StartedTimestamp != nil {
+		in, out := &in.StartedTimestamp, &out.StartedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedTimestamp != nil {
+		in, out := &in.CompletedTimestamp, &out.CompletedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEventAttempt.
+func (in *WebhookEventAttempt) DeepCopy() *WebhookEventAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEventAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEventList) DeepCopyInto(out *WebhookEventList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WebhookEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEventList.
+func (in *WebhookEventList) DeepCopy() *WebhookEventList {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEventList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookEventList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEventSpec) DeepCopyInto(out *WebhookEventSpec) {
+	*out = *in
+	if in.ReceivedTimestamp != nil {
+		in, out := &in.ReceivedTimestamp, &out.ReceivedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEventSpec.
+func (in *WebhookEventSpec) DeepCopy() *WebhookEventSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEventSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookEventStatus) DeepCopyInto(out *WebhookEventStatus) {
+	*out = *in
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]WebhookEventAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClaimedTimestamp != nil {
+		in, out := &in.ClaimedTimestamp, &out.ClaimedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookEventStatus.
+func (in *WebhookEventStatus) DeepCopy() *WebhookEventStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookEventStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Welcome) DeepCopyInto(out *Welcome) {
 	*out = *in
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -36,6 +37,12 @@ const (
 	usernameKey = "username"
 	// secretDataPassword the password in a Secret/Credentials
 	passwordKey = "password"
+	// gitHubAppIDKey the ID of the GitHub App in a Secret
+	gitHubAppIDKey = "appId"
+	// gitHubAppInstallationIDKey the installation ID of the GitHub App in a Secret
+	gitHubAppInstallationIDKey = "installationId"
+	// gitHubAppPrivateKeyKey the PEM encoded private key of the GitHub App in a Secret
+	gitHubAppPrivateKeyKey = "privateKey"
 	// secretPrefix prefix for pipeline secrets
 	secretPrefix = "jx-pipeline"
 )
@@ -134,11 +141,15 @@ func (k *KubeAuthConfigHandler) SaveConfig(config *AuthConfig) error {
 		if user.Username == "" {
 			return errors.New("empty username")
 		}
-		if user.ApiToken == "" && user.Password == "" {
+		if user.ApiToken == "" && user.Password == "" && !user.IsGitHubApp() {
 			return errors.New("empty credentials")
 		}
 		secret.Data[usernameKey] = []byte(user.Username)
-		if user.ApiToken != "" {
+		if user.IsGitHubApp() {
+			secret.Data[gitHubAppIDKey] = []byte(strconv.FormatInt(user.GitHubAppID, 10))
+			secret.Data[gitHubAppInstallationIDKey] = []byte(strconv.FormatInt(user.GitHubAppInstallationID, 10))
+			secret.Data[gitHubAppPrivateKeyKey] = []byte(user.GitHubAppPrivateKey)
+		} else if user.ApiToken != "" {
 			secret.Data[passwordKey] = []byte(user.ApiToken)
 		} else {
 			secret.Data[passwordKey] = []byte(user.Password)
@@ -213,6 +224,9 @@ func (k *KubeAuthConfigHandler) userFromSecret(secret corev1.Secret) (UserAuth,
 	if data == nil {
 		return UserAuth{}, fmt.Errorf("no user auth credentials found in secret '%s'", secret.Name)
 	}
+	if privateKey, ok := data[gitHubAppPrivateKeyKey]; ok && len(privateKey) > 0 {
+		return gitHubAppUserFromSecret(secret)
+	}
 	username, ok := data[usernameKey]
 	if !ok || len(username) == 0 {
 		return UserAuth{}, fmt.Errorf("no user name found in secret '%s'", secret.Name)
@@ -228,6 +242,29 @@ func (k *KubeAuthConfigHandler) userFromSecret(secret corev1.Secret) (UserAuth,
 	}, nil
 }
 
+// gitHubAppUserFromSecret returns the user authenticating as the GitHub App of the secret
+func gitHubAppUserFromSecret(secret corev1.Secret) (UserAuth, error) {
+	data := secret.Data
+	appID, err := strconv.ParseInt(string(data[gitHubAppIDKey]), 10, 64)
+	if err != nil {
+		return UserAuth{}, errors.Wrapf(err, "no valid GitHub App ID found in secret '%s'", secret.Name)
+	}
+	installationID, err := strconv.ParseInt(string(data[gitHubAppInstallationIDKey]), 10, 64)
+	if err != nil {
+		return UserAuth{}, errors.Wrapf(err, "no valid GitHub App installation ID found in secret '%s'", secret.Name)
+	}
+	username := string(data[usernameKey])
+	if username == "" {
+		username = GitHubAppUsername
+	}
+	return UserAuth{
+		Username:                username,
+		GitHubAppID:             appID,
+		GitHubAppInstallationID: installationID,
+		GitHubAppPrivateKey:     string(data[gitHubAppPrivateKeyKey]),
+	}, nil
+}
+
 // NewKubeAuthConfigHandler creates a handler which loads/stores the auth config from/into Kubernetes secrets
 func NewKubeAuthConfigHandler(client kubernetes.Interface, namespace string, kind string, serviceKind string) KubeAuthConfigHandler {
 	return KubeAuthConfigHandler{
@@ -786,3 +786,32 @@ func TestSaveConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigWithGitHubApp(t *testing.T) {
+	t.Parallel()
+
+	s := secret("jx-pipeline-git-github-github", "git", "github", "", true, "GitHub", "https://github.com", "", "")
+	s.Data[gitHubAppIDKey] = []byte("1234")
+	s.Data[gitHubAppInstallationIDKey] = []byte("5678")
+	s.Data[gitHubAppPrivateKeyKey] = []byte("my-private-key")
+	client := k8sfake.NewSimpleClientset(s)
+	handler := NewKubeAuthConfigHandler(client, "", "git", "github")
+
+	config, err := handler.LoadConfig()
+	assert.NoError(t, err)
+	assert.Len(t, config.Servers, 1)
+	user := config.Servers[0].Users[0]
+	assert.Equal(t, GitHubAppUsername, user.Username)
+	assert.Equal(t, int64(1234), user.GitHubAppID)
+	assert.Equal(t, int64(5678), user.GitHubAppInstallationID)
+	assert.Equal(t, "my-private-key", user.GitHubAppPrivateKey)
+	assert.True(t, user.IsGitHubApp())
+	assert.False(t, user.IsInvalid())
+
+	err = handler.SaveConfig(config)
+	assert.NoError(t, err)
+	saved, err := client.CoreV1().Secrets("").Get("jx-pipeline-git-github-github", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "5678", string(saved.Data[gitHubAppInstallationIDKey]))
+	assert.Equal(t, "my-private-key", string(saved.Data[gitHubAppPrivateKeyKey]))
+}
@@ -25,6 +25,13 @@ type UserAuth struct {
 	// GithubAppOwner if using GitHub Apps this represents the owner organisation/user which owns this token.
 	// we need to maintain a different token per owner
 	GithubAppOwner string `json:"appOwner,omitempty"`
+
+	// GitHubAppID the ID of the GitHub App to authenticate as instead of using an API token
+	GitHubAppID int64 `json:"appId,omitempty"`
+	// GitHubAppInstallationID the ID of the installation of the GitHub App whose installation tokens are used
+	GitHubAppInstallationID int64 `json:"appInstallationId,omitempty"`
+	// GitHubAppPrivateKey the PEM encoded private key of the GitHub App signing the requests for installation tokens
+	GitHubAppPrivateKey string `json:"appPrivateKey,omitempty"`
 }
 
 type AuthConfig struct {
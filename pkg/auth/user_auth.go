@@ -2,6 +2,7 @@ package auth
 
 import (
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -10,6 +11,13 @@ const (
 	apiTokenSuffix    = "_API_TOKEN"
 	bearerTokenSuffix = "_BEARER_TOKEN"
 	DefaultUsername   = "dummy"
+
+	appIDSuffix             = "_APP_ID"
+	appInstallationIDSuffix = "_APP_INSTALLATION_ID"
+	appPrivateKeySuffix     = "_APP_PRIVATE_KEY"
+
+	// GitHubAppUsername the username git uses to authenticate with the installation tokens of GitHub Apps
+	GitHubAppUsername = "x-access-token"
 )
 
 // UsernameEnv builds the username environment variable name
@@ -30,6 +38,24 @@ func BearerTokenEnv(prefix string) string {
 	return prefix + bearerTokenSuffix
 }
 
+// GitHubAppIDEnv builds the GitHub App ID environment variable name
+func GitHubAppIDEnv(prefix string) string {
+	prefix = strings.ToUpper(prefix)
+	return prefix + appIDSuffix
+}
+
+// GitHubAppInstallationIDEnv builds the GitHub App installation ID environment variable name
+func GitHubAppInstallationIDEnv(prefix string) string {
+	prefix = strings.ToUpper(prefix)
+	return prefix + appInstallationIDSuffix
+}
+
+// GitHubAppPrivateKeyEnv builds the GitHub App private key environment variable name
+func GitHubAppPrivateKeyEnv(prefix string) string {
+	prefix = strings.ToUpper(prefix)
+	return prefix + appPrivateKeySuffix
+}
+
 // CreateAuthUserFromEnvironment creates a user auth from environment variables
 func CreateAuthUserFromEnvironment(prefix string) UserAuth {
 	user := UserAuth{}
@@ -45,6 +71,15 @@ func CreateAuthUserFromEnvironment(prefix string) UserAuth {
 	if set {
 		user.BearerToken = bearerToken
 	}
+	privateKey, set := os.LookupEnv(GitHubAppPrivateKeyEnv(prefix))
+	if set {
+		user.GitHubAppPrivateKey = privateKey
+		user.GitHubAppID, _ = strconv.ParseInt(os.Getenv(GitHubAppIDEnv(prefix)), 10, 64)
+		user.GitHubAppInstallationID, _ = strconv.ParseInt(os.Getenv(GitHubAppInstallationIDEnv(prefix)), 10, 64)
+		if user.Username == "" {
+			user.Username = GitHubAppUsername
+		}
+	}
 
 	if user.ApiToken != "" || user.Password != "" {
 		if user.Username == "" {
@@ -57,7 +92,7 @@ func CreateAuthUserFromEnvironment(prefix string) UserAuth {
 
 // IsInvalid returns true if the user auth has a valid token
 func (a *UserAuth) IsInvalid() bool {
-	return a.BearerToken == "" && (a.ApiToken == "" || a.Username == "")
+	return a.BearerToken == "" && !a.IsGitHubApp() && (a.ApiToken == "" || a.Username == "")
 }
 
 // Valid returns true when the user authentication is valid, otherwise false
@@ -65,8 +100,13 @@ func (a *UserAuth) IsValid() bool {
 	if a.Username == "" {
 		return false
 	}
-	if a.ApiToken == "" {
+	if a.ApiToken == "" && !a.IsGitHubApp() {
 		return false
 	}
 	return true
 }
+
+// IsGitHubApp returns true if the user authenticates as a GitHub App using its installation tokens
+func (a *UserAuth) IsGitHubApp() bool {
+	return a.GitHubAppID != 0 && a.GitHubAppInstallationID != 0 && a.GitHubAppPrivateKey != ""
+}
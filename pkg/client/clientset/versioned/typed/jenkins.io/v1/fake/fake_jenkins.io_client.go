@@ -80,6 +80,10 @@ func (c *FakeJenkinsV1) Users(namespace string) v1.UserInterface {
 	return &FakeUsers{c, namespace}
 }
 
+func (c *FakeJenkinsV1) WebhookEvents(namespace string) v1.WebhookEventInterface {
+	return &FakeWebhookEvents{c, namespace}
+}
+
 func (c *FakeJenkinsV1) Workflows(namespace string) v1.WorkflowInterface {
 	return &FakeWorkflows{c, namespace}
 }
@@ -5,3 +5,5 @@ package v1
 type SchedulerExpansion interface{}
 
 type SourceRepositoryGroupExpansion interface{}
+
+type WebhookEventExpansion interface{}
@@ -28,6 +28,7 @@ type JenkinsV1Interface interface {
 	SourceRepositoryGroupsGetter
 	TeamsGetter
 	UsersGetter
+	WebhookEventsGetter
 	WorkflowsGetter
 }
 
@@ -104,6 +105,10 @@ func (c *JenkinsV1Client) Users(namespace string) UserInterface {
 	return newUsers(c, namespace)
 }
 
+func (c *JenkinsV1Client) WebhookEvents(namespace string) WebhookEventInterface {
+	return newWebhookEvents(c, namespace)
+}
+
 func (c *JenkinsV1Client) Workflows(namespace string) WorkflowInterface {
 	return newWorkflows(c, namespace)
 }
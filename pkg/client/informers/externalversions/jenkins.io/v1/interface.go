@@ -42,6 +42,8 @@ type Interface interface {
 	Teams() TeamInformer
 	// Users returns a UserInformer.
 	Users() UserInformer
+	// WebhookEvents returns a WebhookEventInformer.
+	WebhookEvents() WebhookEventInformer
 	// Workflows returns a WorkflowInformer.
 	Workflows() WorkflowInformer
 }
@@ -142,6 +144,11 @@ func (v *version) Users() UserInformer {
 	return &userInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// WebhookEvents returns a WebhookEventInformer.
+func (v *version) WebhookEvents() WebhookEventInformer {
+	return &webhookEventInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Workflows returns a WorkflowInformer.
 func (v *version) Workflows() WorkflowInformer {
 	return &workflowInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -138,6 +138,14 @@ type UserListerExpansion interface{}
 // UserNamespaceLister.
 type UserNamespaceListerExpansion interface{}
 
+// WebhookEventListerExpansion allows custom methods to be added to
+// WebhookEventLister.
+type WebhookEventListerExpansion interface{}
+
+// WebhookEventNamespaceListerExpansion allows custom methods to be added to
+// WebhookEventNamespaceLister.
+type WebhookEventNamespaceListerExpansion interface{}
+
 // WorkflowListerExpansion allows custom methods to be added to
 // WorkflowLister.
 type WorkflowListerExpansion interface{}
@@ -77,6 +77,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.GitServiceSpec":                      schema_pkg_apis_jenkinsio_v1_GitServiceSpec(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.GitStatus":                           schema_pkg_apis_jenkinsio_v1_GitStatus(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.GlobalProtectionPolicy":              schema_pkg_apis_jenkinsio_v1_GlobalProtectionPolicy(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ImageScan":                           schema_pkg_apis_jenkinsio_v1_ImageScan(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.IssueLabel":                          schema_pkg_apis_jenkinsio_v1_IssueLabel(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.IssueSummary":                        schema_pkg_apis_jenkinsio_v1_IssueSummary(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.JobBase":                             schema_pkg_apis_jenkinsio_v1_JobBase(ref),
@@ -126,6 +127,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ResourceReference":                   schema_pkg_apis_jenkinsio_v1_ResourceReference(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.Restrictions":                        schema_pkg_apis_jenkinsio_v1_Restrictions(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ReviewPolicy":                        schema_pkg_apis_jenkinsio_v1_ReviewPolicy(ref),
+		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.SBOM":                                schema_pkg_apis_jenkinsio_v1_SBOM(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.Scheduler":                           schema_pkg_apis_jenkinsio_v1_Scheduler(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.SchedulerAgent":                      schema_pkg_apis_jenkinsio_v1_SchedulerAgent(ref),
 		"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.SchedulerList":                       schema_pkg_apis_jenkinsio_v1_SchedulerList(ref),
@@ -2855,6 +2857,89 @@ func schema_pkg_apis_jenkinsio_v1_GlobalProtectionPolicy(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_ImageScan(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ImageScan contains the summary of the vulnerability scan of an image built by the pipeline",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"scanner": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"severity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Severity the lowest severity of the vulnerabilities which fail the scan",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"passed": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"critical": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"high": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"medium": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"low": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"unknown": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"blocking": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Blocking the IDs of the vulnerabilities which failed the scan",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_IssueLabel(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3587,11 +3672,35 @@ func schema_pkg_apis_jenkinsio_v1_PipelineActivitySpec(ref common.ReferenceCallb
 							Format: "",
 						},
 					},
+					"sboms": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.SBOM"),
+									},
+								},
+							},
+						},
+					},
+					"imageScans": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ImageScan"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.Attachment", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.BatchPipelineActivity", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ExtensionExecution", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PipelineActivityStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.Attachment", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.BatchPipelineActivity", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ExtensionExecution", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.ImageScan", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.PipelineActivityStep", "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1.SBOM", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -5314,6 +5423,52 @@ func schema_pkg_apis_jenkinsio_v1_ReviewPolicy(ref common.ReferenceCallback) com
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_SBOM(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SBOM contains the summary of the software bill of materials of an image built by the pipeline",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"format": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"packages": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"releaseAssetURL": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReleaseAssetURL the URL of the SBOM attached to the git release",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"referrer": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Referrer the digest of the OCI artifact of the SBOM referring to the image in the registry",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Scheduler(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
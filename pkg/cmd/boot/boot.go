@@ -166,7 +166,11 @@ func (o *BootOptions) Run() error {
 	// lets report errors parsing this file after the check we are outside of a git clone
 	o.defaultVersionStream(requirements)
 
-	resolver, err := o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+	versionStreamURL, err := o.ResolveVersionStreamURL(requirements.VersionStream)
+	if err != nil {
+		return err
+	}
+	resolver, err := o.CreateVersionResolver(versionStreamURL, requirements.VersionStream.Ref)
 	if err != nil {
 		return errors.Wrapf(err, "there was a problem creating a version resolver from versions stream repository %s and ref %s", requirements.VersionStream.URL, requirements.VersionStream.Ref)
 	}
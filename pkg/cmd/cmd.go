@@ -51,6 +51,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/uninstall"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/update"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/upgrade"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/webhook"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/add"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/namespace"
@@ -201,6 +202,7 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 			Commands: []*cobra.Command{
 				controller.NewCmdController(commonOpts),
 				gc.NewCmdGC(commonOpts),
+				webhook.NewCmdWebhook(commonOpts),
 			},
 		},
 		{
@@ -47,6 +47,7 @@ func NewCmdController(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdControllerBuildNumbers(commonOpts))
 	cmd.AddCommand(NewCmdControllerEnvironment(commonOpts))
 	cmd.AddCommand(pipeline.NewCmdControllerPipelineRunner(commonOpts))
+	cmd.AddCommand(NewCmdControllerPreviewGC(commonOpts))
 	cmd.AddCommand(NewCmdControllerRole(commonOpts))
 	cmd.AddCommand(NewCmdControllerTeam(commonOpts))
 	cmd.AddCommand(NewCmdControllerCommitStatus(commonOpts))
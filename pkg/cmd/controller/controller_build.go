@@ -59,6 +59,7 @@ type ControllerBuildOptions struct {
 	TargetURLTemplate   string
 	FailIfNoGitProvider bool
 	JobURLBase          string
+	GitReportingBatch   time.Duration
 
 	EnvironmentCache *kube.EnvironmentNamespaceCache
 
@@ -69,6 +70,9 @@ type ControllerBuildOptions struct {
 
 	// private field to record whether the lighthouse-foghorn deployment is present - if so, we skip status reporting
 	foghornPresent bool
+
+	// private field batching the git statuses if GitReportingBatch is enabled
+	statusBatcher *gits.CommitStatusBatcher
 }
 
 // LongTermStorageLogWriter is an implementation of logs.LogWriter that saves the obtained log lines
@@ -142,6 +146,7 @@ func NewCmdControllerBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.TargetURLTemplate, "target-url-template", "", "", "The Go template for generating the target URL of pipeline logs/views if git reporting is enabled. If unspecified, a default will be used based on `--job-url-base`.")
 	cmd.Flags().BoolVarP(&options.GitReporting, "git-reporting", "", false, "If enabled then lets report pipeline success/failures to the git provider. Note this is purely tactical until we can do this natively inside tekton")
 	cmd.Flags().StringVarP(&options.JobURLBase, "job-url-base", "", "", "The base URL, such as 'https://dashboard.jenkins-x.live', for generating the target URL for pipeline logs if git reporting is enabled.")
+	cmd.Flags().DurationVarP(&options.GitReportingBatch, "git-reporting-batch", "", 5*time.Second, "How long the git statuses are batched for so that only the latest status of each commit is reported, saving git provider API quota. Use 0 to report every status immediately")
 	return cmd
 }
 
@@ -229,6 +234,10 @@ func (o *ControllerBuildOptions) Run() error {
 	)
 
 	stop := make(chan struct{})
+	if o.GitReporting && o.GitReportingBatch > 0 {
+		o.statusBatcher = gits.NewCommitStatusBatcher()
+		go o.statusBatcher.Run(o.GitReportingBatch, stop)
+	}
 	go controller.Run(stop)
 
 	// Wait forever
@@ -615,7 +624,7 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 					log.Logger().Warnf("No GitURL on PipelineActivity %s", activity.Name)
 				}
 			}
-			masker, err := kube.NewLogMasker(kubeClient, ns)
+			masker, err := o.NewLogMasker(kubeClient, ns)
 			if err != nil {
 				log.Logger().Warnf("Failed to create LogMasker in namespace %s: %s", ns, err.Error())
 			}
@@ -748,7 +757,7 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 					}
 				}
 
-				masker, err := kube.NewLogMasker(kubeClient, ns)
+				masker, err := o.NewLogMasker(kubeClient, ns)
 				if err != nil {
 					log.Logger().Warnf("Failed to create LogMasker in namespace %s: %s", ns, err.Error())
 				}
@@ -1326,6 +1335,11 @@ func (o *ControllerBuildOptions) reportStatus(kubeClient kubernetes.Interface, n
 		return
 	}
 
+	if o.statusBatcher != nil {
+		o.statusBatcher.Add(gitProvider, owner, repo, sha, gitRepoStatus)
+		log.Logger().WithFields(fields).Debug("batched git status")
+		return
+	}
 	_, err = gitProvider.UpdateCommitStatus(owner, repo, sha, gitRepoStatus)
 	if err != nil {
 		log.Logger().WithFields(fields).WithError(err).Warnf("failed to report git status")
@@ -1394,9 +1408,12 @@ func toScmStatusDescriptionRunningStages(activity *v1.PipelineActivity) reportSt
 	if len(stagesByStatus[v1.ActivityStatusTypeRunning]) > 0 {
 		info.runningStages = strings.Join(stagesByStatus[v1.ActivityStatusTypeRunning], ",")
 		info.description = fmt.Sprintf("Pipeline running stage(s): %s", strings.Join(stagesByStatus[v1.ActivityStatusTypeRunning], ", "))
-		if len(info.description) > 63 {
-			info.description = info.description[:59] + "..."
-		}
+	} else if info.scmStatus == "failure" && len(stagesByStatus[v1.ActivityStatusTypeFailed]) > 0 {
+		// the single status of the pipeline combines its parallel stages such as those of a matrix so name the failed ones
+		info.description = fmt.Sprintf("Pipeline failed stage(s): %s", strings.Join(stagesByStatus[v1.ActivityStatusTypeFailed], ", "))
+	}
+	if len(info.description) > 63 {
+		info.description = info.description[:59] + "..."
 	}
 	return info
 }
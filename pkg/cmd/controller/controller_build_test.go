@@ -162,6 +162,34 @@ func TestCreateReportTargetURL(t *testing.T) {
 	assert.Equal(t, "https://myconsole.acme.com/teams/jx/projects/jstrachan/myapp/PR-5/3", actual, "created git report URL for params %#v", params)
 }
 
+func TestToScmStatusDescriptionRunningStages(t *testing.T) {
+	activity := &v1.PipelineActivity{
+		Spec: v1.PipelineActivitySpec{
+			Status: v1.ActivityStatusTypeFailed,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind:  v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{CoreActivityStep: v1.CoreActivityStep{Name: "build 1.12", Status: v1.ActivityStatusTypeSucceeded}},
+				},
+				{
+					Kind:  v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{CoreActivityStep: v1.CoreActivityStep{Name: "build 1.13", Status: v1.ActivityStatusTypeFailed}},
+				},
+			},
+		},
+	}
+	info := toScmStatusDescriptionRunningStages(activity)
+	assert.Equal(t, "failure", info.scmStatus)
+	assert.Equal(t, "Pipeline failed stage(s): build 1.13", info.description)
+
+	activity.Spec.Status = v1.ActivityStatusTypeRunning
+	activity.Spec.Steps[1].Stage.Status = v1.ActivityStatusTypeRunning
+	info = toScmStatusDescriptionRunningStages(activity)
+	assert.Equal(t, "pending", info.scmStatus)
+	assert.Equal(t, "Pipeline running stage(s): build 1.13", info.description)
+	assert.Equal(t, "build 1.13", info.runningStages)
+}
+
 func TestUpdateForStagePreTekton051(t *testing.T) {
 	pod := tekton_helpers_test.AssertLoadSinglePod(t, path.Join("test_data", "controller_build", "update_stage_info_pre_tekton_0.5.1"))
 	si := &tekton.StageInfo{
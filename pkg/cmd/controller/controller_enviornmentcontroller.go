@@ -16,18 +16,21 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/git/credentials"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/controller/pipeline"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/create"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/kube/services"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/webhooks"
 	"github.com/sirupsen/logrus"
 	"k8s.io/test-infra/prow/github"
 
@@ -69,9 +72,19 @@ type ControllerEnvironmentOptions struct {
 	Branch                string
 	PushRef               string
 	Labels                map[string]string
+	DriftInterval         time.Duration
+	DriftRemediate        bool
+	DriftChartDir         string
+	DriftIgnorePaths      []string
+	EnvironmentName       string
+	PromotePolicyInterval time.Duration
 
 	StepCreateTaskOptions create.StepCreateTaskOptions
 	secret                []byte
+	webhookEvents         *webhooks.Ingester
+	lastDriftRemediation  string
+	promotePolicyLock     sync.Mutex
+	promotePolicyStatuses map[string]gits.GitRepoStatus
 }
 
 var (
@@ -118,6 +131,12 @@ func NewCmdControllerEnvironment(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd.Flags().StringVarP(&options.GitRepo, "repo", "", "", "The git repository name. If not specified defaults to $REPO")
 	cmd.Flags().StringVarP(&options.WebHookURL, "webhook-url", "w", "", "The external WebHook URL of this controller to register with the git provider. If not specified defaults to $WEBHOOK_URL")
 	cmd.Flags().StringVarP(&options.PushRef, "push-ref", "", "refs/heads/master", "The git ref passed from the WebHook which should trigger a new deploy pipeline to trigger. Defaults to only webhooks from the master branch")
+	cmd.Flags().DurationVarP(&options.DriftInterval, "drift-interval", "", 0, "The interval to re-render the environment git repository and compare it against the cluster to detect manual changes. Drift detection is disabled if zero")
+	cmd.Flags().BoolVarP(&options.DriftRemediate, "drift-remediate", "", false, "Triggers the pipeline of the environment to revert the resources which have drifted")
+	cmd.Flags().StringVarP(&options.DriftChartDir, "drift-chart-dir", "", "env", "The directory of the helm chart in the environment git repository which is rendered to detect drift")
+	cmd.Flags().StringArrayVarP(&options.DriftIgnorePaths, "drift-ignore", "", nil, "The paths of the resource fields which are ignored when detecting drift, e.g. 'spec.replicas' for deployments scaled by a HorizontalPodAutoscaler")
+	cmd.Flags().StringVarP(&options.EnvironmentName, "environment", "", "", "The name of the Environment to record the drift on. Defaults to the Environment whose source is the environment git repository")
+	cmd.Flags().DurationVarP(&options.PromotePolicyInterval, "promote-policy-interval", "", 5*time.Minute, "The interval to re-evaluate the '"+environments.PromotePolicyFileName+"' promotion policy of the open Pull Requests so that they can be merged once a promotion window opens. The policy is also evaluated on each Pull Request webhook. Periodic evaluation is disabled if zero")
 
 	so := &options.StepCreateTaskOptions
 	so.CommonOptions = commonOpts
@@ -233,6 +252,22 @@ func (o *ControllerEnvironmentOptions) Run() error {
 		}
 	}
 
+	o.webhookEvents, err = o.createWebhookEventIngester()
+	if err != nil {
+		log.Logger().Warnf("webhook events will not be persisted so redeliveries cannot be detected and events cannot be replayed: %s", err)
+	} else {
+		stop := make(chan struct{})
+		defer close(stop)
+		go o.webhookEvents.WatchReplays(stop)
+	}
+
+	if o.DriftInterval > 0 {
+		go o.watchDrift()
+	}
+	if o.PromotePolicyInterval > 0 {
+		go o.watchPromotePolicy()
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle(healthPath, http.HandlerFunc(o.health))
 	mux.Handle(readyPath, http.HandlerFunc(o.ready))
@@ -271,8 +306,9 @@ func (o *ControllerEnvironmentOptions) getIndex(w http.ResponseWriter, r *http.R
 	w.Write([]byte(helloMessage)) //nolint:errcheck
 }
 
-// handle request for pipeline runs
-func (o *ControllerEnvironmentOptions) startPipelineRun(w http.ResponseWriter, r *http.Request) {
+// startPipelineRun triggers the pipeline of the environment for the given git commit or for the head of the branch if
+// the revision is blank
+func (o *ControllerEnvironmentOptions) startPipelineRun(revision string) error {
 	err := o.stepGitCredentials()
 	if err != nil {
 		log.Logger().Warn(err.Error())
@@ -280,7 +316,9 @@ func (o *ControllerEnvironmentOptions) startPipelineRun(w http.ResponseWriter, r
 
 	sourceURL := o.SourceURL
 	branch := o.Branch
-	revision := "master"
+	if revision == "" {
+		revision = "master"
+	}
 	scCopy := o.StepCreateTaskOptions
 	pr := &scCopy
 	coCopy := *o.CommonOptions
@@ -309,15 +347,75 @@ func (o *ControllerEnvironmentOptions) startPipelineRun(w http.ResponseWriter, r
 	err = pr.Run()
 	pipelineLock.Unlock()
 	if err != nil {
-		o.returnError(err, err.Error(), w, r)
-		return
+		return errors.Wrapf(err, "triggering pipeline for repo %s branch %s revision %s", sourceURL, branch, revision)
 	}
 	results := &pipeline.PipelineRunResponse{
 		Resources: pr.Results.ObjectReferences(),
 	}
-	err = o.marshalPayload(w, r, results)
+	data, err := json.Marshal(results)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling the JSON payload %#v", results)
+	}
+	log.Logger().Infof("triggered pipeline and created: %s", string(data))
+	return nil
+}
+
+// createWebhookEventIngester creates the ingester which persists the webhook events of this controller
+func (o *ControllerEnvironmentOptions) createWebhookEventIngester() (*webhooks.Ingester, error) {
+	apisClient, err := o.ApiExtensionsClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the API extensions client")
+	}
+	err = kube.RegisterWebhookEventCRD(apisClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to register the Webhook Event CRD")
+	}
+	jxClient, ns, err := o.JXClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the jx client")
+	}
+	return &webhooks.Ingester{
+		JXClient:  jxClient,
+		Namespace: ns,
+		Source:    environmentControllerService,
+		Handler: func(event *v1.WebhookEvent) error {
+			return o.startPipelineRun(event.Spec.Revision)
+		},
+	}, nil
+}
+
+// processWebhookEvent persists a webhook event of the given git commit and then triggers its pipeline unless it is a
+// redelivery
+func (o *ControllerEnvironmentOptions) processWebhookEvent(w http.ResponseWriter, eventType string, eventGUID string, revision string, data []byte) {
+	if o.webhookEvents == nil {
+		w.Write([]byte("OK")) //nolint:errcheck
+		go func() {
+			o.logPipelineError(o.startPipelineRun(revision))
+		}()
+		return
+	}
+	event, process, err := o.webhookEvents.Ingest(eventType, eventGUID, revision, data)
+	if err != nil {
+		log.Logger().Warnf("failed to persist webhook event %s so triggering its pipeline anyway: %s", eventGUID, err)
+		w.Write([]byte("OK")) //nolint:errcheck
+		go func() {
+			o.logPipelineError(o.startPipelineRun(revision))
+		}()
+		return
+	}
+	if !process {
+		w.Write([]byte(helloMessage + "ignoring redelivery of webhook event: " + event.Name + " which is " + string(event.Status.Phase))) //nolint:errcheck
+		return
+	}
+	w.Write([]byte("OK")) //nolint:errcheck
+	go func() {
+		o.logPipelineError(o.webhookEvents.Process(event, false))
+	}()
+}
+
+func (o *ControllerEnvironmentOptions) logPipelineError(err error) {
 	if err != nil {
-		o.returnError(err, "failed to marshal payload", w, r)
+		log.Logger().Errorf("failed to trigger pipeline: %s", err)
 	}
 }
 
@@ -431,26 +529,6 @@ func (o *ControllerEnvironmentOptions) isReady() bool {
 	return true
 }
 
-func (o *ControllerEnvironmentOptions) marshalPayload(w http.ResponseWriter, r *http.Request, payload interface{}) error {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return errors.Wrapf(err, "marshalling the JSON payload %#v", payload)
-	}
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(data)
-	if err != nil {
-		return errors.Wrapf(err, "writing data %s", data)
-	}
-
-	log.Logger().Infof("completed request successfully and returned: %s", string(data))
-	return nil
-}
-
-func (o *ControllerEnvironmentOptions) returnError(err error, message string, w http.ResponseWriter, r *http.Request) {
-	log.Logger().Errorf("returning error: %v %s", err, message)
-	responseHTTPError(w, http.StatusInternalServerError, "500 Internal Error: "+message+" "+err.Error())
-}
-
 func (o *ControllerEnvironmentOptions) stepGitCredentials() error {
 	if !o.NoGitCredeentialsInit {
 		copy := *o.CommonOptions
@@ -480,6 +558,13 @@ func (o *ControllerEnvironmentOptions) handleWebHookRequests(w http.ResponseWrit
 	if !valid {
 		return
 	}
+	if eventType == "pull_request" || eventType == "pull_request_review" {
+		w.Write([]byte("OK")) //nolint:errcheck
+		go func() {
+			o.logPromotePolicyError(o.checkPromotePolicy())
+		}()
+		return
+	}
 	if eventType != "push" {
 		w.Write([]byte(helloMessage + "ignoring webhook event type: " + eventType)) //nolint:errcheck
 		return
@@ -502,37 +587,16 @@ func (o *ControllerEnvironmentOptions) handleWebHookRequests(w http.ResponseWrit
 	}
 
 	log.Logger().Infof("starting pipeline from event type %s UID %s valid %s method %s", eventType, eventGUID, strconv.FormatBool(valid), r.Method)
-	w.Write([]byte("OK")) //nolint:errcheck
-
-	go o.startPipelineRun(w, r)
+	o.processWebhookEvent(w, eventType, eventGUID, event.After, data)
 }
 
 func (o *ControllerEnvironmentOptions) registerWebHook(webhookURL string, secret []byte) error {
 	gitURL := o.SourceURL
 	log.Logger().Infof("verifying that the webhook is registered for the git repository %s", util.ColorInfo(gitURL))
 
-	var provider gits.GitProvider
-	var err error
-
-	if o.GitKind != "" {
-		gitInfo, err := gits.ParseGitURL(gitURL)
-		if err != nil {
-			return err
-		}
-		gitHostURL := gitInfo.HostURL()
-		ghOwner, err := o.GetGitHubAppOwner(gitInfo)
-		if err != nil {
-			return err
-		}
-		provider, err = o.GitProviderForGitServerURL(gitHostURL, o.GitKind, ghOwner)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create git provider for git URL %s kind %s", gitHostURL, o.GitKind)
-		}
-	} else {
-		provider, err = o.GitProviderForURL(gitURL, "creating webhook git provider")
-		if err != nil {
-			return errors.Wrapf(err, "failed to create git provider for git URL %s", gitURL)
-		}
+	provider, err := o.createGitProvider()
+	if err != nil {
+		return err
 	}
 	isInsecureSSL, err := o.IsInsecureSSLWebhooks()
 	if err != nil {
@@ -554,6 +618,34 @@ func (o *ControllerEnvironmentOptions) registerWebHook(webhookURL string, secret
 	return nil
 }
 
+// createGitProvider creates the git provider of the environment git repository
+func (o *ControllerEnvironmentOptions) createGitProvider() (gits.GitProvider, error) {
+	gitURL := o.SourceURL
+	var provider gits.GitProvider
+	var err error
+	if o.GitKind != "" {
+		gitInfo, err := gits.ParseGitURL(gitURL)
+		if err != nil {
+			return nil, err
+		}
+		gitHostURL := gitInfo.HostURL()
+		ghOwner, err := o.GetGitHubAppOwner(gitInfo)
+		if err != nil {
+			return nil, err
+		}
+		provider, err = o.GitProviderForGitServerURL(gitHostURL, o.GitKind, ghOwner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create git provider for git URL %s kind %s", gitHostURL, o.GitKind)
+		}
+	} else {
+		provider, err = o.GitProviderForURL(gitURL, "creating webhook git provider")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create git provider for git URL %s", gitURL)
+		}
+	}
+	return provider, nil
+}
+
 // ValidateWebhook ensures that the provided request conforms to the
 // format of a Github webhook and the payload can be validated with
 // the provided hmac secret. It returns the event type, the event guid,
@@ -0,0 +1,362 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_cmd "github.com/jenkins-x/jx/v2/pkg/cmd/step/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	driftDetectedReason  = "DriftDetected"
+	driftResolvedReason  = "InSync"
+	driftRemediateReason = "DriftRemediation"
+
+	// maxDriftMessageResources the maximum number of drifted resources listed in the condition message
+	maxDriftMessageResources = 10
+
+	// driftRemediationAnnotation the annotation of the Environment recording the drift the pipeline was last triggered
+	// to revert so that it is not triggered again for the same drift when the controller restarts
+	driftRemediationAnnotation = "jenkins.io/drift-remediation"
+)
+
+// watchDrift periodically checks whether the resources in the cluster have drifted from the environment git repository
+func (o *ControllerEnvironmentOptions) watchDrift() {
+	log.Logger().Infof("checking the drift of the environment every %s", o.DriftInterval.String())
+	for {
+		err := o.checkDrift()
+		if err != nil {
+			log.Logger().Warnf("failed to check the drift of the environment %s: %s", o.SourceURL, err)
+		}
+		time.Sleep(o.DriftInterval)
+	}
+}
+
+// checkDrift renders the environment git repository at the head of its branch, compares the resources against the
+// cluster and records the result on the Environment
+func (o *ControllerEnvironmentOptions) checkDrift() error {
+	err := o.stepGitCredentials()
+	if err != nil {
+		log.Logger().Warn(err.Error())
+	}
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the kube client")
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the jx client")
+	}
+
+	dir, err := ioutil.TempDir("", "jx-environment-drift-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	cloneDir := filepath.Join(dir, "source")
+	err = o.Git().ShallowClone(cloneDir, o.SourceURL, o.Branch, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone %s branch %s", o.SourceURL, o.Branch)
+	}
+	revision, err := o.Git().GetLatestCommitSha(cloneDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the latest commit of %s", o.SourceURL)
+	}
+
+	renderDir := filepath.Join(dir, "output")
+	coCopy := *o.CommonOptions
+	coCopy.BatchMode = true
+	templateOptions := &helm_cmd.StepHelmTemplateOptions{
+		StepHelmOptions: helm_cmd.StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &coCopy,
+			},
+			Dir: filepath.Join(cloneDir, o.DriftChartDir),
+		},
+		Namespace: ns,
+		OutputDir: renderDir,
+	}
+	err = templateOptions.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to render the environment at revision %s", revision)
+	}
+	diffs, err := templateOptions.DetectDrift(renderDir, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compare the environment at revision %s against the cluster", revision)
+	}
+	diffs = ignoreDriftPaths(diffs, o.DriftIgnorePaths)
+	if len(diffs) > 0 {
+		log.Logger().Warnf("%s", driftMessage(diffs, revision))
+	} else {
+		log.Logger().Debugf("the environment is in sync with revision %s", revision)
+	}
+
+	env, err := o.findEnvironment(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	if env != nil {
+		err = o.recordDrift(jxClient, kubeClient, env, diffs, revision)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !o.DriftRemediate {
+		return nil
+	}
+	last := o.lastDriftRemediation
+	if env != nil && env.Annotations[driftRemediationAnnotation] != "" {
+		last = env.Annotations[driftRemediationAnnotation]
+	}
+	remediation := ""
+	if len(diffs) > 0 {
+		remediation = driftRemediationKey(driftMessage(diffs, revision))
+	}
+	if remediation == last {
+		if remediation != "" {
+			log.Logger().Warnf("not triggering the pipeline again as the same resources have drifted since it was last triggered to revert them")
+		}
+		return nil
+	}
+	o.lastDriftRemediation = remediation
+	if env != nil {
+		err = recordDriftRemediation(jxClient, env, remediation)
+		if err != nil {
+			return err
+		}
+	}
+	if remediation == "" {
+		return nil
+	}
+	if env != nil {
+		err = createEnvironmentEvent(kubeClient, env, corev1.EventTypeNormal, driftRemediateReason,
+			fmt.Sprintf("triggering the pipeline to revert %d drifted resources to revision %s", len(diffs), revision))
+		if err != nil {
+			log.Logger().Warnf("failed to create the remediation event of environment %s: %s", env.Name, err)
+		}
+	}
+	// lets make sure the releases are applied even though the environment repository has not changed
+	err = helm_cmd.ClearAppliedHashes(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	return o.startPipelineRun("")
+}
+
+// driftRemediationKey returns the digest of the drift the pipeline is triggered to revert
+func driftRemediationKey(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordDriftRemediation records the drift the pipeline was last triggered to revert on the Environment. A blank key
+// clears the record once the drift is resolved so that the pipeline is triggered again if the same drift comes back
+func recordDriftRemediation(jxClient versioned.Interface, env *v1.Environment, key string) error {
+	latest, err := jxClient.JenkinsV1().Environments(env.Namespace).Get(env.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get Environment %s", env.Name)
+	}
+	if latest.Annotations[driftRemediationAnnotation] == key {
+		return nil
+	}
+	if key == "" {
+		delete(latest.Annotations, driftRemediationAnnotation)
+	} else {
+		if latest.Annotations == nil {
+			latest.Annotations = map[string]string{}
+		}
+		latest.Annotations[driftRemediationAnnotation] = key
+	}
+	_, err = jxClient.JenkinsV1().Environments(env.Namespace).Update(latest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the drift remediation on Environment %s", env.Name)
+	}
+	return nil
+}
+
+// findEnvironment returns the Environment of the source repository or nil if there is none in the namespace
+func (o *ControllerEnvironmentOptions) findEnvironment(jxClient versioned.Interface, ns string) (*v1.Environment, error) {
+	if o.EnvironmentName != "" {
+		env, err := jxClient.JenkinsV1().Environments(ns).Get(o.EnvironmentName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get Environment %s in namespace %s", o.EnvironmentName, ns)
+		}
+		return env, nil
+	}
+	envs, err := jxClient.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Environments in namespace %s", ns)
+	}
+	for i := range envs.Items {
+		env := &envs.Items[i]
+		if sameGitURL(env.Spec.Source.URL, o.SourceURL) {
+			return env, nil
+		}
+	}
+	log.Logger().Debugf("no Environment in namespace %s has the source %s so the drift is not recorded", ns, o.SourceURL)
+	return nil, nil
+}
+
+// recordDrift updates the drifted condition of the Environment and creates an event when it changes
+func (o *ControllerEnvironmentOptions) recordDrift(jxClient versioned.Interface, kubeClient kubernetes.Interface, env *v1.Environment, diffs []helm_cmd.ResourceDifference, revision string) error {
+	copy := env.DeepCopy()
+	if !updateDriftCondition(copy, diffs, revision, metav1.Now()) {
+		return nil
+	}
+	_, err := jxClient.JenkinsV1().Environments(env.Namespace).Update(copy)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the drift condition of Environment %s", env.Name)
+	}
+	condition := findEnvironmentCondition(copy, v1.EnvironmentDrifted)
+	eventType := corev1.EventTypeNormal
+	if condition.Status == corev1.ConditionTrue {
+		eventType = corev1.EventTypeWarning
+	}
+	return createEnvironmentEvent(kubeClient, env, eventType, condition.Reason, condition.Message)
+}
+
+// updateDriftCondition sets the drifted condition of the Environment returning true if it changed
+func updateDriftCondition(env *v1.Environment, diffs []helm_cmd.ResourceDifference, revision string, now metav1.Time) bool {
+	desired := v1.EnvironmentCondition{
+		Type:    v1.EnvironmentDrifted,
+		Status:  corev1.ConditionFalse,
+		Reason:  driftResolvedReason,
+		Message: "the resources in the cluster match the environment git repository",
+	}
+	if len(diffs) > 0 {
+		desired.Status = corev1.ConditionTrue
+		desired.Reason = driftDetectedReason
+		desired.Message = driftMessage(diffs, revision)
+	}
+
+	current := findEnvironmentCondition(env, v1.EnvironmentDrifted)
+	if current == nil {
+		desired.LastTransitionTime = &now
+		env.Status.Conditions = append(env.Status.Conditions, desired)
+		return true
+	}
+	if current.Status == desired.Status && current.Reason == desired.Reason && current.Message == desired.Message {
+		return false
+	}
+	if current.Status != desired.Status || current.LastTransitionTime == nil {
+		current.LastTransitionTime = &now
+	}
+	current.Status = desired.Status
+	current.Reason = desired.Reason
+	current.Message = desired.Message
+	return true
+}
+
+func findEnvironmentCondition(env *v1.Environment, conditionType v1.EnvironmentConditionType) *v1.EnvironmentCondition {
+	for i := range env.Status.Conditions {
+		if env.Status.Conditions[i].Type == conditionType {
+			return &env.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// driftMessage describes the drifted resources
+func driftMessage(diffs []helm_cmd.ResourceDifference, revision string) string {
+	resources := []string{}
+	for i, diff := range diffs {
+		if i == maxDriftMessageResources {
+			resources = append(resources, fmt.Sprintf("and %d more", len(diffs)-i))
+			break
+		}
+		description := diff.Kind + "/" + diff.Name
+		if diff.Change == helm_cmd.ResourceAdded {
+			description += " (deleted)"
+		} else {
+			paths := []string{}
+			for _, d := range diff.Differences {
+				paths = append(paths, d.Path)
+			}
+			description += " (" + strings.Join(paths, ", ") + ")"
+		}
+		resources = append(resources, description)
+	}
+	return fmt.Sprintf("%d resources have drifted from revision %s: %s", len(diffs), revision, strings.Join(resources, ", "))
+}
+
+// ignoreDriftPaths removes the differences of the given paths, e.g. the replicas of deployments which are scaled by
+// a HorizontalPodAutoscaler
+func ignoreDriftPaths(diffs []helm_cmd.ResourceDifference, ignorePaths []string) []helm_cmd.ResourceDifference {
+	if len(ignorePaths) == 0 {
+		return diffs
+	}
+	answer := []helm_cmd.ResourceDifference{}
+	for _, diff := range diffs {
+		if diff.Change != helm_cmd.ResourceChanged {
+			answer = append(answer, diff)
+			continue
+		}
+		differences := []helm_cmd.ValuesDifference{}
+		for _, d := range diff.Differences {
+			if util.StringArrayIndex(ignorePaths, d.Path) < 0 {
+				differences = append(differences, d)
+			}
+		}
+		if len(differences) > 0 {
+			diff.Differences = differences
+			answer = append(answer, diff)
+		}
+	}
+	return answer
+}
+
+// createEnvironmentEvent creates a Kubernetes event for the Environment
+func createEnvironmentEvent(kubeClient kubernetes.Interface, env *v1.Environment, eventType string, reason string, message string) error {
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: env.Name + "-",
+			Namespace:    env.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      v1.SchemeGroupVersion.String(),
+			Kind:            "Environment",
+			Name:            env.Name,
+			Namespace:       env.Namespace,
+			UID:             env.UID,
+			ResourceVersion: env.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: environmentControllerService},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := kubeClient.CoreV1().Events(env.Namespace).Create(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the %s event of Environment %s", reason, env.Name)
+	}
+	return nil
+}
+
+// sameGitURL returns true if the git URLs refer to the same repository
+func sameGitURL(url1 string, url2 string) bool {
+	normalize := func(u string) string {
+		return strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git"))
+	}
+	return url1 != "" && normalize(url1) == normalize(url2)
+}
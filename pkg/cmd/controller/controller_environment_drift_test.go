@@ -0,0 +1,74 @@
+// +build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	helm_cmd "github.com/jenkins-x/jx/v2/pkg/cmd/step/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateDriftCondition(t *testing.T) {
+	diffs := []helm_cmd.ResourceDifference{
+		{
+			Kind:        "Deployment",
+			Namespace:   "jx-production",
+			Name:        "myapp",
+			Change:      helm_cmd.ResourceChanged,
+			Differences: []helm_cmd.ValuesDifference{{Path: "spec.replicas", Left: 5, Right: 2}, {Path: "spec.template.spec.containers[0].image", Left: "myapp:0.9.0", Right: "myapp:1.0.0"}},
+		},
+		{
+			Kind:      "Service",
+			Namespace: "jx-production",
+			Name:      "myapp",
+			Change:    helm_cmd.ResourceAdded,
+		},
+	}
+	env := &v1.Environment{}
+	now := metav1.NewTime(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	require.True(t, updateDriftCondition(env, diffs, "abc123", now))
+	require.Len(t, env.Status.Conditions, 1)
+	condition := env.Status.Conditions[0]
+	assert.Equal(t, v1.EnvironmentDrifted, condition.Type)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "2 resources have drifted from revision abc123: Deployment/myapp (spec.replicas, spec.template.spec.containers[0].image), Service/myapp (deleted)", condition.Message)
+
+	assert.False(t, updateDriftCondition(env, diffs, "abc123", metav1.NewTime(now.Add(time.Hour))), "an unchanged drift should not update the Environment")
+
+	ignored := ignoreDriftPaths(diffs, []string{"spec.replicas"})
+	require.Len(t, ignored, 2)
+	assert.Equal(t, []helm_cmd.ValuesDifference{{Path: "spec.template.spec.containers[0].image", Left: "myapp:0.9.0", Right: "myapp:1.0.0"}}, ignored[0].Differences)
+	assert.Len(t, ignoreDriftPaths(diffs[0:1], []string{"spec.replicas", "spec.template.spec.containers[0].image"}), 0)
+
+	later := metav1.NewTime(now.Add(2 * time.Hour))
+	require.True(t, updateDriftCondition(env, nil, "def456", later))
+	require.Len(t, env.Status.Conditions, 1)
+	condition = env.Status.Conditions[0]
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, driftResolvedReason, condition.Reason)
+	assert.Equal(t, later, *condition.LastTransitionTime)
+}
+
+func TestRecordDriftRemediation(t *testing.T) {
+	env := &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: "jx"}}
+	jxClient := fake.NewSimpleClientset(env)
+
+	key := driftRemediationKey("1 resources have drifted from revision abc123: Service/myapp (deleted)")
+	require.NoError(t, recordDriftRemediation(jxClient, env, key))
+	latest, err := jxClient.JenkinsV1().Environments("jx").Get("production", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, key, latest.Annotations[driftRemediationAnnotation], "the remediation should survive a restart of the controller")
+
+	require.NoError(t, recordDriftRemediation(jxClient, env, ""))
+	latest, err = jxClient.JenkinsV1().Environments("jx").Get("production", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, latest.Annotations[driftRemediationAnnotation])
+}
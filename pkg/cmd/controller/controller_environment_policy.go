@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// watchPromotePolicy periodically re-evaluates the promotion policy of the open Pull Requests so that their status
+// changes when a promotion window opens or a freeze ends
+func (o *ControllerEnvironmentOptions) watchPromotePolicy() {
+	log.Logger().Infof("checking the promotion policy of the Pull Requests every %s", o.PromotePolicyInterval.String())
+	for {
+		o.logPromotePolicyError(o.checkPromotePolicy())
+		time.Sleep(o.PromotePolicyInterval)
+	}
+}
+
+func (o *ControllerEnvironmentOptions) logPromotePolicyError(err error) {
+	if err != nil {
+		log.Logger().Warnf("failed to check the promotion policy of the Pull Requests on %s: %s", o.SourceURL, err)
+	}
+}
+
+// checkPromotePolicy reports whether each open Pull Request on the environment git repository satisfies the promotion
+// policy of its branch via a commit status so that the Pull Requests are not merged until they do
+func (o *ControllerEnvironmentOptions) checkPromotePolicy() error {
+	o.promotePolicyLock.Lock()
+	defer o.promotePolicyLock.Unlock()
+
+	provider, err := o.createGitProvider()
+	if err != nil {
+		return err
+	}
+	prs, err := provider.ListOpenPullRequests(o.GitOwner, o.GitRepo)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the open Pull Requests of %s", o.SourceURL)
+	}
+	if len(prs) == 0 {
+		return nil
+	}
+	policy, err := o.loadPromotePolicy()
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+	if o.promotePolicyStatuses == nil {
+		o.promotePolicyStatuses = map[string]gits.GitRepoStatus{}
+	}
+	return reportPromotePolicyStatuses(provider, policy, prs, time.Now(), o.promotePolicyStatuses)
+}
+
+// loadPromotePolicy loads the promotion policy from the head of the branch of the environment git repository rather
+// than from the Pull Requests so that a Pull Request cannot relax the policy it is checked against
+func (o *ControllerEnvironmentOptions) loadPromotePolicy() (*environments.PromotePolicy, error) {
+	err := o.stepGitCredentials()
+	if err != nil {
+		log.Logger().Warn(err.Error())
+	}
+	dir, err := ioutil.TempDir("", "jx-environment-policy-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	err = o.Git().ShallowClone(dir, o.SourceURL, o.Branch, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to clone %s branch %s", o.SourceURL, o.Branch)
+	}
+	return environments.LoadPromotePolicy(filepath.Join(dir, o.DriftChartDir))
+}
+
+// reportPromotePolicyStatuses updates the promotion policy status of each Pull Request whose status changed since it
+// was last reported. The reported statuses of the commits which are no longer the head of an open Pull Request are
+// forgotten
+func reportPromotePolicyStatuses(provider gits.GitProvider, policy *environments.PromotePolicy, prs []*gits.GitPullRequest, now time.Time, reported map[string]gits.GitRepoStatus) error {
+	errs := []error{}
+	shas := map[string]bool{}
+	defer func() {
+		for sha := range reported {
+			if !shas[sha] {
+				delete(reported, sha)
+			}
+		}
+	}()
+	for _, pr := range prs {
+		if pr.LastCommitSha == "" {
+			err := provider.UpdatePullRequestStatus(pr)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to get the status of Pull Request %s", pr.URL))
+				continue
+			}
+		}
+		shas[pr.LastCommitSha] = true
+		status := policy.PullRequestStatus(provider, pr, now)
+		if last, ok := reported[pr.LastCommitSha]; ok && last == *status {
+			continue
+		}
+		_, err := provider.UpdateCommitStatus(pr.Owner, pr.Repo, pr.LastCommitSha, status)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to report the %s status of Pull Request %s", environments.PromotePolicyStatusContext, pr.URL))
+			continue
+		}
+		log.Logger().Infof("reported the %s status %s of Pull Request %s: %s", environments.PromotePolicyStatusContext, status.State, pr.URL, status.Description)
+		reported[pr.LastCommitSha] = *status
+	}
+	return util.CombineErrors(errs...)
+}
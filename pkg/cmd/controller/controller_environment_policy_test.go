@@ -0,0 +1,56 @@
+// +build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingStatusProvider struct {
+	*gits.FakeProvider
+	statuses []*gits.GitRepoStatus
+}
+
+func (p *recordingStatusProvider) UpdateCommitStatus(org string, repo string, sha string, status *gits.GitRepoStatus) (*gits.GitRepoStatus, error) {
+	p.statuses = append(p.statuses, status)
+	return status, nil
+}
+
+func TestReportPromotePolicyStatuses(t *testing.T) {
+	repo, err := gits.NewFakeRepository("myorg", "environment-production", nil, nil)
+	require.NoError(t, err)
+	provider := &recordingStatusProvider{FakeProvider: gits.NewFakeProvider(repo)}
+	number := 1
+	pr := &gits.GitPullRequest{
+		Owner:         "myorg",
+		Repo:          "environment-production",
+		Number:        &number,
+		LastCommitSha: "abc123",
+	}
+	repo.PullRequests[number] = &gits.FakePullRequest{PullRequest: pr}
+
+	policy := &environments.PromotePolicy{
+		Windows: []environments.PromoteWindow{{Start: "09:00", End: "17:00"}},
+	}
+	reported := map[string]gits.GitRepoStatus{"oldsha": {}}
+	closed := time.Date(2020, 6, 1, 20, 0, 0, 0, time.UTC)
+	require.NoError(t, reportPromotePolicyStatuses(provider, policy, []*gits.GitPullRequest{pr}, closed, reported))
+	require.Len(t, provider.statuses, 1)
+	assert.Equal(t, environments.PromotePolicyStatusContext, provider.statuses[0].Context)
+	assert.Equal(t, "pending", provider.statuses[0].State, "should block the merge outside the promotion windows")
+	assert.NotContains(t, reported, "oldsha", "should forget the statuses of commits which are no longer Pull Requests")
+
+	require.NoError(t, reportPromotePolicyStatuses(provider, policy, []*gits.GitPullRequest{pr}, closed.Add(time.Minute), reported))
+	assert.Len(t, provider.statuses, 1, "should not report an unchanged status again")
+
+	open := time.Date(2020, 6, 2, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, reportPromotePolicyStatuses(provider, policy, []*gits.GitPullRequest{pr}, open, reported))
+	require.Len(t, provider.statuses, 2)
+	assert.Equal(t, "success", provider.statuses[1].State, "should allow the merge once the promotion window opens")
+}
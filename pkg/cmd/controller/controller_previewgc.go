@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/gc"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ControllerPreviewGCOptions the options for the preview garbage collection controller
+type ControllerPreviewGCOptions struct {
+	gc.GCPreviewsOptions
+
+	Namespace      string
+	ResyncInterval time.Duration
+}
+
+var (
+	controllerPreviewGCLong = templates.LongDesc(`
+		Runs the controller which watches the preview environments and deletes those whose pull request is merged or
+		closed, or which are older than the --ttl, releasing their helm release and namespace and commenting on the
+		pull request.
+
+		The preview environments are checked again every --resync-interval so that they are deleted once their time
+		to live expires.
+`)
+
+	controllerPreviewGCExample = templates.Examples(`
+		# delete the preview environments of closed pull requests or which were created more than 3 days ago
+		jx controller previewgc --ttl 72h
+`)
+)
+
+// NewCmdControllerPreviewGC creates the command for the preview garbage collection controller
+func NewCmdControllerPreviewGC(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerPreviewGCOptions{
+		GCPreviewsOptions: gc.GCPreviewsOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "previewgc",
+		Short:   "Runs the controller which garbage collects the preview environments",
+		Long:    controllerPreviewGCLong,
+		Example: controllerPreviewGCExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.AddFlags(cmd)
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to watch or defaults to the current namespace")
+	cmd.Flags().DurationVarP(&options.ResyncInterval, "resync-interval", "", 10*time.Minute, "How often the preview environments are checked again, e.g. for their time to live expiring")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerPreviewGCOptions) Run() error {
+	err := o.RegisterEnvironmentCRD()
+	if err != nil {
+		return err
+	}
+	jxClient, devNs, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = devNs
+	}
+
+	log.Logger().Infof("Watching for preview environments in namespace %s", util.ColorInfo(ns))
+
+	_, controller := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+				return jxClient.JenkinsV1().Environments(ns).List(lo)
+			},
+			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+				return jxClient.JenkinsV1().Environments(ns).Watch(lo)
+			},
+		},
+		&v1.Environment{},
+		o.ResyncInterval,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onPreviewChange(obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onPreviewChange(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	stop := make(chan struct{})
+	go controller.Run(stop)
+
+	// Wait forever
+	select {}
+}
+
+// onPreviewChange garbage collects the preview environment, only logging failures so that the controller keeps
+// running and retries on the next resync
+func (o *ControllerPreviewGCOptions) onPreviewChange(obj interface{}) {
+	env, ok := obj.(*v1.Environment)
+	if !ok {
+		log.Logger().Infof("Object is not a Environment %#v", obj)
+		return
+	}
+	if env.Spec.Kind != v1.EnvironmentKindTypePreview || env.DeletionTimestamp != nil {
+		return
+	}
+	_, err := o.GCPreview(env, time.Now())
+	if err != nil {
+		log.Logger().Warnf("failed to garbage collect preview environment %s: %s", env.Name, err.Error())
+	}
+}
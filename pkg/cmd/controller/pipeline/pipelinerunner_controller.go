@@ -237,6 +237,10 @@ func (c *controller) startPipeline(pipelineRun PipelineRunRequest) (PipelineRunR
 	if err != nil {
 		return response, errors.Wrap(err, "failed to get env vars from prowjob")
 	}
+	// the parameters of custom ChatOps commands are passed as labels of their jobs
+	for name, value := range prow.ChatOpsParameters(pipelineRun.Labels) {
+		envs[name] = value
+	}
 
 	sourceURL := c.getSourceURL(prowJobSpec.Refs.Org, prowJobSpec.Refs.Repo)
 	if sourceURL == "" {
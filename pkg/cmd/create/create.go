@@ -72,6 +72,7 @@ func NewCmdCreate(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdCreateProject(commonOpts))
 	cmd.AddCommand(NewCmdCreatePullRequest(commonOpts))
 	cmd.AddCommand(NewCmdCreateQuickstart(commonOpts))
+	cmd.AddCommand(NewCmdCreateQuickstartCatalog(commonOpts))
 	cmd.AddCommand(NewCmdCreateQuickstartLocation(commonOpts))
 	cmd.AddCommand(NewCmdCreateMLQuickstart(commonOpts))
 	cmd.AddCommand(NewCmdCreateSpring(commonOpts))
@@ -20,6 +20,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/nodes"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/util/uuid"
 )
@@ -38,6 +39,10 @@ var (
  		# using browser automation to login to the Git server
 		# with the username and password to find the API Token
 		jx create git token -n local -p somePassword someUserName	
+
+		# Authenticate as an installation of a GitHub App instead of a user
+		# refreshing its installation tokens automatically
+		jx create git token --app-id 1234 --installation-id 5678 --private-key-file myapp.private-key.pem
 	`)
 )
 
@@ -50,6 +55,10 @@ type CreateGitTokenOptions struct {
 	Password    string
 	ApiToken    string
 	Timeout     string
+
+	GitHubAppID             int64
+	GitHubAppInstallationID int64
+	GitHubAppPrivateKeyFile string
 }
 
 // NewCmdCreateGitToken creates a command
@@ -77,6 +86,9 @@ func NewCmdCreateGitToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.ApiToken, "api-token", "t", "", "The API Token for the user")
 	cmd.Flags().StringVarP(&options.Password, "password", "p", "", "The User password to try automatically create a new API Token")
 	cmd.Flags().StringVarP(&options.Timeout, "timeout", "", "", "The timeout if using browser automation to generate the API token (by passing username and password)")
+	cmd.Flags().Int64VarP(&options.GitHubAppID, "app-id", "", 0, "The ID of the GitHub App to authenticate as instead of using an API token")
+	cmd.Flags().Int64VarP(&options.GitHubAppInstallationID, "installation-id", "", 0, "The ID of the installation of the GitHub App in the organisation or user account")
+	cmd.Flags().StringVarP(&options.GitHubAppPrivateKeyFile, "private-key-file", "", "", "The PEM file of the private key of the GitHub App")
 
 	return cmd
 }
@@ -105,6 +117,10 @@ func (o *CreateGitTokenOptions) Run() error {
 		return err
 	}
 
+	if o.GitHubAppID != 0 {
+		return o.createGitHubAppAuth(authConfigSvc, server)
+	}
+
 	// TODO add the API thingy...
 	if o.Username == "" {
 		return fmt.Errorf("No Username specified")
@@ -152,6 +168,49 @@ func (o *CreateGitTokenOptions) Run() error {
 	return nil
 }
 
+// createGitHubAppAuth saves the user authenticating as the installation of the GitHub App
+func (o *CreateGitTokenOptions) createGitHubAppAuth(authConfigSvc auth.ConfigService, server *auth.AuthServer) error {
+	if server.Kind != "" && server.Kind != gits.KindGitHub {
+		return fmt.Errorf("GitHub Apps are not supported by %s server %s", server.Kind, server.URL)
+	}
+	if o.GitHubAppInstallationID == 0 {
+		return util.MissingOption("installation-id")
+	}
+	if o.GitHubAppPrivateKeyFile == "" {
+		return util.MissingOption("private-key-file")
+	}
+	privateKey, err := ioutil.ReadFile(o.GitHubAppPrivateKeyFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the private key file %s", o.GitHubAppPrivateKeyFile)
+	}
+	if o.Username == "" {
+		o.Username = auth.GitHubAppUsername
+	}
+
+	config := authConfigSvc.Config()
+	userAuth := config.GetOrCreateUserAuth(server.URL, o.Username)
+	userAuth.ApiToken = ""
+	userAuth.GitHubAppID = o.GitHubAppID
+	userAuth.GitHubAppInstallationID = o.GitHubAppInstallationID
+	userAuth.GitHubAppPrivateKey = string(privateKey)
+
+	// lets check the private key can create installation tokens
+	_, err = gits.CreateProvider(server, userAuth, o.Git())
+	if err != nil {
+		return errors.Wrapf(err, "failed to authenticate as GitHub App %d", o.GitHubAppID)
+	}
+
+	config.CurrentServer = server.URL
+	err = authConfigSvc.SaveConfig()
+	if err != nil {
+		return err
+	}
+
+	log.Logger().Infof("Created authentication as installation %s of GitHub App %s for Git server %s at %s",
+		util.ColorInfo(o.GitHubAppInstallationID), util.ColorInfo(o.GitHubAppID), util.ColorInfo(server.Name), util.ColorInfo(server.URL))
+	return nil
+}
+
 // lets try use the users browser to find the API token
 func (o *CreateGitTokenOptions) tryFindAPITokenFromBrowser(tokenUrl string, userAuth *auth.UserAuth) error {
 	var ctxt context.Context
@@ -18,6 +18,7 @@ import (
 const (
 	createQuickstartName = "Create new application from a Quickstart"
 	createSpringName     = "Create new Spring Boot microservice"
+	createTemplateName   = "Create new application from a project template"
 	importDirName        = "Import existing code from a directory"
 	importGitName        = "Import code from a git repository"
 	importGitHubName     = "Import code from a github repository"
@@ -27,25 +28,43 @@ var (
 	createProjectNames = []string{
 		createQuickstartName,
 		createSpringName,
+		createTemplateName,
 		importDirName,
 		importGitName,
 		importGitHubName,
 	}
 
 	createProjectLong = templates.LongDesc(`
-		Create a new Project by importing code, using a Quickstart or custom wizard for Spring.
+		Create a new Project by importing code, using a Quickstart, a project template or custom wizard for Spring.
+
+		A project template is a git repository (or local directory) whose template directory contains the files of the generated project. The paths and contents of the files are go templates which can access the project name via {{ .Name }} and the values of the template via {{ .Values.foo }}.
+
+		The template can contain:
+
+		* a values.schema.json file with the JSON schema of the values to prompt for which can also be passed via --set
+		* a jx-template.yml file configuring the template directory, the files to copy without rendering (copyOnly) and the commands to run in the generated project (hooks)
+
+		Once generated the project is imported into Jenkins X unless --no-import is specified.
 
 ` + helper.SeeAlsoText("jx create quickstart", "jx create spring", "jx import"))
 
 	createProjectExample = templates.Examples(`
 		# Create a project
 		jx create project
+
+		# Create a project from a project template
+		jx create project --template https://github.com/myorg/go-service-template.git
+
+		# Create a project from a project template in batch mode
+		jx create project -b --template https://github.com/myorg/go-service-template.git --name myapp --set team=platform
 	`)
 )
 
 // CreateProjectWizardOptions the options for the command
 type CreateProjectWizardOptions struct {
 	options.CreateOptions
+
+	Template CreateProjectTemplateOptions
 }
 
 // NewCmdCreateProject creates a command object for the "create" command
@@ -55,10 +74,11 @@ func NewCmdCreateProject(commonOpts *opts.CommonOptions) *cobra.Command {
 			CommonOptions: commonOpts,
 		},
 	}
+	options.Template.CommonOptions = commonOpts
 
 	cmd := &cobra.Command{
 		Use:     "project",
-		Short:   "Create a new Project by importing code, using a Quickstart or custom wizard for Spring",
+		Short:   "Create a new Project by importing code, using a Quickstart, a project template or custom wizard for Spring",
 		Long:    createProjectLong,
 		Example: createProjectExample,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -68,12 +88,16 @@ func NewCmdCreateProject(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	options.Template.addCreateProjectTemplateFlags(cmd)
 
 	return cmd
 }
 
 // Run implements the command
 func (o *CreateProjectWizardOptions) Run() error {
+	if o.Template.TemplateURL != "" {
+		return o.Template.Run()
+	}
 	name, err := util.PickName(createProjectNames, "Which kind of project you want to create: ",
 		"Jenkins X supports a number of diffferent wizards for creating or importing new projects.",
 		o.GetIOFileHandles())
@@ -85,6 +109,8 @@ func (o *CreateProjectWizardOptions) Run() error {
 		return o.createQuickstart()
 	case createSpringName:
 		return o.createSpring()
+	case createTemplateName:
+		return o.createFromTemplate()
 	case importDirName:
 		return o.importDir()
 	case importGitName:
@@ -108,6 +134,16 @@ func (o *CreateProjectWizardOptions) createSpring() error {
 	return w.Run()
 }
 
+func (o *CreateProjectWizardOptions) createFromTemplate() error {
+	templateURL, err := util.PickValue("Which git repository URL contains the project template: ", "", true,
+		"Please specify the git URL or local directory of the project template you want to use for your new project", o.GetIOFileHandles())
+	if err != nil {
+		return err
+	}
+	o.Template.TemplateURL = templateURL
+	return o.Template.Run()
+}
+
 func (o *CreateProjectWizardOptions) importDir() error {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -0,0 +1,202 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/projecttemplates"
+	"github.com/jenkins-x/jx/v2/pkg/surveyutils"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	optionTemplate = "template"
+)
+
+// CreateProjectTemplateOptions the options for creating a project from a project template
+type CreateProjectTemplateOptions struct {
+	CreateProjectOptions
+
+	TemplateURL string
+	TemplateRef string
+	Values      []string
+
+	commandRunner func(*util.Command) (string, error)
+}
+
+func (o *CreateProjectTemplateOptions) addCreateProjectTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.TemplateURL, optionTemplate, "", "", "The git URL or local directory of the project template to generate the project from")
+	cmd.Flags().StringVarP(&o.TemplateRef, "template-ref", "", "master", "The git branch or tag of the project template")
+	cmd.Flags().StringArrayVarP(&o.Values, "set", "", []string{}, "The values of the project template prompts e.g. --set foo=bar. Can be specified multiple times")
+
+	o.addCreateAppFlags(cmd)
+}
+
+// Run generates a project from the template, runs the post generation hooks of the template and imports the project
+func (o *CreateProjectTemplateOptions) Run() error {
+	if o.TemplateURL == "" {
+		return util.MissingOption(optionTemplate)
+	}
+	tmpDir, err := ioutil.TempDir("", "jx-project-template-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck
+
+	templateDir, err := o.fetchTemplate(tmpDir)
+	if err != nil {
+		return err
+	}
+	config, err := projecttemplates.LoadTemplateConfig(templateDir)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	for _, kv := range o.Values {
+		tokens := strings.SplitN(kv, "=", 2)
+		if len(tokens) != 2 {
+			return errors.Errorf("cannot parse value %s as key=value", kv)
+		}
+		util.SetMapValueViaPath(values, tokens[0], tokens[1])
+	}
+
+	var details *gits.CreateRepoData
+	if !o.BatchMode {
+		details, err = o.GetGitRepositoryDetails()
+		if err != nil {
+			return err
+		}
+	}
+	name := o.Repository
+	if details != nil {
+		name = details.RepoName
+	}
+	if name == "" {
+		return util.MissingOption("name")
+	}
+
+	schema, err := projecttemplates.LoadValuesSchema(templateDir)
+	if err != nil {
+		return err
+	}
+	if schema != nil {
+		values, err = o.promptValues(schema, values)
+		if err != nil {
+			return err
+		}
+	}
+
+	dir := o.OutDir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+	outDir := filepath.Join(dir, name)
+	exists, err := util.DirExists(outDir)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the directory %s already exists", outDir)
+	}
+
+	data := &projecttemplates.TemplateData{
+		Name:   name,
+		Values: values,
+	}
+	err = config.Generate(templateDir, outDir, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate the project from template %s", o.TemplateURL)
+	}
+	err = o.runHooks(config, outDir, data)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Created project at %s from template %s", util.ColorInfo(outDir), util.ColorInfo(o.TemplateURL))
+
+	if details != nil {
+		o.ConfigureImportOptions(details)
+	}
+	return o.ImportCreatedProject(outDir)
+}
+
+// fetchTemplate returns the directory of the project template cloning it if it is not a local directory
+func (o *CreateProjectTemplateOptions) fetchTemplate(tmpDir string) (string, error) {
+	exists, err := util.DirExists(o.TemplateURL)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return o.TemplateURL, nil
+	}
+	templateDir := filepath.Join(tmpDir, "template")
+	err = o.Git().ShallowClone(templateDir, o.TemplateURL, o.TemplateRef, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to clone the project template %s ref %s", o.TemplateURL, o.TemplateRef)
+	}
+	return templateDir, nil
+}
+
+// runHooks runs the hooks of the project template in the generated project. Outside of batch mode the commands of the
+// hooks are shown and only run once confirmed as the template may not be trusted
+func (o *CreateProjectTemplateOptions) runHooks(config *projecttemplates.TemplateConfig, outDir string, data *projecttemplates.TemplateData) error {
+	handles := o.GetIOFileHandles()
+	commands, err := config.HookCommands(outDir, data, handles.Out, handles.Err)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	if !o.BatchMode {
+		fmt.Fprintf(handles.Out, "The project template %s runs the following commands in %s:\n", o.TemplateURL, outDir)
+		for _, cmd := range commands {
+			fmt.Fprintf(handles.Out, "  %s\n", cmd.String())
+		}
+		confirmed, err := util.Confirm("Do you want to run the commands of the project template?", false, "The commands are run in the generated project directory", handles)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			log.Logger().Warnf("not running the commands of project template %s", o.TemplateURL)
+			return nil
+		}
+	}
+	if o.commandRunner == nil {
+		o.commandRunner = (*util.Command).RunWithoutRetry
+	}
+	return projecttemplates.RunHooks(commands, o.commandRunner)
+}
+
+// promptValues asks for the values of the JSON schema of the project template which were not passed via --set
+func (o *CreateProjectTemplateOptions) promptValues(schema []byte, existing map[string]interface{}) (map[string]interface{}, error) {
+	handles := o.GetIOFileHandles()
+	schemaOptions := surveyutils.JSONSchemaOptions{
+		Out:                handles.Out,
+		In:                 handles.In,
+		OutErr:             handles.Err,
+		NoAsk:              o.BatchMode,
+		AutoAcceptDefaults: o.BatchMode,
+	}
+	data, err := schemaOptions.GenerateValues(schema, existing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to prompt for the values of project template %s", o.TemplateURL)
+	}
+	answer := map[string]interface{}{}
+	err = json.Unmarshal(data, &answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the values of project template %s", o.TemplateURL)
+	}
+	return answer, nil
+}
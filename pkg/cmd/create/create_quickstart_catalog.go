@@ -0,0 +1,142 @@
+package create
+
+import (
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/create/options"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createQuickstartCatalogLong = templates.LongDesc(`
+		Create a catalog of quickstarts for your team
+
+		A catalog is a git repository containing a quickstarts.yml index of quickstarts which are merged with the default quickstarts when using 'jx create quickstart'. Each quickstart in the index can specify its owner, name, language, framework, license, tags and download zip URL.
+
+		Private catalogs and quickstarts are accessed using the git credentials of the git server of the catalog (see 'jx create git token').
+
+`)
+
+	createQuickstartCatalogExample = templates.Examples(`
+		# Create a quickstart catalog using a git repository
+		jx create quickstartcatalog --url https://github.com/myorg/quickstarts.git
+
+		# Create a quickstart catalog using an index file on a branch of a private git repository
+		jx create qscatalog --url https://mygit.server.com/myorg/quickstarts.git --kind gitlab --ref stable --path catalog/quickstarts.yml
+
+	`)
+)
+
+// CreateQuickstartCatalogOptions the options for the create quickstartcatalog command
+type CreateQuickstartCatalogOptions struct {
+	options.CreateOptions
+
+	Name    string
+	GitUrl  string
+	GitKind string
+	Ref     string
+	Path    string
+}
+
+// NewCmdCreateQuickstartCatalog creates a command object for the "create" command
+func NewCmdCreateQuickstartCatalog(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &CreateQuickstartCatalogOptions{
+		CreateOptions: options.CreateOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     opts.QuickStartCatalogCommandName,
+		Short:   "Create a catalog of quickstarts for your team",
+		Aliases: opts.QuickStartCatalogCommandAliases,
+		Long:    createQuickstartCatalogLong,
+		Example: createQuickstartCatalogExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Name, opts.OptionName, "n", "", "The name of the catalog. Defaults to the name of the git repository")
+	cmd.Flags().StringVarP(&options.GitUrl, optionGitUrl, "u", "", "The git clone URL of the catalog repository")
+	cmd.Flags().StringVarP(&options.GitKind, optionGitKind, "k", "", "The kind of Git service of the catalog repository")
+	cmd.Flags().StringVarP(&options.Ref, "ref", "r", "master", "The git branch or tag of the catalog repository")
+	cmd.Flags().StringVarP(&options.Path, "path", "", "", "The path of the index file in the catalog repository. Defaults to quickstarts.yml")
+
+	return cmd
+}
+
+// Run implements the command
+func (o *CreateQuickstartCatalogOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	if o.GitUrl == "" {
+		return util.MissingOption(optionGitUrl)
+	}
+	gitInfo, err := gits.ParseGitURL(o.GitUrl)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse git URL %s", o.GitUrl)
+	}
+	if o.Name == "" {
+		o.Name = naming.ToValidName(gitInfo.Name)
+	}
+	if o.GitKind == "" {
+		authConfigSvc, err := o.GitAuthConfigService()
+		if err != nil {
+			return err
+		}
+		server := authConfigSvc.Config().GetServer(gitInfo.HostURLWithoutUser())
+		if server != nil {
+			o.GitKind = server.Kind
+		}
+	}
+	if o.GitKind == "" && gitInfo.IsGitHub() {
+		o.GitKind = gits.KindGitHub
+	}
+	if o.GitKind == "" {
+		return util.MissingOption(optionGitKind)
+	}
+
+	catalogs, err := kube.GetQuickstartCatalogs(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	catalog := v1.QuickstartCatalog{
+		Name:    o.Name,
+		GitURL:  o.GitUrl,
+		GitKind: o.GitKind,
+		Ref:     o.Ref,
+		Path:    o.Path,
+	}
+	found := false
+	for i, c := range catalogs {
+		if c.Name == o.Name {
+			catalogs[i] = catalog
+			found = true
+		}
+	}
+	if !found {
+		catalogs = append(catalogs, catalog)
+	}
+
+	callback := func(env *v1.Environment) error {
+		env.Spec.TeamSettings.QuickstartCatalogs = catalogs
+		log.Logger().Infof("Adding the quickstart catalog %s for %s", util.ColorInfo(o.Name), util.ColorInfo(o.GitUrl))
+		return nil
+	}
+	return o.ModifyDevEnvironment(callback)
+}
@@ -59,6 +59,7 @@ func NewCmdDelete(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdDeleteJenkins(commonOpts))
 	cmd.AddCommand(NewCmdDeleteNamespace(commonOpts))
 	cmd.AddCommand(NewCmdDeletePreview(commonOpts))
+	cmd.AddCommand(NewCmdDeleteQuickstartCatalog(commonOpts))
 	cmd.AddCommand(NewCmdDeleteQuickstartLocation(commonOpts))
 	cmd.AddCommand(NewCmdDeleteRepo(commonOpts))
 	cmd.AddCommand(NewCmdDeleteToken(commonOpts))
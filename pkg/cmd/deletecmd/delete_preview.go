@@ -121,6 +121,11 @@ func (o *DeletePreviewOptions) DeletePreview(name string) error {
 	if err != nil {
 		return err
 	}
+	hooks, err := preview.GetPreviewHooks(environment)
+	if err != nil {
+		log.Logger().Warnf("not running the post delete hooks of preview environment %s: %s", name, err.Error())
+		hooks = nil
+	}
 	releaseName := kube.GetPreviewEnvironmentReleaseName(environment)
 	if len(releaseName) > 0 {
 		log.Logger().Infof("Deleting helm release: %s", util.ColorInfo(releaseName))
@@ -136,5 +141,9 @@ func (o *DeletePreviewOptions) DeletePreview(name string) error {
 		DeleteNamespace: true,
 	}
 	deleteOptions.Args = []string{name}
-	return deleteOptions.Run()
+	err = deleteOptions.Run()
+	if err != nil {
+		return err
+	}
+	return preview.RunPostDeleteHooks(hooks, environment)
 }
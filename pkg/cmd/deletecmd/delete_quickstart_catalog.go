@@ -0,0 +1,103 @@
+package deletecmd
+
+import (
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteQuickstartCatalogLong = templates.LongDesc(`
+		Deletes a quickstart catalog for your team
+
+`)
+
+	deleteQuickstartCatalogExample = templates.Examples(`
+		# Pick a quickstart catalog to delete for your team
+		jx delete quickstartcatalog
+
+		# Delete the quickstart catalog 'myquickstarts' for your team
+		jx delete qscatalog myquickstarts
+
+	`)
+)
+
+// DeleteQuickstartCatalogOptions the options for the delete quickstartcatalog command
+type DeleteQuickstartCatalogOptions struct {
+	*opts.CommonOptions
+}
+
+// NewCmdDeleteQuickstartCatalog defines the command
+func NewCmdDeleteQuickstartCatalog(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DeleteQuickstartCatalogOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     opts.QuickStartCatalogCommandName + " [name]",
+		Short:   "Deletes a quickstart catalog for your team",
+		Aliases: opts.QuickStartCatalogCommandAliases,
+		Long:    deleteQuickstartCatalogLong,
+		Example: deleteQuickstartCatalogExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+// Run implements the command
+func (o *DeleteQuickstartCatalogOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	catalogs, err := kube.GetQuickstartCatalogs(jxClient, ns)
+	if err != nil {
+		return err
+	}
+
+	name := ""
+	if len(o.Args) > 0 {
+		name = o.Args[0]
+	} else {
+		if o.BatchMode {
+			return util.MissingArgument("name")
+		}
+		names := []string{}
+		for _, catalog := range catalogs {
+			names = append(names, catalog.Name)
+		}
+		name, err = util.PickName(names, "Pick the quickstart catalog to remove from the team settings: ", "", o.GetIOFileHandles())
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return fmt.Errorf("No quickstart catalog chosen")
+		}
+	}
+
+	callback := func(env *v1.Environment) error {
+		settings := &env.Spec.TeamSettings
+		for i, c := range settings.QuickstartCatalogs {
+			if c.Name == name {
+				settings.QuickstartCatalogs = append(settings.QuickstartCatalogs[0:i], settings.QuickstartCatalogs[i+1:]...)
+				log.Logger().Infof("Removing quickstart catalog %s", util.ColorInfo(name))
+				return nil
+			}
+		}
+		return fmt.Errorf("No quickstart catalog found with name: %s", name)
+	}
+	return o.ModifyDevEnvironment(callback)
+}
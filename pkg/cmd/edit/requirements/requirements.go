@@ -205,6 +205,8 @@ func (o *RequirementsOptions) applyDefaults() error {
 			r.SecretStorage = config.SecretStorageTypeLocal
 		case "vault":
 			r.SecretStorage = config.SecretStorageTypeVault
+		case "externalSecrets":
+			r.SecretStorage = config.SecretStorageTypeExternalSecrets
 		default:
 			return util.InvalidOption("secret", o.SecretStorage, config.SecretStorageTypeValues)
 		}
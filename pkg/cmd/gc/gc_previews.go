@@ -15,21 +15,28 @@ import (
 	"strconv"
 
 	"strings"
+	"time"
 
 	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 )
 
-// GetOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
+// GCPreviewsOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
 // referencing the cmd.Flags()
 type GCPreviewsOptions struct {
 	*opts.CommonOptions
 
 	DisableImport bool
 	OutDir        string
+	TTL           time.Duration
+	NoComment     bool
+
+	gitProviderFn   func(gitInfo *gits.GitRepository) (gits.GitProvider, error)
+	deletePreviewFn func(name string) error
 }
 
 var (
@@ -37,11 +44,18 @@ var (
 		Garbage collect Jenkins X preview environments.  If a pull request is merged or closed the associated preview
 		environment will be deleted.
 
+		If a --ttl is specified then preview environments older than it are deleted too. A comment is added to the
+		pull request of each preview environment deleted unless --no-comment is specified.
+
+		To continuously garbage collect the preview environments as they change see: jx controller previewgc
 `)
 
 	GCPreviewsExample = templates.Examples(`
 		jx garbage collect previews
 		jx gc previews
+
+		# also delete any preview environments created more than 3 days ago
+		jx gc previews --ttl 72h
 `)
 )
 
@@ -63,9 +77,16 @@ func NewCmdGCPreviews(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	options.AddFlags(cmd)
 	return cmd
 }
 
+// AddFlags adds the flags which control which preview environments are garbage collected
+func (o *GCPreviewsOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVarP(&o.TTL, "ttl", "", 0, "The time to live of the preview environments after which they are deleted even if their pull request is still open. Defaults to no limit")
+	cmd.Flags().BoolVarP(&o.NoComment, "no-comment", "", false, "Disables commenting on the pull request of each preview environment deleted")
+}
+
 // Run implements this command
 func (o *GCPreviewsOptions) Run() error {
 	client, currentNs, err := o.JXClientAndDevNamespace()
@@ -85,58 +106,15 @@ func (o *GCPreviewsOptions) Run() error {
 	}
 
 	var previewFound bool
-	for _, e := range envs.Items {
+	now := time.Now()
+	for i := range envs.Items {
+		e := &envs.Items[i]
 		if e.Spec.Kind == v1.EnvironmentKindTypePreview {
 			previewFound = true
-			gitInfo, err := gits.ParseGitURL(e.Spec.Source.URL)
-			if err != nil {
-				return err
-			}
-			// we need pull request info to include
-			authConfigSvc, err := o.GitAuthConfigService()
-			if err != nil {
-				return err
-			}
-
-			gitKind, err := o.GitServerKind(gitInfo)
-			if err != nil {
-				return err
-			}
-
-			ghOwner, err := o.GetGitHubAppOwner(gitInfo)
+			_, err = o.GCPreview(e, now)
 			if err != nil {
 				return err
 			}
-			gitProvider, err := gitInfo.CreateProvider(o.InCluster(), authConfigSvc, gitKind, ghOwner, o.Git(), o.BatchMode, o.GetIOFileHandles())
-			if err != nil {
-				return err
-			}
-			prNum, err := strconv.Atoi(e.Spec.PreviewGitSpec.Name)
-			if err != nil {
-				log.Logger().Warn("Unable to convert PR " + e.Spec.PreviewGitSpec.Name + " to a number")
-			}
-			pullRequest, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNum)
-			if err != nil {
-				log.Logger().Warnf("Can not get pull request %s, skipping: %s", e.Spec.PreviewGitSpec.Name, err)
-				continue
-			}
-
-			lowerState := strings.ToLower(*pullRequest.State)
-
-			if strings.HasPrefix(lowerState, "clos") || strings.HasPrefix(lowerState, "merged") || strings.HasPrefix(lowerState, "superseded") || strings.HasPrefix(lowerState, "declined") {
-				// lets delete the preview environment
-				deleteOpts := deletecmd.DeletePreviewOptions{
-					PreviewOptions: preview.PreviewOptions{
-						PromoteOptions: promote.PromoteOptions{
-							CommonOptions: o.CommonOptions,
-						},
-					},
-				}
-				err = deleteOpts.DeletePreview(e.Name)
-				if err != nil {
-					return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
-				}
-			}
 		}
 	}
 	if !previewFound {
@@ -144,3 +122,95 @@ func (o *GCPreviewsOptions) Run() error {
 	}
 	return nil
 }
+
+// GCPreview deletes the preview environment if its pull request is merged or closed or it is older than the --ttl,
+// commenting on the pull request why it was deleted. Returns true if the preview environment was deleted
+func (o *GCPreviewsOptions) GCPreview(e *v1.Environment, now time.Time) (bool, error) {
+	gitInfo, err := gits.ParseGitURL(e.Spec.Source.URL)
+	if err != nil {
+		return false, err
+	}
+	gitProvider, err := o.gitProvider(gitInfo)
+	if err != nil {
+		return false, err
+	}
+	prNum, err := strconv.Atoi(e.Spec.PreviewGitSpec.Name)
+	if err != nil {
+		log.Logger().Warn("Unable to convert PR " + e.Spec.PreviewGitSpec.Name + " to a number")
+	}
+
+	reason := ""
+	pullRequest, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNum)
+	if err != nil {
+		log.Logger().Warnf("Can not get pull request %s: %s", e.Spec.PreviewGitSpec.Name, err)
+		pullRequest = nil
+	} else if pullRequest.State != nil {
+		lowerState := strings.ToLower(*pullRequest.State)
+		if strings.HasPrefix(lowerState, "clos") || strings.HasPrefix(lowerState, "merged") || strings.HasPrefix(lowerState, "superseded") || strings.HasPrefix(lowerState, "declined") {
+			reason = fmt.Sprintf("its pull request is %s", lowerState)
+		}
+	}
+	if reason == "" && o.TTL > 0 && !e.CreationTimestamp.IsZero() {
+		age := now.Sub(e.CreationTimestamp.Time)
+		if age > o.TTL {
+			reason = fmt.Sprintf("it is older than its time to live of %s", o.TTL.String())
+		}
+	}
+	if reason == "" {
+		if pullRequest == nil {
+			log.Logger().Warnf("skipping preview environment %s", e.Name)
+		}
+		return false, nil
+	}
+
+	// lets delete the preview environment
+	log.Logger().Infof("Deleting preview environment %s as %s", util.ColorInfo(e.Name), reason)
+	err = o.deletePreview(e.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
+	}
+	if pullRequest != nil && !o.NoComment {
+		comment := fmt.Sprintf(":broom: Deleted the preview environment `%s` as %s", e.Name, reason)
+		err = gitProvider.AddPRComment(pullRequest, comment)
+		if err != nil {
+			log.Logger().Warnf("failed to comment on pull request %s: %s", e.Spec.PreviewGitSpec.Name, err)
+		}
+	}
+	return true, nil
+}
+
+func (o *GCPreviewsOptions) gitProvider(gitInfo *gits.GitRepository) (gits.GitProvider, error) {
+	if o.gitProviderFn != nil {
+		return o.gitProviderFn(gitInfo)
+	}
+	// we need pull request info to include
+	authConfigSvc, err := o.GitAuthConfigService()
+	if err != nil {
+		return nil, err
+	}
+
+	gitKind, err := o.GitServerKind(gitInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	ghOwner, err := o.GetGitHubAppOwner(gitInfo)
+	if err != nil {
+		return nil, err
+	}
+	return gitInfo.CreateProvider(o.InCluster(), authConfigSvc, gitKind, ghOwner, o.Git(), o.BatchMode, o.GetIOFileHandles())
+}
+
+func (o *GCPreviewsOptions) deletePreview(name string) error {
+	if o.deletePreviewFn != nil {
+		return o.deletePreviewFn(name)
+	}
+	deleteOpts := deletecmd.DeletePreviewOptions{
+		PreviewOptions: preview.PreviewOptions{
+			PromoteOptions: promote.PromoteOptions{
+				CommonOptions: o.CommonOptions,
+			},
+		},
+	}
+	return deleteOpts.DeletePreview(name)
+}
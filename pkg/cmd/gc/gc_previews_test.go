@@ -0,0 +1,76 @@
+// +build unit
+
+package gc
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPreview(t *testing.T) {
+	t.Parallel()
+
+	repo, err := gits.NewFakeRepository("myorg", "myrepo", nil, nil)
+	require.NoError(t, err)
+	states := map[int]string{1: "open", 2: "merged", 3: "open"}
+	for number, state := range states {
+		n := number
+		s := state
+		repo.PullRequests[n] = &gits.FakePullRequest{
+			PullRequest: &gits.GitPullRequest{Owner: "myorg", Repo: "myrepo", Number: &n, State: &s},
+		}
+	}
+	provider := gits.NewFakeProvider(repo)
+
+	now := time.Date(2020, 4, 10, 12, 0, 0, 0, time.UTC)
+	preview := func(name string, pr string, age time.Duration) *v1.Environment {
+		return &v1.Environment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: v1.EnvironmentSpec{
+				Kind:           v1.EnvironmentKindTypePreview,
+				Source:         v1.EnvironmentRepository{URL: "https://fake.git/myorg/myrepo.git"},
+				PreviewGitSpec: v1.PreviewGitSpec{Name: pr},
+			},
+		}
+	}
+
+	deleted := []string{}
+	o := &GCPreviewsOptions{
+		TTL: 72 * time.Hour,
+		gitProviderFn: func(gitInfo *gits.GitRepository) (gits.GitProvider, error) {
+			return provider, nil
+		},
+		deletePreviewFn: func(name string) error {
+			deleted = append(deleted, name)
+			return nil
+		},
+	}
+
+	for _, tc := range []struct {
+		env      *v1.Environment
+		expected bool
+	}{
+		{env: preview("myorg-myrepo-pr-1", "1", time.Hour), expected: false},
+		{env: preview("myorg-myrepo-pr-2", "2", time.Hour), expected: true},
+		{env: preview("myorg-myrepo-pr-3", "3", 73*time.Hour), expected: true},
+		{env: preview("myorg-myrepo-pr-4", "4", time.Hour), expected: false},
+	} {
+		answer, err := o.GCPreview(tc.env, now)
+		require.NoError(t, err, "failed to garbage collect %s", tc.env.Name)
+		assert.Equal(t, tc.expected, answer, "garbage collecting %s", tc.env.Name)
+	}
+
+	assert.Equal(t, []string{"myorg-myrepo-pr-2", "myorg-myrepo-pr-3"}, deleted)
+	assert.Equal(t, "", repo.PullRequests[1].Comment)
+	assert.Equal(t, ":broom: Deleted the preview environment `myorg-myrepo-pr-2` as its pull request is merged", repo.PullRequests[2].Comment)
+	assert.Equal(t, ":broom: Deleted the preview environment `myorg-myrepo-pr-3` as it is older than its time to live of 72h0m0s", repo.PullRequests[3].Comment)
+}
@@ -0,0 +1,117 @@
+package gc
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GCWebhookEventsOptions contains the CLI options for this command
+type GCWebhookEventsOptions struct {
+	*opts.CommonOptions
+
+	Namespace     string
+	Age           time.Duration
+	DeadLetterAge time.Duration
+}
+
+var (
+	GCWebhookEventsLong = templates.LongDesc(`
+		Garbage collect old webhook events which have been processed
+
+		The events in the dead letter queue are kept for longer so that they can be replayed
+`)
+
+	GCWebhookEventsExample = templates.Examples(`
+		# garbage collect old webhook events of the default age
+		jx gc webhookevents
+
+		# garbage collect the events which succeeded more than 2 hours ago
+		jx gc webhookevents -a 2h
+`)
+)
+
+// NewCmdGCWebhookEvents creates the command object
+func NewCmdGCWebhookEvents(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GCWebhookEventsOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "webhookevents",
+		Short:   "garbage collection for webhook events",
+		Aliases: []string{"webhookevent", "hooks"},
+		Long:    GCWebhookEventsLong,
+		Example: GCWebhookEventsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the webhook events. Defaults to the current namespace")
+	cmd.Flags().DurationVarP(&options.Age, "age", "a", 24*time.Hour, "The minimum age of succeeded webhook events to garbage collect")
+	cmd.Flags().DurationVarP(&options.DeadLetterAge, "dead-letter-age", "", 7*24*time.Hour, "The minimum age of failed webhook events to garbage collect")
+	return cmd
+}
+
+// Run implements this command
+func (o *GCWebhookEventsOptions) Run() error {
+	client, ns, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	if o.Namespace != "" {
+		ns = o.Namespace
+	}
+
+	eventInterface := client.JenkinsV1().WebhookEvents(ns)
+	events, err := eventInterface.List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	errors := []error{}
+	for i := range events.Items {
+		event := &events.Items[i]
+		if !o.MatchesWebhookEvent(event, now) {
+			continue
+		}
+		err = eventInterface.Delete(event.Name, &metav1.DeleteOptions{})
+		if err != nil {
+			log.Logger().Warnf("Failed to delete webhook event %s in namespace %s: %s", event.Name, ns, err)
+			errors = append(errors, err)
+			continue
+		}
+		log.Logger().Infof("Deleted webhook event %s in namespace %s with phase %s", event.Name, ns, event.Status.Phase)
+	}
+	return util.CombineErrors(errors...)
+}
+
+// MatchesWebhookEvent returns true if this webhook event can be garbage collected
+func (o *GCWebhookEventsOptions) MatchesWebhookEvent(event *v1.WebhookEvent, now time.Time) bool {
+	var age time.Duration
+	switch event.Status.Phase {
+	case v1.WebhookEventPhaseSucceeded:
+		age = o.Age
+	case v1.WebhookEventPhaseFailed:
+		age = o.DeadLetterAge
+	default:
+		return false
+	}
+	completed := event.CreationTimestamp.Time
+	attempts := event.Status.Attempts
+	if len(attempts) > 0 && attempts[len(attempts)-1].CompletedTimestamp != nil {
+		completed = attempts[len(attempts)-1].CompletedTimestamp.Time
+	}
+	return now.Sub(completed) > age
+}
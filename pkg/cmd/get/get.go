@@ -92,6 +92,7 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetPipeline(commonOpts))
 	cmd.AddCommand(NewCmdGetPostPreviewJob(commonOpts))
 	cmd.AddCommand(NewCmdGetPreview(commonOpts))
+	cmd.AddCommand(NewCmdGetQuickstartCatalog(commonOpts))
 	cmd.AddCommand(NewCmdGetQuickstartLocation(commonOpts))
 	cmd.AddCommand(NewCmdGetQuickstarts(commonOpts))
 	cmd.AddCommand(NewCmdGetRelease(commonOpts))
@@ -34,17 +34,35 @@ type GetBuildLogsOptions struct {
 	WaitForPipelineDuration time.Duration
 	TektonLogger            *logs.TektonLogger
 	FailIfPodFails          bool
+	Structured              bool
+	Follow                  bool
+	Stage                   string
+	Format                  string
 }
 
 // CLILogWriter is an implementation of logs.LogWriter that will show logs in the standard output
 type CLILogWriter struct {
 	*opts.CommonOptions
+	Formatter *logs.StructuredFormatter
+	// LogMasker if not nil masks the secret values in the lines marked as ShouldMask
+	LogMasker *kube.LogMasker
 }
 
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 var (
 	get_build_log_long = templates.LongDesc(`
 		Display a build log
 
+		The values of the secrets in the namespace of the build and, when the secrets are stored in Vault, of the Vault secrets which are resolved into the pipelines are masked in the log.
+
+		The steps of Tekton stages running in parallel are streamed one after another unless --structured is used, which
+		streams the logs of all the running stages at once with each line prefixed by its stage and step in a color per
+		stage. Use --format json to write the lines as NDJSON records for log shippers instead.
+
 `)
 
 	get_build_log_example = templates.Examples(`
@@ -65,6 +83,15 @@ var (
 
 		# View the build logs for a specific tekton build pod
 		jx get build log --pod my-pod-name
+
+		# Follow the logs of the parallel stages of a Tekton build with a prefix per stage and step
+		jx get build log --repo cheese --structured
+
+		# Follow the logs of a single stage of a Tekton build
+		jx get build log --repo cheese --follow --stage build
+
+		# Write the logs written so far as NDJSON for a log shipper
+		jx get build log --repo cheese --build 3 --format json --follow=false -b
 	`)
 )
 
@@ -103,6 +130,10 @@ func NewCmdGetBuildLogs(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.BuildFilter.GitURL, "giturl", "g", "", "The git URL to filter on. If you specify a link to a github repository or PR we can filter the query of build pods accordingly")
 	cmd.Flags().StringVarP(&options.BuildFilter.Context, "context", "", "", "Filters the context of the build")
 	cmd.Flags().BoolVarP(&options.CurrentFolder, "current", "c", false, "Display logs using current folder as repo name, and parent folder as owner")
+	cmd.Flags().BoolVarP(&options.Structured, "structured", "", false, "Streams the logs of all the running Tekton stages at once with each line prefixed by its stage and step")
+	cmd.Flags().BoolVarP(&options.Follow, "follow", "", true, "Follows the logs of the running Tekton stages and waits for the pending ones, otherwise only the logs written so far are displayed")
+	cmd.Flags().StringVarP(&options.Stage, "stage", "", "", "Only displays the logs of the given Tekton stage")
+	cmd.Flags().StringVarP(&options.Format, "format", "", logFormatText, fmt.Sprintf("The format of the structured logs: %s or %s to write NDJSON records", logFormatText, logFormatJSON))
 	options.AddBaseFlags(cmd)
 
 	return cmd
@@ -114,6 +145,9 @@ func (o *GetBuildLogsOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.Format != logFormatText && o.Format != logFormatJSON {
+		return util.InvalidOption("format", o.Format, []string{logFormatText, logFormatJSON})
+	}
 	jxClient, ns, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -155,15 +189,28 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 	var err error
 
 	if o.TektonLogger == nil {
+		structured := o.Structured || o.Format == logFormatJSON
+		logWriter := &CLILogWriter{
+			CommonOptions: o.CommonOptions,
+		}
+		masker, err := o.NewLogMasker(kubeClient, ns)
+		if err != nil {
+			log.Logger().Warnf("Failed to load the secrets in namespace %s to mask them in the logs: %s", ns, err.Error())
+		}
+		logWriter.LogMasker = masker
+		if structured {
+			logWriter.Formatter = logs.NewStructuredFormatter(o.Format == logFormatJSON)
+		}
 		o.TektonLogger = &logs.TektonLogger{
-			KubeClient:   kubeClient,
-			TektonClient: tektonClient,
-			JXClient:     jxClient,
-			Namespace:    ns,
-			LogWriter: &CLILogWriter{
-				CommonOptions: o.CommonOptions,
-			},
+			KubeClient:     kubeClient,
+			TektonClient:   tektonClient,
+			JXClient:       jxClient,
+			Namespace:      ns,
+			LogWriter:      logWriter,
 			FailIfPodFails: o.FailIfPodFails,
+			Structured:     structured,
+			NoFollow:       !o.Follow,
+			Stage:          o.Stage,
 		}
 	}
 	var waitableCondition bool
@@ -235,7 +282,9 @@ func (o *GetBuildLogsOptions) getTektonLogs(kubeClient kubernetes.Interface, tek
 		return false, o.TektonLogger.StreamPipelinePersistentLogs(pa.Spec.BuildLogsURL, jxClient, ns, authSvc)
 	}
 
-	log.Logger().Infof("Build logs for %s", util.ColorInfo(name))
+	if o.Format != logFormatJSON {
+		log.Logger().Infof("Build logs for %s", util.ColorInfo(name))
+	}
 	name = strings.TrimSuffix(name, " ")
 	return false, o.TektonLogger.GetRunningBuildLogs(pa, name, false)
 }
@@ -248,7 +297,18 @@ func (o *CLILogWriter) StreamLog(lch <-chan logs.LogLine, ech <-chan error) erro
 			if !ok {
 				return nil
 			}
-			fmt.Println(l.Line)
+			if o.LogMasker != nil && l.ShouldMask {
+				l.Line = o.LogMasker.MaskLog(l.Line)
+			}
+			if o.Formatter == nil {
+				fmt.Println(l.Line)
+				continue
+			}
+			text, err := o.Formatter.Format(l)
+			if err != nil {
+				return err
+			}
+			fmt.Println(text)
 		case err := <-ech:
 			return err
 		}
@@ -2,10 +2,12 @@ package get
 
 import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -14,6 +16,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 )
 
@@ -40,8 +43,10 @@ type GetLimitsOptions struct {
 
 var (
 	get_limits_long = templates.LongDesc(`
-		Display the github limits for users
+		Display the remaining API quota of the git users of the GitHub, GitHub Enterprise, GitLab and Gitea servers
 
+		The git providers wait for the quota to reset when it is nearly exhausted so a low remaining quota explains
+		slow promotions and pipeline status reporting.
 `)
 
 	get_limits_example = templates.Examples(`
@@ -92,7 +97,8 @@ func (o *GetLimitsOptions) Run() error {
 			kind = "github"
 		}
 
-		if kind == "github" {
+		switch kind {
+		case gits.KindGitHub:
 			for _, u := range s.Users {
 				r, err := o.GetLimits(s.URL, u.Username, u.ApiToken)
 				if err != nil {
@@ -108,23 +114,45 @@ func (o *GetLimitsOptions) Run() error {
 
 				table.AddRow(s.Name, s.URL, u.Username, strconv.Itoa(r.Resources.Core.Limit), strconv.Itoa(r.Resources.Core.Remaining), resetLabel)
 			}
-		}
+		case gits.KindGitlab, gits.KindGitea:
+			for _, u := range s.Users {
+				r, err := o.GetHeaderLimits(kind, s.URL, u)
+				if err != nil {
+					return err
+				}
+				if r == nil {
+					table.AddRow(s.Name, s.URL, u.Username, "unlimited", "", "")
+					continue
+				}
 
+				resetLabel := ""
+				if !r.Reset.IsZero() {
+					resetLabel = time.Until(r.Reset).Round(time.Second).String()
+				}
+
+				table.AddRow(s.Name, s.URL, u.Username, strconv.Itoa(r.Limit), strconv.Itoa(r.Remaining), resetLabel)
+			}
+		}
 	}
 	table.Render()
 
 	return nil
 }
 
+// GetLimits returns the rate limits of the user of the GitHub or GitHub Enterprise server
 func (o *GetLimitsOptions) GetLimits(server string, username string, apitoken string) (RateLimits, error) {
-	url := fmt.Sprintf("https://%s:%s@api.github.com/rate_limit", username, apitoken)
+	u := "https://api.github.com/rate_limit"
+	if !gits.IsGitHubServerURL(server) {
+		u = util.UrlJoin(gits.GitHubEnterpriseApiEndpointURL(server), "rate_limit")
+	}
 
 	// Build the request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		log.Logger().Errorf("NewRequest: %s", err)
 		return RateLimits{}, err
 	}
+	req.SetBasicAuth(username, apitoken)
 
 	// For control over HTTP client headers,
 	// redirect policy, and other settings,
@@ -156,3 +184,31 @@ func (o *GetLimitsOptions) GetLimits(server string, username string, apitoken st
 
 	return limits, nil
 }
+
+// GetHeaderLimits returns the rate limit the GitLab or Gitea server reports in the headers of the response to a
+// request for the current user or nil if it has no rate limit
+func (o *GetLimitsOptions) GetHeaderLimits(kind string, server string, user *auth.UserAuth) (*gits.RateLimit, error) {
+	u := util.UrlJoin(server, "api/v1/user")
+	if kind == gits.KindGitlab {
+		u = util.UrlJoin(server, "api/v4/user")
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating request for server %s", server)
+	}
+	if kind == gits.KindGitlab {
+		req.Header.Set("Private-Token", user.ApiToken)
+	} else {
+		req.Header.Set("Authorization", "token "+user.ApiToken)
+	}
+
+	resp, err := gits.WithRateLimits(nil).Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the current user of server %s", server)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("getting the current user %s of server %s returned status %d", user.Username, server, resp.StatusCode)
+	}
+	return gits.ParseRateLimit(req.URL.Host, resp.Header, time.Now()), nil
+}
@@ -0,0 +1,86 @@
+package get
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/spf13/cobra"
+)
+
+// GetQuickstartCatalogOptions containers the CLI options
+type GetQuickstartCatalogOptions struct {
+	GetOptions
+}
+
+var (
+	getQuickstartCatalogLong = templates.LongDesc(`
+		Display the Quickstart Catalogs for the current Team.
+
+`)
+
+	getQuickstartCatalogExample = templates.Examples(`
+		# List all the quickstart catalogs
+		jx get quickstartcatalogs
+
+		# List all the quickstart catalogs via an alias
+		jx get qscatalog
+
+	`)
+)
+
+// NewCmdGetQuickstartCatalog creates the new command for: jx get quickstartcatalogs
+func NewCmdGetQuickstartCatalog(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetQuickstartCatalogOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     opts.QuickStartCatalogCommandName,
+		Short:   "Display the Quickstart Catalogs",
+		Aliases: opts.QuickStartCatalogCommandAliases,
+		Long:    getQuickstartCatalogLong,
+		Example: getQuickstartCatalogExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetQuickstartCatalogOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	catalogs, err := kube.GetQuickstartCatalogs(jxClient, ns)
+	if err != nil {
+		return err
+	}
+
+	table := o.CreateTable()
+	table.AddRow("NAME", "GIT URL", "KIND", "REF", "PATH")
+
+	for _, catalog := range catalogs {
+		ref := catalog.Ref
+		if ref == "" {
+			ref = "master"
+		}
+		path := catalog.Path
+		if path == "" {
+			path = versionstream.QuickStartsFileName
+		}
+		table.AddRow(catalog.Name, catalog.GitURL, catalog.GitKind, ref, path)
+	}
+	table.Render()
+	return nil
+}
@@ -73,6 +73,7 @@ type ImportOptions struct {
 	DeployKind              string
 	DeployOptions           v1.DeployOptions
 	SchedulerName           string
+	Subdirs                 []string
 
 	DisableDotGitSearch   bool
 	InitialisedGit        bool
@@ -89,7 +90,8 @@ type ImportOptions struct {
 	UseDefaultGit         bool
 	GithubAppInstalled    bool
 
-	reporter ImportReporter
+	reporter     ImportReporter
+	monorepoApps []v1.SourceRepositoryApp
 }
 
 var (
@@ -123,6 +125,9 @@ var (
 
         # Import all repositories from a GitHub organisation which contain the text foo
 		jx import --github --org myname --all --filter foo 
+
+		# Import the applications of a monorepo which are each built and released when their directory changes
+		jx import --subdir services/foo --subdir services/bar
 		`)
 
 	deployKinds = []string{opts.DeployKindKnative, opts.DeployKindDefault}
@@ -181,6 +186,7 @@ func (options *ImportOptions) AddImportFlags(cmd *cobra.Command, createProject b
 	cmd.Flags().BoolVarP(&options.ListDraftPacks, "list-packs", "", false, "list available draft packs")
 	cmd.Flags().StringVarP(&options.DraftPack, "pack", "", "", "The name of the pack to use")
 	cmd.Flags().StringVarP(&options.SchedulerName, "scheduler", "", "", "The name of the Scheduler configuration to use for ChatOps when using Prow")
+	cmd.Flags().StringArrayVarP(&options.Subdirs, "subdir", "", nil, "The subdirectory of an application of a monorepo to import. Can be specified multiple times to import multiple applications")
 	cmd.Flags().StringVarP(&options.DockerRegistryOrg, "docker-registry-org", "", "", "The name of the docker registry organisation to use. If not specified then the Git provider organisation will be used")
 	cmd.Flags().StringVarP(&options.ExternalJenkinsBaseURL, "external-jenkins-url", "", "", "The jenkins url that an external git provider needs to use")
 	cmd.Flags().BoolVarP(&options.DisableMaven, "disable-updatebot", "", false, "disable updatebot-maven-plugin from attempting to fix/update the maven pom.xml")
@@ -381,7 +387,12 @@ func (options *ImportOptions) Run() error {
 	}
 	options.AppName = naming.ToValidName(strings.ToLower(options.AppName))
 
-	if !options.DisableDraft {
+	if len(options.Subdirs) > 0 {
+		err = options.importMonorepoApps()
+		if err != nil {
+			return err
+		}
+	} else if !options.DisableDraft {
 		err = options.DraftCreate()
 		if err != nil {
 			return err
@@ -973,11 +984,18 @@ func (options *ImportOptions) doImport() error {
 			return err
 		}
 	}
+	err = options.ensureMonorepoDockerRepositoriesExist()
+	if err != nil {
+		return err
+	}
 
 	isProw, err := options.IsProw()
 	if err != nil {
 		return err
 	}
+	if len(options.Subdirs) > 0 && !isProw {
+		return fmt.Errorf("importing the applications of a monorepo is only supported when using Prow or Lighthouse")
+	}
 
 	githubAppMode, err := options.IsGitHubAppMode()
 	if err != nil {
@@ -1040,6 +1058,7 @@ func (options *ImportOptions) addProwConfig(gitURL string, gitKind string) error
 			if sr.Spec.SSHCloneURL == "" {
 				sr.Spec.SSHCloneURL = gitInfo.SSHURL
 			}
+			sr.Spec.Apps = addSourceRepositoryApps(sr.Spec.Apps, options.monorepoApps)
 		}
 		sr, err := kube.GetOrCreateSourceRepositoryCallback(jxClient, currentNamespace, gitInfo.Name, gitInfo.Organisation, gitInfo.HostURLWithoutUser(), callback)
 		log.Logger().Debugf("have SourceRepository: %s\n", sr.Name)
@@ -1095,6 +1114,9 @@ func (options *ImportOptions) addProwConfig(gitURL string, gitKind string) error
 			return err
 		}
 	} else {
+		if len(options.monorepoApps) > 0 {
+			return fmt.Errorf("importing the applications of a monorepo requires the team to use Schedulers")
+		}
 		err = prow.AddApplication(client, []string{repo}, currentNamespace, options.DraftPack, settings)
 		if err != nil {
 			return err
@@ -1102,13 +1124,23 @@ func (options *ImportOptions) addProwConfig(gitURL string, gitKind string) error
 	}
 
 	if !gha {
-		startBuildOptions := start.StartPipelineOptions{
-			CommonOptions: options.CommonOptions,
+		contexts := []string{""}
+		if len(options.monorepoApps) > 0 {
+			contexts = []string{}
+			for _, app := range options.monorepoApps {
+				contexts = append(contexts, app.Name)
+			}
 		}
-		startBuildOptions.Args = []string{fmt.Sprintf("%s/%s/%s", gitInfo.Organisation, gitInfo.Name, opts.MasterBranch)}
-		err = startBuildOptions.Run()
-		if err != nil {
-			return fmt.Errorf("failed to start pipeline build: %s", err)
+		for _, context := range contexts {
+			startBuildOptions := start.StartPipelineOptions{
+				CommonOptions: options.CommonOptions,
+				Context:       context,
+			}
+			startBuildOptions.Args = []string{fmt.Sprintf("%s/%s/%s", gitInfo.Organisation, gitInfo.Name, opts.MasterBranch)}
+			err = startBuildOptions.Run()
+			if err != nil {
+				return fmt.Errorf("failed to start pipeline build: %s", err)
+			}
 		}
 	}
 
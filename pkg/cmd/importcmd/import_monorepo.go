@@ -0,0 +1,186 @@
+package importcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// importMonorepoApps applies a build pack to each application in the subdirectories of a monorepo and moves the
+// pipeline of each application into the root of the repository so that it can be triggered with its own context
+func (options *ImportOptions) importMonorepoApps() error {
+	apps, err := monorepoApps(options.Subdirs)
+	if err != nil {
+		return err
+	}
+	options.monorepoApps = apps
+
+	rootDir := options.Dir
+	appName := options.AppName
+	draftPack := options.DraftPack
+	defer func() {
+		options.Dir = rootDir
+		options.AppName = appName
+	}()
+	for _, app := range apps {
+		options.Dir = filepath.Join(rootDir, filepath.FromSlash(app.Path))
+		options.AppName = app.Name
+		exists, err := util.DirExists(options.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if the directory %s exists", options.Dir)
+		}
+		if !exists {
+			return fmt.Errorf("the directory %s of application %s does not exist", options.Dir, app.Name)
+		}
+		if !options.DisableDraft {
+			// lets detect the build pack of each application unless one was specified
+			options.DraftPack = draftPack
+			err = options.DraftCreate()
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply the build pack to application %s", app.Name)
+			}
+		}
+		err = options.fixDockerIgnoreFile()
+		if err != nil {
+			return err
+		}
+		err = writeMonorepoAppPipeline(rootDir, app)
+		if err != nil {
+			return err
+		}
+	}
+
+	options.Dir = rootDir
+	options.AppName = appName
+	if !options.DisableDraft {
+		err = options.CreateProwOwnersFile()
+		if err != nil {
+			return err
+		}
+		err = options.CreateProwOwnersAliasesFile()
+		if err != nil {
+			return err
+		}
+	}
+	err = options.Git().Add(rootDir, "*")
+	if err != nil {
+		return err
+	}
+	return options.Git().CommitIfChanges(rootDir, "Add the pipelines of the monorepo applications")
+}
+
+// ensureMonorepoDockerRepositoriesExist lazily creates the docker repositories of the monorepo applications which
+// have a Dockerfile
+func (options *ImportOptions) ensureMonorepoDockerRepositoriesExist() error {
+	rootDir := options.Dir
+	appName := options.AppName
+	defer func() {
+		options.Dir = rootDir
+		options.AppName = appName
+	}()
+	for _, app := range options.monorepoApps {
+		exists, err := util.FileExists(filepath.Join(rootDir, filepath.FromSlash(app.Path), "Dockerfile"))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		options.AppName = app.Name
+		err = options.ensureDockerRepositoryExists()
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the docker repository of application %s", app.Name)
+		}
+	}
+	return nil
+}
+
+// monorepoApps returns the applications of the subdirectories which are named after the last directory
+func monorepoApps(subdirs []string) ([]v1.SourceRepositoryApp, error) {
+	answer := []v1.SourceRepositoryApp{}
+	for _, subdir := range subdirs {
+		dir := filepath.ToSlash(filepath.Clean(subdir))
+		if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") || filepath.IsAbs(subdir) {
+			return nil, fmt.Errorf("the subdirectory %s must be relative to the root of the repository", subdir)
+		}
+		name := naming.ToValidName(strings.ToLower(filepath.Base(dir)))
+		for _, app := range answer {
+			if app.Name == name {
+				return nil, fmt.Errorf("the subdirectories %s and %s have the same application name %s", app.Path, dir, name)
+			}
+		}
+		answer = append(answer, v1.SourceRepositoryApp{Name: name, Path: dir})
+	}
+	return answer, nil
+}
+
+// writeMonorepoAppPipeline moves the pipeline configuration of the application into the root of the repository
+// recording the directory of the application so that the release steps run in it
+func writeMonorepoAppPipeline(rootDir string, app v1.SourceRepositoryApp) error {
+	appFile := filepath.Join(rootDir, filepath.FromSlash(app.Path), config.ProjectConfigFileName)
+	projectConfig, err := config.LoadProjectConfigFile(appFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the pipeline of application %s", app.Name)
+	}
+	projectConfig.MonorepoApp = &config.MonorepoAppConfig{
+		Name: app.Name,
+		Dir:  app.Path,
+	}
+	pipelineConfig := projectConfig.GetOrCreatePipelineConfig()
+	if kube.GetSliceEnvVar(pipelineConfig.Env, "APP_NAME") == nil {
+		pipelineConfig.Env = append(pipelineConfig.Env, corev1.EnvVar{
+			Name:  "APP_NAME",
+			Value: app.Name,
+		})
+	}
+
+	fileName := filepath.Join(rootDir, pipelinescheduler.MonorepoPipelineFileName(app.Name))
+	err = projectConfig.SaveConfig(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the pipeline of application %s", app.Name)
+	}
+	exists, err := util.FileExists(appFile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = os.Remove(appFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove %s", appFile)
+		}
+	}
+	return nil
+}
+
+// addSourceRepositoryApps adds the applications to the applications of the SourceRepository replacing any with the
+// same name
+func addSourceRepositoryApps(existing []v1.SourceRepositoryApp, apps []v1.SourceRepositoryApp) []v1.SourceRepositoryApp {
+	answer := append([]v1.SourceRepositoryApp{}, existing...)
+	for _, app := range apps {
+		found := false
+		for i := range answer {
+			if answer[i].Name == app.Name {
+				answer[i] = app
+				found = true
+				break
+			}
+		}
+		if !found {
+			answer = append(answer, app)
+		}
+	}
+	if len(answer) == 0 {
+		return nil
+	}
+	return answer
+}
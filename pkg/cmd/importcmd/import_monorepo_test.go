@@ -0,0 +1,80 @@
+// +build unit
+
+package importcmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonorepoApps(t *testing.T) {
+	t.Parallel()
+	apps, err := monorepoApps([]string{"services/foo", "./services/Bar/"})
+	require.NoError(t, err)
+	assert.Equal(t, []v1.SourceRepositoryApp{
+		{Name: "foo", Path: "services/foo"},
+		{Name: "bar", Path: "services/Bar"},
+	}, apps)
+
+	_, err = monorepoApps([]string{"services/foo", "libs/foo"})
+	assert.Error(t, err, "the application names should be unique")
+
+	_, err = monorepoApps([]string{"../foo"})
+	assert.Error(t, err, "the subdirectories should be inside the repository")
+}
+
+func TestWriteMonorepoAppPipeline(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-import-monorepo")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	appDir := filepath.Join(dir, "services", "foo")
+	require.NoError(t, os.MkdirAll(appDir, util.DefaultWritePermissions))
+	appFile := filepath.Join(appDir, config.ProjectConfigFileName)
+	require.NoError(t, ioutil.WriteFile(appFile, []byte("buildPack: go\n"), util.DefaultWritePermissions))
+
+	err = writeMonorepoAppPipeline(dir, v1.SourceRepositoryApp{Name: "foo", Path: "services/foo"})
+	require.NoError(t, err)
+
+	exists, err := util.FileExists(appFile)
+	require.NoError(t, err)
+	assert.False(t, exists, "the pipeline should be moved out of the application directory")
+
+	projectConfig, err := config.LoadProjectConfigFile(filepath.Join(dir, "jenkins-x-foo.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "go", projectConfig.BuildPack)
+	assert.Equal(t, &config.MonorepoAppConfig{Name: "foo", Dir: "services/foo"}, projectConfig.MonorepoApp)
+	require.NotNil(t, projectConfig.PipelineConfig)
+	appName := kube.GetSliceEnvVar(projectConfig.PipelineConfig.Env, "APP_NAME")
+	require.NotNil(t, appName)
+	assert.Equal(t, "foo", appName.Value)
+}
+
+func TestAddSourceRepositoryApps(t *testing.T) {
+	t.Parallel()
+	existing := []v1.SourceRepositoryApp{
+		{Name: "foo", Path: "foo"},
+		{Name: "bar", Path: "bar"},
+	}
+	apps := addSourceRepositoryApps(existing, []v1.SourceRepositoryApp{
+		{Name: "foo", Path: "services/foo"},
+		{Name: "baz", Path: "services/baz"},
+	})
+	assert.Equal(t, []v1.SourceRepositoryApp{
+		{Name: "foo", Path: "services/foo"},
+		{Name: "bar", Path: "bar"},
+		{Name: "baz", Path: "services/baz"},
+	}, apps)
+	assert.Equal(t, "foo", existing[0].Path, "the existing applications should not be modified")
+	assert.Nil(t, addSourceRepositoryApps(nil, nil))
+}
@@ -73,6 +73,7 @@ const (
 
 	BranchPatternCommandName      = "branchpattern"
 	QuickStartLocationCommandName = "quickstartlocation"
+	QuickStartCatalogCommandName  = "quickstartcatalog"
 
 	// LogInfo info level logging
 	LogInfo LogLevel = "INFO"
@@ -92,6 +93,10 @@ var (
 	QuickStartLocationCommandAliases = []string{
 		QuickStartLocationCommandName + "s", "quickstartloc", "qsloc",
 	}
+
+	QuickStartCatalogCommandAliases = []string{
+		QuickStartCatalogCommandName + "s", "qscatalog",
+	}
 )
 
 // ModifyDevEnvironmentFn a callback to create/update the development Environment
@@ -128,6 +133,7 @@ type CommonOptions struct {
 
 	apiExtensionsClient apiextensionsclientset.Interface
 	certManagerClient   certmngclient.Interface
+	chartRepoMirrors    []config.ChartRepositoryMirror
 	complianceClient    *client.SonobuoyClient
 	currentNamespace    string
 	devNamespace        string
@@ -1047,6 +1053,27 @@ func (o *CommonOptions) VaultClient(name string, namespace string) (vault.Client
 	return o.vaultClient, nil
 }
 
+// NewLogMasker creates a LogMasker for the secrets of the namespace and, when the secrets are stored in Vault, the
+// Vault secrets which are resolved into the pipelines
+func (o *CommonOptions) NewLogMasker(kubeClient kubernetes.Interface, ns string) (*kube.LogMasker, error) {
+	masker, err := kube.NewLogMasker(kubeClient, ns)
+	if err != nil {
+		return masker, err
+	}
+	if o.GetSecretsLocation() != secrets.VaultLocationKind {
+		return masker, nil
+	}
+	client, err := o.SystemVaultClient(ns)
+	if err != nil {
+		return masker, errors.Wrap(err, "creating the system vault client")
+	}
+	err = masker.LoadVaultSecrets(client, "")
+	if err != nil {
+		return masker, errors.Wrap(err, "loading the vault secrets")
+	}
+	return masker, nil
+}
+
 // GetSecretsLocation returns the location of the secrets
 func (o *CommonOptions) GetSecretsLocation() secrets.SecretsLocationKind {
 	if o.factory == nil {
@@ -1297,3 +1324,8 @@ func (o *CommonOptions) Kustomize() kustomize.Kustomizer {
 	}
 	return o.kustomizer
 }
+
+// SetKustomizer sets the kustomize client used for this object
+func (o *CommonOptions) SetKustomizer(kustomizer kustomize.Kustomizer) {
+	o.kustomizer = kustomizer
+}
@@ -14,6 +14,10 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/awssecretsmanager"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/azurekeyvault"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/externalsecrets"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/gcpsecretmanager"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl/localvault"
 	"github.com/pborman/uuid"
 
@@ -223,7 +227,7 @@ func (o *CommonOptions) InitHelm(config InitHelmConfig) error {
 		}
 	}
 
-	err = o.Helm().AddRepo("jenkins-x", kube.DefaultChartMuseumURL, "", "")
+	err = o.Helm().AddRepo("jenkins-x", o.MirrorChartRepositoryURL(kube.DefaultChartMuseumURL), "", "")
 	if err != nil {
 		return err
 	}
@@ -287,17 +291,46 @@ func (o *CommonOptions) RegisterLocalHelmRepo(repoName, ns string) error {
 // possible. The name of the repo (regardless of whether it was added or already there) is returned - this may well be
 // different from the requested name (if it's already there).
 func (o *CommonOptions) AddHelmBinaryRepoIfMissing(url, repoName, username, password string) (string, error) {
-	vaultClient, err := o.SystemVaultClient("")
-	if err != nil {
-		vaultClient = nil
+	var vaultClient secreturl.Client
+	// lets not access the Vault of the development cluster when using a remote cluster
+	if !o.RemoteCluster {
+		client, err := o.SystemVaultClient("")
+		if err == nil {
+			vaultClient = client
+		}
 	}
-	name, err := helm.AddHelmRepoIfMissing(url, repoName, username, password, o.Helm(), vaultClient, o.GetIOFileHandles())
+	name, err := helm.AddHelmRepoIfMissing(o.MirrorChartRepositoryURL(url), repoName, username, password, o.Helm(), vaultClient, o.GetIOFileHandles())
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 	return name, nil
 }
 
+// SetChartRepositoryMirrors sets the mirrors used instead of the upstream chart repositories
+func (o *CommonOptions) SetChartRepositoryMirrors(mirrors []config.ChartRepositoryMirror) {
+	if mirrors == nil {
+		mirrors = []config.ChartRepositoryMirror{}
+	}
+	o.chartRepoMirrors = mirrors
+}
+
+// MirrorChartRepositoryURL returns the URL of the mirror of the given chart repository or the URL itself if it is not
+// mirrored. Unless they have been set the mirrors are loaded from the 'jx-requirements.yml' of the current directory
+func (o *CommonOptions) MirrorChartRepositoryURL(repoURL string) string {
+	if o.chartRepoMirrors == nil {
+		requirements, _, err := config.LoadRequirementsConfig("")
+		if err != nil {
+			requirements = config.NewRequirementsConfig()
+		}
+		o.SetChartRepositoryMirrors(requirements.Mirrors)
+	}
+	mirrorURL := config.MirrorChartRepositoryURL(o.chartRepoMirrors, repoURL)
+	if mirrorURL != repoURL {
+		log.Logger().Debugf("using the mirror %s of the chart repository %s", mirrorURL, repoURL)
+	}
+	return mirrorURL
+}
+
 // InstallChartOrGitOps if using gitOps lets write files otherwise lets use helm
 func (o *CommonOptions) InstallChartOrGitOps(isGitOps bool, gitOpsEnvDir string, releaseName string, chart string, alias string, version string, ns string, helmUpdate bool,
 	setValues []string, setSecrets []string, valueFiles []string, repo string) error {
@@ -401,7 +434,7 @@ func (o *CommonOptions) InstallChartWithOptionsAndTimeout(options helm.InstallCh
 			return err
 		}
 	}
-	secretURLClient, err := o.GetSecretURLClient(secrets.AutoLocationKind)
+	secretURLClient, err := o.GetSecretURLClient(secrets.AutoLocationKind, "")
 	if err != nil {
 		return errors.Wrap(err, "failed to create a Secret RL client")
 	}
@@ -409,8 +442,9 @@ func (o *CommonOptions) InstallChartWithOptionsAndTimeout(options helm.InstallCh
 }
 
 // GetSecretURLClient create a new secret URL client base on a given secrets location. If the location is auto,
-// it will try to determine dynamically if is vault or local file system
-func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind) (secreturl.Client, error) {
+// it will try to determine dynamically if is vault or local file system. The clients configured via the requirements
+// load them from the given directory such as the boot directory
+func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind, dir string) (secreturl.Client, error) {
 	if o.secretURLClient != nil {
 		return o.secretURLClient, nil
 	}
@@ -427,9 +461,24 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 			return o.secretURLClient, errors.Wrapf(err, "getting the file system secrets directory")
 		}
 		o.secretURLClient = localvault.NewFileSystemClient(dir)
+	case secrets.AWSSecretsManagerLocationKind, secrets.GCPSecretManagerLocationKind, secrets.AzureKeyVaultLocationKind:
+		o.secretURLClient, err = o.secretBackendClient(location, dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating %s URL client", location)
+		}
+	case secrets.ExternalSecretsLocationKind:
+		requirements, _, err := config.LoadRequirementsConfig(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the requirements")
+		}
+		client, err := externalsecrets.NewClient(requirements.ExternalSecrets)
+		if err != nil {
+			return nil, err
+		}
+		o.secretURLClient = client
 	case secrets.AutoLocationKind:
 		location := o.detectSecretsLocation()
-		o.secretURLClient, err = o.GetSecretURLClient(location)
+		o.secretURLClient, err = o.GetSecretURLClient(location, dir)
 	default:
 		return nil, fmt.Errorf("secrets location %q is not supported", location)
 	}
@@ -437,6 +486,35 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 	return o.secretURLClient, err
 }
 
+// secretBackendClient creates the secret URL client of the cloud secret manager using the secretBackend settings of
+// the requirements in the given directory, defaulting the region and project to those of the cluster
+func (o *CommonOptions) secretBackendClient(location secrets.SecretsLocationKind, dir string) (secreturl.Client, error) {
+	requirements, _, err := config.LoadRequirementsConfig(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the requirements")
+	}
+	backend := config.SecretBackendConfig{}
+	if requirements.SecretBackend != nil {
+		backend = *requirements.SecretBackend
+	}
+	switch location {
+	case secrets.AWSSecretsManagerLocationKind:
+		region := backend.Region
+		if region == "" {
+			region = requirements.Cluster.Region
+		}
+		return awssecretsmanager.NewClient(region, backend.Prefix)
+	case secrets.GCPSecretManagerLocationKind:
+		project := backend.Project
+		if project == "" {
+			project = requirements.Cluster.ProjectID
+		}
+		return gcpsecretmanager.NewClient(project, backend.Prefix), nil
+	default:
+		return azurekeyvault.NewClient(backend.KeyVault, backend.Prefix)
+	}
+}
+
 // detectSecretsLocation detects dynamically the secrets location by trying to create a vault client
 func (o *CommonOptions) detectSecretsLocation() secrets.SecretsLocationKind {
 	_, err := o.SystemVaultClient(o.devNamespace)
@@ -567,6 +645,12 @@ func (o *CommonOptions) AddChartRepos(dir string, helmBinary string, chartRepos
 			}
 			for _, dep := range requirements.Dependencies {
 				repo := dep.Repository
+				// lets fetch the dependencies from the mirror of their chart repository
+				if mirrorURL := o.MirrorChartRepositoryURL(repo); mirrorURL != repo {
+					repo = mirrorURL
+					dep.Repository = repo
+					changed = true
+				}
 				if repo != "" && !util.StringMapHasValue(installedChartRepos, repo) && repo != DefaultChartRepo && !strings.HasPrefix(repo, "file:") && !strings.HasPrefix(repo, "alias:") && !strings.HasPrefix(repo, "@") {
 					name, err := o.AddHelmBinaryRepoIfMissing(repo, "", "", "")
 					if err != nil {
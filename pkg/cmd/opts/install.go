@@ -142,7 +142,7 @@ func (o *CommonOptions) InstallGlooctl() error {
 		suffix += ".exe"
 	}
 	clientURL := fmt.Sprintf("https://github.com/solo-io/gloo/releases/download/v%v/glooctl-%s-%s", packages.GlooVersion, runtime.GOOS, suffix)
-	fullPath := filepath.Join(binDir, fileName)
+	fullPath := filepath.Join(binDir, packages.BinaryWithExtension(fileName))
 	tmpFile := fullPath + ".tmp"
 	err = packages.DownloadFile(clientURL, tmpFile)
 	if err != nil {
@@ -162,7 +162,7 @@ func (o *CommonOptions) InstallKustomize() error {
 		return errors.Wrapf(err, "unable to find JXBinLocation")
 	}
 
-	fullBinaryPath := filepath.Join(binDir, "kustomize")
+	fullBinaryPath := filepath.Join(binDir, packages.BinaryWithExtension("kustomize"))
 	exists, err := util.FileExists(fullBinaryPath)
 	if err != nil {
 		return errors.Wrapf(err, "unable to verify if binary exists")
@@ -186,7 +186,7 @@ func (o *CommonOptions) InstallKustomize() error {
 		}
 	}()
 
-	fullPath := filepath.Join(binDir, "kustomize")
+	fullPath := filepath.Join(binDir, packages.BinaryWithExtension("kustomize"))
 	tarFile := filepath.Join(tmpDir, "kustomize.tar.gz")
 	defer func() {
 		err = os.Remove(tarFile)
@@ -199,12 +199,12 @@ func (o *CommonOptions) InstallKustomize() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to Download File")
 	}
-	err = util.UnTargz(tarFile, tmpDir, []string{"kustomize"})
+	err = util.UnTargz(tarFile, tmpDir, []string{packages.BinaryWithExtension("kustomize")})
 	if err != nil {
 		return errors.Wrapf(err, "failed to Un-tar file")
 	}
 
-	err = os.Rename(filepath.Join(tmpDir, "kustomize"), fullPath)
+	err = os.Rename(filepath.Join(tmpDir, packages.BinaryWithExtension("kustomize")), fullPath)
 	if err != nil {
 		return errors.Wrapf(err, "failed to rename file")
 	}
@@ -225,14 +225,14 @@ func (o *CommonOptions) InstallHelm() error {
 		return err
 	}
 
-	clientURL := fmt.Sprintf("https://get.helm.sh/helm-v%s-%s-%s.tar.gz", packages.Helm2Version, runtime.GOOS, runtime.GOARCH)
-	fullPath := filepath.Join(binDir, binary)
+	clientURL := helmArchiveURL(packages.Helm2Version)
+	fullPath := filepath.Join(binDir, packages.BinaryWithExtension(binary))
 	tarFile := fullPath + ".tgz"
 	err = packages.DownloadFile(clientURL, tarFile)
 	if err != nil {
 		return err
 	}
-	err = util.UnTargz(tarFile, binDir, []string{binary, binary})
+	err = extractHelmBinary(tarFile, binDir)
 	if err != nil {
 		return err
 	}
@@ -298,20 +298,20 @@ func (o *CommonOptions) InstallHelm3() error {
 		return err
 	}
 
-	clientURL := fmt.Sprintf("https://get.helm.sh/helm-v%v-%s-%s.tar.gz", packages.Helm3Version, runtime.GOOS, runtime.GOARCH)
+	clientURL := helmArchiveURL(packages.Helm3Version)
 
 	tmpDir := filepath.Join(binDir, "helm3.tmp")
 	err = os.MkdirAll(tmpDir, util.DefaultWritePermissions)
 	if err != nil {
 		return err
 	}
-	fullPath := filepath.Join(binDir, binary)
+	fullPath := filepath.Join(binDir, packages.BinaryWithExtension(binary))
 	tarFile := filepath.Join(tmpDir, binary+".tgz")
 	err = packages.DownloadFile(clientURL, tarFile)
 	if err != nil {
 		return err
 	}
-	err = util.UnTargz(tarFile, tmpDir, []string{"helm", "helm"})
+	err = extractHelmBinary(tarFile, tmpDir)
 	if err != nil {
 		return err
 	}
@@ -319,7 +319,7 @@ func (o *CommonOptions) InstallHelm3() error {
 	if err != nil {
 		return err
 	}
-	err = os.Rename(filepath.Join(tmpDir, "helm"), fullPath)
+	err = os.Rename(filepath.Join(tmpDir, packages.BinaryWithExtension("helm")), fullPath)
 	if err != nil {
 		return err
 	}
@@ -335,6 +335,34 @@ func (o *CommonOptions) InstallHelm3() error {
 	return o.installHelmSecretsPlugin(fullPath, false)
 }
 
+// helmArchiveURL returns the URL of the helm release archive for the current platform which is a zip on Windows
+func helmArchiveURL(version string) string {
+	extension := "tar.gz"
+	if runtime.GOOS == "windows" {
+		extension = "zip"
+	}
+	return fmt.Sprintf("https://get.helm.sh/helm-v%s-%s-%s.%s", version, runtime.GOOS, runtime.GOARCH, extension)
+}
+
+// extractHelmBinary extracts the helm executable from the release archive into the directory. The Windows zip
+// archives contain 'helm.exe' in a directory named after the platform
+func extractHelmBinary(archive string, dir string) error {
+	fileName := packages.BinaryWithExtension("helm")
+	if runtime.GOOS != "windows" {
+		return util.UnTargz(archive, dir, []string{fileName})
+	}
+	platformDir := runtime.GOOS + "-" + runtime.GOARCH
+	err := util.UnzipSpecificFiles(archive, dir, platformDir+"/"+fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unzip %s", archive)
+	}
+	err = os.Rename(filepath.Join(dir, platformDir, fileName), filepath.Join(dir, fileName))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(dir, platformDir))
+}
+
 func (o *CommonOptions) installHelmSecretsPlugin(helmBinary string, clientOnly bool) error {
 	log.Logger().Infof("Installing %s", util.ColorInfo("helm secrets plugin"))
 	var err error
@@ -1000,7 +1028,7 @@ func (o *CommonOptions) installExternalDNSGKE() error {
 		return errors.Wrap(err, "failed to get clusterName")
 	}
 
-	err = o.helm.AddRepo(kube.ChartOwnerExternalDNS, kube.ChartURLExternalDNS, "", "")
+	err = o.helm.AddRepo(kube.ChartOwnerExternalDNS, o.MirrorChartRepositoryURL(kube.ChartURLExternalDNS), "", "")
 	if err != nil {
 		return errors.Wrapf(err, "adding helm repo")
 	}
@@ -2,6 +2,9 @@ package opts
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
 	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
@@ -10,6 +13,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/quickstarts"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 	"github.com/pkg/errors"
 )
@@ -45,9 +49,101 @@ func (o *CommonOptions) LoadQuickStartsModel(gitHubOrganisations []string, ignor
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading quickstarts: %v", quickstarts)
 	}
+	if !ignoreTeam {
+		jxClient, ns, err := o.JXClientAndDevNamespace()
+		if err != nil {
+			return nil, err
+		}
+		catalogs, err := kube.GetQuickstartCatalogs(jxClient, ns)
+		if err != nil {
+			return nil, err
+		}
+		o.LoadQuickStartsFromCatalogs(model, catalogs, config)
+	}
 	return model, nil
 }
 
+// LoadQuickStartsFromCatalogs loads the quickstarts from the index of each catalog git repository into the model
+func (o *CommonOptions) LoadQuickStartsFromCatalogs(model *quickstarts.QuickstartModel, catalogs []v1.QuickstartCatalog, config *auth.AuthConfig) {
+	for _, catalog := range catalogs {
+		err := o.loadQuickStartCatalog(model, catalog, config)
+		if err != nil {
+			log.Logger().Warnf("failed to load the quickstarts of catalog %s: %s", catalog.GitURL, err.Error())
+		}
+	}
+}
+
+// loadQuickStartCatalog clones the catalog git repository using the credentials of its git server, if there are
+// any, so that private catalogs and quickstarts can be used
+func (o *CommonOptions) loadQuickStartCatalog(model *quickstarts.QuickstartModel, catalog v1.QuickstartCatalog, config *auth.AuthConfig) error {
+	gitInfo, err := gits.ParseGitURL(catalog.GitURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse git URL %s", catalog.GitURL)
+	}
+	serverURL := gitInfo.HostURLWithoutUser()
+	server := config.GetServer(serverURL)
+	kind := catalog.GitKind
+	if kind == "" && server != nil {
+		kind = server.Kind
+	}
+	if kind == "" {
+		kind = gits.KindGitHub
+	}
+
+	cloneURL := catalog.GitURL
+	var gitProvider gits.GitProvider
+	if server != nil {
+		userAuth := config.CurrentUser(server, o.InCluster())
+		if userAuth != nil && !userAuth.IsInvalid() {
+			cloneURL, err = o.Git().CreateAuthenticatedURL(catalog.GitURL, userAuth)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create the authenticated URL of %s", catalog.GitURL)
+			}
+			gitProvider, err = o.GitProviderForGitServerURL(serverURL, kind, "")
+			if err != nil {
+				return errors.Wrapf(err, "failed to create the git provider for %s", serverURL)
+			}
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "jx-quickstart-catalog-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	ref := catalog.Ref
+	if ref == "" {
+		ref = "master"
+	}
+	cloneDir := filepath.Join(dir, "catalog")
+	err = o.Git().ShallowClone(cloneDir, cloneURL, ref, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone %s ref %s", catalog.GitURL, ref)
+	}
+	path := catalog.Path
+	if path == "" {
+		path = versionstream.QuickStartsFileName
+	}
+	fileName := filepath.Join(cloneDir, filepath.FromSlash(path))
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file %s exists", fileName)
+	}
+	if !exists {
+		return fmt.Errorf("the catalog does not contain the index file %s", path)
+	}
+	index, err := versionstream.LoadQuickStartsFile(fileName)
+	if err != nil {
+		return err
+	}
+	if index.DefaultOwner == "" {
+		index.DefaultOwner = gitInfo.Organisation
+	}
+	log.Logger().Debugf("Loaded %d quickstarts from catalog %s", len(index.QuickStarts), catalog.GitURL)
+	return model.LoadCatalogQuickStarts(index, gitProvider, serverURL, kind)
+}
+
 // LoadQuickStartsFromLocations Load all quickstarts from the given locatiotns
 func (o *CommonOptions) LoadQuickStartsFromLocations(locations []v1.QuickStartLocation, config *auth.AuthConfig) (*quickstarts.QuickstartModel, error) {
 	gitMap := map[string]map[string]v1.QuickStartLocation{}
@@ -0,0 +1,79 @@
+package step
+
+import (
+	"strconv"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// RunStepCommand runs the command with the CommandRunner of the step if there is one or without retrying it
+func (o *StepOptions) RunStepCommand(cmd *util.Command) (string, error) {
+	if o.CommandRunner != nil {
+		return o.CommandRunner(cmd)
+	}
+	return cmd.RunWithoutRetry()
+}
+
+// CommentOnPullRequest comments on the pull request of the repository. If the marker is not blank the previous comment
+// containing it is updated instead on the git providers which can edit comments
+func (o *StepOptions) CommentOnPullRequest(gitInfo *gits.GitRepository, pullRequest string, marker string, comment string) error {
+	prNumber, err := strconv.Atoi(pullRequest)
+	if err != nil {
+		return util.InvalidOptionError("pull-request", pullRequest, err)
+	}
+	provider, err := o.GitProviderForURL(gitInfo.URL, "user name to comment as")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the git provider for %s", gitInfo.URL)
+	}
+	pr := &gits.GitPullRequest{
+		Owner:  gitInfo.Organisation,
+		Repo:   gitInfo.Name,
+		Number: &prNumber,
+	}
+	if updater, ok := provider.(gits.PullRequestCommentUpdater); ok && marker != "" {
+		err = updater.UpdatePRComment(pr, marker, comment)
+	} else {
+		err = provider.AddPRComment(pr, comment)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to comment on Pull Request %d of %s/%s", prNumber, gitInfo.Organisation, gitInfo.Name)
+	}
+	return nil
+}
+
+// UpdatePipelineActivity applies the update to the PipelineActivity of the current build of the repository. The
+// description of what is stored is used in the logs
+func (o *StepOptions) UpdatePipelineActivity(gitInfo *gits.GitRepository, description string, update func(activity *v1.PipelineActivity)) error {
+	pipeline, build := o.GetPipelineName(gitInfo, "", "", "")
+	if pipeline == "" || build == "" {
+		log.Logger().Warnf("no pipeline name or build number so cannot store the %s in the PipelineActivity", description)
+		return nil
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "cannot create the JX client")
+	}
+	key := &kube.PipelineActivityKey{
+		Name:     naming.ToValidName(pipeline + "-" + build),
+		Pipeline: pipeline,
+		Build:    build,
+		GitInfo:  gitInfo,
+	}
+	a, _, err := key.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the PipelineActivity %s", key.Name)
+	}
+	update(a)
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(a)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the PipelineActivity %s", a.Name)
+	}
+	log.Logger().Infof("Stored the %s in PipelineActivity %s", description, util.ColorInfo(a.Name))
+	return nil
+}
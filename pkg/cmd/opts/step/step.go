@@ -1,6 +1,9 @@
 package step
 
-import "github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
 
 // GetOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
 // referencing the cmd.Flags()
@@ -9,6 +12,9 @@ type StepOptions struct {
 
 	DisableImport bool
 	OutDir        string
+
+	// CommandRunner runs the commands of the step, which tests replace, see RunStepCommand
+	CommandRunner func(*util.Command) (string, error)
 }
 
 // Run implements this command
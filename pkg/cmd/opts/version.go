@@ -1,9 +1,16 @@
 package opts
 
 import (
+	"fmt"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream/versionstreamrepo"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 
@@ -24,6 +31,110 @@ func (o *CommonOptions) CreateVersionResolver(repo string, gitRef string) (*vers
 	}, nil
 }
 
+// CreateCachedVersionResolver creates a new VersionResolver service using the version stream cached in the jx config dir.
+// The version stream is only fetched again once the cached clone is older than the TTL. In offline mode the cached clone
+// is always used and it is an error if there is none
+func (o *CommonOptions) CreateCachedVersionResolver(repo string, gitRef string, ttl time.Duration, offline bool) (*versionstream.VersionResolver, error) {
+	cache, err := versionstreamrepo.NewCache(ttl, offline)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Debugf("Unable to load team settings because %v", err)
+	}
+	versionsDir, _, err := cache.CloneJXVersionsRepo(repo, gitRef, settings, o.Git(), o.BatchMode, o.AdvancedMode, o.GetIOFileHandles())
+	if err != nil {
+		return nil, err
+	}
+	return &versionstream.VersionResolver{
+		VersionsDir: versionsDir,
+	}, nil
+}
+
+const (
+	// defaultVersionStreamUsername the user name used with a version stream token if no user name is configured
+	defaultVersionStreamUsername = "oauth2"
+	// defaultVersionStreamUsernameKey the key of the user name in a version stream secret
+	defaultVersionStreamUsernameKey = "username"
+	// defaultVersionStreamTokenKey the key of the token in a version stream secret
+	defaultVersionStreamTokenKey = "password"
+)
+
+// ResolveVersionStreamURL returns the URL used to clone the version stream. If the version stream has auth configured the
+// credentials are resolved from the environment variables and then the kubernetes secret and added to the HTTPS URL
+func (o *CommonOptions) ResolveVersionStreamURL(vs config.VersionStreamConfig) (string, error) {
+	auth := vs.Auth
+	if auth == nil || vs.URL == "" {
+		return vs.URL, nil
+	}
+	u, err := url.Parse(vs.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version stream URL %s", vs.URL)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("the version stream %s must use HTTPS to be cloned with auth", vs.URL)
+	}
+
+	username := auth.Username
+	if auth.UsernameEnv != "" && os.Getenv(auth.UsernameEnv) != "" {
+		username = os.Getenv(auth.UsernameEnv)
+	}
+	token := ""
+	if auth.TokenEnv != "" {
+		token = os.Getenv(auth.TokenEnv)
+	}
+	if token == "" && auth.Secret != nil {
+		secretUsername, secretToken, err := o.versionStreamSecretCredentials(auth.Secret)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to load the credentials of the version stream %s", vs.URL)
+		}
+		if username == "" {
+			username = secretUsername
+		}
+		token = secretToken
+	}
+	if token == "" {
+		if auth.TokenEnv != "" {
+			return "", fmt.Errorf("no token found for the version stream %s in the $%s environment variable", vs.URL, auth.TokenEnv)
+		}
+		return "", fmt.Errorf("no token or secret configured for the auth of the version stream %s", vs.URL)
+	}
+	if username == "" {
+		username = defaultVersionStreamUsername
+	}
+	u.User = url.UserPassword(username, token)
+	return u.String(), nil
+}
+
+// versionStreamSecretCredentials returns the user name and token in the kubernetes secret of a version stream
+func (o *CommonOptions) versionStreamSecretCredentials(ref *config.VersionStreamSecretRef) (string, string, error) {
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return "", "", err
+	}
+	if ref.Namespace != "" {
+		ns = ref.Namespace
+	}
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to find secret %s in namespace %s", ref.Name, ns)
+	}
+	usernameKey := ref.UsernameKey
+	if usernameKey == "" {
+		usernameKey = defaultVersionStreamUsernameKey
+	}
+	tokenKey := ref.TokenKey
+	if tokenKey == "" {
+		tokenKey = defaultVersionStreamTokenKey
+	}
+	token := string(secret.Data[tokenKey])
+	if token == "" {
+		return "", "", fmt.Errorf("the secret %s in namespace %s has no %s key", ref.Name, ns, tokenKey)
+	}
+	return string(secret.Data[usernameKey]), token, nil
+}
+
 // GetVersionResolver gets a VersionResolver, lazy creating one if required so we can reuse it later
 func (o *CommonOptions) GetVersionResolver() (*versionstream.VersionResolver, error) {
 	var err error
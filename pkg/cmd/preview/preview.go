@@ -49,6 +49,13 @@ var (
 	previewLong = templates.LongDesc(`
 		Creates or updates a Preview Environment for the given Pull Request or Branch.
 
+		If the preview chart contains a hooks.yaml file its preCreate hooks are run before the chart is installed to
+		provision any resources such as databases or queues. The YAML values the hooks write to the file in the
+		$PREVIEW_HOOK_OUTPUT environment variable are passed to the chart. The postDelete hooks are run when the
+		preview environment is deleted. As they are run by the garbage collection with its own credentials the
+		postDelete hooks are always loaded from the hooks.yaml file on the base branch of the Pull Request, never from
+		the Pull Request itself.
+
 		For more documentation on Preview Environments see: [https://jenkins-x.io/about/features/#preview-environments](https://jenkins-x.io/about/features/#preview-environments)
 
 `)
@@ -524,6 +531,16 @@ func (o *PreviewOptions) Run() error {
 		helmOptions.ValueFiles = append(helmOptions.ValueFiles, defaultValuesFileName)
 	}
 
+	// the outputs of the pre create hooks take precedence such as the URLs of the provisioned databases
+	hooksValuesFile, err := o.runPreCreateHooks(jxClient, ns, env, dir)
+	if err != nil {
+		return err
+	}
+	if hooksValuesFile != "" {
+		defer os.Remove(hooksValuesFile)
+		helmOptions.ValueFiles = append(helmOptions.ValueFiles, hooksValuesFile)
+	}
+
 	err = o.InstallChartWithOptions(helmOptions)
 	if err != nil {
 		return err
@@ -0,0 +1,289 @@
+package preview
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PreviewHooksFileName the file in the preview chart declaring the hooks which provision and deprovision the
+	// ephemeral resources of each preview such as databases or queues
+	PreviewHooksFileName = "hooks.yaml"
+
+	// AnnotationPreviewHooks the annotation on the preview Environment recording the post delete hooks of the base
+	// branch so that they can be run without the preview chart
+	AnnotationPreviewHooks = "jenkins.io/preview-hooks"
+
+	// PreviewHookOutputEnvVar the environment variable of the file a pre create hook can write YAML values to which
+	// are injected into the helm values of the preview
+	PreviewHookOutputEnvVar = "PREVIEW_HOOK_OUTPUT"
+
+	defaultPreviewHookTimeout = 10 * time.Minute
+
+	defaultPreviewHooksBranch = "master"
+)
+
+// PreviewHooks the hooks of a preview chart
+type PreviewHooks struct {
+	// PreCreate the hooks run before the preview chart is installed or upgraded so they should be idempotent
+	PreCreate []PreviewHook `json:"preCreate,omitempty"`
+	// PostDelete the hooks run after the preview release and namespace are deleted
+	PostDelete []PreviewHook `json:"postDelete,omitempty"`
+}
+
+// PreviewHook a command run to provision or deprovision a resource of a preview
+type PreviewHook struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// Timeout the maximum duration of the command such as 5m. Defaults to 10m
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// LoadPreviewHooks loads the hooks of the preview chart in the dir returning nil if it has none
+func LoadPreviewHooks(dir string) (*PreviewHooks, error) {
+	fileName := filepath.Join(dir, PreviewHooksFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	hooks := &PreviewHooks{}
+	err = yaml.Unmarshal(data, hooks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	for i, hook := range append(hooks.PreCreate, hooks.PostDelete...) {
+		if hook.Command == "" {
+			return nil, errors.Errorf("hook %d in file %s has no command", i, fileName)
+		}
+	}
+	return hooks, nil
+}
+
+// LoadTrustedPostDeleteHooks loads the post delete hooks from the hooks file of the preview chart in the dir on the
+// given base branch of the repository rather than from the pull request. The post delete hooks are run later by
+// jx gc previews or the controllers with their own credentials so they must not be changeable by a pull request
+func LoadTrustedPostDeleteHooks(gitter gits.Gitter, dir string, baseBranch string) ([]PreviewHook, error) {
+	if baseBranch == "" {
+		baseBranch = defaultPreviewHooksBranch
+	}
+	rootDir, _, err := gitter.FindGitConfigDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the git repository of %s", dir)
+	}
+	if rootDir == "" {
+		return nil, errors.Errorf("no git repository found for %s", dir)
+	}
+	rel, err := filepath.Rel(rootDir, filepath.Join(dir, PreviewHooksFileName))
+	if err != nil {
+		return nil, err
+	}
+	err = gitter.FetchBranch(rootDir, "origin", baseBranch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch the branch %s", baseBranch)
+	}
+	text, err := gitter.LoadFileFromBranch(rootDir, "FETCH_HEAD", filepath.ToSlash(rel))
+	if err != nil {
+		log.Logger().Debugf("no preview hooks file %s on branch %s: %s", rel, baseBranch, err.Error())
+		return nil, nil
+	}
+	hooks := &PreviewHooks{}
+	err = yaml.Unmarshal([]byte(text), hooks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s on branch %s", rel, baseBranch)
+	}
+	for i, hook := range hooks.PostDelete {
+		if hook.Command == "" {
+			return nil, errors.Errorf("post delete hook %d in file %s on branch %s has no command", i, rel, baseBranch)
+		}
+	}
+	return hooks.PostDelete, nil
+}
+
+// GetPreviewHooks returns the hooks recorded on the preview Environment or nil if it has none
+func GetPreviewHooks(env *v1.Environment) (*PreviewHooks, error) {
+	text := env.Annotations[AnnotationPreviewHooks]
+	if text == "" {
+		return nil, nil
+	}
+	hooks := &PreviewHooks{}
+	err := json.Unmarshal([]byte(text), hooks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the %s annotation of preview environment %s", AnnotationPreviewHooks, env.Name)
+	}
+	return hooks, nil
+}
+
+// RunPreCreateHooks runs the pre create hooks in the dir returning their combined output values
+func RunPreCreateHooks(hooks *PreviewHooks, env *v1.Environment, dir string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if hooks == nil {
+		return values, nil
+	}
+	for _, hook := range hooks.PreCreate {
+		output, err := runPreviewHook(hook, env, dir, true)
+		if err != nil {
+			return nil, err
+		}
+		util.CombineMapTrees(values, output)
+	}
+	return values, nil
+}
+
+// RunPostDeleteHooks runs all the post delete hooks of the deleted preview Environment in an empty temporary
+// directory even if some of them fail
+func RunPostDeleteHooks(hooks *PreviewHooks, env *v1.Environment) error {
+	if hooks == nil || len(hooks.PostDelete) == 0 {
+		return nil
+	}
+	dir, err := ioutil.TempDir("", "preview-hooks-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the directory of the post delete hooks")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	errs := []error{}
+	for _, hook := range hooks.PostDelete {
+		_, err := runPreviewHook(hook, env, dir, false)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// runPreviewHook runs the hook returning the values it writes to its output file if captureOutput is true
+func runPreviewHook(hook PreviewHook, env *v1.Environment, dir string, captureOutput bool) (map[string]interface{}, error) {
+	name := hook.Name
+	if name == "" {
+		name = hook.Command
+	}
+	timeout := defaultPreviewHookTimeout
+	if hook.Timeout != "" {
+		d, err := time.ParseDuration(hook.Timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the timeout %s of preview hook %s", hook.Timeout, name)
+		}
+		timeout = d
+	}
+
+	envVars := util.MergeMaps(map[string]string{
+		"PREVIEW_NAME":         env.Name,
+		"PREVIEW_NAMESPACE":    env.Spec.Namespace,
+		"PREVIEW_RELEASE":      kube.GetPreviewEnvironmentReleaseName(env),
+		"PREVIEW_PULL_REQUEST": env.Spec.PreviewGitSpec.Name,
+		"PREVIEW_SOURCE_URL":   env.Spec.Source.URL,
+	}, hook.Env)
+
+	outputFile := ""
+	if captureOutput {
+		f, err := ioutil.TempFile("", "preview-hook-*.yaml")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the output file of preview hook %s", name)
+		}
+		outputFile = f.Name()
+		f.Close()
+		defer os.Remove(outputFile)
+		envVars[PreviewHookOutputEnvVar] = outputFile
+	}
+
+	log.Logger().Infof("Running preview hook %s for preview environment %s", util.ColorInfo(name), util.ColorInfo(env.Name))
+	cmd := util.Command{
+		Name:    hook.Command,
+		Args:    hook.Args,
+		Dir:     dir,
+		Env:     envVars,
+		Timeout: timeout,
+		Out:     os.Stdout,
+		Err:     os.Stderr,
+	}
+	_, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run preview hook %s", name)
+	}
+	if !captureOutput {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(outputFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the output of preview hook %s", name)
+	}
+	values := map[string]interface{}{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the YAML output of preview hook %s", name)
+	}
+	return values, nil
+}
+
+// runPreCreateHooks records the post delete hooks of the preview chart on the base branch on the preview Environment
+// and runs the pre create hooks of the preview chart in the dir returning the values file of their outputs or a blank
+// string if there is none
+func (o *PreviewOptions) runPreCreateHooks(jxClient versioned.Interface, ns string, env *v1.Environment, dir string) (string, error) {
+	hooks, err := LoadPreviewHooks(dir)
+	if err != nil || hooks == nil {
+		return "", err
+	}
+	if len(hooks.PostDelete) > 0 {
+		hooks.PostDelete, err = LoadTrustedPostDeleteHooks(o.Git(), dir, os.Getenv("PULL_BASE_REF"))
+		if err != nil {
+			log.Logger().Warnf("not recording the post delete hooks of preview environment %s: %s", env.Name, err.Error())
+			hooks.PostDelete = nil
+		}
+	}
+	data, err := json.Marshal(&PreviewHooks{PostDelete: hooks.PostDelete})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the preview hooks")
+	}
+	if env.Annotations[AnnotationPreviewHooks] != string(data) {
+		if env.Annotations == nil {
+			env.Annotations = map[string]string{}
+		}
+		env.Annotations[AnnotationPreviewHooks] = string(data)
+		updated, err := jxClient.JenkinsV1().Environments(ns).PatchUpdate(env)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to record the hooks on preview environment %s", env.Name)
+		}
+		env = updated
+	}
+
+	values, err := RunPreCreateHooks(hooks, env, dir)
+	if err != nil || len(values) == 0 {
+		return "", err
+	}
+	data, err = yaml.Marshal(values)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the values of the preview hooks")
+	}
+	f, err := ioutil.TempFile("", "preview-hooks-values-*.yaml")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the values file of the preview hooks")
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save file %s", f.Name())
+	}
+	return f.Name(), nil
+}
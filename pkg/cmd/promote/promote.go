@@ -27,6 +27,7 @@ import (
 	typev1 "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/typed/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
@@ -37,7 +38,8 @@ import (
 )
 
 const (
-	optionPullRequestPollTime = "pull-request-poll-time"
+	optionPullRequestPollTime  = "pull-request-poll-time"
+	optionVersionFromLastBuild = "version-from-last-build"
 
 	GitStatusSuccess = "success"
 )
@@ -53,6 +55,7 @@ type PromoteOptions struct {
 	Namespace               string
 	Environment             string
 	Application             string
+	Applications            []string
 	Pipeline                string
 	Build                   string
 	Version                 string
@@ -61,11 +64,13 @@ type PromoteOptions struct {
 	HelmRepositoryURL       string
 	NoHelmUpdate            bool
 	AllAutomatic            bool
+	VersionFromLastBuild    bool
 	NoMergePullRequest      bool
 	NoPoll                  bool
 	NoWaitAfterMerge        bool
 	IgnoreLocalFiles        bool
 	NoWaitForUpdatePipeline bool
+	NoWaitForCanary         bool
 	Timeout                 string
 	PullRequestPollTime     string
 	Filter                  string
@@ -77,6 +82,11 @@ type PromoteOptions struct {
 	Activities              typev1.PipelineActivityInterface
 	GitInfo                 *gits.GitRepository
 	releaseResource         *v1.Release
+	promotePolicy           *environments.PromotePolicy
+	promotePolicyStatus     *gits.GitRepoStatus
+	promotePolicySha        string
+	appVersions             []AppVersion
+	canarySpecs             map[string]map[string]string
 	ReleaseInfo             *ReleaseInfo
 	prow                    bool
 
@@ -95,6 +105,8 @@ var (
 	promote_long = templates.LongDesc(`
 		Promotes a version of an application to zero to many permanent environments.
 
+		The Pull Requests on a GitOps environment are only merged once they satisfy the 'promote-policy.yaml' file of the environment repository, if any, which can declare the required approvers, the time windows and freeze periods of the promotions and the canary rollout percentages of the promoted applications.
+
 		For more documentation see: [https://jenkins-x.io/docs/getting-started/promotion/](https://jenkins-x.io/docs/getting-started/promotion/)
 
 `)
@@ -107,6 +119,9 @@ var (
 		# Promote a version of the myapp application to production
 		jx promote --app myapp --version 1.2.3 --env production
 
+		# Promote the versions of the last builds of several applications to production in a single Pull Request
+		jx promote --app myapp --app myotherapp --version-from-last-build --env production
+
 		# To search for all the available charts for a given name use -f.
 		# e.g. to find a redis chart to install
 		jx promote -f redis
@@ -140,6 +155,7 @@ func NewCmdPromote(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The Namespace to promote to")
 	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to promote to")
 	cmd.Flags().BoolVarP(&options.AllAutomatic, "all-auto", "", false, "Promote to all automatic environments in order")
+	cmd.Flags().BoolVarP(&options.VersionFromLastBuild, optionVersionFromLastBuild, "", false, "Promote the version of the last successful release build of each application rather than the latest chart version")
 
 	options.AddPromoteOptions(cmd)
 	return cmd
@@ -147,7 +163,7 @@ func NewCmdPromote(commonOpts *opts.CommonOptions) *cobra.Command {
 
 // AddPromoteOptions adds command level options to `promote`
 func (o *PromoteOptions) AddPromoteOptions(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&o.Application, opts.OptionApplication, "a", "", "The Application to promote")
+	cmd.Flags().VarP(&applicationsValue{options: o}, opts.OptionApplication, "a", "The Application to promote. Specify it several times to promote several applications in a single Pull Request")
 	cmd.Flags().StringVarP(&o.Filter, "filter", "f", "", "The search filter to find charts to promote")
 	cmd.Flags().StringVarP(&o.Alias, "alias", "", "", "The optional alias used in the 'requirements.yaml' file")
 	cmd.Flags().StringVarP(&o.Pipeline, "pipeline", "", "", "The Pipeline string in the form 'folderName/repoName/branch' which is used to update the PipelineActivity. If not specified its defaulted from  the '$BUILD_NUMBER' environment variable")
@@ -162,9 +178,36 @@ func (o *PromoteOptions) AddPromoteOptions(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.NoMergePullRequest, "no-merge", "", false, "Disables automatic merge of promote Pull Requests")
 	cmd.Flags().BoolVarP(&o.NoPoll, "no-poll", "", false, "Disables polling for Pull Request or Pipeline status")
 	cmd.Flags().BoolVarP(&o.NoWaitAfterMerge, "no-wait", "", false, "Disables waiting for completing promotion after the Pull request is merged")
+	cmd.Flags().BoolVarP(&o.NoWaitForCanary, "no-wait-canary", "", false, "Disables waiting for the Flagger canary analysis of the promoted application to pass")
 	cmd.Flags().BoolVarP(&o.IgnoreLocalFiles, "ignore-local-file", "", false, "Ignores the local file system when deducing the Git repository")
 }
 
+// applicationsValue the value of the --app flag which appends to the Applications and sets the Application to the
+// first of them so that the commands embedding the options can keep using the Application
+type applicationsValue struct {
+	options *PromoteOptions
+}
+
+// String implements pflag.Value
+func (v *applicationsValue) String() string {
+	return "[" + strings.Join(v.options.Applications, ",") + "]"
+}
+
+// Set implements pflag.Value
+func (v *applicationsValue) Set(value string) error {
+	o := v.options
+	o.Applications = append(o.Applications, value)
+	if len(o.Applications) == 1 {
+		o.Application = value
+	}
+	return nil
+}
+
+// Type implements pflag.Value
+func (v *applicationsValue) Type() string {
+	return "stringArray"
+}
+
 func (o *PromoteOptions) hasApplicationFlag() bool {
 	return o.Application != ""
 }
@@ -317,10 +360,25 @@ func (o *PromoteOptions) Run() error {
 
 	o.Activities = jxClient.JenkinsV1().PipelineActivities(ns)
 
-	releaseName := o.ReleaseName
-	if releaseName == "" {
-		releaseName = targetNS + "-" + o.Application
-		o.ReleaseName = releaseName
+	if o.isBatch() {
+		if o.Version != "" {
+			return fmt.Errorf("cannot specify a version when promoting several applications, use --%s to promote the versions of their last builds", optionVersionFromLastBuild)
+		}
+		if o.Alias != "" {
+			return fmt.Errorf("cannot specify an alias when promoting several applications")
+		}
+		if o.ReleaseName != "" {
+			return fmt.Errorf("cannot specify a release name when promoting several applications")
+		}
+	} else if o.VersionFromLastBuild && o.Version == "" {
+		o.Version, err = o.FindLastBuildVersion(o.Application)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.ReleaseName == "" && !o.isBatch() {
+		o.ReleaseName = targetNS + "-" + o.Application
 	}
 
 	if o.AllAutomatic {
@@ -338,7 +396,12 @@ func (o *PromoteOptions) Run() error {
 			return fmt.Errorf("Could not find an Environment called %s", o.Environment)
 		}
 	}
-	releaseInfo, err := o.Promote(targetNS, env, true)
+	var releaseInfo *ReleaseInfo
+	if o.isBatch() {
+		releaseInfo, err = o.PromoteApplications(targetNS, env)
+	} else {
+		releaseInfo, err = o.Promote(targetNS, env, true)
+	}
 	if err != nil {
 		return err
 	}
@@ -385,7 +448,12 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 			if ns == "" {
 				return fmt.Errorf("No namespace for environment %s", env.Name)
 			}
-			releaseInfo, err := o.Promote(ns, &env, false)
+			var releaseInfo *ReleaseInfo
+			if o.isBatch() {
+				releaseInfo, err = o.PromoteApplications(ns, &env)
+			} else {
+				releaseInfo, err = o.Promote(ns, &env, false)
+			}
 			if err != nil {
 				return err
 			}
@@ -565,7 +633,11 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 			}
 		}
 		requirements.SetAppVersion(app, version, o.HelmRepositoryURL, o.Alias)
-		return nil
+		name := app
+		if o.Alias != "" {
+			name = o.Alias
+		}
+		return o.applyPromotePolicy(dir, name)
 	}
 	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
 	if err != nil {
@@ -588,7 +660,74 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 	}
 	info, err := options.Create(env, envDir, &details, filter, "", true)
 	releaseInfo.PullRequestInfo = info
-	return err
+	if err != nil {
+		return err
+	}
+	return o.reportCreatedPullRequestPolicy(gitProvider, info)
+}
+
+// reportCreatedPullRequestPolicy reports the promotion policy status of the created Pull Request straight away so that
+// it cannot be merged before the policy is satisfied even if this command does not wait for it
+func (o *PromoteOptions) reportCreatedPullRequestPolicy(gitProvider gits.GitProvider, info *gits.PullRequestInfo) error {
+	if o.promotePolicy == nil || info == nil || info.PullRequest == nil {
+		return nil
+	}
+	pr := info.PullRequest
+	if pr.LastCommitSha == "" {
+		err := gitProvider.UpdatePullRequestStatus(pr)
+		if err != nil {
+			return errors.Wrapf(err, "getting the status of Pull Request %s", pr.URL)
+		}
+	}
+	// the Pull Request not satisfying the policy yet is reported by the status
+	_ = o.reportPromotePolicy(gitProvider, pr)
+	return nil
+}
+
+// applyPromotePolicy loads the promotion policy of the environment chart in the directory enabling the canary
+// rollout of the applications if the policy declares one
+func (o *PromoteOptions) applyPromotePolicy(dir string, apps ...string) error {
+	policy, err := environments.LoadPromotePolicy(dir)
+	if err != nil {
+		return err
+	}
+	o.promotePolicy = policy
+	if policy == nil {
+		return nil
+	}
+	for _, app := range apps {
+		err = policy.ApplyCanary(dir, app)
+		if err != nil {
+			return errors.Wrapf(err, "enabling the canary rollout of app %s", app)
+		}
+	}
+	return nil
+}
+
+// reportPromotePolicy reports whether the Pull Request satisfies the promotion policy of the environment via a commit
+// status which stays pending until it does so that no merge bot merges it before then. Returns an error describing
+// why the Pull Request does not satisfy the policy
+func (o *PromoteOptions) reportPromotePolicy(gitProvider gits.GitProvider, pr *gits.GitPullRequest) error {
+	if o.promotePolicy == nil || pr == nil {
+		return nil
+	}
+	status := o.promotePolicy.PullRequestStatus(gitProvider, pr, time.Now())
+	if pr.LastCommitSha != "" && (o.promotePolicyStatus == nil || *o.promotePolicyStatus != *status || o.promotePolicySha != pr.LastCommitSha) {
+		_, err := gitProvider.UpdateCommitStatus(pr.Owner, pr.Repo, pr.LastCommitSha, status)
+		if err != nil {
+			log.Logger().Warnf("Failed to report the %s status of the Pull Request %s: %s", environments.PromotePolicyStatusContext, pr.URL, err)
+		} else {
+			if status.State != "success" {
+				log.Logger().Warnf("Not merging the Pull Request %s yet as it does not satisfy the promotion policy of the environment: %s", util.ColorInfo(pr.URL), status.Description)
+			}
+			o.promotePolicyStatus = status
+			o.promotePolicySha = pr.LastCommitSha
+		}
+	}
+	if status.State != "success" {
+		return errors.New(status.Description)
+	}
+	return nil
 }
 
 func (o *PromoteOptions) GetTargetNamespace(ns string, env string) (string, *v1.Environment, error) {
@@ -660,7 +799,7 @@ func (o *PromoteOptions) WaitForPromotion(ns string, env *v1.Environment, releas
 	}
 	pullRequestInfo := releaseInfo.PullRequestInfo
 	if pullRequestInfo != nil {
-		promoteKey := o.CreatePromoteKey(env)
+		promoteKey := o.promoteKeys(ns, env)
 
 		err := o.waitForGitOpsPullRequest(ns, env, releaseInfo, end, duration, promoteKey)
 		if err != nil {
@@ -676,15 +815,17 @@ func (o *PromoteOptions) WaitForPromotion(ns string, env *v1.Environment, releas
 }
 
 // TODO This could do with a refactor and some tests...
-func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, end time.Time, duration time.Duration, promoteKey *kube.PromoteStepActivityKey) error {
+func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, end time.Time, duration time.Duration, promoteKey promoteStepActivityKeys) error {
 	pullRequestInfo := releaseInfo.PullRequestInfo
 	logMergeFailure := false
 	logNoMergeCommitSha := false
 	logHasMergeSha := false
 	logMergeStatusError := false
 	logNoMergeStatuses := false
+	logDraft := false
 	urlStatusMap := map[string]string{}
 	urlStatusTargetURLMap := map[string]string{}
+	o.recordCanarySpecs(ns)
 
 	jxClient, _, err := o.JXClient()
 	if err != nil {
@@ -743,7 +884,11 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 
 						if o.NoWaitForUpdatePipeline {
 							log.Logger().Info("Pull Request merged but we are not waiting for the update pipeline to complete!")
-							err = o.CommentOnIssues(ns, env, promoteKey)
+							err = o.waitForCanaries(ns, end, promoteKey)
+							if err != nil {
+								return err
+							}
+							err = o.commentOnPromotedIssues(ns, env, promoteKey)
 							if err == nil {
 								err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
 							}
@@ -810,7 +955,11 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 								}
 								if succeeded {
 									log.Logger().Info("Merge status checks all passed so the promotion worked!")
-									err = o.CommentOnIssues(ns, env, promoteKey)
+									err = o.waitForCanaries(ns, end, promoteKey)
+									if err != nil {
+										return err
+									}
+									err = o.commentOnPromotedIssues(ns, env, promoteKey)
 									if err == nil {
 										err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
 									}
@@ -825,6 +974,8 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 						return fmt.Errorf("Promotion failed as Pull Request %s is closed without merging", pr.URL)
 					}
 
+					// lets report the promotion policy first as its status is part of the last commit status
+					policyErr := o.reportPromotePolicy(gitProvider, pr)
 					// lets try merge if the status is good
 					status, err := gitProvider.PullRequestLastCommitStatus(pr)
 					if err != nil {
@@ -834,7 +985,14 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 						log.Logger().Info("The build for the Pull Request last commit is currently in progress.")
 					} else {
 						if status == "success" {
-							if !(o.NoMergePullRequest) {
+							if policyErr != nil {
+								log.Logger().Debugf("Not merging the Pull Request %s as it does not satisfy the promotion policy of environment %s: %s", pr.URL, env.Name, policyErr)
+							} else if pr.Draft != nil && *pr.Draft {
+								if !logDraft {
+									logDraft = true
+									log.Logger().Infof("Not merging the Pull Request %s until it is no longer a draft", util.ColorInfo(pr.URL))
+								}
+							} else if !(o.NoMergePullRequest) {
 								tideMerge := false
 								// Now check if tide is running or not
 								commitStatues, err := gitProvider.ListCommitStatus(pr.Owner, pr.Repo, pr.LastCommitSha)
@@ -883,6 +1041,62 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 	return nil
 }
 
+// promotedApps returns the names of the promoted applications
+func (o *PromoteOptions) promotedApps() []string {
+	if len(o.Applications) == 0 {
+		return []string{o.Application}
+	}
+	return o.Applications
+}
+
+// recordCanarySpecs records the applied specs of the Flagger Canaries of the promoted applications in the namespace
+// before the promotion is merged so that waiting for the canary analysis ignores the rollout of the current versions
+func (o *PromoteOptions) recordCanarySpecs(ns string) {
+	if o.NoWaitForCanary {
+		return
+	}
+	client, _, err := o.GetFactory().CreateDynamicClient()
+	if err != nil {
+		log.Logger().Warnf("Failed to create the dynamic client to find the Canaries: %s", err)
+		return
+	}
+	o.canarySpecs = map[string]map[string]string{}
+	for _, app := range o.promotedApps() {
+		specs, err := flagger.CanarySpecs(client, ns, app)
+		if err != nil {
+			log.Logger().Warnf("Failed to find the Canaries of app %s in namespace %s: %s", app, ns, err)
+			continue
+		}
+		o.canarySpecs[app] = specs
+	}
+}
+
+// waitForCanaries waits for the Flagger canary analysis of the promoted applications in the namespace to pass
+func (o *PromoteOptions) waitForCanaries(ns string, end time.Time, promoteKey promoteStepActivityKeys) error {
+	if o.NoWaitForCanary {
+		return nil
+	}
+	client, _, err := o.GetFactory().CreateDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+	for _, app := range o.promotedApps() {
+		err = flagger.WaitForCanaries(client, ns, app, o.canarySpecs[app], time.Until(end), *o.PullRequestPollDuration)
+		if err != nil {
+			jxClient, _, jxErr := o.JXClient()
+			kubeClient, kubeErr := o.KubeClient()
+			if jxErr == nil && kubeErr == nil {
+				promoteErr := promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.FailedPromotionUpdate)
+				if promoteErr != nil {
+					log.Logger().Warnf("Failed to update PipelineActivity: %s", promoteErr)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 func (o *PromoteOptions) findLatestVersion(app string) (string, error) {
 	charts, err := o.Helm().SearchCharts(app, true)
 	if err != nil {
@@ -939,6 +1153,11 @@ func (o *PromoteOptions) verifyHelmConfigured() error {
 }
 
 func (o *PromoteOptions) CreatePromoteKey(env *v1.Environment) *kube.PromoteStepActivityKey {
+	return o.createPromoteKey(env, o.Application, o.ReleaseName)
+}
+
+// createPromoteKey creates the key of the PipelineActivity recording the promotion of the release of the app
+func (o *PromoteOptions) createPromoteKey(env *v1.Environment, app string, releaseName string) *kube.PromoteStepActivityKey {
 	pipeline := o.Pipeline
 	if o.Build == "" {
 		o.Build = builds.GetBuildNumber()
@@ -951,18 +1170,25 @@ func (o *PromoteOptions) CreatePromoteKey(env *v1.Environment) *kube.PromoteStep
 	if !o.IgnoreLocalFiles {
 		var err error
 		gitInfo, err = o.Git().Info("")
-		releaseName := o.ReleaseName
-		if o.releaseResource == nil && releaseName != "" {
-			jxClient, _, err := o.JXClient()
-			if err == nil && jxClient != nil {
-				release, err := jxClient.JenkinsV1().Releases(env.Spec.Namespace).Get(releaseName, metav1.GetOptions{})
-				if err == nil && release != nil {
-					o.releaseResource = release
+		// the release of the options is that of the only promoted application
+		release := o.releaseResource
+		if o.isBatch() {
+			release = nil
+		}
+		if release == nil && releaseName != "" {
+			jxClient, _, jxErr := o.JXClient()
+			if jxErr == nil && jxClient != nil {
+				r, getErr := jxClient.JenkinsV1().Releases(env.Spec.Namespace).Get(releaseName, metav1.GetOptions{})
+				if getErr == nil && r != nil {
+					release = r
+					if !o.isBatch() {
+						o.releaseResource = release
+					}
 				}
 			}
 		}
-		if o.releaseResource != nil {
-			releaseNotesURL = o.releaseResource.Spec.ReleaseNotesURL
+		if release != nil {
+			releaseNotesURL = release.Spec.ReleaseNotesURL
 		}
 		if err != nil {
 			log.Logger().Warnf("Could not discover the Git repository info %s", err)
@@ -971,7 +1197,7 @@ func (o *PromoteOptions) CreatePromoteKey(env *v1.Environment) *kube.PromoteStep
 		}
 	}
 	if pipeline == "" {
-		pipeline, build = o.GetPipelineName(gitInfo, pipeline, build, o.Application)
+		pipeline, build = o.GetPipelineName(gitInfo, pipeline, build, app)
 	}
 	if pipeline != "" && build == "" {
 		log.Logger().Warnf("No $BUILD_NUMBER environment variable found so cannot record promotion activities into the PipelineActivity resources in kubernetes")
@@ -1003,10 +1229,14 @@ func (o *PromoteOptions) CreatePromoteKey(env *v1.Environment) *kube.PromoteStep
 
 // CommentOnIssues comments on any issues for a release that the fix is available in the given environment
 func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Environment, promoteKey *kube.PromoteStepActivityKey) error {
+	return o.commentOnIssues(targetNS, environment, o.Application, o.Version, o.ReleaseName, promoteKey)
+}
+
+// commentOnIssues comments on any issues for the release of the version of the app that the fix is available in the
+// given environment
+func (o *PromoteOptions) commentOnIssues(targetNS string, environment *v1.Environment, app string, version string, releaseName string, promoteKey *kube.PromoteStepActivityKey) error {
 	ens := environment.Spec.Namespace
 	envName := environment.Spec.Label
-	app := o.Application
-	version := o.Version
 	if ens == "" {
 		log.Logger().Warnf("Environment %s has no namespace", envName)
 		return nil
@@ -1042,7 +1272,7 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 		return err
 	}
 
-	releaseName := naming.ToValidNameWithDots(app + "-" + version)
+	versionReleaseName := naming.ToValidNameWithDots(app + "-" + version)
 	jxClient, _, err := o.JXClient()
 	if err != nil {
 		return err
@@ -1051,7 +1281,7 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 	if err != nil {
 		return err
 	}
-	appNames := []string{app, o.ReleaseName, ens + "-" + app}
+	appNames := []string{app, releaseName, ens + "-" + app}
 	url := ""
 	for _, n := range appNames {
 		url, err = services.FindServiceURL(kubeClient, ens, naming.ToValidName(n))
@@ -1069,8 +1299,8 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 
 	if available == "" {
 		ing, err := kubeClient.ExtensionsV1beta1().Ingresses(ens).Get(app, metav1.GetOptions{})
-		if err != nil || ing == nil && o.ReleaseName != "" && o.ReleaseName != app {
-			ing, err = kubeClient.ExtensionsV1beta1().Ingresses(ens).Get(o.ReleaseName, metav1.GetOptions{})
+		if err != nil || ing == nil && releaseName != "" && releaseName != app {
+			ing, err = kubeClient.ExtensionsV1beta1().Ingresses(ens).Get(releaseName, metav1.GetOptions{})
 		}
 		if ing != nil {
 			if len(ing.Spec.Rules) > 0 {
@@ -1089,7 +1319,7 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 		log.Logger().Debugf("Application is available at: %s", util.ColorInfo(url))
 	}
 
-	release, err := jxClient.JenkinsV1().Releases(ens).Get(releaseName, metav1.GetOptions{})
+	release, err := jxClient.JenkinsV1().Releases(ens).Get(versionReleaseName, metav1.GetOptions{})
 	if err == nil && release != nil {
 		o.releaseResource = release
 		issues := release.Spec.Issues
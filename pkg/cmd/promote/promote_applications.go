@@ -0,0 +1,249 @@
+package promote
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// AppVersion an application and the version of it to promote
+type AppVersion struct {
+	Application string
+	Version     string
+}
+
+// isBatch returns true if several applications are promoted in a single Pull Request
+func (o *PromoteOptions) isBatch() bool {
+	return len(o.Applications) > 1
+}
+
+// PromoteApplications promotes all the applications to the environment in a single Pull Request
+func (o *PromoteOptions) PromoteApplications(targetNS string, env *v1.Environment) (*ReleaseInfo, error) {
+	if env == nil || env.Spec.Source.URL == "" || !env.Spec.Kind.IsPermanent() {
+		return nil, fmt.Errorf("several applications can only be promoted to a permanent environment with a git source repository")
+	}
+	appVersions, err := o.ResolveAppVersions(o.Applications)
+	if err != nil {
+		return nil, err
+	}
+	o.appVersions = appVersions
+	info := util.ColorInfo
+	releaseNames := []string{}
+	for _, av := range appVersions {
+		log.Logger().Infof("Promoting app %s version %s to namespace %s", info(av.Application), info(av.Version), info(targetNS))
+		releaseNames = append(releaseNames, targetNS+"-"+av.Application)
+	}
+
+	releaseInfo := &ReleaseInfo{
+		ReleaseName: strings.Join(releaseNames, ","),
+		FullAppName: strings.Join(o.Applications, ","),
+	}
+	err = o.PromoteApplicationsViaPullRequest(env, releaseInfo, appVersions)
+	if err != nil {
+		return releaseInfo, err
+	}
+
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return releaseInfo, err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return releaseInfo, err
+	}
+	promoteKey := o.promoteKeys(targetNS, env)
+	startPromotePR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
+		err := kube.StartPromotionPullRequest(a, s, ps, p)
+		if err != nil {
+			return err
+		}
+		pr := releaseInfo.PullRequestInfo
+		if pr != nil && pr.PullRequest != nil && p.PullRequestURL == "" {
+			p.PullRequestURL = pr.PullRequest.URL
+		}
+		return nil
+	}
+	err = promoteKey.OnPromotePullRequest(kubeClient, jxClient, o.Namespace, startPromotePR)
+	if err != nil {
+		log.Logger().Warnf("Failed to update PipelineActivity: %s", err)
+	}
+	// lets sleep a little before we try poll for the PR status
+	time.Sleep(waitAfterPullRequestCreated)
+	return releaseInfo, nil
+}
+
+// ResolveAppVersions resolves the version to promote of each application from its last build or its latest chart
+func (o *PromoteOptions) ResolveAppVersions(apps []string) ([]AppVersion, error) {
+	answer := []AppVersion{}
+	for _, app := range apps {
+		var version string
+		var err error
+		if o.VersionFromLastBuild {
+			version, err = o.FindLastBuildVersion(app)
+		} else {
+			version, err = o.findLatestVersion(app)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving the version of app %s", app)
+		}
+		answer = append(answer, AppVersion{Application: app, Version: version})
+	}
+	return answer, nil
+}
+
+// FindLastBuildVersion returns the version of the last successful release build of the application. The builds of
+// the repositories of the same name in several owners are ambiguous
+func (o *PromoteOptions) FindLastBuildVersion(app string) (string, error) {
+	if o.Activities == nil {
+		return "", fmt.Errorf("no PipelineActivity client to find the last build of app %s", app)
+	}
+	list, err := o.Activities.List(metav1.ListOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "listing the PipelineActivities of app %s", app)
+	}
+	var last *v1.PipelineActivity
+	owners := map[string]string{}
+	for i := range list.Items {
+		a := &list.Items[i]
+		spec := &a.Spec
+		if spec.GitRepository != app || spec.Version == "" || spec.Status != v1.ActivityStatusTypeSucceeded {
+			continue
+		}
+		// pull request builds produce preview versions
+		if strings.HasPrefix(strings.ToUpper(spec.GitBranch), "PR-") {
+			continue
+		}
+		owners[spec.GitOwner] = a.Name
+		if last == nil || activityStarted(a).After(activityStarted(last)) {
+			last = a
+		}
+	}
+	if last == nil {
+		return "", fmt.Errorf("no successful release build found for app %s", app)
+	}
+	if len(owners) > 1 {
+		return "", fmt.Errorf("the release builds of app %s are of the repositories of several owners: %s", app, strings.Join(util.SortedMapKeys(owners), ", "))
+	}
+	return last.Spec.Version, nil
+}
+
+func activityStarted(a *v1.PipelineActivity) time.Time {
+	if a.Spec.StartedTimestamp != nil {
+		return a.Spec.StartedTimestamp.Time
+	}
+	return a.CreationTimestamp.Time
+}
+
+// PromoteApplicationsViaPullRequest creates a single Pull Request on the environment updating the versions of all the
+// applications
+func (o *PromoteOptions) PromoteApplicationsViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo, appVersions []AppVersion) error {
+	names := []string{}
+	changes := []string{}
+	for _, av := range appVersions {
+		names = append(names, av.Application+"-"+av.Version)
+		changes = append(changes, av.Application+" to "+av.Version)
+	}
+	details := gits.PullRequestDetails{
+		BranchName: "promote-" + strings.Join(names, "-"),
+		Title:      "chore: " + strings.Join(changes, ", "),
+		Message:    fmt.Sprintf("chore: Promote %s", strings.Join(changes, ", ")),
+	}
+
+	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		apps := []string{}
+		for _, av := range appVersions {
+			requirements.SetAppVersion(av.Application, av.Version, o.HelmRepositoryURL, "")
+			apps = append(apps, av.Application)
+		}
+		return o.applyPromotePolicy(dir, apps...)
+	}
+	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
+	if err != nil {
+		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
+	}
+
+	options := environments.EnvironmentPullRequestOptions{
+		Gitter:        o.Git(),
+		ModifyChartFn: modifyChartFn,
+		GitProvider:   gitProvider,
+	}
+	info, err := options.Create(env, o.CloneDir, &details, &gits.PullRequestFilter{}, "", true)
+	releaseInfo.PullRequestInfo = info
+	if err != nil {
+		return err
+	}
+	return o.reportCreatedPullRequestPolicy(gitProvider, info)
+}
+
+// promoteStepActivityKeys the keys of the PipelineActivities recording the promotion of each application
+type promoteStepActivityKeys []*kube.PromoteStepActivityKey
+
+// promoteKeys returns the keys of the PipelineActivities recording the promotion to the environment of each
+// application in the order of the Applications
+func (o *PromoteOptions) promoteKeys(targetNS string, env *v1.Environment) promoteStepActivityKeys {
+	if !o.isBatch() {
+		return promoteStepActivityKeys{o.CreatePromoteKey(env)}
+	}
+	answer := promoteStepActivityKeys{}
+	for _, app := range o.Applications {
+		answer = append(answer, o.createPromoteKey(env, app, targetNS+"-"+app))
+	}
+	return answer
+}
+
+// OnPromotePullRequest updates the promote Pull Request step of the PipelineActivity of each key
+func (keys promoteStepActivityKeys) OnPromotePullRequest(kubeClient kubernetes.Interface, jxClient versioned.Interface, ns string, fn kube.PromotePullRequestFn) error {
+	errs := []error{}
+	for _, key := range keys {
+		err := key.OnPromotePullRequest(kubeClient, jxClient, ns, fn)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// OnPromoteUpdate updates the promote update step of the PipelineActivity of each key
+func (keys promoteStepActivityKeys) OnPromoteUpdate(kubeClient kubernetes.Interface, jxClient versioned.Interface, ns string, fn kube.PromoteUpdateFn) error {
+	errs := []error{}
+	for _, key := range keys {
+		err := key.OnPromoteUpdate(kubeClient, jxClient, ns, fn)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// commentOnPromotedIssues comments on the issues of the releases of the promoted applications that their fixes are
+// available in the environment
+func (o *PromoteOptions) commentOnPromotedIssues(ns string, env *v1.Environment, promoteKeys promoteStepActivityKeys) error {
+	if !o.isBatch() {
+		return o.CommentOnIssues(ns, env, promoteKeys[0])
+	}
+	errs := []error{}
+	for i, av := range o.appVersions {
+		if i >= len(promoteKeys) {
+			break
+		}
+		err := o.commentOnIssues(ns, env, av.Application, av.Version, ns+"-"+av.Application, promoteKeys[i])
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
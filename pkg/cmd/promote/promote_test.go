@@ -5,14 +5,18 @@ package promote_test
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/jx/v2/pkg/tests"
 
 	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/promote"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -70,6 +74,71 @@ func TestEnsureApplicationNameIsDefinedWithoutApplicationFlagWithBatchFlag(t *te
 	assert.Equal(t, "myDiscoveredApp", promoteOptions.Application)
 }
 
+func TestAddPromoteOptionsWithSeveralApplications(t *testing.T) {
+	promoteOptions := &promote.PromoteOptions{
+		CommonOptions: &opts.CommonOptions{},
+	}
+	cmd := &cobra.Command{}
+	promoteOptions.AddPromoteOptions(cmd)
+
+	err := cmd.Flags().Parse([]string{"--app", "myapp", "-a", "myotherapp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"myapp", "myotherapp"}, promoteOptions.Applications)
+	assert.Equal(t, "myapp", promoteOptions.Application, "the commands embedding the options should see the first application")
+}
+
+func TestFindLastBuildVersion(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	activity := func(name string, owner string, repo string, branch string, version string, status v1.ActivityStatusType, started time.Time) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "jx",
+			},
+			Spec: v1.PipelineActivitySpec{
+				GitOwner:         owner,
+				GitRepository:    repo,
+				GitBranch:        branch,
+				Version:          version,
+				Status:           status,
+				StartedTimestamp: &metav1.Time{Time: started},
+			},
+		}
+	}
+	jxClient := jxfake.NewSimpleClientset(
+		activity("myorg-myapp-master-1", "myorg", "myapp", "master", "1.0.1", v1.ActivityStatusTypeSucceeded, now.Add(-3*time.Hour)),
+		activity("myorg-myapp-master-2", "myorg", "myapp", "master", "1.0.2", v1.ActivityStatusTypeSucceeded, now.Add(-2*time.Hour)),
+		activity("myorg-myapp-master-3", "myorg", "myapp", "master", "1.0.3", v1.ActivityStatusTypeFailed, now.Add(-time.Hour)),
+		activity("myorg-myapp-pr-4-1", "myorg", "myapp", "PR-4", "0.0.0-SNAPSHOT-PR-4-1", v1.ActivityStatusTypeSucceeded, now),
+		activity("myorg-myotherapp-master-1", "myorg", "myotherapp", "master", "2.0.0", v1.ActivityStatusTypeSucceeded, now),
+		activity("myorg-myapp-docs-master-1", "myorg", "MyApp", "master", "3.0.0", v1.ActivityStatusTypeSucceeded, now),
+		activity("myorg-shared-master-1", "myorg", "shared", "master", "1.0.0", v1.ActivityStatusTypeSucceeded, now),
+		activity("otherorg-shared-master-1", "otherorg", "shared", "master", "4.0.0", v1.ActivityStatusTypeSucceeded, now),
+	)
+
+	promoteOptions := &promote.PromoteOptions{
+		Activities: jxClient.JenkinsV1().PipelineActivities("jx"),
+	}
+
+	version, err := promoteOptions.FindLastBuildVersion("myapp")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.2", version, "should skip failed and pull request builds and match the repository exactly")
+
+	promoteOptions.VersionFromLastBuild = true
+	appVersions, err := promoteOptions.ResolveAppVersions([]string{"myapp", "myotherapp"})
+	require.NoError(t, err)
+	assert.Equal(t, []promote.AppVersion{{Application: "myapp", Version: "1.0.2"}, {Application: "myotherapp", Version: "2.0.0"}}, appVersions)
+
+	_, err = promoteOptions.FindLastBuildVersion("unknown")
+	assert.Error(t, err)
+
+	_, err = promoteOptions.FindLastBuildVersion("shared")
+	assert.Error(t, err, "the builds of repositories of several owners should be ambiguous")
+}
+
 func TestEnsureApplicationNameIsDefinedWithoutApplicationFlag(t *testing.T) {
 	tests.SkipForWindows(t, "go-expect does not work on windows")
 
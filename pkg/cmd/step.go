@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/bdd"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/boot"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/build"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/buildpack"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/cluster"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/create"
@@ -22,6 +23,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/pr"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/report"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/restore"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/scan"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/scheduler"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/syntax"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/update"
@@ -48,6 +50,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	cmd.AddCommand(boot.NewCmdStepBoot(commonOpts))
+	cmd.AddCommand(build.NewCmdStepBuild(commonOpts))
 	cmd.AddCommand(buildpack.NewCmdStepBuildPack(commonOpts))
 	cmd.AddCommand(bdd.NewCmdStepBDD(commonOpts))
 	cmd.AddCommand(e2e.NewCmdStepE2E(commonOpts))
@@ -85,6 +88,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(config.NewCmdStepPatchConfigMap(commonOpts))
 	cmd.AddCommand(update.NewCmdStepUpdate(commonOpts))
 	cmd.AddCommand(report.NewCmdStepReport(commonOpts))
+	cmd.AddCommand(scan.NewCmdStepScan(commonOpts))
 	cmd.AddCommand(step.NewCmdStepOverrideRequirements(commonOpts))
 	cmd.AddCommand(restore.NewCmdStepRestore(commonOpts))
 
@@ -123,7 +123,11 @@ func (o *StepBootVaultOptions) Run() error {
 		return errors.Wrap(err, "creating vault operator client")
 	}
 
-	resolver, err := o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+	versionStreamURL, err := o.ResolveVersionStreamURL(requirements.VersionStream)
+	if err != nil {
+		return err
+	}
+	resolver, err := o.CreateVersionResolver(versionStreamURL, requirements.VersionStream.Ref)
 	if err != nil {
 		return errors.Wrap(err, "unable to create version stream resolver")
 	}
@@ -0,0 +1,62 @@
+package build
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/spf13/cobra"
+)
+
+// StepBuildOptions contains the command line flags
+type StepBuildOptions struct {
+	step.StepOptions
+}
+
+// NewCmdStepBuild Steps a command object for the "step build" command
+func NewCmdStepBuild(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepBuildOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "build [command]",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepBuildPack(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepBuildOptions) Run() error {
+	return o.Cmd.Help()
+}
+
+// NewCmdStepBuildPack Steps a command object for the "step build pack" command
+func NewCmdStepBuildPack(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepBuildOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "pack",
+		Short: "pack [command]",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepBuildPackCNB(commonOpts))
+	return cmd
+}
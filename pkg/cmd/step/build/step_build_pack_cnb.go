@@ -0,0 +1,242 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultCNBBuilderImage the default Cloud Native Buildpacks builder image whose version is pinned in the version stream
+	DefaultCNBBuilderImage = "paketobuildpacks/builder"
+
+	defaultVersionFile = "VERSION"
+)
+
+var (
+	stepBuildPackCNBLong = templates.LongDesc(`
+		Builds the image of the application with Cloud Native Buildpacks using the 'pack' CLI rather than a Dockerfile and skaffold.
+
+		The image is named after the docker registry, docker registry organisation and application in the same way as the images built with Kaniko, so 'jx step tag' and the promotion of the release use it as is.
+
+		The builder image is pinned to the version and digest in the version stream unless it is specified with a tag or digest. Builder images which are not pinned fail rather than using the latest builder.
+
+		Pipelines build the image with this step rather than skaffold and Kaniko if the 'cnbBuilder' of the pipeline configuration in jenkins-x.yml is specified.
+`)
+
+	stepBuildPackCNBExample = templates.Examples(`
+		# builds and pushes the image of the application in the current directory using the version in the VERSION file
+		jx step build pack cnb
+
+		# builds the image with a specific builder
+		jx step build pack cnb --version 1.2.3 --builder heroku/buildpacks:18
+			`)
+)
+
+// StepBuildPackCNBOptions contains the command line flags
+type StepBuildPackCNBOptions struct {
+	step.StepOptions
+
+	Dir         string
+	Image       string
+	Version     string
+	VersionFile string
+	Builder     string
+	RunImage    string
+	Env         []string
+	NoPublish   bool
+	ClearCache  bool
+
+	versionResolver *versionstream.VersionResolver
+}
+
+// NewCmdStepBuildPackCNB Creates a new Command object
+func NewCmdStepBuildPackCNB(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepBuildPackCNBOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "cnb",
+		Short:   "Builds the image of the application with Cloud Native Buildpacks",
+		Long:    stepBuildPackCNBLong,
+		Example: stepBuildPackCNBExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the application source code. Defaults to the current directory")
+	cmd.Flags().StringVarP(&options.Image, "image", "i", "", "The name of the image without its tag. Defaults to the docker registry, docker registry organisation and application name")
+	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version the image is tagged with. Defaults to the $VERSION environment variable or the version file")
+	cmd.Flags().StringVarP(&options.VersionFile, "version-file", "", defaultVersionFile, "The file name used to load the version number from if no '--version' option or $VERSION is specified")
+	cmd.Flags().StringVarP(&options.Builder, "builder", "b", DefaultCNBBuilderImage, "The Cloud Native Buildpacks builder image. If it has no tag or digest it is pinned to the version in the version stream")
+	cmd.Flags().StringVarP(&options.RunImage, "run-image", "", "", "The run image to use instead of the one of the builder")
+	cmd.Flags().StringArrayVarP(&options.Env, "env", "e", nil, "The build time environment variables of the buildpacks of the form NAME=VALUE")
+	cmd.Flags().BoolVarP(&options.NoPublish, "no-publish", "", false, "Builds the image in the local docker daemon rather than pushing it to the registry")
+	cmd.Flags().BoolVarP(&options.ClearCache, "clear-cache", "", false, "Clears the build cache of the image before building it")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepBuildPackCNBOptions) Run() error {
+	var err error
+	dir := o.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range o.Env {
+		if !strings.Contains(e, "=") {
+			return util.InvalidOptionf("env", e, "build time environment variables must be of the form NAME=VALUE")
+		}
+	}
+	version, err := o.findVersion(dir)
+	if err != nil {
+		return err
+	}
+	image := o.Image
+	if image == "" {
+		image, err = o.defaultImage(dir)
+		if err != nil {
+			return err
+		}
+	}
+	builder, err := o.resolveBuilder()
+	if err != nil {
+		return err
+	}
+
+	image = image + ":" + naming.ToValidImageVersion(version)
+	args := []string{"build", image, "--builder", builder, "--path", dir}
+	if !o.NoPublish {
+		args = append(args, "--publish")
+	}
+	if o.RunImage != "" {
+		args = append(args, "--run-image", o.RunImage)
+	}
+	for _, e := range o.Env {
+		args = append(args, "--env", e)
+	}
+	if o.ClearCache {
+		args = append(args, "--clear-cache")
+	}
+
+	log.Logger().Infof("Building image %s with builder %s", util.ColorInfo(image), util.ColorInfo(builder))
+	_, err = o.RunStepCommand(&util.Command{
+		Dir:  dir,
+		Name: "pack",
+		Args: args,
+		Out:  o.Out,
+		Err:  o.Err,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to build image %s with Cloud Native Buildpacks", image)
+	}
+	return nil
+}
+
+// findVersion returns the version of the --version flag, the $VERSION environment variable or the version file
+func (o *StepBuildPackCNBOptions) findVersion(dir string) (string, error) {
+	if o.Version != "" {
+		return o.Version, nil
+	}
+	if version := os.Getenv("VERSION"); version != "" {
+		return version, nil
+	}
+	path := o.VersionFile
+	if path == "" {
+		path = defaultVersionFile
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	exists, err := util.FileExists(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if exists {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read version file %s", path)
+		}
+		if version := strings.TrimSpace(string(data)); version != "" {
+			return version, nil
+		}
+	}
+	return "", errors.Errorf("no version specified with --version, $VERSION or the version file %s", path)
+}
+
+// defaultImage returns the image name used by the release of the application so its chart and promotion pick it up
+func (o *StepBuildPackCNBOptions) defaultImage(dir string) (string, error) {
+	gitInfo, err := o.FindGitInfo(dir)
+	if err != nil {
+		log.Logger().Warnf("failed to find git repository: %s", err.Error())
+	}
+	projectConfig, _, err := config.LoadProjectConfig(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load the project configuration in %s", dir)
+	}
+	dockerRegistry := o.GetDockerRegistry(projectConfig)
+	dockerRegistryOrg := o.GetDockerRegistryOrg(projectConfig, gitInfo)
+	appName := os.Getenv("APP_NAME")
+	if appName == "" {
+		appName = os.Getenv("REPO_NAME")
+	}
+	if appName == "" && gitInfo != nil {
+		appName = gitInfo.Name
+	}
+	if dockerRegistry == "" || dockerRegistryOrg == "" || appName == "" {
+		return "", errors.Errorf("could not generate the image name for docker registry %q, docker registry organisation %q and application %q so please specify --image", dockerRegistry, dockerRegistryOrg, appName)
+	}
+	return dockerRegistry + "/" + dockerRegistryOrg + "/" + naming.ToValidName(appName), nil
+}
+
+// resolveBuilder returns the builder image pinned to the version and digest in the version stream failing if the
+// builder has no tag or digest and is not in the version stream
+func (o *StepBuildPackCNBOptions) resolveBuilder() (string, error) {
+	builder := o.Builder
+	if builder == "" {
+		builder = DefaultCNBBuilderImage
+	}
+	if o.versionResolver == nil {
+		resolver, err := o.GetVersionResolver()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to create the version resolver")
+		}
+		o.versionResolver = resolver
+	}
+	answer, err := o.versionResolver.ResolveDockerImageDigest(builder)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve the builder image %s in the version stream", builder)
+	}
+	if !isPinnedImage(answer) {
+		return "", errors.Errorf("the builder image %s is not in the version stream %s so please specify its tag or digest via --builder", builder, o.versionResolver.VersionsDir)
+	}
+	return answer, nil
+}
+
+// isPinnedImage returns true if the docker image has a tag or digest
+func isPinnedImage(image string) bool {
+	return strings.Contains(image, "@") || strings.LastIndex(image, ":") > strings.LastIndex(image, "/")
+}
@@ -0,0 +1,75 @@
+// +build unit
+
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepBuildPackCNB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-step-buildpack-cnb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	versionFile := filepath.Join(dir, "docker", "paketobuildpacks", "builder.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(versionFile), util.DefaultWritePermissions))
+	require.NoError(t, ioutil.WriteFile(versionFile, []byte("version: 0.0.35-base\ndigest: sha256:abc\n"), util.DefaultFileWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.3\n"), util.DefaultFileWritePermissions))
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	var commands []*util.Command
+	o := &StepBuildPackCNBOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+			CommandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, cmd)
+				return "", nil
+			},
+		},
+		Dir:             dir,
+		Image:           "10.0.0.1:5000/myorg/myapp",
+		Builder:         DefaultCNBBuilderImage,
+		Env:             []string{"BP_JVM_VERSION=11"},
+		versionResolver: &versionstream.VersionResolver{VersionsDir: dir},
+	}
+	require.NoError(t, o.Run())
+	require.Len(t, commands, 1)
+	assert.Equal(t, "pack", commands[0].Name)
+	assert.Equal(t, []string{"build", "10.0.0.1:5000/myorg/myapp:1.2.3",
+		"--builder", "paketobuildpacks/builder:0.0.35-base@sha256:abc",
+		"--path", dir,
+		"--publish",
+		"--env", "BP_JVM_VERSION=11",
+	}, commands[0].Args)
+
+	o.Version = "1.2.4"
+	o.Builder = "heroku/buildpacks:18"
+	o.NoPublish = true
+	commands = nil
+	require.NoError(t, o.Run())
+	require.Len(t, commands, 1)
+	assert.Equal(t, []string{"build", "10.0.0.1:5000/myorg/myapp:1.2.4",
+		"--builder", "heroku/buildpacks:18",
+		"--path", dir,
+		"--env", "BP_JVM_VERSION=11",
+	}, commands[0].Args)
+
+	o.Builder = "heroku/buildpacks"
+	commands = nil
+	assert.Error(t, o.Run(), "builders which are not in the version stream should be pinned")
+	assert.Empty(t, commands)
+
+	o.Builder = DefaultCNBBuilderImage
+	o.Env = []string{"BP_JVM_VERSION"}
+	assert.Error(t, o.Run())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -30,6 +31,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
 	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile/gitresolver"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/tekton"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
@@ -39,6 +41,7 @@ import (
 	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
 )
@@ -110,6 +113,7 @@ type StepCreateTaskOptions struct {
 	NoKaniko            bool
 	SemanticRelease     bool
 	KanikoImage         string
+	ManifestToolImage   string
 	KanikoSecretMount   string
 	KanikoSecret        string
 	KanikoSecretKey     string
@@ -127,6 +131,8 @@ type StepCreateTaskOptions struct {
 	pipelineParams       []pipelineapi.Param
 	version              string
 	previewVersionPrefix string
+	tagPrefix            string
+	baseBranch           string
 	VersionResolver      *versionstream.VersionResolver
 	CloneDir             string
 }
@@ -210,6 +216,7 @@ func (o *StepCreateTaskOptions) AddCommonFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.NoReleasePrepare, "no-release-prepare", "", false, "Disables creating the release version number and tagging git and triggering the release pipeline from the new tag")
 	cmd.Flags().BoolVarP(&o.NoKaniko, "no-kaniko", "", false, "Disables using kaniko directly for building docker images")
 	cmd.Flags().StringVarP(&o.KanikoImage, "kaniko-image", "", syntax.KanikoDockerImage, "The docker image for Kaniko")
+	cmd.Flags().StringVarP(&o.ManifestToolImage, "manifest-tool-image", "", syntax.ManifestToolDockerImage, "The docker image for pushing the manifest lists of multi-arch images built with Kaniko")
 	cmd.Flags().StringVarP(&o.KanikoSecretMount, "kaniko-secret-mount", "", kanikoSecretMount, "The mount point of the Kaniko secret")
 	cmd.Flags().StringVarP(&o.KanikoSecret, "kaniko-secret", "", kanikoSecretName, "The name of the kaniko secret")
 	cmd.Flags().StringVarP(&o.KanikoSecretKey, "kaniko-secret-key", "", kanikoSecretKey, "The key in the Kaniko Secret to mount")
@@ -257,6 +264,9 @@ func (o *StepCreateTaskOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "Unable to find or parse PULL_REFS from custom environment")
 	}
+	if pr != nil {
+		o.baseBranch = pr.BaseBranch
+	}
 
 	exists, err := o.effectiveProjectConfigExists()
 	if err != nil {
@@ -492,6 +502,13 @@ func (o *StepCreateTaskOptions) createEffectiveProjectConfigFromOptions(tektonCl
 	if err != nil {
 		return nil, err
 	}
+	if o.ManifestToolImage == "" {
+		o.ManifestToolImage = syntax.ManifestToolDockerImage
+	}
+	o.ManifestToolImage, err = o.VersionResolver.ResolveDockerImageDigest(o.ManifestToolImage)
+	if err != nil {
+		return nil, err
+	}
 	if o.KanikoSecretMount == "" {
 		o.KanikoSecretMount = kanikoSecretMount
 	}
@@ -585,6 +602,7 @@ func (o *StepCreateTaskOptions) createEffectiveProjectConfig(packsDir string, pr
 		DefaultImage:      o.DefaultImage,
 		UseKaniko:         !o.NoKaniko,
 		KanikoImage:       o.KanikoImage,
+		ManifestToolImage: o.ManifestToolImage,
 		ProjectID:         o.ProjectID,
 		DockerRegistry:    o.DockerRegistry,
 		DockerRegistryOrg: o.DockerRegistryOrg,
@@ -648,6 +666,12 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 		DefaultImage:       "",
 		InterpretMode:      o.InterpretMode,
 	}
+	if effectivePipeline.Options != nil && len(effectivePipeline.Options.Caches) > 0 && !o.InterpretMode {
+		crdParams.CacheStorage, err = o.cacheStorage(ns)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	pipeline, tasks, structure, err := effectivePipeline.GenerateCRDs(crdParams)
 	if err != nil {
@@ -675,6 +699,56 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 	return tektonCRDs, nil
 }
 
+// cacheStorage returns where the caches of the pipeline are stored, the bucket of the cache storage location of the
+// team or else the jx-build-cache PersistentVolumeClaim, or nil if there is neither
+func (o *StepCreateTaskOptions) cacheStorage(ns string) (*syntax.CacheStorage, error) {
+	storage := &syntax.CacheStorage{
+		Key: o.cacheKey(),
+		// lets not let untrusted pull requests change the caches restored by the release pipelines
+		RestoreOnly: o.PipelineKind == jenkinsfile.PipelineKindPullRequest,
+	}
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range settings.StorageLocations {
+		if location.Classifier == kube.ClassificationCache && location.BucketURL != "" {
+			storage.BucketURL = location.BucketURL
+			return storage, nil
+		}
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = kubeClient.CoreV1().PersistentVolumeClaims(ns).Get(syntax.DefaultCacheClaimName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Logger().Warnf("ignoring the caches of the pipeline as there is no %s storage location with a bucket nor PersistentVolumeClaim %s in namespace %s",
+				kube.ClassificationCache, syntax.DefaultCacheClaimName, ns)
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to find PersistentVolumeClaim %s in namespace %s", syntax.DefaultCacheClaimName, ns)
+	}
+	storage.ClaimName = syntax.DefaultCacheClaimName
+	return storage, nil
+}
+
+// cacheKey returns the folder the caches of the pipeline are stored in which contains the kind of the pipeline and the
+// branch. Pull requests use the caches of the release pipeline of their base branch
+func (o *StepCreateTaskOptions) cacheKey() string {
+	kind := o.PipelineKind
+	branch := o.Branch
+	if kind == jenkinsfile.PipelineKindPullRequest {
+		kind = jenkinsfile.PipelineKindRelease
+		branch = o.baseBranch
+		if branch == "" {
+			branch = "master"
+		}
+	}
+	return strings.Join([]string{naming.ToValidName(o.GitInfo.Organisation), naming.ToValidName(o.GitInfo.Name), naming.ToValidName(kind), naming.ToValidName(branch)}, "/")
+}
+
 func (o *StepCreateTaskOptions) loadProjectConfig() (*config.ProjectConfig, string, error) {
 	if o.Context != "" {
 		fileName := filepath.Join(o.CloneDir, fmt.Sprintf("jenkins-x-%s.yml", o.Context))
@@ -1014,7 +1088,7 @@ func (o *StepCreateTaskOptions) modifyEnvVars(container *corev1.Container, globa
 		}
 	}
 
-	if isKanikoExecutorStep(container) && !o.NoKaniko {
+	if (isKanikoExecutorStep(container) || isManifestToolStep(container)) && !o.NoKaniko {
 		if kube.GetSliceEnvVar(envVars, "GOOGLE_APPLICATION_CREDENTIALS") == nil {
 			envVars = append(envVars, corev1.EnvVar{
 				Name:  "GOOGLE_APPLICATION_CREDENTIALS",
@@ -1042,7 +1116,7 @@ func (o *StepCreateTaskOptions) modifyEnvVars(container *corev1.Container, globa
 func (o *StepCreateTaskOptions) modifyVolumes(container *corev1.Container, volumes []corev1.Volume) []corev1.Volume {
 	answer := volumes
 
-	if isKanikoExecutorStep(container) && !o.NoKaniko {
+	if (isKanikoExecutorStep(container) || isManifestToolStep(container)) && !o.NoKaniko {
 		kubeClient, ns, err := o.KubeClientAndDevNamespace()
 		if err != nil {
 			log.Logger().Warnf("failed to find kaniko secret: %s", err)
@@ -1297,7 +1371,7 @@ func (o *StepCreateTaskOptions) setBuildVersion(projectConfig *config.ProjectCon
 			version = "0.0.1"
 		}
 		o.version = version
-		o.setRevisionForReleasePipeline(version)
+		o.setRevisionForReleasePipeline(projectConfig, version)
 		o.pipelineParams = append(o.pipelineParams, pipelineapi.Param{
 			Name:  "version",
 			Value: syntax.StringParamValue(o.version),
@@ -1310,12 +1384,19 @@ func (o *StepCreateTaskOptions) setBuildVersion(projectConfig *config.ProjectCon
 		if release == nil {
 			return fmt.Errorf("no Release pipeline available")
 		}
+		if app := projectConfig.MonorepoApp; app != nil {
+			o.tagPrefix = app.TagPrefix()
+		}
 		sv := release.SetVersion
 		if sv == nil {
 			command := "jx step next-version --use-git-tag-only --tag"
 			if o.SemanticRelease {
 				command = "jx step next-version --semantic-release --tag"
 			}
+			if app := projectConfig.MonorepoApp; app != nil {
+				// lets version each application of the monorepo separately
+				command += fmt.Sprintf(" --tag-prefix %s --dir %s --charts-dir %s", app.TagPrefix(), app.Dir, filepath.Join(app.Dir, "charts", app.Name))
+			}
 			// lets create a default set version pipeline
 			sv = &jenkinsfile.PipelineLifecycle{
 				Steps: []*syntax.Step{
@@ -1336,7 +1417,7 @@ func (o *StepCreateTaskOptions) setBuildVersion(projectConfig *config.ProjectCon
 		if err != nil {
 			return err
 		}
-		o.setRevisionForReleasePipeline(version)
+		o.setRevisionForReleasePipeline(projectConfig, version)
 	} else {
 		// lets use the branch name if we can find it for the version number
 		branch := o.Branch
@@ -1367,9 +1448,11 @@ func (o *StepCreateTaskOptions) setBuildVersion(projectConfig *config.ProjectCon
 	return nil
 }
 
-func (o *StepCreateTaskOptions) setRevisionForReleasePipeline(version string) {
+func (o *StepCreateTaskOptions) setRevisionForReleasePipeline(projectConfig *config.ProjectConfig, version string) {
 	if o.UseBranchAsRevision {
 		o.Revision = o.Branch
+	} else if projectConfig.MonorepoApp != nil {
+		o.Revision = projectConfig.MonorepoApp.TagPrefix() + "v" + version
 	} else {
 		o.Revision = "v" + version
 	}
@@ -1409,6 +1492,11 @@ func (o *StepCreateTaskOptions) runStepCommand(step *syntax.Step) error {
 		Err:  o.Err,
 		Dir:  o.CloneDir,
 	}
+	if o.tagPrefix != "" {
+		// lets tag the version of a monorepo application with its prefix even if the build pack set version steps
+		// do not pass --tag-prefix
+		cmd.Env = map[string]string{config.TagPrefixEnvVar: o.tagPrefix}
+	}
 	result, err := cmd.RunWithoutRetry()
 	if err != nil {
 		return err
@@ -1596,3 +1684,16 @@ func isKanikoExecutorStep(container *corev1.Container) bool {
 	return strings.HasPrefix(strings.Join(container.Command, " "), "/kaniko/executor") ||
 		(len(container.Args) > 0 && strings.HasPrefix(strings.Join(container.Args, " "), "/kaniko/executor"))
 }
+
+// isManifestToolStep looks at a container and determines whether it is the step pushing the manifest list of a
+// multi-arch image built with Kaniko, which needs the same registry credentials as Kaniko.
+func isManifestToolStep(container *corev1.Container) bool {
+	image := container.Image
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return path.Base(image) == "manifest-tool" || strings.HasSuffix(container.Name, syntax.ManifestToolStepSuffix)
+}
@@ -620,6 +620,63 @@ func assertTektonCRDs(t *testing.T, testCase testCase, crds *tekton.CRDWrapper,
 	}
 }
 
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+
+	o := &StepCreateTaskOptions{
+		GitInfo:      &gits.GitRepository{Organisation: "MyOrg", Name: "myapp"},
+		PipelineKind: jenkinsfile.PipelineKindRelease,
+		Branch:       "master",
+	}
+	assert.Equal(t, "myorg/myapp/release/master", o.cacheKey())
+
+	o.PipelineKind = jenkinsfile.PipelineKindPullRequest
+	o.Branch = "PR-123"
+	o.baseBranch = "release-1.x"
+	assert.Equal(t, "myorg/myapp/release/release-1.x", o.cacheKey(), "pull requests should restore the caches of their base branch")
+}
+
+func TestSetBuildVersionTagsMonorepoAppWithBuildPackSetVersion(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-set-build-version")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	projectConfig := &config.ProjectConfig{
+		MonorepoApp: &config.MonorepoAppConfig{Name: "foo", Dir: "services/foo"},
+		PipelineConfig: &jenkinsfile.PipelineConfig{
+			Pipelines: jenkinsfile.Pipelines{
+				Release: &jenkinsfile.PipelineLifecycles{
+					SetVersion: &jenkinsfile.PipelineLifecycle{
+						Steps: []*syntax.Step{
+							{
+								Name:    "next-version",
+								Command: "printf '%s' \"$" + config.TagPrefixEnvVar + "\" > tag-prefix && echo 1.2.3 > VERSION",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	o := &StepCreateTaskOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		PipelineKind: jenkinsfile.PipelineKindRelease,
+		CloneDir:     dir,
+	}
+	err = o.setBuildVersion(projectConfig)
+	assert.NoError(t, err)
+
+	tagPrefix, err := ioutil.ReadFile(filepath.Join(dir, "tag-prefix"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo/", string(tagPrefix), "the set version steps should be passed the tag prefix of the application")
+	assert.Equal(t, "1.2.3", o.version)
+	assert.Equal(t, "foo/v1.2.3", o.Revision)
+}
+
 func assertLoadPodTemplates(t *testing.T) map[string]*corev1.Pod {
 	fileName := filepath.Join("test_data", "step_create_task", "PodTemplates.yml")
 	if tests.AssertFileExists(t, fileName) {
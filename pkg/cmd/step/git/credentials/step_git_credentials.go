@@ -9,6 +9,7 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/gits/credentialhelper"
 	"github.com/pkg/errors"
 
@@ -263,6 +264,17 @@ func (o *StepGitCredentialsOptions) CreateGitCredentialsFromAuthService(authConf
 			if password == "" {
 				password = gitAuth.Password
 			}
+			if password == "" && gitAuth.IsGitHubApp() {
+				token, err := gits.CreateGitHubAppInstallationToken(server.URL, gitAuth)
+				if err != nil {
+					log.Logger().Warnf("Failed to create the GitHub App installation token for git service URL %q: %s", server.URL, err.Error())
+					continue
+				}
+				password = token
+				if username == "" {
+					username = gits.GitHubAppTokenUsername
+				}
+			}
 			if username == "" || password == "" {
 				log.Logger().Warnf("Empty auth config for git service URL %q", server.URL)
 				continue
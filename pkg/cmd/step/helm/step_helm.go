@@ -1,16 +1,32 @@
 package helm
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
 	"github.com/ghodss/yaml"
 	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream/versionstreamrepo"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/helm/pkg/chartutil"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
@@ -27,6 +43,34 @@ const (
 	REPO_OWNER    = "REPO_OWNER"
 	REPO_NAME     = "REPO_NAME"
 	PULL_PULL_SHA = "PULL_PULL_SHA"
+
+	// OnMissingError fails if a dependency version cannot be resolved
+	OnMissingError = "error"
+	// OnMissingPrompt prompts for the version of a dependency which cannot be resolved
+	OnMissingPrompt = "prompt"
+	// OnMissingSkip leaves a dependency which cannot be resolved without a version
+	OnMissingSkip = "skip"
+
+	// PinPolicyExact resolves missing dependency versions to the stable version in the version stream
+	PinPolicyExact = "exact"
+	// PinPolicyPatch resolves dependency versions to the highest patch of their current major and minor version
+	PinPolicyPatch = "patch"
+	// PinPolicyMinor resolves dependency versions to the highest minor and patch of their current major version
+	PinPolicyMinor = "minor"
+
+	// DefaultResolveWorkers the default number of dependencies whose versions are resolved concurrently
+	DefaultResolveWorkers = 8
+)
+
+var (
+	// OnMissingModes the supported modes if a dependency version cannot be resolved
+	OnMissingModes = []string{OnMissingError, OnMissingPrompt, OnMissingSkip}
+
+	// PinPolicies the supported policies for resolving dependency versions
+	PinPolicies = []string{PinPolicyExact, PinPolicyPatch, PinPolicyMinor}
+
+	// defaultProviderValuesHierarchy the provider values layers used if no hierarchy is configured
+	defaultProviderValuesHierarchy = []string{"${provider}"}
 )
 
 // StepHelmOptions contains the command line flags
@@ -36,8 +80,66 @@ type StepHelmOptions struct {
 	Dir         string
 	https       bool
 	GitProvider string
+	HelmBinary  string
+
+	DenyListFile      string
+	DenyListFallback  bool
+	ReportFile        string
+	ReportFormat      string
+	SuggestOnMiss     bool
+	ResolvedOutput    string
+	CheckKubeVersion  bool
+	VersionStreamFlat bool
+	GitHubURL         string
+	GitHubToken       string
+	ValidateValues    bool
+	ValidateSchema    bool
+	ValuesFiles       []string
+	ValuesCacheTTL    time.Duration
+	Retries           int
+	RetryBackoff      time.Duration
+	OnMissing         string
+	GraphFile         string
+	NameTransforms    string
+	ExportEnvFile     string
+	PinPolicy         string
+	ForceRewrite      bool
+	Proxy             string
+	AuditPrefixes     bool
+	WarnStale         int
+	ResolveWorkers    int
+	Output            string
+	KustomizeDir      string
+	Timings           bool
+	MetricsGateway    string
 
-	versionResolver *versionstream.VersionResolver
+	ProviderValuesConfigMap string
+	ProviderValuesHierarchy []string
+	VersionStreamCacheTTL   time.Duration
+	Offline                 bool
+	VerifyCharts            bool
+	Keyring                 string
+	CosignKey               string
+
+	IncludePrereleaseInReport bool
+
+	versionResolver        *versionstream.VersionResolver
+	versionResolverFactory func(url string, ref string) (*versionstream.VersionResolver, error)
+	denyList               *versionstream.DenyList
+	nameTransforms         *versionstream.ChartNameTransforms
+	report                 *ResolutionReport
+	kubeVersion            *semver.Version
+	chartIndexes           map[string]*helm.ChartIndex
+	httpClient             *http.Client
+	gitHubReleasesProvider gits.GitProvider
+	versionPrompt          *bufio.Reader
+	commandRunner          func(*util.Command) (string, error)
+	cacheLock              *sync.Mutex
+	sopsValues             *helm.SopsValues
+	timings                *phaseTimings
+	valuesCacheDir         string
+	// noCluster generates the values without accessing Vault or the cluster, e.g. for 'jx step helm template'
+	noCluster bool
 }
 
 // NewCmdStepHelm Steps a command object for the "step" command
@@ -61,10 +163,16 @@ func NewCmdStepHelm(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepHelmApply(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmBuild(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmDelete(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmDiffValues(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmEnv(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmExport(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmInstall(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmLint(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmList(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmRelease(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmRollback(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmTemplate(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmVerifyImages(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmVersion(commonOpts))
 	return cmd
 }
@@ -79,115 +187,942 @@ func (o *StepHelmOptions) addStepHelmFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.https, "clone-https", "", true, "Clone the environment Git repo over https rather than ssh which uses `git@foo/bar.git`")
 	cmd.Flags().BoolVarP(&o.RemoteCluster, "remote", "", false, "If enabled assume we are in a remote cluster such as a stand alone Staging/Production cluster")
 	cmd.Flags().StringVarP(&o.GitProvider, "git-provider", "", "github.com", "The Git provider for the environment Git repository")
+	cmd.Flags().StringVarP(&o.HelmBinary, "helm-binary", "", "", "The optional path or name of the helm executable to use rather than the default helm binary")
+	cmd.Flags().IntVarP(&o.Retries, "retries", "", helm.DefaultRetries, "The maximum number of attempts of helm fetch, install and chart repository operations which fail with a transient error such as a 502 from the chart repository or a timeout. Values less than 2 disable retries")
+	cmd.Flags().DurationVarP(&o.RetryBackoff, "retry-backoff", "", helm.DefaultRetryBackoff, "The delay before the first retry of a transient failure which doubles for each subsequent retry")
+}
+
+// addValidateValuesFlag adds the flag to validate the values files before they are used
+func (o *StepHelmOptions) addValidateValuesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.ValidateValues, "validate-values", "", true, "Validates that each values file parses as YAML before it is used so that any errors report the invalid file")
+}
+
+// addValidateSchemaFlag adds the flag to validate the merged values against the chart's values schema
+func (o *StepHelmOptions) addValidateSchemaFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.ValidateSchema, "validate-schema", "", true, "Validates the merged values against the '"+helm.ValuesSchemaFileName+"' file of the chart, if there is one, failing with the keys which violate the schema")
+}
+
+// validateValuesSchema validates the merged values files against the values schema in the chart directory
+func (o *StepHelmOptions) validateValuesSchema(dir string, valuesFiles []string) error {
+	if !o.ValidateSchema {
+		return nil
+	}
+	err := helm.ValidateChartValuesSchema(dir, valuesFiles...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate the values of the chart in %s", dir)
+	}
+	return nil
+}
+
+// configureHelmBinary validates the helm executable specified via --helm-binary and configures helm to use it, retrying
+// any transient failures
+func (o *StepHelmOptions) configureHelmBinary() error {
+	o.configureRetries()
+	if o.HelmBinary == "" {
+		return nil
+	}
+	path, err := exec.LookPath(o.HelmBinary)
+	if err != nil {
+		return errors.Wrapf(err, "the helm binary %s is not an executable", o.HelmBinary)
+	}
+	h := o.Helm()
+	h.SetHelmBinary(path)
+	version, err := h.Version(false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the version of the helm binary %s", path)
+	}
+	log.Logger().Infof("Using helm binary %s version %s", util.ColorInfo(path), util.ColorInfo(version))
+	return nil
+}
+
+// configureRetries wraps helm so that its fetch, install and chart repository operations are retried if --retries
+// allows more than one attempt
+func (o *StepHelmOptions) configureRetries() {
+	if o.Retries < 2 {
+		return
+	}
+	h := o.Helm()
+	if _, ok := h.(*helm.RetryHelmer); ok {
+		return
+	}
+	o.SetHelm(helm.NewRetryHelmer(h, o.retryPolicy()))
+}
+
+// retryPolicy returns the policy for retrying transient helm and chart repository failures
+func (o *StepHelmOptions) retryPolicy() *helm.RetryPolicy {
+	return &helm.RetryPolicy{
+		Attempts: o.Retries,
+		Backoff:  o.RetryBackoff,
+	}
+}
+
+func (o *StepHelmOptions) addProviderValuesFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ProviderValuesConfigMap, "provider-values-configmap", "", "", "The optional ConfigMap of the form 'namespace/name:key' containing the kubernetes provider specific override values.tmpl.yaml template which is used if there is no template in the --provider-values-dir")
+	cmd.Flags().StringSliceVarP(&o.ProviderValuesHierarchy, "provider-values-hierarchy", "", nil, "The directories of the --provider-values-dir whose values.tmpl.yaml files are merged in order so that later layers override earlier ones, e.g. 'kubernetes,${provider},${provider}-${region}'. The ${provider}, ${region}, ${zone} and ${cluster} variables are replaced from the 'jx-requirements.yml' and layers using a variable without a value are skipped. Defaults to the 'cluster.providerValuesHierarchy' of the 'jx-requirements.yml' or '${provider}'")
+}
+
+func (o *StepHelmOptions) addVersionResolutionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.DenyListFile, "deny-list", "", "", "The optional YAML file mapping charts to yanked versions which must never be resolved from the version stream")
+	cmd.Flags().BoolVarP(&o.DenyListFallback, "deny-list-fallback", "", false, "If the version stream resolves a denied version then use the next lower stable version from the chart repository rather than failing")
+	cmd.Flags().StringVarP(&o.ReportFile, "report", "", "", "The optional file to write a report of the dependency versions resolved from the version stream grouped by requirements file")
+	cmd.Flags().BoolVarP(&o.SuggestOnMiss, "suggest-on-miss", "", false, "If a dependency cannot be found in the version stream then suggest the closest matching chart names in the error message")
+	cmd.Flags().StringVarP(&o.ResolvedOutput, "resolved-output", "", "", "The optional file name, relative to each 'requirements.yaml' file, to write the resolved requirements to rather than modifying the 'requirements.yaml' file in place. e.g. 'requirements.resolved.yaml'")
+	cmd.Flags().BoolVarP(&o.CheckKubeVersion, "check-kube-version", "", false, "Verifies that the 'kubeVersion' constraint of each resolved chart version in its chart repository is satisfied by the version of the current cluster")
+	cmd.Flags().DurationVarP(&o.VersionStreamCacheTTL, "version-stream-cache-ttl", "", 0, "If specified the version stream is cached in '~/.jx/"+versionstreamrepo.CacheDirName+"' by URL and git ref and is only fetched again once the cached clone is older than this duration. e.g. '1h'")
+	cmd.Flags().BoolVarP(&o.Offline, "offline", "", false, "Uses the version stream cached in '~/.jx/"+versionstreamrepo.CacheDirName+"' and any remote values files cached in '~/.jx/cache/"+ValuesCacheDirName+"' regardless of their age rather than fetching them. Fails if they have not been cached")
+	cmd.Flags().BoolVarP(&o.VersionStreamFlat, "version-stream-flat", "", false, "Forces the version stream to be read from a single flat 'versions.yaml' file rather than the directory per kind layout. By default the flat layout is detected if there is a 'versions.yaml' file and no kind directories")
+	cmd.Flags().StringVarP(&o.GitHubURL, "github-url", "", "https://github.com", "The URL of the GitHub server whose Releases API is used to resolve the versions of dependencies with a 'github.com/owner/repo' repository")
+	cmd.Flags().StringVarP(&o.GitHubToken, "github-token", "", "", "The API token used to query the GitHub Releases API. Defaults to the $"+gitHubTokenEnvVar+" environment variable")
+	cmd.Flags().StringVarP(&o.OnMissing, "on-missing", "", OnMissingError, fmt.Sprintf("What to do if a dependency version cannot be found in the version stream. One of: %s. The '%s' mode is only supported when attached to a terminal", strings.Join(OnMissingModes, ", "), OnMissingPrompt))
+	cmd.Flags().StringVarP(&o.GraphFile, "graph", "", "", "The optional file to write a Graphviz DOT graph of the charts and their resolved dependency versions to")
+	cmd.Flags().StringVarP(&o.NameTransforms, "name-transforms", "", "", "The optional YAML file of per chart repository transforms (stripPrefix and/or replace with) applied to the dependency names before looking them up in the version stream")
+	cmd.Flags().StringVarP(&o.ExportEnvFile, "export-env", "", "", "The optional file to write the resolved dependency versions to as 'CHART_<NAME>_VERSION=<version>' lines suitable for sourcing in a shell. The dependency alias or name is upper cased with any character other than a letter, digit or underscore replaced by an underscore")
+	cmd.Flags().BoolVarP(&o.IncludePrereleaseInReport, "include-prerelease-in-report", "", false, "Flags whether each version in the resolution report is a semantic version prerelease")
+	cmd.Flags().StringVarP(&o.PinPolicy, "pin-policy", "", PinPolicyExact, fmt.Sprintf("How dependency versions are resolved. One of: %s. The '%s' policy resolves missing versions from the version stream whereas the '%s' and '%s' policies resolve the highest version in the chart repository within the major.minor or major of the version each dependency already declares", strings.Join(PinPolicies, ", "), PinPolicyExact, PinPolicyPatch, PinPolicyMinor))
+	cmd.Flags().BoolVarP(&o.ForceRewrite, "force-rewrite", "", false, "Always loads the version stream and rewrites the requirements files even if every dependency already has a version")
+	cmd.Flags().StringVarP(&o.Proxy, "proxy", "", "", "The URL of the HTTP proxy used to fetch chart repository indexes. Defaults to the $HTTP_PROXY, $HTTPS_PROXY and $NO_PROXY environment variables")
+	cmd.Flags().BoolVarP(&o.AuditPrefixes, "audit-prefixes", "", false, "Verifies that every chart repository used by the requirements files has a prefix in the 'charts/repositories.yml' file of the version stream and fails listing all the repositories without one")
+	cmd.Flags().IntVarP(&o.ResolveWorkers, "resolve-workers", "", DefaultResolveWorkers, "The maximum number of dependencies of each 'requirements.yaml' file whose versions are resolved concurrently")
+	cmd.Flags().IntVarP(&o.WarnStale, "warn-stale", "", 0, "If greater than zero then warn if a resolved version is more than this number of minor versions, or any major version, behind the latest stable version in its chart repository")
+	cmd.Flags().StringVarP(&o.ReportFormat, "report-format", "", ReportFormatJSON, fmt.Sprintf("The format of the resolution report. One of: %s", strings.Join(ReportFormats, ", ")))
 }
 
+// discoverValuesFiles returns the values files of the chart directory replacing any SOPS encrypted values files with
+// their decrypted values
 func (o *StepHelmOptions) discoverValuesFiles(dir string) ([]string, error) {
-	valuesFiles := []string{}
-	for _, name := range []string{"values.yaml", helm.SecretsFileName, "myvalues.yaml"} {
-		path := filepath.Join(dir, name)
-		exists, err := util.FileExists(path)
+	valuesFiles, err := o.findValuesFiles(dir, []string{"values.yaml", helm.SecretsFileName, "myvalues.yaml", "*" + helm.SopsFileSuffix})
+	if err != nil {
+		return valuesFiles, err
+	}
+	valuesFiles, err = o.decryptSopsValuesFiles(valuesFiles)
+	if err != nil {
+		return valuesFiles, err
+	}
+	if o.ValidateValues {
+		err := o.validateValuesFiles(valuesFiles)
 		if err != nil {
 			return valuesFiles, err
 		}
-		if exists {
-			valuesFiles = append(valuesFiles, path)
-		}
 	}
 	return valuesFiles, nil
 }
 
+// validateValuesFiles returns an error naming each of the given values files which is not valid YAML
+func (o *StepHelmOptions) validateValuesFiles(valuesFiles []string) error {
+	errs := []error{}
+	for _, valuesFile := range valuesFiles {
+		data, err := ioutil.ReadFile(valuesFile)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to load values file %s", valuesFile))
+			continue
+		}
+		_, err = helm.LoadValues(data)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid YAML in values file %s", valuesFile))
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
 func (o *StepHelmOptions) getOrCreateVersionResolver(requirementsConfig *config.RequirementsConfig) (*versionstream.VersionResolver, error) {
 	if o.versionResolver == nil {
 		vs := requirementsConfig.VersionStream
 
-		var err error
-		o.versionResolver, err = o.CreateVersionResolver(vs.URL, vs.Ref)
+		factory := o.versionResolverFactory
+		if factory == nil {
+			factory = o.CreateVersionResolver
+			if o.VersionStreamCacheTTL > 0 || o.Offline {
+				factory = func(url string, ref string) (*versionstream.VersionResolver, error) {
+					return o.CreateCachedVersionResolver(url, ref, o.VersionStreamCacheTTL, o.Offline)
+				}
+			}
+		}
+		versionStreamURL, err := o.ResolveVersionStreamURL(vs)
+		if err != nil {
+			return nil, err
+		}
+		stopTiming := o.startTiming(PhaseVersionStream, util.SanitizeURL(versionStreamURL))
+		o.versionResolver, err = factory(versionStreamURL, vs.Ref)
+		stopTiming()
 		if err != nil {
 			return o.versionResolver, errors.Wrapf(err, "failed to create version resolver")
 		}
+		o.versionResolver.Flat = o.VersionStreamFlat
 	}
 	return o.versionResolver, nil
 }
 
+// isBlankPrefix returns true if the repository prefix has no path segments, e.g. it is empty or only contains whitespace
+// or slashes, so that the chart names resolve against the root of the version stream
+func isBlankPrefix(prefix string) bool {
+	return strings.Trim(prefix, "/ \t") == ""
+}
+
+// parseCompositeChartReference parses a composite chart reference of the form 'prefix/subpath@name' such as
+// 'internal/team-a@service' returning the full version stream chart name, e.g. 'internal/team-a/service', and the
+// chart name. The subpath is optional and may contain multiple paths
+func parseCompositeChartReference(text string) (string, string, bool) {
+	idx := strings.LastIndex(text, "@")
+	if idx <= 0 || idx == len(text)-1 {
+		return "", "", false
+	}
+	path := text[:idx]
+	name := text[idx+1:]
+	if strings.Contains(name, "/") {
+		return "", "", false
+	}
+	for _, p := range strings.Split(path, "/") {
+		if p == "" {
+			return "", "", false
+		}
+	}
+	return path + "/" + name, name, true
+}
+
 func (o *StepHelmOptions) verifyRequirementsYAML(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string) error {
 	req, err := helm.LoadRequirementsFile(fileName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to load %s", fileName)
 	}
 
+	pinned := false
+	switch o.PinPolicy {
+	case PinPolicyPatch, PinPolicyMinor:
+		pinned = true
+	case PinPolicyExact, "":
+	default:
+		return util.InvalidOption("pin-policy", o.PinPolicy, PinPolicies)
+	}
+
+	if resolver != nil && !pinned {
+		// lets load any flat version stream up front so the resolver is read only while resolving concurrently
+		_, err = resolver.FlatVersionStream()
+		if err != nil {
+			return errors.Wrapf(err, "failed to load the version stream")
+		}
+	}
+	if o.cacheLock == nil {
+		o.cacheLock = &sync.Mutex{}
+	}
+	results, err := o.resolveDependencies(req.Dependencies, func(dep *helm.Dependency) (*resolvedDependency, error) {
+		return o.resolveDependency(resolver, prefixes, fileName, dep, pinned)
+	})
+	if err != nil {
+		return err
+	}
+
 	modified := false
-	for _, dep := range req.Dependencies {
-		if dep.Version == "" {
-			name := dep.Alias
-			if name == "" {
-				name = dep.Name
+	blankPrefixCharts := map[string][]string{}
+	for i, dep := range req.Dependencies {
+		r := results[i]
+		if r == nil {
+			continue
+		}
+		if pinned {
+			// lets report every pinned dependency as each version was resolved from the chart repository
+			if o.report != nil {
+				o.report.Add(fileName, r.name, dep.Repository, r.fullChartName, r.version)
 			}
-			repo := dep.Repository
-			if repo == "" {
-				return fmt.Errorf("cannot to find a version for dependency %s in file %s as there is no 'repository'", name, fileName)
+			if r.version != dep.Version {
+				log.Logger().Debugf("pinning dependency %s in file %s from version %s to %s", dep.Name, fileName, dep.Version, r.version)
+				dep.Version = r.version
+				modified = true
 			}
+			continue
+		}
+		if r.version == dep.Version {
+			continue
+		}
+		dep.Version = r.version
+		modified = true
+		if r.blankPrefix && util.StringArrayIndex(blankPrefixCharts[r.version], dep.Name) < 0 {
+			blankPrefixCharts[r.version] = append(blankPrefixCharts[r.version], dep.Name)
+		}
+		if o.report != nil {
+			o.report.Add(fileName, r.name, dep.Repository, r.fullChartName, r.version)
+		}
+		log.Logger().Debugf("adding version %s to dependency %s in file %s", r.version, r.name, fileName)
+	}
+
+	for version, charts := range blankPrefixCharts {
+		if len(charts) > 1 {
+			return fmt.Errorf("refusing to save %s as the charts %s all resolved to version %s using a blank repository prefix which usually means the version stream is misconfigured", fileName, strings.Join(charts, ", "), version)
+		}
+	}
+
+	if o.ForceRewrite {
+		modified = true
+	}
+	outFile := fileName
+	if o.ResolvedOutput != "" {
+		// always write the resolved file so that it can be relied upon even if nothing needed resolving
+		outFile = filepath.Join(filepath.Dir(fileName), o.ResolvedOutput)
+		modified = true
+	}
+	if modified {
+		err = helm.SaveFile(outFile, req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save %s", outFile)
+		}
+		log.Logger().Debugf("adding dependency versions to file %s", outFile)
+	}
+	return nil
+}
 
-			prefix := prefixes.PrefixForURL(repo)
-			if prefix == "" {
-				return fmt.Errorf("the helm repository %s does not have an associated prefix in in the 'charts/repositories.yml' file the version stream, so we cannot default the version in file %s", repo, fileName)
+// resolvedDependency the version resolved for a dependency of a requirements file
+type resolvedDependency struct {
+	name          string
+	fullChartName string
+	version       string
+	blankPrefix   bool
+}
+
+// resolveDependencies resolves the versions of the dependencies concurrently using at most --resolve-workers goroutines.
+// The results are returned in the order of the dependencies, with nil for any which do not need a new version, so that
+// the saved file is deterministic. The errors of all the dependencies are combined
+func (o *StepHelmOptions) resolveDependencies(deps []*helm.Dependency, resolve func(dep *helm.Dependency) (*resolvedDependency, error)) ([]*resolvedDependency, error) {
+	results := make([]*resolvedDependency, len(deps))
+	errs := make([]error, len(deps))
+	workers := o.ResolveWorkers
+	if workers < 1 || o.OnMissing == OnMissingPrompt {
+		// lets prompt for any missing versions in the order of the dependencies
+		workers = 1
+	}
+	if workers > len(deps) {
+		workers = len(deps)
+	}
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = resolve(deps[i])
+			}
+		}()
+	}
+	for i := range deps {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, util.CombineErrors(errs...)
+}
+
+// resolveDependency resolves the version of a single dependency without modifying it so that it can be called
+// concurrently. Returns nil if the dependency already has a version or should be skipped
+func (o *StepHelmOptions) resolveDependency(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string, dep *helm.Dependency, pinned bool) (*resolvedDependency, error) {
+	defer o.startTiming(PhaseResolve, dep.Name)()
+	name := dep.Alias
+	if name == "" {
+		name = dep.Name
+	}
+	if pinned {
+		newVersion, err := o.pinVersion(dep, fileName)
+		if err != nil {
+			return nil, err
+		}
+		return &resolvedDependency{name: name, fullChartName: dep.Name, version: newVersion}, nil
+	}
+	if dep.Version != "" {
+		return nil, nil
+	}
+	repo := dep.Repository
+	if repo == "" {
+		return nil, fmt.Errorf("cannot to find a version for dependency %s in file %s as there is no 'repository'", name, fileName)
+	}
+
+	var err error
+	fullChartName := ""
+	newVersion := ""
+	chartDep := dep
+	blankPrefix := false
+	owner, repoName, gitHosted := parseGitHubRepository(repo)
+	if gitHosted {
+		fullChartName = gitHubRepositoryPrefix + owner + "/" + repoName + "/" + dep.Name
+		newVersion, err = o.resolveGitHubReleaseVersion(dep, owner, repoName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find a version for dependency %s in file %s", name, fileName)
+		}
+	} else {
+		compositeChartName, chartName, composite := parseCompositeChartReference(dep.Name)
+		if composite {
+			// lets look up the chart repository index using the chart name rather than the reference
+			chartDep = &helm.Dependency{}
+			*chartDep = *dep
+			chartDep.Name = chartName
+			fullChartName = compositeChartName
+		} else {
+			prefix, found := prefixes.LookupPrefixForURL(repo)
+			if !found {
+				return nil, fmt.Errorf("the helm repository %s does not have an associated prefix in in the 'charts/repositories.yml' file the version stream, so we cannot default the version in file %s", repo, fileName)
 			}
-			newVersion := ""
-			fullChartName := prefix + "/" + dep.Name
-			newVersion, err := resolver.StableVersionNumber(versionstream.KindChart, fullChartName)
+			blankPrefix = isBlankPrefix(prefix)
+			transforms, err := o.getNameTransforms()
 			if err != nil {
-				return errors.Wrapf(err, "failed to find version of chart %s in file %s", fullChartName, fileName)
+				return nil, err
+			}
+			fullChartName = prefix + "/" + transforms.Transform(repo, dep.Name)
+		}
+		newVersion, err = resolver.StableVersionNumber(versionstream.KindChart, fullChartName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find version of chart %s in file %s", fullChartName, fileName)
+		}
+		if newVersion == "" {
+			missingErr := fmt.Errorf("failed to find a version for dependency %s in file %s in the current version stream - please either add an explicit version to this file or add chart %s to the version stream%s", name, fileName, fullChartName, o.chartSuggestions(resolver, fullChartName))
+			newVersion, err = o.onMissingVersion(name, fileName, missingErr)
+			if err != nil {
+				return nil, err
 			}
 			if newVersion == "" {
-				return fmt.Errorf("failed to find a version for dependency %s in file %s in the current version stream - please either add an explicit version to this file or add chart %s to the version stream", name, fileName, fullChartName)
+				return nil, nil
+			}
+		}
+		if isVersionConstraint(newVersion) {
+			newVersion, err = o.resolveVersionConstraint(chartDep, fullChartName, newVersion)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve dependency %s in file %s", name, fileName)
 			}
-			dep.Version = newVersion
-			modified = true
-			log.Logger().Debugf("adding version %s to dependency %s in file %s", newVersion, name, fileName)
 		}
 	}
-
-	if modified {
-		err = helm.SaveFile(fileName, req)
+	newVersion, err = o.applyDenyList(chartDep, fullChartName, newVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve dependency %s in file %s", name, fileName)
+	}
+	if o.CheckKubeVersion && gitHosted {
+		log.Logger().Warnf("cannot check the kubeVersion of dependency %s in file %s as it is not in a chart repository", name, fileName)
+	} else if o.CheckKubeVersion {
+		err = o.verifyKubeVersion(chartDep, newVersion)
 		if err != nil {
-			return errors.Wrapf(err, "failed to save %s", fileName)
+			return nil, errors.Wrapf(err, "failed to verify dependency %s in file %s", name, fileName)
 		}
-		log.Logger().Debugf("adding dependency versions to file %s", fileName)
+	}
+	if o.WarnStale > 0 && !gitHosted {
+		err = o.warnStaleVersion(chartDep, fullChartName, newVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify dependency %s in file %s", name, fileName)
+		}
+	}
+	return &resolvedDependency{
+		name:          name,
+		fullChartName: fullChartName,
+		version:       newVersion,
+		blankPrefix:   blankPrefix,
+	}, nil
+}
+
+// lockCaches serialises access to the lazily created clients and caches while dependencies are resolved concurrently
+// returning the function to unlock them
+func (o *StepHelmOptions) lockCaches() func() {
+	if o.cacheLock == nil {
+		return func() {}
+	}
+	o.cacheLock.Lock()
+	return o.cacheLock.Unlock
+}
+
+// onMissingVersion handles a dependency whose version cannot be found in the version stream depending on the
+// --on-missing mode. Returns an empty version if the dependency should be skipped
+func (o *StepHelmOptions) onMissingVersion(name string, fileName string, missingErr error) (string, error) {
+	switch o.OnMissing {
+	case OnMissingSkip:
+		log.Logger().Warnf("skipping dependency %s in file %s as its version could not be resolved: %s", name, fileName, missingErr.Error())
+		return "", nil
+	case OnMissingPrompt:
+		return o.promptForVersion(name, fileName)
+	default:
+		return "", missingErr
+	}
+}
+
+// validateOnMissing validates the --on-missing mode before any dependencies are resolved. The prompt mode requires the
+// input of the command to be a terminal unless the versions are read from a test reader
+func (o *StepHelmOptions) validateOnMissing() error {
+	if o.OnMissing != "" && util.StringArrayIndex(OnMissingModes, o.OnMissing) < 0 {
+		return util.InvalidOption("on-missing", o.OnMissing, OnMissingModes)
+	}
+	if o.OnMissing == OnMissingPrompt && o.versionPrompt == nil && !isTerminal(o.GetIOFileHandles().In) {
+		return util.InvalidOptionf("on-missing", o.OnMissing, "the '%s' mode is only supported when attached to a terminal", OnMissingPrompt)
 	}
 	return nil
 }
 
-func (o *StepHelmOptions) replaceMissingVersionsFromVersionStream(requirementsConfig *config.RequirementsConfig, dir string) error {
-	fileName := filepath.Join(dir, helm.RequirementsFileName)
-	exists, err := util.FileExists(fileName)
+// isTerminal returns true if the input is a terminal
+func isTerminal(in io.Reader) bool {
+	file, ok := in.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptForVersion reads the version of the dependency from the input of the command
+func (o *StepHelmOptions) promptForVersion(name string, fileName string) (string, error) {
+	handles := o.GetIOFileHandles()
+	if o.versionPrompt == nil {
+		o.versionPrompt = bufio.NewReader(handles.In)
+	}
+	fmt.Fprintf(handles.Err, "Enter the version of dependency %s in file %s: ", name, fileName)
+	line, err := o.versionPrompt.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrapf(err, "failed to read the version of dependency %s", name)
+	}
+	version := strings.TrimSpace(line)
+	if version == "" {
+		return "", fmt.Errorf("no version entered for dependency %s in file %s", name, fileName)
+	}
+	return version, nil
+}
+
+// chartSuggestions returns a message suggesting the closest chart names in the version stream if --suggest-on-miss is enabled
+func (o *StepHelmOptions) chartSuggestions(resolver *versionstream.VersionResolver, fullChartName string) string {
+	if !o.SuggestOnMiss {
+		return ""
+	}
+	names, err := resolver.StableVersionNames(versionstream.KindChart)
+	if err != nil {
+		log.Logger().Warnf("failed to load the chart names from the version stream: %s", err.Error())
+		return ""
+	}
+	suggestions := util.SuggestionsFor(fullChartName, names, util.DefaultSuggestionsMinimumDistance)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(". Did you mean one of: %s", strings.Join(suggestions, ", "))
+}
+
+func (o *StepHelmOptions) getDenyList() (*versionstream.DenyList, error) {
+	defer o.lockCaches()()
+	if o.denyList == nil && o.DenyListFile != "" {
+		var err error
+		o.denyList, err = versionstream.LoadDenyList(o.DenyListFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load deny list")
+		}
+	}
+	return o.denyList, nil
+}
+
+func (o *StepHelmOptions) getNameTransforms() (*versionstream.ChartNameTransforms, error) {
+	defer o.lockCaches()()
+	if o.nameTransforms == nil && o.NameTransforms != "" {
+		var err error
+		o.nameTransforms, err = versionstream.LoadChartNameTransforms(o.NameTransforms)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load chart name transforms")
+		}
+	}
+	return o.nameTransforms, nil
+}
+
+// applyDenyList returns an error if the resolved version of the chart has been yanked unless we are falling back to
+// the next lower stable version in the chart repository
+func (o *StepHelmOptions) applyDenyList(dep *helm.Dependency, fullChartName string, version string) (string, error) {
+	denyList, err := o.getDenyList()
 	if err != nil {
-		return errors.Wrapf(err, "failed to check for file %s", fileName)
+		return "", err
 	}
-	if !exists {
-		log.Logger().Infof("No requirements file: %s so not checking for missing versions\n", fileName)
+	if !denyList.IsDenied(fullChartName, version) {
+		return version, nil
+	}
+	if !o.DenyListFallback {
+		return "", fmt.Errorf("version %s of chart %s is in the deny list %s", version, fullChartName, o.DenyListFile)
+	}
+	index, err := o.fetchChartIndex(dep.Repository)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find a fallback for denied version %s of chart %s", version, fullChartName)
+	}
+	candidates := []string{}
+	for _, entry := range index.Entries[dep.Name] {
+		candidates = append(candidates, entry.Version)
+	}
+	fallback := denyList.FallbackVersion(fullChartName, version, candidates)
+	if fallback == "" {
+		return "", fmt.Errorf("version %s of chart %s is in the deny list %s and there is no lower stable version in repository %s", version, fullChartName, o.DenyListFile, dep.Repository)
+	}
+	log.Logger().Warnf("version %s of chart %s is in the deny list so using version %s instead", version, fullChartName, fallback)
+	return fallback, nil
+}
+
+// isVersionConstraint returns true if the version from the version stream is a range such as '>=1.2 <2' rather than
+// a concrete version
+func isVersionConstraint(version string) bool {
+	_, err := semver.NewVersion(version)
+	if err == nil {
+		return false
+	}
+	_, err = semver.NewConstraint(version)
+	return err == nil
+}
+
+// resolveVersionConstraint returns the highest version of the chart in its chart repository which satisfies the
+// version constraint from the version stream. The version is returned as the index lists it, e.g. with any 'v' prefix,
+// so that helm can find it in the chart repository
+func (o *StepHelmOptions) resolveVersionConstraint(dep *helm.Dependency, fullChartName string, constraintText string) (string, error) {
+	constraint, err := semver.NewConstraint(constraintText)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version constraint %s for chart %s", constraintText, fullChartName)
+	}
+	index, err := o.fetchChartIndex(dep.Repository)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve the version constraint %s of chart %s", constraintText, fullChartName)
+	}
+	answer := ""
+	var best *semver.Version
+	for _, entry := range index.Entries[dep.Name] {
+		v, err := semver.NewVersion(entry.Version)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+			answer = entry.Version
+		}
+	}
+	if answer != "" {
+		log.Logger().Debugf("resolved the version constraint %s of chart %s to version %s", constraintText, fullChartName, answer)
+		return answer, nil
+	}
+	return "", fmt.Errorf("no version of chart %s in repository %s satisfies the version constraint %s", fullChartName, dep.Repository, constraintText)
+}
+
+// pinVersion returns the highest version of the dependency in its chart repository which matches the major.minor of
+// its current version for the patch pin policy or the major of its current version for the minor pin policy. The
+// version is pinned as the index lists it
+func (o *StepHelmOptions) pinVersion(dep *helm.Dependency, fileName string) (string, error) {
+	if dep.Version == "" {
+		return "", fmt.Errorf("cannot pin dependency %s in file %s using the %s pin policy as it has no version", dep.Name, fileName, o.PinPolicy)
+	}
+	base, err := semver.NewVersion(dep.Version)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot pin dependency %s in file %s using the %s pin policy as its version %s is not a semantic version", dep.Name, fileName, o.PinPolicy, dep.Version)
+	}
+	constraint := fmt.Sprintf(">=%d.0.0 <%d.0.0", base.Major(), base.Major()+1)
+	if o.PinPolicy == PinPolicyPatch {
+		constraint = fmt.Sprintf(">=%d.%d.0 <%d.%d.0", base.Major(), base.Minor(), base.Major(), base.Minor()+1)
+	}
+	answer, err := o.resolveVersionConstraint(dep, dep.Name, constraint)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to pin dependency %s in file %s", dep.Name, fileName)
+	}
+	return answer, nil
+}
+
+// warnStaleVersion warns if the resolved version is more than the --warn-stale number of minor versions, or any major
+// version, behind the latest stable version of the chart in its chart repository
+func (o *StepHelmOptions) warnStaleVersion(dep *helm.Dependency, fullChartName string, version string) error {
+	resolved, err := semver.NewVersion(version)
+	if err != nil {
+		// lets ignore versions which are not semantic versions
+		return nil
+	}
+	index, err := o.fetchChartIndex(dep.Repository)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the latest version of chart %s", fullChartName)
+	}
+	var latest *semver.Version
+	for _, v := range index.Versions(dep.Name) {
+		if len(v.Pre) > 0 {
+			continue
+		}
+		latest, err = semver.NewVersion(v.String())
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse version %s of chart %s", v.String(), fullChartName)
+		}
+	}
+	if latest == nil {
 		return nil
 	}
+	if latest.Major() > resolved.Major() {
+		log.Logger().Warnf("chart %s version %s is behind the latest major version %s", fullChartName, version, latest.String())
+	} else if latest.Major() == resolved.Major() && latest.Minor()-resolved.Minor() > int64(o.WarnStale) {
+		log.Logger().Warnf("chart %s version %s is %d minor versions behind the latest version %s", fullChartName, version, latest.Minor()-resolved.Minor(), latest.String())
+	}
+	return nil
+}
 
-	vs := requirementsConfig.VersionStream
+// verifyKubeVersion returns an error if the chart version declares a 'kubeVersion' constraint which the current cluster
+// does not satisfy
+func (o *StepHelmOptions) verifyKubeVersion(dep *helm.Dependency, version string) error {
+	index, err := o.fetchChartIndex(dep.Repository)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the kubeVersion of chart %s", dep.Name)
+	}
+	entry := index.Entry(dep.Name, version)
+	if entry == nil || entry.KubeVersion == "" {
+		return nil
+	}
+	constraint, err := semver.NewConstraint(entry.KubeVersion)
+	if err != nil {
+		return errors.Wrapf(err, "invalid kubeVersion %s for version %s of chart %s", entry.KubeVersion, version, dep.Name)
+	}
+	kubeVersion, err := o.getKubeVersion()
+	if err != nil {
+		return err
+	}
+	if !constraint.Check(kubeVersion) {
+		return fmt.Errorf("version %s of chart %s requires kubeVersion %s but the cluster is running version %s", version, dep.Name, entry.KubeVersion, kubeVersion.String())
+	}
+	return nil
+}
 
-	log.Logger().Infof("Verifying the helm requirements versions in dir: %s using version stream URL: %s and git ref: %s\n", o.Dir, vs.URL, vs.Ref)
+// getKubeVersion lazily loads the version of the current cluster
+func (o *StepHelmOptions) getKubeVersion() (*semver.Version, error) {
+	defer o.lockCaches()()
+	if o.kubeVersion == nil {
+		kubeClient, err := o.KubeClient()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the kube client")
+		}
+		info, err := kubeClient.Discovery().ServerVersion()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find the kubernetes server version")
+		}
+		v, err := semver.NewVersion(info.GitVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the kubernetes server version %s", info.GitVersion)
+		}
+		// lets ignore any provider suffix such as '-gke.1' as pre-releases never satisfy a version range
+		o.kubeVersion, err = semver.NewVersion(fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the kubernetes server version %s", info.GitVersion)
+		}
+	}
+	return o.kubeVersion, nil
+}
 
-	resolver, err := o.getOrCreateVersionResolver(requirementsConfig)
+// fetchChartIndex fetches the index of the given chart repository caching it for the duration of the command
+func (o *StepHelmOptions) fetchChartIndex(repoURL string) (*helm.ChartIndex, error) {
+	key := strings.TrimSuffix(repoURL, "/")
+	unlock := o.lockCaches()
+	index := o.chartIndexes[key]
+	unlock()
+	if index != nil {
+		return index, nil
+	}
+	httpClient, err := o.getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	err = o.retryPolicy().Retry("fetch the index of chart repository "+repoURL, func() error {
+		index, err = helm.FetchChartIndex(httpClient, repoURL)
+		return err
+	})
 	if err != nil {
-		return errors.Wrapf(err, "failed to create version resolver")
+		return nil, err
+	}
+	unlock = o.lockCaches()
+	defer unlock()
+	if o.chartIndexes == nil {
+		o.chartIndexes = map[string]*helm.ChartIndex{}
+	}
+	o.chartIndexes[key] = index
+	return index, nil
+}
+
+// runCommand runs the given command using the test command runner if there is one
+func (o *StepHelmOptions) runCommand(cmd *util.Command) (string, error) {
+	if o.commandRunner != nil {
+		return o.commandRunner(cmd)
 	}
+	return cmd.RunWithoutRetry()
+}
 
-	prefixes, err := resolver.GetRepositoryPrefixes()
+// getHTTPClient lazily creates the HTTP client used to fetch chart repository indexes. The default client honours the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables whereas the --proxy flag routes every request via the proxy
+func (o *StepHelmOptions) getHTTPClient() (*http.Client, error) {
+	defer o.lockCaches()()
+	if o.httpClient == nil {
+		if o.Proxy == "" {
+			o.httpClient = util.GetClient()
+		} else {
+			proxyURL, err := url.Parse(o.Proxy)
+			if err != nil {
+				return nil, util.InvalidOptionError("proxy", o.Proxy, err)
+			}
+			if proxyURL.Scheme == "" || proxyURL.Host == "" {
+				return nil, util.InvalidOptionf("proxy", o.Proxy, "the proxy must be an absolute URL such as http://proxy.example.com:3128")
+			}
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(proxyURL)
+			o.httpClient = &http.Client{
+				Transport: transport,
+				Timeout:   util.GetClient().Timeout,
+			}
+		}
+	}
+	return o.httpClient, nil
+}
+
+func (o *StepHelmOptions) replaceMissingVersionsFromVersionStream(requirementsConfig *config.RequirementsConfig, dir string, recursive bool) error {
+	fileNames, err := o.findRequirementsFiles(dir, recursive)
 	if err != nil {
-		return errors.Wrapf(err, "failed to load repository prefixes")
+		return err
+	}
+	if len(fileNames) == 0 {
+		log.Logger().Infof("No requirements file in dir: %s so not checking for missing versions\n", dir)
+		return nil
+	}
+
+	if o.ReportFile != "" || o.ExportEnvFile != "" {
+		o.report = NewResolutionReport(dir)
+		o.report.IncludePrerelease = o.IncludePrereleaseInReport
 	}
 
-	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	pinned, err := o.allDependenciesPinned(fileNames)
 	if err != nil {
-		return errors.Wrapf(err, "failed to replace missing versions in file %s", fileName)
+		return err
+	}
+	if pinned {
+		log.Logger().Infof("All the helm requirements in dir: %s have versions so not loading the version stream\n", o.Dir)
+	} else {
+		vs := requirementsConfig.VersionStream
+
+		log.Logger().Infof("Verifying the helm requirements versions in dir: %s using version stream URL: %s and git ref: %s\n", o.Dir, vs.URL, vs.Ref)
+
+		resolver, err := o.getOrCreateVersionResolver(requirementsConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create version resolver")
+		}
+
+		prefixes, err := resolver.GetRepositoryPrefixes()
+		if err != nil {
+			return errors.Wrapf(err, "failed to load repository prefixes")
+		}
+
+		if o.AuditPrefixes {
+			missing, err := o.auditRepositoryPrefixes(prefixes, fileNames)
+			if err != nil {
+				return err
+			}
+			if len(missing) > 0 {
+				for _, repo := range missing {
+					log.Logger().Warnf("the helm repository %s does not have a prefix in the 'charts/repositories.yml' file of the version stream", util.ColorWarning(repo))
+				}
+				return fmt.Errorf("the helm repositories %s do not have a prefix in the 'charts/repositories.yml' file of the version stream", strings.Join(missing, ", "))
+			}
+		}
+
+		for _, fileName := range fileNames {
+			err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+			if err != nil {
+				return errors.Wrapf(err, "failed to replace missing versions in file %s", fileName)
+			}
+		}
+	}
+	if o.GraphFile != "" {
+		err = o.writeDependencyGraph(dir, fileNames)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write the dependency graph")
+		}
+		log.Logger().Infof("Wrote the dependency graph to %s", util.ColorInfo(o.GraphFile))
+	}
+	if o.ExportEnvFile != "" {
+		err = o.report.WriteEnv(o.ExportEnvFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to export the resolved versions")
+		}
+		log.Logger().Infof("Exported the resolved versions to %s", util.ColorInfo(o.ExportEnvFile))
+	}
+	if o.ReportFile != "" {
+		err = o.report.Write(o.ReportFile, o.ReportFormat)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write the resolution report")
+		}
+		log.Logger().Infof("Wrote the resolved versions report to %s", util.ColorInfo(o.ReportFile))
 	}
 	return nil
 }
 
+// auditRepositoryPrefixes returns the sorted distinct chart repositories used by the dependencies in the requirements
+// files which do not have a prefix in the version stream. Local, GitHub hosted and composite references are ignored as
+// they do not need a prefix
+func (o *StepHelmOptions) auditRepositoryPrefixes(prefixes *versionstream.RepositoryPrefixes, fileNames []string) ([]string, error) {
+	answer := []string{}
+	for _, fileName := range fileNames {
+		req, err := helm.LoadRequirementsFile(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s", fileName)
+		}
+		for _, dep := range req.Dependencies {
+			repo := dep.Repository
+			if repo == "" || strings.HasPrefix(repo, "file://") {
+				continue
+			}
+			if _, _, gitHosted := parseGitHubRepository(repo); gitHosted {
+				continue
+			}
+			if _, _, composite := parseCompositeChartReference(dep.Name); composite {
+				continue
+			}
+			if _, found := prefixes.LookupPrefixForURL(repo); !found && util.StringArrayIndex(answer, repo) < 0 {
+				answer = append(answer, repo)
+			}
+		}
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// allDependenciesPinned returns true if every dependency in the requirements files already has a version so that there
+// is no need to load the version stream. It always returns false if the files are rewritten regardless of their versions
+func (o *StepHelmOptions) allDependenciesPinned(fileNames []string) (bool, error) {
+	if o.ForceRewrite || o.AuditPrefixes || o.ResolvedOutput != "" || (o.PinPolicy != "" && o.PinPolicy != PinPolicyExact) {
+		return false, nil
+	}
+	for _, fileName := range fileNames {
+		req, err := helm.LoadRequirementsFile(fileName)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to load %s", fileName)
+		}
+		for _, dep := range req.Dependencies {
+			if dep.Version == "" {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// findRequirementsFiles returns the requirements files in the given directory or the whole directory tree if recursive,
+// which includes the requirements of any locally vendored sub charts in 'charts/<name>' at any depth
+func (o *StepHelmOptions) findRequirementsFiles(dir string, recursive bool) ([]string, error) {
+	fileNames := []string{}
+	if !recursive {
+		fileName := filepath.Join(dir, helm.RequirementsFileName)
+		exists, err := util.FileExists(fileName)
+		if err != nil {
+			return fileNames, errors.Wrapf(err, "failed to check for file %s", fileName)
+		}
+		if exists {
+			fileNames = append(fileNames, fileName)
+		}
+		return fileNames, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == helm.RequirementsFileName {
+			fileNames = append(fileNames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fileNames, errors.Wrapf(err, "failed to find requirements files in dir %s", dir)
+	}
+	return fileNames, nil
+}
+
 func (o *StepHelmOptions) createFuncMap(requirementsConfig *config.RequirementsConfig) (template.FuncMap, error) {
 	funcMap := helm.NewFunctionMap()
 	resolver, err := o.getOrCreateVersionResolver(requirementsConfig)
@@ -206,6 +1141,29 @@ func (o *StepHelmOptions) createFuncMap(requirementsConfig *config.RequirementsC
 		}
 		return version
 	}
+
+	// returns the full image reference pinned by digest if the version stream has one
+	// which can be used like: `{{ versionStreamImage "gcr.io/foo/bar" }}
+	funcMap["versionStreamImage"] = func(image string) string {
+		answer, err := resolver.ResolveDockerImageDigest(image)
+		if err != nil {
+			log.Logger().Errorf("failed to find the image %s in the version stream due to: %s\n", image, err.Error())
+		} else if !strings.Contains(answer, "@") {
+			log.Logger().Warnf("the image %s is not pinned by digest as the version stream has no digest for it\n", answer)
+		}
+		return answer
+	}
+
+	// returns the digest of an image or package
+	// which can be used like: `{{ versionStreamDigest "docker" "gcr.io/foo/bar" }}
+	funcMap["versionStreamDigest"] = func(kindString, name string) string {
+		kind := versionstream.VersionKind(kindString)
+		digest, err := resolver.StableVersionDigest(kind, name)
+		if err != nil {
+			log.Logger().Errorf("failed to find %s digest for %s in the version stream due to: %s\n", kindString, name, err.Error())
+		}
+		return digest
+	}
 	return funcMap, nil
 }
 
@@ -215,53 +1173,207 @@ func (o *StepHelmOptions) overwriteProviderValues(requirements *config.Requireme
 		log.Logger().Warnf("No provider in the requirements file %s\n", requirementsFileName)
 		return valuesData, nil
 	}
-	valuesTmplYamlFile := filepath.Join(providersValuesDir, provider, "values.tmpl.yaml")
-	exists, err := util.FileExists(valuesTmplYamlFile)
-	if err != nil {
-		return valuesData, errors.Wrapf(err, "failed to check if file exists: %s", valuesTmplYamlFile)
-	}
-	log.Logger().Infof("Applying the kubernetes overrides at %s\n", util.ColorInfo(valuesTmplYamlFile))
 
-	if !exists {
-		log.Logger().Warnf("No provider specific values overrides exist in file %s\n", valuesTmplYamlFile)
-		return valuesData, nil
+	layers := o.providerValuesLayers(requirements)
+	overrideDatas := [][]byte{}
+	if providersValuesDir != "" {
+		for _, layer := range layers {
+			valuesTmplYamlFile := filepath.Join(providersValuesDir, layer, "values.tmpl.yaml")
+			exists, err := util.FileExists(valuesTmplYamlFile)
+			if err != nil {
+				return valuesData, errors.Wrapf(err, "failed to check if file exists: %s", valuesTmplYamlFile)
+			}
+			if !exists {
+				log.Logger().Debugf("No provider specific values overrides exist in file %s", valuesTmplYamlFile)
+				continue
+			}
+			log.Logger().Infof("Applying the kubernetes overrides at %s\n", util.ColorInfo(valuesTmplYamlFile))
 
+			funcMap, err := o.createFuncMap(requirements)
+			if err != nil {
+				return valuesData, err
+			}
+
+			overrideData, err := helm.ReadValuesYamlFileTemplateOutput(valuesTmplYamlFile, params, funcMap, requirements)
+			if err != nil {
+				return valuesData, errors.Wrapf(err, "failed to load provider specific helm value overrides %s", valuesTmplYamlFile)
+			}
+			overrideDatas = append(overrideDatas, overrideData)
+		}
 	}
-	funcMap, err := o.createFuncMap(requirements)
-	if err != nil {
-		return valuesData, err
-	}
+	if len(overrideDatas) == 0 && o.ProviderValuesConfigMap != "" {
+		text, err := o.loadProviderValuesConfigMap()
+		if err != nil {
+			return valuesData, err
+		}
+		log.Logger().Infof("Applying the kubernetes overrides from ConfigMap %s\n", util.ColorInfo(o.ProviderValuesConfigMap))
 
-	overrideData, err := helm.ReadValuesYamlFileTemplateOutput(valuesTmplYamlFile, params, funcMap, requirements)
-	if err != nil {
-		return valuesData, errors.Wrapf(err, "failed to load provider specific helm value overrides %s", valuesTmplYamlFile)
+		funcMap, err := o.createFuncMap(requirements)
+		if err != nil {
+			return valuesData, err
+		}
+
+		overrideData, err := helm.ReadValuesYamlTemplateOutput(o.ProviderValuesConfigMap, text, params, funcMap, requirements)
+		if err != nil {
+			return valuesData, errors.Wrapf(err, "failed to load provider specific helm value overrides from ConfigMap %s", o.ProviderValuesConfigMap)
+		}
+		overrideDatas = append(overrideDatas, overrideData)
 	}
-	if len(overrideData) == 0 {
+	if len(overrideDatas) == 0 {
+		log.Logger().Warnf("No provider specific values overrides exist in dir %s for the layers %s\n", providersValuesDir, strings.Join(layers, ", "))
 		return valuesData, nil
 	}
 
-	// now lets apply the overrides
+	// now lets apply the overrides of each layer in turn
 	values, err := helm.LoadValues(valuesData)
 	if err != nil {
 		return valuesData, errors.Wrapf(err, "failed to unmarshal the default helm values")
 	}
-
-	overrides, err := helm.LoadValues(overrideData)
-	if err != nil {
-		return valuesData, errors.Wrapf(err, "failed to unmarshal the default helm values")
+	modified := false
+	for _, overrideData := range overrideDatas {
+		if len(overrideData) == 0 {
+			continue
+		}
+		overrides, err := helm.LoadValues(overrideData)
+		if err != nil {
+			return valuesData, errors.Wrapf(err, "failed to unmarshal the provider specific helm value overrides")
+		}
+		util.CombineMapTrees(values, overrides)
+		modified = true
+	}
+	if !modified {
+		return valuesData, nil
 	}
-
-	util.CombineMapTrees(values, overrides)
 
 	data, err := yaml.Marshal(values)
 	return data, err
 }
 
-func (o *StepHelmOptions) getChartValues(targetNS string) ([]string, []string) {
-	return []string{
-			fmt.Sprintf("tags.jx-ns-%s=true", targetNS),
-			fmt.Sprintf("global.jxNs%s=true", util.ToCamelCase(targetNS)),
-		}, []string{
-			fmt.Sprintf("global.jxNs=%s", targetNS),
+// providerValuesLayers returns the directories of the provider values dir whose overrides are applied in order by
+// expanding the variables of the --provider-values-hierarchy or the hierarchy in the requirements
+func (o *StepHelmOptions) providerValuesLayers(requirements *config.RequirementsConfig) []string {
+	hierarchy := o.ProviderValuesHierarchy
+	if len(hierarchy) == 0 {
+		hierarchy = requirements.Cluster.ProviderValuesHierarchy
+	}
+	if len(hierarchy) == 0 {
+		hierarchy = defaultProviderValuesHierarchy
+	}
+	variables := map[string]string{
+		"provider": requirements.Cluster.Provider,
+		"region":   requirements.Cluster.Region,
+		"zone":     requirements.Cluster.Zone,
+		"cluster":  requirements.Cluster.ClusterName,
+	}
+	layers := []string{}
+	for _, layer := range hierarchy {
+		missing := false
+		dir := os.Expand(layer, func(name string) string {
+			value := variables[name]
+			if value == "" {
+				missing = true
+			}
+			return value
+		})
+		if missing || strings.TrimSpace(dir) == "" {
+			log.Logger().Debugf("Skipping the provider values layer %s as it uses a variable without a value", layer)
+			continue
 		}
+		layers = append(layers, dir)
+	}
+	return layers
+}
+
+// loadProviderValuesConfigMap returns the provider values template from the --provider-values-configmap which is of the
+// form 'namespace/name:key'. If the namespace is omitted the current namespace is used
+func (o *StepHelmOptions) loadProviderValuesConfigMap() (string, error) {
+	ref := o.ProviderValuesConfigMap
+	idx := strings.LastIndex(ref, ":")
+	if idx <= 0 || idx == len(ref)-1 {
+		return "", util.InvalidOptionf("provider-values-configmap", ref, "the ConfigMap must be of the form 'namespace/name:key'")
+	}
+	name := ref[:idx]
+	key := ref[idx+1:]
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the kube client")
+	}
+	paths := strings.Split(name, "/")
+	if len(paths) == 2 {
+		ns = paths[0]
+		name = paths[1]
+	}
+	if len(paths) > 2 || ns == "" || name == "" {
+		return "", util.InvalidOptionf("provider-values-configmap", ref, "the ConfigMap must be of the form 'namespace/name:key'")
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load ConfigMap %s in namespace %s", name, ns)
+	}
+	text, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap %s in namespace %s has no key %s", name, ns, key)
+	}
+	return text, nil
+}
+
+// getChartValues returns the values and string values set on the charts applied to the target namespace including any
+// tenancy guardrails of the namespace in the requirements
+func (o *StepHelmOptions) getChartValues(targetNS string, requirements *config.RequirementsConfig) ([]string, []string) {
+	setValues := []string{
+		fmt.Sprintf("tags.jx-ns-%s=true", targetNS),
+		fmt.Sprintf("global.jxNs%s=true", util.ToCamelCase(targetNS)),
+	}
+	setStrings := []string{
+		fmt.Sprintf("global.jxNs=%s", targetNS),
+	}
+	if requirements != nil {
+		tenancyValues, tenancyStrings := tenancyChartValues(requirements.Tenancy.NamespaceGuardrails(targetNS))
+		setValues = append(setValues, tenancyValues...)
+		setStrings = append(setStrings, tenancyStrings...)
+	}
+	return setValues, setStrings
+}
+
+// tenancyChartValues returns the 'global.tenancy' values and string values of the guardrails of a namespace which
+// charts use to render its NetworkPolicy, ResourceQuota and LimitRange
+func tenancyChartValues(guardrails *config.TenancyNamespaceConfig) ([]string, []string) {
+	if guardrails == nil {
+		return nil, nil
+	}
+	const prefix = "global.tenancy."
+	setValues := []string{prefix + "enabled=true"}
+	setStrings := []string{}
+	if policy := guardrails.NetworkPolicy; policy != nil {
+		setValues = append(setValues, prefix+"networkPolicy.enabled=true", fmt.Sprintf("%snetworkPolicy.defaultDeny=%t", prefix, policy.DefaultDeny))
+		if len(policy.AllowNamespaces) > 0 {
+			setValues = append(setValues, fmt.Sprintf("%snetworkPolicy.allowNamespaces={%s}", prefix, strings.Join(policy.AllowNamespaces, ",")))
+		}
+	}
+	if len(guardrails.ResourceQuota) > 0 {
+		setValues = append(setValues, prefix+"resourceQuota.enabled=true")
+		setStrings = append(setStrings, quantityChartValues(prefix+"resourceQuota.hard.", guardrails.ResourceQuota)...)
+	}
+	if limits := guardrails.LimitRange; limits != nil {
+		setValues = append(setValues, prefix+"limitRange.enabled=true")
+		setStrings = append(setStrings, quantityChartValues(prefix+"limitRange.defaultRequest.", limits.DefaultRequest)...)
+		setStrings = append(setStrings, quantityChartValues(prefix+"limitRange.default.", limits.Default)...)
+		setStrings = append(setStrings, quantityChartValues(prefix+"limitRange.max.", limits.Max)...)
+	}
+	return setValues, setStrings
+}
+
+// quantityChartValues returns the sorted values of the resource quantities escaping the dots in resource names such
+// as 'requests.cpu' so that helm does not treat them as nested keys
+func quantityChartValues(prefix string, quantities map[string]string) []string {
+	keys := []string{}
+	for k := range quantities {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	answer := []string{}
+	for _, k := range keys {
+		answer = append(answer, prefix+strings.Replace(k, ".", "\\.", -1)+"="+quantities[k])
+	}
+	return answer
 }
@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/google/uuid"
 	"github.com/mholt/archiver"
 	"github.com/pkg/errors"
@@ -35,15 +38,50 @@ type StepHelmApplyOptions struct {
 	StepHelmOptions
 
 	Namespace          string
+	Environment        string
 	ReleaseName        string
 	Wait               bool
 	Force              bool
+	ForceApply         bool
 	DisableHelmVersion bool
 	Boot               bool
 	Vault              bool
 	NoVault            bool
 	NoMasking          bool
 	ProviderValuesDir  string
+	SetJSON            []string
+	ReportUnusedValues bool
+	AnnotateVersions   bool
+	AnnotateProvenance bool
+	StrictMerge        bool
+	Contexts           []string
+	KubeConfigs        []string
+	ClusterValues      []string
+	ClustersReportFile string
+	DryRun             bool
+	WaitReady          bool
+	WaitReadyTimeout   time.Duration
+	FromPlan           string
+	PlanHash           string
+	UpdateLock         bool
+	AppsFile           string
+	Parallelism        int
+	Prune              bool
+	PruneWhitelist     []string
+
+	// ClusterResults the results of applying the chart to each of the clusters
+	ClusterResults []ClusterApplyResult
+	// AppResults the results of applying each of the apps of the --apps-file
+	AppResults []AppApplyResult
+
+	cluster               *config.RemoteClusterConfig
+	contextSwitcher       KubeContextSwitcher
+	applyInCluster        func(cluster config.RemoteClusterConfig) error
+	waitReadyPollInterval time.Duration
+	applyApp              func(app ApplyApp) error
+	progressInterval      time.Duration
+	dependencyBuildLock   *sync.Mutex
+	buildLocked           bool
 }
 
 var (
@@ -52,6 +90,33 @@ var (
 
 		This step is usually used to apply any GitOps promotion changes into a Staging or Production cluster.
 
+		A release is skipped if its chart files, merged values, provider overrides and dependency versions have not
+		changed since it was last applied. Use --force-apply to apply it anyway.
+
+		Resources whose templates have been removed from the chart are left behind unless --prune is specified, which
+		deletes the resources of the --prune-whitelist kinds applied by the previous apply of the release which are no
+		longer rendered. Combine it with --dry-run to report the resources which would be pruned.
+
+		An environment repository with many apps can apply the chart of each app as its own release concurrently via
+		--apps-file. An app is only applied once the apps in its 'needs' have been applied:
+
+		    apps:
+		    - name: ingress
+		      dir: systems/jxing
+		      namespace: kube-system
+		    - name: velero
+		      dir: systems/velero
+		      namespace: velero
+		    - name: env
+		      dir: env
+		      needs:
+		      - ingress
+
+		A chart, or a dependency of the environment chart, whose values contain 'rollout: canary' or 'rollout: bluegreen'
+		has a Flagger Canary generated for each of its Deployments so that Flagger rolls out their changes once they pass
+		the canary analysis configured by 'canary.canaryAnalysis' in the values. This requires Flagger to be installed
+		and the helm template mode.
+
         Environment Variables:
 		- JX_NO_DELETE_TMP_DIR="true" - prevents the removal of the temporary directory.
 `)
@@ -60,9 +125,39 @@ var (
 		# apply the chart in the env folder to namespace jx-staging
 		jx step helm apply --dir env --namespace jx-staging
 
+		# apply the chart in the env folder to the current namespace of each of the kube contexts in turn
+		jx step helm apply --dir env --contexts us-east,eu-west
+
+		# apply the chart in the env folder post rendering its manifests with the kustomization in env/kustomize/production
+		jx step helm apply --dir env --kustomize-dir kustomize/production
+
+		# apply the plan written by 'jx step helm build --plan' failing if it is not the reviewed plan
+		jx step helm apply --from-plan helm-plan.json --plan-hash "$REVIEWED_PLAN_HASH"
+
+		# apply the chart in the env folder updating its dependency lock if the dependencies have changed
+		jx step helm apply --dir env --update-lock
+
+		# apply the chart in the env folder deleting the resources which are no longer in the chart
+		jx step helm apply --dir env --prune
+
+		# apply the charts of the apps in apps.yml concurrently in the order of their needs
+		jx step helm apply --apps-file apps.yml --parallelism 8
+
+		# apply the chart in the env folder annotating every resource with the pipeline run which applied it
+		jx step helm apply --dir env --provenance-annotations
+
+		# apply the chart in the env folder printing the time spent in each phase
+		jx step helm apply --dir env --timings
+
+		# apply the chart in the env folder to each of the 'clusters' in the jx-requirements.yml
+		jx step helm apply --dir env --remote --clusters-report-file clusters.json
+
+		# apply the chart to the clusters of each kube config file overriding the values of one of them
+		jx step helm apply --dir env --kubeconfigs us-east.yaml,eu-west.yaml --cluster-values eu-west.yaml=env/eu-west.yaml
+
 `)
 
-	defaultValueFileNames = []string{"values.yaml", "myvalues.yaml", helm.SecretsFileName, filepath.Join("env", helm.SecretsFileName)}
+	defaultValueFileNames = []string{"values.yaml", "myvalues.yaml", helm.SecretsFileName, filepath.Join("env", helm.SecretsFileName), "*" + helm.SopsFileSuffix}
 )
 
 func NewCmdStepHelmApply(commonOpts *opts.CommonOptions) *cobra.Command {
@@ -87,23 +182,73 @@ func NewCmdStepHelmApply(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	options.addValidateValuesFlag(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addValidateSchemaFlag(cmd)
+	options.addVersionResolutionFlags(cmd)
+	options.addVerifyChartsFlags(cmd)
+	options.addKustomizeFlag(cmd)
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The Kubernetes namespace to apply the helm chart to")
 	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", "", "The name of the release")
+	cmd.Flags().StringVarP(&options.Environment, "environment", "", "", "The key of the environment in the 'jx-requirements.yml' whose helm settings are used to apply the chart. Defaults to the environment of the namespace")
 	cmd.Flags().BoolVarP(&options.Wait, "wait", "", true, "Wait for Kubernetes readiness probe to confirm deployment")
 	cmd.Flags().BoolVarP(&options.Force, "force", "f", true, "Whether to to pass '--force' to helm to help deal with upgrading if a previous promote failed")
+	cmd.Flags().BoolVarP(&options.ForceApply, "force-apply", "", false, "Applies the chart even if its files, values and dependency versions have not changed since it was last applied. The hash of each applied release is stored in the '"+ApplyHashesConfigMapName+"' ConfigMap of its namespace")
 	cmd.Flags().BoolVar(&options.DisableHelmVersion, "no-helm-version", false, "Don't set Chart version before applying")
 	cmd.Flags().BoolVarP(&options.Vault, "vault", "", false, "Helm secrets are stored in vault")
 	cmd.Flags().BoolVarP(&options.Boot, "boot", "", false, "In Boot mode we load the Version Stream from the 'jx-requirements.yml' and use that to replace any missing versions in the 'requirements.yaml' file from the Version Stream")
 	cmd.Flags().BoolVarP(&options.NoVault, "no-vault", "", false, "Disables loading secrets from Vault. e.g. if bootstrapping core services like Ingress before we have a Vault")
 	cmd.Flags().BoolVarP(&options.NoMasking, "no-masking", "", false, "The effective 'values.yaml' file is output to the console with parameters masked. Enabling this flag will show the unmasked secrets in the console output")
 	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	options.addProviderValuesFlags(cmd)
+	cmd.Flags().StringArrayVarP(&options.SetJSON, "set-json", "", []string{}, "Sets a value in the merged 'values.yaml' at the given dotted path to the parsed JSON value, e.g. 'foo.hosts=[\"a.com\",\"b.com\"]'. Can be specified multiple times")
+	cmd.Flags().BoolVarP(&options.AnnotateVersions, "annotate-versions", "", false, "Annotates the rendered resources with the '"+helm.AnnotationChartVersion+"' annotation recording the name and version of the chart or dependency they came from. Only supported when using helm template mode")
+	cmd.Flags().BoolVarP(&options.AnnotateProvenance, "provenance-annotations", "", false, "Annotates every applied resource, including hooks, with the git commit, build number, prow job ID, chart version and jx version which applied it using the '"+helm.AnnotationGitSHA+"', '"+helm.AnnotationBuildNumber+"', '"+helm.AnnotationProwJobID+"', '"+helm.AnnotationAppliedChartVersion+"' and '"+helm.AnnotationJXVersion+"' annotations. Only supported when using helm template mode")
+	cmd.Flags().BoolVarP(&options.StrictMerge, "strict-merge", "", false, "Fails if any of the values files sets a key of the merged 'values.yaml' to null, or replaces it with a scalar, so that base configuration is removed")
+	cmd.Flags().StringSliceVarP(&options.Contexts, "contexts", "", nil, "The kube contexts to apply the helm chart to in turn, restoring the current context afterwards. Unless --namespace is specified the namespace of each context is used")
+	cmd.Flags().StringSliceVarP(&options.KubeConfigs, "kubeconfigs", "", nil, "The kube config files of the clusters to apply the helm chart to in turn using the current context of each file")
+	cmd.Flags().StringArrayVarP(&options.ClusterValues, "cluster-values", "", nil, "Adds a values file relative to the chart directory which overrides the values of the chart when applying it to one of the clusters of the form 'cluster=values.yaml'. Can be specified multiple times")
+	cmd.Flags().StringVarP(&options.ClustersReportFile, "clusters-report-file", "", "", "The file to write the JSON report of the result of applying the helm chart to each of the clusters")
+	cmd.Flags().StringVarP(&options.FromPlan, "from-plan", "", "", "Applies the rendered manifests of the plan file written by 'jx step helm build --plan' rather than building and rendering the chart so that exactly what was reviewed is applied")
+	cmd.Flags().StringVarP(&options.PlanHash, "plan-hash", "", "", "The expected hash of the --from-plan. If specified the plan is only applied if it has this hash")
+	cmd.Flags().BoolVarP(&options.UpdateLock, "update-lock", "", false, "Updates the '"+DependencyLockFileName+"' of the chart written by 'jx step helm build' if the built dependencies no longer match it rather than failing")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Renders the chart with the merged values and overrides and outputs the differences of each resource against the currently deployed release as YAML instead of applying the chart")
+	cmd.Flags().BoolVarP(&options.WaitReady, "wait-ready", "", false, "After applying the chart waits for the Deployments, StatefulSets, DaemonSets, Jobs and any other resources with status conditions created by the release to become ready. Fails with a summary of the unhealthy resources and their recent events if they do not")
+	cmd.Flags().DurationVarP(&options.WaitReadyTimeout, "wait-ready-timeout", "", DefaultWaitReadyTimeout, "The maximum time to wait for the resources of the release to become ready when using --wait-ready")
+	cmd.Flags().StringVarP(&options.AppsFile, "apps-file", "", "", "The YAML file of the apps of the environment repository whose charts are applied concurrently as separate releases once the apps in their 'needs' have been applied")
+	cmd.Flags().IntVarP(&options.Parallelism, "parallelism", "", DefaultApplyParallelism, "The maximum number of apps of the --apps-file applied at the same time")
+	cmd.Flags().BoolVarP(&options.Prune, "prune", "", false, "Deletes the resources applied by the previous apply of the release which are no longer rendered by its chart. The resources of each release are recorded in the '"+ApplyResourcesConfigMapName+"' ConfigMap of its namespace so are only pruned once the release has been applied with --prune")
+	cmd.Flags().StringSliceVarP(&options.PruneWhitelist, "prune-whitelist", "", DefaultPruneWhitelist, "The kinds of resources deleted by --prune")
+	options.addTimingsFlags(cmd)
+	cmd.Flags().BoolVarP(&options.ReportUnusedValues, "report-unused-values", "", false, "Reports the merged values keys which do not appear to be referenced by any chart template. This is a best effort static analysis of the templates")
 
 	return cmd
 }
 
 func (o *StepHelmApplyOptions) Run() error {
-	var err error
+	if err := o.validateOnMissing(); err != nil {
+		return err
+	}
+	if o.startTimings() {
+		defer o.reportTimings("jx-step-helm-apply")
+	}
+	clusters, err := o.applyClusters()
+	if err != nil {
+		return err
+	}
+	if len(clusters) > 0 {
+		return o.runInClusters(clusters)
+	}
+	if o.AppsFile != "" {
+		return o.runApps()
+	}
+	if o.FromPlan != "" {
+		return o.applyPlan()
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
 	chartName := o.Dir
 	dir := o.Dir
 	releaseName := o.ReleaseName
@@ -148,9 +293,12 @@ func (o *StepHelmApplyOptions) Run() error {
 		return err
 	}
 
-	err = kube.EnsureNamespaceCreated(kubeClient, ns, nil, nil)
-	if err != nil {
-		return err
+	// a dry run only reads from the cluster
+	if !o.DryRun {
+		err = kube.EnsureNamespaceCreated(kubeClient, ns, nil, nil)
+		if err != nil {
+			return err
+		}
 	}
 
 	_, devNs, err := o.KubeClientAndDevNamespace()
@@ -176,6 +324,7 @@ func (o *StepHelmApplyOptions) Run() error {
 		return errors.Wrapf(err, "could not find absolute path of dir %s", dir)
 	}
 	dir = path
+	sourceDir := path
 
 	devGitInfo, err := o.FindGitInfo(dir)
 	if err != nil {
@@ -189,7 +338,7 @@ func (o *StepHelmApplyOptions) Run() error {
 		defer os.RemoveAll(rootTmpDir) //nolint:errcheck
 	}
 
-	if os.Getenv(kube.DisableBuildLockEnvKey) == "" {
+	if os.Getenv(kube.DisableBuildLockEnvKey) == "" && !o.buildLocked && !o.DryRun {
 		release, err := kube.AcquireBuildLock(kubeClient, devNs, ns)
 		if err != nil {
 			return errors.Wrapf(err, "fail to acquire the lock")
@@ -218,12 +367,24 @@ func (o *StepHelmApplyOptions) Run() error {
 
 	o.Helm().SetCWD(dir)
 
-	valueFiles := []string{}
-	for _, name := range defaultValueFileNames {
-		file := filepath.Join(dir, name)
-		exists, err := util.FileExists(file)
-		if exists && err == nil {
-			valueFiles = append(valueFiles, file)
+	valueFiles, err := o.findValuesFiles(dir, defaultValueFileNames)
+	if err != nil {
+		return err
+	}
+	clusterValueFiles, err := o.clusterValuesFiles(dir)
+	if err != nil {
+		return err
+	}
+	valueFiles = append(valueFiles, clusterValueFiles...)
+	defer o.closeSopsValues()
+	valueFiles, err = o.decryptSopsValuesFiles(valueFiles)
+	if err != nil {
+		return err
+	}
+	if o.ValidateValues {
+		err = o.validateValuesFiles(valueFiles)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -259,8 +420,9 @@ func (o *StepHelmApplyOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "loading the requirements")
 	}
+	o.SetChartRepositoryMirrors(requirements.Mirrors)
 
-	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)))
+	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)), o.Dir)
 	if err != nil {
 		return errors.Wrap(err, "failed to create a Secret RL client")
 	}
@@ -271,17 +433,26 @@ func (o *StepHelmApplyOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	chartValues, params, err := helm.GenerateValues(requirements, funcMap, dir, nil, true, secretURLClient)
+	stopTiming := o.startTiming(PhaseRender, "values "+releaseName)
+	chartValues, params, err := helm.GenerateEnvironmentValues(requirements, o.environmentKey(ns, devNs), funcMap, dir, nil, true, secretURLClient)
+	stopTiming()
 	if err != nil {
 		return errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
 	}
-	if o.ProviderValuesDir != "" && requirementsFileName != "" {
+	if (o.ProviderValuesDir != "" || o.ProviderValuesConfigMap != "") && requirementsFileName != "" {
 		chartValues, err = o.overwriteProviderValues(requirements, requirementsFileName, chartValues, params, o.ProviderValuesDir)
 		if err != nil {
 			return errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
 		}
 	}
 
+	if len(o.SetJSON) > 0 {
+		chartValues, err = o.applySetJSONValues(chartValues)
+		if err != nil {
+			return errors.Wrap(err, "failed to apply the --set-json values")
+		}
+	}
+
 	chartValuesFile := filepath.Join(dir, helm.ValuesFileName)
 	err = ioutil.WriteFile(chartValuesFile, chartValues, 0755)
 	if err != nil {
@@ -306,17 +477,54 @@ func (o *StepHelmApplyOptions) Run() error {
 
 	log.Logger().Debugf("Using values files: %s", strings.Join(valueFiles, ", "))
 
+	if o.StrictMerge {
+		err = o.verifyStrictMerge(chartValuesFile, valueFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	schemaValuesFiles := []string{chartValuesFile}
+	for _, valueFile := range valueFiles {
+		if valueFile != chartValuesFile {
+			schemaValuesFiles = append(schemaValuesFiles, valueFile)
+		}
+	}
+	err = o.validateValuesSchema(dir, schemaValuesFiles)
+	if err != nil {
+		return err
+	}
+
 	if o.Boot {
-		err = o.replaceMissingVersionsFromVersionStream(requirements, dir)
+		err = o.replaceMissingVersionsFromVersionStream(requirements, dir, false)
 		if err != nil {
 			return errors.Wrapf(err, "failed to replace missing versions in the requirements.yaml in dir %s", dir)
 		}
 	}
 
+	unlock := o.lockDependencyBuild()
+	stopTiming = o.startTiming(PhaseHelm, "dependency build "+releaseName)
 	_, err = o.HelmInitDependencyBuild(dir, o.DefaultReleaseCharts(), valueFiles)
+	stopTiming()
+	unlock()
+	if err != nil {
+		return err
+	}
+	err = o.verifyDependencyLock(dir, sourceDir)
 	if err != nil {
 		return err
 	}
+	if o.verifyChartsEnabled(requirements) {
+		err = o.verifyChartDependencies(dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify the charts of %s", dir)
+		}
+	}
+	// lets record the dependency versions before the dependencies are repackaged with their secrets
+	dependencyLock, err := CreateDependencyLock(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the dependency lock of %s", dir)
+	}
 
 	// Now let's unpack all the dependencies and apply the vault URLs
 	dependencies, err := filepath.Glob(filepath.Join(dir, "charts", "*.tgz"))
@@ -370,7 +578,14 @@ func (o *StepHelmApplyOptions) Run() error {
 		return errors.Wrap(err, "applying chart overrides")
 	}
 
-	setValues, setStrings := o.getChartValues(ns)
+	if o.ReportUnusedValues {
+		err = o.reportUnusedValues(dir, append([]string{chartValuesFile}, valueFiles...))
+		if err != nil {
+			log.Logger().Warnf("failed to report unused values: %s", err.Error())
+		}
+	}
+
+	setValues, setStrings := o.getChartValues(ns, requirements)
 
 	helmOptions := helm.InstallChartOptions{
 		Chart:       chartName,
@@ -387,15 +602,138 @@ func (o *StepHelmApplyOptions) Run() error {
 		helmOptions.VersionsGitRef = requirements.VersionStream.Ref
 	}
 
-	if o.Wait {
-		helmOptions.Wait = true
-		err = o.InstallChartWithOptionsAndTimeout(helmOptions, "600")
-	} else {
-		err = o.InstallChartWithOptions(helmOptions)
+	if o.AnnotateVersions {
+		o.annotateVersions()
+	}
+
+	if o.DryRun {
+		return o.dryRun(helmOptions, helmTemplate)
+	}
+
+	contentHash, err := releaseContentHash(dir, dependencyLock, valueFiles, setValues, setStrings)
+	if err != nil {
+		return err
+	}
+	unchanged, err := o.releaseUnchanged(kubeClient, ns, releaseName, contentHash)
+	if err != nil || unchanged {
+		return err
+	}
+
+	timeout, err := o.configureHelmRelease(requirements, o.environmentKey(ns, devNs))
+	if err != nil {
+		return err
 	}
+	err = o.configurePostRender(dir)
+	if err != nil {
+		return err
+	}
+	err = o.configureExternalSecrets(requirements, secretURLClient)
+	if err != nil {
+		return err
+	}
+	err = o.configureRollouts(dir, releaseName, append([]string{chartValuesFile}, valueFiles...))
+	if err != nil {
+		return errors.Wrap(err, "configuring the Flagger rollouts")
+	}
+	if o.AnnotateProvenance {
+		o.annotateProvenance(sourceDir, dir)
+	}
+	helmOptions.Wait = o.Wait
+	stopTiming = o.startTiming(PhaseHelm, "install "+releaseName)
+	err = o.InstallChartWithOptionsAndTimeout(helmOptions, timeout)
+	stopTiming()
 	if err != nil {
 		return errors.Wrapf(err, "upgrading helm chart '%s'", chartName)
 	}
+	if o.WaitReady {
+		err = o.waitReady(helmOptions, helmTemplate)
+		if err != nil {
+			return err
+		}
+	}
+	if o.Prune {
+		err = o.prune(kubeClient, helmOptions)
+		if err != nil {
+			return err
+		}
+	}
+	return saveAppliedHash(kubeClient, ns, releaseName, contentHash)
+}
+
+// verifyStrictMerge returns an error if merging the values files on top of the chart values removes any of its keys
+func (o *StepHelmApplyOptions) verifyStrictMerge(chartValuesFile string, valueFiles []string) error {
+	base, err := helm.LoadValuesFile(chartValuesFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load values file %s", chartValuesFile)
+	}
+	overrides := []map[string]interface{}{}
+	for _, valueFile := range valueFiles {
+		if valueFile == chartValuesFile {
+			continue
+		}
+		values, err := helm.LoadValuesFile(valueFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load values file %s", valueFile)
+		}
+		overrides = append(overrides, values)
+	}
+	removed := helm.FindRemovedValues(base, overrides...)
+	if len(removed) > 0 {
+		return fmt.Errorf("the values files %s remove the following keys of %s: %s", strings.Join(valueFiles, ", "), chartValuesFile, strings.Join(removed, ", "))
+	}
+	return nil
+}
+
+// annotateVersions enables annotating the rendered resources with their chart versions
+func (o *StepHelmApplyOptions) annotateVersions() {
+	helmer := helm.Unwrap(o.Helm())
+	helmTemplate, ok := helmer.(*helm.HelmTemplate)
+	if !ok {
+		log.Logger().Warnf("the --annotate-versions flag is only supported when using helm template mode so ignoring it")
+		return
+	}
+	helmTemplate.AnnotateVersions = true
+}
+
+// applySetJSONValues sets the values parsed from the --set-json flags into the merged values
+func (o *StepHelmApplyOptions) applySetJSONValues(valuesData []byte) ([]byte, error) {
+	overrides, err := helm.SetJSONValuesToMap(o.SetJSON)
+	if err != nil {
+		return valuesData, err
+	}
+	values, err := helm.LoadValues(valuesData)
+	if err != nil {
+		return valuesData, errors.Wrapf(err, "failed to unmarshal the helm values")
+	}
+	for _, setJSON := range o.SetJSON {
+		path := strings.SplitN(setJSON, "=", 2)[0]
+		util.SetMapValueViaPath(values, path, util.GetMapValueViaPath(overrides, path))
+	}
+	return yaml.Marshal(values)
+}
+
+// reportUnusedValues logs the keys of the merged values files which no chart template appears to reference
+func (o *StepHelmApplyOptions) reportUnusedValues(dir string, valueFiles []string) error {
+	values := map[string]interface{}{}
+	for _, valueFile := range valueFiles {
+		fileValues, err := helm.LoadValuesFile(valueFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load values file %s", valueFile)
+		}
+		util.CombineMapTrees(values, fileValues)
+	}
+	unused, err := helm.FindUnusedValues(dir, values)
+	if err != nil {
+		return err
+	}
+	if len(unused) == 0 {
+		log.Logger().Infof("All the values appear to be used by the chart templates (best effort static analysis)")
+		return nil
+	}
+	log.Logger().Warnf("The following values do not appear to be used by any chart template (best effort static analysis, so please double check before removing them):")
+	for _, path := range unused {
+		log.Logger().Warnf("  %s", path)
+	}
 	return nil
 }
 
@@ -0,0 +1,383 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AppApplySucceeded the status of an app which was applied
+	AppApplySucceeded = "succeeded"
+	// AppApplyFailed the status of an app which failed to apply
+	AppApplyFailed = "failed"
+	// AppApplySkipped the status of an app which was not applied as an app it needs failed
+	AppApplySkipped = "skipped"
+
+	// DefaultApplyParallelism the default maximum number of apps applied at the same time
+	DefaultApplyParallelism = 4
+
+	defaultApplyProgressInterval = 30 * time.Second
+)
+
+// ApplyAppsConfig the apps of an environment repository applied concurrently by 'jx step helm apply --apps-file'
+type ApplyAppsConfig struct {
+	// Apps the charts to apply
+	Apps []ApplyApp `json:"apps"`
+}
+
+// ApplyApp a chart in the environment repository applied as its own release
+type ApplyApp struct {
+	// Name the unique name of the app used by the 'needs' of other apps
+	Name string `json:"name"`
+	// Dir the directory of the chart relative to the apps file
+	Dir string `json:"dir"`
+	// Namespace the namespace to apply the chart to. Defaults to the --namespace
+	Namespace string `json:"namespace,omitempty"`
+	// ReleaseName the name of the release. Defaults to the name of the app so that apps in the same namespace are
+	// separate releases
+	ReleaseName string `json:"releaseName,omitempty"`
+	// Needs the names of the apps which must be applied before this app
+	Needs []string `json:"needs,omitempty"`
+}
+
+// AppApplyResult the result of applying an app
+type AppApplyResult struct {
+	App       string `json:"app"`
+	Namespace string `json:"namespace,omitempty"`
+	Status    string `json:"status"`
+	Duration  string `json:"duration,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// appApplied the outcome of an app applied in the background
+type appApplied struct {
+	name string
+	err  error
+}
+
+// LoadApplyAppsConfig loads the apps in the given file resolving their directories relative to the file
+func LoadApplyAppsConfig(fileName string) (*ApplyAppsConfig, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the apps file %s", fileName)
+	}
+	config := &ApplyAppsConfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the apps file %s", fileName)
+	}
+	dir := filepath.Dir(fileName)
+	for i := range config.Apps {
+		app := &config.Apps[i]
+		if app.Dir == "" {
+			app.Dir = app.Name
+		}
+		if app.ReleaseName == "" {
+			app.ReleaseName = app.Name
+		}
+		if !filepath.IsAbs(app.Dir) {
+			app.Dir = filepath.Join(dir, app.Dir)
+		}
+	}
+	err = validateApplyApps(config.Apps)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid apps file %s", fileName)
+	}
+	return config, nil
+}
+
+// validateApplyApps returns an error if the apps have duplicate names, need apps which do not exist or the needs
+// form a cycle
+func validateApplyApps(apps []ApplyApp) error {
+	needs := map[string][]string{}
+	for _, app := range apps {
+		if app.Name == "" {
+			return fmt.Errorf("the app in dir %s has no name", app.Dir)
+		}
+		if _, ok := needs[app.Name]; ok {
+			return fmt.Errorf("there is more than one app called %s", app.Name)
+		}
+		needs[app.Name] = app.Needs
+	}
+	for _, app := range apps {
+		for _, need := range app.Needs {
+			if _, ok := needs[need]; !ok {
+				return fmt.Errorf("the app %s needs the app %s which does not exist", app.Name, need)
+			}
+		}
+	}
+
+	// lets walk the needs depth first to find any cycles
+	visited := map[string]bool{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if util.StringArrayIndex(path, name) >= 0 {
+			return fmt.Errorf("the needs of the apps form a cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+		for _, need := range needs[name] {
+			err := visit(need, append(path, name))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, app := range apps {
+		err := visit(app.Name, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runApps applies the chart of each of the apps in the --apps-file as its own release. Apps are applied concurrently,
+// up to the --parallelism, once all the apps they need have been applied. If an app fails the apps which need it are
+// skipped but the other apps are still applied and any failures are combined into the error
+func (o *StepHelmApplyOptions) runApps() error {
+	config, err := LoadApplyAppsConfig(o.AppsFile)
+	if err != nil {
+		return err
+	}
+	apps := config.Apps
+	if len(apps) == 0 {
+		log.Logger().Warnf("there are no apps in the apps file %s", o.AppsFile)
+		return nil
+	}
+
+	apply := o.applyApp
+	if apply == nil {
+		namespaces, err := o.appDeployNamespaces(apps)
+		if err != nil {
+			return err
+		}
+		release, err := o.acquireAppsBuildLocks(namespaces)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		// the helm dependency build uses the shared helm repositories so lets only build one chart at a time
+		dependencyBuildLock := &sync.Mutex{}
+		apply = func(app ApplyApp) error {
+			appOptions := *o
+			commonOptions := *o.CommonOptions
+			// lets use a helmer per app as the helmer runs in the directory of the chart
+			commonOptions.SetHelm(nil)
+			appOptions.CommonOptions = &commonOptions
+			appOptions.AppsFile = ""
+			appOptions.Args = nil
+			appOptions.Dir = app.Dir
+			appOptions.ReleaseName = app.ReleaseName
+			appOptions.Namespace = namespaces[app.Name]
+			appOptions.dependencyBuildLock = dependencyBuildLock
+			appOptions.buildLocked = true
+			return appOptions.Run()
+		}
+	}
+	parallelism := o.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	progressInterval := o.progressInterval
+	if progressInterval <= 0 {
+		progressInterval = defaultApplyProgressInterval
+	}
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	log.Logger().Infof("Applying %d apps with a parallelism of %d", len(apps), parallelism)
+	results := map[string]*AppApplyResult{}
+	started := map[string]time.Time{}
+	running := map[string]bool{}
+	appliedCh := make(chan appApplied, len(apps))
+	for len(results) < len(apps) {
+		for _, app := range apps {
+			if results[app.Name] != nil || running[app.Name] {
+				continue
+			}
+			ready, failedNeed := appReadiness(app, results)
+			if failedNeed != "" {
+				results[app.Name] = &AppApplyResult{
+					App:       app.Name,
+					Namespace: app.Namespace,
+					Status:    AppApplySkipped,
+					Error:     fmt.Sprintf("the app %s it needs was not applied", failedNeed),
+				}
+				log.Logger().Warnf("Skipping app %s as the app %s it needs was not applied", util.ColorWarning(app.Name), failedNeed)
+				continue
+			}
+			if !ready || len(running) >= parallelism {
+				continue
+			}
+			running[app.Name] = true
+			started[app.Name] = time.Now()
+			log.Logger().Infof("Applying app %s (%d of %d)", util.ColorInfo(app.Name), len(started), len(apps))
+			go func(app ApplyApp) {
+				appliedCh <- appApplied{name: app.Name, err: apply(app)}
+			}(app)
+		}
+		if len(running) == 0 {
+			// any remaining apps need apps which were skipped so the next pass skips them too
+			continue
+		}
+
+		select {
+		case applied := <-appliedCh:
+			delete(running, applied.name)
+			result := &AppApplyResult{
+				App:       applied.name,
+				Namespace: appNamespace(apps, applied.name),
+				Status:    AppApplySucceeded,
+				Duration:  time.Since(started[applied.name]).Round(time.Second).String(),
+			}
+			results[applied.name] = result
+			if applied.err != nil {
+				result.Status = AppApplyFailed
+				result.Error = applied.err.Error()
+				log.Logger().Warnf("Failed to apply app %s after %s: %s", util.ColorWarning(applied.name), result.Duration, applied.err.Error())
+				continue
+			}
+			log.Logger().Infof("Applied app %s in %s (%d of %d apps done)", util.ColorInfo(applied.name), result.Duration, len(results), len(apps))
+		case <-ticker.C:
+			names := []string{}
+			for name := range running {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			progress := []string{}
+			for _, name := range names {
+				progress = append(progress, fmt.Sprintf("%s (%s)", name, time.Since(started[name]).Round(time.Second)))
+			}
+			log.Logger().Infof("Still applying %s with %d of %d apps done", strings.Join(progress, ", "), len(results), len(apps))
+		}
+	}
+
+	errs := []error{}
+	o.AppResults = []AppApplyResult{}
+	log.Logger().Infof("Applied the apps:")
+	for _, app := range apps {
+		result := results[app.Name]
+		o.AppResults = append(o.AppResults, *result)
+		switch result.Status {
+		case AppApplySucceeded:
+			log.Logger().Infof("  %s: %s in %s", result.App, util.ColorInfo(result.Status), result.Duration)
+		case AppApplyFailed:
+			log.Logger().Infof("  %s: %s in %s: %s", result.App, util.ColorError(result.Status), result.Duration, result.Error)
+			errs = append(errs, fmt.Errorf("failed to apply app %s: %s", result.App, result.Error))
+		default:
+			log.Logger().Infof("  %s: %s as %s", result.App, util.ColorWarning(result.Status), result.Error)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// appDeployNamespaces returns the namespace each app is applied to by name defaulting to the --namespace so that the
+// build locks are acquired for the same namespaces the apps are applied to
+func (o *StepHelmApplyOptions) appDeployNamespaces(apps []ApplyApp) (map[string]string, error) {
+	answer := map[string]string{}
+	for _, app := range apps {
+		ns := app.Namespace
+		if ns == "" {
+			ns = o.Namespace
+		}
+		ns, err := o.GetDeployNamespace(ns)
+		if err != nil {
+			return nil, err
+		}
+		answer[app.Name] = ns
+	}
+	return answer, nil
+}
+
+// acquireAppsBuildLocks acquires the build lock of each namespace the apps are applied to up front as the apps applied
+// concurrently to the same namespace would otherwise wait for each others locks. Returns the function to release them
+func (o *StepHelmApplyOptions) acquireAppsBuildLocks(appNamespaces map[string]string) (func(), error) {
+	releases := []func() error{}
+	release := func() {
+		for _, r := range releases {
+			r() //nolint:errcheck
+		}
+	}
+	if os.Getenv(kube.DisableBuildLockEnvKey) != "" || o.DryRun {
+		return release, nil
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return release, err
+	}
+	_, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return release, err
+	}
+	namespaces := []string{}
+	for _, ns := range appNamespaces {
+		if util.StringArrayIndex(namespaces, ns) < 0 {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	// lets acquire the locks in the same order each time
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		r, err := kube.AcquireBuildLock(kubeClient, devNs, ns)
+		if err != nil {
+			release()
+			return func() {}, errors.Wrapf(err, "fail to acquire the lock of namespace %s", ns)
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// appReadiness returns true if all the apps the app needs have been applied or the name of a need which failed or
+// was skipped
+func appReadiness(app ApplyApp, results map[string]*AppApplyResult) (bool, string) {
+	ready := true
+	for _, need := range app.Needs {
+		result := results[need]
+		if result == nil {
+			ready = false
+			continue
+		}
+		if result.Status != AppApplySucceeded {
+			return false, need
+		}
+	}
+	return ready, ""
+}
+
+// appNamespace returns the namespace of the app with the given name
+func appNamespace(apps []ApplyApp, name string) string {
+	for _, app := range apps {
+		if app.Name == name {
+			return app.Namespace
+		}
+	}
+	return ""
+}
+
+// lockDependencyBuild serializes building the dependencies of the charts when applying apps concurrently returning
+// the function to unlock it
+func (o *StepHelmApplyOptions) lockDependencyBuild() func() {
+	if o.dependencyBuildLock == nil {
+		return func() {}
+	}
+	o.dependencyBuildLock.Lock()
+	return o.dependencyBuildLock.Unlock
+}
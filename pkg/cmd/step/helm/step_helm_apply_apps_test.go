@@ -0,0 +1,135 @@
+// +build unit
+
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyApps(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-apply-apps-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	appsFile := filepath.Join(dir, "apps.yml")
+	apps := `apps:
+- name: env
+  dir: env
+  namespace: jx
+  needs: [ingress, cert-manager]
+- name: ingress
+  dir: systems/jxing
+  namespace: kube-system
+- name: cert-manager
+  dir: systems/cm
+  namespace: cert-manager
+  needs: [ingress]
+- name: velero
+  namespace: velero
+- name: broken
+  namespace: broken
+- name: after-broken
+  needs: [broken]
+- name: after-after-broken
+  needs: [after-broken]
+`
+	require.NoError(t, ioutil.WriteFile(appsFile, []byte(apps), util.DefaultFileWritePermissions))
+
+	lock := sync.Mutex{}
+	finished := map[string]bool{}
+	active := 0
+	maxActive := 0
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		AppsFile:         appsFile,
+		Parallelism:      2,
+		progressInterval: time.Millisecond,
+	}
+	o.applyApp = func(app ApplyApp) error {
+		assert.Equal(t, app.Name, app.ReleaseName, "should default the release name to the name of the app")
+		lock.Lock()
+		for _, need := range app.Needs {
+			assert.True(t, finished[need], "app %s should be applied after the app %s it needs", app.Name, need)
+		}
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		lock.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		lock.Lock()
+		defer lock.Unlock()
+		active--
+		finished[app.Name] = true
+		if app.Name == "broken" {
+			return fmt.Errorf("helm upgrade failed")
+		}
+		return nil
+	}
+	err = o.Run()
+	require.Error(t, err)
+	assert.Equal(t, "failed to apply app broken: helm upgrade failed", err.Error())
+	assert.Equal(t, 2, maxActive, "should apply apps concurrently up to the parallelism")
+
+	statuses := map[string]string{}
+	for _, result := range o.AppResults {
+		statuses[result.App] = result.Status
+	}
+	assert.Equal(t, map[string]string{
+		"env":                AppApplySucceeded,
+		"ingress":            AppApplySucceeded,
+		"cert-manager":       AppApplySucceeded,
+		"velero":             AppApplySucceeded,
+		"broken":             AppApplyFailed,
+		"after-broken":       AppApplySkipped,
+		"after-after-broken": AppApplySkipped,
+	}, statuses)
+	assert.Equal(t, "env", o.AppResults[0].App, "the results should be in the order of the apps file")
+	assert.False(t, finished["after-broken"], "should not apply an app whose needs failed")
+
+	config, err := LoadApplyAppsConfig(appsFile)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "systems", "jxing"), config.Apps[1].Dir)
+	assert.Equal(t, filepath.Join(dir, "velero"), config.Apps[3].Dir, "should default the dir to the name of the app")
+}
+
+func TestValidateApplyApps(t *testing.T) {
+	t.Parallel()
+
+	err := validateApplyApps([]ApplyApp{
+		{Name: "a", Needs: []string{"c"}},
+		{Name: "b", Needs: []string{"a"}},
+		{Name: "c", Needs: []string{"b"}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "the needs of the apps form a cycle: a -> c -> b -> a", err.Error())
+
+	err = validateApplyApps([]ApplyApp{{Name: "a", Needs: []string{"missing"}}})
+	assert.EqualError(t, err, "the app a needs the app missing which does not exist")
+
+	err = validateApplyApps([]ApplyApp{{Name: "a"}, {Name: "a"}})
+	assert.EqualError(t, err, "there is more than one app called a")
+
+	err = validateApplyApps([]ApplyApp{{Name: "a"}, {Name: "b", Needs: []string{"a"}}, {Name: "c", Needs: []string{"a", "b"}}})
+	assert.NoError(t, err)
+}
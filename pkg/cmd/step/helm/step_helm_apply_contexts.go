@@ -0,0 +1,319 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// ClusterApplySucceeded the status of a cluster the chart was applied to
+	ClusterApplySucceeded = "succeeded"
+	// ClusterApplyFailed the status of a cluster the chart failed to apply to
+	ClusterApplyFailed = "failed"
+
+	kubeConfigEnvVar = "KUBECONFIG"
+)
+
+// ClustersApplyReport the results of applying the chart to each of the clusters
+type ClustersApplyReport struct {
+	Clusters []ClusterApplyResult `json:"clusters"`
+}
+
+// ClusterApplyResult the result of applying the chart to a cluster
+type ClusterApplyResult struct {
+	Cluster    string `json:"cluster"`
+	Context    string `json:"context,omitempty"`
+	KubeConfig string `json:"kubeConfig,omitempty"`
+	Status     string `json:"status"`
+	Duration   string `json:"duration"`
+	Error      string `json:"error,omitempty"`
+}
+
+// KubeContextSwitcher reads and switches the current kube context
+type KubeContextSwitcher interface {
+	// CurrentContext returns the name of the current kube context
+	CurrentContext() (string, error)
+
+	// SwitchContext makes the given kube context the current context
+	SwitchContext(name string) error
+}
+
+// kubeConfigContextSwitcher switches the current context in the kube config file like 'jx context'
+type kubeConfigContextSwitcher struct {
+	kuber kube.Kuber
+}
+
+// CurrentContext returns the name of the current kube context
+func (s *kubeConfigContextSwitcher) CurrentContext() (string, error) {
+	config, _, err := s.kuber.LoadConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to load the kube config")
+	}
+	return kube.CurrentContextName(config), nil
+}
+
+// SwitchContext makes the given kube context the current context in the kube config file
+func (s *kubeConfigContextSwitcher) SwitchContext(name string) error {
+	config, po, err := s.kuber.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load the kube config")
+	}
+	if config == nil || config.Contexts[name] == nil {
+		return fmt.Errorf("could not find Kubernetes context %s", name)
+	}
+	if config.CurrentContext == name {
+		return nil
+	}
+	newConfig := *config
+	newConfig.CurrentContext = name
+	err = clientcmd.ModifyConfig(po, newConfig, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to switch the kube config to context %s", name)
+	}
+	return nil
+}
+
+// getContextSwitcher lazily creates the switcher used to switch between the clusters
+func (o *StepHelmApplyOptions) getContextSwitcher() KubeContextSwitcher {
+	if o.contextSwitcher == nil {
+		o.contextSwitcher = &kubeConfigContextSwitcher{kuber: o.Kube()}
+	}
+	return o.contextSwitcher
+}
+
+// applyClusters returns the clusters to apply the chart to from the --contexts and --kubeconfigs flags or, when using
+// --remote without either flag, from the 'clusters' of the 'jx-requirements.yml' file. The --cluster-values are added
+// to the values files of the matching clusters
+func (o *StepHelmApplyOptions) applyClusters() ([]config.RemoteClusterConfig, error) {
+	if o.cluster != nil {
+		return nil, nil
+	}
+	clusters := []config.RemoteClusterConfig{}
+	for _, context := range o.Contexts {
+		clusters = append(clusters, config.RemoteClusterConfig{Context: context})
+	}
+	for _, kubeConfig := range o.KubeConfigs {
+		clusters = append(clusters, config.RemoteClusterConfig{KubeConfig: kubeConfig})
+	}
+	if len(clusters) == 0 && o.RemoteCluster {
+		requirements, requirementsFileName, err := config.LoadRequirementsConfig(o.Dir)
+		if err != nil && requirementsFileName != "" {
+			return nil, errors.Wrapf(err, "failed to load %s", requirementsFileName)
+		}
+		if err == nil {
+			clusters = append(clusters, requirements.Clusters...)
+		}
+	}
+
+	for _, clusterValues := range o.ClusterValues {
+		paths := strings.SplitN(clusterValues, "=", 2)
+		if len(paths) != 2 || paths[0] == "" || paths[1] == "" {
+			return nil, util.InvalidOptionf("cluster-values", clusterValues, "should be of the form 'cluster=values.yaml'")
+		}
+		found := false
+		for i := range clusters {
+			if clusterName(clusters[i]) == paths[0] {
+				clusters[i].ValuesFiles = append(clusters[i].ValuesFiles, paths[1])
+				found = true
+			}
+		}
+		if !found {
+			return nil, util.InvalidOptionf("cluster-values", clusterValues, "there is no cluster called %s", paths[0])
+		}
+	}
+	return clusters, nil
+}
+
+// clusterName returns the name of the cluster used in the apply report
+func clusterName(cluster config.RemoteClusterConfig) string {
+	if cluster.Name != "" {
+		return cluster.Name
+	}
+	if cluster.Context != "" {
+		return cluster.Context
+	}
+	return cluster.KubeConfig
+}
+
+// describeCluster returns the description of the cluster used in log messages and errors
+func describeCluster(cluster config.RemoteClusterConfig) string {
+	switch {
+	case cluster.Name != "":
+		return "cluster " + cluster.Name
+	case cluster.KubeConfig != "" && cluster.Context != "":
+		return fmt.Sprintf("kube context %s of kube config %s", cluster.Context, cluster.KubeConfig)
+	case cluster.KubeConfig != "":
+		return "kube config " + cluster.KubeConfig
+	default:
+		return "kube context " + cluster.Context
+	}
+}
+
+// runInClusters applies the chart against each of the clusters in turn, restoring the original kube context and kube
+// config afterwards. The chart is applied to every cluster even if some fail and any failures are combined into the
+// error. The result of each cluster is reported at the end and written to the --clusters-report-file if specified
+func (o *StepHelmApplyOptions) runInClusters(clusters []config.RemoteClusterConfig) (err error) {
+	switcher := o.getContextSwitcher()
+	original, err := switcher.CurrentContext()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		restoreErr := switcher.SwitchContext(original)
+		if restoreErr == nil {
+			o.ResetClientsAndNamespaces()
+			return
+		}
+		err = util.CombineErrors(err, errors.Wrapf(restoreErr, "failed to restore the original kube context %s", original))
+	}()
+
+	apply := o.applyInCluster
+	if apply == nil {
+		apply = func(cluster config.RemoteClusterConfig) error {
+			clusterOptions := *o
+			clusterOptions.cluster = &cluster
+			if clusterOptions.Namespace == "" {
+				clusterOptions.Namespace = cluster.Namespace
+			}
+			return clusterOptions.Run()
+		}
+	}
+
+	errs := []error{}
+	failed := []string{}
+	o.ClusterResults = []ClusterApplyResult{}
+	for _, cluster := range clusters {
+		description := describeCluster(cluster)
+		log.Logger().Infof("Applying the helm chart to %s", util.ColorInfo(description))
+		start := time.Now()
+		err := o.applyToCluster(switcher, cluster, apply)
+		result := ClusterApplyResult{
+			Cluster:    clusterName(cluster),
+			Context:    cluster.Context,
+			KubeConfig: cluster.KubeConfig,
+			Status:     ClusterApplySucceeded,
+			Duration:   time.Since(start).Round(time.Second).String(),
+		}
+		if err != nil {
+			result.Status = ClusterApplyFailed
+			result.Error = err.Error()
+			o.ClusterResults = append(o.ClusterResults, result)
+			failed = append(failed, clusterName(cluster))
+			errs = append(errs, errors.Wrapf(err, "failed to apply the helm chart to %s", description))
+			continue
+		}
+		o.ClusterResults = append(o.ClusterResults, result)
+		log.Logger().Infof("Applied the helm chart to %s", util.ColorInfo(description))
+	}
+
+	log.Logger().Infof("Applied the helm chart to %d of %d clusters:", len(clusters)-len(failed), len(clusters))
+	for _, result := range o.ClusterResults {
+		if result.Status == ClusterApplySucceeded {
+			log.Logger().Infof("  %s: %s in %s", result.Cluster, util.ColorInfo(result.Status), result.Duration)
+		} else {
+			log.Logger().Infof("  %s: %s in %s: %s", result.Cluster, util.ColorError(result.Status), result.Duration, result.Error)
+		}
+	}
+	if o.ClustersReportFile != "" {
+		reportErr := o.writeClustersReport()
+		if reportErr != nil {
+			errs = append(errs, reportErr)
+		}
+	}
+	if len(failed) > 0 {
+		log.Logger().Warnf("Failed to apply the helm chart to %d of %d clusters: %s", len(failed), len(clusters), util.ColorWarning(fmt.Sprintf("%v", failed)))
+	}
+	return util.CombineErrors(errs...)
+}
+
+// applyToCluster switches to the kube config and kube context of the cluster then applies the chart. A kube config
+// and any context switched in it are restored afterwards whereas the context of the current kube config is restored
+// once all the clusters have been applied
+func (o *StepHelmApplyOptions) applyToCluster(switcher KubeContextSwitcher, cluster config.RemoteClusterConfig, apply func(config.RemoteClusterConfig) error) (err error) {
+	if cluster.KubeConfig != "" {
+		original, found := os.LookupEnv(kubeConfigEnvVar)
+		err = os.Setenv(kubeConfigEnvVar, cluster.KubeConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set $%s", kubeConfigEnvVar)
+		}
+		defer func() {
+			if found {
+				os.Setenv(kubeConfigEnvVar, original) //nolint:errcheck
+			} else {
+				os.Unsetenv(kubeConfigEnvVar) //nolint:errcheck
+			}
+			o.ResetClientsAndNamespaces()
+		}()
+
+		if cluster.Context != "" {
+			current, currentErr := switcher.CurrentContext()
+			if currentErr != nil {
+				return currentErr
+			}
+			defer func() {
+				restoreErr := switcher.SwitchContext(current)
+				if restoreErr != nil {
+					err = util.CombineErrors(err, errors.Wrapf(restoreErr, "failed to restore the kube context %s of kube config %s", current, cluster.KubeConfig))
+				}
+			}()
+		}
+	}
+	if cluster.Context != "" {
+		err = switcher.SwitchContext(cluster.Context)
+		if err != nil {
+			return err
+		}
+	}
+	// lets recreate the clients and namespaces for the new cluster
+	o.ResetClientsAndNamespaces()
+	return apply(cluster)
+}
+
+// clusterValuesFiles returns the values files of the cluster being applied relative to the given chart directory
+func (o *StepHelmApplyOptions) clusterValuesFiles(dir string) ([]string, error) {
+	if o.cluster == nil {
+		return nil, nil
+	}
+	answer := []string{}
+	for _, valuesFile := range o.cluster.ValuesFiles {
+		path := valuesFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, valuesFile)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+		}
+		if !exists {
+			return nil, fmt.Errorf("the values file %s of %s does not exist", valuesFile, describeCluster(*o.cluster))
+		}
+		answer = append(answer, path)
+	}
+	return answer, nil
+}
+
+// writeClustersReport writes the results of applying the chart to each cluster to the --clusters-report-file as JSON
+func (o *StepHelmApplyOptions) writeClustersReport() error {
+	data, err := json.MarshalIndent(&ClustersApplyReport{Clusters: o.ClusterResults}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the clusters report")
+	}
+	err = ioutil.WriteFile(o.ClustersReportFile, data, util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the clusters report %s", o.ClustersReportFile)
+	}
+	log.Logger().Infof("Wrote the clusters report %s", util.ColorInfo(o.ClustersReportFile))
+	return nil
+}
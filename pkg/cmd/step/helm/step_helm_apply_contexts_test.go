@@ -0,0 +1,213 @@
+// +build unit
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeContextSwitcher struct {
+	contexts []string
+	current  string
+	switches []string
+}
+
+func (s *fakeContextSwitcher) CurrentContext() (string, error) {
+	return s.current, nil
+}
+
+func (s *fakeContextSwitcher) SwitchContext(name string) error {
+	for _, context := range s.contexts {
+		if context == name {
+			s.current = name
+			s.switches = append(s.switches, name)
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find Kubernetes context %s", name)
+}
+
+func TestApplyInContexts(t *testing.T) {
+	t.Parallel()
+
+	switcher := &fakeContextSwitcher{
+		contexts: []string{"dev", "staging", "production"},
+		current:  "dev",
+	}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	applied := []string{}
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		Contexts:        []string{"staging", "production"},
+		contextSwitcher: switcher,
+		applyInCluster: func(cluster config.RemoteClusterConfig) error {
+			assert.Equal(t, cluster.Context, switcher.current, "the kube context should be switched before applying")
+			applied = append(applied, cluster.Context)
+			return nil
+		},
+	}
+	err := o.Run()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staging", "production"}, applied)
+	assert.Equal(t, []string{"staging", "production", "dev"}, switcher.switches)
+	assert.Equal(t, "dev", switcher.current, "the original kube context should be restored")
+}
+
+func TestApplyInContextsAggregatesFailures(t *testing.T) {
+	t.Parallel()
+
+	switcher := &fakeContextSwitcher{
+		contexts: []string{"dev", "staging", "production"},
+		current:  "dev",
+	}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	applied := []string{}
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		Contexts:        []string{"staging", "missing", "production"},
+		contextSwitcher: switcher,
+		applyInCluster: func(cluster config.RemoteClusterConfig) error {
+			applied = append(applied, cluster.Context)
+			if cluster.Context == "staging" {
+				return fmt.Errorf("helm failed")
+			}
+			return nil
+		},
+	}
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to apply the helm chart to kube context staging: helm failed")
+	assert.Contains(t, err.Error(), "failed to apply the helm chart to kube context missing")
+	assert.NotContains(t, err.Error(), "kube context production")
+	assert.Equal(t, []string{"staging", "production"}, applied, "the chart should still be applied to the remaining contexts")
+	assert.Equal(t, "dev", switcher.current, "the original kube context should be restored")
+}
+
+func TestApplyInRequirementsClusters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-apply-clusters-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	requirements := config.NewRequirementsConfig()
+	requirements.Clusters = []config.RemoteClusterConfig{
+		{
+			Name:    "us-east",
+			Context: "staging",
+		},
+		{
+			Name:        "eu-west",
+			KubeConfig:  filepath.Join(dir, "eu-west.yaml"),
+			Namespace:   "jx-production",
+			ValuesFiles: []string{"eu-west.yaml"},
+		},
+	}
+	err = requirements.SaveConfig(filepath.Join(dir, config.RequirementsConfigFileName))
+	require.NoError(t, err)
+
+	switcher := &fakeContextSwitcher{
+		contexts: []string{"dev", "staging"},
+		current:  "dev",
+	}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	originalKubeConfig := os.Getenv(kubeConfigEnvVar)
+	kubeConfigs := []string{}
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			Dir: dir,
+		},
+		ClusterValues:      []string{"us-east=us-east.yaml"},
+		ClustersReportFile: filepath.Join(dir, "clusters.json"),
+		contextSwitcher:    switcher,
+		applyInCluster: func(cluster config.RemoteClusterConfig) error {
+			kubeConfigs = append(kubeConfigs, os.Getenv(kubeConfigEnvVar))
+			if cluster.Name == "eu-west" {
+				return fmt.Errorf("helm failed")
+			}
+			return nil
+		},
+	}
+	o.RemoteCluster = true
+
+	err = o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to apply the helm chart to cluster eu-west: helm failed")
+	assert.Equal(t, []string{originalKubeConfig, filepath.Join(dir, "eu-west.yaml")}, kubeConfigs, "the kube config of the cluster should be used when applying")
+	assert.Equal(t, originalKubeConfig, os.Getenv(kubeConfigEnvVar), "the original kube config should be restored")
+	assert.Equal(t, "dev", switcher.current, "the original kube context should be restored")
+
+	require.Len(t, o.ClusterResults, 2)
+	assert.Equal(t, "us-east", o.ClusterResults[0].Cluster)
+	assert.Equal(t, ClusterApplySucceeded, o.ClusterResults[0].Status)
+	assert.Equal(t, "eu-west", o.ClusterResults[1].Cluster)
+	assert.Equal(t, ClusterApplyFailed, o.ClusterResults[1].Status)
+	assert.Equal(t, "helm failed", o.ClusterResults[1].Error)
+
+	data, err := ioutil.ReadFile(o.ClustersReportFile)
+	require.NoError(t, err)
+	report := &ClustersApplyReport{}
+	err = json.Unmarshal(data, report)
+	require.NoError(t, err)
+	assert.Equal(t, o.ClusterResults, report.Clusters)
+
+	o.cluster = nil
+	clusters, err := o.applyClusters()
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, []string{"us-east.yaml"}, clusters[0].ValuesFiles, "the --cluster-values should be added to the cluster")
+	assert.Equal(t, []string{"eu-west.yaml"}, clusters[1].ValuesFiles)
+
+	o.ClusterValues = []string{"missing=values.yaml"}
+	_, err = o.applyClusters()
+	require.Error(t, err, "should fail if the --cluster-values refer to an unknown cluster")
+}
+
+func TestClusterValuesFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-cluster-values-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "eu-west.yaml"), []byte("replicas: 3\n"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	o := &StepHelmApplyOptions{}
+	valuesFiles, err := o.clusterValuesFiles(dir)
+	require.NoError(t, err)
+	assert.Empty(t, valuesFiles)
+
+	o.cluster = &config.RemoteClusterConfig{
+		Name:        "eu-west",
+		ValuesFiles: []string{"eu-west.yaml"},
+	}
+	valuesFiles, err = o.clusterValuesFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "eu-west.yaml")}, valuesFiles)
+
+	o.cluster.ValuesFiles = []string{"us-east.yaml"}
+	_, err = o.clusterValuesFiles(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the values file us-east.yaml of cluster eu-west does not exist")
+}
@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ApplyHashesConfigMapName the name of the ConfigMap in the namespace of the releases which stores the content
+	// hash of the last chart applied for each release keyed by the release name
+	ApplyHashesConfigMapName = "jx-helm-apply-hashes"
+)
+
+// unhashedChartFiles the files helm regenerates with a timestamp when building the dependencies whose resolved
+// versions are hashed instead
+var unhashedChartFiles = []string{"requirements.lock", "Chart.lock"}
+
+// releaseContentHash returns the hash of what is applied for the release: the files of the chart, including the
+// generated values.yaml and any provider overrides, the names and versions of its dependencies, the values files and
+// the values set on the command line. The dependency archives are not hashed as they are repackaged with their
+// secrets on every apply
+func releaseContentHash(dir string, dependencies *DependencyLock, valueFiles []string, setValues []string, setStrings []string) (string, error) {
+	hash := sha256.New()
+	chartsDir := filepath.Join(dir, "charts")
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == chartsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if util.StringArrayIndex(unhashedChartFiles, rel) >= 0 {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		writeHashEntry(hash, "file", filepath.ToSlash(rel), string(data))
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to hash the chart in %s", dir)
+	}
+	if dependencies != nil {
+		for _, dep := range dependencies.Dependencies {
+			writeHashEntry(hash, "dependency", dep.Name, dep.Version)
+		}
+	}
+	// the values files are often temporary files so only their content and order matter
+	for _, valueFile := range valueFiles {
+		data, err := ioutil.ReadFile(valueFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to load values file %s", valueFile)
+		}
+		writeHashEntry(hash, "values", string(data))
+	}
+	for _, value := range setValues {
+		writeHashEntry(hash, "set", value)
+	}
+	for _, value := range setStrings {
+		writeHashEntry(hash, "set-string", value)
+	}
+	return digestPrefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeHashEntry writes each field prefixed by its length so that different fields cannot hash the same
+func writeHashEntry(w io.Writer, fields ...string) {
+	for _, field := range fields {
+		fmt.Fprintf(w, "%d\x00%s\x00", len(field), field)
+	}
+}
+
+// lastAppliedHash returns the content hash of the chart last applied for the release or a blank string if there is none
+func lastAppliedHash(kubeClient kubernetes.Interface, ns string, releaseName string) (string, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ApplyHashesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", ApplyHashesConfigMapName, ns)
+	}
+	return cm.Data[releaseName], nil
+}
+
+// saveAppliedHash records the content hash of the chart applied for the release
+func saveAppliedHash(kubeClient kubernetes.Interface, ns string, releaseName string, hash string) error {
+	_, err := kube.DefaultModifyConfigMap(kubeClient, ns, ApplyHashesConfigMapName, func(cm *v1.ConfigMap) error {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[releaseName] = hash
+		return nil
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the applied hash of release %s in ConfigMap %s in namespace %s", releaseName, ApplyHashesConfigMapName, ns)
+	}
+	return nil
+}
+
+// clearAppliedHash removes the content hash of the release so that the chart is applied again even if it has not
+// changed, e.g. after the release has been rolled back
+func clearAppliedHash(kubeClient kubernetes.Interface, ns string, releaseName string) error {
+	hash, err := lastAppliedHash(kubeClient, ns, releaseName)
+	if err != nil || hash == "" {
+		return err
+	}
+	_, err = kube.DefaultModifyConfigMap(kubeClient, ns, ApplyHashesConfigMapName, func(cm *v1.ConfigMap) error {
+		delete(cm.Data, releaseName)
+		return nil
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clear the applied hash of release %s in ConfigMap %s in namespace %s", releaseName, ApplyHashesConfigMapName, ns)
+	}
+	return nil
+}
+
+// ClearAppliedHashes removes the content hashes of all the releases in the namespace so that their charts are applied
+// again even if they have not changed, e.g. to revert the resources which have drifted from the environment repository
+func ClearAppliedHashes(kubeClient kubernetes.Interface, ns string) error {
+	err := kubeClient.CoreV1().ConfigMaps(ns).Delete(ApplyHashesConfigMapName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete ConfigMap %s in namespace %s", ApplyHashesConfigMapName, ns)
+	}
+	return nil
+}
+
+// releaseUnchanged returns true if the chart with the given content hash was the last one applied for the release
+// so it can be skipped unless --force-apply is specified
+func (o *StepHelmApplyOptions) releaseUnchanged(kubeClient kubernetes.Interface, ns string, releaseName string, hash string) (bool, error) {
+	if o.ForceApply {
+		return false, nil
+	}
+	last, err := lastAppliedHash(kubeClient, ns, releaseName)
+	if err != nil {
+		return false, err
+	}
+	if last != hash {
+		log.Logger().Debugf("the content hash of release %s changed from %s to %s", releaseName, last, hash)
+		return false, nil
+	}
+	log.Logger().Infof("Skipping release %s in namespace %s as its chart, values and dependencies are unchanged since it was last applied with hash %s. Use --force-apply to apply it anyway", util.ColorInfo(releaseName), util.ColorInfo(ns), util.ColorInfo(hash))
+	return true, nil
+}
@@ -0,0 +1,98 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReleaseContentHash(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-apply-delta-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"Chart.yaml":                  "name: env\nversion: 0.0.1\n",
+		"values.yaml":                 "foo: bar\n",
+		"templates/configmap.yaml":    "kind: ConfigMap\n",
+		"charts/exposecontroller.tgz": "archive",
+		"requirements.lock":           "generated: 2020-04-01T10:00:00Z\n",
+		"myvalues.yaml":               "replicas: 2\n",
+	}
+	for name, text := range files {
+		fileName := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions))
+		require.NoError(t, ioutil.WriteFile(fileName, []byte(text), util.DefaultFileWritePermissions))
+	}
+	lock := &DependencyLock{Dependencies: []LockedDependency{{Name: "exposecontroller", Version: "2.3.89", Digest: "sha256:abc"}}}
+	valueFiles := []string{filepath.Join(dir, "myvalues.yaml")}
+	setValues := []string{"tags.jx-ns-staging=true"}
+
+	hash, err := releaseContentHash(dir, lock, valueFiles, setValues, nil)
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", hash)
+
+	// lets change the files which are regenerated on every apply
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "charts", "exposecontroller.tgz"), []byte("repackaged"), util.DefaultFileWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "requirements.lock"), []byte("generated: 2020-04-02T10:00:00Z\n"), util.DefaultFileWritePermissions))
+	lock.Dependencies[0].Digest = "sha256:def"
+	unchanged, err := releaseContentHash(dir, lock, valueFiles, setValues, nil)
+	require.NoError(t, err)
+	assert.Equal(t, hash, unchanged, "should ignore the dependency archives and lock files")
+
+	lock.Dependencies[0].Version = "2.3.90"
+	changed, err := releaseContentHash(dir, lock, valueFiles, setValues, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed, "should hash the dependency versions")
+	lock.Dependencies[0].Version = "2.3.89"
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte("foo: baz\n"), util.DefaultFileWritePermissions))
+	changed, err = releaseContentHash(dir, lock, valueFiles, setValues, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed, "should hash the generated values")
+
+	changed, err = releaseContentHash(dir, lock, valueFiles, nil, setValues)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed, "should distinguish set values from set strings")
+}
+
+func TestReleaseUnchanged(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := kubemocks.NewSimpleClientset()
+	o := &StepHelmApplyOptions{}
+	unchanged, err := o.releaseUnchanged(kubeClient, "jx-staging", "jx", "sha256:abc")
+	require.NoError(t, err)
+	assert.False(t, unchanged, "should apply a release which has not been applied")
+
+	require.NoError(t, saveAppliedHash(kubeClient, "jx-staging", "jx", "sha256:abc"))
+	require.NoError(t, saveAppliedHash(kubeClient, "jx-staging", "other", "sha256:def"))
+	unchanged, err = o.releaseUnchanged(kubeClient, "jx-staging", "jx", "sha256:abc")
+	require.NoError(t, err)
+	assert.True(t, unchanged)
+	unchanged, err = o.releaseUnchanged(kubeClient, "jx-staging", "jx", "sha256:123")
+	require.NoError(t, err)
+	assert.False(t, unchanged, "should apply a release whose hash has changed")
+
+	o.ForceApply = true
+	unchanged, err = o.releaseUnchanged(kubeClient, "jx-staging", "jx", "sha256:abc")
+	require.NoError(t, err)
+	assert.False(t, unchanged, "should apply an unchanged release when using --force-apply")
+
+	require.NoError(t, clearAppliedHash(kubeClient, "jx-staging", "jx"))
+	hash, err := lastAppliedHash(kubeClient, "jx-staging", "jx")
+	require.NoError(t, err)
+	assert.Equal(t, "", hash)
+	hash, err = lastAppliedHash(kubeClient, "jx-staging", "other")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:def", hash, "should only clear the hash of the release")
+}
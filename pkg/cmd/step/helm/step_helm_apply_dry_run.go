@@ -0,0 +1,281 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ResourceAdded the resource would be created by the apply
+	ResourceAdded = "added"
+	// ResourceRemoved the resource is deployed but is no longer rendered by the chart
+	ResourceRemoved = "removed"
+	// ResourceChanged the resource would be modified by the apply
+	ResourceChanged = "changed"
+	// ResourcePruned the resource was applied for the release but is no longer rendered so would be deleted by --prune
+	ResourcePruned = "pruned"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+var yamlDocumentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// ResourceDifference the difference between a deployed resource and the rendered resource which would be applied
+type ResourceDifference struct {
+	Kind        string             `json:"kind"`
+	Namespace   string             `json:"namespace,omitempty"`
+	Name        string             `json:"name"`
+	Change      string             `json:"change"`
+	Differences []ValuesDifference `json:"differences,omitempty"`
+}
+
+// manifestResource a single resource parsed from a manifest
+type manifestResource struct {
+	kind      string
+	namespace string
+	name      string
+	object    map[string]interface{}
+}
+
+// parseManifests parses the resources in the multi document YAML manifests keyed by kind, namespace and name. Resources
+// without a namespace use the given default namespace
+func parseManifests(text string, defaultNamespace string) (map[string]*manifestResource, error) {
+	answer := map[string]*manifestResource{}
+	for _, doc := range yamlDocumentSeparator.Split(text, -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		object := map[string]interface{}{}
+		err := yaml.Unmarshal([]byte(doc), &object)
+		if err != nil {
+			return answer, errors.Wrap(err, "failed to unmarshal manifest YAML")
+		}
+		kind, _ := object["kind"].(string)
+		metadata, _ := object["metadata"].(map[string]interface{})
+		if kind == "" || metadata == nil {
+			continue
+		}
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		resource := &manifestResource{
+			kind:      kind,
+			namespace: namespace,
+			name:      name,
+			object:    object,
+		}
+		answer[resource.key()] = resource
+	}
+	return answer, nil
+}
+
+func (r *manifestResource) key() string {
+	return r.kind + "/" + r.namespace + "/" + r.name
+}
+
+// diffManifests returns the differences between the deployed and rendered resources sorted by kind, namespace and name
+func diffManifests(deployed map[string]*manifestResource, rendered map[string]*manifestResource) []ResourceDifference {
+	keys := []string{}
+	for key := range rendered {
+		keys = append(keys, key)
+	}
+	for key := range deployed {
+		if rendered[key] == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	answer := []ResourceDifference{}
+	for _, key := range keys {
+		left := deployed[key]
+		right := rendered[key]
+		resource := right
+		if resource == nil {
+			resource = left
+		}
+		diff := ResourceDifference{
+			Kind:      resource.kind,
+			Namespace: resource.namespace,
+			Name:      resource.name,
+		}
+		switch {
+		case left == nil:
+			diff.Change = ResourceAdded
+		case right == nil:
+			diff.Change = ResourceRemoved
+		default:
+			diff.Differences = diffValuesMaps(left.object, right.object)
+			if len(diff.Differences) == 0 {
+				continue
+			}
+			diff.Change = ResourceChanged
+		}
+		answer = append(answer, diff)
+	}
+	return answer
+}
+
+// dryRun renders the chart with the given options and writes the differences against the currently deployed resources
+// as YAML instead of applying the chart
+func (o *StepHelmApplyOptions) dryRun(helmOptions helm.InstallChartOptions, helmTemplate bool) error {
+	rendered, err := o.renderResources(helmOptions)
+	if err != nil {
+		return err
+	}
+
+	var deployed map[string]*manifestResource
+	if helmTemplate {
+		deployed, err = o.lastAppliedResources(rendered)
+	} else {
+		deployed, err = o.releaseResources(helmOptions.ReleaseName, helmOptions.Ns)
+	}
+	if err != nil {
+		return err
+	}
+
+	diffs := diffManifests(deployed, rendered)
+	if o.Prune {
+		kubeClient, err := o.KubeClient()
+		if err != nil {
+			return err
+		}
+		diffs, err = o.addPruneDifferences(kubeClient, helmOptions.Ns, helmOptions.ReleaseName, rendered, diffs)
+		if err != nil {
+			return err
+		}
+	}
+	data, err := yaml.Marshal(diffs)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the resource differences to YAML")
+	}
+	log.Logger().Infof("Dry run of release %s in namespace %s found %d changed resource(s)", util.ColorInfo(helmOptions.ReleaseName), util.ColorInfo(helmOptions.Ns), len(diffs))
+	_, err = fmt.Fprint(o.Out, string(data))
+	return err
+}
+
+// renderResources renders the chart with the given options returning the resources it contains
+func (o *StepHelmApplyOptions) renderResources(helmOptions helm.InstallChartOptions) (map[string]*manifestResource, error) {
+	outDir, err := ioutil.TempDir("", "jx-helm-apply-render-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory to render the helm chart")
+	}
+	defer os.RemoveAll(outDir)
+
+	stopTiming := o.startTiming(PhaseRender, "manifests "+helmOptions.ReleaseName)
+	err = o.Helm().Template(helmOptions.Chart, helmOptions.ReleaseName, helmOptions.Ns, outDir, true, helmOptions.SetValues, helmOptions.SetStrings, helmOptions.ValueFiles)
+	stopTiming()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render the helm chart '%s'", helmOptions.Chart)
+	}
+	err = o.postRender(helmOptions.Dir, outDir)
+	if err != nil {
+		return nil, err
+	}
+	renderedText, err := readManifests(outDir)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := parseManifests(renderedText, helmOptions.Ns)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the rendered manifests")
+	}
+	return rendered, nil
+}
+
+// readManifests returns the concatenated YAML files rendered in the given directory
+func readManifests(dir string) (string, error) {
+	var buffer strings.Builder
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		buffer.WriteString("\n---\n")
+		buffer.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the rendered manifests in %s", dir)
+	}
+	return buffer.String(), nil
+}
+
+// releaseResources returns the resources of the deployed helm release or no resources if it has not been deployed yet
+func (o *StepHelmApplyOptions) releaseResources(releaseName string, ns string) (map[string]*manifestResource, error) {
+	args := []string{"get", "manifest", releaseName}
+	version, err := o.Helm().Version(false)
+	if err == nil {
+		v, err := semver.ParseTolerant(version)
+		if err == nil && v.Major >= 3 {
+			args = append(args, "--namespace", ns)
+		}
+	}
+	text, err := o.runCommand(&util.Command{
+		Name: o.Helm().HelmBinary(),
+		Args: args,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			log.Logger().Infof("Release %s has not been deployed yet", util.ColorInfo(releaseName))
+			return map[string]*manifestResource{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the manifest of release %s", releaseName)
+	}
+	resources, err := parseManifests(text, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the manifest of release %s", releaseName)
+	}
+	return resources, nil
+}
+
+// lastAppliedResources returns the last applied configuration of each of the rendered resources which has been
+// deployed. When using helm template mode there is no release so removed resources cannot be detected
+func (o *StepHelmApplyOptions) lastAppliedResources(rendered map[string]*manifestResource) (map[string]*manifestResource, error) {
+	answer := map[string]*manifestResource{}
+	for key, resource := range rendered {
+		text, err := o.runCommand(&util.Command{
+			Name: "kubectl",
+			Args: []string{"get", resource.kind, resource.name, "--namespace", resource.namespace, "--ignore-not-found", "-o", "jsonpath={.metadata.annotations.kubectl\\.kubernetes\\.io/last-applied-configuration}"},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the %s %s in namespace %s", resource.kind, resource.name, resource.namespace)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		object := map[string]interface{}{}
+		err = yaml.Unmarshal([]byte(text), &object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the %s annotation of %s %s", lastAppliedAnnotation, resource.kind, resource.name)
+		}
+		answer[key] = &manifestResource{
+			kind:      resource.kind,
+			namespace: resource.namespace,
+			name:      resource.name,
+			object:    object,
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,154 @@
+// +build unit
+
+package helm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDeployedManifest = `---
+# Source: myapp/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+  namespace: other
+data:
+  foo: bar
+`
+
+const testRenderedManifest = `
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp
+`
+
+func TestParseManifests(t *testing.T) {
+	t.Parallel()
+
+	resources, err := parseManifests(testDeployedManifest, "jx-staging")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	service := resources["Service/jx-staging/myapp"]
+	require.NotNil(t, service, "should have parsed the Service into the default namespace")
+	assert.Equal(t, "myapp", service.name)
+	assert.NotNil(t, resources["ConfigMap/other/old-config"], "should have kept the namespace of the ConfigMap")
+}
+
+func TestDiffManifests(t *testing.T) {
+	t.Parallel()
+
+	deployed, err := parseManifests(testDeployedManifest, "jx-staging")
+	require.NoError(t, err)
+	rendered, err := parseManifests(testRenderedManifest, "jx-staging")
+	require.NoError(t, err)
+
+	diffs := diffManifests(deployed, rendered)
+	assert.Equal(t, []ResourceDifference{
+		{
+			Kind:      "ConfigMap",
+			Namespace: "other",
+			Name:      "old-config",
+			Change:    ResourceRemoved,
+		},
+		{
+			Kind:      "Deployment",
+			Namespace: "jx-staging",
+			Name:      "myapp",
+			Change:    ResourceChanged,
+			Differences: []ValuesDifference{
+				{
+					Path:  "spec.replicas",
+					Left:  float64(1),
+					Right: float64(3),
+				},
+			},
+		},
+		{
+			Kind:      "Secret",
+			Namespace: "jx-staging",
+			Name:      "myapp",
+			Change:    ResourceAdded,
+		},
+	}, diffs)
+}
+
+func TestReleaseResources(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.Version(false)).ThenReturn("3.8.0", nil)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetHelm(helmer)
+
+	commands := [][]string{}
+	deployed := true
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+				if !deployed {
+					return "", errors.New("Error: release: not found")
+				}
+				return testDeployedManifest, nil
+			},
+		},
+	}
+
+	resources, err := o.releaseResources("jx-staging", "jx-staging")
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+	assert.Equal(t, [][]string{{"helm", "get", "manifest", "jx-staging", "--namespace", "jx-staging"}}, commands)
+
+	deployed = false
+	resources, err = o.releaseResources("jx-staging", "jx-staging")
+	require.NoError(t, err)
+	assert.Empty(t, resources, "a release which has not been deployed should have no resources")
+}
@@ -0,0 +1,30 @@
+package helm
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/externalsecrets"
+)
+
+// configureExternalSecrets converts the Secrets rendered from the secret URIs of the values into ExternalSecrets
+// when the secretStorage of the requirements is externalSecrets so that the secret values are never read by the
+// pipeline
+func (o *StepHelmApplyOptions) configureExternalSecrets(requirements *config.RequirementsConfig, secretURLClient secreturl.Client) error {
+	if requirements.SecretStorage != config.SecretStorageTypeExternalSecrets {
+		return nil
+	}
+	client, ok := secretURLClient.(*externalsecrets.Client)
+	if !ok {
+		return fmt.Errorf("the secretStorage %s requires the external secrets client but was %T", requirements.SecretStorage, secretURLClient)
+	}
+	helmer := helm.Unwrap(o.Helm())
+	helmTemplate, ok := helmer.(*helm.HelmTemplate)
+	if !ok {
+		return fmt.Errorf("the secretStorage %s is only supported when using helm template mode", requirements.SecretStorage)
+	}
+	helmTemplate.ConvertManifest = client.ConvertManifestFile
+	return nil
+}
@@ -0,0 +1,58 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/builds"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/version"
+)
+
+// provenanceAnnotations returns the annotations tracing the resources applied from the chart in the given directory
+// back to the git commit, pipeline run and versions which applied them. Annotations whose value cannot be found, e.g.
+// when not running in a pipeline, are omitted
+func (o *StepHelmApplyOptions) provenanceAnnotations(sourceDir string, chartDir string) map[string]string {
+	answer := map[string]string{}
+	add := func(key string, value string) {
+		if value != "" {
+			answer[key] = value
+		}
+	}
+
+	sha := os.Getenv(PULL_PULL_SHA)
+	if sha == "" {
+		sha = os.Getenv("PULL_BASE_SHA")
+	}
+	if sha == "" {
+		var err error
+		sha, err = o.Git().GetLatestCommitSha(sourceDir)
+		if err != nil {
+			log.Logger().Debugf("failed to find the git commit of %s: %s", sourceDir, err.Error())
+		}
+	}
+	add(helm.AnnotationGitSHA, sha)
+	add(helm.AnnotationBuildNumber, builds.GetBuildNumber())
+	add(helm.AnnotationProwJobID, os.Getenv(PROW_JOB_ID))
+
+	_, chartVersion, err := helm.LoadChartNameAndVersion(filepath.Join(chartDir, helm.ChartFileName))
+	if err != nil {
+		log.Logger().Debugf("failed to find the version of the chart in %s: %s", chartDir, err.Error())
+	}
+	add(helm.AnnotationAppliedChartVersion, chartVersion)
+	add(helm.AnnotationJXVersion, version.GetVersion())
+	return answer
+}
+
+// annotateProvenance enables adding the provenance annotations to every resource applied from the chart in the given
+// directory
+func (o *StepHelmApplyOptions) annotateProvenance(sourceDir string, chartDir string) {
+	helmer := helm.Unwrap(o.Helm())
+	helmTemplate, ok := helmer.(*helm.HelmTemplate)
+	if !ok {
+		log.Logger().Warnf("the --provenance-annotations flag is only supported when using helm template mode so ignoring it")
+		return
+	}
+	helmTemplate.Annotations = o.provenanceAnnotations(sourceDir, chartDir)
+}
@@ -0,0 +1,42 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-helm-apply-provenance-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, helm.ChartFileName), []byte("name: env\nversion: 0.0.7\n"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	for name, value := range map[string]string{PULL_PULL_SHA: "abc123", "JX_BUILD_NUMBER": "42", PROW_JOB_ID: "1234-5678"} {
+		old, hasOld := os.LookupEnv(name)
+		require.NoError(t, os.Setenv(name, value))
+		if hasOld {
+			defer os.Setenv(name, old)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+
+	o := &StepHelmApplyOptions{}
+	annotations := o.provenanceAnnotations(dir, dir)
+	assert.Equal(t, "abc123", annotations[helm.AnnotationGitSHA])
+	assert.Equal(t, "42", annotations[helm.AnnotationBuildNumber])
+	assert.Equal(t, "1234-5678", annotations[helm.AnnotationProwJobID])
+	assert.Equal(t, "0.0.7", annotations[helm.AnnotationAppliedChartVersion])
+	assert.Equal(t, version.GetVersion(), annotations[helm.AnnotationJXVersion])
+}
@@ -0,0 +1,176 @@
+package helm
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ApplyResourcesConfigMapName the name of the ConfigMap in the namespace of the releases which stores the resources
+	// last applied for each release keyed by the release name
+	ApplyResourcesConfigMapName = "jx-helm-apply-resources"
+)
+
+// DefaultPruneWhitelist the kinds of resources which are pruned by default
+var DefaultPruneWhitelist = []string{"ConfigMap", "CronJob", "DaemonSet", "Deployment", "Ingress", "Job", "Role", "RoleBinding", "Secret", "Service", "ServiceAccount", "StatefulSet"}
+
+// AppliedResource a resource applied for a release
+type AppliedResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r AppliedResource) key() string {
+	return r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// lastAppliedResourceList returns the resources last applied for the release or none if it has not been applied
+// with --prune
+func lastAppliedResourceList(kubeClient kubernetes.Interface, ns string, releaseName string) ([]AppliedResource, error) {
+	answer := []AppliedResource{}
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ApplyResourcesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return answer, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", ApplyResourcesConfigMapName, ns)
+	}
+	text := cm.Data[releaseName]
+	if text == "" {
+		return answer, nil
+	}
+	err = json.Unmarshal([]byte(text), &answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the applied resources of release %s in ConfigMap %s", releaseName, ApplyResourcesConfigMapName)
+	}
+	return answer, nil
+}
+
+// saveAppliedResourceList records the resources applied for the release sorted by kind, namespace and name
+func saveAppliedResourceList(kubeClient kubernetes.Interface, ns string, releaseName string, rendered map[string]*manifestResource) error {
+	resources := []AppliedResource{}
+	for _, resource := range rendered {
+		resources = append(resources, AppliedResource{Kind: resource.kind, Namespace: resource.namespace, Name: resource.name})
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].key() < resources[j].key()
+	})
+	data, err := json.Marshal(resources)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the applied resources of release %s", releaseName)
+	}
+	_, err = kube.DefaultModifyConfigMap(kubeClient, ns, ApplyResourcesConfigMapName, func(cm *v1.ConfigMap) error {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[releaseName] = string(data)
+		return nil
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the applied resources of release %s in ConfigMap %s in namespace %s", releaseName, ApplyResourcesConfigMapName, ns)
+	}
+	return nil
+}
+
+// pruneCandidates returns the resources of the whitelisted kinds which were last applied for the release but are no
+// longer rendered by its chart
+func (o *StepHelmApplyOptions) pruneCandidates(kubeClient kubernetes.Interface, ns string, releaseName string, rendered map[string]*manifestResource) ([]AppliedResource, error) {
+	applied, err := lastAppliedResourceList(kubeClient, ns, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	whitelist := o.PruneWhitelist
+	if len(whitelist) == 0 {
+		whitelist = DefaultPruneWhitelist
+	}
+	answer := []AppliedResource{}
+	for _, resource := range applied {
+		if rendered[resource.key()] != nil || !kindInWhitelist(resource.Kind, whitelist) {
+			continue
+		}
+		answer = append(answer, resource)
+	}
+	return answer, nil
+}
+
+// kindInWhitelist returns true if the kind matches one of the kinds of the whitelist ignoring case
+func kindInWhitelist(kind string, whitelist []string) bool {
+	for _, w := range whitelist {
+		if strings.EqualFold(w, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune deletes the resources which were last applied for the release but are no longer rendered by its chart then
+// records the rendered resources so that they can be pruned by a later apply
+func (o *StepHelmApplyOptions) prune(kubeClient kubernetes.Interface, helmOptions helm.InstallChartOptions) error {
+	rendered, err := o.renderResources(helmOptions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the resources of release %s to prune", helmOptions.ReleaseName)
+	}
+	return o.pruneResources(kubeClient, helmOptions.Ns, helmOptions.ReleaseName, rendered)
+}
+
+// pruneResources deletes the prune candidates of the rendered resources using kubectl
+func (o *StepHelmApplyOptions) pruneResources(kubeClient kubernetes.Interface, ns string, releaseName string, rendered map[string]*manifestResource) error {
+	candidates, err := o.pruneCandidates(kubeClient, ns, releaseName, rendered)
+	if err != nil {
+		return err
+	}
+	errs := []error{}
+	for _, resource := range candidates {
+		_, err = o.runCommand(&util.Command{
+			Name: "kubectl",
+			Args: []string{"delete", resource.Kind, resource.Name, "--namespace", resource.Namespace, "--ignore-not-found"},
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to prune %s %s in namespace %s", resource.Kind, resource.Name, resource.Namespace))
+			continue
+		}
+		log.Logger().Infof("Pruned %s %s in namespace %s which is no longer in release %s", resource.Kind, util.ColorInfo(resource.Name), util.ColorInfo(resource.Namespace), util.ColorInfo(releaseName))
+	}
+	err = util.CombineErrors(errs...)
+	if err != nil {
+		// lets keep the last applied resources so that the failed resources are pruned by the next apply
+		return err
+	}
+	return saveAppliedResourceList(kubeClient, ns, releaseName, rendered)
+}
+
+// addPruneDifferences adds the resources which would be pruned to the differences of a dry run
+func (o *StepHelmApplyOptions) addPruneDifferences(kubeClient kubernetes.Interface, ns string, releaseName string, rendered map[string]*manifestResource, diffs []ResourceDifference) ([]ResourceDifference, error) {
+	candidates, err := o.pruneCandidates(kubeClient, ns, releaseName, rendered)
+	if err != nil {
+		return diffs, err
+	}
+	for _, resource := range candidates {
+		found := false
+		for _, diff := range diffs {
+			if diff.Kind == resource.Kind && diff.Namespace == resource.Namespace && diff.Name == resource.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, ResourceDifference{Kind: resource.Kind, Namespace: resource.Namespace, Name: resource.Name, Change: ResourcePruned})
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Kind+"/"+diffs[i].Namespace+"/"+diffs[i].Name < diffs[j].Kind+"/"+diffs[j].Namespace+"/"+diffs[j].Name
+	})
+	return diffs, nil
+}
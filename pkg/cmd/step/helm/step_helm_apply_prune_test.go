@@ -0,0 +1,63 @@
+// +build unit
+
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPruneResources(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := kubemocks.NewSimpleClientset()
+	commands := []string{}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, cmd.Name+" "+strings.Join(cmd.Args, " "))
+				return "", nil
+			},
+		},
+	}
+
+	first, err := parseManifests(testDeployedManifest, "jx-staging")
+	require.NoError(t, err)
+	require.NoError(t, o.pruneResources(kubeClient, "jx-staging", "jx", first))
+	assert.Empty(t, commands, "should not prune a release which has not been applied with --prune")
+	applied, err := lastAppliedResourceList(kubeClient, "jx-staging", "jx")
+	require.NoError(t, err)
+	assert.Equal(t, []AppliedResource{
+		{Kind: "ConfigMap", Namespace: "other", Name: "old-config"},
+		{Kind: "Deployment", Namespace: "jx-staging", Name: "myapp"},
+		{Kind: "Service", Namespace: "jx-staging", Name: "myapp"},
+	}, applied)
+
+	second, err := parseManifests("apiVersion: v1\nkind: Service\nmetadata:\n  name: myapp\n", "jx-staging")
+	require.NoError(t, err)
+	diffs, err := o.addPruneDifferences(kubeClient, "jx-staging", "jx", second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []ResourceDifference{
+		{Kind: "ConfigMap", Namespace: "other", Name: "old-config", Change: ResourcePruned},
+		{Kind: "Deployment", Namespace: "jx-staging", Name: "myapp", Change: ResourcePruned},
+	}, diffs, "the dry run should report the resources which would be pruned")
+	assert.Empty(t, commands, "the dry run should not delete any resources")
+
+	o.PruneWhitelist = []string{"deployment", "service"}
+	require.NoError(t, o.pruneResources(kubeClient, "jx-staging", "jx", second))
+	assert.Equal(t, []string{"kubectl delete Deployment myapp --namespace jx-staging --ignore-not-found"}, commands, "should only prune the whitelisted kinds")
+	applied, err = lastAppliedResourceList(kubeClient, "jx-staging", "jx")
+	require.NoError(t, err)
+	assert.Equal(t, []AppliedResource{{Kind: "Service", Namespace: "jx-staging", Name: "myapp"}}, applied)
+}
@@ -0,0 +1,76 @@
+package helm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultWaitInstallTimeout the default timeout in seconds of helm when waiting for the release
+	defaultWaitInstallTimeout = "600"
+)
+
+var validHookDeletePolicies = []string{"before-hook-creation", "hook-succeeded", "hook-failed"}
+
+// environmentKey returns the key of the environment in the requirements which is being applied using the
+// --environment flag or the namespace of the release relative to the dev namespace
+func (o *StepHelmApplyOptions) environmentKey(ns string, devNs string) string {
+	if o.Environment != "" {
+		return o.Environment
+	}
+	if ns == devNs {
+		return kube.LabelValueDevEnvironment
+	}
+	return strings.TrimPrefix(ns, devNs+"-")
+}
+
+// configureHelmRelease configures the helmer with the helm settings of the environment in the requirements
+// returning the timeout in seconds to use when installing or upgrading the release
+func (o *StepHelmApplyOptions) configureHelmRelease(requirements *config.RequirementsConfig, envKey string) (string, error) {
+	helmConfig := requirements.EnvironmentHelmConfig(envKey)
+
+	timeout := opts.DefaultInstallTimeout
+	if o.Wait {
+		timeout = defaultWaitInstallTimeout
+	}
+	var hookTimeout time.Duration
+	if helmConfig.Timeout != "" {
+		duration, err := time.ParseDuration(helmConfig.Timeout)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid helm timeout %s for environment %s", helmConfig.Timeout, envKey)
+		}
+		timeout = strconv.Itoa(int(duration.Seconds()))
+		hookTimeout = duration
+	}
+	for _, policy := range strings.Split(helmConfig.HookDeletePolicy, ",") {
+		if policy != "" && util.StringArrayIndex(validHookDeletePolicies, policy) < 0 {
+			return "", fmt.Errorf("invalid helm hook delete policy %s for environment %s. Supported values: %s", policy, envKey, strings.Join(validHookDeletePolicies, ", "))
+		}
+	}
+
+	helmer := helm.Unwrap(o.Helm())
+	switch h := helmer.(type) {
+	case *helm.HelmTemplate:
+		if helmConfig.Atomic {
+			log.Logger().Warnf("the atomic helm setting of environment %s is not supported when using helm template mode so ignoring it", envKey)
+		}
+		h.HookDeletePolicy = helmConfig.HookDeletePolicy
+		h.HookTimeout = hookTimeout
+	case *helm.HelmCLI:
+		if helmConfig.HookDeletePolicy != "" {
+			log.Logger().Warnf("the helm hook delete policy of environment %s is only supported when using helm template mode so ignoring it", envKey)
+		}
+		h.Atomic = helmConfig.Atomic
+	}
+	return timeout, nil
+}
@@ -0,0 +1,114 @@
+package helm
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// chartRollout the rollout of the manifests rendered from a chart or one of its dependencies
+type chartRollout struct {
+	// pathSegment the segment of the paths of the rendered manifests of the dependency or blank for the chart
+	pathSegment string
+	rollout     *flagger.Rollout
+}
+
+// configureRollouts adds a Flagger Canary for the Deployments of the chart and of its dependencies whose values opt
+// in with 'rollout: canary' or 'rollout: bluegreen' so that Flagger rolls out their changes
+func (o *StepHelmApplyOptions) configureRollouts(dir string, chartName string, valueFiles []string) error {
+	values := map[string]interface{}{}
+	for _, valueFile := range valueFiles {
+		fileValues, err := helm.LoadValuesFile(valueFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load values file %s", valueFile)
+		}
+		util.CombineMapTrees(values, fileValues)
+	}
+	rollouts, err := findChartRollouts(dir, chartName, values)
+	if err != nil || len(rollouts) == 0 {
+		return err
+	}
+
+	helmTemplate, ok := helm.Unwrap(o.Helm()).(*helm.HelmTemplate)
+	if !ok {
+		log.Logger().Warnf("ignoring the rollout of app %s as generating Flagger Canaries is only supported when using helm template mode", rollouts[0].rollout.App)
+		return nil
+	}
+	previous := helmTemplate.ConvertManifest
+	helmTemplate.ConvertManifest = func(fileName string) error {
+		if previous != nil {
+			err := previous(fileName)
+			if err != nil {
+				return err
+			}
+		}
+		rollout := rolloutForManifest(rollouts, fileName)
+		if rollout == nil {
+			return nil
+		}
+		return rollout.ConvertManifestFile(fileName)
+	}
+	return nil
+}
+
+// findChartRollouts returns the rollouts of the dependencies of the chart followed by the rollout of the chart itself
+func findChartRollouts(dir string, chartName string, values map[string]interface{}) ([]chartRollout, error) {
+	answer := []chartRollout{}
+	requirementsFile := filepath.Join(dir, helm.RequirementsFileName)
+	exists, err := util.FileExists(requirementsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking if file %s exists", requirementsFile)
+	}
+	if exists {
+		requirements, err := helm.LoadRequirementsFile(requirementsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range requirements.Dependencies {
+			key := dep.Name
+			if dep.Alias != "" {
+				key = dep.Alias
+			}
+			depValues, _ := values[key].(map[string]interface{})
+			rollout, err := flagger.RolloutFromValues(key, depValues)
+			if err != nil {
+				return nil, err
+			}
+			if rollout != nil {
+				answer = append(answer, chartRollout{pathSegment: "/charts/" + dep.Name + "/", rollout: rollout})
+			}
+		}
+	}
+	rollout, err := flagger.RolloutFromValues(chartName, values)
+	if err != nil {
+		return nil, err
+	}
+	if rollout != nil {
+		answer = append(answer, chartRollout{rollout: rollout})
+	}
+	return answer, nil
+}
+
+// rolloutForManifest returns the rollout of the chart the manifest file was rendered from or nil if it has none
+func rolloutForManifest(rollouts []chartRollout, fileName string) *flagger.Rollout {
+	path := filepath.ToSlash(fileName)
+	for _, r := range rollouts {
+		if r.pathSegment != "" && strings.Contains(path, r.pathSegment) {
+			return r.rollout
+		}
+	}
+	// the manifests of dependencies without a rollout must not use the rollout of the chart
+	if strings.Contains(path, "/charts/") {
+		return nil
+	}
+	last := rollouts[len(rollouts)-1]
+	if last.pathSegment == "" {
+		return last.rollout
+	}
+	return nil
+}
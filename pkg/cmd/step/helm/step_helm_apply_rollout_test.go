@@ -0,0 +1,61 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindChartRollouts(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-chart-rollouts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	requirements := `dependencies:
+- name: myapp
+  version: 1.0.0
+- name: other
+  alias: myother
+  version: 2.0.0
+- name: plain
+  version: 3.0.0
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "requirements.yaml"), []byte(requirements), 0644))
+	values := map[string]interface{}{
+		"myapp": map[string]interface{}{
+			"rollout": "canary",
+		},
+		"myother": map[string]interface{}{
+			"rollout": "bluegreen",
+		},
+	}
+
+	rollouts, err := findChartRollouts(dir, "jx", values)
+	require.NoError(t, err)
+	require.Len(t, rollouts, 2)
+	assert.Equal(t, "myapp", rollouts[0].rollout.App)
+	assert.Equal(t, "myother", rollouts[1].rollout.App, "should use the alias of the dependency")
+
+	rollout := rolloutForManifest(rollouts, "/tmp/output/env/charts/myapp/templates/deployment.yaml")
+	require.NotNil(t, rollout)
+	assert.Equal(t, "canary", rollout.Strategy)
+	rollout = rolloutForManifest(rollouts, "/tmp/output/env/charts/other/templates/deployment.yaml")
+	require.NotNil(t, rollout)
+	assert.Equal(t, "bluegreen", rollout.Strategy)
+	assert.Nil(t, rolloutForManifest(rollouts, "/tmp/output/env/charts/plain/templates/deployment.yaml"))
+	assert.Nil(t, rolloutForManifest(rollouts, "/tmp/output/env/templates/deployment.yaml"))
+
+	values["rollout"] = "canary"
+	rollouts, err = findChartRollouts(dir, "jx", values)
+	require.NoError(t, err)
+	rollout = rolloutForManifest(rollouts, "/tmp/output/env/templates/deployment.yaml")
+	require.NotNil(t, rollout, "should roll out the chart itself")
+	assert.Equal(t, "jx", rollout.App)
+}
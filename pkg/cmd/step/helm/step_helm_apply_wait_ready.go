@@ -0,0 +1,309 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ResourceCurrent the resource has been fully reconciled and is ready
+	ResourceCurrent = "Current"
+	// ResourceInProgress the resource is still being reconciled
+	ResourceInProgress = "InProgress"
+	// ResourceFailed the resource failed to reconcile and will not become ready without a change
+	ResourceFailed = "Failed"
+
+	// DefaultWaitReadyTimeout the default time to wait for the resources of a release to become ready
+	DefaultWaitReadyTimeout = 5 * time.Minute
+
+	defaultWaitReadyPollInterval = 5 * time.Second
+
+	// maxResourceEvents the maximum number of the most recent events reported for each unhealthy resource
+	maxResourceEvents = 5
+)
+
+// ResourceHealth the health of a resource of a release
+type ResourceHealth struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Status    string
+	Message   string
+	Events    []string
+}
+
+// resourceEvent an event of a resource returned by 'kubectl get events'
+type resourceEvent struct {
+	Type          string    `json:"type"`
+	Reason        string    `json:"reason"`
+	Message       string    `json:"message"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+}
+
+// resourceKind a kind of resource in a namespace which is listed once each time the resources are polled
+type resourceKind struct {
+	kind      string
+	namespace string
+}
+
+// waitReady waits for the resources created by the release to become ready returning an error summarising the
+// unhealthy resources and their recent events if any fail or are not ready before the timeout. Hooks are ignored as
+// helm deletes them once they have run
+func (o *StepHelmApplyOptions) waitReady(helmOptions helm.InstallChartOptions, helmTemplate bool) error {
+	var resources map[string]*manifestResource
+	var err error
+	if helmTemplate {
+		resources, err = o.renderResources(helmOptions)
+	} else {
+		resources, err = o.releaseResources(helmOptions.ReleaseName, helmOptions.Ns)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the resources of release %s", helmOptions.ReleaseName)
+	}
+	keys := []string{}
+	kinds := []resourceKind{}
+	found := map[resourceKind]bool{}
+	for key, resource := range resources {
+		if isHookResource(resource.object) {
+			continue
+		}
+		keys = append(keys, key)
+		kind := resourceKind{kind: resource.kind, namespace: resource.namespace}
+		if !found[kind] {
+			found[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(keys)
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].kind != kinds[j].kind {
+			return kinds[i].kind < kinds[j].kind
+		}
+		return kinds[i].namespace < kinds[j].namespace
+	})
+
+	timeout := o.WaitReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultWaitReadyTimeout
+	}
+	pollInterval := o.waitReadyPollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultWaitReadyPollInterval
+	}
+	log.Logger().Infof("Waiting up to %s for the %d resource(s) of release %s to become ready", timeout.String(), len(keys), util.ColorInfo(helmOptions.ReleaseName))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		live := map[string]map[string]interface{}{}
+		for _, kind := range kinds {
+			err = o.listLiveResources(kind.kind, kind.namespace, live)
+			if err != nil {
+				return err
+			}
+		}
+		unhealthy := []*ResourceHealth{}
+		failed := false
+		for _, key := range keys {
+			health := resourceHealth(resources[key], live[key])
+			if health.Status == ResourceCurrent {
+				continue
+			}
+			if health.Status == ResourceFailed {
+				failed = true
+			}
+			unhealthy = append(unhealthy, health)
+		}
+		if len(unhealthy) == 0 {
+			log.Logger().Infof("All the resources of release %s are ready", util.ColorInfo(helmOptions.ReleaseName))
+			return nil
+		}
+		if failed || !time.Now().Before(deadline) {
+			for _, health := range unhealthy {
+				health.Events, err = o.resourceEvents(health)
+				if err != nil {
+					log.Logger().Warnf("failed to get the events of %s %s: %s", health.Kind, health.Name, err.Error())
+				}
+			}
+			return unhealthyResourcesError(helmOptions.ReleaseName, unhealthy, failed)
+		}
+		log.Logger().Debugf("%d resource(s) of release %s are not ready yet", len(unhealthy), helmOptions.ReleaseName)
+		time.Sleep(pollInterval)
+	}
+}
+
+// resourceHealth returns the health of the resource from its live object in the cluster
+func resourceHealth(resource *manifestResource, object map[string]interface{}) *ResourceHealth {
+	health := &ResourceHealth{
+		Kind:      resource.kind,
+		Namespace: resource.namespace,
+		Name:      resource.name,
+	}
+	if object == nil {
+		health.Status = ResourceInProgress
+		health.Message = "not found"
+		return health
+	}
+	health.Status, health.Message = computeResourceStatus(object)
+	return health
+}
+
+// computeResourceStatus computes the status of a resource in the style of kstatus. Deployments, StatefulSets,
+// DaemonSets and Jobs are checked using their replica and completion counts and any other resource using its
+// 'Ready', 'Reconciling' and 'Stalled' conditions. Resources without any status are considered current
+func computeResourceStatus(object map[string]interface{}) (string, string) {
+	kind, _ := object["kind"].(string)
+	spec, _ := object["spec"].(map[string]interface{})
+	status, _ := object["status"].(map[string]interface{})
+	metadata, _ := object["metadata"].(map[string]interface{})
+
+	generation := numberField(metadata, "generation", 0)
+	if _, ok := status["observedGeneration"]; ok && numberField(status, "observedGeneration", 0) < generation {
+		return ResourceInProgress, "the latest generation has not been observed yet"
+	}
+
+	switch kind {
+	case "Deployment":
+		replicas := numberField(spec, "replicas", 1)
+		updated := numberField(status, "updatedReplicas", 0)
+		available := numberField(status, "availableReplicas", 0)
+		for _, condition := range conditions(status) {
+			if condition["type"] == "Progressing" && condition["reason"] == "ProgressDeadlineExceeded" {
+				return ResourceFailed, fmt.Sprintf("progress deadline exceeded: %v", condition["message"])
+			}
+		}
+		if updated < replicas {
+			return ResourceInProgress, fmt.Sprintf("%d of %d replicas updated", updated, replicas)
+		}
+		if available < replicas {
+			return ResourceInProgress, fmt.Sprintf("%d of %d replicas available", available, replicas)
+		}
+		return ResourceCurrent, ""
+	case "StatefulSet":
+		replicas := numberField(spec, "replicas", 1)
+		ready := numberField(status, "readyReplicas", 0)
+		if ready < replicas {
+			return ResourceInProgress, fmt.Sprintf("%d of %d replicas ready", ready, replicas)
+		}
+		if current, _ := status["currentRevision"].(string); current != "" {
+			if update, _ := status["updateRevision"].(string); update != "" && update != current {
+				return ResourceInProgress, fmt.Sprintf("rolling out revision %s", update)
+			}
+		}
+		return ResourceCurrent, ""
+	case "DaemonSet":
+		desired := numberField(status, "desiredNumberScheduled", 0)
+		ready := numberField(status, "numberReady", 0)
+		if ready < desired {
+			return ResourceInProgress, fmt.Sprintf("%d of %d pods ready", ready, desired)
+		}
+		return ResourceCurrent, ""
+	case "Job":
+		for _, condition := range conditions(status) {
+			if condition["type"] == "Failed" && condition["status"] == "True" {
+				return ResourceFailed, fmt.Sprintf("job failed: %v", condition["message"])
+			}
+		}
+		completions := numberField(spec, "completions", 1)
+		succeeded := numberField(status, "succeeded", 0)
+		if succeeded < completions {
+			return ResourceInProgress, fmt.Sprintf("%d of %d completions succeeded", succeeded, completions)
+		}
+		return ResourceCurrent, ""
+	}
+
+	for _, condition := range conditions(status) {
+		message := fmt.Sprintf("%v", condition["message"])
+		switch {
+		case condition["type"] == "Stalled" && condition["status"] == "True":
+			return ResourceFailed, message
+		case condition["type"] == "Reconciling" && condition["status"] == "True":
+			return ResourceInProgress, message
+		case condition["type"] == "Ready" && condition["status"] != "True":
+			return ResourceInProgress, message
+		}
+	}
+	return ResourceCurrent, ""
+}
+
+// resourceEvents returns the most recent events of the resource
+func (o *StepHelmApplyOptions) resourceEvents(health *ResourceHealth) ([]string, error) {
+	text, err := o.runCommand(&util.Command{
+		Name: "kubectl",
+		Args: []string{"get", "events", "--namespace", health.Namespace, "--field-selector", "involvedObject.kind=" + health.Kind + ",involvedObject.name=" + health.Name, "-o", "json"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := struct {
+		Items []resourceEvent `json:"items"`
+	}{}
+	err = json.Unmarshal([]byte(text), &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the JSON of the events")
+	}
+	events := list.Items
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTimestamp.Before(events[j].LastTimestamp)
+	})
+	if len(events) > maxResourceEvents {
+		events = events[len(events)-maxResourceEvents:]
+	}
+	answer := []string{}
+	for _, event := range events {
+		answer = append(answer, fmt.Sprintf("%s %s: %s", event.Type, event.Reason, event.Message))
+	}
+	return answer, nil
+}
+
+// unhealthyResourcesError returns an error summarising the unhealthy resources and their recent events
+func unhealthyResourcesError(releaseName string, unhealthy []*ResourceHealth, failed bool) error {
+	reason := "did not become ready in time"
+	if failed {
+		reason = "failed"
+	}
+	lines := []string{fmt.Sprintf("%d resource(s) of release %s %s:", len(unhealthy), releaseName, reason)}
+	for _, health := range unhealthy {
+		line := fmt.Sprintf("  %s %s in namespace %s is %s", health.Kind, health.Name, health.Namespace, health.Status)
+		if health.Message != "" {
+			line += ": " + health.Message
+		}
+		lines = append(lines, line)
+		for _, event := range health.Events {
+			lines = append(lines, "    "+event)
+		}
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// conditions returns the conditions of the status of a resource
+func conditions(status map[string]interface{}) []map[string]interface{} {
+	answer := []map[string]interface{}{}
+	items, _ := status["conditions"].([]interface{})
+	for _, item := range items {
+		if condition, ok := item.(map[string]interface{}); ok {
+			answer = append(answer, condition)
+		}
+	}
+	return answer
+}
+
+// numberField returns the integer value of a field of the map or the default value if it is missing
+func numberField(values map[string]interface{}, name string, defaultValue int64) int64 {
+	switch value := values[name].(type) {
+	case float64:
+		return int64(value)
+	case int64:
+		return value
+	case int:
+		return int64(value)
+	}
+	return defaultValue
+}
@@ -0,0 +1,149 @@
+// +build unit
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeResourceStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		object   string
+		expected string
+	}{
+		{
+			name:     "available deployment",
+			object:   `{"kind":"Deployment","metadata":{"generation":2},"spec":{"replicas":2},"status":{"observedGeneration":2,"updatedReplicas":2,"availableReplicas":2}}`,
+			expected: ResourceCurrent,
+		},
+		{
+			name:     "deployment with an unobserved generation",
+			object:   `{"kind":"Deployment","metadata":{"generation":3},"spec":{"replicas":2},"status":{"observedGeneration":2,"updatedReplicas":2,"availableReplicas":2}}`,
+			expected: ResourceInProgress,
+		},
+		{
+			name:     "rolling deployment",
+			object:   `{"kind":"Deployment","spec":{"replicas":3},"status":{"updatedReplicas":3,"availableReplicas":1}}`,
+			expected: ResourceInProgress,
+		},
+		{
+			name:     "deployment exceeding its progress deadline",
+			object:   `{"kind":"Deployment","status":{"conditions":[{"type":"Progressing","status":"False","reason":"ProgressDeadlineExceeded","message":"timed out"}]}}`,
+			expected: ResourceFailed,
+		},
+		{
+			name:     "statefulset rolling out a revision",
+			object:   `{"kind":"StatefulSet","spec":{"replicas":1},"status":{"readyReplicas":1,"currentRevision":"a","updateRevision":"b"}}`,
+			expected: ResourceInProgress,
+		},
+		{
+			name:     "succeeded job",
+			object:   `{"kind":"Job","status":{"succeeded":1}}`,
+			expected: ResourceCurrent,
+		},
+		{
+			name:     "failed job",
+			object:   `{"kind":"Job","status":{"conditions":[{"type":"Failed","status":"True","message":"BackoffLimitExceeded"}]}}`,
+			expected: ResourceFailed,
+		},
+		{
+			name:     "custom resource which is not ready",
+			object:   `{"kind":"Certificate","status":{"conditions":[{"type":"Ready","status":"False","message":"issuing"}]}}`,
+			expected: ResourceInProgress,
+		},
+		{
+			name:     "stalled custom resource",
+			object:   `{"kind":"Kustomization","status":{"conditions":[{"type":"Stalled","status":"True","message":"invalid"}]}}`,
+			expected: ResourceFailed,
+		},
+		{
+			name:     "resource without a status",
+			object:   `{"kind":"ConfigMap","data":{"foo":"bar"}}`,
+			expected: ResourceCurrent,
+		},
+	}
+	for _, tc := range testCases {
+		object := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(tc.object), &object), tc.name)
+		status, _ := computeResourceStatus(object)
+		assert.Equal(t, tc.expected, status, tc.name)
+	}
+}
+
+// testHookManifest a test hook which helm deletes once it has succeeded
+const testHookManifest = `---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: myapp-test
+  annotations:
+    helm.sh/hook: test-success
+    helm.sh/hook-delete-policy: hook-succeeded
+`
+
+func TestWaitReady(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.Version(false)).ThenReturn("3.8.0", nil)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetHelm(helmer)
+
+	polls := 0
+	available := 0
+	o := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				switch {
+				case cmd.Name == "helm":
+					return testDeployedManifest + testHookManifest, nil
+				case cmd.Args[1] == "events":
+					return `{"items":[{"type":"Warning","reason":"BackOff","message":"Back-off restarting failed container","lastTimestamp":"2020-04-01T12:00:00Z"}]}`, nil
+				case cmd.Args[1] == "Job":
+					assert.Fail(t, "should not wait for the hooks")
+				case cmd.Args[1] == "Deployment":
+					polls++
+					return fmt.Sprintf(`{"items":[{"kind":"Deployment","metadata":{"name":"myapp"},"spec":{"replicas":1},"status":{"updatedReplicas":1,"availableReplicas":%d}}]}`, available), nil
+				}
+				return `{"items":[{"kind":"` + cmd.Args[1] + `","metadata":{"name":"myapp"}},{"kind":"` + cmd.Args[1] + `","metadata":{"name":"old-config"}}]}`, nil
+			},
+		},
+		WaitReadyTimeout:      50 * time.Millisecond,
+		waitReadyPollInterval: time.Millisecond,
+	}
+	helmOptions := helm.InstallChartOptions{
+		ReleaseName: "jx-staging",
+		Ns:          "jx-staging",
+	}
+
+	err := o.waitReady(helmOptions, false)
+	require.Error(t, err, "the deployment never becomes available")
+	assert.Contains(t, err.Error(), "Deployment myapp in namespace jx-staging is InProgress: 0 of 1 replicas available")
+	assert.Contains(t, err.Error(), "Warning BackOff: Back-off restarting failed container")
+	assert.NotContains(t, err.Error(), "ConfigMap", "should only report the unhealthy resources")
+	assert.True(t, polls > 1, "should have polled the deployment until the timeout")
+
+	available = 1
+	err = o.waitReady(helmOptions, false)
+	require.NoError(t, err)
+}
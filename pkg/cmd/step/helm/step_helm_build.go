@@ -11,6 +11,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
 	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -25,6 +26,12 @@ type StepHelmBuildOptions struct {
 	recursive         bool
 	Boot              bool
 	ProviderValuesDir string
+	PlanFile          string
+	ReleaseName       string
+	Namespace         string
+	ScanSecrets       bool
+	SecretsAllowlist  string
+	NoPolicies        bool
 }
 
 var (
@@ -32,12 +39,42 @@ var (
 		Builds the helm chart in a given directory.
 
 		This step is usually used to validate any GitOps Pull Requests.
+
+		The resolved version and digest of each dependency chart is written to the '` + DependencyLockFileName + `' file of the chart.
+		Once the lock is committed 'jx step helm apply' fails if the dependencies it builds no longer match the lock.
+
+		When using --scan-secrets the build fails if the values files or the rendered manifests contain anything which looks
+		like a secret such as AWS access keys, private keys, GitHub or Slack tokens, passwords in URLs or high entropy strings.
+		The '` + helm.SecretsFileName + `' and SOPS encrypted values files along with rendered Secret resources are not scanned.
+		With --boot the values files and templates of the values tree are scanned rather than the generated values.yaml.
+		False positives can be ignored via a secrets allowlist which defaults to the '` + DefaultSecretsAllowlistFileName + `' file of the chart:
+
+		    rules:
+		    - high-entropy
+		    paths:
+		    - 'values.yaml:jenkins\.image\..*'
+		    fingerprints:
+		    - sha256:4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b
+
+		If the requirements have 'policies' settings the rendered manifests must pass the rego policies of their git
+		repository, as verified by 'jx step verify policies'. Any deny rule which fires fails the build, so the Pull Request
+		of a promotion to an environment is not merged, and the violations are commented on the Pull Request of $PULL_NUMBER.
 `)
 
 	StepHelmBuildExample = templates.Examples(`
 		# builds the helm chart in the env directory
 		jx step helm build --dir env
 
+		# builds the helm chart in the env directory and writes the plan of the release as a build artifact
+		jx step helm build --dir env --boot --plan helm-plan.json --namespace jx-staging
+		jx step stash --classifier plan --pattern helm-plan.json
+
+		# builds the helm chart in the env directory failing if it contains any secrets
+		jx step helm build --dir env --scan-secrets
+
+		# builds the helm chart in the env directory without verifying the policies of the team
+		jx step helm build --dir env --no-policies
+
 `)
 )
 
@@ -64,18 +101,40 @@ func NewCmdStepHelmBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	options.addStepHelmFlags(cmd)
+	options.addValidateValuesFlag(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addValidateSchemaFlag(cmd)
+	options.addVersionResolutionFlags(cmd)
+	options.addKustomizeFlag(cmd)
 
-	cmd.Flags().BoolVarP(&options.recursive, "recursive", "r", false, "Build recursively the dependent charts")
+	cmd.Flags().BoolVarP(&options.recursive, "recursive", "r", false, "Build recursively the dependent charts. In Boot mode this also replaces missing versions in every nested 'requirements.yaml' file")
 	cmd.Flags().BoolVarP(&options.Boot, "boot", "", false, "In Boot mode we load the Version Stream from the 'jx-requirements.yml' and use that to replace any missing versions in the 'reuqirements.yaml' file from the Version Stream")
 	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	options.addProviderValuesFlags(cmd)
+	cmd.Flags().StringVarP(&options.PlanFile, "plan", "", "", "Writes the plan of the release to the given file after building the chart. The plan contains the rendered manifests, merged values and resolved dependency versions along with their content addressed hash so it can be applied via 'jx step helm apply --from-plan'. As the plan includes any rendered Secrets it should only be stored where the pipeline can read it")
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", DefaultPlanReleaseName, "The name of the release used to render the --plan")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The namespace used to render the --plan. Defaults to the current namespace")
+	cmd.Flags().BoolVarP(&options.ScanSecrets, "scan-secrets", "", false, "Fails the build if the values files or rendered manifests of the chart contain anything which looks like a secret")
+	cmd.Flags().StringVarP(&options.SecretsAllowlist, "secrets-allowlist", "", "", "The YAML file of the rules, path regular expressions and fingerprints of findings ignored by --scan-secrets. Defaults to the '"+DefaultSecretsAllowlistFileName+"' file of the chart")
+	cmd.Flags().BoolVarP(&options.NoPolicies, "no-policies", "", false, "Do not verify the rendered manifests pass the policies of the requirements")
 	return cmd
 }
 
 func (o *StepHelmBuildOptions) Run() error {
-	_, _, err := o.KubeClientAndNamespace()
+	if err := o.validateOnMissing(); err != nil {
+		return err
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
+	defer o.closeSopsValues()
+	_, ns, err := o.KubeClientAndNamespace()
 	if err != nil {
 		return err
 	}
+	if o.Namespace != "" {
+		ns = o.Namespace
+	}
 
 	dir := o.Dir
 	if dir == "" {
@@ -90,13 +149,24 @@ func (o *StepHelmBuildOptions) Run() error {
 		return err
 	}
 
+	var valuesFindings []SecretFinding
+	if o.ScanSecrets {
+		valuesFindings, err = o.scanValuesFilesForSecrets(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	var policyConfig *config.PolicyConfig
 	if o.Boot {
 		requirements, requirementsFileName, err := config.LoadRequirementsConfig(dir)
 		if err != nil {
 			return err
 		}
+		policyConfig = requirements.Policies
+		o.SetChartRepositoryMirrors(requirements.Mirrors)
 
-		secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)))
+		secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)), dir)
 		if err != nil {
 			return errors.Wrap(err, "creating a Secret URL client")
 		}
@@ -116,14 +186,14 @@ func (o *StepHelmBuildOptions) Run() error {
 		if err != nil {
 			return errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
 		}
-		if o.ProviderValuesDir != "" {
+		if o.ProviderValuesDir != "" || o.ProviderValuesConfigMap != "" {
 			chartValues, err = o.overwriteProviderValues(requirements, requirementsFileName, chartValues, params, o.ProviderValuesDir)
 			if err != nil {
 				return errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
 			}
 		}
 
-		err = o.replaceMissingVersionsFromVersionStream(requirements, dir)
+		err = o.replaceMissingVersionsFromVersionStream(requirements, dir, o.recursive)
 		if err != nil {
 			return errors.Wrapf(err, "failed to replace missing versions in the requirements.yaml in dir %s", dir)
 		}
@@ -139,11 +209,53 @@ func (o *StepHelmBuildOptions) Run() error {
 		if err != nil {
 			return err
 		}
+	} else if !o.NoPolicies {
+		policyConfig = o.teamPolicyConfig()
+	}
+	policyConfig = o.effectivePolicyConfig(policyConfig)
+
+	err = o.validateValuesSchema(dir, valuesFiles)
+	if err != nil {
+		return err
 	}
 
 	if o.recursive {
-		return o.HelmInitRecursiveDependencyBuild(dir, o.DefaultReleaseCharts(), valuesFiles)
+		err = o.HelmInitRecursiveDependencyBuild(dir, o.DefaultReleaseCharts(), valuesFiles)
+	} else {
+		_, err = o.HelmInitDependencyBuild(dir, o.DefaultReleaseCharts(), valuesFiles)
+	}
+	if err != nil {
+		return err
+	}
+	err = writeDependencyLock(dir)
+	if err != nil || (o.PlanFile == "" && !o.ScanSecrets && policyConfig == nil) {
+		return err
+	}
+
+	// the plan renders the manifests so lets reuse them when scanning for secrets and verifying the policies
+	plan, err := o.createPlan(dir, o.ReleaseName, ns, valuesFiles)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the plan")
+	}
+	if o.ScanSecrets {
+		err = o.scanSecrets(dir, valuesFindings, plan.Manifests)
+		if err != nil {
+			return err
+		}
+	}
+	if policyConfig != nil {
+		err = o.verifyPolicies(dir, policyConfig, plan.Manifests)
+		if err != nil {
+			return err
+		}
+	}
+	if o.PlanFile == "" {
+		return nil
+	}
+	err = SavePlan(plan, o.PlanFile)
+	if err != nil {
+		return err
 	}
-	_, err = o.HelmInitDependencyBuild(dir, o.DefaultReleaseCharts(), valuesFiles)
-	return err
+	log.Logger().Infof("Wrote the plan %s of release %s with hash %s", util.ColorInfo(o.PlanFile), util.ColorInfo(o.ReleaseName), util.ColorInfo(plan.Hash))
+	return nil
 }
@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/policies"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// teamPolicyConfig returns the policy settings of the requirements of the team or nil if there are none
+func (o *StepHelmBuildOptions) teamPolicyConfig() *config.PolicyConfig {
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Warnf("failed to get the team settings so not verifying the policies: %s", err.Error())
+		return nil
+	}
+	requirements, err := config.GetRequirementsConfigFromTeamSettings(teamSettings)
+	if err != nil {
+		log.Logger().Warnf("failed to get the requirements from team settings so not verifying the policies: %s", err.Error())
+		return nil
+	}
+	if requirements == nil {
+		return nil
+	}
+	return requirements.Policies
+}
+
+// effectivePolicyConfig returns the policy settings the rendered manifests are verified against or nil if they are not
+// verified
+func (o *StepHelmBuildOptions) effectivePolicyConfig(policyConfig *config.PolicyConfig) *config.PolicyConfig {
+	if o.NoPolicies || policyConfig == nil || policyConfig.URL == "" {
+		return nil
+	}
+	return policyConfig
+}
+
+// verifyPolicies fails if the rendered manifests of the chart do not pass the policies commenting the violations on
+// the Pull Request being built so that the promotion is not merged
+func (o *StepHelmBuildOptions) verifyPolicies(dir string, policyConfig *config.PolicyConfig, manifests []PlanManifest) error {
+	tmpDir, err := ioutil.TempDir("", "jx-helm-build-policies-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var policyManifests []policies.Manifest
+	for _, manifest := range manifests {
+		policyManifests = append(policyManifests, policies.Manifest{Path: manifest.Path, Content: manifest.Content})
+	}
+	manifestsDir := filepath.Join(tmpDir, "manifests")
+	files, err := policies.WriteManifests(manifestsDir, policyManifests)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	verifier := &policies.Verifier{
+		Git:           o.Git(),
+		Err:           o.Err,
+		CommandRunner: o.CommandRunner,
+	}
+	results, err := verifier.Verify(tmpDir, policyConfig, manifestsDir, files)
+	if err != nil {
+		return err
+	}
+	failures, warnings := policies.CountViolations(results)
+	policies.LogViolations(results)
+
+	pullRequest := os.Getenv("PULL_NUMBER")
+	if pullRequest != "" && failures+warnings > 0 {
+		gitInfo, err := o.FindGitInfo(dir)
+		if err != nil {
+			log.Logger().Warnf("failed to find git repository so cannot comment on the Pull Request: %s", err.Error())
+		} else {
+			err = o.CommentOnPullRequest(gitInfo, pullRequest, policies.CommentMarker, policies.Comment(results))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d policy violations found in the rendered manifests of the chart in %s", failures, dir)
+	}
+	log.Logger().Infof("The rendered manifests of the chart in %s pass the policies %s with %d warnings", util.ColorInfo(dir), util.ColorInfo(policyConfig.URL), warnings)
+	return nil
+}
@@ -0,0 +1,49 @@
+// +build unit
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPolicies(t *testing.T) {
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetGit(gits.NewGitFake())
+	output := ""
+	o := &StepHelmBuildOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+				CommandRunner: func(cmd *util.Command) (string, error) {
+					assert.Equal(t, "conftest", cmd.Name)
+					assert.Contains(t, cmd.Args, "env/templates/deployment.yaml")
+					_, err := cmd.Out.Write([]byte(output))
+					return "", err
+				},
+			},
+		},
+	}
+	policyConfig := o.effectivePolicyConfig(&config.PolicyConfig{URL: "https://github.com/myorg/policies.git"})
+	require.NotNil(t, policyConfig)
+	manifests := []PlanManifest{{Path: "env/templates/deployment.yaml", Content: "kind: Deployment\n"}}
+
+	output = `[{"filename":"env/templates/deployment.yaml","namespace":"main","failures":[{"msg":"Containers must not run as root"}]}]`
+	err := o.verifyPolicies("env", policyConfig, manifests)
+	require.Error(t, err, "deny rules should fail the build")
+	assert.Contains(t, err.Error(), "1 policy violations")
+
+	output = `[{"filename":"env/templates/deployment.yaml","namespace":"main","warnings":[{"msg":"Deployment has no liveness probe"}]}]`
+	require.NoError(t, o.verifyPolicies("env", policyConfig, manifests), "warn rules should not fail the build")
+
+	assert.Nil(t, o.effectivePolicyConfig(&config.PolicyConfig{}), "requirements without a policies git URL should not be verified")
+	o.NoPolicies = true
+	assert.Nil(t, o.effectivePolicyConfig(policyConfig), "--no-policies should not verify the policies")
+}
@@ -2,6 +2,7 @@ package helm
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
@@ -9,27 +10,64 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// AnnotationHelmReleaseName the annotation helm 3 adds to the resources of a release with the release name
+	AnnotationHelmReleaseName = "meta.helm.sh/release-name"
+	// AnnotationHelmReleaseNamespace the annotation helm 3 adds to the resources of a release with its namespace
+	AnnotationHelmReleaseNamespace = "meta.helm.sh/release-namespace"
+)
+
+// releaseLabels the labels whose value is the name of the release which owns a resource
+var releaseLabels = []string{helm.LabelReleaseName, "release", "app.kubernetes.io/instance"}
+
+// protectedNamespaces the namespaces which are never deleted by --purge-namespaces
+var protectedNamespaces = []string{"default", "kube-system", "kube-public", "kube-node-lease"}
+
 // StepHelmDeleteOptions contains the command line flags
 type StepHelmDeleteOptions struct {
 	StepHelmOptions
 
-	Namespace string
-	Purge     bool
+	Namespace       string
+	Purge           bool
+	PurgePVCs       bool
+	PurgeCRDs       bool
+	PurgeNamespaces bool
+	Confirm         bool
 }
 
 var (
 	stepHelmDeleteLong = templates.LongDesc(`
 		Deletes a helm release
+
+		Deleting a release leaves behind the PersistentVolumeClaims created by its StatefulSets, its
+		CustomResourceDefinitions and any namespaces it created. These are also deleted if --purge-pvcs, --purge-crds
+		or --purge-namespaces are specified. A PersistentVolumeClaim is owned by the release if it has one of the labels
+		'jenkins.io/chart-release', 'release' or 'app.kubernetes.io/instance' or the helm 3 annotation
+		'meta.helm.sh/release-name' with the name of the release. As a release with the same name could be installed in
+		another namespace the cluster scoped CustomResourceDefinitions and namespaces are only owned by the release if
+		they have both the 'meta.helm.sh/release-name' and 'meta.helm.sh/release-namespace' annotations of the release.
+
+		The resources which will be deleted are listed before asking for confirmation. Use --confirm to delete them
+		without asking, e.g. in a pipeline.
 `)
 
 	stepHelmDeleteExample = templates.Examples(`
-		# list all the helm releases in the current namespace
-		jx step helm list
+		# delete the jx-preview release in the current namespace
+		jx step helm delete jx-preview --purge
+
+		# delete the jx-preview release along with its persistent volume claims and namespaces without asking
+		jx step helm delete jx-preview --namespace jx-preview --purge --purge-pvcs --purge-namespaces --confirm
 
 `)
 )
@@ -59,12 +97,19 @@ func NewCmdStepHelmDelete(commonOpts *opts.CommonOptions) *cobra.Command {
 	options.addStepHelmFlags(cmd)
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "the namespace to look for the helm releases. Defaults to the current namespace")
 	cmd.Flags().BoolVarP(&options.Purge, "purge", "", false, "Whether to purge the helm release")
+	cmd.Flags().BoolVarP(&options.PurgePVCs, "purge-pvcs", "", false, "Deletes the PersistentVolumeClaims owned by the release in its namespace")
+	cmd.Flags().BoolVarP(&options.PurgeCRDs, "purge-crds", "", false, "Deletes the CustomResourceDefinitions owned by the release along with all of their custom resources")
+	cmd.Flags().BoolVarP(&options.PurgeNamespaces, "purge-namespaces", "", false, "Deletes the namespaces owned by the release along with everything in them")
+	cmd.Flags().BoolVarP(&options.Confirm, "confirm", "", false, "Deletes the resources owned by the release without asking for confirmation")
 
 	return cmd
 }
 
 // Run performs the CLI command
 func (o *StepHelmDeleteOptions) Run() error {
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
 	args := o.Args
 	if len(args) == 0 {
 		return util.MissingArgument("releaseName")
@@ -82,10 +127,178 @@ func (o *StepHelmDeleteOptions) Run() error {
 			return err
 		}
 	}
+
+	// lets find and confirm what will be cleaned up before deleting anything
+	var kubeClient kubernetes.Interface
+	var apiClient apiextensionsclientset.Interface
+	var resources []AppliedResource
+	cleanup := o.PurgePVCs || o.PurgeCRDs || o.PurgeNamespaces
+	if cleanup {
+		kubeClient, err = o.KubeClient()
+		if err != nil {
+			return err
+		}
+		if o.PurgeCRDs {
+			apiClient, err = o.ApiExtensionsClient()
+			if err != nil {
+				return errors.Wrap(err, "failed to create the API extensions client")
+			}
+		}
+		resources, err = o.cleanupInventory(kubeClient, apiClient, ns, releaseName)
+		if err != nil {
+			return err
+		}
+		err = o.confirmCleanup(releaseName, resources)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = h.DeleteRelease(ns, releaseName, o.Purge)
 	if err != nil {
 		return err
 	}
 	log.Logger().Infof("Deleted release %s in namespace %s", util.ColorInfo(releaseName), util.ColorInfo(ns))
+	if cleanup {
+		return o.cleanupResources(kubeClient, apiClient, releaseName, resources)
+	}
 	return nil
 }
+
+// ownedByRelease returns true if the labels or annotations of a resource show that it is owned by the release in the
+// given namespace. Cluster scoped resources must have the release namespace annotation as the labels do not say which
+// namespace the release is in
+func ownedByRelease(meta metav1.ObjectMeta, ns string, releaseName string, clusterScoped bool) bool {
+	if meta.Annotations[AnnotationHelmReleaseName] == releaseName {
+		releaseNs := meta.Annotations[AnnotationHelmReleaseNamespace]
+		if clusterScoped {
+			return releaseNs == ns
+		}
+		return releaseNs == "" || releaseNs == ns
+	}
+	if clusterScoped {
+		return false
+	}
+	for _, label := range releaseLabels {
+		if meta.Labels[label] == releaseName {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupInventory returns the PVCs, CRDs and namespaces owned by the release which are deleted by the --purge-pvcs,
+// --purge-crds and --purge-namespaces flags
+func (o *StepHelmDeleteOptions) cleanupInventory(kubeClient kubernetes.Interface, apiClient apiextensionsclientset.Interface, ns string, releaseName string) ([]AppliedResource, error) {
+	answer := []AppliedResource{}
+	if o.PurgePVCs {
+		pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the PersistentVolumeClaims in namespace %s", ns)
+		}
+		for _, pvc := range pvcs.Items {
+			if ownedByRelease(pvc.ObjectMeta, ns, releaseName, false) {
+				answer = append(answer, AppliedResource{Kind: "PersistentVolumeClaim", Namespace: ns, Name: pvc.Name})
+			}
+		}
+	}
+	if o.PurgeCRDs {
+		crds, err := apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the CustomResourceDefinitions")
+		}
+		for _, crd := range crds.Items {
+			if ownedByRelease(crd.ObjectMeta, ns, releaseName, true) {
+				answer = append(answer, AppliedResource{Kind: "CustomResourceDefinition", Name: crd.Name})
+			}
+		}
+	}
+	if o.PurgeNamespaces {
+		namespaces, err := kubeClient.CoreV1().Namespaces().List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the namespaces")
+		}
+		protected := protectedNamespaces
+		_, devNs, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find the development namespace which must not be deleted")
+		}
+		if devNs != "" {
+			protected = append([]string{devNs}, protected...)
+		}
+		for _, namespace := range namespaces.Items {
+			if !ownedByRelease(namespace.ObjectMeta, ns, releaseName, true) {
+				continue
+			}
+			if util.StringArrayIndex(protected, namespace.Name) >= 0 {
+				log.Logger().Warnf("Not deleting namespace %s owned by release %s as it is protected", util.ColorInfo(namespace.Name), util.ColorInfo(releaseName))
+				continue
+			}
+			answer = append(answer, AppliedResource{Kind: "Namespace", Name: namespace.Name})
+		}
+	}
+	// lets delete the namespaces last as they contain the other resources
+	kindOrder := []string{"PersistentVolumeClaim", "CustomResourceDefinition", "Namespace"}
+	sort.SliceStable(answer, func(i, j int) bool {
+		ki := util.StringArrayIndex(kindOrder, answer[i].Kind)
+		kj := util.StringArrayIndex(kindOrder, answer[j].Kind)
+		if ki != kj {
+			return ki < kj
+		}
+		return answer[i].Name < answer[j].Name
+	})
+	return answer, nil
+}
+
+// confirmCleanup prints the resources which will be deleted with the release then asks for confirmation unless
+// --confirm is specified. Returns an error if the deletion is not confirmed
+func (o *StepHelmDeleteOptions) confirmCleanup(releaseName string, resources []AppliedResource) error {
+	if len(resources) == 0 {
+		log.Logger().Infof("No PersistentVolumeClaims, CustomResourceDefinitions or namespaces owned by release %s were found to delete", util.ColorInfo(releaseName))
+		return nil
+	}
+	log.Logger().Infof("The following resources owned by release %s will be deleted:", util.ColorInfo(releaseName))
+	for _, resource := range resources {
+		if resource.Namespace != "" {
+			log.Logger().Infof("  %s %s in namespace %s", resource.Kind, util.ColorWarning(resource.Name), resource.Namespace)
+		} else {
+			log.Logger().Infof("  %s %s", resource.Kind, util.ColorWarning(resource.Name))
+		}
+	}
+	if o.Confirm {
+		return nil
+	}
+	if o.BatchMode {
+		return fmt.Errorf("refusing to delete the %d resources owned by release %s in batch mode without --confirm", len(resources), releaseName)
+	}
+	confirmed, err := util.Confirm(fmt.Sprintf("Delete release %s and the %d resources listed above which cannot be recovered?", releaseName, len(resources)), false, "The data of deleted PersistentVolumeClaims, the custom resources of deleted CustomResourceDefinitions and everything in deleted namespaces is lost", o.GetIOFileHandles())
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("not deleting release %s as the deletion of its resources was not confirmed", releaseName)
+	}
+	return nil
+}
+
+// cleanupResources deletes the resources owned by the release once it has been deleted
+func (o *StepHelmDeleteOptions) cleanupResources(kubeClient kubernetes.Interface, apiClient apiextensionsclientset.Interface, releaseName string, resources []AppliedResource) error {
+	errs := []error{}
+	for _, resource := range resources {
+		var err error
+		switch resource.Kind {
+		case "PersistentVolumeClaim":
+			err = kubeClient.CoreV1().PersistentVolumeClaims(resource.Namespace).Delete(resource.Name, &metav1.DeleteOptions{})
+		case "CustomResourceDefinition":
+			err = apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(resource.Name, &metav1.DeleteOptions{})
+		case "Namespace":
+			err = kubeClient.CoreV1().Namespaces().Delete(resource.Name, &metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete %s %s", resource.Kind, resource.Name))
+			continue
+		}
+		log.Logger().Infof("Deleted %s %s owned by release %s", resource.Kind, util.ColorInfo(resource.Name), util.ColorInfo(releaseName))
+	}
+	return util.CombineErrors(errs...)
+}
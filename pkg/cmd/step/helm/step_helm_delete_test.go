@@ -0,0 +1,77 @@
+// +build unit
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsmocks "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStepHelmDeleteCleanup(t *testing.T) {
+	t.Parallel()
+
+	owned := map[string]string{AnnotationHelmReleaseName: "jx-preview", AnnotationHelmReleaseNamespace: "jx-preview"}
+	kubeClient := kubemocks.NewSimpleClientset(
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "jx-preview", Labels: map[string]string{"release": "jx-preview"}}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "jx-preview", Labels: map[string]string{"release": "other"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jx-preview-db", Annotations: owned}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Annotations: owned}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jx-other-db", Labels: map[string]string{helm.LabelReleaseName: "jx-preview"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jx-staging"}},
+	)
+	apiClient := apiextensionsmocks.NewSimpleClientset(
+		&v1beta1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "databases.example.com", Annotations: map[string]string{AnnotationHelmReleaseName: "jx-preview", AnnotationHelmReleaseNamespace: "jx-preview"}}},
+		&v1beta1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "caches.example.com", Annotations: map[string]string{AnnotationHelmReleaseName: "jx-preview", AnnotationHelmReleaseNamespace: "jx-other"}}},
+		&v1beta1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "queues.example.com", Annotations: map[string]string{AnnotationHelmReleaseName: "jx-preview"}}},
+	)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetKubeClient(kubeClient)
+	o := &StepHelmDeleteOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		PurgePVCs:       true,
+		PurgeCRDs:       true,
+		PurgeNamespaces: true,
+	}
+	resources, err := o.cleanupInventory(kubeClient, apiClient, "jx-preview", "jx-preview")
+	require.NoError(t, err)
+	assert.Equal(t, []AppliedResource{
+		{Kind: "PersistentVolumeClaim", Namespace: "jx-preview", Name: "data-db-0"},
+		{Kind: "CustomResourceDefinition", Name: "databases.example.com"},
+		{Kind: "Namespace", Name: "jx-preview-db"},
+	}, resources, "should only find the resources owned by the release skipping protected namespaces and cluster scoped resources without the release namespace")
+
+	o.BatchMode = true
+	err = o.confirmCleanup("jx-preview", resources)
+	require.Error(t, err, "should not delete the resources in batch mode without --confirm")
+	o.Confirm = true
+	require.NoError(t, o.confirmCleanup("jx-preview", resources))
+
+	require.NoError(t, o.cleanupResources(kubeClient, apiClient, "jx-preview", resources))
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims("jx-preview").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pvcs.Items, 1)
+	assert.Equal(t, "other", pvcs.Items[0].Name)
+	_, err = kubeClient.CoreV1().Namespaces().Get("jx-preview-db", metav1.GetOptions{})
+	assert.Error(t, err, "should delete the namespace")
+	_, err = kubeClient.CoreV1().Namespaces().Get("kube-system", metav1.GetOptions{})
+	assert.NoError(t, err, "should keep the protected namespace")
+	crds, err := apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, crds.Items, 2)
+	assert.Equal(t, "caches.example.com", crds.Items[0].Name)
+}
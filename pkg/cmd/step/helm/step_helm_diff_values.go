@@ -0,0 +1,206 @@
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
+
+// StepHelmDiffValuesOptions contains the command line flags
+type StepHelmDiffValuesOptions struct {
+	StepHelmOptions
+
+	LeftDir           string
+	RightDir          string
+	ProviderValuesDir string
+	FailOnDiff        bool
+}
+
+// ValuesDifference a single difference between the merged values of two environments.
+// A nil Left or Right value means the path is missing on that side
+type ValuesDifference struct {
+	Path  string      `json:"path"`
+	Left  interface{} `json:"left,omitempty"`
+	Right interface{} `json:"right,omitempty"`
+}
+
+var (
+	stepHelmDiffValuesLong = templates.LongDesc(`
+		Compares the merged helm values of two environment directories.
+
+		The values of each directory are generated in the same way as 'jx step helm apply' does: the values tree is
+		merged together, any 'values.tmpl.yaml' templates are evaluated and any kubernetes provider specific overrides
+		are applied. The differences are then output as YAML.
+`)
+
+	stepHelmDiffValuesExample = templates.Examples(`
+		# compare the merged values of the staging and production environments
+		jx step helm diff-values --left-dir staging/env --right-dir production/env
+
+		# fail if the merged values differ
+		jx step helm diff-values --left-dir staging/env --right-dir production/env --fail-on-diff
+`)
+)
+
+// NewCmdStepHelmDiffValues creates the command
+func NewCmdStepHelmDiffValues(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmDiffValuesOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "diff-values",
+		Short:   "Compares the merged helm values of two environment directories",
+		Long:    stepHelmDiffValuesLong,
+		Example: stepHelmDiffValuesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.LeftDir, "left-dir", "", "", "The directory of the first environment chart")
+	cmd.Flags().StringVarP(&options.RightDir, "right-dir", "", "", "The directory of the second environment chart")
+	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	options.addProviderValuesFlags(cmd)
+	cmd.Flags().BoolVarP(&options.FailOnDiff, "fail-on-diff", "", false, "Returns an error if the merged values of the two environments differ")
+	return cmd
+}
+
+// Run implements the command
+func (o *StepHelmDiffValuesOptions) Run() error {
+	if o.LeftDir == "" {
+		return util.MissingOption("left-dir")
+	}
+	if o.RightDir == "" {
+		return util.MissingOption("right-dir")
+	}
+	differences, err := o.DiffValues()
+	if err != nil {
+		return err
+	}
+	if len(differences) == 0 {
+		log.Logger().Infof("The merged values of %s and %s are the same", util.ColorInfo(o.LeftDir), util.ColorInfo(o.RightDir))
+		return nil
+	}
+	data, err := yaml.Marshal(differences)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the values differences to YAML")
+	}
+	fmt.Fprintf(o.Out, "%s", string(data))
+	if o.FailOnDiff {
+		return fmt.Errorf("found %d differences between the merged values of %s and %s", len(differences), o.LeftDir, o.RightDir)
+	}
+	return nil
+}
+
+// DiffValues returns the differences between the merged values of the left and right directories sorted by path
+func (o *StepHelmDiffValuesOptions) DiffValues() ([]ValuesDifference, error) {
+	left, err := o.mergedValues(o.LeftDir)
+	if err != nil {
+		return nil, err
+	}
+	right, err := o.mergedValues(o.RightDir)
+	if err != nil {
+		return nil, err
+	}
+	return diffValuesMaps(left, right), nil
+}
+
+// mergedValues generates the merged values for the given directory like 'jx step helm apply' does
+func (o *StepHelmDiffValuesOptions) mergedValues(dir string) (map[string]interface{}, error) {
+	requirements, requirementsFileName, err := config.LoadRequirementsConfig(dir)
+	if err != nil {
+		if requirementsFileName != "" {
+			return nil, errors.Wrapf(err, "failed to load %s", requirementsFileName)
+		}
+		log.Logger().Debugf("no %s found for %s so using the defaults", config.RequirementsConfigFileName, dir)
+		requirements = config.NewRequirementsConfig()
+	}
+	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)), dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a Secret URL client")
+	}
+
+	DefaultEnvironments(requirements, nil)
+
+	funcMap, err := o.createFuncMap(requirements)
+	if err != nil {
+		return nil, err
+	}
+	data, params, err := helm.GenerateValues(requirements, funcMap, dir, nil, false, secretURLClient)
+	if err != nil {
+		return nil, errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
+	}
+	if (o.ProviderValuesDir != "" || o.ProviderValuesConfigMap != "") && requirementsFileName != "" {
+		data, err = o.overwriteProviderValues(requirements, requirementsFileName, data, params, o.ProviderValuesDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
+		}
+	}
+	values, err := helm.LoadValues(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the merged values of %s", dir)
+	}
+	return values, nil
+}
+
+// diffValuesMaps compares the leaf values of the two maps
+func diffValuesMaps(left map[string]interface{}, right map[string]interface{}) []ValuesDifference {
+	answer := []ValuesDifference{}
+	leftLeaves := flattenValues("", left)
+	rightLeaves := flattenValues("", right)
+	for path, l := range leftLeaves {
+		r, ok := rightLeaves[path]
+		if !ok || !reflect.DeepEqual(l, r) {
+			answer = append(answer, ValuesDifference{Path: path, Left: l, Right: r})
+		}
+	}
+	for path, r := range rightLeaves {
+		if _, ok := leftLeaves[path]; !ok {
+			answer = append(answer, ValuesDifference{Path: path, Right: r})
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Path < answer[j].Path
+	})
+	return answer
+}
+
+// flattenValues returns the non map values indexed by their dotted path
+func flattenValues(prefix string, values map[string]interface{}) map[string]interface{} {
+	answer := map[string]interface{}{}
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		m, ok := value.(map[string]interface{})
+		if ok && len(m) > 0 {
+			for k, v := range flattenValues(path, m) {
+				answer[k] = v
+			}
+		} else {
+			answer[path] = value
+		}
+	}
+	return answer
+}
@@ -0,0 +1,84 @@
+// +build unit
+
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestDiffValuesOptions(t *testing.T) *StepHelmDiffValuesOptions {
+	resolver, _ := createTestResolver(t)
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.Out = os.Stdout
+	commonOpts.SetSecretURLClient(fakevault.NewFakeClient())
+
+	o := &StepHelmDiffValuesOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		LeftDir:  filepath.Join("test_data", "diff_values", "staging"),
+		RightDir: filepath.Join("test_data", "diff_values", "production"),
+	}
+	o.versionResolver = resolver
+	return o
+}
+
+func TestDiffValues(t *testing.T) {
+	o := createTestDiffValuesOptions(t)
+
+	differences, err := o.DiffValues()
+	require.NoError(t, err, "failed to diff the values")
+
+	paths := []string{}
+	for _, d := range differences {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"debug", "expose.config.domain", "expose.config.tls", "nginx.cluster", "replicaCount"}, paths)
+
+	for _, d := range differences {
+		switch d.Path {
+		case "debug":
+			assert.Equal(t, true, d.Left, "left value of %s", d.Path)
+			assert.Nil(t, d.Right, "right value of %s", d.Path)
+		case "expose.config.domain":
+			assert.Equal(t, "staging.example.com", d.Left, "left value of %s", d.Path)
+			assert.Equal(t, "example.com", d.Right, "right value of %s", d.Path)
+		case "expose.config.tls":
+			assert.Nil(t, d.Left, "left value of %s", d.Path)
+			assert.Equal(t, true, d.Right, "right value of %s", d.Path)
+		case "nginx.cluster":
+			assert.Equal(t, "staging", d.Left, "left value of %s", d.Path)
+			assert.Equal(t, "production", d.Right, "right value of %s", d.Path)
+		}
+	}
+}
+
+func TestDiffValuesSameDirectory(t *testing.T) {
+	o := createTestDiffValuesOptions(t)
+	o.RightDir = o.LeftDir
+	o.FailOnDiff = true
+
+	err := o.Run()
+	assert.NoError(t, err, "should not fail when the values are the same")
+}
+
+func TestDiffValuesFailOnDiff(t *testing.T) {
+	o := createTestDiffValuesOptions(t)
+
+	err := o.Run()
+	assert.NoError(t, err, "should not fail on differences by default")
+
+	o.FailOnDiff = true
+	err = o.Run()
+	assert.Error(t, err, "should fail on differences when --fail-on-diff is enabled")
+}
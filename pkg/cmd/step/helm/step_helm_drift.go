@@ -0,0 +1,204 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// hookAnnotation the annotation of the resources of a chart which are helm hooks
+const hookAnnotation = "helm.sh/hook"
+
+// DetectDrift compares the resources rendered into the given directory against the live resources in the cluster
+// returning the resources which have been changed or deleted since they were applied. Only the rendered fields are
+// compared so that the defaulted fields and the status of the live resources are ignored. Secrets are skipped as
+// their rendered values are not comparable with the encoded live values and hooks are skipped as they are not kept
+// in sync with the release
+func (o *StepHelmOptions) DetectDrift(renderDir string, ns string) ([]ResourceDifference, error) {
+	text, err := readManifests(renderDir)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := parseManifests(text, ns)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the rendered manifests")
+	}
+	keys := []string{}
+	for key, resource := range rendered {
+		if resource.kind == "Secret" || isHookResource(resource.object) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// lets list each kind of resource in each namespace once rather than getting each resource
+	live := map[string]map[string]interface{}{}
+	listed := map[string]bool{}
+	answer := []ResourceDifference{}
+	for _, key := range keys {
+		resource := rendered[key]
+		listKey := resource.kind + "/" + resource.namespace
+		if !listed[listKey] {
+			err = o.listLiveResources(resource.kind, resource.namespace, live)
+			if err != nil {
+				return nil, err
+			}
+			listed[listKey] = true
+		}
+		diff := ResourceDifference{
+			Kind:      resource.kind,
+			Namespace: resource.namespace,
+			Name:      resource.name,
+		}
+		object := live[key]
+		if object == nil {
+			diff.Change = ResourceAdded
+		} else {
+			diff.Differences = driftDifferences("", resource.object, object)
+			if len(diff.Differences) == 0 {
+				continue
+			}
+			diff.Change = ResourceChanged
+		}
+		answer = append(answer, diff)
+	}
+	return answer, nil
+}
+
+// isHookResource returns true if the resource is a helm hook
+func isHookResource(object map[string]interface{}) bool {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	_, ok := annotations[hookAnnotation]
+	return ok
+}
+
+// listLiveResources adds the live objects of the given kind in the namespace to the objects keyed like the rendered
+// resources
+func (o *StepHelmOptions) listLiveResources(kind string, ns string, objects map[string]map[string]interface{}) error {
+	text, err := o.runCommand(&util.Command{
+		Name: "kubectl",
+		Args: []string{"get", kind, "--namespace", ns, "-o", "json"},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the %s resources in namespace %s", kind, ns)
+	}
+	list := struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	err = json.Unmarshal([]byte(text), &list)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the %s resources in namespace %s", kind, ns)
+	}
+	for _, object := range list.Items {
+		metadata, _ := object["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		objects[kind+"/"+ns+"/"+name] = object
+	}
+	return nil
+}
+
+// driftDifferences returns the differences between a rendered value and the live value ignoring any live fields
+// which are not rendered. The live values are the left side of the differences like for 'jx step helm apply --dry-run'
+func driftDifferences(path string, rendered interface{}, live interface{}) []ValuesDifference {
+	switch r := rendered.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(r) == 0 && live == nil {
+				return nil
+			}
+			return []ValuesDifference{{Path: path, Left: live, Right: rendered}}
+		}
+		keys := []string{}
+		for key := range r {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		answer := []ValuesDifference{}
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			answer = append(answer, driftDifferences(childPath, r[key], l[key])...)
+		}
+		return answer
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(r) {
+			if len(r) == 0 && len(l) == 0 {
+				return nil
+			}
+			return []ValuesDifference{{Path: path, Left: live, Right: rendered}}
+		}
+		answer := []ValuesDifference{}
+		for i := range r {
+			answer = append(answer, driftDifferences(fmt.Sprintf("%s[%d]", path, i), r[i], l[i])...)
+		}
+		return answer
+	default:
+		if driftValuesEqual(rendered, live) {
+			return nil
+		}
+		return []ValuesDifference{{Path: path, Left: live, Right: rendered}}
+	}
+}
+
+// driftValuesEqual returns true if the rendered and live scalar values are the same. Numbers are compared by value as
+// the rendered YAML and live JSON numbers have different types and quantities such as 1Gi and 1024Mi or 1 and "1" are
+// compared semantically as the API server normalises them
+func driftValuesEqual(rendered interface{}, live interface{}) bool {
+	if reflect.DeepEqual(rendered, live) {
+		return true
+	}
+	r, rNumber := driftNumber(rendered)
+	l, lNumber := driftNumber(live)
+	if rNumber && lNumber {
+		return r == l
+	}
+	_, rString := rendered.(string)
+	_, lString := live.(string)
+	if (!rString && !rNumber) || (!lString && !lNumber) {
+		return false
+	}
+	rq, err := resource.ParseQuantity(driftQuantityText(rendered))
+	if err != nil {
+		return false
+	}
+	lq, err := resource.ParseQuantity(driftQuantityText(live))
+	if err != nil {
+		return false
+	}
+	return rq.Cmp(lq) == 0
+}
+
+// driftNumber returns the value of a number parsed from YAML or JSON
+func driftNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func driftQuantityText(value interface{}) string {
+	if n, ok := driftNumber(value); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,122 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDriftRendered = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  labels:
+    app: myapp
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: myapp
+        image: myapp:1.0.0
+        ports:
+        - containerPort: 8080
+        resources:
+          limits:
+            cpu: 1
+            memory: 1Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp
+data:
+  password: cGFzc3dvcmQ=
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: myapp-migrate
+  annotations:
+    helm.sh/hook: pre-upgrade
+`
+
+	testDriftLiveDeployment = `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"myapp","namespace":"jx-production",
+"labels":{"app":"myapp"},"annotations":{"deployment.kubernetes.io/revision":"3"}},
+"spec":{"replicas":5,"template":{"spec":{"containers":[{"name":"myapp","image":"myapp:1.0.0","imagePullPolicy":"IfNotPresent",
+"ports":[{"containerPort":8080,"protocol":"TCP"}],"resources":{"limits":{"cpu":"1","memory":"1024Mi"}}}]}}}},
+"status":{"replicas":5}}`
+)
+
+func TestDetectDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-step-helm-drift")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "manifests.yaml"), []byte(testDriftRendered), util.DefaultFileWritePermissions))
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commands := [][]string{}
+	liveDeployment := testDriftLiveDeployment
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		commandRunner: func(cmd *util.Command) (string, error) {
+			commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+			if cmd.Args[1] == "Deployment" {
+				return `{"items":[` + liveDeployment + `]}`, nil
+			}
+			return `{"items":[]}`, nil
+		},
+	}
+
+	diffs, err := o.DetectDrift(dir, "jx-production")
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, ResourceDifference{
+		Kind:        "Deployment",
+		Namespace:   "jx-production",
+		Name:        "myapp",
+		Change:      ResourceChanged,
+		Differences: []ValuesDifference{{Path: "spec.replicas", Left: float64(5), Right: float64(2)}},
+	}, diffs[0])
+	assert.Equal(t, ResourceDifference{Kind: "Service", Namespace: "jx-production", Name: "myapp", Change: ResourceAdded}, diffs[1])
+	assert.Len(t, commands, 2, "secrets and hooks should not be compared")
+	assert.Equal(t, []string{"kubectl", "get", "Deployment", "--namespace", "jx-production", "-o", "json"}, commands[0])
+
+	liveDeployment = `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"myapp","labels":{"app":"myapp"}},
+"spec":{"replicas":2,"template":{"spec":{"containers":[{"name":"myapp","image":"myapp:0.9.0",
+"ports":[{"containerPort":8080}],"resources":{"limits":{"cpu":"1000m","memory":"1Gi"}}}]}}}}`
+	diffs, err = o.DetectDrift(dir, "jx-production")
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, []ValuesDifference{{Path: "spec.template.spec.containers[0].image", Left: "myapp:0.9.0", Right: "myapp:1.0.0"}}, diffs[0].Differences)
+}
+
+func TestDriftValuesEqual(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, driftValuesEqual(int64(8080), float64(8080)))
+	assert.True(t, driftValuesEqual(float64(1), "1"))
+	assert.True(t, driftValuesEqual("1Gi", "1024Mi"))
+	assert.True(t, driftValuesEqual("500m", "0.5"))
+	assert.False(t, driftValuesEqual("1Gi", "1G"))
+	assert.False(t, driftValuesEqual(int64(2), float64(5)))
+	assert.False(t, driftValuesEqual("myapp:1.0.0", "myapp:0.9.0"))
+	assert.False(t, driftValuesEqual(true, "true"))
+}
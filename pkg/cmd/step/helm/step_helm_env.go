@@ -1,9 +1,15 @@
 package helm
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 
@@ -14,20 +20,64 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	// EnvFormatBash outputs bash export statements
+	EnvFormatBash = "bash"
+	// EnvFormatFish outputs fish set statements
+	EnvFormatFish = "fish"
+	// EnvFormatPowerShell outputs PowerShell $env assignments
+	EnvFormatPowerShell = "powershell"
+	// EnvFormatGitHub appends the environment variables to the $GITHUB_ENV file of GitHub Actions
+	EnvFormatGitHub = "github"
+	// EnvFormatDotEnv outputs a dotenv file
+	EnvFormatDotEnv = "dotenv"
+
+	githubEnvVar       = "GITHUB_ENV"
+	githubEnvDelimiter = "JX_HELM_ENV_EOF"
+)
+
+// EnvFormats the supported --format values of 'jx step helm env'
+var EnvFormats = []string{EnvFormatBash, EnvFormatFish, EnvFormatPowerShell, EnvFormatGitHub, EnvFormatDotEnv}
+
 // StepHelmEnvOptions contains the command line flags
 type StepHelmEnvOptions struct {
 	StepHelmOptions
+
+	Format    string
+	ConfigMap string
+	Namespace string
 }
 
 var (
 	StepHelmEnvLong = templates.LongDesc(`
 		Generates the helm environment variables
+
+		The --format option outputs the environment variables for the following shells and CI systems:
+
+		* ` + EnvFormatBash + ` - bash export statements
+		* ` + EnvFormatFish + ` - fish set statements
+		* ` + EnvFormatPowerShell + ` - PowerShell $env assignments
+		* ` + EnvFormatGitHub + ` - appended to the $GITHUB_ENV file of GitHub Actions so later steps of the job can use them
+		* ` + EnvFormatDotEnv + ` - a dotenv file
+
+		The environment variables can also be written to a ConfigMap via --configmap so they can be mounted into the pods of a pipeline.
 `)
 
 	StepHelmEnvExample = templates.Examples(`
 		# output the helm environment variables that should be set to use helm directly
 		jx step helm env
 
+		# sets the helm environment variables in fish
+		jx step helm env --format fish | source
+
+		# sets the helm environment variables in PowerShell
+		jx step helm env --format powershell | Invoke-Expression
+
+		# sets the helm environment variables for the later steps of a GitHub Actions job
+		jx step helm env --format github
+
+		# writes the helm environment variables to the helm-env ConfigMap
+		jx step helm env --format dotenv --configmap helm-env
 `)
 )
 
@@ -54,22 +104,110 @@ func NewCmdStepHelmEnv(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	options.addStepHelmFlags(cmd)
 
+	cmd.Flags().StringVarP(&options.Format, "format", "", EnvFormatBash, fmt.Sprintf("The format of the environment variables. Supported values: %s", strings.Join(EnvFormats, ", ")))
+	cmd.Flags().StringVarP(&options.ConfigMap, "configmap", "", "", "The name of a ConfigMap to create or update with the environment variables")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The namespace of the --configmap. Defaults to the current namespace")
 	return cmd
 }
 
 func (o *StepHelmEnvOptions) Run() error {
+	if util.StringArrayIndex(EnvFormats, o.Format) < 0 {
+		return util.InvalidOption("format", o.Format, EnvFormats)
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
 	h := o.Helm()
-	if h != nil {
-		log.Logger().Info("")
-		log.Logger().Info("# helm environment variables")
-		envVars := h.Env()
-		keys := util.SortedMapKeys(envVars)
-		for _, key := range keys {
-			if strings.HasPrefix(key, "HELM") {
-				log.Logger().Infof("export %s=\"%s\"", key, envVars[key])
+	if h == nil {
+		return nil
+	}
+	envVars := map[string]string{}
+	for key, value := range h.Env() {
+		if strings.HasPrefix(key, "HELM") {
+			envVars[key] = value
+		}
+	}
+
+	out := o.Out
+	if o.Format == EnvFormatGitHub {
+		fileName := os.Getenv(githubEnvVar)
+		if fileName != "" {
+			f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, util.DefaultFileWritePermissions)
+			if err != nil {
+				return errors.Wrapf(err, "failed to open the $%s file %s", githubEnvVar, fileName)
 			}
+			defer f.Close()
+			out = f
+			log.Logger().Infof("Appending the helm environment variables to %s", util.ColorInfo(fileName))
 		}
-		log.Logger().Info("")
 	}
+	err := writeEnvVars(out, o.Format, envVars)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the environment variables as %s", o.Format)
+	}
+	if o.ConfigMap == "" {
+		return nil
+	}
+	return o.saveEnvConfigMap(envVars)
+}
+
+// saveEnvConfigMap creates or updates the --configmap with the environment variables
+func (o *StepHelmEnvOptions) saveEnvConfigMap(envVars map[string]string) error {
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return err
+	}
+	if o.Namespace != "" {
+		ns = o.Namespace
+	}
+	_, err = kube.DefaultModifyConfigMap(kubeClient, ns, o.ConfigMap, func(cm *v1.ConfigMap) error {
+		cm.Data = envVars
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Wrote the helm environment variables to ConfigMap %s in namespace %s", util.ColorInfo(o.ConfigMap), util.ColorInfo(ns))
 	return nil
 }
+
+// writeEnvVars writes the environment variables sorted by name in the given format
+func writeEnvVars(out io.Writer, format string, envVars map[string]string) error {
+	buffer := strings.Builder{}
+	if format != EnvFormatGitHub {
+		buffer.WriteString("# helm environment variables\n")
+	}
+	for _, key := range util.SortedMapKeys(envVars) {
+		value := envVars[key]
+		switch format {
+		case EnvFormatBash:
+			buffer.WriteString(fmt.Sprintf("export %s=\"%s\"\n", key, escapeEnvValue(value, "\\", "\"", "$", "`")))
+		case EnvFormatFish:
+			buffer.WriteString(fmt.Sprintf("set -gx %s '%s'\n", key, escapeEnvValue(value, "\\", "'")))
+		case EnvFormatPowerShell:
+			buffer.WriteString(fmt.Sprintf("$env:%s = '%s'\n", key, strings.Replace(value, "'", "''", -1)))
+		case EnvFormatGitHub:
+			// multi line values need a delimiter in the $GITHUB_ENV file
+			if strings.Contains(value, "\n") {
+				buffer.WriteString(fmt.Sprintf("%s<<%s\n%s\n%s\n", key, githubEnvDelimiter, value, githubEnvDelimiter))
+			} else {
+				buffer.WriteString(fmt.Sprintf("%s=%s\n", key, value))
+			}
+		case EnvFormatDotEnv:
+			value = escapeEnvValue(value, "\\", "\"")
+			buffer.WriteString(fmt.Sprintf("%s=\"%s\"\n", key, strings.Replace(value, "\n", "\\n", -1)))
+		default:
+			return util.InvalidOption("format", format, EnvFormats)
+		}
+	}
+	_, err := io.WriteString(out, buffer.String())
+	return err
+}
+
+// escapeEnvValue escapes the given characters of the value with a backslash
+func escapeEnvValue(value string, chars ...string) string {
+	for _, c := range chars {
+		value = strings.Replace(value, c, "\\"+c, -1)
+	}
+	return value
+}
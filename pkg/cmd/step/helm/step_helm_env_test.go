@@ -0,0 +1,70 @@
+// +build unit
+
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWriteEnvVars(t *testing.T) {
+	t.Parallel()
+
+	envVars := map[string]string{
+		"HELM_HOME":  "/home/jenkins/.helm",
+		"HELM_QUOTE": `it's "$HOME"`,
+	}
+	expected := map[string]string{
+		EnvFormatBash:       "# helm environment variables\nexport HELM_HOME=\"/home/jenkins/.helm\"\nexport HELM_QUOTE=\"it's \\\"\\$HOME\\\"\"\n",
+		EnvFormatFish:       "# helm environment variables\nset -gx HELM_HOME '/home/jenkins/.helm'\nset -gx HELM_QUOTE 'it\\'s \"$HOME\"'\n",
+		EnvFormatPowerShell: "# helm environment variables\n$env:HELM_HOME = '/home/jenkins/.helm'\n$env:HELM_QUOTE = 'it''s \"$HOME\"'\n",
+		EnvFormatGitHub:     "HELM_HOME=/home/jenkins/.helm\nHELM_QUOTE=it's \"$HOME\"\n",
+		EnvFormatDotEnv:     "# helm environment variables\nHELM_HOME=\"/home/jenkins/.helm\"\nHELM_QUOTE=\"it's \\\"$HOME\\\"\"\n",
+	}
+	for _, format := range EnvFormats {
+		out := &strings.Builder{}
+		err := writeEnvVars(out, format, envVars)
+		require.NoError(t, err)
+		assert.Equal(t, expected[format], out.String(), "format %s", format)
+	}
+
+	out := &strings.Builder{}
+	err := writeEnvVars(out, EnvFormatGitHub, map[string]string{"HELM_VALUES": "a\nb"})
+	require.NoError(t, err)
+	assert.Equal(t, "HELM_VALUES<<"+githubEnvDelimiter+"\na\nb\n"+githubEnvDelimiter+"\n", out.String(), "should use a delimiter for multi line values")
+
+	err = writeEnvVars(out, "csh", envVars)
+	assert.Error(t, err)
+}
+
+func TestSaveEnvConfigMap(t *testing.T) {
+	t.Parallel()
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	kubeClient := kubemocks.NewSimpleClientset()
+	commonOpts.SetKubeClient(kubeClient)
+	o := &StepHelmEnvOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+		},
+		ConfigMap: "helm-env",
+		Namespace: "jx",
+	}
+	envVars := map[string]string{"HELM_HOME": "/home/jenkins/.helm"}
+	require.NoError(t, o.saveEnvConfigMap(envVars))
+	envVars = map[string]string{"HELM_HOME": "/tmp/helm"}
+	require.NoError(t, o.saveEnvConfigMap(envVars), "should update an existing ConfigMap")
+
+	data, err := kube.GetConfigMapData(kubeClient, "helm-env", "jx")
+	require.NoError(t, err)
+	assert.Equal(t, envVars, data)
+}
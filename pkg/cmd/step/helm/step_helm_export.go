@@ -0,0 +1,460 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ExportFormatFlux exports Flux v2 HelmRepository and HelmRelease resources
+	ExportFormatFlux = "flux"
+	// ExportFormatArgoCD exports Argo CD Application resources
+	ExportFormatArgoCD = "argocd"
+
+	// DefaultExportDir the default directory the exported resources are written to
+	DefaultExportDir = "export"
+
+	defaultArgoCDNamespace = "argocd"
+	defaultArgoCDServer    = "https://kubernetes.default.svc"
+	defaultFluxInterval    = "10m"
+	ociRepositoryPrefix    = "oci://"
+)
+
+// ExportFormats the supported export formats
+var ExportFormats = []string{ExportFormatFlux, ExportFormatArgoCD}
+
+// StepHelmExportOptions contains the command line flags
+type StepHelmExportOptions struct {
+	StepHelmOptions
+
+	Format          string
+	OutputDir       string
+	Namespace       string
+	ArgoCDNamespace string
+	ArgoCDProject   string
+	Interval        string
+	VersionStream   bool
+}
+
+// ExportedRelease a dependency of the environment chart exported as its own release
+type ExportedRelease struct {
+	Name       string
+	Chart      string
+	Version    string
+	Repository string
+	Values     map[string]interface{}
+}
+
+var (
+	StepHelmExportLong = templates.LongDesc(`
+		Exports the dependencies of an environment chart as Flux v2 HelmRelease or Argo CD Application resources.
+
+		Each dependency of the 'requirements.yaml' of the chart becomes its own release using the version pinned in the
+		requirements or, if it has no version, the version resolved from the version stream of the 'jx-requirements.yml'.
+		The values of each release are the values under the name or alias of the dependency in the values files of the
+		chart, along with the 'global' values. Dependencies disabled by their condition or tags in the values are not
+		exported.
+
+		The resources are written to one file per resource in the --output-dir so that the releases can be moved from
+		'jx step helm apply' to Flux or Argo CD one at a time. The secrets files are not exported, so any secret URIs in
+		the values need to be replaced with the secret mechanism of Flux or Argo CD.
+`)
+
+	StepHelmExportExample = templates.Examples(`
+		# exports the releases of the env chart as Flux HelmReleases
+		jx step helm export --dir env --format flux --namespace jx-staging
+
+		# exports the releases of the env chart as Argo CD Applications
+		jx step helm export --dir env --format argocd --output-dir argocd/staging
+
+`)
+)
+
+// NewCmdStepHelmExport creates the command to export the releases of an environment chart for Flux or Argo CD
+func NewCmdStepHelmExport(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmExportOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Exports the dependencies of an environment chart as Flux HelmReleases or Argo CD Applications",
+		Long:    StepHelmExportLong,
+		Example: StepHelmExportExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory containing the environment chart to export")
+	options.addValuesFilesFlag(cmd)
+
+	cmd.Flags().StringVarP(&options.Format, "format", "", ExportFormatFlux, fmt.Sprintf("The format of the exported resources. Values: %s", strings.Join(ExportFormats, ", ")))
+	cmd.Flags().StringVarP(&options.OutputDir, "output-dir", "", DefaultExportDir, "The directory the exported resources are written to")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "jx", "The namespace the releases are installed into")
+	cmd.Flags().StringVarP(&options.ArgoCDNamespace, "argocd-namespace", "", defaultArgoCDNamespace, "The namespace of the Argo CD Applications")
+	cmd.Flags().StringVarP(&options.ArgoCDProject, "argocd-project", "", "default", "The Argo CD project of the Applications")
+	cmd.Flags().StringVarP(&options.Interval, "interval", "", defaultFluxInterval, "The interval at which Flux reconciles the HelmRepositories and HelmReleases")
+	cmd.Flags().BoolVarP(&options.VersionStream, "version-stream", "", true, "Resolves the versions of the dependencies without a version from the version stream of the 'jx-requirements.yml'")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepHelmExportOptions) Run() error {
+	if util.StringArrayIndex(ExportFormats, o.Format) < 0 {
+		return util.InvalidOption("format", o.Format, ExportFormats)
+	}
+	dir := o.Dir
+	if dir == "" {
+		dir = "."
+	}
+	releases, err := o.exportedReleases(dir)
+	if err != nil {
+		return err
+	}
+	if len(releases) == 0 {
+		log.Logger().Warnf("The chart in %s has no dependencies to export", dir)
+		return nil
+	}
+
+	var resources map[string]map[string]interface{}
+	if o.Format == ExportFormatArgoCD {
+		resources, err = o.argoCDResources(releases)
+		if err != nil {
+			return err
+		}
+	} else {
+		resources = o.fluxResources(releases)
+	}
+	err = os.MkdirAll(o.OutputDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", o.OutputDir)
+	}
+	for name, resource := range resources {
+		data, err := yaml.Marshal(resource)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s to YAML", name)
+		}
+		fileName := filepath.Join(o.OutputDir, name+".yaml")
+		err = ioutil.WriteFile(fileName, data, util.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", fileName)
+		}
+	}
+	log.Logger().Infof("Exported the %d releases of the chart in %s to %s", len(releases), dir, util.ColorInfo(o.OutputDir))
+	return nil
+}
+
+// exportedReleases returns a release for each enabled dependency of the chart with its resolved version, repository
+// URL and the values of the dependency
+func (o *StepHelmExportOptions) exportedReleases(dir string) ([]ExportedRelease, error) {
+	requirementsFile, err := o.resolvedRequirementsFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if requirementsFile == "" {
+		return nil, nil
+	}
+	defer os.RemoveAll(filepath.Dir(requirementsFile))
+	requirements, err := helm.LoadRequirementsFile(requirementsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the requirements of the chart in %s", dir)
+	}
+
+	valuesFiles, err := o.findValuesFiles(dir, []string{helm.ValuesFileName, "myvalues.yaml"})
+	if err != nil {
+		return nil, err
+	}
+	values, err := helm.MergeValuesFiles(valuesFiles...)
+	if err != nil {
+		return nil, err
+	}
+	globals, _ := values["global"].(map[string]interface{})
+
+	repoURLs := map[string]string{}
+	answer := []ExportedRelease{}
+	for _, dep := range requirements.Dependencies {
+		name := dep.Alias
+		if name == "" {
+			name = dep.Name
+		}
+		if !dependencyEnabled(dep, values) {
+			log.Logger().Infof("Not exporting the dependency %s of the chart in %s as it is disabled", util.ColorInfo(name), dir)
+			continue
+		}
+		if dep.Version == "" {
+			return nil, errors.Errorf("the dependency %s of the chart in %s has no version", name, dir)
+		}
+		repoURL, err := o.exportRepositoryURL(dep.Repository, repoURLs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export the dependency %s of the chart in %s", name, dir)
+		}
+		releaseValues, _ := values[name].(map[string]interface{})
+		if releaseValues == nil {
+			releaseValues = map[string]interface{}{}
+		}
+		if len(globals) > 0 {
+			releaseValues["global"] = globals
+		}
+		for _, path := range findSecretURIs("", releaseValues) {
+			log.Logger().Warnf("the value %s of release %s is a secret URI which Flux and Argo CD cannot resolve", path, util.ColorWarning(name))
+		}
+		answer = append(answer, ExportedRelease{
+			Name:       name,
+			Chart:      dep.Name,
+			Version:    dep.Version,
+			Repository: repoURL,
+			Values:     releaseValues,
+		})
+	}
+	return answer, nil
+}
+
+// dependencyEnabled returns false if the condition or tags of the dependency disable it in the values like helm does.
+// The first path of the condition which is a boolean takes precedence over the tags and the dependency is enabled if
+// any of its tags is enabled or none of them are set
+func dependencyEnabled(dep *helm.Dependency, values map[string]interface{}) bool {
+	for _, path := range strings.Split(dep.Condition, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if enabled, ok := valueViaPath(values, path).(bool); ok {
+			return enabled
+		}
+	}
+	tags, _ := values["tags"].(map[string]interface{})
+	disabled := false
+	for _, tag := range dep.Tags {
+		enabled, ok := tags[tag].(bool)
+		if ok && enabled {
+			return true
+		}
+		disabled = disabled || ok
+	}
+	return !disabled
+}
+
+// valueViaPath returns the value at the dot separated path of the values or nil if there is none
+func valueViaPath(values map[string]interface{}, path string) interface{} {
+	var value interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// resolvedRequirementsFile returns a copy of the requirements file of the chart with any missing versions resolved
+// from the version stream, or a blank string if the chart has no requirements
+func (o *StepHelmExportOptions) resolvedRequirementsFile(dir string) (string, error) {
+	fileName := filepath.Join(dir, helm.RequirementsFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return "", nil
+	}
+	tmpDir, err := ioutil.TempDir("", "jx-helm-export-")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a temporary directory")
+	}
+	answer := filepath.Join(tmpDir, helm.RequirementsFileName)
+	err = util.CopyFile(fileName, answer)
+	if err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+		return "", errors.Wrapf(err, "failed to copy %s", fileName)
+	}
+	if !o.VersionStream {
+		return answer, nil
+	}
+	requirements, _, err := config.LoadRequirementsConfig(dir)
+	if err != nil {
+		log.Logger().Debugf("using the default version stream as there is no requirements file: %s", err.Error())
+		requirements = config.NewRequirementsConfig()
+	}
+	err = o.replaceMissingVersionsFromVersionStream(requirements, tmpDir, false)
+	if err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+		return "", errors.Wrapf(err, "failed to resolve the versions of %s", fileName)
+	}
+	return answer, nil
+}
+
+// exportRepositoryURL returns the URL of the chart repository of a dependency looking up repository names, such as
+// '@jenkins-x' or 'alias:jenkins-x', in the helm repositories
+func (o *StepHelmExportOptions) exportRepositoryURL(repository string, repoURLs map[string]string) (string, error) {
+	if strings.HasPrefix(repository, "file://") || repository == "" {
+		return "", errors.Errorf("the local chart repository %q cannot be exported", repository)
+	}
+	name := ""
+	if strings.HasPrefix(repository, "@") {
+		name = strings.TrimPrefix(repository, "@")
+	} else if strings.HasPrefix(repository, "alias:") {
+		name = strings.TrimPrefix(repository, "alias:")
+	}
+	if name == "" {
+		return repository, nil
+	}
+	if len(repoURLs) == 0 {
+		repos, err := o.Helm().ListRepos()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to list the helm repositories")
+		}
+		for k, v := range repos {
+			repoURLs[k] = v
+		}
+	}
+	answer := repoURLs[name]
+	if answer == "" {
+		return "", errors.Errorf("there is no helm repository called %s", name)
+	}
+	return answer, nil
+}
+
+// findSecretURIs returns the sorted paths of the values which are secret URIs
+func findSecretURIs(path string, values map[string]interface{}) []string {
+	answer := []string{}
+	for key, value := range values {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			answer = append(answer, findSecretURIs(childPath, v)...)
+		case string:
+			if secreturl.IsURI(v) {
+				answer = append(answer, childPath)
+			}
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// repositoryResourceName returns the name of the HelmRepository of a chart repository URL
+func repositoryResourceName(repoURL string) string {
+	name := strings.TrimPrefix(repoURL, ociRepositoryPrefix)
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "http://")
+	return naming.ToValidName(strings.Trim(name, "/"))
+}
+
+// fluxResources returns the Flux HelmRepository and HelmRelease resources of the releases keyed by their file name
+func (o *StepHelmExportOptions) fluxResources(releases []ExportedRelease) map[string]map[string]interface{} {
+	interval := o.Interval
+	if interval == "" {
+		interval = defaultFluxInterval
+	}
+	answer := map[string]map[string]interface{}{}
+	for _, release := range releases {
+		repoName := repositoryResourceName(release.Repository)
+		repoSpec := map[string]interface{}{
+			"interval": interval,
+			"url":      release.Repository,
+		}
+		if strings.HasPrefix(release.Repository, ociRepositoryPrefix) {
+			repoSpec["type"] = "oci"
+		}
+		answer["helmrepository-"+repoName] = map[string]interface{}{
+			"apiVersion": "source.toolkit.fluxcd.io/v1beta2",
+			"kind":       "HelmRepository",
+			"metadata": map[string]interface{}{
+				"name":      repoName,
+				"namespace": o.Namespace,
+			},
+			"spec": repoSpec,
+		}
+		answer["helmrelease-"+release.Name] = map[string]interface{}{
+			"apiVersion": "helm.toolkit.fluxcd.io/v2beta1",
+			"kind":       "HelmRelease",
+			"metadata": map[string]interface{}{
+				"name":      release.Name,
+				"namespace": o.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"interval":    interval,
+				"releaseName": release.Name,
+				"chart": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"chart":   release.Chart,
+						"version": release.Version,
+						"sourceRef": map[string]interface{}{
+							"kind": "HelmRepository",
+							"name": repoName,
+						},
+					},
+				},
+				"values": release.Values,
+			},
+		}
+	}
+	return answer
+}
+
+// argoCDResources returns the Argo CD Application resources of the releases keyed by their file name
+func (o *StepHelmExportOptions) argoCDResources(releases []ExportedRelease) (map[string]map[string]interface{}, error) {
+	answer := map[string]map[string]interface{}{}
+	for _, release := range releases {
+		helmSource := map[string]interface{}{
+			"releaseName": release.Name,
+		}
+		if len(release.Values) > 0 {
+			data, err := yaml.Marshal(release.Values)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal the values of release %s to YAML", release.Name)
+			}
+			helmSource["values"] = string(data)
+		}
+		answer["application-"+release.Name] = map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata": map[string]interface{}{
+				"name":      release.Name,
+				"namespace": o.ArgoCDNamespace,
+			},
+			"spec": map[string]interface{}{
+				"project": o.ArgoCDProject,
+				"source": map[string]interface{}{
+					// Argo CD expects OCI repositories without the scheme
+					"repoURL":        strings.TrimPrefix(release.Repository, ociRepositoryPrefix),
+					"chart":          release.Chart,
+					"targetRevision": release.Version,
+					"helm":           helmSource,
+				},
+				"destination": map[string]interface{}{
+					"server":    defaultArgoCDServer,
+					"namespace": o.Namespace,
+				},
+			},
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,127 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFluxAndArgoCD(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-export-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	chartDir := filepath.Join(dir, "env")
+	require.NoError(t, os.MkdirAll(chartDir, util.DefaultWritePermissions))
+	requirements := `dependencies:
+- name: exposecontroller
+  alias: expose
+  version: 2.3.89
+  repository: https://storage.googleapis.com/chartmuseum.jenkins-x.io
+- name: nexus
+  version: 0.1.20
+  repository: oci://ghcr.io/jenkins-x/charts
+- name: lighthouse
+  version: 0.0.633
+  repository: https://storage.googleapis.com/chartmuseum.jenkins-x.io
+  condition: lighthouse.enabled
+- name: docker-registry
+  version: 1.9.2
+  repository: https://kubernetes-charts.storage.googleapis.com
+  tags:
+  - registry
+`
+	values := `global:
+  domain: example.com
+expose:
+  config:
+    exposer: Ingress
+nexus:
+  password: vault:mycluster/nexus:password
+lighthouse:
+  enabled: false
+tags:
+  registry: false
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(chartDir, "requirements.yaml"), []byte(requirements), util.DefaultFileWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(values), util.DefaultFileWritePermissions))
+
+	loadResource := func(fileName string) map[string]interface{} {
+		data, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+		resource := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal(data, &resource))
+		return resource
+	}
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	o := &StepHelmExportOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			Dir: chartDir,
+		},
+		Format:        ExportFormatFlux,
+		OutputDir:     filepath.Join(dir, "flux"),
+		Namespace:     "jx-staging",
+		Interval:      "5m",
+		VersionStream: true,
+	}
+	require.NoError(t, o.Run())
+
+	files, err := filepath.Glob(filepath.Join(o.OutputDir, "*.yaml"))
+	require.NoError(t, err)
+	names := []string{}
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	assert.ElementsMatch(t, []string{
+		"helmrelease-expose.yaml",
+		"helmrelease-nexus.yaml",
+		"helmrepository-ghcr-io-jenkins-x-charts.yaml",
+		"helmrepository-storage-googleapis-com-chartmuseum-jenkins-x-io.yaml",
+	}, names, "should not export the disabled dependencies")
+
+	release := loadResource(filepath.Join(o.OutputDir, "helmrelease-expose.yaml"))
+	assert.Equal(t, "HelmRelease", release["kind"])
+	spec := release["spec"].(map[string]interface{})
+	chart := spec["chart"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, "exposecontroller", chart["chart"])
+	assert.Equal(t, "2.3.89", chart["version"])
+	assert.Equal(t, "storage-googleapis-com-chartmuseum-jenkins-x-io", chart["sourceRef"].(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{
+		"config": map[string]interface{}{"exposer": "Ingress"},
+		"global": map[string]interface{}{"domain": "example.com"},
+	}, spec["values"])
+	repo := loadResource(filepath.Join(o.OutputDir, "helmrepository-ghcr-io-jenkins-x-charts.yaml"))
+	assert.Equal(t, "oci", repo["spec"].(map[string]interface{})["type"])
+
+	o.Format = ExportFormatArgoCD
+	o.OutputDir = filepath.Join(dir, "argocd")
+	o.ArgoCDNamespace = "argocd"
+	o.ArgoCDProject = "default"
+	require.NoError(t, o.Run())
+	app := loadResource(filepath.Join(o.OutputDir, "application-nexus.yaml"))
+	assert.Equal(t, "argocd", app["metadata"].(map[string]interface{})["namespace"])
+	appSpec := app["spec"].(map[string]interface{})
+	source := appSpec["source"].(map[string]interface{})
+	assert.Equal(t, "ghcr.io/jenkins-x/charts", source["repoURL"])
+	assert.Equal(t, "0.1.20", source["targetRevision"])
+	assert.Contains(t, source["helm"].(map[string]interface{})["values"], "password: vault:mycluster/nexus:password")
+	assert.Equal(t, "jx-staging", appSpec["destination"].(map[string]interface{})["namespace"])
+
+	o.Format = "kustomize"
+	assert.Error(t, o.Run())
+}
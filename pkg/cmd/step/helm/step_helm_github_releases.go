@@ -0,0 +1,99 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	gitHubRepositoryPrefix = "github.com/"
+
+	// gitHubTokenEnvVar the environment variable used for the GitHub API token if --github-token is not specified
+	gitHubTokenEnvVar = "GH_TOKEN"
+)
+
+// parseGitHubRepository returns the owner and name of a chart repository of the form 'github.com/owner/repo'
+// (optionally with a URL scheme) which publishes its charts as GitHub release assets
+func parseGitHubRepository(repository string) (string, string, bool) {
+	text := repository
+	idx := strings.Index(text, "://")
+	if idx > 0 {
+		text = text[idx+3:]
+	}
+	if !strings.HasPrefix(text, gitHubRepositoryPrefix) {
+		return "", "", false
+	}
+	text = strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(text, gitHubRepositoryPrefix), "/"), ".git")
+	paths := strings.Split(text, "/")
+	if len(paths) != 2 || paths[0] == "" || paths[1] == "" {
+		return "", "", false
+	}
+	return paths[0], paths[1], true
+}
+
+// getGitHubReleasesProvider lazily creates the git provider used to query the GitHub Releases API
+func (o *StepHelmOptions) getGitHubReleasesProvider() (gits.GitProvider, error) {
+	defer o.lockCaches()()
+	if o.gitHubReleasesProvider == nil {
+		token := o.GitHubToken
+		if token == "" {
+			token = os.Getenv(gitHubTokenEnvVar)
+		}
+		server := &auth.AuthServer{
+			URL:  o.GitHubURL,
+			Kind: gits.KindGitHub,
+		}
+		var err error
+		if token == "" {
+			o.gitHubReleasesProvider, err = gits.NewAnonymousGitHubProvider(server, nil)
+		} else {
+			o.gitHubReleasesProvider, err = gits.NewGitHubProvider(server, &auth.UserAuth{ApiToken: token}, nil)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the GitHub provider")
+		}
+	}
+	return o.gitHubReleasesProvider, nil
+}
+
+// resolveGitHubReleaseVersion returns the highest stable version released in the GitHub repository for the chart.
+// Release tags of the form '1.2.3', 'v1.2.3' or '<chart>-1.2.3' are supported
+func (o *StepHelmOptions) resolveGitHubReleaseVersion(dep *helm.Dependency, owner string, repo string) (string, error) {
+	provider, err := o.getGitHubReleasesProvider()
+	if err != nil {
+		return "", err
+	}
+	releases, err := provider.ListReleases(owner, repo)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the releases of github.com/%s/%s", owner, repo)
+	}
+	answer := ""
+	var best semver.Version
+	for _, release := range releases {
+		if release.PreRelease {
+			continue
+		}
+		text := strings.TrimPrefix(release.TagName, dep.Name+"-")
+		v, err := semver.ParseTolerant(text)
+		if err != nil || len(v.Pre) > 0 {
+			continue
+		}
+		if answer == "" || v.GT(best) {
+			best = v
+			answer = v.String()
+		}
+	}
+	if answer == "" {
+		return "", fmt.Errorf("no stable release of chart %s found in github.com/%s/%s", dep.Name, owner, repo)
+	}
+	log.Logger().Debugf("resolved version %s of chart %s from the releases of github.com/%s/%s", answer, dep.Name, owner, repo)
+	return answer, nil
+}
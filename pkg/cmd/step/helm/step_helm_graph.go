@@ -0,0 +1,125 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/helm/pkg/chartutil"
+)
+
+// DependencyGraph a graph of charts and their dependencies which can be rendered as a Graphviz DOT file
+type DependencyGraph struct {
+	nodes  []string
+	labels map[string]string
+	edges  []string
+}
+
+// NewDependencyGraph creates a new empty graph
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		labels: map[string]string{},
+	}
+}
+
+// AddNode adds a node with the given label unless the node already exists
+func (g *DependencyGraph) AddNode(id string, label string) {
+	if _, ok := g.labels[id]; ok {
+		return
+	}
+	g.nodes = append(g.nodes, id)
+	g.labels[id] = label
+}
+
+// AddEdge adds an edge from the parent node to a child node, adding the child node with the given label if required.
+// The edge is labelled with the optional edge label such as the alias of the dependency
+func (g *DependencyGraph) AddEdge(parent string, child string, label string, edgeLabel string) {
+	g.AddNode(child, label)
+	edge := fmt.Sprintf("%q -> %q", parent, child)
+	if edgeLabel != "" {
+		edge += fmt.Sprintf(" [label=%q]", edgeLabel)
+	}
+	g.edges = append(g.edges, edge)
+}
+
+// ToDOT renders the graph in the Graphviz DOT format
+func (g *DependencyGraph) ToDOT() string {
+	var buffer strings.Builder
+	buffer.WriteString("digraph dependencies {\n")
+	for _, id := range g.nodes {
+		buffer.WriteString(fmt.Sprintf("  %q [label=%q];\n", id, g.labels[id]))
+	}
+	for _, edge := range g.edges {
+		buffer.WriteString(fmt.Sprintf("  %s;\n", edge))
+	}
+	buffer.WriteString("}\n")
+	return buffer.String()
+}
+
+// writeDependencyGraph writes a DOT file of the charts in the directory and their resolved dependencies using the
+// given requirements files
+func (o *StepHelmOptions) writeDependencyGraph(dir string, fileNames []string) error {
+	// lets add the parent charts before their sub charts
+	sorted := append([]string{}, fileNames...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i], string(filepath.Separator)) < strings.Count(sorted[j], string(filepath.Separator))
+	})
+	graph := NewDependencyGraph()
+	for _, fileName := range sorted {
+		chartDir := filepath.Dir(fileName)
+		id, err := filepath.Rel(dir, chartDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the relative path of %s", chartDir)
+		}
+		id = filepath.ToSlash(id)
+		label, err := chartLabel(chartDir)
+		if err != nil {
+			return err
+		}
+		graph.AddNode(id, label)
+
+		resolvedFile := fileName
+		if o.ResolvedOutput != "" {
+			resolvedFile = filepath.Join(chartDir, o.ResolvedOutput)
+		}
+		req, err := helm.LoadRequirementsFile(resolvedFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load %s", resolvedFile)
+		}
+		for _, dep := range req.Dependencies {
+			// the sub charts are vendored by chart name rather than alias so lets use the same id as their directory
+			child := "charts/" + dep.Name
+			if id != "." {
+				child = id + "/" + child
+			}
+			graph.AddEdge(id, child, dep.Name+"\n"+dep.Version, dep.Alias)
+		}
+	}
+	err := ioutil.WriteFile(o.GraphFile, []byte(graph.ToDOT()), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.GraphFile)
+	}
+	return nil
+}
+
+// chartLabel returns the graph label of the chart in the given directory using its 'Chart.yaml' if it exists
+func chartLabel(chartDir string) (string, error) {
+	fileName := filepath.Join(chartDir, helm.ChartFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return filepath.Base(chartDir), nil
+	}
+	metadata, err := chartutil.LoadChartfile(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load %s", fileName)
+	}
+	return metadata.GetName() + "\n" + metadata.GetVersion(), nil
+}
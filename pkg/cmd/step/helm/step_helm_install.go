@@ -11,8 +11,10 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -62,6 +64,7 @@ func NewCmdStepHelmInstall(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	options.addVerifyChartsFlags(cmd)
 
 	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the release to install")
 	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version to install. Defaults to the latest")
@@ -74,6 +77,9 @@ func NewCmdStepHelmInstall(commonOpts *opts.CommonOptions) *cobra.Command {
 }
 
 func (o *StepHelmInstallOptions) Run() error {
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
 	args := o.Args
 	if len(args) == 0 {
 		return fmt.Errorf("Missing chart argument")
@@ -97,7 +103,15 @@ func (o *StepHelmInstallOptions) Run() error {
 		version = ""
 	}
 
-	SetValues, setStrings := o.getChartValues(ns)
+	// lets only fail if the requirements exist but cannot be loaded as installing does not need them
+	requirements, requirementsFileName, err := config.LoadRequirementsConfig(o.Dir)
+	if err != nil {
+		if requirementsFileName != "" {
+			return errors.Wrapf(err, "failed to load %s", requirementsFileName)
+		}
+		requirements = nil
+	}
+	SetValues, setStrings := o.getChartValues(ns, requirements)
 
 	helmOptions := helm.InstallChartOptions{
 		Chart:       chart,
@@ -108,6 +122,12 @@ func (o *StepHelmInstallOptions) Run() error {
 		SetStrings:  append(setStrings, o.ValueStrings...),
 		ValueFiles:  o.ValuesFiles,
 	}
+	if o.verifyChartsEnabled(requirements) {
+		err = o.chartVerifier().VerifyChart(chart, version, helmOptions.Repository, helmOptions.Username, helmOptions.Password)
+		if err != nil {
+			return err
+		}
+	}
 	err = o.InstallChartWithOptions(helmOptions)
 	if err != nil {
 		return err
@@ -0,0 +1,79 @@
+package helm
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultKustomizeDir the directory of a chart containing the kustomization used to post render its manifests
+	defaultKustomizeDir = "kustomize"
+)
+
+// addKustomizeFlag adds the flag of the kustomization used to post render the manifests of the chart
+func (o *StepHelmOptions) addKustomizeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.KustomizeDir, "kustomize-dir", "", "", "The directory, relative to the chart, of the kustomization used to post render the manifests of the chart so that labels, tolerations or resource limits can be patched without forking the chart. The rendered manifests are added to the kustomization as the '"+kustomize.RenderedManifestsFileName+"' resource. Defaults to the '"+defaultKustomizeDir+"' directory of the chart if it contains a '"+kustomize.KustomizationFileName+"' file")
+}
+
+// findKustomizeDir returns the directory of the kustomization used to post render the chart in the given directory or
+// an empty string if the chart is not post rendered
+func (o *StepHelmOptions) findKustomizeDir(chartDir string) (string, error) {
+	dir := o.KustomizeDir
+	if dir == "" {
+		dir = defaultKustomizeDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(chartDir, dir)
+	}
+	fileName := filepath.Join(dir, kustomize.KustomizationFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if exists {
+		return dir, nil
+	}
+	if o.KustomizeDir != "" {
+		return "", fmt.Errorf("the --kustomize-dir %s does not contain a %s file", o.KustomizeDir, kustomize.KustomizationFileName)
+	}
+	return "", nil
+}
+
+// postRender runs the kustomization of the chart in the given directory over the manifests rendered into the
+// manifests directory
+func (o *StepHelmOptions) postRender(chartDir string, manifestsDir string) error {
+	dir, err := o.findKustomizeDir(chartDir)
+	if err != nil || dir == "" {
+		return err
+	}
+	log.Logger().Debugf("Post rendering the manifests of chart %s with the kustomization in %s", chartDir, dir)
+	return kustomize.PostRender(o.Kustomize(), dir, manifestsDir)
+}
+
+// configurePostRender configures the helmer to post render the manifests of the chart in the given directory with its
+// kustomization before they are applied
+func (o *StepHelmApplyOptions) configurePostRender(chartDir string) error {
+	dir, err := o.findKustomizeDir(chartDir)
+	if err != nil || dir == "" {
+		return err
+	}
+
+	helmer := helm.Unwrap(o.Helm())
+	if h, ok := helmer.(*helm.HelmTemplate); ok {
+		h.KustomizeDir = dir
+		h.Kustomizer = o.Kustomize()
+		return nil
+	}
+	if o.KustomizeDir != "" {
+		return fmt.Errorf("post rendering the chart with the --kustomize-dir %s is only supported when using helm template mode", o.KustomizeDir)
+	}
+	log.Logger().Warnf("ignoring the kustomization in %s as post rendering the chart is only supported when using helm template mode", dir)
+	return nil
+}
@@ -0,0 +1,35 @@
+// +build unit
+
+package helm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindKustomizeDir(t *testing.T) {
+	t.Parallel()
+
+	o := &StepHelmOptions{}
+	chartDir := filepath.Join("test_data", "kustomize_chart")
+	dir, err := o.findKustomizeDir(chartDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(chartDir, "kustomize"), dir, "should default to the kustomize dir of the chart")
+
+	dir, err = o.findKustomizeDir(filepath.Join("test_data", "lint"))
+	require.NoError(t, err)
+	assert.Equal(t, "", dir, "should not post render a chart without a kustomization")
+
+	o.KustomizeDir = "production"
+	_, err = o.findKustomizeDir(chartDir)
+	require.Error(t, err, "should fail if the --kustomize-dir has no kustomization")
+
+	o.KustomizeDir, err = filepath.Abs(filepath.Join(chartDir, "kustomize"))
+	require.NoError(t, err)
+	dir, err = o.findKustomizeDir(filepath.Join("test_data", "lint"))
+	require.NoError(t, err)
+	assert.Equal(t, o.KustomizeDir, dir)
+}
@@ -0,0 +1,371 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// LintRuleHelm the issues reported by 'helm lint'
+	LintRuleHelm = "helm-lint"
+	// LintRuleNamespaceTags dependencies enabled by tags which are never set by jx as they are not 'jx-ns-' tags
+	LintRuleNamespaceTags = "jx-ns-tags"
+	// LintRuleRepositoryPrefix dependencies whose chart repository has no prefix in the version stream
+	LintRuleRepositoryPrefix = "repository-prefix"
+	// LintRuleSecrets secrets committed in the values files
+	LintRuleSecrets = "secrets"
+	// LintRuleDeprecatedExpose templates using the deprecated '.Values.expose' values
+	LintRuleDeprecatedExpose = "deprecated-expose"
+
+	namespaceTagPrefix = "jx-ns-"
+)
+
+var (
+	// LintRules the rules checked by 'jx step helm lint'
+	LintRules = []string{LintRuleHelm, LintRuleNamespaceTags, LintRuleRepositoryPrefix, LintRuleSecrets, LintRuleDeprecatedExpose}
+
+	secretKeyRegex        = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|api_key|privatekey|private_key|accesskey|access_key)$`)
+	secretReferenceRegex  = regexp.MustCompile(`(?i)(existing|name|ref|file|path)`)
+	deprecatedExposeRegex = regexp.MustCompile(`\.Values\.expose\b`)
+
+	StepHelmLintLong = templates.LongDesc(`
+		Lints the helm chart in a given directory using 'helm lint' along with the following Jenkins X specific rules:
+
+		* ` + LintRuleNamespaceTags + ` - dependencies enabled by tags which are not 'jx-ns-' tags so are never enabled by jx
+		* ` + LintRuleRepositoryPrefix + ` - dependencies whose chart repository has no prefix in the version stream
+		* ` + LintRuleSecrets + ` - secrets committed in the values files rather than being stored in vault or a secrets file
+		* ` + LintRuleDeprecatedExpose + ` - templates using the deprecated '.Values.expose' values
+`)
+
+	StepHelmLintExample = templates.Examples(`
+		# lints the helm chart in the env directory
+		jx step helm lint --dir env
+
+		# lints the helm chart without running 'helm lint'
+		jx step helm lint --skip-rule helm-lint
+`)
+)
+
+// LintIssue an issue found when linting a chart
+type LintIssue struct {
+	Rule    string
+	File    string
+	Message string
+}
+
+// StepHelmLintOptions contains the command line flags
+type StepHelmLintOptions struct {
+	StepHelmOptions
+
+	SkipRules []string
+
+	// Issues the issues found by the last lint
+	Issues []LintIssue
+}
+
+// NewCmdStepHelmLint creates the `jx step helm lint` command
+func NewCmdStepHelmLint(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmLintOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   "Lints the helm chart in a given directory using 'helm lint' and Jenkins X specific rules",
+		Aliases: []string{""},
+		Long:    StepHelmLintLong,
+		Example: StepHelmLintExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addStepHelmFlags(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addVersionResolutionFlags(cmd)
+
+	cmd.Flags().StringArrayVarP(&options.SkipRules, "skip-rule", "", nil, "The lint rules to skip. Supported values: "+strings.Join(LintRules, ", "))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepHelmLintOptions) Run() error {
+	for _, rule := range o.SkipRules {
+		if util.StringArrayIndex(LintRules, rule) < 0 {
+			return util.InvalidOption("skip-rule", rule, LintRules)
+		}
+	}
+	if err := o.validateOnMissing(); err != nil {
+		return err
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
+	defer o.closeSopsValues()
+
+	dir := o.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	issues, err := o.Lint(dir)
+	if err != nil {
+		return err
+	}
+	o.Issues = issues
+	if len(issues) == 0 {
+		log.Logger().Infof("No lint issues found in the chart %s", util.ColorInfo(dir))
+		return nil
+	}
+	for _, issue := range issues {
+		log.Logger().Warnf("%s: %s: %s", util.ColorWarning(issue.Rule), issue.File, issue.Message)
+	}
+	return fmt.Errorf("found %d lint issues in the chart %s", len(issues), dir)
+}
+
+// Lint returns the issues found in the chart in the given directory by the rules which are not skipped
+func (o *StepHelmLintOptions) Lint(dir string) ([]LintIssue, error) {
+	issues := []LintIssue{}
+	if o.isRuleEnabled(LintRuleHelm) {
+		valuesFiles, err := o.discoverValuesFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		o.Helm().SetCWD(dir)
+		output, err := o.Helm().Lint(valuesFiles)
+		if err != nil {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleHelm,
+				File:    dir,
+				Message: strings.TrimSpace(output + " " + err.Error()),
+			})
+		}
+	}
+
+	requirementsFile := filepath.Join(dir, helm.RequirementsFileName)
+	exists, err := util.FileExists(requirementsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", requirementsFile)
+	}
+	if exists {
+		req, err := helm.LoadRequirementsFile(requirementsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s", requirementsFile)
+		}
+		if o.isRuleEnabled(LintRuleNamespaceTags) {
+			answer, err := lintNamespaceTags(dir, requirementsFile, req)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, answer...)
+		}
+		if o.isRuleEnabled(LintRuleRepositoryPrefix) {
+			answer, err := o.lintRepositoryPrefixes(dir, requirementsFile, req)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, answer...)
+		}
+	}
+
+	if o.isRuleEnabled(LintRuleSecrets) {
+		valuesFiles, err := o.findValuesFiles(dir, []string{helm.ValuesFileName, "myvalues.yaml"})
+		if err != nil {
+			return nil, err
+		}
+		for _, valuesFile := range valuesFiles {
+			if filepath.Base(valuesFile) == helm.SecretsFileName || helm.IsSopsFile(valuesFile) {
+				continue
+			}
+			values, err := helm.LoadValuesFile(valuesFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to load values file %s", valuesFile)
+			}
+			issues = append(issues, lintSecrets(valuesFile, "", values)...)
+		}
+	}
+
+	if o.isRuleEnabled(LintRuleDeprecatedExpose) {
+		answer, err := lintDeprecatedExpose(filepath.Join(dir, "templates"))
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, answer...)
+	}
+	return issues, nil
+}
+
+func (o *StepHelmLintOptions) isRuleEnabled(rule string) bool {
+	return util.StringArrayIndex(o.SkipRules, rule) < 0
+}
+
+// lintNamespaceTags returns the dependencies which are enabled by tags that are not 'jx-ns-' tags set by jx when
+// applying the chart nor set in the values.yaml of the chart so the dependency can never be installed
+func lintNamespaceTags(dir string, requirementsFile string, req *helm.Requirements) ([]LintIssue, error) {
+	valuesFile := filepath.Join(dir, helm.ValuesFileName)
+	values := map[string]interface{}{}
+	exists, err := util.FileExists(valuesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", valuesFile)
+	}
+	if exists {
+		values, err = helm.LoadValuesFile(valuesFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load values file %s", valuesFile)
+		}
+	}
+	tags, _ := values["tags"].(map[string]interface{})
+
+	issues := []LintIssue{}
+	for _, dep := range req.Dependencies {
+		if len(dep.Tags) == 0 {
+			continue
+		}
+		enabled := false
+		for _, tag := range dep.Tags {
+			if strings.HasPrefix(tag, namespaceTagPrefix) || tags[tag] != nil {
+				enabled = true
+				break
+			}
+		}
+		if !enabled {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleNamespaceTags,
+				File:    requirementsFile,
+				Message: fmt.Sprintf("dependency %s is enabled by the tags %s which are never set. Use a '%s<namespace>' tag to install it into a namespace", dep.Name, strings.Join(dep.Tags, ", "), namespaceTagPrefix),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// lintRepositoryPrefixes returns the dependencies whose chart repository has no prefix in the version stream so that
+// their versions cannot be resolved from the version stream
+func (o *StepHelmLintOptions) lintRepositoryPrefixes(dir string, requirementsFile string, req *helm.Requirements) ([]LintIssue, error) {
+	requirements, requirementsFileName, err := config.LoadRequirementsConfig(dir)
+	if err != nil {
+		if requirementsFileName != "" {
+			return nil, errors.Wrapf(err, "failed to load %s", requirementsFileName)
+		}
+		requirements = config.NewRequirementsConfig()
+	}
+	resolver, err := o.getOrCreateVersionResolver(requirements)
+	if err != nil {
+		return nil, err
+	}
+	prefixes, err := resolver.GetRepositoryPrefixes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load repository prefixes")
+	}
+
+	issues := []LintIssue{}
+	for _, dep := range req.Dependencies {
+		repo := dep.Repository
+		if repo == "" || strings.HasPrefix(repo, "file://") || strings.HasPrefix(repo, "@") || strings.HasPrefix(repo, "alias:") {
+			continue
+		}
+		if prefixes.PrefixForURL(repo) == "" {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleRepositoryPrefix,
+				File:    requirementsFile,
+				Message: fmt.Sprintf("the chart repository %s of dependency %s has no prefix in the 'charts/repositories.yml' file of the version stream", repo, dep.Name),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// lintSecrets returns the values whose keys look like secrets which are not vault or local secret URIs or templates
+func lintSecrets(valuesFile string, path string, values map[string]interface{}) []LintIssue {
+	issues := []LintIssue{}
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		switch value := values[key].(type) {
+		case map[string]interface{}:
+			issues = append(issues, lintSecrets(valuesFile, childPath, value)...)
+		case string:
+			if value == "" || !secretKeyRegex.MatchString(key) || secretReferenceRegex.MatchString(key) {
+				continue
+			}
+			if secreturl.IsURI(value) || strings.Contains(value, "{{") {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleSecrets,
+				File:    valuesFile,
+				Message: fmt.Sprintf("the value %s looks like a secret. Please store it in vault or a '%s' file", childPath, helm.SecretsFileName),
+			})
+		}
+	}
+	return issues
+}
+
+// lintDeprecatedExpose returns the templates which use the deprecated '.Values.expose' values
+func lintDeprecatedExpose(templatesDir string) ([]LintIssue, error) {
+	exists, err := util.DirExists(templatesDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if dir exists %s", templatesDir)
+	}
+	if !exists {
+		return nil, nil
+	}
+	issues := []LintIssue{}
+	err = filepath.Walk(templatesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load template %s", path)
+		}
+		lines := []string{}
+		for i, line := range strings.Split(string(data), "\n") {
+			if deprecatedExposeRegex.MatchString(line) {
+				lines = append(lines, fmt.Sprintf("%d", i+1))
+			}
+		}
+		if len(lines) > 0 {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleDeprecatedExpose,
+				File:    path,
+				Message: fmt.Sprintf("the deprecated '.Values.expose' values are used on lines %s", strings.Join(lines, ", ")),
+			})
+		}
+		return nil
+	})
+	return issues, err
+}
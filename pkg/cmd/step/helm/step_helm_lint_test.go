@@ -0,0 +1,86 @@
+// +build unit
+
+package helm
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepHelmLint(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.Version(false)).ThenReturn("3.8.0", nil)
+	pegomock.When(helmer.Lint(pegomock.AnyStringSlice())).ThenReturn("[ERROR] Chart.yaml: icon is required", errors.New("1 chart(s) failed"))
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetHelm(helmer)
+	resolver, _ := createTestResolver(t)
+
+	o := &StepHelmLintOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			versionResolver: resolver,
+		},
+	}
+
+	dir := filepath.Join("test_data", "lint")
+	requirementsFile := filepath.Join(dir, "requirements.yaml")
+	issues, err := o.Lint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []LintIssue{
+		{
+			Rule:    LintRuleHelm,
+			File:    dir,
+			Message: "[ERROR] Chart.yaml: icon is required 1 chart(s) failed",
+		},
+		{
+			Rule:    LintRuleNamespaceTags,
+			File:    requirementsFile,
+			Message: "dependency dashboard is enabled by the tags dashboard which are never set. Use a 'jx-ns-<namespace>' tag to install it into a namespace",
+		},
+		{
+			Rule:    LintRuleRepositoryPrefix,
+			File:    requirementsFile,
+			Message: "the chart repository https://charts.example.com of dependency dashboard has no prefix in the 'charts/repositories.yml' file of the version stream",
+		},
+		{
+			Rule:    LintRuleRepositoryPrefix,
+			File:    requirementsFile,
+			Message: "the chart repository https://charts.example.com of dependency nginx has no prefix in the 'charts/repositories.yml' file of the version stream",
+		},
+		{
+			Rule:    LintRuleSecrets,
+			File:    filepath.Join(dir, "values.yaml"),
+			Message: "the value db.password looks like a secret. Please store it in vault or a 'secrets.yaml' file",
+		},
+		{
+			Rule:    LintRuleDeprecatedExpose,
+			File:    filepath.Join(dir, "templates", "service.yaml"),
+			Message: "the deprecated '.Values.expose' values are used on lines 6",
+		},
+	}, issues)
+
+	o.SkipRules = []string{LintRuleHelm, LintRuleRepositoryPrefix, LintRuleSecrets}
+	issues, err = o.Lint(dir)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, LintRuleNamespaceTags, issues[0].Rule)
+	assert.Equal(t, LintRuleDeprecatedExpose, issues[1].Rule)
+
+	o.SkipRules = []string{"does-not-exist"}
+	err = o.Run()
+	require.Error(t, err, "should fail on an unknown lint rule")
+}
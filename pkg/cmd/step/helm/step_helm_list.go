@@ -33,6 +33,9 @@ var (
 		# list all the helm releases in the current namespace
 		jx step helm list
 
+		# list the helm releases in the jx namespace as JSON
+		jx step helm list -n jx --output json
+
 `)
 )
 
@@ -58,12 +61,19 @@ func NewCmdStepHelmList(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	options.addOutputFlag(cmd)
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "the namespace to look for the helm releases. Defaults to the current namespace")
 
 	return cmd
 }
 
 func (o *StepHelmListOptions) Run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
 	h := o.Helm()
 	if h == nil {
 		return fmt.Errorf("No Helmer created!")
@@ -72,6 +82,9 @@ func (o *StepHelmListOptions) Run() error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if o.Output != "" {
+		return o.renderOutput(toReleaseListOutput(releases, sortedKeys))
+	}
 	output, err := helm.RenderReleasesAsTable(releases, sortedKeys)
 	if err != nil {
 		return errors.WithStack(err)
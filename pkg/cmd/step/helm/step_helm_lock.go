@@ -0,0 +1,203 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DependencyLockFileName the name of the file in the chart directory locking the digests of the dependency charts.
+	// It is separate from the 'requirements.lock' as helm regenerates that file whenever it updates the dependencies
+	DependencyLockFileName = "dependencies.lock"
+
+	digestPrefix = "sha256:"
+)
+
+// DependencyLock the resolved versions and digests of the dependency charts of a chart written by
+// 'jx step helm build' so that 'jx step helm apply' only applies the same dependency charts
+type DependencyLock struct {
+	// RequirementsDigest the digest of the 'requirements.yaml' the dependencies were resolved from
+	RequirementsDigest string `json:"requirementsDigest"`
+	// Dependencies the resolved dependency charts sorted by name
+	Dependencies []LockedDependency `json:"dependencies"`
+}
+
+// LockedDependency a dependency chart with its resolved version and the digest of its chart archive
+type LockedDependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+}
+
+// LoadDependencyLock loads the dependency lock in the given file returning nil if the file does not exist
+func LoadDependencyLock(fileName string) (*DependencyLock, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the dependency lock %s", fileName)
+	}
+	lock := &DependencyLock{}
+	err = yaml.Unmarshal(data, lock)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the dependency lock %s", fileName)
+	}
+	return lock, nil
+}
+
+// SaveDependencyLock writes the dependency lock to the given file
+func SaveDependencyLock(lock *DependencyLock, fileName string) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the dependency lock")
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the dependency lock %s", fileName)
+	}
+	return nil
+}
+
+// CreateDependencyLock creates the dependency lock of the chart in the given directory from the chart archives in
+// its 'charts' directory so it should be called after the dependencies have been built
+func CreateDependencyLock(dir string) (*DependencyLock, error) {
+	lock := &DependencyLock{
+		Dependencies: []LockedDependency{},
+	}
+	requirementsFile := filepath.Join(dir, helm.RequirementsFileName)
+	exists, err := util.FileExists(requirementsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", requirementsFile)
+	}
+	if exists {
+		lock.RequirementsDigest, err = fileDigest(requirementsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	archives, err := filepath.Glob(filepath.Join(dir, "charts", "*.tgz"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding chart dependencies in %s", filepath.Join(dir, "charts"))
+	}
+	for _, archive := range archives {
+		metadata, err := helm.LoadChartArchive(archive)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := fileDigest(archive)
+		if err != nil {
+			return nil, err
+		}
+		lock.Dependencies = append(lock.Dependencies, LockedDependency{
+			Name:    metadata.Name,
+			Version: metadata.Version,
+			Digest:  digest,
+		})
+	}
+	sort.Slice(lock.Dependencies, func(i, j int) bool {
+		return lock.Dependencies[i].Name < lock.Dependencies[j].Name
+	})
+	return lock, nil
+}
+
+// Differences returns a description of each difference between the lock and the actual lock or an empty slice if
+// the lock is up to date
+func (l *DependencyLock) Differences(actual *DependencyLock) []string {
+	differences := []string{}
+	if l.RequirementsDigest != actual.RequirementsDigest {
+		differences = append(differences, fmt.Sprintf("the %s has changed", helm.RequirementsFileName))
+	}
+	locked := map[string]LockedDependency{}
+	for _, dep := range l.Dependencies {
+		locked[dep.Name] = dep
+	}
+	for _, dep := range actual.Dependencies {
+		expected, ok := locked[dep.Name]
+		delete(locked, dep.Name)
+		switch {
+		case !ok:
+			differences = append(differences, fmt.Sprintf("the dependency %s %s is not locked", dep.Name, dep.Version))
+		case expected.Version != dep.Version:
+			differences = append(differences, fmt.Sprintf("the dependency %s resolved to version %s rather than the locked version %s", dep.Name, dep.Version, expected.Version))
+		case expected.Digest != dep.Digest:
+			differences = append(differences, fmt.Sprintf("the dependency %s %s has digest %s rather than the locked digest %s", dep.Name, dep.Version, dep.Digest, expected.Digest))
+		}
+	}
+	for _, dep := range l.Dependencies {
+		if _, ok := locked[dep.Name]; ok {
+			differences = append(differences, fmt.Sprintf("the locked dependency %s %s is no longer a dependency", dep.Name, dep.Version))
+		}
+	}
+	return differences
+}
+
+// fileDigest returns the sha256 digest of the given file
+func fileDigest(fileName string) (string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	sum := sha256.Sum256(data)
+	return digestPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// writeDependencyLock writes the dependency lock of the built chart in the given directory
+func writeDependencyLock(dir string) error {
+	lock, err := CreateDependencyLock(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the dependency lock of %s", dir)
+	}
+	fileName := filepath.Join(dir, DependencyLockFileName)
+	err = SaveDependencyLock(lock, fileName)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Wrote the dependency lock %s of %d dependencies", util.ColorInfo(fileName), len(lock.Dependencies))
+	return nil
+}
+
+// verifyDependencyLock verifies the dependencies built in the given directory match the dependency lock in the source
+// directory of the chart. If the lock is stale it is updated when using --update-lock otherwise an error is returned
+func (o *StepHelmApplyOptions) verifyDependencyLock(dir string, sourceDir string) error {
+	fileName := filepath.Join(sourceDir, DependencyLockFileName)
+	lock, err := LoadDependencyLock(fileName)
+	if err != nil {
+		return err
+	}
+	if lock == nil && !o.UpdateLock {
+		return nil
+	}
+	actual, err := CreateDependencyLock(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the dependency lock of %s", dir)
+	}
+	if lock != nil {
+		differences := lock.Differences(actual)
+		if len(differences) == 0 {
+			log.Logger().Debugf("the dependencies match the dependency lock %s", fileName)
+			return nil
+		}
+		if !o.UpdateLock {
+			return fmt.Errorf("the dependency lock %s is stale as %s. Run 'jx step helm build' to update the lock or use --update-lock", fileName, strings.Join(differences, ", "))
+		}
+		log.Logger().Warnf("updating the stale dependency lock %s as %s", fileName, strings.Join(differences, ", "))
+	}
+	return SaveDependencyLock(actual, fileName)
+}
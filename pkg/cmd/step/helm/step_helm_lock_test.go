@@ -0,0 +1,100 @@
+// +build unit
+
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeChartArchive writes a chart archive of the given chart version into the charts dir of the chart
+func writeChartArchive(t *testing.T, chartDir string, name string, version string, template string) {
+	dir := filepath.Join(chartDir, "charts")
+	require.NoError(t, os.MkdirAll(dir, util.DefaultWritePermissions))
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", name, version)))
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		name + "/" + helm.ChartFileName:     fmt.Sprintf("name: %s\nversion: %s\n", name, version),
+		name + "/templates/deployment.yaml": template,
+	}
+	for fileName, text := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: fileName, Mode: 0600, Size: int64(len(text)), Typeflag: tar.TypeReg}))
+		_, err = tw.Write([]byte(text))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestDependencyLock(t *testing.T) {
+	t.Parallel()
+
+	chartDir, err := ioutil.TempDir("", "test-helm-lock-")
+	require.NoError(t, err)
+	defer os.RemoveAll(chartDir)
+	requirements := "dependencies:\n- name: tekton\n  repository: http://chartmuseum.jenkins-x.io\n  version: 0.0.56\n- name: nginx\n  repository: https://charts.example.com\n  version: '>=1.0.0'\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(chartDir, helm.RequirementsFileName), []byte(requirements), util.DefaultFileWritePermissions))
+	writeChartArchive(t, chartDir, "tekton", "0.0.56", "kind: Deployment\n")
+	writeChartArchive(t, chartDir, "nginx", "1.2.0", "kind: Deployment\n")
+
+	err = writeDependencyLock(chartDir)
+	require.NoError(t, err)
+	lock, err := LoadDependencyLock(filepath.Join(chartDir, DependencyLockFileName))
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	require.Len(t, lock.Dependencies, 2)
+	assert.Equal(t, "nginx", lock.Dependencies[0].Name, "the dependencies should be sorted by name")
+	assert.Equal(t, "1.2.0", lock.Dependencies[0].Version)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", lock.Dependencies[0].Digest)
+	assert.Equal(t, "tekton", lock.Dependencies[1].Name)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", lock.RequirementsDigest)
+
+	actual, err := CreateDependencyLock(chartDir)
+	require.NoError(t, err)
+	assert.Empty(t, lock.Differences(actual), "the lock should be up to date")
+
+	buildDir, err := ioutil.TempDir("", "test-helm-lock-build-")
+	require.NoError(t, err)
+	defer os.RemoveAll(buildDir)
+	require.NoError(t, util.CopyFile(filepath.Join(chartDir, helm.RequirementsFileName), filepath.Join(buildDir, helm.RequirementsFileName)))
+	writeChartArchive(t, buildDir, "tekton", "0.0.56", "kind: Deployment\n")
+	writeChartArchive(t, buildDir, "nginx", "1.3.0", "kind: Deployment\n")
+
+	o := &StepHelmApplyOptions{}
+	err = o.verifyDependencyLock(buildDir, chartDir)
+	require.Error(t, err, "should fail if a floating version resolves to a different chart")
+	assert.Contains(t, err.Error(), "the dependency nginx resolved to version 1.3.0 rather than the locked version 1.2.0")
+
+	actual, err = CreateDependencyLock(buildDir)
+	require.NoError(t, err)
+	actual.Dependencies[1].Digest = "sha256:1234"
+	actual.Dependencies = actual.Dependencies[1:]
+	assert.Equal(t, []string{
+		"the dependency tekton 0.0.56 has digest sha256:1234 rather than the locked digest " + lock.Dependencies[1].Digest,
+		"the locked dependency nginx 1.2.0 is no longer a dependency",
+	}, lock.Differences(actual))
+
+	o.UpdateLock = true
+	err = o.verifyDependencyLock(buildDir, chartDir)
+	require.NoError(t, err)
+	updated, err := LoadDependencyLock(filepath.Join(chartDir, DependencyLockFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", updated.Dependencies[0].Version, "should have updated the stale lock")
+
+	o.UpdateLock = false
+	err = o.verifyDependencyLock(buildDir, buildDir)
+	assert.NoError(t, err, "should not verify a chart without a dependency lock")
+}
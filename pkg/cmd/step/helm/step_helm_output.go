@@ -0,0 +1,112 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// OutputFormatJSON outputs the results of the step as JSON
+	OutputFormatJSON = "json"
+	// OutputFormatYAML outputs the results of the step as YAML
+	OutputFormatYAML = "yaml"
+)
+
+// OutputFormats the supported --output formats
+var OutputFormats = []string{OutputFormatJSON, OutputFormatYAML}
+
+// ReleaseOutput the structured output of a helm release
+type ReleaseOutput struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace,omitempty"`
+	Chart        string `json:"chart,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+	AppVersion   string `json:"appVersion,omitempty"`
+	Revision     string `json:"revision,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Updated      string `json:"updated,omitempty"`
+}
+
+// ReleaseListOutput the structured output of 'jx step helm list'
+type ReleaseListOutput struct {
+	Releases []ReleaseOutput `json:"releases"`
+}
+
+// ChartVersionOutput the structured output of 'jx step helm version'
+type ChartVersionOutput struct {
+	ChartFile string `json:"chartFile"`
+	Version   string `json:"version"`
+}
+
+// ChartReleaseOutput the structured output of 'jx step helm release'
+type ChartReleaseOutput struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// addOutputFlag adds the flag to output the results of the step in a machine readable format
+func (o *StepHelmOptions) addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Output, "output", "", "", "Outputs the results in a machine readable format rather than as log messages. Supported values: json, yaml")
+}
+
+// validateOutput returns an error if the --output format is not supported
+func (o *StepHelmOptions) validateOutput() error {
+	if o.Output != "" && util.StringArrayIndex(OutputFormats, o.Output) < 0 {
+		return util.InvalidOption("output", o.Output, OutputFormats)
+	}
+	return nil
+}
+
+// renderOutput writes the given results to the console in the --output format
+func (o *StepHelmOptions) renderOutput(value interface{}) error {
+	return renderOutput(o.Out, o.Output, value)
+}
+
+// renderOutput writes the given results to the writer in the given format
+func renderOutput(out io.Writer, format string, value interface{}) error {
+	var data []byte
+	var err error
+	switch format {
+	case OutputFormatJSON:
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	case OutputFormatYAML:
+		data, err = yaml.Marshal(value)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the output as %s", format)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// toReleaseListOutput converts the helm releases to the structured output in the order of the sorted keys
+func toReleaseListOutput(releases map[string]helm.ReleaseSummary, sortedKeys []string) *ReleaseListOutput {
+	answer := &ReleaseListOutput{
+		Releases: []ReleaseOutput{},
+	}
+	for _, key := range sortedKeys {
+		release := releases[key]
+		answer.Releases = append(answer.Releases, ReleaseOutput{
+			Name:         release.ReleaseName,
+			Namespace:    release.Namespace,
+			Chart:        release.Chart,
+			ChartVersion: release.ChartVersion,
+			AppVersion:   release.AppVersion,
+			Revision:     release.Revision,
+			Status:       release.Status,
+			Updated:      release.Updated,
+		})
+	}
+	return answer
+}
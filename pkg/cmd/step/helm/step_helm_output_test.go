@@ -0,0 +1,86 @@
+// +build unit
+
+package helm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderReleaseListOutput(t *testing.T) {
+	t.Parallel()
+
+	releases := map[string]helm.ReleaseSummary{
+		"jxing": {
+			ReleaseName:  "jxing",
+			Namespace:    "kube-system",
+			Chart:        "nginx-ingress",
+			ChartVersion: "1.3.1",
+			AppVersion:   "0.25.1",
+			Revision:     "2",
+			Status:       "DEPLOYED",
+		},
+		"jenkins-x": {
+			ReleaseName:  "jenkins-x",
+			Namespace:    "jx",
+			Chart:        "jenkins-x-platform",
+			ChartVersion: "2.0.1200",
+			Revision:     "7",
+			Status:       "FAILED",
+		},
+	}
+	output := toReleaseListOutput(releases, []string{"jenkins-x", "jxing"})
+
+	var buf bytes.Buffer
+	err := renderOutput(&buf, OutputFormatJSON, output)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "releases": [
+    {
+      "name": "jenkins-x",
+      "namespace": "jx",
+      "chart": "jenkins-x-platform",
+      "chartVersion": "2.0.1200",
+      "revision": "7",
+      "status": "FAILED"
+    },
+    {
+      "name": "jxing",
+      "namespace": "kube-system",
+      "chart": "nginx-ingress",
+      "chartVersion": "1.3.1",
+      "appVersion": "0.25.1",
+      "revision": "2",
+      "status": "DEPLOYED"
+    }
+  ]
+}
+`, buf.String())
+
+	buf.Reset()
+	err = renderOutput(&buf, OutputFormatYAML, &ChartReleaseOutput{
+		Name:    "myapp",
+		Version: "1.0.1",
+		URL:     "http://chartmuseum.jenkins-x.io/charts/myapp-1.0.1.tgz",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "name: myapp\nurl: http://chartmuseum.jenkins-x.io/charts/myapp-1.0.1.tgz\nversion: 1.0.1\n", buf.String())
+
+	err = renderOutput(&buf, "xml", output)
+	assert.Error(t, err)
+}
+
+func TestValidateOutput(t *testing.T) {
+	t.Parallel()
+
+	o := &StepHelmOptions{}
+	assert.NoError(t, o.validateOutput())
+	o.Output = OutputFormatYAML
+	assert.NoError(t, o.validateOutput())
+	o.Output = "jsn"
+	assert.Error(t, o.validateOutput())
+}
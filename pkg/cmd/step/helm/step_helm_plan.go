@@ -0,0 +1,269 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPlanReleaseName the default release name used to render the chart of a plan
+	DefaultPlanReleaseName = "jx"
+
+	planHashPrefix = "sha256:"
+)
+
+// HelmPlan the rendered manifests, merged values and resolved dependency versions of a chart generated by
+// 'jx step helm build --plan' so that 'jx step helm apply --from-plan' applies exactly what was reviewed
+type HelmPlan struct {
+	Chart        string                 `json:"chart"`
+	ReleaseName  string                 `json:"releaseName"`
+	Namespace    string                 `json:"namespace"`
+	Dependencies []PlanDependency       `json:"dependencies"`
+	Values       map[string]interface{} `json:"values"`
+	Manifests    []PlanManifest         `json:"manifests"`
+
+	// Hash the content addressed hash of the rest of the plan
+	Hash string `json:"hash"`
+}
+
+// PlanDependency a dependency of the chart with its resolved version
+type PlanDependency struct {
+	Name       string `json:"name"`
+	Alias      string `json:"alias,omitempty"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+}
+
+// PlanManifest a manifest rendered from the chart
+type PlanManifest struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ComputeHash returns the content addressed hash of the plan ignoring any current hash. Maps are marshalled with
+// sorted keys so the hash is deterministic
+func (p *HelmPlan) ComputeHash() (string, error) {
+	unhashed := *p
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the plan")
+	}
+	sum := sha256.Sum256(data)
+	return planHashPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// Verify returns an error if the content of the plan does not match its hash or the given expected hash
+func (p *HelmPlan) Verify(expectedHash string) error {
+	hash, err := p.ComputeHash()
+	if err != nil {
+		return err
+	}
+	if hash != p.Hash {
+		return fmt.Errorf("the plan has been modified as its content has hash %s rather than %s", hash, p.Hash)
+	}
+	if expectedHash != "" && expectedHash != p.Hash {
+		return fmt.Errorf("the plan has hash %s rather than the expected hash %s", p.Hash, expectedHash)
+	}
+	return nil
+}
+
+// SavePlan computes the hash of the plan and writes it to the given file
+func SavePlan(plan *HelmPlan, fileName string) error {
+	hash, err := plan.ComputeHash()
+	if err != nil {
+		return err
+	}
+	plan.Hash = hash
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the plan")
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the plan %s", fileName)
+	}
+	return nil
+}
+
+// LoadPlan loads the plan in the given file
+func LoadPlan(fileName string) (*HelmPlan, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the plan %s", fileName)
+	}
+	plan := &HelmPlan{}
+	err = json.Unmarshal(data, plan)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the plan %s", fileName)
+	}
+	return plan, nil
+}
+
+// createPlan renders the built chart in the given directory with the values files returning the plan of the release
+func (o *StepHelmOptions) createPlan(dir string, releaseName string, ns string, valuesFiles []string) (*HelmPlan, error) {
+	chartName, _, err := helm.LoadChartNameAndVersion(filepath.Join(dir, helm.ChartFileName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the chart in %s", dir)
+	}
+	plan := &HelmPlan{
+		Chart:        chartName,
+		ReleaseName:  releaseName,
+		Namespace:    ns,
+		Dependencies: []PlanDependency{},
+		Manifests:    []PlanManifest{},
+	}
+
+	req, err := helm.LoadRequirementsFile(filepath.Join(dir, helm.RequirementsFileName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the requirements of %s", dir)
+	}
+	for _, dep := range req.Dependencies {
+		plan.Dependencies = append(plan.Dependencies, PlanDependency{
+			Name:       dep.Name,
+			Alias:      dep.Alias,
+			Repository: dep.Repository,
+			Version:    dep.Version,
+		})
+	}
+
+	mergeFiles := []string{}
+	chartValuesFile := filepath.Join(dir, helm.ValuesFileName)
+	exists, err := util.FileExists(chartValuesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", chartValuesFile)
+	}
+	if exists {
+		mergeFiles = append(mergeFiles, chartValuesFile)
+	}
+	for _, valuesFile := range valuesFiles {
+		if util.StringArrayIndex(mergeFiles, valuesFile) < 0 {
+			mergeFiles = append(mergeFiles, valuesFile)
+		}
+	}
+	plan.Values, err = helm.MergeValuesFiles(mergeFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	outDir, err := ioutil.TempDir("", "jx-helm-plan-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory to render the helm chart")
+	}
+	defer os.RemoveAll(outDir)
+
+	// lets use absolute paths as helm runs in the working directory of the helmer
+	chartDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find absolute path of dir %s", dir)
+	}
+	templateValuesFiles := []string{}
+	for _, valuesFile := range valuesFiles {
+		path, err := filepath.Abs(valuesFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not find absolute path of values file %s", valuesFile)
+		}
+		templateValuesFiles = append(templateValuesFiles, path)
+	}
+	o.Helm().SetCWD(chartDir)
+	err = o.Helm().Template(chartDir, releaseName, ns, outDir, true, nil, nil, templateValuesFiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render the helm chart in %s", dir)
+	}
+	err = o.postRender(chartDir, outDir)
+	if err != nil {
+		return nil, err
+	}
+	// filepath.Walk visits the files in lexical order so the manifests are always in the same order
+	err = filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		plan.Manifests = append(plan.Manifests, PlanManifest{
+			Path:    filepath.ToSlash(rel),
+			Content: string(data),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the rendered manifests in %s", outDir)
+	}
+	return plan, nil
+}
+
+// applyPlan applies the manifests of the --from-plan after verifying the plan has not been modified
+func (o *StepHelmApplyOptions) applyPlan() error {
+	plan, err := LoadPlan(o.FromPlan)
+	if err != nil {
+		return err
+	}
+	err = plan.Verify(o.PlanHash)
+	if err != nil {
+		return errors.Wrapf(err, "refusing to apply the plan %s", o.FromPlan)
+	}
+	ns := plan.Namespace
+	if o.Namespace != "" && o.Namespace != ns {
+		return fmt.Errorf("the plan %s was rendered for namespace %s rather than %s", o.FromPlan, ns, o.Namespace)
+	}
+
+	dir, err := ioutil.TempDir("", "jx-helm-apply-plan-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory to apply the plan")
+	}
+	defer os.RemoveAll(dir)
+	for _, manifest := range plan.Manifests {
+		fileName := filepath.Join(dir, filepath.FromSlash(manifest.Path))
+		if !strings.HasPrefix(fileName, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("the manifest %s of the plan %s is outside of the plan", manifest.Path, o.FromPlan)
+		}
+		err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the directory of manifest %s", manifest.Path)
+		}
+		err = ioutil.WriteFile(fileName, []byte(manifest.Content), util.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write manifest %s", manifest.Path)
+		}
+	}
+	if len(plan.Manifests) == 0 {
+		log.Logger().Warnf("The plan %s has no manifests to apply", o.FromPlan)
+		return nil
+	}
+
+	log.Logger().Infof("Applying the plan %s of chart %s with hash %s to namespace %s", util.ColorInfo(o.FromPlan), util.ColorInfo(plan.Chart), util.ColorInfo(plan.Hash), util.ColorInfo(ns))
+	args := []string{"apply", "--recursive", "--filename", dir, "--namespace", ns}
+	if o.Wait {
+		args = append(args, "--wait")
+	}
+	_, err = o.runCommand(&util.Command{
+		Name: "kubectl",
+		Args: args,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply the plan %s", o.FromPlan)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// +build unit
+
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndApplyPlan(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.Template(pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyBool(),
+		pegomock.AnyStringSlice(), pegomock.AnyStringSlice(), pegomock.AnyStringSlice())).Then(func(params []pegomock.Param) pegomock.ReturnValues {
+		releaseName := params[1].(string)
+		ns := params[2].(string)
+		outDir := params[3].(string)
+		for _, valuesFile := range params[7].([]string) {
+			assert.True(t, filepath.IsAbs(valuesFile), "the values file %s should be absolute", valuesFile)
+		}
+		dir := filepath.Join(outDir, "lint", "templates")
+		require.NoError(t, os.MkdirAll(dir, util.DefaultWritePermissions))
+		for _, kind := range []string{"Service", "Deployment"} {
+			manifest := fmt.Sprintf("apiVersion: v1\nkind: %s\nmetadata:\n  name: %s\n  namespace: %s\n", kind, releaseName, ns)
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, kind+".yaml"), []byte(manifest), util.DefaultWritePermissions))
+		}
+		return []pegomock.ReturnValue{nil}
+	})
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetHelm(helmer)
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+	}
+
+	chartDir := filepath.Join("test_data", "lint")
+	plan, err := o.createPlan(chartDir, "jx", "jx-staging", []string{filepath.Join(chartDir, helm.ValuesFileName)})
+	require.NoError(t, err)
+	assert.Equal(t, "lint", plan.Chart)
+	require.Len(t, plan.Dependencies, 4)
+	assert.Equal(t, PlanDependency{Name: "tekton", Repository: "http://chartmuseum.jenkins-x.io", Version: "0.0.56"}, plan.Dependencies[0])
+	assert.Equal(t, true, plan.Values["tags"].(map[string]interface{})["ingress"])
+	require.Len(t, plan.Manifests, 2)
+	assert.Equal(t, "lint/templates/Deployment.yaml", plan.Manifests[0].Path, "the manifests should be sorted by path")
+	assert.Equal(t, "lint/templates/Service.yaml", plan.Manifests[1].Path)
+
+	dir, err := ioutil.TempDir("", "test-helm-plan-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	planFile := filepath.Join(dir, "helm-plan.json")
+	err = SavePlan(plan, planFile)
+	require.NoError(t, err)
+	hash := plan.Hash
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", hash)
+
+	again, err := o.createPlan(chartDir, "jx", "jx-staging", []string{filepath.Join(chartDir, helm.ValuesFileName)})
+	require.NoError(t, err)
+	againHash, err := again.ComputeHash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, againHash, "the plan should be deterministic")
+
+	loaded, err := LoadPlan(planFile)
+	require.NoError(t, err)
+	require.NoError(t, loaded.Verify(hash))
+	assert.Error(t, loaded.Verify("sha256:1234"), "should fail if the plan is not the expected plan")
+	loaded.Manifests[0].Content += "  labels:\n    tampered: true\n"
+	assert.Error(t, loaded.Verify(""), "should fail if the plan has been modified")
+
+	var applied []string
+	applyOptions := &StepHelmApplyOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				assert.Equal(t, "kubectl", cmd.Name)
+				applied = cmd.Args
+				data, err := ioutil.ReadFile(filepath.Join(cmd.Args[3], "lint", "templates", "Service.yaml"))
+				require.NoError(t, err)
+				assert.Equal(t, plan.Manifests[1].Content, string(data))
+				return "", nil
+			},
+		},
+		FromPlan: planFile,
+		PlanHash: hash,
+	}
+	err = applyOptions.Run()
+	require.NoError(t, err)
+	require.Len(t, applied, 6)
+	assert.Equal(t, []string{"apply", "--recursive", "--filename"}, applied[:3])
+	assert.Equal(t, []string{"--namespace", "jx-staging"}, applied[4:])
+
+	applyOptions.Namespace = "jx-production"
+	err = applyOptions.Run()
+	require.Error(t, err, "should fail if the plan was rendered for a different namespace")
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/blang/semver"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
@@ -26,6 +27,11 @@ import (
 // StepHelmReleaseOptions contains the command line flags
 type StepHelmReleaseOptions struct {
 	StepHelmOptions
+
+	RequireVersionBump bool
+	Registry           string
+	Insecure           bool
+	DockerConfigSecret string
 }
 
 var (
@@ -36,6 +42,15 @@ var (
 	StepHelmReleaseExample = templates.Examples(`
 		jx step helm release
 
+		# fail if the chart version has not been bumped since the last published version
+		jx step helm release --require-version-bump
+
+		# push the chart to an OCI registry using helm 3
+		jx step helm release --registry gcr.io/myproject/charts
+
+		# release the chart outputting the URL of the pushed chart as JSON
+		jx step helm release --output json
+
 `)
 )
 
@@ -61,10 +76,24 @@ func NewCmdStepHelmRelease(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	options.addValidateValuesFlag(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addOutputFlag(cmd)
+	cmd.Flags().StringVarP(&options.Registry, "registry", "", "", "The OCI registry to push the chart to using helm 3 rather than uploading it to the chart repository. e.g. 'gcr.io/myproject/charts'")
+	cmd.Flags().BoolVarP(&options.Insecure, "insecure", "", false, "Allows insecure connections to the OCI registry")
+	cmd.Flags().StringVarP(&options.DockerConfigSecret, "docker-config-secret", "", DefaultDockerConfigSecret, "The Secret in the current namespace containing the docker 'config.json' with the credentials used to login to the OCI registry")
+	cmd.Flags().BoolVarP(&options.RequireVersionBump, "require-version-bump", "", false, "Refuses to publish the chart unless the version in 'Chart.yaml' is greater than the latest version already published in the chart repository")
 	return cmd
 }
 
 func (o *StepHelmReleaseOptions) Run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
+	defer o.closeSopsValues()
 	dir := o.Dir
 	valuesFiles, err := o.discoverValuesFiles(dir)
 	if err != nil {
@@ -93,6 +122,17 @@ func (o *StepHelmReleaseOptions) Run() error {
 	if version == "" {
 		return fmt.Errorf("Could not find version in chart %s", chartFile)
 	}
+	chartRepo := o.ReleaseChartRepositoryURL()
+	if o.RequireVersionBump && o.Registry != "" {
+		return fmt.Errorf("the --require-version-bump flag is not supported when pushing to an OCI --registry")
+	}
+	if o.RequireVersionBump {
+		err = o.verifyVersionBump(chartRepo, name, version)
+		if err != nil {
+			return err
+		}
+	}
+
 	tarball := fmt.Sprintf("%s-%s.tgz", name, version)
 	exists, err := util.FileExists(tarball)
 	if err != nil {
@@ -103,7 +143,13 @@ func (o *StepHelmReleaseOptions) Run() error {
 	}
 	defer os.Remove(tarball)
 
-	chartRepo := o.ReleaseChartRepositoryURL()
+	if o.Registry != "" {
+		err = o.pushToRegistry(tarball)
+		if err != nil {
+			return err
+		}
+		return o.renderReleaseOutput(name, version, ociRegistryReference(o.Registry)+"/"+name+":"+version)
+	}
 
 	userName := os.Getenv("CHARTMUSEUM_CREDS_USR")
 	password := os.Getenv("CHARTMUSEUM_CREDS_PSW")
@@ -138,15 +184,38 @@ func (o *StepHelmReleaseOptions) Run() error {
 	}
 
 	// post the tarball to the chart repository
-	client := http.Client{}
-
 	u := util.UrlJoin(chartRepo, "/api/charts")
+	log.Logger().Infof("Uploading chart file %s to %s", util.ColorInfo(tarball), util.ColorInfo(u))
+	err = o.retryPolicy().Retry("upload chart "+tarball+" to "+u, func() error {
+		return uploadChart(u, tarball, userName, password)
+	})
+	if err != nil {
+		return err
+	}
+	return o.renderReleaseOutput(name, version, util.UrlJoin(chartRepo, "charts", tarball))
+}
+
+// renderReleaseOutput outputs the released chart and the URL it was pushed to if an --output format is specified
+func (o *StepHelmReleaseOptions) renderReleaseOutput(name string, version string, u string) error {
+	if o.Output == "" {
+		return nil
+	}
+	return o.renderOutput(&ChartReleaseOutput{
+		Name:    name,
+		Version: version,
+		URL:     u,
+	})
+}
+
+// uploadChart posts the chart archive to the chartmuseum upload endpoint
+func uploadChart(u string, tarball string, userName string, password string) error {
+	client := http.Client{}
 
 	file, err := os.Open(tarball)
 	if err != nil {
 		return errors.Wrapf(err, "failed to open the chart archive '%s'", tarball)
 	}
-	log.Logger().Infof("Uploading chart file %s to %s", util.ColorInfo(tarball), util.ColorInfo(u))
+	defer file.Close() //nolint:errcheck
 	req, err := http.NewRequest(http.MethodPost, u, bufio.NewReader(file))
 	if err != nil {
 		return errors.Wrapf(err, "failed to build the chart upload request for endpoint '%s'", u)
@@ -161,6 +230,7 @@ func (o *StepHelmReleaseOptions) Run() error {
 		errRes, _ := ioutil.ReadAll(res.Body)
 		return errors.Wrapf(err, "failed to execute the chart upload HTTP request, url: '%s', status: '%s', response: '%s'", u, res.Status, string(errRes))
 	}
+	defer res.Body.Close() //nolint:errcheck
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return errors.Wrap(err, "failed to read the response body of chart upload request")
@@ -173,3 +243,26 @@ func (o *StepHelmReleaseOptions) Run() error {
 	}
 	return nil
 }
+
+// verifyVersionBump returns an error if the chart version is not greater than the highest version of the chart
+// already published in the chart repository
+func (o *StepHelmReleaseOptions) verifyVersionBump(chartRepo string, name string, version string) error {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse version %s of chart %s", version, name)
+	}
+	index, err := o.fetchChartIndex(chartRepo)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch the published versions of chart %s", name)
+	}
+	published := index.Versions(name)
+	if len(published) == 0 {
+		log.Logger().Debugf("chart %s has not been published to %s yet", name, chartRepo)
+		return nil
+	}
+	latest := published[len(published)-1]
+	if !v.GT(latest) {
+		return fmt.Errorf("chart %s version %s must be greater than the latest published version %s in %s", name, version, latest.String(), chartRepo)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package helm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultDockerConfigSecret the default Secret containing the docker 'config.json' used to login to OCI registries
+	DefaultDockerConfigSecret = "jenkins-docker-cfg"
+
+	dockerConfigKey = "config.json"
+	ociScheme       = "oci://"
+)
+
+// dockerConfig the subset of a docker 'config.json' file used to find the credentials of a registry
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths,omitempty"`
+}
+
+type dockerAuth struct {
+	Auth     string `json:"auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// ociRegistryReference returns the 'oci://' reference of the given registry which may omit the scheme. e.g. 'gcr.io/myproject/charts'
+func ociRegistryReference(registry string) string {
+	return ociScheme + strings.TrimSuffix(strings.TrimPrefix(registry, ociScheme), "/")
+}
+
+// ociRegistryHost returns the host of the given registry reference. e.g. 'gcr.io' for 'oci://gcr.io/myproject/charts'
+func ociRegistryHost(registry string) string {
+	text := registry
+	idx := strings.Index(text, "://")
+	if idx >= 0 {
+		text = text[idx+3:]
+	}
+	return strings.SplitN(text, "/", 2)[0]
+}
+
+// findRegistryCredentials returns the user name and password of the given registry host in the docker 'config.json'
+// data or empty strings if there are no credentials for the host
+func findRegistryCredentials(data []byte, host string) (string, string, error) {
+	config := &dockerConfig{}
+	err := json.Unmarshal(data, config)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to unmarshal the docker config")
+	}
+	for key, auth := range config.Auths {
+		if ociRegistryHost(key) != host {
+			continue
+		}
+		if auth.Username != "" && auth.Password != "" {
+			return auth.Username, auth.Password, nil
+		}
+		if auth.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to decode the docker config auth of registry %s", key)
+		}
+		paths := strings.SplitN(string(decoded), ":", 2)
+		if len(paths) != 2 {
+			return "", "", fmt.Errorf("the docker config auth of registry %s is not of the form 'user:password'", key)
+		}
+		return paths[0], paths[1], nil
+	}
+	return "", "", nil
+}
+
+// loadRegistryCredentials returns the credentials of the registry host from the docker config Secret in the current
+// namespace or empty strings if the Secret does not exist or has no credentials for the host
+func (o *StepHelmReleaseOptions) loadRegistryCredentials(host string) (string, string, error) {
+	client, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to create the kube client")
+	}
+	secret, err := client.CoreV1().Secrets(ns).Get(o.DockerConfigSecret, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Logger().Warnf("no Secret %s in namespace %s so not logging in to registry %s", o.DockerConfigSecret, ns, host)
+			return "", "", nil
+		}
+		return "", "", errors.Wrapf(err, "failed to load Secret %s in namespace %s", o.DockerConfigSecret, ns)
+	}
+	user, password, err := findRegistryCredentials(secret.Data[dockerConfigKey], host)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to find the credentials of registry %s in Secret %s", host, o.DockerConfigSecret)
+	}
+	if user == "" {
+		log.Logger().Warnf("no credentials for registry %s in Secret %s so not logging in", host, o.DockerConfigSecret)
+	}
+	return user, password, nil
+}
+
+// pushToRegistry pushes the chart archive to the --registry using the helm 3 OCI registry support
+func (o *StepHelmReleaseOptions) pushToRegistry(tarball string) error {
+	helmBinary := o.Helm().HelmBinary()
+	version, err := o.Helm().Version(false)
+	if err != nil {
+		return errors.Wrap(err, "failed to find the helm version")
+	}
+	v, err := semver.ParseTolerant(version)
+	if err != nil || v.Major < 3 {
+		return fmt.Errorf("pushing charts to OCI registries requires helm 3 but found helm version %s", version)
+	}
+
+	host := ociRegistryHost(o.Registry)
+	user, password, err := o.loadRegistryCredentials(host)
+	if err != nil {
+		return err
+	}
+	if user != "" {
+		args := []string{"registry", "login", host, "--username", user, "--password-stdin"}
+		if o.Insecure {
+			args = append(args, "--insecure")
+		}
+		_, err = o.runCommand(&util.Command{
+			Name: helmBinary,
+			Args: args,
+			In:   strings.NewReader(password),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to login to registry %s", host)
+		}
+	}
+
+	ref := ociRegistryReference(o.Registry)
+	args := []string{"push", tarball, ref}
+	if o.Insecure {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+	log.Logger().Infof("Pushing chart file %s to %s", util.ColorInfo(tarball), util.ColorInfo(ref))
+	err = o.retryPolicy().Retry("push chart "+tarball+" to "+ref, func() error {
+		_, err := o.runCommand(&util.Command{
+			Name: helmBinary,
+			Args: args,
+		})
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to push the chart archive %s to %s", tarball, ref)
+	}
+	return nil
+}
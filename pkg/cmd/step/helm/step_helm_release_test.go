@@ -0,0 +1,142 @@
+// +build unit
+
+package helm
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyVersionBump(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		version string
+		bumped  bool
+	}{
+		{name: "foo", version: "2.0.0", bumped: true},
+		{name: "foo", version: "v2.1.0", bumped: true},
+		{name: "foo", version: "1.3.0", bumped: false},
+		{name: "foo", version: "1.4.0", bumped: false},
+		{name: "bar", version: "2.5.0", bumped: false},
+		{name: "unpublished", version: "0.0.1", bumped: true},
+	}
+	o := &StepHelmReleaseOptions{}
+	for _, tc := range testCases {
+		err := o.verifyVersionBump(server.URL, tc.name, tc.version)
+		if tc.bumped {
+			assert.NoError(t, err, "chart %s version %s", tc.name, tc.version)
+		} else {
+			require.Error(t, err, "chart %s version %s", tc.name, tc.version)
+			assert.Contains(t, err.Error(), "must be greater than the latest published version")
+		}
+	}
+
+	err := o.verifyVersionBump(server.URL, "foo", "not-a-version")
+	assert.Error(t, err, "invalid chart version")
+}
+
+func TestOCIRegistryReference(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "oci://gcr.io/myproject/charts", ociRegistryReference("gcr.io/myproject/charts"))
+	assert.Equal(t, "oci://gcr.io/myproject/charts", ociRegistryReference("oci://gcr.io/myproject/charts/"))
+	assert.Equal(t, "gcr.io", ociRegistryHost("oci://gcr.io/myproject/charts"))
+	assert.Equal(t, "harbor.example.com:8443", ociRegistryHost("harbor.example.com:8443/charts"))
+	assert.Equal(t, "harbor.example.com", ociRegistryHost("https://harbor.example.com"))
+}
+
+func TestFindRegistryCredentials(t *testing.T) {
+	t.Parallel()
+
+	auth := base64.StdEncoding.EncodeToString([]byte("_json_key:secret:with:colons"))
+	data := []byte(`{"auths": {"https://gcr.io": {"auth": "` + auth + `"}, "harbor.example.com": {"username": "admin", "password": "changeme"}}}`)
+
+	user, password, err := findRegistryCredentials(data, "gcr.io")
+	require.NoError(t, err)
+	assert.Equal(t, "_json_key", user)
+	assert.Equal(t, "secret:with:colons", password)
+
+	user, password, err = findRegistryCredentials(data, "harbor.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user)
+	assert.Equal(t, "changeme", password)
+
+	user, _, err = findRegistryCredentials(data, "docker.io")
+	require.NoError(t, err)
+	assert.Equal(t, "", user, "there should be no credentials for docker.io")
+}
+
+func TestPushToRegistry(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("admin:changeme"))
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DefaultDockerConfigSecret,
+			Namespace: "jx",
+		},
+		Data: map[string][]byte{
+			"config.json": []byte(`{"auths": {"harbor.example.com": {"auth": "` + auth + `"}}}`),
+		},
+	})
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.Version(false)).ThenReturn("3.8.0", nil)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetCurrentNamespace("jx")
+	commonOpts.SetKubeClient(kubeClient)
+	commonOpts.SetHelm(helmer)
+
+	commands := [][]string{}
+	stdin := ""
+	o := &StepHelmReleaseOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+				if cmd.In != nil {
+					data, err := ioutil.ReadAll(cmd.In)
+					require.NoError(t, err)
+					stdin = string(data)
+				}
+				return "", nil
+			},
+		},
+		Registry:           "harbor.example.com/charts",
+		Insecure:           true,
+		DockerConfigSecret: DefaultDockerConfigSecret,
+	}
+	err := o.pushToRegistry("mychart-1.0.0.tgz")
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"helm", "registry", "login", "harbor.example.com", "--username", "admin", "--password-stdin", "--insecure"},
+		{"helm", "push", "mychart-1.0.0.tgz", "oci://harbor.example.com/charts", "--insecure-skip-tls-verify"},
+	}, commands)
+	assert.Equal(t, "changeme", stdin, "the password should be passed on stdin")
+
+	pegomock.When(helmer.Version(false)).ThenReturn("2.16.3", nil)
+	err = o.pushToRegistry("mychart-1.0.0.tgz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires helm 3")
+}
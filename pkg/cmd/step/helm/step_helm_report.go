@@ -0,0 +1,198 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ReportFormatJSON writes the resolution report as JSON
+	ReportFormatJSON = "json"
+	// ReportFormatMarkdown writes the resolution report as Markdown
+	ReportFormatMarkdown = "markdown"
+)
+
+var (
+	// ReportFormats the supported resolution report formats
+	ReportFormats = []string{ReportFormatJSON, ReportFormatMarkdown}
+
+	invalidEnvVarCharacters = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+// ResolutionReport records the dependency versions resolved from the version stream grouped by requirements file
+type ResolutionReport struct {
+	Files []*RequirementsResolution `json:"files"`
+
+	// IncludePrerelease flags whether each resolved version is a semantic version prerelease
+	IncludePrerelease bool `json:"-"`
+
+	dir string
+}
+
+// RequirementsResolution the dependency versions resolved for a single requirements file
+type RequirementsResolution struct {
+	Path         string                  `json:"path"`
+	Dependencies []*DependencyResolution `json:"dependencies"`
+}
+
+// DependencyResolution a single dependency version resolved from the version stream
+type DependencyResolution struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Chart      string `json:"chart"`
+	Version    string `json:"version"`
+	Prerelease *bool  `json:"prerelease,omitempty"`
+}
+
+// NewResolutionReport creates a new report with file paths relative to the given directory
+func NewResolutionReport(dir string) *ResolutionReport {
+	return &ResolutionReport{
+		Files: []*RequirementsResolution{},
+		dir:   dir,
+	}
+}
+
+// Add records a resolved dependency version for the given requirements file
+func (r *ResolutionReport) Add(fileName string, name string, repository string, chart string, version string) {
+	path := fileName
+	if r.dir != "" {
+		rel, err := filepath.Rel(r.dir, fileName)
+		if err == nil {
+			path = rel
+		}
+	}
+	file := r.File(path)
+	if file == nil {
+		file = &RequirementsResolution{
+			Path:         path,
+			Dependencies: []*DependencyResolution{},
+		}
+		r.Files = append(r.Files, file)
+	}
+	resolution := &DependencyResolution{
+		Name:       name,
+		Repository: repository,
+		Chart:      chart,
+		Version:    version,
+	}
+	if r.IncludePrerelease {
+		prerelease := isPrerelease(version)
+		resolution.Prerelease = &prerelease
+	}
+	file.Dependencies = append(file.Dependencies, resolution)
+}
+
+// isPrerelease returns true if the version is a semantic version with a prerelease suffix
+func isPrerelease(version string) bool {
+	v, err := semver.ParseTolerant(version)
+	return err == nil && len(v.Pre) > 0
+}
+
+// File returns the resolutions for the given requirements file path or nil if there are none
+func (r *ResolutionReport) File(path string) *RequirementsResolution {
+	for _, file := range r.Files {
+		if file.Path == path {
+			return file
+		}
+	}
+	return nil
+}
+
+// ToJSON renders the report as JSON
+func (r *ResolutionReport) ToJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the resolution report to JSON")
+	}
+	return data, nil
+}
+
+// ToMarkdown renders the report as Markdown with a table per requirements file
+func (r *ResolutionReport) ToMarkdown() []byte {
+	var buffer strings.Builder
+	buffer.WriteString("# Resolved Chart Versions\n")
+	for _, file := range r.Files {
+		buffer.WriteString(fmt.Sprintf("\n## %s\n\n", file.Path))
+		if r.IncludePrerelease {
+			buffer.WriteString("| Dependency | Chart | Repository | Version | Prerelease |\n")
+			buffer.WriteString("| --- | --- | --- | --- | --- |\n")
+		} else {
+			buffer.WriteString("| Dependency | Chart | Repository | Version |\n")
+			buffer.WriteString("| --- | --- | --- | --- |\n")
+		}
+		for _, dep := range file.Dependencies {
+			if r.IncludePrerelease {
+				prerelease := dep.Prerelease != nil && *dep.Prerelease
+				buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %t |\n", dep.Name, dep.Chart, dep.Repository, dep.Version, prerelease))
+			} else {
+				buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", dep.Name, dep.Chart, dep.Repository, dep.Version))
+			}
+		}
+	}
+	return []byte(buffer.String())
+}
+
+// ChartVersionEnvVar returns the name of the environment variable exporting the resolved version of the given
+// dependency. The name is upper cased, any character other than a letter, digit or underscore is replaced with an
+// underscore and the result is wrapped as 'CHART_<NAME>_VERSION'. e.g. 'nginx-ingress' becomes
+// 'CHART_NGINX_INGRESS_VERSION'
+func ChartVersionEnvVar(name string) string {
+	return "CHART_" + invalidEnvVarCharacters.ReplaceAllString(strings.ToUpper(name), "_") + "_VERSION"
+}
+
+// ToEnv renders the resolved versions as 'CHART_<NAME>_VERSION=<version>' lines suitable for sourcing in a shell.
+// If a dependency name is resolved in more than one requirements file only the first resolved version is exported
+func (r *ResolutionReport) ToEnv() []byte {
+	var buffer strings.Builder
+	exported := map[string]bool{}
+	for _, file := range r.Files {
+		for _, dep := range file.Dependencies {
+			envVar := ChartVersionEnvVar(dep.Name)
+			if exported[envVar] {
+				continue
+			}
+			exported[envVar] = true
+			buffer.WriteString(fmt.Sprintf("%s=%s\n", envVar, dep.Version))
+		}
+	}
+	return []byte(buffer.String())
+}
+
+// WriteEnv writes the resolved versions to the given file as environment variable assignments
+func (r *ResolutionReport) WriteEnv(fileName string) error {
+	err := ioutil.WriteFile(fileName, r.ToEnv(), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// Write writes the report to the given file in the given format
+func (r *ResolutionReport) Write(fileName string, format string) error {
+	var data []byte
+	var err error
+	switch format {
+	case ReportFormatJSON, "":
+		data, err = r.ToJSON()
+		if err != nil {
+			return err
+		}
+	case ReportFormatMarkdown:
+		data = r.ToMarkdown()
+	default:
+		return util.InvalidOption("report-format", format, ReportFormats)
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
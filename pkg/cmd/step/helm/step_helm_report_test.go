@@ -0,0 +1,212 @@
+// +build unit
+
+package helm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecursiveResolutionReport(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	dir, _ := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+	saveTestRequirements(t, filepath.Join(dir, "nested", helm.RequirementsFileName),
+		&helm.Dependency{Name: "bar", Repository: testChartRepository},
+		&helm.Dependency{Name: "foo", Repository: testChartRepository, Version: "1.0.0"})
+
+	reportFile := filepath.Join(dir, "report.json")
+	o := &StepHelmOptions{
+		Dir:             dir,
+		ReportFile:      reportFile,
+		ReportFormat:    ReportFormatJSON,
+		versionResolver: resolver,
+	}
+	err := o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, true)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(reportFile)
+	require.NoError(t, err, "failed to load report %s", reportFile)
+	report := &ResolutionReport{}
+	err = json.Unmarshal(data, report)
+	require.NoError(t, err, "failed to parse report %s", reportFile)
+
+	require.Len(t, report.Files, 2, "report files")
+
+	root := report.File(helm.RequirementsFileName)
+	require.NotNil(t, root, "no report group for the root requirements file")
+	require.Len(t, root.Dependencies, 1)
+	assert.Equal(t, "jenkins-x/foo", root.Dependencies[0].Chart)
+	assert.Equal(t, "1.2.3", root.Dependencies[0].Version)
+
+	nested := report.File(filepath.Join("nested", helm.RequirementsFileName))
+	require.NotNil(t, nested, "no report group for the nested requirements file")
+	require.Len(t, nested.Dependencies, 1, "only dependencies without a version should be reported")
+	assert.Equal(t, "bar", nested.Dependencies[0].Name)
+	assert.Equal(t, "2.0.0", nested.Dependencies[0].Version)
+}
+
+func TestPinnedResolutionReport(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "qux", Repository: server.URL, Version: "v1.2.0"},
+		&helm.Dependency{Name: "foo", Repository: server.URL, Version: "1.2.3"})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		PinPolicy: PinPolicyPatch,
+		report:    NewResolutionReport(dir),
+	}
+	err := o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "qux", "v1.2.3")
+	assertDependencyVersion(t, fileName, "foo", "1.2.3")
+
+	root := o.report.File(helm.RequirementsFileName)
+	require.NotNil(t, root, "no report group for the requirements file")
+	require.Len(t, root.Dependencies, 2, "every pinned dependency should be reported")
+	assert.Equal(t, "qux", root.Dependencies[0].Name)
+	assert.Equal(t, "v1.2.3", root.Dependencies[0].Version)
+	assert.Equal(t, "foo", root.Dependencies[1].Name)
+	assert.Equal(t, "1.2.3", root.Dependencies[1].Version)
+}
+
+func TestResolutionReportMarkdown(t *testing.T) {
+	t.Parallel()
+
+	report := NewResolutionReport("/tmp/env")
+	report.Add("/tmp/env/requirements.yaml", "foo", testChartRepository, "jenkins-x/foo", "1.2.3")
+	report.Add("/tmp/env/nested/requirements.yaml", "bar", testChartRepository, "jenkins-x/bar", "2.0.0")
+
+	md := string(report.ToMarkdown())
+	assert.Contains(t, md, "## requirements.yaml\n")
+	assert.Contains(t, md, "## nested/requirements.yaml\n")
+	assert.Contains(t, md, "| foo | jenkins-x/foo | http://chartmuseum.jenkins-x.io | 1.2.3 |")
+	assert.Contains(t, md, "| bar | jenkins-x/bar | http://chartmuseum.jenkins-x.io | 2.0.0 |")
+}
+
+func TestDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	dir, _ := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: testChartRepository},
+		&helm.Dependency{Name: "db", Alias: "database", Repository: testChartRepository, Version: "0.5.0"})
+	defer os.RemoveAll(dir)
+	err := ioutil.WriteFile(filepath.Join(dir, helm.ChartFileName), []byte("name: myapp\nversion: 0.1.0\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	saveTestRequirements(t, filepath.Join(dir, "charts", "foo", helm.RequirementsFileName),
+		&helm.Dependency{Name: "bar", Repository: testChartRepository})
+	saveTestRequirements(t, filepath.Join(dir, "charts", "db", helm.RequirementsFileName),
+		&helm.Dependency{Name: "bar", Repository: testChartRepository})
+
+	graphFile := filepath.Join(dir, "graph.dot")
+	o := &StepHelmOptions{
+		Dir:             dir,
+		GraphFile:       graphFile,
+		versionResolver: resolver,
+	}
+	err = o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, true)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(graphFile)
+	require.NoError(t, err, "failed to load graph %s", graphFile)
+
+	expected := `digraph dependencies {
+  "." [label="myapp\n0.1.0"];
+  "charts/foo" [label="foo\n1.2.3"];
+  "charts/db" [label="db\n0.5.0"];
+  "charts/db/charts/bar" [label="bar\n2.0.0"];
+  "charts/foo/charts/bar" [label="bar\n2.0.0"];
+  "." -> "charts/foo";
+  "." -> "charts/db" [label="database"];
+  "charts/db" -> "charts/db/charts/bar";
+  "charts/foo" -> "charts/foo/charts/bar";
+}
+`
+	assert.Equal(t, expected, string(data))
+}
+
+func TestExportEnv(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	dir, _ := writeTestRequirements(t,
+		&helm.Dependency{Name: "nginx-ingress", Repository: testChartRepository},
+		&helm.Dependency{Name: "foo", Alias: "my.foo-chart", Repository: testChartRepository},
+		&helm.Dependency{Name: "bar", Repository: testChartRepository, Version: "1.0.0"})
+	defer os.RemoveAll(dir)
+
+	envFile := filepath.Join(dir, "versions.env")
+	o := &StepHelmOptions{
+		Dir:             dir,
+		ExportEnvFile:   envFile,
+		versionResolver: resolver,
+	}
+	err := o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, false)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(envFile)
+	require.NoError(t, err, "failed to load file %s", envFile)
+	assert.Equal(t, "CHART_NGINX_INGRESS_VERSION=1.26.2\nCHART_MY_FOO_CHART_VERSION=1.2.3\n", string(data))
+}
+
+func TestChartVersionEnvVar(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "CHART_NGINX_INGRESS_VERSION", ChartVersionEnvVar("nginx-ingress"))
+	assert.Equal(t, "CHART_JX_APP_SSO_VERSION", ChartVersionEnvVar("jx.app-sso"))
+	assert.Equal(t, "CHART_CERT_MANAGER_V1_VERSION", ChartVersionEnvVar("Cert-Manager_v1"))
+}
+
+func TestResolutionReportIncludePrerelease(t *testing.T) {
+	t.Parallel()
+
+	report := NewResolutionReport("/tmp/env")
+	report.IncludePrerelease = true
+	report.Add("/tmp/env/requirements.yaml", "foo", testChartRepository, "jenkins-x/foo", "1.2.3")
+	report.Add("/tmp/env/requirements.yaml", "bar", testChartRepository, "jenkins-x/bar", "2.0.0-beta.1")
+	report.Add("/tmp/env/requirements.yaml", "baz", testChartRepository, "jenkins-x/baz", "v1.6.0-rc.2")
+
+	data, err := report.ToJSON()
+	require.NoError(t, err)
+	actual := &ResolutionReport{}
+	err = json.Unmarshal(data, actual)
+	require.NoError(t, err, "failed to parse report %s", string(data))
+
+	file := actual.File(helm.RequirementsFileName)
+	require.NotNil(t, file, "no report group for the requirements file")
+	require.Len(t, file.Dependencies, 3)
+	expected := map[string]bool{"foo": false, "bar": true, "baz": true}
+	for _, dep := range file.Dependencies {
+		require.NotNil(t, dep.Prerelease, "no prerelease flag for dependency %s", dep.Name)
+		assert.Equal(t, expected[dep.Name], *dep.Prerelease, "prerelease flag for dependency %s", dep.Name)
+	}
+
+	md := string(report.ToMarkdown())
+	assert.Contains(t, md, "| Dependency | Chart | Repository | Version | Prerelease |")
+	assert.Contains(t, md, "| foo | jenkins-x/foo | http://chartmuseum.jenkins-x.io | 1.2.3 | false |")
+	assert.Contains(t, md, "| bar | jenkins-x/bar | http://chartmuseum.jenkins-x.io | 2.0.0-beta.1 | true |")
+
+	stable := NewResolutionReport("/tmp/env")
+	stable.Add("/tmp/env/requirements.yaml", "bar", testChartRepository, "jenkins-x/bar", "2.0.0-beta.1")
+	data, err = stable.ToJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "prerelease", "the prerelease flag should only be reported when enabled")
+}
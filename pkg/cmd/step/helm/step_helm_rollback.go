@@ -0,0 +1,284 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultRollbackHistoryMax the default maximum number of revisions of the release history
+	DefaultRollbackHistoryMax = 10
+)
+
+// StepHelmRollbackOptions contains the command line flags
+type StepHelmRollbackOptions struct {
+	StepHelmOptions
+
+	Namespace   string
+	ReleaseName string
+	Revision    int
+	Max         int
+	Wait        bool
+	Force       bool
+	GitPush     bool
+}
+
+// RevisionOutput the structured output of a revision of the history of a release
+type RevisionOutput struct {
+	Revision     int    `json:"revision"`
+	Updated      string `json:"updated"`
+	Status       string `json:"status"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chartVersion"`
+	AppVersion   string `json:"appVersion,omitempty"`
+	ValuesHash   string `json:"valuesHash"`
+	Description  string `json:"description,omitempty"`
+}
+
+// ReleaseHistoryOutput the structured output of 'jx step helm rollback' listing the history of a release
+type ReleaseHistoryOutput struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Revisions []RevisionOutput `json:"revisions"`
+}
+
+var (
+	StepHelmRollbackLong = templates.LongDesc(`
+		Lists the history of a helm release or rolls it back to one of its revisions.
+
+		If the --dir of the chart of the environment git repository is specified then after the rollback the chart is
+		restored to the last commit before the revision was deployed and committed so that the GitOps state does not
+		drift from the state of the cluster.
+
+		Rolling back is only supported when using helm rather than helm template mode.
+`)
+
+	StepHelmRollbackExample = templates.Examples(`
+		# lists the revisions of the jx-staging release with the hash of their values
+		jx step helm rollback --name jx-staging --namespace jx-staging
+
+		# rolls back the jx-staging release to revision 3 and updates the chart in the env dir of the git clone
+		jx step helm rollback --name jx-staging --namespace jx-staging --revision 3 --dir env --git-push
+
+`)
+)
+
+// NewCmdStepHelmRollback creates the command to list the history of a release or roll it back
+func NewCmdStepHelmRollback(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmRollbackOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Lists the history of a helm release or rolls it back to one of its revisions",
+		Aliases: []string{"history"},
+		Long:    StepHelmRollbackLong,
+		Example: StepHelmRollbackExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the chart of the environment in its git clone which is restored to match the revision after the rollback")
+	cmd.Flags().StringVarP(&options.HelmBinary, "helm-binary", "", "", "The helm binary to use")
+	options.addOutputFlag(cmd)
+
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The namespace of the release. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", "", "The name of the release")
+	cmd.Flags().IntVarP(&options.Revision, "revision", "r", 0, "The revision to roll back the release to. If not specified the history of the release is listed")
+	cmd.Flags().IntVarP(&options.Max, "max", "", DefaultRollbackHistoryMax, "The maximum number of revisions of the history to list")
+	cmd.Flags().BoolVarP(&options.Wait, "wait", "", true, "Wait for the resources of the release to be ready after rolling back")
+	cmd.Flags().BoolVarP(&options.Force, "force", "f", false, "Force the resource updates of the rollback through delete and recreate if needed")
+	cmd.Flags().BoolVarP(&options.GitPush, "git-push", "", false, "Pushes the commit restoring the chart in the --dir to the remote of the git clone")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepHelmRollbackOptions) Run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+	releaseName := o.ReleaseName
+	if releaseName == "" && len(o.Args) > 0 {
+		releaseName = o.Args[0]
+	}
+	if releaseName == "" {
+		return util.MissingOption("name")
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
+	ns := o.Namespace
+	if ns == "" {
+		var err error
+		_, ns, err = o.KubeClientAndNamespace()
+		if err != nil {
+			return err
+		}
+	}
+	cli, err := o.helmCLI()
+	if err != nil {
+		return err
+	}
+
+	revisions, err := cli.History(ns, releaseName, o.Max)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the history of release %s in namespace %s", releaseName, ns)
+	}
+	if o.Revision <= 0 {
+		return o.renderHistory(cli, releaseName, ns, revisions)
+	}
+
+	var revision *helm.ReleaseRevision
+	for i := range revisions {
+		if revisions[i].Revision == o.Revision {
+			revision = &revisions[i]
+		}
+	}
+	if revision == nil {
+		return fmt.Errorf("the release %s in namespace %s has no revision %d in its last %d revisions", releaseName, ns, o.Revision, len(revisions))
+	}
+	err = cli.Rollback(ns, releaseName, o.Revision, o.Wait, o.Force)
+	if err != nil {
+		return errors.Wrapf(err, "failed to roll back release %s in namespace %s to revision %d", releaseName, ns, o.Revision)
+	}
+	log.Logger().Infof("Rolled back release %s in namespace %s to revision %s of chart %s %s", util.ColorInfo(releaseName), util.ColorInfo(ns), util.ColorInfo(strconv.Itoa(o.Revision)), util.ColorInfo(revision.Chart), util.ColorInfo(revision.ChartVersion))
+
+	// lets make sure the next 'jx step helm apply' does not skip the release as its chart is unchanged
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	err = clearAppliedHash(kubeClient, ns, releaseName)
+	if err != nil {
+		return err
+	}
+
+	if o.Dir == "" {
+		log.Logger().Warnf("the environment git repository has not been updated to match the rollback as no --dir was specified")
+		return nil
+	}
+	return o.restoreEnvironmentChart(releaseName, revision)
+}
+
+// helmCLI returns the helm CLI so that the release history can be used
+func (o *StepHelmRollbackOptions) helmCLI() (*helm.HelmCLI, error) {
+	helmer := helm.Unwrap(o.Helm())
+	cli, ok := helmer.(*helm.HelmCLI)
+	if !ok {
+		return nil, fmt.Errorf("rolling back a release is only supported when using helm rather than helm template mode")
+	}
+	return cli, nil
+}
+
+// renderHistory outputs the revisions of the release along with the hash of their values
+func (o *StepHelmRollbackOptions) renderHistory(cli *helm.HelmCLI, releaseName string, ns string, revisions []helm.ReleaseRevision) error {
+	history := &ReleaseHistoryOutput{
+		Name:      releaseName,
+		Namespace: ns,
+		Revisions: []RevisionOutput{},
+	}
+	for _, revision := range revisions {
+		values, err := cli.GetValues(ns, releaseName, revision.Revision)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the values of revision %d of release %s", revision.Revision, releaseName)
+		}
+		sum := sha256.Sum256([]byte(values))
+		history.Revisions = append(history.Revisions, RevisionOutput{
+			Revision:     revision.Revision,
+			Updated:      revision.Updated,
+			Status:       revision.Status,
+			Chart:        revision.Chart,
+			ChartVersion: revision.ChartVersion,
+			AppVersion:   revision.AppVersion,
+			ValuesHash:   digestPrefix + hex.EncodeToString(sum[:]),
+			Description:  revision.Description,
+		})
+	}
+	if o.Output != "" {
+		return o.renderOutput(history)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("REVISION", "UPDATED", "STATUS", "CHART", "VERSION", "VALUES HASH", "DESCRIPTION")
+	for _, revision := range history.Revisions {
+		// lets show the same short hash as git so the table fits in the console
+		hash := strings.TrimPrefix(revision.ValuesHash, digestPrefix)[:12]
+		table.AddRow(strconv.Itoa(revision.Revision), revision.Updated, revision.Status, revision.Chart, revision.ChartVersion, hash, revision.Description)
+	}
+	table.Render()
+	return nil
+}
+
+// restoreEnvironmentChart restores the chart in the --dir of the environment git clone to the last commit before the
+// revision was deployed and commits it so that the GitOps state matches the rolled back release
+func (o *StepHelmRollbackOptions) restoreEnvironmentChart(releaseName string, revision *helm.ReleaseRevision) error {
+	dir, err := filepath.Abs(o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "could not find absolute path of dir %s", o.Dir)
+	}
+	gitter := o.Git()
+	gitDir, _, err := gitter.FindGitConfigDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the git clone of %s", dir)
+	}
+	if gitDir == "" {
+		return fmt.Errorf("the --dir %s is not in a git clone", o.Dir)
+	}
+	chartPath, err := filepath.Rel(gitDir, dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the path of %s in the git clone %s", dir, gitDir)
+	}
+
+	output, err := o.runCommand(&util.Command{
+		Dir:  gitDir,
+		Name: "git",
+		Args: []string{"log", "-1", "--format=%H", "--before=" + revision.Updated, "--", chartPath},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the commit of %s deployed as revision %d", chartPath, revision.Revision)
+	}
+	sha := strings.TrimSpace(output)
+	if sha == "" {
+		return fmt.Errorf("could not find a commit of %s before revision %d was deployed at %s", chartPath, revision.Revision, revision.Updated)
+	}
+	err = gitter.CheckoutCommitFiles(gitDir, sha, []string{chartPath})
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore %s to commit %s", chartPath, sha)
+	}
+	message := fmt.Sprintf("chore: rollback release %s to revision %d\n\nRestores %s to commit %s which was deployed as revision %d of chart %s %s", releaseName, revision.Revision, chartPath, sha, revision.Revision, revision.Chart, revision.ChartVersion)
+	err = gitter.CommitIfChanges(gitDir, message)
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit the rollback of %s", chartPath)
+	}
+	log.Logger().Infof("Restored %s in the git clone %s to commit %s", util.ColorInfo(chartPath), util.ColorInfo(gitDir), util.ColorInfo(sha))
+	if !o.GitPush {
+		return nil
+	}
+	err = gitter.Push(gitDir, "origin", false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push the rollback of %s", chartPath)
+	}
+	log.Logger().Infof("Pushed the rollback of %s", util.ColorInfo(chartPath))
+	return nil
+}
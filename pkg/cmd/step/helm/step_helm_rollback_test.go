@@ -0,0 +1,98 @@
+// +build unit
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeHelmRunner returns the output of the helm command for its arguments
+type fakeHelmRunner struct {
+	util.Command
+
+	commands []string
+	outputs  map[string]string
+}
+
+func (r *fakeHelmRunner) RunWithoutRetry() (string, error) {
+	command := strings.Join(r.Args, " ")
+	r.commands = append(r.commands, command)
+	return r.outputs[command], nil
+}
+
+func TestStepHelmRollback(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeHelmRunner{
+		outputs: map[string]string{
+			"history jx-staging --output json --max 10 --namespace jx-staging": `[
+				{"revision":1,"updated":"2020-05-11T10:13:53+01:00","status":"superseded","chart":"env-0.0.1","description":"Install complete"},
+				{"revision":2,"updated":"2020-05-12T09:00:00+01:00","status":"deployed","chart":"env-0.0.2","description":"Upgrade complete"}
+			]`,
+			"get values jx-staging --revision 1 --namespace jx-staging": "foo: bar\n",
+			"get values jx-staging --revision 2 --namespace jx-staging": "foo: baz\n",
+		},
+	}
+	out := &bytes.Buffer{}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.Out = out
+	commonOpts.SetHelm(helm.NewHelmCLIWithRunner(runner, "helm3", helm.V3, "", false, nil))
+	commonOpts.SetGit(gits.NewGitFake())
+	kubeClient := kubemocks.NewSimpleClientset()
+	commonOpts.SetKubeClient(kubeClient)
+	require.NoError(t, saveAppliedHash(kubeClient, "jx-staging", "jx-staging", "sha256:abc"))
+
+	var gitArgs []string
+	o := &StepHelmRollbackOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			commandRunner: func(cmd *util.Command) (string, error) {
+				gitArgs = cmd.Args
+				return "1234abcd\n", nil
+			},
+		},
+		Namespace:   "jx-staging",
+		ReleaseName: "jx-staging",
+		Max:         DefaultRollbackHistoryMax,
+	}
+	o.Output = OutputFormatJSON
+
+	err := o.Run()
+	require.NoError(t, err)
+	history := &ReleaseHistoryOutput{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), history))
+	require.Len(t, history.Revisions, 2)
+	assert.Equal(t, "env", history.Revisions[0].Chart)
+	assert.Equal(t, "0.0.1", history.Revisions[0].ChartVersion)
+	assert.Equal(t, "SUPERSEDED", history.Revisions[0].Status)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", history.Revisions[0].ValuesHash)
+	assert.NotEqual(t, history.Revisions[0].ValuesHash, history.Revisions[1].ValuesHash, "the values hash should change with the values")
+
+	o.Revision = 3
+	err = o.Run()
+	require.Error(t, err, "should fail to roll back to a revision which is not in the history")
+
+	o.Revision = 1
+	o.Dir = "."
+	err = o.Run()
+	require.NoError(t, err)
+	assert.Contains(t, runner.commands, "rollback jx-staging 1 --namespace jx-staging")
+	hash, err := lastAppliedHash(kubeClient, "jx-staging", "jx-staging")
+	require.NoError(t, err)
+	assert.Equal(t, "", hash, "should clear the applied hash so the next apply does not skip the release")
+	assert.Equal(t, []string{"log", "-1", "--format=%H", "--before=2020-05-11T10:13:53+01:00", "--", "."}, gitArgs, "should restore the chart to the commit deployed as the revision")
+}
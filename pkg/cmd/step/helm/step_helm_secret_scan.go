@@ -0,0 +1,376 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSecretsAllowlistFileName the name of the file in the chart directory used as the secrets allowlist if no
+	// --secrets-allowlist is specified
+	DefaultSecretsAllowlistFileName = "secrets-allowlist.yaml"
+
+	// SecretRuleAWSAccessKey AWS access key IDs
+	SecretRuleAWSAccessKey = "aws-access-key"
+	// SecretRulePrivateKey PEM encoded private keys
+	SecretRulePrivateKey = "private-key"
+	// SecretRuleGitHubToken GitHub personal access, OAuth and app tokens
+	SecretRuleGitHubToken = "github-token"
+	// SecretRuleSlackToken Slack bot, app and user tokens
+	SecretRuleSlackToken = "slack-token"
+	// SecretRuleURLPassword passwords embedded in URLs
+	SecretRuleURLPassword = "url-password"
+	// SecretRuleHighEntropy random looking base64 or hex strings
+	SecretRuleHighEntropy = "high-entropy"
+
+	minHighEntropyLength   = 20
+	base64EntropyThreshold = 4.5
+	hexEntropyThreshold    = 3.0
+)
+
+var (
+	secretPatterns = []struct {
+		rule  string
+		regex *regexp.Regexp
+	}{
+		{SecretRuleAWSAccessKey, regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{SecretRulePrivateKey, regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY-----`)},
+		{SecretRuleGitHubToken, regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+		{SecretRuleSlackToken, regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}`)},
+	}
+
+	base64TokenRegex = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+	hexRegex         = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	digestRegex      = regexp.MustCompile(`\b(sha1|sha256|sha512):[0-9a-fA-F]+`)
+
+	templateActionRegex = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+)
+
+// SecretFinding a value which looks like a secret found when scanning the values and rendered manifests of a chart
+type SecretFinding struct {
+	// Rule the rule which found the secret
+	Rule string
+	// Location the values file or manifest and the path of the value within it
+	Location string
+	// Redacted the start of the secret so it can be found without revealing it
+	Redacted string
+	// Fingerprint the digest of the secret which can be added to the allowlist
+	Fingerprint string
+}
+
+// SecretsAllowlist the findings which are not secrets such as the digests of test fixtures
+type SecretsAllowlist struct {
+	// Rules the rules which are not checked
+	Rules []string `json:"rules,omitempty"`
+	// Paths the regular expressions matching the locations of findings which are ignored
+	Paths []string `json:"paths,omitempty"`
+	// Fingerprints the fingerprints of values which are not secrets
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	paths []*regexp.Regexp
+}
+
+// LoadSecretsAllowlist loads the secrets allowlist in the given file returning an empty allowlist if the file does
+// not exist
+func LoadSecretsAllowlist(fileName string) (*SecretsAllowlist, error) {
+	allowlist := &SecretsAllowlist{}
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return allowlist, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the secrets allowlist %s", fileName)
+	}
+	err = yaml.Unmarshal(data, allowlist)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the secrets allowlist %s", fileName)
+	}
+	for _, path := range allowlist.Paths {
+		regex, err := regexp.Compile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid path %s in the secrets allowlist %s", path, fileName)
+		}
+		allowlist.paths = append(allowlist.paths, regex)
+	}
+	return allowlist, nil
+}
+
+// Allows returns true if the finding is not a secret
+func (a *SecretsAllowlist) Allows(finding SecretFinding) bool {
+	if util.StringArrayIndex(a.Rules, finding.Rule) >= 0 || util.StringArrayIndex(a.Fingerprints, finding.Fingerprint) >= 0 {
+		return true
+	}
+	for _, regex := range a.paths {
+		if regex.MatchString(finding.Location) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanValuesForSecrets returns the strings in the given values which look like secrets
+func ScanValuesForSecrets(location string, path string, value interface{}) []SecretFinding {
+	findings := []SecretFinding{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			findings = append(findings, ScanValuesForSecrets(location, childPath, v[key])...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			findings = append(findings, ScanValuesForSecrets(location, path+"["+strconv.Itoa(i)+"]", item)...)
+		}
+	case string:
+		findings = append(findings, scanStringForSecrets(location+":"+path, v)...)
+	}
+	return findings
+}
+
+// scanStringForSecrets returns the secrets in the given string skipping secret URIs and templates
+func scanStringForSecrets(location string, value string) []SecretFinding {
+	findings := []SecretFinding{}
+	if secreturl.IsURI(value) || strings.Contains(value, "{{") {
+		return findings
+	}
+	for _, pattern := range secretPatterns {
+		for _, match := range pattern.regex.FindAllString(value, -1) {
+			findings = append(findings, newSecretFinding(pattern.rule, location, match))
+		}
+	}
+	// lets not report the base64 encoded bodies of certificates or keys which did not match a pattern
+	if len(findings) > 0 || strings.Contains(value, "-----BEGIN ") {
+		return findings
+	}
+
+	// lets only check the password of URLs as their paths are long but not random
+	u, err := url.Parse(value)
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		if password, ok := u.User.Password(); ok && password != "" {
+			findings = append(findings, newSecretFinding(SecretRuleURLPassword, location, password))
+		}
+		return findings
+	}
+
+	// image digests are random but are not secrets
+	value = digestRegex.ReplaceAllString(value, "")
+	for _, token := range base64TokenRegex.FindAllString(value, -1) {
+		if isHighEntropy(token) {
+			findings = append(findings, newSecretFinding(SecretRuleHighEntropy, location, token))
+		}
+	}
+	return findings
+}
+
+// isHighEntropy returns true if the token looks like a random hex or base64 string
+func isHighEntropy(token string) bool {
+	if len(token) < minHighEntropyLength {
+		return false
+	}
+	// lets not report numbers such as timestamps or IDs
+	if strings.Trim(token, "0123456789") == "" {
+		return false
+	}
+	if hexRegex.MatchString(token) {
+		return shannonEntropy(token) > hexEntropyThreshold
+	}
+	return shannonEntropy(token) > base64EntropyThreshold
+}
+
+// shannonEntropy returns the Shannon entropy of the characters of the given string in bits
+func shannonEntropy(text string) float64 {
+	counts := map[rune]int{}
+	for _, r := range text {
+		counts[r]++
+	}
+	answer := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(text))
+		answer -= p * math.Log2(p)
+	}
+	return answer
+}
+
+// newSecretFinding creates a finding redacting the secret
+func newSecretFinding(rule string, location string, secret string) SecretFinding {
+	sum := sha256.Sum256([]byte(secret))
+	redacted := "****"
+	if len(secret) > 8 {
+		redacted = secret[:4] + redacted
+	}
+	return SecretFinding{
+		Rule:        rule,
+		Location:    location,
+		Redacted:    redacted,
+		Fingerprint: digestPrefix + hex.EncodeToString(sum[:]),
+	}
+}
+
+// ScanManifestsForSecrets returns the values of the rendered manifests which look like secrets. Secret resources are
+// skipped as they are expected to contain secrets
+func ScanManifestsForSecrets(manifests []PlanManifest) ([]SecretFinding, error) {
+	findings := []SecretFinding{}
+	for _, manifest := range manifests {
+		for _, doc := range yamlDocumentSeparator.Split(manifest.Content, -1) {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			object := map[string]interface{}{}
+			err := yaml.Unmarshal([]byte(doc), &object)
+			if err != nil {
+				return findings, errors.Wrapf(err, "failed to unmarshal manifest %s", manifest.Path)
+			}
+			kind, _ := object["kind"].(string)
+			if kind == "Secret" {
+				continue
+			}
+			name := ""
+			if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+				name, _ = metadata["name"].(string)
+			}
+			findings = append(findings, ScanValuesForSecrets(manifest.Path+":"+kind+"/"+name, "", object)...)
+		}
+	}
+	return findings, nil
+}
+
+// scanValuesFilesForSecrets returns the values which look like secrets in the values files other than secrets files.
+// In boot mode the values.yaml of the chart is generated from the values tree with the secrets resolved so the source
+// values and templates of the tree are scanned instead. It must be called before the values are generated
+func (o *StepHelmBuildOptions) scanValuesFilesForSecrets(dir string) ([]SecretFinding, error) {
+	var valuesFiles []string
+	var err error
+	if o.Boot {
+		valuesFiles, err = findValuesTreeFiles(dir)
+	} else {
+		valuesFiles, err = o.findValuesFiles(dir, []string{helm.ValuesFileName, "myvalues.yaml"})
+	}
+	if err != nil {
+		return nil, err
+	}
+	findings := []SecretFinding{}
+	for _, valuesFile := range valuesFiles {
+		if filepath.Base(valuesFile) == helm.SecretsFileName || helm.IsSopsFile(valuesFile) {
+			continue
+		}
+		data, err := ioutil.ReadFile(valuesFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load values file %s", valuesFile)
+		}
+		if filepath.Base(valuesFile) == helm.ValuesTemplateFileName {
+			// lets scan the literal values of the template rather than the values it resolves
+			data = templateActionRegex.ReplaceAll(data, nil)
+		}
+		values, err := helm.LoadValues(data)
+		if err != nil {
+			if filepath.Base(valuesFile) == helm.ValuesTemplateFileName {
+				log.Logger().Warnf("not scanning the values template %s for secrets as it is not valid YAML without its actions: %s", valuesFile, err)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to parse values file %s", valuesFile)
+		}
+		location := valuesFile
+		if rel, err := filepath.Rel(dir, valuesFile); err == nil && !strings.HasPrefix(rel, "..") {
+			location = rel
+		}
+		findings = append(findings, ScanValuesForSecrets(location, "", values)...)
+	}
+	return findings, nil
+}
+
+// findValuesTreeFiles returns the values templates and the values files of the sub directories of the values tree
+// which generate the values.yaml of the chart in the directory
+func findValuesTreeFiles(dir string) ([]string, error) {
+	answer := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ignore, err := util.IgnoreFile(rel, helm.DefaultValuesTreeIgnores)
+		if err != nil || ignore {
+			return err
+		}
+		name := info.Name()
+		if name == helm.ValuesTemplateFileName || (name == helm.ValuesFileName && filepath.Dir(rel) != ".") {
+			answer = append(answer, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the values tree files in %s", dir)
+	}
+	return answer, nil
+}
+
+// scanSecrets fails if the findings of the values files or the rendered manifests of the chart contain anything which
+// looks like a secret and is not in the secrets allowlist
+func (o *StepHelmBuildOptions) scanSecrets(dir string, valuesFindings []SecretFinding, manifests []PlanManifest) error {
+	allowlistFile := o.SecretsAllowlist
+	if allowlistFile == "" {
+		allowlistFile = filepath.Join(dir, DefaultSecretsAllowlistFileName)
+	}
+	allowlist, err := LoadSecretsAllowlist(allowlistFile)
+	if err != nil {
+		return err
+	}
+
+	findings := append([]SecretFinding{}, valuesFindings...)
+	manifestFindings, err := ScanManifestsForSecrets(manifests)
+	if err != nil {
+		return err
+	}
+	findings = append(findings, manifestFindings...)
+
+	lines := []string{}
+	for _, finding := range findings {
+		if allowlist.Allows(finding) {
+			log.Logger().Debugf("ignoring the allowed %s finding at %s", finding.Rule, finding.Location)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("* %s %s %s fingerprint %s", finding.Location, finding.Rule, finding.Redacted, finding.Fingerprint))
+	}
+	if len(lines) == 0 {
+		log.Logger().Infof("No secrets found in the values or rendered manifests of the chart in %s", util.ColorInfo(dir))
+		return nil
+	}
+	return fmt.Errorf("found %d possible secrets in the values or rendered manifests of the chart in %s:\n%s\nPlease store them in vault or a '%s' file or add them to the secrets allowlist %s", len(lines), dir, strings.Join(lines, "\n"), helm.SecretsFileName, allowlistFile)
+}
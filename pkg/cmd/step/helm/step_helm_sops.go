@@ -0,0 +1,38 @@
+package helm
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+)
+
+// decryptSopsValuesFiles replaces any SOPS encrypted values files with their decrypted values held in a memory backed
+// directory so that they are never written to disk. The decrypted values are removed by closeSopsValues
+func (o *StepHelmOptions) decryptSopsValuesFiles(valuesFiles []string) ([]string, error) {
+	found := false
+	for _, valuesFile := range valuesFiles {
+		if helm.IsSopsFile(valuesFile) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return valuesFiles, nil
+	}
+	if o.sopsValues == nil {
+		var err error
+		o.sopsValues, err = helm.NewSopsValues()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return o.sopsValues.DecryptValuesFiles(valuesFiles, o.runCommand)
+}
+
+// closeSopsValues removes the decrypted values of any SOPS encrypted values files
+func (o *StepHelmOptions) closeSopsValues() {
+	err := o.sopsValues.Close()
+	if err != nil {
+		log.Logger().Warnf("failed to remove the decrypted SOPS values: %s", err.Error())
+	}
+	o.sopsValues = nil
+}
@@ -0,0 +1,278 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/io/secrets"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
+
+// StepHelmTemplateOptions contains the command line flags
+type StepHelmTemplateOptions struct {
+	StepHelmOptions
+
+	Namespace         string
+	ReleaseName       string
+	OutputDir         string
+	ProviderValuesDir string
+	Boot              bool
+}
+
+var (
+	stepHelmTemplateLong = templates.LongDesc(`
+		Renders the helm chart in a given directory without applying it.
+
+		The values are generated in the same way as 'jx step helm apply' does: the values tree is merged together, any
+		'values.tmpl.yaml' templates are evaluated using the version stream and any kubernetes provider specific overrides
+		are applied. The rendered manifests are then written to the console or to an output directory so that they can
+		be checked by policy tools such as conftest or OPA.
+
+		The chart is rendered without accessing Vault or the cluster. Secret URIs are only resolved from the local file
+		system or a cloud secret manager and any other secret URIs, such as those of Vault, are left in the rendered
+		manifests.
+`)
+
+	stepHelmTemplateExample = templates.Examples(`
+		# render the chart in the env folder to the console
+		jx step helm template --dir env
+
+		# render the chart in the env folder into the output directory
+		jx step helm template --dir env --output-dir manifests
+`)
+)
+
+// NewCmdStepHelmTemplate creates the command
+func NewCmdStepHelmTemplate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmTemplateOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "template",
+		Short:   "Renders the helm chart in a given directory with the merged values without applying it",
+		Long:    stepHelmTemplateLong,
+		Example: stepHelmTemplateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addStepHelmFlags(cmd)
+	options.addValidateValuesFlag(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addValidateSchemaFlag(cmd)
+	options.addVersionResolutionFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", kube.DefaultNamespace, "The Kubernetes namespace to render the helm chart for")
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", "", "The name of the release. Defaults to the name of the chart directory")
+	cmd.Flags().StringVarP(&options.OutputDir, "output-dir", "o", "", "The directory to write the rendered manifests to. If not specified the manifests are written to the console")
+	cmd.Flags().BoolVarP(&options.Boot, "boot", "", false, "In Boot mode we load the Version Stream from the 'jx-requirements.yml' and use that to replace any missing versions in the 'requirements.yaml' file from the Version Stream")
+	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	options.addProviderValuesFlags(cmd)
+	return cmd
+}
+
+// Run implements the command
+func (o *StepHelmTemplateOptions) Run() error {
+	// lets use helm without tiller so that we never need to access the cluster
+	o.RemoteCluster = true
+	o.noCluster = true
+	if o.ProviderValuesConfigMap != "" {
+		log.Logger().Warnf("ignoring the --provider-values-configmap %s as the chart is rendered without accessing the cluster", o.ProviderValuesConfigMap)
+		o.ProviderValuesConfigMap = ""
+	}
+	if o.CheckKubeVersion {
+		log.Logger().Warnf("not checking the kubeVersion of the dependencies as the chart is rendered without accessing the cluster")
+		o.CheckKubeVersion = false
+	}
+	if err := o.validateOnMissing(); err != nil {
+		return err
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return err
+	}
+	defer o.closeSopsValues()
+	dir := o.Dir
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return errors.Wrapf(err, "could not find absolute path of dir %s", o.Dir)
+	}
+	releaseName := o.ReleaseName
+	if releaseName == "" {
+		releaseName = filepath.Base(dir)
+	}
+	outDir := o.OutputDir
+	if outDir != "" {
+		outDir, err = filepath.Abs(outDir)
+		if err != nil {
+			return errors.Wrapf(err, "could not find absolute path of output dir %s", o.OutputDir)
+		}
+	}
+
+	rootTmpDir, err := ioutil.TempDir("", "jx-helm-template-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory to render the helm chart")
+	}
+	defer os.RemoveAll(rootTmpDir) //nolint:errcheck
+
+	// lets render a copy of the chart so that the generated values and versions do not modify the source directory
+	tmpDir := filepath.Join(rootTmpDir, filepath.Base(dir))
+	err = os.MkdirAll(tmpDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary dir %s", tmpDir)
+	}
+	err = util.CopyDir(dir, tmpDir, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy helm dir %s to temporary dir %s", dir, tmpDir)
+	}
+
+	valuesFiles, err := o.generateValues(tmpDir)
+	if err != nil {
+		return err
+	}
+	err = o.buildDependencies(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	renderDir := outDir
+	if renderDir == "" {
+		renderDir = filepath.Join(rootTmpDir, "output")
+	}
+	err = os.MkdirAll(renderDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the output dir %s", renderDir)
+	}
+	err = o.Helm().Template(tmpDir, releaseName, o.Namespace, renderDir, false, nil, nil, valuesFiles)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render the helm chart in %s", dir)
+	}
+	if outDir != "" {
+		log.Logger().Infof("Rendered the helm chart %s to %s", util.ColorInfo(dir), util.ColorInfo(outDir))
+		return nil
+	}
+	text, err := readManifests(renderDir)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(o.Out, text)
+	return err
+}
+
+// generateValues writes the merged 'values.yaml' file into the chart directory like 'jx step helm apply' does and
+// returns the values files to render the chart with
+func (o *StepHelmTemplateOptions) generateValues(dir string) ([]string, error) {
+	requirements, requirementsFileName, err := config.LoadRequirementsConfig(dir)
+	if err != nil {
+		if requirementsFileName != "" {
+			return nil, errors.Wrapf(err, "failed to load %s", requirementsFileName)
+		}
+		log.Logger().Debugf("no %s found for %s so using the defaults", config.RequirementsConfigFileName, dir)
+		requirements = config.NewRequirementsConfig()
+	}
+	o.SetChartRepositoryMirrors(requirements.Mirrors)
+	secretURLClient, err := o.templateSecretURLClient(requirements, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	DefaultEnvironments(requirements, nil)
+
+	funcMap, err := o.createFuncMap(requirements)
+	if err != nil {
+		return nil, err
+	}
+	chartValues, params, err := helm.GenerateValues(requirements, funcMap, dir, nil, true, secretURLClient)
+	if err != nil {
+		return nil, errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
+	}
+	if (o.ProviderValuesDir != "" || o.ProviderValuesConfigMap != "") && requirementsFileName != "" {
+		chartValues, err = o.overwriteProviderValues(requirements, requirementsFileName, chartValues, params, o.ProviderValuesDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
+		}
+	}
+	if o.Boot {
+		err = o.replaceMissingVersionsFromVersionStream(requirements, dir, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to replace missing versions in the requirements.yaml in dir %s", dir)
+		}
+	}
+
+	chartValuesFile := filepath.Join(dir, helm.ValuesFileName)
+	err = ioutil.WriteFile(chartValuesFile, chartValues, util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing values.yaml for tree to %s", chartValuesFile)
+	}
+	valuesFiles, err := o.discoverValuesFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = o.validateValuesSchema(dir, valuesFiles)
+	if err != nil {
+		return nil, err
+	}
+	return valuesFiles, nil
+}
+
+// templateSecretURLClient returns the client resolving the secret URIs of the values without accessing Vault or the
+// cluster. Returns nil if the secrets are stored in Vault or their location would be detected using the cluster
+func (o *StepHelmTemplateOptions) templateSecretURLClient(requirements *config.RequirementsConfig, dir string) (secreturl.Client, error) {
+	location := secrets.ToSecretsLocation(string(requirements.SecretStorage))
+	switch location {
+	case secrets.VaultLocationKind, secrets.KubeLocationKind, secrets.AutoLocationKind:
+		log.Logger().Infof("not resolving the %s secret URIs as the chart is rendered without accessing Vault or the cluster", location)
+		return nil, nil
+	}
+	secretURLClient, err := o.GetSecretURLClient(location, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a Secret URL client")
+	}
+	return secretURLClient, nil
+}
+
+// buildDependencies downloads the dependencies of the chart using a client only helm so that the cluster is not used
+func (o *StepHelmTemplateOptions) buildDependencies(dir string) error {
+	h := o.Helm()
+	h.SetCWD(dir)
+	err := h.RemoveRequirementsLock()
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove requirements.lock file from chart '%s'", dir)
+	}
+	err = h.Init(true, "", "", false)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize Helm")
+	}
+	err = o.AddChartRepos(dir, h.HelmBinary(), []string{kube.DefaultChartMuseumURL})
+	if err != nil {
+		return errors.Wrapf(err, "failed to add the chart repositories of chart '%s'", dir)
+	}
+	err = h.BuildDependency()
+	if err != nil {
+		return errors.Wrapf(err, "failed to build the dependencies of chart '%s'", dir)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// +build unit
+
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTemplateOptions(t *testing.T) (*StepHelmTemplateOptions, *bytes.Buffer) {
+	resolver, _ := createTestResolver(t)
+	helmer := helm_test.NewMockHelmer()
+	pegomock.When(helmer.HelmBinary()).ThenReturn("helm")
+	pegomock.When(helmer.ListRepos()).ThenReturn(map[string]string{"jenkins-x": kube.DefaultChartMuseumURL}, nil)
+
+	// lets render the replicaCount of the merged values.yaml into a manifest
+	pegomock.When(helmer.Template(pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyString(), pegomock.AnyBool(),
+		pegomock.AnyStringSlice(), pegomock.AnyStringSlice(), pegomock.AnyStringSlice())).Then(func(params []pegomock.Param) pegomock.ReturnValues {
+		releaseName := params[1].(string)
+		ns := params[2].(string)
+		outDir := params[3].(string)
+		valuesFiles := params[7].([]string)
+		values, err := helm.MergeValuesFiles(valuesFiles...)
+		require.NoError(t, err)
+		manifest := fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: %s\n  namespace: %s\nspec:\n  replicas: %v\n", releaseName, ns, values["replicaCount"])
+		dir := filepath.Join(outDir, "env", "templates")
+		require.NoError(t, os.MkdirAll(dir, util.DefaultWritePermissions))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(manifest), util.DefaultWritePermissions))
+		return []pegomock.ReturnValue{nil}
+	})
+
+	out := &bytes.Buffer{}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.Out = out
+	commonOpts.SetHelm(helmer)
+
+	o := &StepHelmTemplateOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			Dir: filepath.Join("test_data", "diff_values", "staging"),
+		},
+		Namespace:   "jx-staging",
+		ReleaseName: "jx-staging",
+	}
+	o.versionResolver = resolver
+	return o, out
+}
+
+func TestStepHelmTemplate(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	o, out := createTestTemplateOptions(t)
+	valuesFile := filepath.Join(o.Dir, helm.ValuesFileName)
+	original, err := ioutil.ReadFile(valuesFile)
+	require.NoError(t, err)
+
+	err = o.Run()
+	require.NoError(t, err)
+
+	resources, err := parseManifests(out.String(), o.Namespace)
+	require.NoError(t, err)
+	deployment := resources["Deployment/jx-staging/jx-staging"]
+	require.NotNil(t, deployment, "should have written the rendered Deployment to the console but got %s", out.String())
+	assert.Equal(t, map[string]interface{}{"replicas": float64(1)}, deployment.object["spec"])
+
+	data, err := ioutil.ReadFile(valuesFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(data), "should not have modified the source directory")
+}
+
+func TestStepHelmTemplateOutputDir(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	outDir, err := ioutil.TempDir("", "test-step-helm-template-")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+
+	o, out := createTestTemplateOptions(t)
+	o.OutputDir = outDir
+	err = o.Run()
+	require.NoError(t, err)
+
+	assert.Empty(t, out.String(), "should not have written the manifests to the console")
+	assert.FileExists(t, filepath.Join(outDir, "env", "templates", "deployment.yaml"))
+}
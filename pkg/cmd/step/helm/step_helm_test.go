@@ -0,0 +1,1118 @@
+// +build unit
+
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	mocks "github.com/jenkins-x/jx/v2/pkg/util/mocks"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/helm/pkg/chartutil"
+)
+
+const testChartRepository = "http://chartmuseum.jenkins-x.io"
+
+func createTestResolver(t *testing.T) (*versionstream.VersionResolver, *versionstream.RepositoryPrefixes) {
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: path.Join("test_data", "version_stream"),
+	}
+	prefixes, err := resolver.GetRepositoryPrefixes()
+	require.NoError(t, err, "failed to load the repository prefixes")
+	return resolver, prefixes
+}
+
+// createTestChartRepository serves the chart repository in the test_data/chart_repo directory
+func createTestChartRepository() *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir(path.Join("test_data", "chart_repo"))))
+}
+
+func createTestPrefixes(repoURL string) *versionstream.RepositoryPrefixes {
+	return &versionstream.RepositoryPrefixes{
+		Repositories: []versionstream.RepositoryURLs{
+			{
+				Prefix: "jenkins-x",
+				URLs:   []string{repoURL},
+			},
+		},
+	}
+}
+
+// writeTestRequirements writes a requirements.yaml file with the given dependencies into a new temporary directory
+func writeTestRequirements(t *testing.T, deps ...*helm.Dependency) (string, string) {
+	dir, err := ioutil.TempDir("", "test-step-helm-")
+	require.NoError(t, err)
+
+	fileName := filepath.Join(dir, helm.RequirementsFileName)
+	saveTestRequirements(t, fileName, deps...)
+	return dir, fileName
+}
+
+func saveTestRequirements(t *testing.T, fileName string, deps ...*helm.Dependency) {
+	err := os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	err = helm.SaveFile(fileName, &helm.Requirements{Dependencies: deps})
+	require.NoError(t, err, "failed to save %s", fileName)
+}
+
+func assertDependencyVersion(t *testing.T, fileName string, name string, expected string) {
+	req, err := helm.LoadRequirementsFile(fileName)
+	require.NoError(t, err, "failed to load %s", fileName)
+	for _, dep := range req.Dependencies {
+		if dep.Name == name {
+			assert.Equal(t, expected, dep.Version, "version of dependency %s in file %s", name, fileName)
+			return
+		}
+	}
+	assert.Fail(t, "missing dependency", "no dependency %s in file %s", name, fileName)
+}
+
+func TestVerifyRequirementsYAMLDeniedVersion(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		DenyListFile: path.Join("test_data", "deny_list.yaml"),
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err, "should have failed to resolve a denied version")
+	assert.Contains(t, err.Error(), "deny list")
+
+	assertDependencyVersion(t, fileName, "foo", "")
+}
+
+func TestVerifyRequirementsYAMLNotDeniedVersion(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "bar", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		DenyListFile: path.Join("test_data", "deny_list.yaml"),
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+}
+
+func TestDenyListFallbackVersion(t *testing.T) {
+	t.Parallel()
+
+	denyList, err := versionstream.LoadDenyList(path.Join("test_data", "deny_list.yaml"))
+	require.NoError(t, err)
+
+	index, err := helm.LoadChartIndex([]byte(`entries:
+  foo:
+  - version: 1.3.0
+  - version: 1.2.3
+  - version: 1.2.2
+  - version: 1.2.3-beta.1
+  - version: 1.1.0
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2.2", denyList.FallbackVersion("jenkins-x/foo", "1.2.3", versionStrings(index, "foo")))
+
+	index, err = helm.LoadChartIndex([]byte(`entries:
+  foo:
+  - version: v1.2.3
+  - version: v1.2.2
+  - version: v1.1.0
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.2.2", denyList.FallbackVersion("jenkins-x/foo", "1.2.3", versionStrings(index, "foo")), "the fallback should keep the version as written in the chart repository")
+}
+
+func versionStrings(index *helm.ChartIndex, name string) []string {
+	answer := []string{}
+	for _, entry := range index.Entries[name] {
+		answer = append(answer, entry.Version)
+	}
+	return answer
+}
+
+func TestApplySetJSONValues(t *testing.T) {
+	t.Parallel()
+
+	o := &StepHelmApplyOptions{
+		SetJSON: []string{`expose.hosts=["a.com","b.com"]`, `expose.config={"tls":{"enabled":true}}`},
+	}
+	data, err := o.applySetJSONValues([]byte("expose:\n  port: 80\n  config:\n    http: true\n"))
+	require.NoError(t, err)
+
+	values, err := helm.LoadValues(data)
+	require.NoError(t, err)
+	assert.Equal(t, float64(80), util.GetMapValueViaPath(values, "expose.port"))
+	assert.Equal(t, []interface{}{"a.com", "b.com"}, util.GetMapValueViaPath(values, "expose.hosts"))
+	assert.Equal(t, map[string]interface{}{"tls": map[string]interface{}{"enabled": true}}, util.GetMapValueViaPath(values, "expose.config"))
+}
+
+func TestConfigureHelmBinary(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	dir, err := ioutil.TempDir("", "test-helm-binary-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helmBinary := filepath.Join(dir, "helm-2.16.3")
+	err = ioutil.WriteFile(helmBinary, []byte("#!/bin/sh\n"), 0755)
+	require.NoError(t, err)
+
+	runner := mocks.NewMockCommander()
+	pegomock.When(runner.RunWithoutRetry()).ThenReturn("Client: v2.16.3+g1ee0254", nil)
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetHelm(helm.NewHelmCLIWithRunner(runner, "helm", helm.V2, "", false, nil))
+
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		HelmBinary: helmBinary,
+	}
+	err = o.configureHelmBinary()
+	require.NoError(t, err)
+
+	assert.Equal(t, helmBinary, o.Helm().HelmBinary())
+	runner.VerifyWasCalledOnce().SetName(helmBinary)
+}
+
+func TestConfigureHelmBinaryNotExecutable(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-binary-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helmBinary := filepath.Join(dir, "helm")
+	err = ioutil.WriteFile(helmBinary, []byte("not a binary"), 0644)
+	require.NoError(t, err)
+
+	o := &StepHelmOptions{
+		HelmBinary: helmBinary,
+	}
+	err = o.configureHelmBinary()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not an executable")
+}
+
+func TestVerifyRequirementsYAMLSuggestOnMiss(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "nginx", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "Did you mean")
+
+	o.SuggestOnMiss = true
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Did you mean one of: jenkins-x/nginx-ingress")
+}
+
+func TestVerifyRequirementsYAMLResolvedOutput(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+	original, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+
+	o := &StepHelmOptions{
+		ResolvedOutput: "requirements.resolved.yaml",
+	}
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	actual, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(actual), "the input file %s should not be modified", fileName)
+
+	assertDependencyVersion(t, filepath.Join(dir, "requirements.resolved.yaml"), "foo", "1.2.3")
+}
+
+func TestVerifyRequirementsYAMLCheckKubeVersion(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+	resolver, _ := createTestResolver(t)
+
+	testData := map[string]bool{
+		"v1.15.3-gke.1": false,
+		"v1.16.0":       true,
+	}
+	for gitVersion, expectedValid := range testData {
+		dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: server.URL})
+		defer os.RemoveAll(dir)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: gitVersion}
+		commonOpts := opts.NewCommonOptionsWithFactory(nil)
+		commonOpts.SetKubeClient(kubeClient)
+
+		o := &StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			CheckKubeVersion: true,
+		}
+		err := o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+		if expectedValid {
+			require.NoError(t, err, "cluster version %s", gitVersion)
+			assertDependencyVersion(t, fileName, "foo", "1.2.3")
+		} else {
+			require.Error(t, err, "cluster version %s", gitVersion)
+			assert.Contains(t, err.Error(), "requires kubeVersion >=1.16.0")
+		}
+	}
+}
+
+func TestFetchChartIndexIsCached(t *testing.T) {
+	t.Parallel()
+
+	fetches := 0
+	fileServer := http.FileServer(http.Dir(path.Join("test_data", "chart_repo")))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: server.URL},
+		&helm.Dependency{Name: "bar", Repository: server.URL})
+	defer os.RemoveAll(dir)
+
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.16.0"}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetKubeClient(kubeClient)
+
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		CheckKubeVersion: true,
+	}
+	err := o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "foo", "1.2.3")
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+	assert.Equal(t, 1, fetches, "the chart repository index should only be fetched once")
+}
+
+func TestVerifyRequirementsYAMLGitHubReleases(t *testing.T) {
+	t.Parallel()
+
+	authorization := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/acme/charts/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+  {"tag_name": "mychart-1.3.0-rc.1", "prerelease": true},
+  {"tag_name": "mychart-1.2.0"},
+  {"tag_name": "mychart-1.10.0"},
+  {"tag_name": "other-9.0.0"}
+]`))
+	}))
+	defer server.Close()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "mychart", Repository: "https://github.com/acme/charts"})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		GitHubURL:   server.URL,
+		GitHubToken: "my-token",
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "mychart", "1.10.0")
+	assert.Equal(t, "Bearer my-token", authorization, "should have used the GitHub token")
+}
+
+func TestParseGitHubRepository(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string][]string{
+		"github.com/acme/charts":             {"acme", "charts"},
+		"https://github.com/acme/charts/":    {"acme", "charts"},
+		"https://github.com/acme/charts.git": {"acme", "charts"},
+		"http://chartmuseum.jenkins-x.io":    nil,
+		"https://github.com/acme":            nil,
+	}
+	for repository, expected := range testCases {
+		owner, name, ok := parseGitHubRepository(repository)
+		if expected == nil {
+			assert.False(t, ok, "should not parse %s as a GitHub repository", repository)
+			continue
+		}
+		if assert.True(t, ok, "should parse %s as a GitHub repository", repository) {
+			assert.Equal(t, expected, []string{owner, name}, "parsing %s", repository)
+		}
+	}
+}
+
+func TestDiscoverValuesFilesValidatesYAML(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-validate-values-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte("foo:\n  bar: 1\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	myValuesFile := filepath.Join(dir, "myvalues.yaml")
+	err = ioutil.WriteFile(myValuesFile, []byte("foo:\n  bar: 1\n    baz: [\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	o := &StepHelmOptions{}
+	valuesFiles, err := o.discoverValuesFiles(dir)
+	require.NoError(t, err, "should not validate the values files by default")
+	assert.Len(t, valuesFiles, 2)
+
+	o.ValidateValues = true
+	_, err = o.discoverValuesFiles(dir)
+	require.Error(t, err, "should have failed to validate the malformed values file")
+	assert.Contains(t, err.Error(), myValuesFile)
+	assert.NotContains(t, err.Error(), filepath.Join(dir, "values.yaml"))
+}
+
+func TestVerifyRequirementsYAMLVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "baz", Repository: server.URL})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{}
+	err := o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "baz", "1.5.0")
+
+	// the version should be written as the chart repository index lists it so that helm can find it
+	dir2, fileName2 := writeTestRequirements(t, &helm.Dependency{Name: "qux", Repository: server.URL})
+	defer os.RemoveAll(dir2)
+	err = o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName2)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName2, "qux", "v1.2.3")
+}
+
+func TestIsVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]bool{
+		"1.2.3":       false,
+		"v1.2.3":      false,
+		"1.2.3-rc.1":  false,
+		">=1.2 <2":    true,
+		"~1.2":        true,
+		"^2.0.0":      true,
+		"1.2.x":       true,
+		"not-a-range": false,
+	}
+	for version, expected := range testCases {
+		assert.Equal(t, expected, isVersionConstraint(version), "isVersionConstraint(%s)", version)
+	}
+}
+
+func TestVerifyRequirementsYAMLOnMissingError(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "missing", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		OnMissing: OnMissingError,
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to find a version for dependency missing")
+}
+
+func TestVerifyRequirementsYAMLOnMissingSkip(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "missing", Repository: testChartRepository},
+		&helm.Dependency{Name: "bar", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		OnMissing: OnMissingSkip,
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "missing", "")
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+}
+
+func TestVerifyRequirementsYAMLOnMissingPrompt(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "missing", Repository: testChartRepository},
+		&helm.Dependency{Name: "other", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	prompts := &bytes.Buffer{}
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &opts.CommonOptions{Err: prompts},
+		},
+		OnMissing:     OnMissingPrompt,
+		versionPrompt: bufio.NewReader(strings.NewReader("1.0.0\n 2.0.0 \n")),
+	}
+	require.NoError(t, o.validateOnMissing())
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "missing", "1.0.0")
+	assertDependencyVersion(t, fileName, "other", "2.0.0")
+	assert.Contains(t, prompts.String(), "Enter the version of dependency missing")
+}
+
+func TestValidateOnMissing(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []string{"", OnMissingError, OnMissingSkip} {
+		o := &StepHelmOptions{OnMissing: mode}
+		assert.NoError(t, o.validateOnMissing(), "mode %q should be valid", mode)
+	}
+
+	o := &StepHelmOptions{OnMissing: "ignore"}
+	assert.Error(t, o.validateOnMissing())
+
+	o = &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		OnMissing: OnMissingPrompt,
+	}
+	err := o.validateOnMissing()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "terminal")
+}
+
+func TestVerifyRequirementsYAMLResolveWorkers(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+
+	resolver, _ := createTestResolver(t)
+	deps := []*helm.Dependency{
+		{Name: "foo", Repository: server.URL},
+		{Name: "bar", Repository: server.URL},
+		{Name: "baz", Repository: server.URL},
+		{Name: "foo", Alias: "other-foo", Repository: server.URL},
+	}
+	dir, fileName := writeTestRequirements(t, deps...)
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		ResolveWorkers: 3,
+		report:         NewResolutionReport(dir),
+	}
+	err := o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "foo", "1.2.3")
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+	assertDependencyVersion(t, fileName, "baz", "1.5.0")
+
+	require.Len(t, o.report.Files, 1)
+	names := []string{}
+	for _, resolution := range o.report.Files[0].Dependencies {
+		names = append(names, resolution.Name)
+	}
+	assert.Equal(t, []string{"foo", "bar", "baz", "other-foo"}, names, "the report should be in the order of the dependencies")
+
+	dir, fileName = writeTestRequirements(t,
+		&helm.Dependency{Name: "missing", Repository: server.URL},
+		&helm.Dependency{Name: "foo", Repository: server.URL},
+		&helm.Dependency{Name: "other", Repository: server.URL})
+	defer os.RemoveAll(dir)
+
+	o = &StepHelmOptions{
+		ResolveWorkers: 3,
+	}
+	err = o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to find a version for dependency missing")
+	assert.Contains(t, err.Error(), "failed to find a version for dependency other")
+	assertDependencyVersion(t, fileName, "foo", "")
+}
+
+func TestVerifyRequirementsYAMLNameTransforms(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "mirror-foo", Repository: testChartRepository},
+		&helm.Dependency{Name: "mirror-legacy-bar", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err, "should fail to resolve the mirrored chart names without a transform")
+
+	o.NameTransforms = path.Join("test_data", "name_transforms.yaml")
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "mirror-foo", "1.2.3")
+	assertDependencyVersion(t, fileName, "mirror-legacy-bar", "2.0.0")
+}
+
+func TestVerifyStrictMerge(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-strict-merge-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	valuesFile := filepath.Join(dir, helm.ValuesFileName)
+	err = ioutil.WriteFile(valuesFile, []byte("expose:\n  config:\n    domain: acme.com\n    tls: true\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	myValuesFile := filepath.Join(dir, "myvalues.yaml")
+	err = ioutil.WriteFile(myValuesFile, []byte("expose:\n  config:\n    domain: null\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	o := &StepHelmApplyOptions{
+		StrictMerge: true,
+	}
+	err = o.verifyStrictMerge(valuesFile, []string{valuesFile})
+	require.NoError(t, err)
+
+	err = o.verifyStrictMerge(valuesFile, []string{valuesFile, myValuesFile})
+	require.Error(t, err, "should fail when an override nulls a base key")
+	assert.Contains(t, err.Error(), "expose.config.domain")
+	assert.NotContains(t, err.Error(), "expose.config.tls")
+}
+
+func TestReplaceMissingVersionsInTransitiveSubCharts(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: testChartRepository},
+		&helm.Dependency{Name: "sub", Repository: "file://charts/sub", Version: "0.1.0"})
+	defer os.RemoveAll(dir)
+	subFileName := filepath.Join(dir, "charts", "sub", helm.RequirementsFileName)
+	saveTestRequirements(t, subFileName,
+		&helm.Dependency{Name: "bar", Repository: testChartRepository},
+		&helm.Dependency{Name: "subsub", Repository: "file://charts/subsub", Version: "0.2.0"})
+	subSubFileName := filepath.Join(dir, "charts", "sub", "charts", "subsub", helm.RequirementsFileName)
+	saveTestRequirements(t, subSubFileName,
+		&helm.Dependency{Name: "nginx-ingress", Repository: testChartRepository})
+
+	o := &StepHelmOptions{
+		Dir:             dir,
+		versionResolver: resolver,
+	}
+	err := o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, true)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "foo", "1.2.3")
+	assertDependencyVersion(t, fileName, "sub", "0.1.0")
+	assertDependencyVersion(t, subFileName, "bar", "2.0.0")
+	assertDependencyVersion(t, subFileName, "subsub", "0.2.0")
+	assertDependencyVersion(t, subSubFileName, "nginx-ingress", "1.26.2")
+}
+
+func TestParseCompositeChartReference(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		text          string
+		fullChartName string
+		chartName     string
+		ok            bool
+	}{
+		{text: "internal/team-a@service", fullChartName: "internal/team-a/service", chartName: "service", ok: true},
+		{text: "internal/team-a/backend@service", fullChartName: "internal/team-a/backend/service", chartName: "service", ok: true},
+		{text: "internal@service", fullChartName: "internal/service", chartName: "service", ok: true},
+		{text: "service"},
+		{text: "@service"},
+		{text: "internal/team-a@"},
+		{text: "internal//team-a@service"},
+		{text: "internal@team-a/service"},
+	}
+	for _, tc := range testCases {
+		fullChartName, chartName, ok := parseCompositeChartReference(tc.text)
+		assert.Equal(t, tc.ok, ok, "parsing %s", tc.text)
+		assert.Equal(t, tc.fullChartName, fullChartName, "full chart name parsing %s", tc.text)
+		assert.Equal(t, tc.chartName, chartName, "chart name parsing %s", tc.text)
+	}
+}
+
+func TestVerifyRequirementsYAMLCompositeReference(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "internal/team-a@service", Repository: "http://charts.example.com"},
+		&helm.Dependency{Name: "foo", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.NoError(t, err)
+
+	assertDependencyVersion(t, fileName, "internal/team-a@service", "3.1.0")
+	assertDependencyVersion(t, fileName, "foo", "1.2.3")
+}
+
+func TestVerifyRequirementsYAMLBlankPrefix(t *testing.T) {
+	t.Parallel()
+
+	versionsDir, err := ioutil.TempDir("", "test-blank-prefix-")
+	require.NoError(t, err)
+	defer os.RemoveAll(versionsDir)
+	for _, name := range []string{"foo", "bar"} {
+		chartFile := filepath.Join(versionsDir, string(versionstream.KindChart), name+".yml")
+		err = os.MkdirAll(filepath.Dir(chartFile), util.DefaultWritePermissions)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(chartFile, []byte("version: 1.0.0\n"), util.DefaultWritePermissions)
+		require.NoError(t, err)
+	}
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: versionsDir,
+	}
+	prefixes := &versionstream.RepositoryPrefixes{
+		Repositories: []versionstream.RepositoryURLs{
+			{
+				Prefix: "",
+				URLs:   []string{testChartRepository},
+			},
+		},
+	}
+
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: testChartRepository},
+		&helm.Dependency{Name: "bar", Repository: testChartRepository})
+	defer os.RemoveAll(dir)
+	original, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+
+	o := &StepHelmOptions{}
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blank repository prefix")
+
+	actual, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(actual), "the requirements file should not have been saved")
+
+	// a single chart resolved via a blank prefix is not suspicious
+	dir2, fileName2 := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: testChartRepository})
+	defer os.RemoveAll(dir2)
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName2)
+	require.NoError(t, err)
+	assertDependencyVersion(t, fileName2, "foo", "1.0.0")
+}
+
+func TestIsBlankPrefix(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isBlankPrefix(""))
+	assert.True(t, isBlankPrefix("/"))
+	assert.True(t, isBlankPrefix(" "))
+	assert.True(t, isBlankPrefix("//"))
+	assert.False(t, isBlankPrefix("jenkins-x"))
+	assert.False(t, isBlankPrefix("/stable"))
+}
+
+func TestVerifyRequirementsYAMLPinPolicy(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+	resolver, _ := createTestResolver(t)
+	prefixes := createTestPrefixes(server.URL)
+
+	testCases := []struct {
+		policy   string
+		versions map[string]string
+		expected map[string]string
+	}{
+		{
+			policy:   PinPolicyExact,
+			versions: map[string]string{"foo": "", "bar": "2.0.0"},
+			expected: map[string]string{"foo": "1.2.3", "bar": "2.0.0"},
+		},
+		{
+			policy:   PinPolicyPatch,
+			versions: map[string]string{"foo": "1.2.0", "bar": "2.0.0"},
+			expected: map[string]string{"foo": "1.2.3", "bar": "2.0.0"},
+		},
+		{
+			policy:   PinPolicyMinor,
+			versions: map[string]string{"foo": "1.1.0", "bar": "2.0.0"},
+			expected: map[string]string{"foo": "1.3.0", "bar": "2.1.0"},
+		},
+	}
+	for _, tc := range testCases {
+		dir, fileName := writeTestRequirements(t,
+			&helm.Dependency{Name: "foo", Repository: server.URL, Version: tc.versions["foo"]},
+			&helm.Dependency{Name: "bar", Repository: server.URL, Version: tc.versions["bar"]})
+		defer os.RemoveAll(dir)
+
+		o := &StepHelmOptions{
+			PinPolicy: tc.policy,
+		}
+		err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+		require.NoError(t, err, "pin policy %s", tc.policy)
+		for name, version := range tc.expected {
+			assertDependencyVersion(t, fileName, name, version)
+		}
+	}
+}
+
+func TestVerifyRequirementsYAMLPinPolicyErrors(t *testing.T) {
+	t.Parallel()
+
+	server := createTestChartRepository()
+	defer server.Close()
+	resolver, _ := createTestResolver(t)
+	prefixes := createTestPrefixes(server.URL)
+
+	dir, fileName := writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: server.URL})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		PinPolicy: PinPolicyPatch,
+	}
+	err := o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "as it has no version")
+
+	o.PinPolicy = "latest"
+	err = o.verifyRequirementsYAML(resolver, prefixes, fileName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--pin-policy")
+}
+
+func TestReplaceMissingVersionsSkipsResolverWhenPinned(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: testChartRepository, Version: "1.0.0"},
+		&helm.Dependency{Name: "bar", Repository: testChartRepository, Version: "2.0.0"})
+	defer os.RemoveAll(dir)
+
+	created := 0
+	o := &StepHelmOptions{
+		Dir: dir,
+		versionResolverFactory: func(url string, ref string) (*versionstream.VersionResolver, error) {
+			created++
+			return resolver, nil
+		},
+	}
+	err := o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, created, "the version resolver should not be created when every dependency has a version")
+	assertDependencyVersion(t, fileName, "foo", "1.0.0")
+
+	o.ForceRewrite = true
+	err = o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created, "the version resolver should be created when forcing a rewrite")
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+}
+
+func TestFetchChartIndexViaProxy(t *testing.T) {
+	t.Parallel()
+
+	proxied := []string{}
+	index, err := ioutil.ReadFile(filepath.Join("test_data", "chart_repo", "index.yaml"))
+	require.NoError(t, err)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write(index)
+	}))
+	defer proxy.Close()
+
+	o := &StepHelmOptions{
+		Proxy: proxy.URL,
+	}
+	chartIndex, err := o.fetchChartIndex("http://charts.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://charts.example.com/index.yaml"}, proxied, "the requests should be routed via the proxy")
+	assert.NotNil(t, chartIndex.Entry("foo", "1.2.3"), "should have loaded the index via the proxy")
+
+	o = &StepHelmOptions{
+		Proxy: "not-a-url",
+	}
+	_, err = o.fetchChartIndex("http://charts.example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--proxy")
+}
+
+func TestAuditPrefixes(t *testing.T) {
+	t.Parallel()
+
+	resolver, prefixes := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: testChartRepository, Version: "1.0.0"},
+		&helm.Dependency{Name: "cheese", Repository: "https://charts.example.com", Version: "0.1.0"},
+		&helm.Dependency{Name: "wine", Repository: "https://charts.example.com", Version: "0.2.0"},
+		&helm.Dependency{Name: "local", Repository: "file://charts/local", Version: "0.3.0"})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		Dir:             dir,
+		AuditPrefixes:   true,
+		versionResolver: resolver,
+	}
+	missing, err := o.auditRepositoryPrefixes(prefixes, []string{fileName})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://charts.example.com"}, missing)
+
+	err = o.replaceMissingVersionsFromVersionStream(config.NewRequirementsConfig(), dir, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the helm repositories https://charts.example.com do not have a prefix")
+}
+
+func TestVerifyRequirementsYAMLWarnStale(t *testing.T) {
+	server := createTestChartRepository()
+	defer server.Close()
+
+	resolver, _ := createTestResolver(t)
+	dir, fileName := writeTestRequirements(t,
+		&helm.Dependency{Name: "foo", Repository: server.URL},
+		&helm.Dependency{Name: "bar", Repository: server.URL})
+	defer os.RemoveAll(dir)
+
+	o := &StepHelmOptions{
+		WarnStale: 1,
+	}
+	var err error
+	output := log.CaptureOutput(func() {
+		err = o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	})
+	require.NoError(t, err)
+	assertDependencyVersion(t, fileName, "bar", "2.0.0")
+	assert.Contains(t, output, "chart jenkins-x/bar version 2.0.0 is behind the latest major version 3.0.0")
+	assert.NotContains(t, output, "chart jenkins-x/foo", "foo is only 1 minor version behind")
+
+	dir, fileName = writeTestRequirements(t, &helm.Dependency{Name: "foo", Repository: server.URL})
+	defer os.RemoveAll(dir)
+	o.WarnStale = 0
+	output = log.CaptureOutput(func() {
+		err = o.verifyRequirementsYAML(resolver, createTestPrefixes(server.URL), fileName)
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, output, "behind the latest", "the stale check should be disabled")
+}
+
+func TestWarnStaleVersionMinor(t *testing.T) {
+	server := createTestChartRepository()
+	defer server.Close()
+
+	o := &StepHelmOptions{
+		WarnStale: 1,
+	}
+	testCases := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{name: "baz", version: "2.0.0"},
+		{name: "foo", version: "1.2.3"},
+		{name: "foo", version: "1.1.0", expected: "chart jenkins-x/foo version 1.1.0 is 2 minor versions behind the latest version 1.3.0"},
+	}
+	for _, tc := range testCases {
+		dep := &helm.Dependency{Name: tc.name, Repository: server.URL}
+		var err error
+		output := log.CaptureOutput(func() {
+			err = o.warnStaleVersion(dep, "jenkins-x/"+tc.name, tc.version)
+		})
+		require.NoError(t, err, "chart %s version %s", tc.name, tc.version)
+		if tc.expected == "" {
+			assert.NotContains(t, output, "behind the latest", "chart %s version %s", tc.name, tc.version)
+		} else {
+			assert.Contains(t, output, tc.expected)
+		}
+	}
+}
+
+func TestOverwriteProviderValuesFromConfigMap(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "provider-values",
+			Namespace: "jx-system",
+		},
+		Data: map[string]string{
+			"values.tmpl.yaml": "cluster:\n  provider: {{ .Requirements.cluster.provider }}\nfoo: overridden\n",
+		},
+	})
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetKubeClient(kubeClient)
+
+	resolver, _ := createTestResolver(t)
+	o := &StepHelmOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		ProviderValuesConfigMap: "jx-system/provider-values:values.tmpl.yaml",
+		versionResolver:         resolver,
+	}
+	requirements := config.NewRequirementsConfig()
+	requirements.Cluster.Provider = "gke"
+
+	missingDir, err := ioutil.TempDir("", "test-provider-values-")
+	require.NoError(t, err)
+	defer os.RemoveAll(missingDir)
+
+	data, err := o.overwriteProviderValues(requirements, config.RequirementsConfigFileName, []byte("foo: bar\nbar: baz\n"), chartutil.Values{}, missingDir)
+	require.NoError(t, err)
+	values, err := helm.LoadValues(data)
+	require.NoError(t, err)
+	assert.Equal(t, "overridden", values["foo"])
+	assert.Equal(t, "baz", values["bar"])
+	assert.Equal(t, map[string]interface{}{"provider": "gke"}, values["cluster"])
+
+	o.ProviderValuesConfigMap = "jx-system/provider-values:missing"
+	_, err = o.overwriteProviderValues(requirements, config.RequirementsConfigFileName, []byte("foo: bar\n"), chartutil.Values{}, missingDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no key missing")
+
+	o.ProviderValuesConfigMap = "provider-values"
+	_, err = o.overwriteProviderValues(requirements, config.RequirementsConfigFileName, []byte("foo: bar\n"), chartutil.Values{}, missingDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--provider-values-configmap")
+}
+
+func TestOverwriteProviderValuesHierarchy(t *testing.T) {
+	t.Parallel()
+
+	resolver, _ := createTestResolver(t)
+	o := &StepHelmOptions{
+		versionResolver: resolver,
+	}
+	requirements := config.NewRequirementsConfig()
+	requirements.Cluster.Provider = "eks"
+	requirements.Cluster.ProviderValuesHierarchy = []string{"kubernetes", "${provider}", "${provider}-${region}"}
+	providerValuesDir := filepath.Join("test_data", "provider_values")
+
+	assert.Equal(t, []string{"kubernetes", "eks"}, o.providerValuesLayers(requirements), "should skip layers using a variable without a value")
+
+	requirements.Cluster.Region = "us-east-1"
+	data, err := o.overwriteProviderValues(requirements, config.RequirementsConfigFileName, []byte("foo: bar\nreplicas: 0\n"), chartutil.Values{}, providerValuesDir)
+	require.NoError(t, err)
+	values, err := helm.LoadValues(data)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", values["foo"])
+	assert.Equal(t, "eks", values["provider"], "later layers should override earlier layers")
+	assert.Equal(t, map[string]interface{}{"class": "gp2"}, values["storage"])
+	assert.Equal(t, float64(3), values["replicas"])
+
+	o.ProviderValuesHierarchy = []string{"kubernetes", "missing"}
+	data, err = o.overwriteProviderValues(requirements, config.RequirementsConfigFileName, []byte("foo: bar\n"), chartutil.Values{}, providerValuesDir)
+	require.NoError(t, err)
+	values, err = helm.LoadValues(data)
+	require.NoError(t, err)
+	assert.Equal(t, "kubernetes", values["provider"], "the flag should override the hierarchy in the requirements")
+	assert.Equal(t, float64(1), values["replicas"])
+
+	o.ProviderValuesHierarchy = nil
+	requirements.Cluster.ProviderValuesHierarchy = nil
+	assert.Equal(t, []string{"eks"}, o.providerValuesLayers(requirements), "should default to the provider")
+}
+
+func TestGetChartValuesWithTenancy(t *testing.T) {
+	t.Parallel()
+
+	o := &StepHelmOptions{}
+	requirements := config.NewRequirementsConfig()
+	setValues, setStrings := o.getChartValues("jx-preview-pr-1", requirements)
+	assert.Equal(t, []string{"tags.jx-ns-jx-preview-pr-1=true", "global.jxNsJxPreviewPr1=true"}, setValues, "should not inject guardrails unless tenancy is enabled")
+	assert.Equal(t, []string{"global.jxNs=jx-preview-pr-1"}, setStrings)
+
+	requirements.Tenancy = &config.TenancyConfig{
+		Enabled:       true,
+		NetworkPolicy: &config.NetworkPolicyConfig{DefaultDeny: true, AllowNamespaces: []string{"nginx", "jx"}},
+		ResourceQuota: map[string]string{"requests.cpu": "4", "limits.memory": "8Gi"},
+		Namespaces: []config.TenancyNamespaceConfig{
+			{
+				Name:          "jx-preview-*",
+				ResourceQuota: map[string]string{"requests.cpu": "1"},
+				LimitRange:    &config.LimitRangeConfig{DefaultRequest: map[string]string{"cpu": "100m"}, Max: map[string]string{"memory": "1Gi"}},
+			},
+			{Name: "nginx", Disabled: true},
+		},
+	}
+	setValues, setStrings = o.getChartValues("jx-preview-pr-1", requirements)
+	assert.Equal(t, []string{
+		"tags.jx-ns-jx-preview-pr-1=true",
+		"global.jxNsJxPreviewPr1=true",
+		"global.tenancy.enabled=true",
+		"global.tenancy.networkPolicy.enabled=true",
+		"global.tenancy.networkPolicy.defaultDeny=true",
+		"global.tenancy.networkPolicy.allowNamespaces={nginx,jx}",
+		"global.tenancy.resourceQuota.enabled=true",
+		"global.tenancy.limitRange.enabled=true",
+	}, setValues)
+	assert.Equal(t, []string{
+		"global.jxNs=jx-preview-pr-1",
+		`global.tenancy.resourceQuota.hard.limits\.memory=8Gi`,
+		`global.tenancy.resourceQuota.hard.requests\.cpu=1`,
+		"global.tenancy.limitRange.defaultRequest.cpu=100m",
+		"global.tenancy.limitRange.max.memory=1Gi",
+	}, setStrings, "should override the default guardrails with those of the matching namespaces")
+
+	setValues, _ = o.getChartValues("nginx", requirements)
+	assert.Len(t, setValues, 2, "should not inject guardrails into a disabled namespace")
+}
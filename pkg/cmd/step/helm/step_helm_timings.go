@@ -0,0 +1,184 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// PhaseVersionStream the time spent cloning or updating the version stream
+	PhaseVersionStream = "version-stream"
+	// PhaseResolve the time spent resolving the version of a dependency
+	PhaseResolve = "resolve"
+	// PhaseRender the time spent rendering the values templates and manifests
+	PhaseRender = "render"
+	// PhaseHelm the time spent running helm
+	PhaseHelm = "helm"
+
+	// TimingsMetricName the name of the metric pushed to the --metrics-gateway with the seconds spent in each phase
+	TimingsMetricName = "jx_step_helm_phase_duration_seconds"
+	// TimingsCountMetricName the name of the metric pushed to the --metrics-gateway with the number of times each
+	// phase ran
+	TimingsCountMetricName = "jx_step_helm_phase_count"
+)
+
+// timingPhases the order in which the phases are reported
+var timingPhases = []string{PhaseVersionStream, PhaseResolve, PhaseRender, PhaseHelm}
+
+// PhaseTiming the total time spent in a phase of a step for one subject such as a dependency or release
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+}
+
+// phaseTimings records the time spent in each phase of a step which may run concurrently
+type phaseTimings struct {
+	lock    sync.Mutex
+	timings map[string]*PhaseTiming
+	now     func() time.Time
+}
+
+func newPhaseTimings() *phaseTimings {
+	return &phaseTimings{
+		timings: map[string]*PhaseTiming{},
+		now:     time.Now,
+	}
+}
+
+func (t *phaseTimings) record(phase string, name string, duration time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	key := phase + "/" + name
+	timing := t.timings[key]
+	if timing == nil {
+		timing = &PhaseTiming{Phase: phase, Name: name}
+		t.timings[key] = timing
+	}
+	timing.Count++
+	timing.Duration += duration
+}
+
+// list returns the timings in the order of the phases with the slowest first
+func (t *phaseTimings) list() []PhaseTiming {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	answer := []PhaseTiming{}
+	for _, timing := range t.timings {
+		answer = append(answer, *timing)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		pi := util.StringArrayIndex(timingPhases, answer[i].Phase)
+		pj := util.StringArrayIndex(timingPhases, answer[j].Phase)
+		if pi != pj {
+			return pi < pj
+		}
+		if answer[i].Duration != answer[j].Duration {
+			return answer[i].Duration > answer[j].Duration
+		}
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+// addTimingsFlags adds the flags to report the time spent in each phase of the step
+func (o *StepHelmOptions) addTimingsFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.Timings, "timings", "", false, "Prints a summary table of the time spent cloning the version stream, resolving each dependency, rendering templates and running helm")
+	cmd.Flags().StringVarP(&o.MetricsGateway, "metrics-gateway", "", "", "The URL of the Prometheus pushgateway to push the time spent in each phase of the step to")
+}
+
+// startTiming starts timing the phase for the given subject returning the function which stops it. Does nothing
+// unless the timings are being recorded
+func (o *StepHelmOptions) startTiming(phase string, name string) func() {
+	t := o.timings
+	if t == nil {
+		return func() {}
+	}
+	start := t.now()
+	return func() {
+		t.record(phase, name, t.now().Sub(start))
+	}
+}
+
+// startTimings starts recording the timings if --timings or --metrics-gateway are specified and they are not
+// already being recorded, e.g. by the step applying this release to each cluster. Returns true if they were started
+func (o *StepHelmOptions) startTimings() bool {
+	if o.timings != nil || (!o.Timings && o.MetricsGateway == "") {
+		return false
+	}
+	o.timings = newPhaseTimings()
+	return true
+}
+
+// reportTimings prints the recorded timings and pushes them to the --metrics-gateway. Failures are only logged so that
+// they do not fail the step
+func (o *StepHelmOptions) reportTimings(job string) {
+	if o.timings == nil {
+		return
+	}
+	timings := o.timings.list()
+	if o.Timings {
+		table := o.CreateTable()
+		table.AddRow("PHASE", "NAME", "COUNT", "DURATION")
+		for _, timing := range timings {
+			table.AddRow(timing.Phase, timing.Name, fmt.Sprintf("%d", timing.Count), timing.Duration.Round(time.Millisecond).String())
+		}
+		table.Render()
+	}
+	if o.MetricsGateway != "" {
+		err := o.pushTimings(job, timings)
+		if err != nil {
+			log.Logger().Warnf("failed to push the timings to %s: %s", o.MetricsGateway, err.Error())
+		}
+	}
+}
+
+// pushTimings replaces the metrics of the job in the Prometheus pushgateway with the timings
+func (o *StepHelmOptions) pushTimings(job string, timings []PhaseTiming) error {
+	var buffer strings.Builder
+	fmt.Fprintf(&buffer, "# HELP %s The seconds spent in each phase of the step\n# TYPE %s gauge\n", TimingsMetricName, TimingsMetricName)
+	for _, timing := range timings {
+		fmt.Fprintf(&buffer, "%s{phase=\"%s\",name=\"%s\"} %g\n", TimingsMetricName, escapeMetricLabel(timing.Phase), escapeMetricLabel(timing.Name), timing.Duration.Seconds())
+	}
+	fmt.Fprintf(&buffer, "# HELP %s The number of times each phase of the step ran\n# TYPE %s gauge\n", TimingsCountMetricName, TimingsCountMetricName)
+	for _, timing := range timings {
+		fmt.Fprintf(&buffer, "%s{phase=\"%s\",name=\"%s\"} %d\n", TimingsCountMetricName, escapeMetricLabel(timing.Phase), escapeMetricLabel(timing.Name), timing.Count)
+	}
+
+	pushURL := strings.TrimSuffix(o.MetricsGateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, pushURL, strings.NewReader(buffer.String()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to %s", pushURL)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	httpClient, err := o.getHTTPClient()
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push the metrics to %s", pushURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("failed to push the metrics to %s: status %s", pushURL, resp.Status)
+	}
+	log.Logger().Debugf("pushed %d timings to %s", len(timings), pushURL)
+	return nil
+}
+
+// escapeMetricLabel escapes the value of a label in the Prometheus text format
+func escapeMetricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
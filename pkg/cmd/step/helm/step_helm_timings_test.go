@@ -0,0 +1,65 @@
+// +build unit
+
+package helm
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseTimings(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	timings := newPhaseTimings()
+	timings.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	o := &StepHelmOptions{}
+	o.startTiming(PhaseHelm, "install jx")()
+	assert.Nil(t, o.timings, "should not record timings unless they are enabled")
+
+	o.timings = timings
+	o.startTiming(PhaseHelm, "install jx")()
+	o.startTiming(PhaseResolve, "exposecontroller")()
+	o.startTiming(PhaseResolve, "exposecontroller")()
+	stop := o.startTiming(PhaseResolve, "jenkins-x-platform")
+	o.startTiming(PhaseVersionStream, "https://github.com/jenkins-x/jenkins-x-versions.git")()
+	stop()
+
+	assert.Equal(t, []PhaseTiming{
+		{Phase: PhaseVersionStream, Name: "https://github.com/jenkins-x/jenkins-x-versions.git", Count: 1, Duration: time.Second},
+		{Phase: PhaseResolve, Name: "jenkins-x-platform", Count: 1, Duration: 3 * time.Second},
+		{Phase: PhaseResolve, Name: "exposecontroller", Count: 2, Duration: 2 * time.Second},
+		{Phase: PhaseHelm, Name: "install jx", Count: 1, Duration: time.Second},
+	}, timings.list())
+}
+
+func TestPushTimings(t *testing.T) {
+	t.Parallel()
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/metrics/job/jx-step-helm-apply", r.URL.Path)
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	o := &StepHelmOptions{MetricsGateway: server.URL + "/"}
+	err := o.pushTimings("jx-step-helm-apply", []PhaseTiming{
+		{Phase: PhaseResolve, Name: `say "hi"`, Count: 2, Duration: 1500 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, body, `jx_step_helm_phase_duration_seconds{phase="resolve",name="say \"hi\""} 1.5`)
+	assert.Contains(t, body, `jx_step_helm_phase_count{phase="resolve",name="say \"hi\""} 2`)
+}
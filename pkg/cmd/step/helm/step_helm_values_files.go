@@ -0,0 +1,231 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ValuesCacheDirName the directory in '~/.jx/cache' where remote values files are cached by URL
+	ValuesCacheDirName = "values"
+
+	remoteValuesTimeout = 30 * time.Second
+)
+
+// remoteValuesSchemes the URL schemes of values files which are fetched rather than read from the chart directory
+var remoteValuesSchemes = []string{"http", "https", "s3", "gs", "azblob"}
+
+// ValuesFilesConfigFileName the file in a chart directory declaring the ordered values files used to build and apply it
+var ValuesFilesConfigFileName = filepath.Join(".jx", "valuesFiles.yaml")
+
+// ValuesFilesConfig the ordered list of values files, or glob patterns such as 'values-*.yaml', relative to the chart
+// directory or URLs such as 'https://' or 's3://' of shared values files. Later files override the values of earlier ones
+type ValuesFilesConfig struct {
+	ValuesFiles []string `json:"valuesFiles"`
+}
+
+// addValuesFilesFlag adds the flag to declare the values files of the chart
+func (o *StepHelmOptions) addValuesFilesFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values-files", "", nil, "The ordered values files, or glob patterns such as 'values-*.yaml', relative to the chart directory. Can also be a 'https://', 's3://', 'gs://' or 'azblob://' URL of a values file shared by many charts. Overrides the '"+ValuesFilesConfigFileName+"' file in the chart directory. Can be specified multiple times")
+	cmd.Flags().DurationVarP(&o.ValuesCacheTTL, "values-cache-ttl", "", 0, "If specified the remote values files cached in '~/.jx/cache/"+ValuesCacheDirName+"' are only fetched again once they are older than this duration. e.g. '1h'")
+}
+
+// loadValuesFilesConfig loads the values files configuration from the chart directory returning nil if there is none
+func loadValuesFilesConfig(dir string) (*ValuesFilesConfig, error) {
+	fileName := filepath.Join(dir, ValuesFilesConfigFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	config := &ValuesFilesConfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML in file %s", fileName)
+	}
+	return config, nil
+}
+
+// findValuesFiles returns the existing values files of the chart directory in order. The names are taken from the
+// --values-files flag, then the '.jx/valuesFiles.yaml' file in the directory and finally the given default names.
+// Glob patterns are expanded in lexical order and each file is only returned once. Remote values URLs are fetched into
+// the cache and the cached file returned in their place
+func (o *StepHelmOptions) findValuesFiles(dir string, defaultNames []string) ([]string, error) {
+	patterns := o.ValuesFiles
+	if len(patterns) == 0 {
+		config, err := loadValuesFilesConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			patterns = config.ValuesFiles
+		}
+	}
+	if len(patterns) == 0 {
+		patterns = defaultNames
+	}
+
+	answer := []string{}
+	for _, pattern := range patterns {
+		if isRemoteValuesURL(pattern) {
+			cached, err := o.fetchRemoteValuesFile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if util.StringArrayIndex(answer, cached) < 0 {
+				answer = append(answer, cached)
+			}
+			continue
+		}
+		path := pattern
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid values files pattern %s", pattern)
+		}
+		for _, match := range matches {
+			if util.StringArrayIndex(answer, match) < 0 {
+				answer = append(answer, match)
+			}
+		}
+	}
+	return answer, nil
+}
+
+// isRemoteValuesURL returns true if the values file is a URL which is fetched rather than a path in the chart directory
+func isRemoteValuesURL(text string) bool {
+	if !strings.Contains(text, "://") {
+		return false
+	}
+	u, err := url.Parse(text)
+	if err != nil {
+		return false
+	}
+	return util.StringArrayIndex(remoteValuesSchemes, u.Scheme) >= 0
+}
+
+// fetchRemoteValuesFile fetches the values file at the URL into the cache returning the cached file name. The cached
+// file is used without fetching it if it is younger than --values-cache-ttl or when --offline. If the fetch fails a
+// previously cached file is used with a warning
+func (o *StepHelmOptions) fetchRemoteValuesFile(valuesURL string) (string, error) {
+	cacheDir := o.valuesCacheDir
+	if cacheDir == "" {
+		dir, err := util.CacheDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to find the cache directory")
+		}
+		cacheDir = filepath.Join(dir, ValuesCacheDirName)
+	}
+	err := os.MkdirAll(cacheDir, util.DefaultWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the values cache directory %s", cacheDir)
+	}
+	hash := sha256.Sum256([]byte(valuesURL))
+	fileName := filepath.Join(cacheDir, hex.EncodeToString(hash[:])+".yaml")
+
+	info, err := os.Stat(fileName)
+	cached := err == nil
+	if o.Offline {
+		if !cached {
+			return "", errors.Errorf("the values file %s has not been cached in %s so cannot be used --offline", valuesURL, cacheDir)
+		}
+		return fileName, nil
+	}
+	if cached && o.ValuesCacheTTL > 0 && time.Since(info.ModTime()) < o.ValuesCacheTTL {
+		log.Logger().Debugf("using the values file %s cached in %s", valuesURL, fileName)
+		return fileName, nil
+	}
+
+	data, err := o.readRemoteValues(valuesURL)
+	if err != nil {
+		if cached {
+			log.Logger().Warnf("failed to fetch the values file %s so using the copy cached in %s: %s", valuesURL, fileName, err.Error())
+			return fileName, nil
+		}
+		return "", errors.Wrapf(err, "failed to fetch the values file %s", valuesURL)
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultFileWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to cache the values file %s in %s", valuesURL, fileName)
+	}
+	log.Logger().Debugf("fetched the values file %s into %s", valuesURL, fileName)
+	return fileName, nil
+}
+
+// readRemoteValues reads the values file at the URL. Buckets are read using the cloud credentials of the cluster and
+// HTTP URLs using the git credentials of the host if there are any
+func (o *StepHelmOptions) readRemoteValues(valuesURL string) ([]byte, error) {
+	u, err := url.Parse(valuesURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse URL %s", valuesURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return buckets.ReadBucketURL(u, remoteValuesTimeout)
+	}
+	httpClient, err := o.getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, valuesURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the request to %s", valuesURL)
+	}
+	if u.User == nil {
+		o.addRemoteValuesAuth(req)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to invoke GET on %s", valuesURL)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GET data from %s", valuesURL)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf("status %s when performing GET on %s", resp.Status, valuesURL)
+	}
+	return data, nil
+}
+
+// addRemoteValuesAuth adds the basic auth of the git server of the request host, e.g. to fetch the raw values file
+// of a private repository. The request is made anonymously if there are no credentials
+func (o *StepHelmOptions) addRemoteValuesAuth(req *http.Request) {
+	if o.CommonOptions == nil || o.noCluster {
+		return
+	}
+	authSvc, err := o.GitAuthConfigService()
+	if err != nil {
+		log.Logger().Debugf("not using git credentials to fetch %s: %s", req.URL.String(), err.Error())
+		return
+	}
+	serverURL := req.URL.Scheme + "://" + req.URL.Host
+	for _, a := range authSvc.Config().FindUserAuths(serverURL) {
+		if a.ApiToken != "" {
+			req.SetBasicAuth(a.Username, a.ApiToken)
+			return
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// +build unit
+
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindValuesFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-step-helm-values-files-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"values.yaml", "myvalues.yaml", "values-b.yaml", "values-a.yaml", "values-staging.yaml"} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte("foo: bar\n"), util.DefaultWritePermissions)
+		require.NoError(t, err)
+	}
+	relativePaths := func(paths []string) []string {
+		answer := []string{}
+		for _, path := range paths {
+			rel, err := filepath.Rel(dir, path)
+			require.NoError(t, err)
+			answer = append(answer, rel)
+		}
+		return answer
+	}
+
+	o := &StepHelmOptions{}
+	valuesFiles, err := o.findValuesFiles(dir, []string{"values.yaml", "secrets.yaml", "myvalues.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"values.yaml", "myvalues.yaml"}, relativePaths(valuesFiles), "should use the default names which exist")
+
+	configFile := filepath.Join(dir, ValuesFilesConfigFileName)
+	err = os.MkdirAll(filepath.Dir(configFile), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(configFile, []byte("valuesFiles:\n- values.yaml\n- values-?.yaml\n- values-staging.yaml\n- values*.yaml\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	valuesFiles, err = o.findValuesFiles(dir, []string{"values.yaml", "secrets.yaml", "myvalues.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"values.yaml", "values-a.yaml", "values-b.yaml", "values-staging.yaml"}, relativePaths(valuesFiles), "should expand the patterns of the config file in order")
+
+	o.ValuesFiles = []string{"myvalues.yaml", "values-staging.yaml", "missing.yaml"}
+	valuesFiles, err = o.findValuesFiles(dir, []string{"values.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myvalues.yaml", "values-staging.yaml"}, relativePaths(valuesFiles), "the flag should override the config file")
+
+	o.ValuesFiles = []string{"values-[.yaml"}
+	_, err = o.findValuesFiles(dir, nil)
+	require.Error(t, err, "should fail on a malformed pattern")
+}
+
+func TestFindValuesFilesWithRemoteURL(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-step-helm-values-urls-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte("foo: bar\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	requests := 0
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "org: shared-%d\n", requests)
+	}))
+	defer server.Close()
+	valuesURL := server.URL + "/org/values.yaml"
+
+	o := &StepHelmOptions{
+		ValuesFiles:    []string{valuesURL, "values.yaml"},
+		valuesCacheDir: filepath.Join(dir, "cache"),
+	}
+	valuesFiles, err := o.findValuesFiles(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, valuesFiles, 2)
+	assert.Equal(t, filepath.Join(dir, "values.yaml"), valuesFiles[1], "should keep the declared order")
+	cached := valuesFiles[0]
+	assert.Equal(t, filepath.Join(dir, "cache"), filepath.Dir(cached))
+	data, err := ioutil.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "org: shared-1\n", string(data))
+
+	o.ValuesCacheTTL = time.Hour
+	_, err = o.findValuesFiles(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests, "should use the cached file within the TTL")
+
+	o.ValuesCacheTTL = 0
+	_, err = o.findValuesFiles(dir, nil)
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "org: shared-2\n", string(data), "should fetch the file again without a TTL")
+
+	status = http.StatusInternalServerError
+	_, err = o.findValuesFiles(dir, nil)
+	require.NoError(t, err, "should fall back to the cached file when the fetch fails")
+	data, err = ioutil.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "org: shared-2\n", string(data))
+
+	o.ValuesFiles = []string{server.URL + "/other.yaml"}
+	_, err = o.findValuesFiles(dir, nil)
+	require.Error(t, err, "should fail when a file which has not been cached cannot be fetched")
+
+	o.Offline = true
+	_, err = o.findValuesFiles(dir, nil)
+	require.Error(t, err, "should fail --offline when the file has not been cached")
+	o.ValuesFiles = []string{valuesURL}
+	valuesFiles, err = o.findValuesFiles(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cached}, valuesFiles)
+}
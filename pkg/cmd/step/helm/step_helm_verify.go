@@ -0,0 +1,52 @@
+package helm
+
+import (
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// addVerifyChartsFlags adds the flags to verify the signatures of charts
+func (o *StepHelmOptions) addVerifyChartsFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.VerifyCharts, "verify", "", false, "Verifies the signatures of the charts using their '"+helm.ProvenanceFileExtension+"' provenance files, or cosign for charts in an OCI registry, before installing them. Verification is always enabled if 'verifyCharts' is true in the 'jx-requirements.yml'")
+	cmd.Flags().StringVarP(&o.Keyring, "keyring", "", "", "The keyring containing the public keys used to verify the provenance files of the charts. Defaults to the default keyring of helm")
+	cmd.Flags().StringVarP(&o.CosignKey, "cosign-key", "", "", "The public key used to verify the cosign signatures of charts in an OCI registry. If not specified keyless verification is used")
+}
+
+// verifyChartsEnabled returns true if the chart signatures must be verified because of the --verify flag or the
+// 'verifyCharts' requirement of the environment
+func (o *StepHelmOptions) verifyChartsEnabled(requirements *config.RequirementsConfig) bool {
+	return o.VerifyCharts || (requirements != nil && requirements.VerifyCharts)
+}
+
+// chartVerifier creates the verifier of the chart signatures
+func (o *StepHelmOptions) chartVerifier() *helm.ChartVerifier {
+	return &helm.ChartVerifier{
+		HelmBinary: o.Helm().HelmBinary(),
+		Keyring:    o.Keyring,
+		CosignKey:  o.CosignKey,
+		Runner:     o.runCommand,
+	}
+}
+
+// verifyChartDependencies fetches the missing provenance files of the dependency archives of the chart and verifies
+// the archives. It must be called before the archives are repackaged as that invalidates their signatures
+func (o *StepHelmOptions) verifyChartDependencies(dir string) error {
+	fileName := filepath.Join(dir, helm.RequirementsFileName)
+	requirements, err := helm.LoadRequirementsFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", fileName)
+	}
+	httpClient, err := o.getHTTPClient()
+	if err != nil {
+		return err
+	}
+	err = helm.FetchProvenanceFiles(httpClient, dir, requirements.Dependencies)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch the provenance files of the dependencies of %s", dir)
+	}
+	return o.chartVerifier().VerifyChartDependencies(dir)
+}
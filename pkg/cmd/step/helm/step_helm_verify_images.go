@@ -0,0 +1,594 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ImageVerified the status of an image which exists and passed all the checks
+	ImageVerified = "verified"
+	// ImageMissing the status of an image which does not exist in its registry
+	ImageMissing = "missing"
+	// ImageMismatch the status of an image whose version or digest does not match the version stream
+	ImageMismatch = "mismatch"
+	// ImageUnsigned the status of an image whose cosign signature could not be verified
+	ImageUnsigned = "unsigned"
+	// ImageError the status of an image which could not be checked
+	ImageError = "error"
+
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIHost     = "registry-1.docker.io"
+	dockerContentDigest  = "Docker-Content-Digest"
+	shortImageDigestSize = len(digestPrefix) + 12
+)
+
+var (
+	// manifestAcceptTypes the media types of the image manifests and indexes accepted from the registries
+	manifestAcceptTypes = []string{
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.oci.image.index.v1+json",
+	}
+
+	// containerListKeys the keys of the lists of containers in the pod specs of the workloads
+	containerListKeys = []string{"containers", "initContainers", "ephemeralContainers"}
+
+	authChallengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// StepHelmVerifyImagesOptions contains the command line flags
+type StepHelmVerifyImagesOptions struct {
+	StepHelmOptions
+
+	Namespace          string
+	ReleaseName        string
+	PlanFile           string
+	VersionStream      bool
+	VerifySignatures   bool
+	InsecureRegistries []string
+}
+
+// ImageVerification the result of verifying an image referenced by the rendered manifests
+type ImageVerification struct {
+	Image         string `json:"image"`
+	Status        string `json:"status"`
+	Digest        string `json:"digest,omitempty"`
+	VersionStream string `json:"versionStream,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// VerifyImagesOutput the structured output of 'jx step helm verify-images'
+type VerifyImagesOutput struct {
+	Images []ImageVerification `json:"images"`
+}
+
+// ImageReference a parsed reference to a container image
+type ImageReference struct {
+	// Name the name of the image as it was referenced without the tag or digest
+	Name string
+	// Registry the host of the registry, e.g. 'docker.io' or 'gcr.io'
+	Registry string
+	// Repository the repository within the registry, e.g. 'library/nginx'
+	Repository string
+	// Tag the tag of the image if it has one
+	Tag string
+	// Digest the digest of the image if it has one
+	Digest string
+}
+
+var (
+	StepHelmVerifyImagesLong = templates.LongDesc(`
+		Verifies the container images referenced by the rendered manifests of a chart.
+
+		Each image must exist in its registry, which is checked via a HEAD request of its manifest, and if the version
+		stream has a version or digest of the image the image must use it. If --verify-signatures is enabled the cosign
+		signature of each image is also verified.
+
+		The chart in the --dir is rendered after its dependencies have been built, e.g. by 'jx step helm build', or the
+		manifests of a --plan are used. Registries which require credentials use those of the docker config, including its
+		credential helpers and store.
+
+		The step fails with a table of the images which are missing, do not match the version stream or are unsigned.
+`)
+
+	StepHelmVerifyImagesExample = templates.Examples(`
+		# verifies the images of the chart in the env dir exist and match the version stream
+		jx step helm verify-images --dir env
+
+		# verifies the images of a plan including their cosign signatures
+		jx step helm verify-images --plan plan.json --verify-signatures --cosign-key cosign.pub
+
+`)
+)
+
+// NewCmdStepHelmVerifyImages creates the command to verify the images of the rendered manifests of a chart
+func NewCmdStepHelmVerifyImages(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmVerifyImagesOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "verify-images",
+		Short:   "Verifies the container images referenced by the rendered manifests of a chart",
+		Aliases: []string{"verify-image"},
+		Long:    StepHelmVerifyImagesLong,
+		Example: StepHelmVerifyImagesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory containing the helm chart to render")
+	cmd.Flags().StringVarP(&options.HelmBinary, "helm-binary", "", "", "The helm binary used to render the chart")
+	options.addOutputFlag(cmd)
+	options.addValuesFilesFlag(cmd)
+	options.addKustomizeFlag(cmd)
+
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "n", DefaultPlanReleaseName, "The name of the release used to render the chart")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "", "", "The namespace used to render the chart. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&options.PlanFile, "plan", "", "", "Verifies the images of the manifests of the plan written by 'jx step helm build --plan' rather than rendering the chart")
+	cmd.Flags().BoolVarP(&options.VersionStream, "version-stream", "", true, "Verifies the images match the versions and digests in the version stream of the 'jx-requirements.yml'")
+	cmd.Flags().BoolVarP(&options.VerifySignatures, "verify-signatures", "", false, "Verifies the cosign signature of each image")
+	cmd.Flags().StringVarP(&options.CosignKey, "cosign-key", "", "", "The public key used to verify the cosign signatures of the images. If not specified keyless verification is used")
+	cmd.Flags().StringArrayVarP(&options.InsecureRegistries, "insecure-registry", "", nil, "The registries which are accessed via HTTP rather than HTTPS")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepHelmVerifyImagesOptions) Run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+	dir := o.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+	manifests, err := o.renderedManifests(dir)
+	if err != nil {
+		return err
+	}
+	images, err := FindManifestImages(manifests)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		log.Logger().Warnf("No container images are referenced by the rendered manifests of the chart in %s", dir)
+		return nil
+	}
+
+	var resolver *versionstream.VersionResolver
+	if o.VersionStream {
+		requirements, _, err := config.LoadRequirementsConfig(dir)
+		if err != nil {
+			return err
+		}
+		resolver, err = o.getOrCreateVersionResolver(requirements)
+		if err != nil {
+			return err
+		}
+	}
+	httpClient, err := o.getHTTPClient()
+	if err != nil {
+		return err
+	}
+	registry := &registryClient{
+		httpClient: httpClient,
+		insecure:   o.InsecureRegistries,
+	}
+
+	results := &VerifyImagesOutput{}
+	failed := 0
+	for _, image := range images {
+		result := o.verifyImage(registry, resolver, image)
+		if result.Status != ImageVerified {
+			failed++
+		}
+		results.Images = append(results.Images, result)
+	}
+	if o.Output != "" {
+		err = o.renderOutput(results)
+		if err != nil {
+			return err
+		}
+	} else {
+		table := o.CreateTable()
+		table.AddRow("IMAGE", "STATUS", "DIGEST", "MESSAGE")
+		for _, result := range results.Images {
+			digest := result.Digest
+			if len(digest) > shortImageDigestSize {
+				digest = digest[:shortImageDigestSize]
+			}
+			table.AddRow(result.Image, result.Status, digest, result.Message)
+		}
+		table.Render()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d images referenced by the chart in %s failed verification", failed, len(images), dir)
+	}
+	log.Logger().Infof("Verified the %d images referenced by the chart in %s", len(images), util.ColorInfo(dir))
+	return nil
+}
+
+// renderedManifests returns the manifests of the --plan or renders the chart in the given directory
+func (o *StepHelmVerifyImagesOptions) renderedManifests(dir string) ([]PlanManifest, error) {
+	if o.PlanFile != "" {
+		plan, err := LoadPlan(o.PlanFile)
+		if err != nil {
+			return nil, err
+		}
+		err = plan.Verify("")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid plan %s", o.PlanFile)
+		}
+		return plan.Manifests, nil
+	}
+	if err := o.configureHelmBinary(); err != nil {
+		return nil, err
+	}
+	defer o.closeSopsValues()
+	ns := o.Namespace
+	if ns == "" {
+		var err error
+		_, ns, err = o.KubeClientAndNamespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+	valuesFiles, err := o.discoverValuesFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := o.createPlan(dir, o.ReleaseName, ns, valuesFiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render the chart in %s", dir)
+	}
+	return plan.Manifests, nil
+}
+
+// verifyImage checks the image exists in its registry, matches the version stream and optionally is signed
+func (o *StepHelmVerifyImagesOptions) verifyImage(registry *registryClient, resolver *versionstream.VersionResolver, image string) ImageVerification {
+	result := ImageVerification{
+		Image:  image,
+		Status: ImageVerified,
+	}
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		result.Status = ImageError
+		result.Message = err.Error()
+		return result
+	}
+	digest, exists, err := registry.manifestDigest(ref)
+	if err != nil {
+		result.Status = ImageError
+		result.Message = err.Error()
+		return result
+	}
+	if !exists {
+		result.Status = ImageMissing
+		result.Message = fmt.Sprintf("the image does not exist in the registry %s", ref.Registry)
+		return result
+	}
+	result.Digest = digest
+
+	if resolver != nil {
+		expected, err := stableImageVersion(resolver, ref.Name)
+		if err != nil {
+			result.Status = ImageError
+			result.Message = err.Error()
+			return result
+		}
+		result.VersionStream = expected.Version
+		// images only pinned by digest are checked against the digest of the version stream
+		if expected.Version != "" && ref.Tag != expected.Version && !(ref.Tag == "" && ref.Digest != "") {
+			result.Status = ImageMismatch
+			result.Message = fmt.Sprintf("the version stream has version %s", expected.Version)
+			return result
+		}
+		if expected.Digest != "" && digest != "" && digest != expected.Digest {
+			result.Status = ImageMismatch
+			result.Message = fmt.Sprintf("the version stream has digest %s", expected.Digest)
+			return result
+		}
+	}
+
+	if o.VerifySignatures {
+		// lets verify the digest found in the registry so the tag cannot change between the checks
+		signed := image
+		if digest != "" && ref.Digest == "" {
+			signed = ref.Name + "@" + digest
+		}
+		args := []string{"verify"}
+		if o.CosignKey != "" {
+			args = append(args, "--key", o.CosignKey)
+		}
+		args = append(args, signed)
+		_, err = o.runCommand(&util.Command{
+			Name: "cosign",
+			Args: args,
+		})
+		if err != nil {
+			result.Status = ImageUnsigned
+			result.Message = fmt.Sprintf("failed to verify the cosign signature: %s", err.Error())
+		}
+	}
+	return result
+}
+
+// stableImageVersion returns the version stream entry of the image trying without any 'docker.io/' prefix if need be
+func stableImageVersion(resolver *versionstream.VersionResolver, name string) (*versionstream.StableVersion, error) {
+	data, err := resolver.StableVersion(versionstream.KindDocker, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the image %s in the version stream", name)
+	}
+	prefix := dockerHubRegistry + "/"
+	if data.Version == "" && strings.HasPrefix(name, prefix) {
+		return stableImageVersion(resolver, strings.TrimPrefix(name, prefix))
+	}
+	return data, nil
+}
+
+// FindManifestImages returns the sorted unique images of the containers, init containers and ephemeral containers
+// of the resources in the rendered manifests
+func FindManifestImages(manifests []PlanManifest) ([]string, error) {
+	found := map[string]bool{}
+	for _, manifest := range manifests {
+		for _, doc := range yamlDocumentSeparator.Split(manifest.Content, -1) {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			object := map[string]interface{}{}
+			err := yaml.Unmarshal([]byte(doc), &object)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal manifest %s", manifest.Path)
+			}
+			findContainerImages(object, found)
+		}
+	}
+	images := []string{}
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// findContainerImages adds the images of any containers nested in the given value
+func findContainerImages(value interface{}, found map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if util.StringArrayIndex(containerListKeys, key) >= 0 {
+				if containers, ok := child.([]interface{}); ok {
+					for _, container := range containers {
+						if m, ok := container.(map[string]interface{}); ok {
+							if image, ok := m["image"].(string); ok && image != "" {
+								found[image] = true
+							}
+						}
+					}
+					continue
+				}
+			}
+			findContainerImages(child, found)
+		}
+	case []interface{}:
+		for _, child := range v {
+			findContainerImages(child, found)
+		}
+	}
+}
+
+// ParseImageReference parses the image name, tag and digest using the same defaults as docker for images without a
+// registry or tag
+func ParseImageReference(image string) (*ImageReference, error) {
+	image = strings.TrimSpace(image)
+	ref := &ImageReference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return nil, fmt.Errorf("invalid digest %s of image %s", ref.Digest, image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return nil, fmt.Errorf("invalid image %s", image)
+	}
+	ref.Name = name
+	paths := strings.SplitN(name, "/", 2)
+	if len(paths) == 1 || !(strings.ContainsAny(paths[0], ".:") || paths[0] == "localhost") {
+		ref.Registry = dockerHubRegistry
+		ref.Repository = name
+	} else {
+		ref.Registry = paths[0]
+		ref.Repository = paths[1]
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	return ref, nil
+}
+
+// manifestReference returns the digest or tag used to fetch the manifest of the image defaulting to 'latest'
+func (r *ImageReference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return "latest"
+}
+
+// registryClient checks whether images exist using the docker registry HTTP API
+type registryClient struct {
+	httpClient *http.Client
+	insecure   []string
+}
+
+// manifestDigest returns the digest of the manifest of the image and whether it exists using a HEAD request
+// authenticating with a token if the registry requires one
+func (c *registryClient) manifestDigest(ref *ImageReference) (string, bool, error) {
+	host := ref.Registry
+	if host == dockerHubRegistry {
+		host = dockerHubAPIHost
+	}
+	scheme := "https"
+	if util.StringArrayIndex(c.insecure, ref.Registry) >= 0 {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, ref.Repository, ref.manifestReference())
+	resp, err := c.headManifest(manifestURL, "")
+	if err != nil {
+		return "", false, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorization(resp.Header.Get("Www-Authenticate"), ref)
+		if err != nil {
+			return "", false, err
+		}
+		resp, err = c.headManifest(manifestURL, authorization)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header.Get(dockerContentDigest), true, nil
+	case http.StatusNotFound:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("status %s fetching the manifest %s", resp.Status, manifestURL)
+	}
+}
+
+// headManifest requests the manifest with the given authorization header if it is not blank
+func (c *registryClient) headManifest(manifestURL string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the request of manifest %s", manifestURL)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch the manifest %s", manifestURL)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorization returns the authorization header for the registry challenge. Bearer challenges fetch a pull token
+// using any credentials of the docker config whereas basic challenges use the credentials directly
+func (c *registryClient) authorization(challenge string, ref *ImageReference) (string, error) {
+	credentials, err := registryCredentials(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+		if credentials == "" {
+			return "", fmt.Errorf("the registry %s requires credentials which are not in the docker config", ref.Registry)
+		}
+		return credentials, nil
+	}
+	params := map[string]string{}
+	for _, match := range authChallengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("the registry %s returned an unsupported authentication challenge: %s", ref.Registry, challenge)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query := url.Values{}
+	query.Set("scope", scope)
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	tokenURL := realm + "?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the token request %s", tokenURL)
+	}
+	if credentials != "" {
+		req.Header.Set("Authorization", credentials)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch a token for registry %s", ref.Registry)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %s fetching a token for registry %s", resp.Status, ref.Registry)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the token for registry %s", ref.Registry)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// registryCredentials returns the authorization header of the credentials of the registry in the docker config, which
+// may come from its credential helpers or store, or a blank string if there are none
+func registryCredentials(registry string) (string, error) {
+	reg, err := name.NewRegistry(registry, name.WeakValidation)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the registry %s", registry)
+	}
+	authenticator, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the credentials of registry %s", registry)
+	}
+	header, err := authenticator.Authorization()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the credentials of registry %s", registry)
+	}
+	return header, nil
+}
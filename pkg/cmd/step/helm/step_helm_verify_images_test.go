@@ -0,0 +1,135 @@
+// +build unit
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyImages(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "registry", r.URL.Query().Get("service"))
+			fmt.Fprint(w, `{"token": "pull-token"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, http.MethodHead, r.Method)
+		switch r.URL.Path {
+		case "/v2/jx/builder/manifests/1.0.0", "/v2/jx/builder/manifests/0.9.0", "/v2/jx/unsigned/manifests/1.0.0":
+			w.Header().Set(dockerContentDigest, "sha256:"+strings.Repeat("a", 64))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "test-helm-verify-images-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	versionFile := filepath.Join(dir, "docker", registry, "jx", "builder.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(versionFile), util.DefaultWritePermissions))
+	require.NoError(t, ioutil.WriteFile(versionFile, []byte("version: 1.0.0\n"), util.DefaultFileWritePermissions))
+
+	manifests := []PlanManifest{
+		{
+			Path:    "env/templates/deployment.yaml",
+			Content: fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nspec:\n  template:\n    spec:\n      initContainers:\n      - image: %[1]s/jx/missing:1.0.0\n      containers:\n      - image: %[1]s/jx/builder:1.0.0\n      - image: %[1]s/jx/unsigned:1.0.0\n", registry),
+		},
+		{
+			Path:    "env/templates/cronjob.yaml",
+			Content: fmt.Sprintf("apiVersion: batch/v1beta1\nkind: CronJob\nspec:\n  jobTemplate:\n    spec:\n      template:\n        spec:\n          containers:\n          - image: %s/jx/builder:0.9.0\n", registry),
+		},
+	}
+	plan := &HelmPlan{Chart: "env", Manifests: manifests}
+	planFile := filepath.Join(dir, "plan.json")
+	require.NoError(t, SavePlan(plan, planFile))
+
+	out := &bytes.Buffer{}
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.Out = out
+	o := &StepHelmVerifyImagesOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+			},
+			Output:          OutputFormatJSON,
+			CosignKey:       "cosign.pub",
+			versionResolver: &versionstream.VersionResolver{VersionsDir: dir},
+		},
+		PlanFile:           planFile,
+		VersionStream:      true,
+		VerifySignatures:   true,
+		InsecureRegistries: []string{registry},
+	}
+	signed := []string{}
+	o.commandRunner = func(cmd *util.Command) (string, error) {
+		image := cmd.Args[len(cmd.Args)-1]
+		assert.Equal(t, []string{"verify", "--key", "cosign.pub", image}, cmd.Args)
+		if strings.Contains(image, "unsigned") {
+			return "", fmt.Errorf("no matching signatures")
+		}
+		signed = append(signed, image)
+		return "", nil
+	}
+	err = o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 of the 4 images")
+
+	results := &VerifyImagesOutput{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), results))
+	statuses := map[string]string{}
+	for _, result := range results.Images {
+		statuses[strings.TrimPrefix(result.Image, registry+"/")] = result.Status
+	}
+	assert.Equal(t, map[string]string{
+		"jx/builder:0.9.0":  ImageMismatch,
+		"jx/builder:1.0.0":  ImageVerified,
+		"jx/missing:1.0.0":  ImageMissing,
+		"jx/unsigned:1.0.0": ImageUnsigned,
+	}, statuses)
+	assert.Equal(t, []string{registry + "/jx/builder@sha256:" + strings.Repeat("a", 64)}, signed, "should verify the signature of the digest in the registry")
+}
+
+func TestParseImageReference(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]ImageReference{
+		"nginx":                             {Name: "nginx", Registry: "docker.io", Repository: "library/nginx"},
+		"jenkinsxio/jx:2.0.1":               {Name: "jenkinsxio/jx", Registry: "docker.io", Repository: "jenkinsxio/jx", Tag: "2.0.1"},
+		"localhost:5000/foo/bar@sha256:abc": {Name: "localhost:5000/foo/bar", Registry: "localhost:5000", Repository: "foo/bar", Digest: "sha256:abc"},
+		"gcr.io/jenkinsxio/builder-go:0.1.2@sha256:abc": {Name: "gcr.io/jenkinsxio/builder-go", Registry: "gcr.io", Repository: "jenkinsxio/builder-go", Tag: "0.1.2", Digest: "sha256:abc"},
+	}
+	for image, expected := range testCases {
+		ref, err := ParseImageReference(image)
+		require.NoError(t, err, "image %s", image)
+		assert.Equal(t, expected, *ref, "image %s", image)
+	}
+
+	_, err := ParseImageReference("gcr.io/foo@abc")
+	assert.Error(t, err)
+}
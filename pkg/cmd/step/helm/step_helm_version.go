@@ -35,6 +35,9 @@ var (
 		# updates the current Helm Chart.yaml to the latest build number version
 		jx step helm version
 
+		# updates the chart version outputting the modified chart as YAML
+		jx step helm version --version 1.2.3 --output yaml
+
 `)
 )
 
@@ -60,6 +63,7 @@ func NewCmdStepHelmVersion(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	options.addOutputFlag(cmd)
 
 	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version to update. If none specified it defaults to $BUILD_NUMBER")
 
@@ -67,6 +71,9 @@ func NewCmdStepHelmVersion(commonOpts *opts.CommonOptions) *cobra.Command {
 }
 
 func (o *StepHelmVersionOptions) Run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
 	version := o.Version
 	if version == "" {
 		version = builds.GetBuildNumber()
@@ -95,5 +102,11 @@ func (o *StepHelmVersionOptions) Run() error {
 		return err
 	}
 	log.Logger().Infof("Modified file %s to set the chart to version %s", util.ColorInfo(chartFile), util.ColorInfo(version))
+	if o.Output != "" {
+		return o.renderOutput(&ChartVersionOutput{
+			ChartFile: chartFile,
+			Version:   version,
+		})
+	}
 	return nil
 }
@@ -0,0 +1,279 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/gits/releases"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// SBOMFormatSPDX the SPDX JSON format of SBOMs
+	SBOMFormatSPDX = "spdx"
+	// SBOMFormatCycloneDX the CycloneDX JSON format of SBOMs
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+var (
+	stepReportSBOMLong = templates.LongDesc(`
+		Generates the software bill of materials (SBOM) of an image with syft.
+
+		The SBOM is attached to the git release of the version and pushed to the registry as an OCI artifact referring to the image with oras. A summary of it is stored in the PipelineActivity of the build.
+`)
+	stepReportSBOMExample = templates.Examples(`
+		# generates the SPDX SBOM of the image of the release
+		jx step report sbom --image $DOCKER_REGISTRY/$ORG/$APP_NAME:$VERSION
+
+		# generates a CycloneDX SBOM without attaching it to the git release
+		jx step report sbom --image myorg/myapp:1.2.3 --format cyclonedx --no-release
+`)
+)
+
+// sbomFormat the syft output and media type of a SBOM format
+type sbomFormat struct {
+	output    string
+	mediaType string
+	fileName  string
+}
+
+var sbomFormatNames = []string{SBOMFormatCycloneDX, SBOMFormatSPDX}
+
+var sbomFormats = map[string]sbomFormat{
+	SBOMFormatSPDX:      {output: "spdx-json", mediaType: "application/spdx+json", fileName: "sbom.spdx.json"},
+	SBOMFormatCycloneDX: {output: "cyclonedx-json", mediaType: "application/vnd.cyclonedx+json", fileName: "sbom.cdx.json"},
+}
+
+// sbomDocument the packages of a SPDX document or the components of a CycloneDX BOM
+type sbomDocument struct {
+	Packages   []json.RawMessage `json:"packages,omitempty"`
+	Components []json.RawMessage `json:"components,omitempty"`
+}
+
+// StepReportSBOMOptions contains the command line flags and other helper objects
+type StepReportSBOMOptions struct {
+	StepReportOptions
+	Image      string
+	Format     string
+	FileName   string
+	Dir        string
+	Version    string
+	NoRelease  bool
+	NoReferrer bool
+	NoActivity bool
+}
+
+// NewCmdStepReportSBOM Creates a new Command object
+func NewCmdStepReportSBOM(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepReportSBOMOptions{
+		StepReportOptions: StepReportOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "sbom",
+		Short:   "Generates the software bill of materials of an image and attaches it to the release and the image",
+		Long:    stepReportSBOMLong,
+		Example: stepReportSBOMExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.StepReportOptions.AddReportFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.Image, "image", "i", "", "The image to generate the SBOM of [required]")
+	cmd.Flags().StringVarP(&options.Format, "format", "f", SBOMFormatSPDX, "The format of the SBOM. One of: "+strings.Join(sbomFormatNames, ", "))
+	cmd.Flags().StringVarP(&options.FileName, "name", "n", "", "The name of the SBOM file. Defaults to a name based on the format")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the git repository of the release")
+	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version of the git release. Defaults to the $VERSION environment variable")
+	cmd.Flags().BoolVarP(&options.NoRelease, "no-release", "", false, "Do not attach the SBOM to the git release")
+	cmd.Flags().BoolVarP(&options.NoReferrer, "no-referrer", "", false, "Do not push the SBOM to the registry as an artifact referring to the image")
+	cmd.Flags().BoolVarP(&options.NoActivity, "no-activity", "", false, "Do not store the summary of the SBOM in the PipelineActivity")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepReportSBOMOptions) Run() error {
+	if o.Image == "" {
+		return util.MissingOption("image")
+	}
+	format, ok := sbomFormats[o.Format]
+	if !ok {
+		return util.InvalidOption("format", o.Format, sbomFormatNames)
+	}
+	fileName := o.FileName
+	if fileName == "" {
+		fileName = format.fileName
+	}
+	outputDir := o.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	err := os.MkdirAll(outputDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrap(err, "failed to create directories")
+	}
+	path := filepath.Join(outputDir, fileName)
+
+	log.Logger().Infof("Generating the %s SBOM of image %s", o.Format, util.ColorInfo(o.Image))
+	_, err = o.RunStepCommand(&util.Command{
+		Name: "syft",
+		Args: []string{o.Image, "-o", format.output, "--file", path},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate the SBOM of image %s", o.Image)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the SBOM file %s", path)
+	}
+	doc := &sbomDocument{}
+	err = json.Unmarshal(data, doc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the SBOM file %s", path)
+	}
+	summary := v1.SBOM{
+		Image:    o.Image,
+		Format:   o.Format,
+		Packages: len(doc.Packages) + len(doc.Components),
+	}
+	log.Logger().Infof("Generated SBOM %s with %d packages", util.ColorInfo(path), summary.Packages)
+
+	if !o.NoReferrer {
+		summary.Referrer, err = o.attachReferrer(outputDir, fileName, format.mediaType)
+		if err != nil {
+			return err
+		}
+	}
+
+	var gitInfo *gits.GitRepository
+	if !o.NoRelease || !o.NoActivity {
+		gitInfo, err = o.FindGitInfo(o.Dir)
+		if err != nil {
+			log.Logger().Warnf("failed to find git repository so cannot attach the SBOM to the release or the PipelineActivity: %s", err.Error())
+			return nil
+		}
+	}
+	if !o.NoRelease {
+		summary.ReleaseAssetURL, err = o.uploadReleaseAsset(gitInfo, path, fileName)
+		if err != nil {
+			return err
+		}
+	}
+	if !o.NoActivity {
+		return o.UpdatePipelineActivity(gitInfo, "SBOM summary", func(a *v1.PipelineActivity) {
+			updateSBOMs(a, summary)
+		})
+	}
+	return nil
+}
+
+// attachReferrer pushes the SBOM to the registry of the image as an artifact referring to the image and returns its digest
+func (o *StepReportSBOMOptions) attachReferrer(dir string, fileName string, mediaType string) (string, error) {
+	out, err := o.RunStepCommand(&util.Command{
+		Dir:  dir,
+		Name: "oras",
+		Args: []string{"attach", "--artifact-type", mediaType, o.Image, fileName + ":" + mediaType},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to attach the SBOM to image %s", o.Image)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Digest:") {
+			digest := strings.TrimSpace(strings.TrimPrefix(line, "Digest:"))
+			log.Logger().Infof("Attached SBOM %s to image %s", util.ColorInfo(digest), util.ColorInfo(o.Image))
+			return digest, nil
+		}
+	}
+	return "", nil
+}
+
+// uploadReleaseAsset attaches the SBOM to the git release of the version and returns the URL of the asset or an empty
+// string if there is no release
+func (o *StepReportSBOMOptions) uploadReleaseAsset(gitInfo *gits.GitRepository, path string, name string) (string, error) {
+	version := o.Version
+	if version == "" {
+		version = os.Getenv("VERSION")
+	}
+	if version == "" {
+		log.Logger().Warnf("no --version or $VERSION so cannot attach the SBOM to the release")
+		return "", nil
+	}
+	provider, err := o.GitProviderForURL(gitInfo.URL, "git provider")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the git provider for %s", gitInfo.URL)
+	}
+	tags := []string{version}
+	if !strings.HasPrefix(version, "v") {
+		tags = append(tags, "v"+version)
+	}
+	var release *gits.GitRelease
+	for _, tag := range tags {
+		release, err = provider.GetRelease(gitInfo.Organisation, gitInfo.Name, tag)
+		if err != nil && !releases.ReleaseNotFoundError(err) {
+			return "", errors.Wrapf(err, "failed to get the release %s of %s/%s", tag, gitInfo.Organisation, gitInfo.Name)
+		}
+		if err == nil && release != nil {
+			if release.TagName == "" {
+				release.TagName = tag
+			}
+			break
+		}
+	}
+	if release == nil {
+		log.Logger().Warnf("no release %s of %s/%s so cannot attach the SBOM to it", version, gitInfo.Organisation, gitInfo.Name)
+		return "", nil
+	}
+
+	file, err := os.Open(path)
+	// The file will be closed by the release asset uploader
+	if err != nil {
+		return "", errors.Wrapf(err, "opening %s", path)
+	}
+	var asset *gits.GitReleaseAsset
+	if uploader, ok := provider.(gits.ReleaseAssetTagUploader); ok {
+		asset, err = uploader.UploadReleaseAssetForTag(gitInfo.Organisation, gitInfo.Name, release.TagName, name, file)
+	} else {
+		asset, err = provider.UploadReleaseAsset(gitInfo.Organisation, gitInfo.Name, release.ID, name, file)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "uploading %s to release %s of %s/%s", path, release.TagName, gitInfo.Organisation, gitInfo.Name)
+	}
+	if asset == nil {
+		log.Logger().Warnf("the git provider of %s does not support release assets so the SBOM was not attached to the release", gitInfo.URL)
+		return "", nil
+	}
+	log.Logger().Infof("Uploaded %s to release asset %s", path, util.ColorInfo(asset.BrowserDownloadURL))
+	return asset.BrowserDownloadURL, nil
+}
+
+// updateSBOMs stores the summary of the SBOM in the PipelineActivity, replacing any previous summary of the image
+func updateSBOMs(a *v1.PipelineActivity, summary v1.SBOM) {
+	for i := range a.Spec.SBOMs {
+		if a.Spec.SBOMs[i].Image == summary.Image && a.Spec.SBOMs[i].Format == summary.Format {
+			a.Spec.SBOMs[i] = summary
+			return
+		}
+	}
+	a.Spec.SBOMs = append(a.Spec.SBOMs, summary)
+}
@@ -0,0 +1,60 @@
+// +build unit
+
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepReportSBOM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-step-report-sbom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	var commands []*util.Command
+	o := &StepReportSBOMOptions{
+		StepReportOptions: StepReportOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: &commonOpts,
+				CommandRunner: func(cmd *util.Command) (string, error) {
+					commands = append(commands, cmd)
+					if cmd.Name == "syft" {
+						file := cmd.Args[len(cmd.Args)-1]
+						return "", ioutil.WriteFile(file, []byte(`{"bomFormat":"CycloneDX","components":[{"name":"a"},{"name":"b"}]}`), util.DefaultFileWritePermissions)
+					}
+					return "Uploading 3f2a1b sbom.cdx.json\nAttached to [registry] 10.0.0.1:5000/myorg/myapp:1.2.3\nDigest: sha256:abc\n", nil
+				},
+			},
+			OutputDir: dir,
+		},
+		Image:      "10.0.0.1:5000/myorg/myapp:1.2.3",
+		Format:     SBOMFormatCycloneDX,
+		NoRelease:  true,
+		NoActivity: true,
+	}
+	require.NoError(t, o.Run())
+	require.Len(t, commands, 2)
+	path := filepath.Join(dir, "sbom.cdx.json")
+	assert.Equal(t, "syft", commands[0].Name)
+	assert.Equal(t, []string{"10.0.0.1:5000/myorg/myapp:1.2.3", "-o", "cyclonedx-json", "--file", path}, commands[0].Args)
+	assert.Equal(t, "oras", commands[1].Name)
+	assert.Equal(t, dir, commands[1].Dir)
+	assert.Equal(t, []string{"attach", "--artifact-type", "application/vnd.cyclonedx+json", "10.0.0.1:5000/myorg/myapp:1.2.3", "sbom.cdx.json:application/vnd.cyclonedx+json"}, commands[1].Args)
+
+	digest, err := o.attachReferrer(dir, "sbom.cdx.json", "application/vnd.cyclonedx+json")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", digest)
+
+	o.Format = "swid"
+	assert.Error(t, o.Run())
+}
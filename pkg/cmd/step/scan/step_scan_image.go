@@ -0,0 +1,461 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ScannerTrivy scans images with trivy
+	ScannerTrivy = "trivy"
+	// ScannerGrype scans images with grype
+	ScannerGrype = "grype"
+
+	defaultSeverity = "CRITICAL"
+
+	// maxCommentVulnerabilities the maximum number of blocking vulnerabilities listed in the Pull Request comment
+	maxCommentVulnerabilities = 20
+)
+
+var (
+	stepScanImageLong = templates.LongDesc(`
+		Scans an image for vulnerabilities with trivy or grype and fails if any vulnerability has the severity threshold or a higher severity.
+
+		The scanner, severity threshold and ignored vulnerabilities default to the 'imageScan' settings of the 'jenkins-x.yml' file of the project or else of the requirements of the team.
+
+		The number of vulnerabilities of each severity is stored in the PipelineActivity of the build and commented on the Pull Request when scanning the image of a Pull Request. Later scans of the image update the comment on git providers which can edit comments.
+`)
+
+	stepScanImageExample = templates.Examples(`
+		# scans the image of the release failing on critical vulnerabilities
+		jx step scan image --image $DOCKER_REGISTRY/$ORG/$APP_NAME:$VERSION
+
+		# scans the image with grype failing on high or critical vulnerabilities which have a fix
+		jx step scan image --image myorg/myapp:1.2.3 --scanner grype --severity HIGH --ignore-unfixed
+`)
+
+	scanners = []string{ScannerGrype, ScannerTrivy}
+
+	// severities the severities of vulnerabilities from the lowest to the highest
+	severities = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+)
+
+// Vulnerability a vulnerability found in a package of an image
+type Vulnerability struct {
+	ID           string
+	Package      string
+	Version      string
+	FixedVersion string
+	Severity     string
+}
+
+type trivyReport struct {
+	Results []trivyResult `json:"Results"`
+}
+
+type trivyResult struct {
+	Target          string `json:"Target"`
+	Vulnerabilities []struct {
+		VulnerabilityID  string `json:"VulnerabilityID"`
+		PkgName          string `json:"PkgName"`
+		InstalledVersion string `json:"InstalledVersion"`
+		FixedVersion     string `json:"FixedVersion"`
+		Severity         string `json:"Severity"`
+	} `json:"Vulnerabilities"`
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+			Fix      struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// StepScanImageOptions contains the command line flags
+type StepScanImageOptions struct {
+	step.StepOptions
+
+	Image         string
+	Scanner       string
+	Severity      string
+	IgnoreUnfixed bool
+	Ignore        []string
+	Dir           string
+	PullRequest   string
+	NoComment     bool
+	NoActivity    bool
+}
+
+// NewCmdStepScanImage Creates a new Command object
+func NewCmdStepScanImage(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepScanImageOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "image",
+		Short:   "Scans an image for vulnerabilities and fails if their severity exceeds the threshold",
+		Long:    stepScanImageLong,
+		Example: stepScanImageExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Image, "image", "i", "", "The image to scan [required]")
+	cmd.Flags().StringVarP(&options.Scanner, "scanner", "s", "", "The vulnerability scanner. One of: "+strings.Join(scanners, ", ")+". Defaults to the imageScan settings or "+ScannerTrivy)
+	cmd.Flags().StringVarP(&options.Severity, "severity", "", "", "The lowest severity of the vulnerabilities which fail the scan. One of: "+strings.Join(severities[1:], ", ")+". Defaults to the imageScan settings or "+defaultSeverity)
+	cmd.Flags().BoolVarP(&options.IgnoreUnfixed, "ignore-unfixed", "", false, "Ignore the vulnerabilities without a fixed version of the package")
+	cmd.Flags().StringArrayVarP(&options.Ignore, "ignore", "", nil, "The IDs of the vulnerabilities which never fail the scan in addition to the ones of the imageScan settings")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the project containing the jenkins-x.yml file")
+	cmd.Flags().StringVarP(&options.PullRequest, "pull-request", "p", "", "The number of the Pull Request to comment on. Defaults to $PULL_NUMBER")
+	cmd.Flags().BoolVarP(&options.NoComment, "no-comment", "", false, "Do not comment the summary of the scan on the Pull Request")
+	cmd.Flags().BoolVarP(&options.NoActivity, "no-activity", "", false, "Do not store the summary of the scan in the PipelineActivity")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepScanImageOptions) Run() error {
+	if o.Image == "" {
+		return util.MissingOption("image")
+	}
+	scanConfig := o.effectiveScanConfig()
+	if util.StringArrayIndex(scanners, scanConfig.Scanner) < 0 {
+		return util.InvalidOption("scanner", scanConfig.Scanner, scanners)
+	}
+	if severityRank(scanConfig.Severity) <= 0 {
+		return util.InvalidOption("severity", scanConfig.Severity, severities[1:])
+	}
+
+	vulnerabilities, err := o.scan(scanConfig)
+	if err != nil {
+		return err
+	}
+	summary := EvaluateScan(o.Image, vulnerabilities, scanConfig)
+	log.Logger().Infof("Found %d critical, %d high, %d medium, %d low and %d unknown vulnerabilities in image %s",
+		summary.Critical, summary.High, summary.Medium, summary.Low, summary.Unknown, util.ColorInfo(o.Image))
+
+	var gitInfo *gits.GitRepository
+	pullRequest := o.PullRequest
+	if pullRequest == "" {
+		pullRequest = os.Getenv("PULL_NUMBER")
+	}
+	if (!o.NoComment && pullRequest != "") || !o.NoActivity {
+		gitInfo, err = o.FindGitInfo(o.Dir)
+		if err != nil {
+			log.Logger().Warnf("failed to find git repository so cannot report the scan on the Pull Request or the PipelineActivity: %s", err.Error())
+		}
+	}
+	if gitInfo != nil {
+		if !o.NoComment && pullRequest != "" {
+			err = o.CommentOnPullRequest(gitInfo, pullRequest, ScanCommentMarker(o.Image), ScanComment(summary, vulnerabilities))
+			if err != nil {
+				return err
+			}
+		}
+		if !o.NoActivity {
+			err = o.UpdatePipelineActivity(gitInfo, "scan summary", func(a *v1.PipelineActivity) {
+				updateImageScans(a, summary)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !summary.Passed {
+		return errors.Errorf("found %d vulnerabilities of severity %s or higher in image %s: %s", len(summary.Blocking),
+			summary.Severity, o.Image, strings.Join(summary.Blocking, ", "))
+	}
+	log.Logger().Infof("No vulnerabilities of severity %s or higher found in image %s", summary.Severity, util.ColorInfo(o.Image))
+	return nil
+}
+
+// effectiveScanConfig returns the settings of the scan from the flags, the jenkins-x.yml file of the project or the
+// requirements of the team in that order
+func (o *StepScanImageOptions) effectiveScanConfig() *config.ImageScanConfig {
+	answer := &config.ImageScanConfig{}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Warnf("failed to get the team settings: %s", err.Error())
+	} else {
+		requirements, err := config.GetRequirementsConfigFromTeamSettings(teamSettings)
+		if err != nil {
+			log.Logger().Warnf("failed to get the requirements from team settings: %s", err.Error())
+		} else if requirements != nil {
+			answer = MergeScanConfig(answer, requirements.ImageScan)
+		}
+	}
+	dir := o.Dir
+	if dir == "" {
+		dir = "."
+	}
+	projectConfig, _, err := config.LoadProjectConfig(dir)
+	if err != nil {
+		log.Logger().Warnf("failed to load the project configuration in %s: %s", dir, err.Error())
+	} else if projectConfig != nil {
+		answer = MergeScanConfig(answer, projectConfig.ImageScan)
+	}
+	answer = MergeScanConfig(answer, &config.ImageScanConfig{
+		Scanner:       o.Scanner,
+		Severity:      o.Severity,
+		IgnoreUnfixed: o.IgnoreUnfixed,
+		Ignore:        o.Ignore,
+	})
+	if answer.Scanner == "" {
+		answer.Scanner = ScannerTrivy
+	}
+	if answer.Severity == "" {
+		answer.Severity = defaultSeverity
+	}
+	answer.Severity = strings.ToUpper(answer.Severity)
+	return answer
+}
+
+// MergeScanConfig returns the scan settings with the non empty settings of the override replacing the base settings.
+// The ignored vulnerabilities of both are ignored
+func MergeScanConfig(base *config.ImageScanConfig, override *config.ImageScanConfig) *config.ImageScanConfig {
+	answer := base.DeepCopy()
+	if override == nil {
+		return answer
+	}
+	if override.Scanner != "" {
+		answer.Scanner = override.Scanner
+	}
+	if override.Severity != "" {
+		answer.Severity = override.Severity
+	}
+	if override.IgnoreUnfixed {
+		answer.IgnoreUnfixed = true
+	}
+	for _, id := range override.Ignore {
+		if util.StringArrayIndex(answer.Ignore, id) < 0 {
+			answer.Ignore = append(answer.Ignore, id)
+		}
+	}
+	return answer
+}
+
+// severityRank returns the rank of the severity from 0 for unknown to 4 for critical or -1 if it is not a severity
+func severityRank(severity string) int {
+	return util.StringArrayIndex(severities, normalizeSeverity(severity))
+}
+
+// normalizeSeverity returns the upper case severity treating the negligible severity of grype as low
+func normalizeSeverity(severity string) string {
+	answer := strings.ToUpper(severity)
+	if answer == "NEGLIGIBLE" {
+		return "LOW"
+	}
+	if answer == "" {
+		return "UNKNOWN"
+	}
+	return answer
+}
+
+// EvaluateScan returns the summary of the vulnerabilities found in the image. The scan fails if any vulnerability
+// which is not ignored has the severity threshold or a higher severity
+func EvaluateScan(image string, vulnerabilities []Vulnerability, scanConfig *config.ImageScanConfig) v1.ImageScan {
+	answer := v1.ImageScan{
+		Image:    image,
+		Scanner:  scanConfig.Scanner,
+		Severity: scanConfig.Severity,
+	}
+	threshold := severityRank(scanConfig.Severity)
+	for _, v := range vulnerabilities {
+		if scanConfig.IgnoreUnfixed && v.FixedVersion == "" {
+			continue
+		}
+		if util.StringArrayIndex(scanConfig.Ignore, v.ID) >= 0 {
+			continue
+		}
+		rank := severityRank(v.Severity)
+		switch normalizeSeverity(v.Severity) {
+		case "CRITICAL":
+			answer.Critical++
+		case "HIGH":
+			answer.High++
+		case "MEDIUM":
+			answer.Medium++
+		case "LOW":
+			answer.Low++
+		default:
+			answer.Unknown++
+		}
+		if rank >= threshold && util.StringArrayIndex(answer.Blocking, v.ID) < 0 {
+			answer.Blocking = append(answer.Blocking, v.ID)
+		}
+	}
+	answer.Passed = len(answer.Blocking) == 0
+	return answer
+}
+
+// scan runs the scanner on the image and returns the vulnerabilities it found
+func (o *StepScanImageOptions) scan(scanConfig *config.ImageScanConfig) ([]Vulnerability, error) {
+	dir, err := ioutil.TempDir("", "jx-scan-image-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	reportFile := filepath.Join(dir, "report.json")
+
+	var args []string
+	if scanConfig.Scanner == ScannerGrype {
+		args = []string{o.Image, "--output", "json", "--file", reportFile}
+		if scanConfig.IgnoreUnfixed {
+			args = append(args, "--only-fixed")
+		}
+	} else {
+		args = []string{"image", "--quiet", "--format", "json", "--output", reportFile}
+		if scanConfig.IgnoreUnfixed {
+			args = append(args, "--ignore-unfixed")
+		}
+		args = append(args, o.Image)
+	}
+	log.Logger().Infof("Scanning image %s with %s", util.ColorInfo(o.Image), scanConfig.Scanner)
+	_, err = o.RunStepCommand(&util.Command{
+		Name: scanConfig.Scanner,
+		Args: args,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan image %s with %s", o.Image, scanConfig.Scanner)
+	}
+	data, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the %s report %s", scanConfig.Scanner, reportFile)
+	}
+	if scanConfig.Scanner == ScannerGrype {
+		return ParseGrypeReport(data)
+	}
+	return ParseTrivyReport(data)
+}
+
+// ParseTrivyReport returns the vulnerabilities of a trivy JSON report, which is a list of results in older versions of
+// trivy
+func ParseTrivyReport(data []byte) ([]Vulnerability, error) {
+	report := &trivyReport{}
+	err := json.Unmarshal(data, report)
+	if err != nil {
+		err = json.Unmarshal(data, &report.Results)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal the trivy report")
+		}
+	}
+	var answer []Vulnerability
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			answer = append(answer, Vulnerability{
+				ID:           v.VulnerabilityID,
+				Package:      v.PkgName,
+				Version:      v.InstalledVersion,
+				FixedVersion: v.FixedVersion,
+				Severity:     v.Severity,
+			})
+		}
+	}
+	return answer, nil
+}
+
+// ParseGrypeReport returns the vulnerabilities of a grype JSON report
+func ParseGrypeReport(data []byte) ([]Vulnerability, error) {
+	report := &grypeReport{}
+	err := json.Unmarshal(data, report)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the grype report")
+	}
+	var answer []Vulnerability
+	for _, m := range report.Matches {
+		answer = append(answer, Vulnerability{
+			ID:           m.Vulnerability.ID,
+			Package:      m.Artifact.Name,
+			Version:      m.Artifact.Version,
+			FixedVersion: strings.Join(m.Vulnerability.Fix.Versions, ", "),
+			Severity:     m.Vulnerability.Severity,
+		})
+	}
+	return answer, nil
+}
+
+// ScanCommentMarker returns the hidden marker of the Pull Request comment summarising the scan of the image so that the
+// comment is updated by later scans of the image rather than commenting again
+func ScanCommentMarker(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return fmt.Sprintf("<!-- jx step scan image %s -->", image)
+}
+
+// ScanComment returns the markdown of the Pull Request comment summarising the scan
+func ScanComment(summary v1.ImageScan, vulnerabilities []Vulnerability) string {
+	var sb strings.Builder
+	sb.WriteString(ScanCommentMarker(summary.Image) + "\n")
+	sb.WriteString(fmt.Sprintf("**Vulnerability scan of `%s` by %s**\n\n", summary.Image, summary.Scanner))
+	sb.WriteString("| Critical | High | Medium | Low | Unknown |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	sb.WriteString(fmt.Sprintf("| %d | %d | %d | %d | %d |\n\n", summary.Critical, summary.High, summary.Medium, summary.Low, summary.Unknown))
+	if summary.Passed {
+		sb.WriteString(fmt.Sprintf(":white_check_mark: No vulnerabilities of severity %s or higher\n", summary.Severity))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf(":x: %d vulnerabilities of severity %s or higher:\n\n", len(summary.Blocking), summary.Severity))
+	listed := map[string]bool{}
+	for _, v := range vulnerabilities {
+		if listed[v.ID] || util.StringArrayIndex(summary.Blocking, v.ID) < 0 {
+			continue
+		}
+		if len(listed) == maxCommentVulnerabilities {
+			break
+		}
+		listed[v.ID] = true
+		fixed := ""
+		if v.FixedVersion != "" {
+			fixed = fmt.Sprintf(" fixed in %s", v.FixedVersion)
+		}
+		sb.WriteString(fmt.Sprintf("* %s %s in %s %s%s\n", normalizeSeverity(v.Severity), v.ID, v.Package, v.Version, fixed))
+	}
+	if more := len(summary.Blocking) - len(listed); more > 0 {
+		sb.WriteString(fmt.Sprintf("* and %d more\n", more))
+	}
+	return sb.String()
+}
+
+// updateImageScans stores the summary of the scan in the PipelineActivity, replacing any previous scan of the image
+func updateImageScans(a *v1.PipelineActivity, summary v1.ImageScan) {
+	for i := range a.Spec.ImageScans {
+		if a.Spec.ImageScans[i].Image == summary.Image {
+			a.Spec.ImageScans[i] = summary
+			return
+		}
+	}
+	a.Spec.ImageScans = append(a.Spec.ImageScans, summary)
+}
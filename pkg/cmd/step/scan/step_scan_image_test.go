@@ -0,0 +1,122 @@
+// +build unit
+
+package scan
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	trivyReportJSON = `{"Results":[{"Target":"myapp (alpine 3.12)","Vulnerabilities":[
+{"VulnerabilityID":"CVE-2020-0001","PkgName":"openssl","InstalledVersion":"1.1.1g","FixedVersion":"1.1.1h","Severity":"CRITICAL"},
+{"VulnerabilityID":"CVE-2020-0002","PkgName":"musl","InstalledVersion":"1.1.24","Severity":"HIGH"},
+{"VulnerabilityID":"CVE-2020-0003","PkgName":"zlib","InstalledVersion":"1.2.11","FixedVersion":"1.2.12","Severity":"LOW"}]}]}`
+
+	grypeReportJSON = `{"matches":[
+{"vulnerability":{"id":"CVE-2020-0001","severity":"Critical","fix":{"versions":["1.1.1h"]}},"artifact":{"name":"openssl","version":"1.1.1g"}},
+{"vulnerability":{"id":"CVE-2020-0004","severity":"Negligible","fix":{"versions":[]}},"artifact":{"name":"busybox","version":"1.31.1"}}]}`
+)
+
+func TestParseReports(t *testing.T) {
+	vulnerabilities, err := ParseTrivyReport([]byte(trivyReportJSON))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 3)
+	assert.Equal(t, Vulnerability{ID: "CVE-2020-0001", Package: "openssl", Version: "1.1.1g", FixedVersion: "1.1.1h", Severity: "CRITICAL"}, vulnerabilities[0])
+
+	vulnerabilities, err = ParseTrivyReport([]byte(`[{"Target":"myapp","Vulnerabilities":[{"VulnerabilityID":"CVE-2020-0002","Severity":"HIGH"}]}]`))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 1)
+	assert.Equal(t, "CVE-2020-0002", vulnerabilities[0].ID)
+
+	vulnerabilities, err = ParseGrypeReport([]byte(grypeReportJSON))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 2)
+	assert.Equal(t, Vulnerability{ID: "CVE-2020-0004", Package: "busybox", Version: "1.31.1", Severity: "Negligible"}, vulnerabilities[1])
+}
+
+func TestEvaluateScan(t *testing.T) {
+	vulnerabilities, err := ParseTrivyReport([]byte(trivyReportJSON))
+	require.NoError(t, err)
+
+	summary := EvaluateScan("myapp:1.0.0", vulnerabilities, &config.ImageScanConfig{Scanner: ScannerTrivy, Severity: "HIGH"})
+	assert.False(t, summary.Passed)
+	assert.Equal(t, 1, summary.Critical)
+	assert.Equal(t, 1, summary.High)
+	assert.Equal(t, 1, summary.Low)
+	assert.Equal(t, []string{"CVE-2020-0001", "CVE-2020-0002"}, summary.Blocking)
+
+	summary = EvaluateScan("myapp:1.0.0", vulnerabilities, &config.ImageScanConfig{Scanner: ScannerTrivy, Severity: "HIGH", IgnoreUnfixed: true, Ignore: []string{"CVE-2020-0001"}})
+	assert.True(t, summary.Passed)
+	assert.Equal(t, 0, summary.Critical)
+	assert.Equal(t, 0, summary.High)
+	assert.Empty(t, summary.Blocking)
+	assert.Contains(t, ScanComment(summary, vulnerabilities), "No vulnerabilities of severity HIGH or higher")
+
+	merged := MergeScanConfig(&config.ImageScanConfig{Scanner: ScannerGrype, Severity: "LOW", Ignore: []string{"CVE-1"}}, &config.ImageScanConfig{Severity: "HIGH", Ignore: []string{"CVE-1", "CVE-2"}})
+	assert.Equal(t, &config.ImageScanConfig{Scanner: ScannerGrype, Severity: "HIGH", Ignore: []string{"CVE-1", "CVE-2"}}, merged)
+}
+
+func TestScanComment(t *testing.T) {
+	vulnerabilities := []Vulnerability{}
+	for i := 1; i <= maxCommentVulnerabilities+1; i++ {
+		vulnerabilities = append(vulnerabilities, Vulnerability{ID: fmt.Sprintf("CVE-2020-%04d", i), Package: "openssl", Version: "1.1.1g", Severity: "CRITICAL"})
+	}
+	summary := EvaluateScan("myapp:1.0.0", vulnerabilities[:maxCommentVulnerabilities], &config.ImageScanConfig{Scanner: ScannerTrivy, Severity: "HIGH"})
+	comment := ScanComment(summary, vulnerabilities[:maxCommentVulnerabilities])
+	assert.Contains(t, comment, "CVE-2020-0020")
+	assert.NotContains(t, comment, "more", "all the vulnerabilities should be listed")
+	assert.Contains(t, comment, ScanCommentMarker("myapp:1.0.0"))
+	assert.Equal(t, ScanCommentMarker("myapp:1.0.0"), ScanCommentMarker("myapp:1.0.1"), "the comment should be updated by the scans of later versions")
+
+	summary = EvaluateScan("myapp:1.0.0", vulnerabilities, &config.ImageScanConfig{Scanner: ScannerTrivy, Severity: "HIGH"})
+	comment = ScanComment(summary, vulnerabilities)
+	assert.NotContains(t, comment, "CVE-2020-0021")
+	assert.Contains(t, comment, "* and 1 more\n")
+}
+
+func TestStepScanImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-step-scan-image")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, config.ProjectConfigFileName), []byte("imageScan:\n  scanner: grype\n  severity: high\n"), util.DefaultFileWritePermissions))
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	var commands []*util.Command
+	o := &StepScanImageOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+			CommandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, cmd)
+				file := cmd.Args[util.StringArrayIndex(cmd.Args, "--file")+1]
+				return "", ioutil.WriteFile(file, []byte(grypeReportJSON), util.DefaultFileWritePermissions)
+			},
+		},
+		Image:      "10.0.0.1:5000/myorg/myapp:1.2.3",
+		Dir:        dir,
+		NoComment:  true,
+		NoActivity: true,
+	}
+	err = o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CVE-2020-0001")
+	require.Len(t, commands, 1)
+	assert.Equal(t, "grype", commands[0].Name)
+	assert.Equal(t, "10.0.0.1:5000/myorg/myapp:1.2.3", commands[0].Args[0])
+
+	o.Ignore = []string{"CVE-2020-0001"}
+	require.NoError(t, o.Run())
+
+	o.Severity = "SEVERE"
+	assert.Error(t, o.Run())
+}
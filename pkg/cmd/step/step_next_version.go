@@ -5,6 +5,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -26,6 +27,7 @@ import (
 	version "github.com/hashicorp/go-version"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/spf13/cobra"
 )
@@ -42,6 +44,7 @@ type StepNextVersionOptions struct {
 	Filename        string
 	Dir             string
 	ChartsDir       string
+	TagPrefix       string
 	Tag             bool
 	UseGitTagOnly   bool
 	NewVersion      string
@@ -70,6 +73,9 @@ var (
 
 		# lets use git to create a new version from a tag and tag git
         jx step next-version --use-git-tag-only --tag
+
+		# lets version an application of a monorepo using the tags with its prefix such as 'foo/v1.2.3'
+		jx step next-version --use-git-tag-only --tag --tag-prefix foo/ --charts-dir services/foo/charts/foo
               
 `)
 )
@@ -97,12 +103,16 @@ func NewCmdStepNextVersion(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "the directory to look for files that contain a pom.xml or Makefile with the project version to bump")
 	cmd.Flags().StringVarP(&options.ChartsDir, "charts-dir", "", "", "the directory of the chart to update the version (in conjunction with --tag)")
 	cmd.Flags().BoolVarP(&options.Tag, "tag", "t", false, "tag and push new version")
+	cmd.Flags().StringVarP(&options.TagPrefix, "tag-prefix", "", "", "the prefix of the git tags of the versions before the 'v' such as the application name of a monorepo. Defaults to $JX_TAG_PREFIX")
 	cmd.Flags().BoolVarP(&options.UseGitTagOnly, "use-git-tag-only", "", false, "only use a git tag so work out new semantic version, else specify filename [pom.xml,package.json,Makefile,Chart.yaml]")
 	cmd.Flags().BoolVarP(&options.SemanticRelease, "semantic-release", "", false, "use conventional commits to determine next version. Ignores the --use-git-tag-only and --version options See https://github.com/angular/angular.js/blob/master/DEVELOPERS.md#-git-commit-guidelines")
 	return cmd
 }
 
 func (o *StepNextVersionOptions) Run() error {
+	if o.TagPrefix == "" {
+		o.TagPrefix = os.Getenv(config.TagPrefixEnvVar)
+	}
 
 	var err error
 	if o.SemanticRelease {
@@ -110,7 +120,7 @@ func (o *StepNextVersionOptions) Run() error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		rev, tag, err := o.Git().GetCommitPointedToByLatestTag(o.Dir)
+		rev, tag, err := o.getCommitPointedToByLatestTag()
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -152,6 +162,7 @@ func (o *StepNextVersionOptions) Run() error {
 			Flags: StepTagFlags{
 				Version:   o.NewVersion,
 				ChartsDir: o.ChartsDir,
+				TagPrefix: o.TagPrefix,
 			},
 			StepOptions: o.StepOptions,
 		}
@@ -280,6 +291,13 @@ func (o *StepNextVersionOptions) getLatestTag() (string, error) {
 	versionsRaw = make([]string, len(tags))
 	for i, tag := range tags {
 		log.Logger().Debugf("found tag %s", tag)
+		if o.TagPrefix != "" {
+			// lets ignore the versions of the other applications of a monorepo
+			if !strings.HasPrefix(tag, o.TagPrefix) {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, o.TagPrefix)
+		}
 		tag = strings.TrimPrefix(tag, "v")
 		if tag != "" {
 			versionsRaw[i] = tag
@@ -312,6 +330,41 @@ func (o *StepNextVersionOptions) getLatestTag() (string, error) {
 	return versions[latest-1].String(), nil
 }
 
+// getCommitPointedToByLatestTag returns the commit and version of the latest tag. With a tag prefix the latest tag is
+// the highest version of the tags with the prefix so that the tags of the other applications of a monorepo are ignored
+func (o *StepNextVersionOptions) getCommitPointedToByLatestTag() (string, string, error) {
+	if o.TagPrefix == "" {
+		return o.Git().GetCommitPointedToByLatestTag(o.Dir)
+	}
+	tags, err := o.Git().Tags(o.Dir)
+	if err != nil {
+		return "", "", err
+	}
+	latestTag := ""
+	var latest *semver.Version
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, o.TagPrefix) {
+			continue
+		}
+		v, err := semver.Parse(strings.TrimPrefix(strings.TrimPrefix(tag, o.TagPrefix), "v"))
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.GT(*latest) {
+			latest = &v
+			latestTag = tag
+		}
+	}
+	if latest == nil {
+		return "", "", fmt.Errorf("no existing tags found with prefix %s", o.TagPrefix)
+	}
+	rev, err := o.Git().GetCommitPointedToByTag(o.Dir, latestTag)
+	if err != nil {
+		return "", "", err
+	}
+	return rev, latest.String(), nil
+}
+
 func (o *StepNextVersionOptions) getNewVersionFromTagAndFile() (string, error) {
 
 	// get the latest github tag
@@ -44,6 +44,7 @@ type StepTagFlags struct {
 	Dir                  string
 	ChartsDir            string
 	ChartValueRepository string
+	TagPrefix            string
 	NoApply              bool
 }
 
@@ -91,6 +92,7 @@ func NewCmdStepTag(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Flags.ChartsDir, "charts-dir", "d", "", "the directory of the chart to update the version")
 	cmd.Flags().StringVarP(&options.Flags.Dir, "dir", "", "", "the directory which may contain a 'jenkins-x.yml'")
+	cmd.Flags().StringVarP(&options.Flags.TagPrefix, "tag-prefix", "", "", "the prefix of the tag before the 'v' such as the application name of a monorepo. Defaults to $JX_TAG_PREFIX")
 	cmd.Flags().StringVarP(&options.Flags.ChartValueRepository, "charts-value-repository", "r", "", "the fully qualified image name without the version tag. e.g. 'dockerregistry/myorg/myapp'")
 
 	cmd.Flags().BoolVarP(&options.Flags.NoApply, "no-apply", "", false, "Do not push the tag to the server, this is used for example in dry runs")
@@ -139,7 +141,11 @@ func (o *StepTagOptions) Run() error {
 		return err
 	}
 
-	tag := "v" + o.Flags.Version
+	tagPrefix := o.Flags.TagPrefix
+	if tagPrefix == "" {
+		tagPrefix = os.Getenv(config.TagPrefixEnvVar)
+	}
+	tag := tagPrefix + "v" + o.Flags.Version
 	log.Logger().Debugf("performing git commit")
 	err = o.Git().AddCommit("", fmt.Sprintf("release %s", o.Flags.Version))
 	if err != nil {
@@ -12,7 +12,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream/versionstreamrepo"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
@@ -30,6 +32,9 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// offlineDockerRegistry the placeholder of the docker registry of the effective pipeline created without a cluster
+const offlineDockerRegistry = "docker-registry"
+
 // StepSyntaxEffectiveOptions contains the command line flags
 type StepSyntaxEffectiveOptions struct {
 	step.StepOptions
@@ -42,6 +47,7 @@ type StepSyntaxEffectiveOptions struct {
 	DefaultImage      string
 	UseKaniko         bool
 	KanikoImage       string
+	ManifestToolImage string
 	ProjectID         string
 	DockerRegistry    string
 	DockerRegistryOrg string
@@ -52,6 +58,10 @@ type StepSyntaxEffectiveOptions struct {
 
 	ValidateInCluster bool
 
+	// Offline creates the effective pipeline without connecting to the cluster using the defaults of the team settings,
+	// no pod templates and the cached version stream if there is one
+	Offline bool
+
 	PodTemplates map[string]*corev1.Pod
 
 	GitInfo         *gits.GitRepository
@@ -114,6 +124,7 @@ func (o *StepSyntaxEffectiveOptions) addFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.UseKaniko, "use-kaniko", "", true, "Enables using kaniko directly for building docker images")
 	cmd.Flags().BoolVarP(&o.ShortView, "short", "s", false, "Use short concise output")
 	cmd.Flags().StringVarP(&o.KanikoImage, "kaniko-image", "", syntax.KanikoDockerImage, "The docker image for Kaniko")
+	cmd.Flags().StringVarP(&o.ManifestToolImage, "manifest-tool-image", "", syntax.ManifestToolDockerImage, "The docker image for pushing the manifest lists of multi-arch images built with Kaniko")
 	cmd.Flags().StringVarP(&o.ProjectID, "project-id", "", "", "The cloud project ID. If not specified we default to the install project")
 	cmd.Flags().StringVarP(&o.DockerRegistry, "docker-registry", "", "", "The Docker Registry host name to use which is added as a prefix to docker images")
 	cmd.Flags().StringVarP(&o.DockerRegistryOrg, "docker-registry-org", "", "", "The Docker registry organisation. If blank the git repository owner is used")
@@ -122,21 +133,39 @@ func (o *StepSyntaxEffectiveOptions) addFlags(cmd *cobra.Command) {
 
 // Run implements this command
 func (o *StepSyntaxEffectiveOptions) Run() error {
-	settings, err := o.TeamSettings()
+	effectiveConfig, err := o.loadEffectivePipeline()
 	if err != nil {
 		return err
 	}
+	return o.writeEffectivePipeline(effectiveConfig)
+}
 
-	kubeClient, ns, err := o.KubeClientAndDevNamespace()
-	if err != nil {
-		return errors.Wrap(err, "unable to create Kube client")
+// loadEffectivePipeline loads the project config of the current directory and generates its effective pipelines
+func (o *StepSyntaxEffectiveOptions) loadEffectivePipeline() (*config.ProjectConfig, error) {
+	var settings *v1.TeamSettings
+	var kubeClient kubernetes.Interface
+	var ns string
+	var err error
+	if o.Offline {
+		settings = &v1.TeamSettings{}
+		settings.DefaultMissingValues()
+	} else {
+		settings, err = o.TeamSettings()
+		if err != nil {
+			return nil, err
+		}
+
+		kubeClient, ns, err = o.KubeClientAndDevNamespace()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create Kube client")
+		}
 	}
 
 	if o.ProjectID == "" {
-		if !o.RemoteCluster {
+		if !o.RemoteCluster && !o.Offline {
 			data, err := kube.ReadInstallValues(kubeClient, ns)
 			if err != nil {
-				return errors.Wrapf(err, "failed to read install values from namespace %s", ns)
+				return nil, errors.Wrapf(err, "failed to read install values from namespace %s", ns)
 			}
 			o.ProjectID = data["projectID"]
 		}
@@ -148,44 +177,60 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 		o.DefaultImage = syntax.DefaultContainerImage
 	}
 	if o.VersionResolver == nil {
-		o.VersionResolver, err = o.GetVersionResolver()
-		if err != nil {
-			return err
+		if o.Offline {
+			o.VersionResolver = o.cachedVersionResolver(settings)
+		} else {
+			o.VersionResolver, err = o.GetVersionResolver()
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	if o.KanikoImage == "" {
 		o.KanikoImage = syntax.KanikoDockerImage
 	}
-	o.KanikoImage, err = o.VersionResolver.ResolveDockerImage(o.KanikoImage)
-	if err != nil {
-		return err
+	if o.ManifestToolImage == "" {
+		o.ManifestToolImage = syntax.ManifestToolDockerImage
+	}
+	if o.VersionResolver != nil {
+		o.KanikoImage, err = o.VersionResolver.ResolveDockerImage(o.KanikoImage)
+		if err != nil {
+			return nil, err
+		}
+		o.ManifestToolImage, err = o.VersionResolver.ResolveDockerImageDigest(o.ManifestToolImage)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if o.Verbose {
 		log.Logger().Info("setting up docker registry\n")
 	}
 
+	if o.DockerRegistry == "" && o.Offline {
+		o.DockerRegistry = offlineDockerRegistry
+	}
 	if o.DockerRegistry == "" {
 		data, err := kube.GetConfigMapData(kubeClient, kube.ConfigMapJenkinsDockerRegistry, ns)
 		if err != nil {
-			return fmt.Errorf("could not find ConfigMap %s in namespace %s: %s", kube.ConfigMapJenkinsDockerRegistry, ns, err)
+			return nil, fmt.Errorf("could not find ConfigMap %s in namespace %s: %s", kube.ConfigMapJenkinsDockerRegistry, ns, err)
 		}
 		o.DockerRegistry = data["docker.registry"]
 		if o.DockerRegistry == "" {
-			return util.MissingOption("docker-registry")
+			return nil, util.MissingOption("docker-registry")
 		}
 	}
 
 	workingDir, err := os.Getwd()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	o.GitInfo, err = o.FindGitInfo(workingDir)
 	if err != nil {
-		return errors.Wrapf(err, "failed to find git information from dir %s", workingDir)
+		return nil, errors.Wrapf(err, "failed to find git information from dir %s", workingDir)
 	}
 	projectConfig, projectConfigFile, err := o.LoadProjectConfig(workingDir)
 	if err != nil {
-		return errors.Wrapf(err, "failed to load project config in dir %s", workingDir)
+		return nil, errors.Wrapf(err, "failed to load project config in dir %s", workingDir)
 	}
 	if o.BuildPackURL == "" || o.BuildPackRef == "" {
 		if projectConfig.BuildPackGitURL != "" {
@@ -200,10 +245,10 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 		}
 	}
 	if o.BuildPackURL == "" {
-		return util.MissingOption("url")
+		return nil, util.MissingOption("url")
 	}
 	if o.BuildPackRef == "" {
-		return util.MissingOption("ref")
+		return nil, util.MissingOption("ref")
 	}
 
 	if o.Pack == "" {
@@ -212,34 +257,51 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 	if o.Pack == "" {
 		o.Pack, err = o.DiscoverBuildPack(workingDir, projectConfig, o.Pack)
 		if err != nil {
-			return errors.Wrapf(err, "failed to discover the build pack")
+			return nil, errors.Wrapf(err, "failed to discover the build pack")
 		}
 	}
 
 	if o.Pack == "" {
-		return util.MissingOption("pack")
+		return nil, util.MissingOption("pack")
 	}
 
-	o.PodTemplates, err = kube.LoadPodTemplates(kubeClient, ns)
-	if err != nil {
-		return err
+	if !o.Offline {
+		o.PodTemplates, err = kube.LoadPodTemplates(kubeClient, ns)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	packsDir, err := gitresolver.InitBuildPack(o.Git(), o.BuildPackURL, o.BuildPackRef)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	resolver, err := gitresolver.CreateResolver(packsDir, o.Git())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	effectiveConfig, err := o.CreateEffectivePipeline(packsDir, projectConfig, projectConfigFile, resolver)
-	if err != nil {
-		return err
+	return o.CreateEffectivePipeline(packsDir, projectConfig, projectConfigFile, resolver)
+}
+
+// cachedVersionResolver returns the resolver of the cached clone of the version stream or nil if it has not been cached
+// so that the images of the effective pipeline are not resolved
+func (o *StepSyntaxEffectiveOptions) cachedVersionResolver(settings *v1.TeamSettings) *versionstream.VersionResolver {
+	cache, err := versionstreamrepo.NewCache(0, true)
+	if err == nil {
+		var versionsDir string
+		versionsDir, _, err = cache.CloneJXVersionsRepo("", "", settings, o.Git(), true, false, o.GetIOFileHandles())
+		if err == nil {
+			return &versionstream.VersionResolver{VersionsDir: versionsDir}
+		}
 	}
+	log.Logger().Warnf("not resolving the images of the effective pipeline as there is no cached version stream: %s", err.Error())
+	return nil
+}
 
+// writeEffectivePipeline writes the effective pipelines to the output file or directory or to the console
+func (o *StepSyntaxEffectiveOptions) writeEffectivePipeline(effectiveConfig *config.ProjectConfig) error {
 	if o.ShortView {
 		effectiveConfig = o.makeConcisePipeline(effectiveConfig)
 	}
@@ -407,9 +469,9 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 			PodTemplates:      o.PodTemplates,
 			CustomImage:       o.CustomImage,
 			DefaultImage:      o.DefaultImage,
-			WorkspaceDir:      o.getWorkspaceDir(),
+			WorkspaceDir:      o.getProjectWorkspaceDir(projectConfig),
 			GitHost:           o.GitInfo.Host,
-			GitName:           o.GitInfo.Name,
+			GitName:           o.getProjectAppName(projectConfig),
 			GitOrg:            o.GitInfo.Organisation,
 			ProjectID:         o.ProjectID,
 			DockerRegistry:    o.getDockerRegistry(projectConfig),
@@ -426,10 +488,27 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 		}
 	}
 
+	err = syntax.ValidatePlatforms(pipelineConfig.Platforms)
+	if err != nil {
+		return nil, err
+	}
+	cnbBuilder := pipelineConfig.CNBBuilder
+	if cnbBuilder != "" {
+		if len(pipelineConfig.Platforms) > 0 {
+			return nil, errors.Errorf("the platforms %s cannot be built with the Cloud Native Buildpacks builder %s", strings.Join(pipelineConfig.Platforms, ", "), cnbBuilder)
+		}
+		if o.VersionResolver != nil {
+			cnbBuilder, err = o.VersionResolver.ResolveDockerImageDigest(cnbBuilder)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve the Cloud Native Buildpacks builder %s in the version stream", pipelineConfig.CNBBuilder)
+			}
+		}
+	}
+
 	// Replace placeholders in directories.
 	replacePlaceholderArgs := syntax.StepPlaceholderReplacementArgs{
-		WorkspaceDir:      o.getWorkspaceDir(),
-		GitName:           o.GitInfo.Name,
+		WorkspaceDir:      o.getProjectWorkspaceDir(projectConfig),
+		GitName:           o.getProjectAppName(projectConfig),
 		GitOrg:            o.GitInfo.Organisation,
 		GitHost:           o.GitInfo.Host,
 		ProjectID:         o.ProjectID,
@@ -437,6 +516,9 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 		DockerRegistryOrg: o.GetDockerRegistryOrg(projectConfig, o.GitInfo),
 		KanikoImage:       o.KanikoImage,
 		UseKaniko:         o.UseKaniko,
+		Platforms:         pipelineConfig.Platforms,
+		ManifestToolImage: o.ManifestToolImage,
+		CNBBuilder:        cnbBuilder,
 	}
 	parsed.ReplacePlaceholdersInStepAndStageDirs(replacePlaceholderArgs)
 	parsed.AddContainerEnvVarsToPipeline(pipelineConfig.Env)
@@ -451,6 +533,12 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 		}
 		parsed.Options.ContainerOptions = mergedContainer
 	}
+	if len(pipelineConfig.Caches) > 0 {
+		if parsed.Options == nil {
+			parsed.Options = &syntax.RootOptions{}
+		}
+		parsed.Options.Caches = syntax.CombineCaches(pipelineConfig.Caches, parsed.Options.Caches)
+	}
 
 	for _, override := range pipelines.Overrides {
 		if override.MatchesPipeline(kind) {
@@ -508,6 +596,24 @@ func (o *StepSyntaxEffectiveOptions) getWorkspaceDir() string {
 	return filepath.Join("/workspace", o.SourceName)
 }
 
+// getProjectWorkspaceDir returns the directory the steps run in which is the directory of the application for a
+// monorepo
+func (o *StepSyntaxEffectiveOptions) getProjectWorkspaceDir(projectConfig *config.ProjectConfig) string {
+	if projectConfig.MonorepoApp != nil && projectConfig.MonorepoApp.Dir != "" {
+		return filepath.Join(o.getWorkspaceDir(), projectConfig.MonorepoApp.Dir)
+	}
+	return o.getWorkspaceDir()
+}
+
+// getProjectAppName returns the name of the application used for its image and chart which is the repository name
+// unless the application is in a monorepo
+func (o *StepSyntaxEffectiveOptions) getProjectAppName(projectConfig *config.ProjectConfig) string {
+	if projectConfig.MonorepoApp != nil && projectConfig.MonorepoApp.Name != "" {
+		return projectConfig.MonorepoApp.Name
+	}
+	return o.GitInfo.Name
+}
+
 func (o *StepSyntaxEffectiveOptions) getDockerRegistry(projectConfig *config.ProjectConfig) string {
 	dockerRegistry := o.DockerRegistry
 	if dockerRegistry == "" {
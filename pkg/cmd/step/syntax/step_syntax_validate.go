@@ -1,28 +1,63 @@
 package syntax
 
 import (
+	"os"
+
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 // StepSyntaxValidateOptions contains the command line flags
 type StepSyntaxValidateOptions struct {
-	step.StepOptions
+	StepSyntaxEffectiveOptions
+
+	Explain bool
 }
 
+var (
+	stepSyntaxValidateLong = templates.LongDesc(`
+		Validates the pipeline YAML files and build packs.
+
+		With --explain the jenkins-x.yml in the current directory is resolved into its effective pipelines after the
+		build pack inheritance and overrides, which are written as YAML, and then checked for common mistakes such as
+		overrides of undefined pipelines or stages and steps without an image. Env vars defined more than once are
+		reported as warnings.
+
+		The effective pipeline is created without connecting to a cluster so the default build pack of the team settings,
+		no pod templates and the cached version stream, if there is one, are used. Use --docker-registry, --project-id
+		and --url to render them rather than their placeholders.
+`)
+
+	stepSyntaxValidateExample = templates.Examples(`
+		# view the effective pipeline of the jenkins-x.yml in the current directory and check it for common mistakes
+		jx step syntax validate --explain
+
+		# check the effective pipeline of the jenkins-x-bdd.yml only writing it to a file
+		jx step syntax validate --explain --context bdd --output-file effective.yml
+`)
+)
+
 // NewCmdStepSyntaxValidate Steps a command object for the "step" command
 func NewCmdStepSyntaxValidate(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &StepSyntaxValidateOptions{
-		StepOptions: step.StepOptions{
-			CommonOptions: commonOpts,
+		StepSyntaxEffectiveOptions: StepSyntaxEffectiveOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
 		},
 	}
 
 	cmd := &cobra.Command{
-		Use:   "validate",
-		Short: "validate [command]",
+		Use:     "validate",
+		Short:   "validate [command]",
+		Long:    stepSyntaxValidateLong,
+		Example: stepSyntaxValidateExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
 			options.Args = args
@@ -30,6 +65,10 @@ func NewCmdStepSyntaxValidate(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().BoolVarP(&options.Explain, "explain", "", false, "Writes the effective pipeline as YAML and checks it for common mistakes")
+	cmd.Flags().StringArrayVarP(&options.CustomEnvs, "env", "e", nil, "List of custom environment variables to be applied to resources that are created")
+	options.addFlags(cmd)
+
 	cmd.AddCommand(NewCmdStepSyntaxValidateBuildPacks(commonOpts))
 	cmd.AddCommand(NewCmdStepSyntaxValidatePipeline(commonOpts))
 	return cmd
@@ -37,5 +76,39 @@ func NewCmdStepSyntaxValidate(commonOpts *opts.CommonOptions) *cobra.Command {
 
 // Run implements this command
 func (o *StepSyntaxValidateOptions) Run() error {
-	return o.Cmd.Help()
+	if !o.Explain {
+		return o.Cmd.Help()
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// the env vars are checked before the effective pipeline is created as it combines the pipeline config env vars
+	projectConfig, projectConfigFile, err := o.LoadProjectConfig(workingDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load project config in dir %s", workingDir)
+	}
+	for _, warning := range LintDuplicateEnvVars(projectConfig.PipelineConfig) {
+		log.Logger().Warnf("%s: %s", projectConfigFile, warning)
+	}
+
+	o.Offline = true
+	effectiveConfig, err := o.loadEffectivePipeline()
+	if err != nil {
+		return err
+	}
+	err = o.writeEffectivePipeline(effectiveConfig)
+	if err != nil {
+		return err
+	}
+	issues := LintEffectivePipeline(effectiveConfig.PipelineConfig)
+	if len(issues) > 0 {
+		log.Logger().Errorf("One or more mistakes in the effective pipeline of %s:", projectConfigFile)
+		for _, issue := range issues {
+			log.Logger().Errorf("\t%s", issue)
+		}
+		return errors.New("FAILURE")
+	}
+	log.Logger().Infof("Successfully validated the effective pipeline of %s", util.ColorInfo(projectConfigFile))
+	return nil
 }
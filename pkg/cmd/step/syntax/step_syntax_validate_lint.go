@@ -0,0 +1,196 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// lifecycleStageNames the names of the build pack lifecycles which overrides can use as their stage
+var lifecycleStageNames = []string{"setup", "setVersion", "preBuild", "build", "postBuild", "promote", "post"}
+
+// LintEffectivePipeline returns the mistakes in the effective pipelines such as overrides of pipelines or stages which
+// are not defined and steps which have no image
+func LintEffectivePipeline(pipelineConfig *jenkinsfile.PipelineConfig) []string {
+	if pipelineConfig == nil {
+		return nil
+	}
+	answer := []string{}
+	pipelines := pipelineConfig.Pipelines.AllMap()
+	for i, override := range pipelineConfig.Pipelines.Overrides {
+		if override == nil {
+			continue
+		}
+		if override.Pipeline != "" && util.StringArrayIndex(jenkinsfile.PipelineKinds, strings.ToLower(override.Pipeline)) < 0 {
+			answer = append(answer, fmt.Sprintf("override %d refers to pipeline %s which is not one of %s", i+1, override.Pipeline, strings.Join(jenkinsfile.PipelineKinds, ", ")))
+			continue
+		}
+		if override.Stage == "" || util.StringArrayIndex(lifecycleStageNames, override.Stage) >= 0 {
+			continue
+		}
+		found := false
+		for kind, lifecycles := range pipelines {
+			if override.MatchesPipeline(kind) && lifecycles.Pipeline != nil && hasStage(lifecycles.Pipeline.Stages, override.Stage) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			answer = append(answer, fmt.Sprintf("override %d refers to stage %s which is not defined in the effective pipeline", i+1, override.Stage))
+		}
+	}
+	for _, kind := range jenkinsfile.PipelineKinds {
+		lifecycles := pipelines[kind]
+		if lifecycles == nil || lifecycles.Pipeline == nil {
+			continue
+		}
+		parsed := lifecycles.Pipeline
+		hasImage := agentHasImage(parsed.Agent) || (parsed.Options != nil && parsed.Options.ContainerOptions != nil && parsed.Options.ContainerOptions.Image != "")
+		for i := range parsed.Stages {
+			answer = append(answer, lintStageImages(kind, &parsed.Stages[i], hasImage)...)
+		}
+	}
+	return answer
+}
+
+// LintDuplicateEnvVars returns the environment variables which are defined more than once in the same env list of the
+// pipeline configuration, where all but one of the values would be silently ignored
+func LintDuplicateEnvVars(pipelineConfig *jenkinsfile.PipelineConfig) []string {
+	if pipelineConfig == nil {
+		return nil
+	}
+	answer := duplicateEnvVars("the pipeline config", pipelineConfig.Env)
+	if pipelineConfig.ContainerOptions != nil {
+		answer = append(answer, duplicateEnvVars("the container options", pipelineConfig.ContainerOptions.Env)...)
+	}
+	pipelines := pipelineConfig.Pipelines.AllMap()
+	for _, kind := range jenkinsfile.PipelineKinds {
+		lifecycles := pipelines[kind]
+		if lifecycles == nil {
+			continue
+		}
+		for _, named := range lifecycles.All() {
+			if named.Lifecycle != nil {
+				answer = append(answer, stepsDuplicateEnvVars(fmt.Sprintf("pipeline %s lifecycle %s", kind, named.Name), named.Lifecycle.Steps)...)
+			}
+		}
+		parsed := lifecycles.Pipeline
+		if parsed == nil {
+			continue
+		}
+		answer = append(answer, duplicateEnvVars("pipeline "+kind, parsed.Env)...)
+		if parsed.Options != nil && parsed.Options.ContainerOptions != nil {
+			answer = append(answer, duplicateEnvVars(fmt.Sprintf("the container options of pipeline %s", kind), parsed.Options.ContainerOptions.Env)...)
+		}
+		for i := range parsed.Stages {
+			answer = append(answer, stageDuplicateEnvVars(kind, &parsed.Stages[i])...)
+		}
+	}
+	return answer
+}
+
+func hasStage(stages []syntax.Stage, name string) bool {
+	for i := range stages {
+		stage := &stages[i]
+		if stage.Name == name || hasStage(stage.Stages, name) || hasStage(stage.Parallel, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func agentHasImage(agent *syntax.Agent) bool {
+	return agent != nil && (agent.Image != "" || agent.Label != "")
+}
+
+func lintStageImages(kind string, stage *syntax.Stage, hasImage bool) []string {
+	hasImage = hasImage || agentHasImage(stage.Agent) || (stage.Options != nil && stage.Options.RootOptions != nil &&
+		stage.Options.ContainerOptions != nil && stage.Options.ContainerOptions.Image != "")
+	answer := []string{}
+	for i := range stage.Steps {
+		answer = append(answer, lintStepImages(kind, stage.Name, &stage.Steps[i], hasImage)...)
+	}
+	for i := range stage.Stages {
+		answer = append(answer, lintStageImages(kind, &stage.Stages[i], hasImage)...)
+	}
+	for i := range stage.Parallel {
+		answer = append(answer, lintStageImages(kind, &stage.Parallel[i], hasImage)...)
+	}
+	return answer
+}
+
+func lintStepImages(kind string, stageName string, step *syntax.Step, hasImage bool) []string {
+	hasImage = hasImage || step.Image != "" || agentHasImage(step.Agent)
+	answer := []string{}
+	for _, child := range step.Steps {
+		if child != nil {
+			answer = append(answer, lintStepImages(kind, stageName, child, hasImage)...)
+		}
+	}
+	if step.Loop != nil {
+		for i := range step.Loop.Steps {
+			answer = append(answer, lintStepImages(kind, stageName, &step.Loop.Steps[i], hasImage)...)
+		}
+	}
+	if !hasImage && len(step.Steps) == 0 && step.Loop == nil {
+		answer = append(answer, fmt.Sprintf("step %s of stage %s in pipeline %s has no image and neither has its stage nor the pipeline", stepName(step), stageName, kind))
+	}
+	return answer
+}
+
+func stepName(step *syntax.Step) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	command := strings.TrimSpace(step.Command + " " + strings.Join(step.Arguments, " "))
+	if command == "" {
+		command = step.Sh
+	}
+	return fmt.Sprintf("'%s'", command)
+}
+
+func stageDuplicateEnvVars(kind string, stage *syntax.Stage) []string {
+	location := fmt.Sprintf("stage %s of pipeline %s", stage.Name, kind)
+	answer := duplicateEnvVars(location, stage.Env)
+	if stage.Options != nil && stage.Options.RootOptions != nil && stage.Options.ContainerOptions != nil {
+		answer = append(answer, duplicateEnvVars("the container options of "+location, stage.Options.ContainerOptions.Env)...)
+	}
+	for i := range stage.Steps {
+		answer = append(answer, stepsDuplicateEnvVars(location, []*syntax.Step{&stage.Steps[i]})...)
+	}
+	for i := range stage.Stages {
+		answer = append(answer, stageDuplicateEnvVars(kind, &stage.Stages[i])...)
+	}
+	for i := range stage.Parallel {
+		answer = append(answer, stageDuplicateEnvVars(kind, &stage.Parallel[i])...)
+	}
+	return answer
+}
+
+func stepsDuplicateEnvVars(location string, steps []*syntax.Step) []string {
+	answer := []string{}
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		answer = append(answer, duplicateEnvVars(fmt.Sprintf("step %s of %s", stepName(step), location), step.Env)...)
+		answer = append(answer, stepsDuplicateEnvVars(location, step.Steps)...)
+	}
+	return answer
+}
+
+func duplicateEnvVars(location string, envVars []corev1.EnvVar) []string {
+	answer := []string{}
+	counts := map[string]int{}
+	for _, e := range envVars {
+		counts[e.Name]++
+		if counts[e.Name] == 2 {
+			answer = append(answer, fmt.Sprintf("env var %s is defined more than once in %s", e.Name, location))
+		}
+	}
+	return answer
+}
@@ -0,0 +1,74 @@
+// +build unit
+
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/syntax"
+	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+const testLintPipelineConfig = `env:
+- name: FOO
+  value: a
+- name: FOO
+  value: b
+pipelines:
+  overrides:
+  - pipeline: release
+    stage: build
+    name: jx-promote
+  - pipeline: release
+    stage: missing
+  - pipeline: nightly
+  release:
+    pipeline:
+      agent:
+        image: maven
+      stages:
+      - name: build
+        steps:
+        - name: mvn
+          command: mvn install
+          env:
+          - name: BAR
+            value: a
+          - name: BAR
+            value: b
+  pullRequest:
+    pipeline:
+      stages:
+      - name: ci
+        parallel:
+        - name: unit
+          agent:
+            image: maven
+          steps:
+          - command: mvn test
+        - name: lint
+          steps:
+          - name: golint
+            command: golint
+`
+
+func TestLintPipelineConfig(t *testing.T) {
+	t.Parallel()
+
+	pipelineConfig := &jenkinsfile.PipelineConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte(testLintPipelineConfig), pipelineConfig))
+
+	assert.Equal(t, []string{
+		"override 2 refers to stage missing which is not defined in the effective pipeline",
+		"override 3 refers to pipeline nightly which is not one of release, pullrequest, feature",
+		"step golint of stage lint in pipeline pullrequest has no image and neither has its stage nor the pipeline",
+	}, syntax.LintEffectivePipeline(pipelineConfig))
+
+	assert.Equal(t, []string{
+		"env var FOO is defined more than once in the pipeline config",
+		"env var BAR is defined more than once in step mvn of stage build of pipeline release",
+	}, syntax.LintDuplicateEnvVars(pipelineConfig))
+}
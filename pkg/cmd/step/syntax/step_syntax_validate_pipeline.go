@@ -51,7 +51,7 @@ func NewCmdStepSyntaxValidatePipeline(commonOpts *opts.CommonOptions) *cobra.Com
 	cmd := &cobra.Command{
 		Use:     "pipeline",
 		Short:   "Validates a pipeline YAML file",
-		Long:    "Validates the pipeline YAML file in the current directory for the given context, or jenkins-x.yml by default",
+		Long:    "Validates the pipeline YAML file in the current directory for the given context, or jenkins-x.yml by default. Use 'jx step syntax validate --explain' to validate its effective pipeline",
 		Example: validatePipeline,
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
@@ -138,6 +138,9 @@ func (o *StepSyntaxValidatePipelineOptions) Run() error {
 		} else {
 			log.Logger().Infof("No lifecycles defined in %s", pipelineFile)
 		}
+		for _, warning := range LintDuplicateEnvVars(projectConfig.PipelineConfig) {
+			log.Logger().Warnf("Validation warning in %s: %s", pipelineFile, warning)
+		}
 	}
 
 	if hasErrors {
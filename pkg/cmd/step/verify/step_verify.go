@@ -38,10 +38,12 @@ func NewCmdStepVerify(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepVerifyInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyPackages(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyPod(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyPolicies(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyPreInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyRequirements(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyURL(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyValues(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyVersionStreamUpgrade(commonOpts))
 
 	return cmd
 }
@@ -406,7 +406,11 @@ func (o *StepVerifyEnvironmentsOptions) createDevEnvironmentRepository(gitInfo *
 	}
 	if isDefaultBootURL && fromGitRef == "master" {
 		// If the GitURL is not overridden and the GitRef is set to it's default value then look up the version number
-		resolver, err := o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+		versionStreamURL, err := o.ResolveVersionStreamURL(requirements.VersionStream)
+		if err != nil {
+			return nil, err
+		}
+		resolver, err := o.CreateVersionResolver(versionStreamURL, requirements.VersionStream.Ref)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create version resolver")
 		}
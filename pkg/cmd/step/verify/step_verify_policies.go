@@ -0,0 +1,236 @@
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_cmd "github.com/jenkins-x/jx/v2/pkg/cmd/step/helm"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/policies"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepVerifyPoliciesLong = templates.LongDesc(`
+		Verifies the rendered manifests of a release pass the rego policies of a git repository using conftest.
+
+		The manifests are those of a --plan created by 'jx step helm build --plan' or the YAML files in a --manifests-dir.
+		The git repository of the policies defaults to the 'policies' settings of the requirements of the team.
+
+		Any deny rule which fires fails the step and the violations are commented on the Pull Request when verifying the
+		manifests of a Pull Request. Warn rules are reported without failing the step.
+
+		'jx step helm build' verifies the policies of the requirements automatically so that the Pull Requests of promotions
+		to environments which violate them fail their pipeline and are not merged.
+`)
+
+	stepVerifyPoliciesExample = templates.Examples(`
+		# verifies the plan of the environment passes the policies of the team
+		jx step helm build --dir env --plan plan.json
+		jx step verify policies --plan plan.json
+
+		# verifies rendered manifests against the policies of a specific git repository and rego package
+		jx step verify policies --manifests-dir output --policy-git-url https://github.com/myorg/policies.git --namespace kubernetes
+`)
+)
+
+// StepVerifyPoliciesOptions contains the command line flags
+type StepVerifyPoliciesOptions struct {
+	step.StepOptions
+
+	Dir           string
+	PlanFile      string
+	ManifestsDir  string
+	PolicyGitURL  string
+	PolicyGitRef  string
+	PolicyPath    string
+	PolicyDir     string
+	Namespaces    []string
+	AllNamespaces bool
+	PullRequest   string
+	NoComment     bool
+}
+
+// NewCmdStepVerifyPolicies creates the command
+func NewCmdStepVerifyPolicies(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyPoliciesOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "policies",
+		Short:   "Verifies the rendered manifests of a release pass the rego policies of the team",
+		Long:    stepVerifyPoliciesLong,
+		Example: stepVerifyPoliciesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the git repository of the release used to find the Pull Request. Defaults to the current directory")
+	cmd.Flags().StringVarP(&options.PlanFile, "plan", "", "", "The plan created by 'jx step helm build --plan' whose manifests are verified")
+	cmd.Flags().StringVarP(&options.ManifestsDir, "manifests-dir", "", "", "The directory of the rendered YAML manifests which are verified if there is no --plan")
+	cmd.Flags().StringVarP(&options.PolicyGitURL, "policy-git-url", "", "", "The git URL of the repository of the policies. Defaults to the policies settings of the requirements")
+	cmd.Flags().StringVarP(&options.PolicyGitRef, "policy-git-ref", "", "", "The branch, tag or commit of the policies. Defaults to the policies settings of the requirements or the default branch")
+	cmd.Flags().StringVarP(&options.PolicyPath, "policy-path", "", "", "The directory of the policies in their git repository. Defaults to the policies settings of the requirements or '"+policies.DefaultPath+"'")
+	cmd.Flags().StringVarP(&options.PolicyDir, "policy-dir", "", "", "A local directory of the policies to use instead of cloning their git repository")
+	cmd.Flags().StringArrayVarP(&options.Namespaces, "namespace", "", nil, "The rego packages of the policies. Defaults to the policies settings of the requirements or '"+policies.DefaultNamespace+"'")
+	cmd.Flags().BoolVarP(&options.AllNamespaces, "all-namespaces", "", false, "Verifies the policies of all the rego packages")
+	cmd.Flags().StringVarP(&options.PullRequest, "pull-request", "p", "", "The number of the Pull Request to comment on. Defaults to $PULL_NUMBER")
+	cmd.Flags().BoolVarP(&options.NoComment, "no-comment", "", false, "Do not comment the violations on the Pull Request")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyPoliciesOptions) Run() error {
+	if o.PlanFile == "" && o.ManifestsDir == "" {
+		return util.MissingOption("plan")
+	}
+	tmpDir, err := ioutil.TempDir("", "jx-verify-policies-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestsDir, files, err := o.manifestFiles(tmpDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Logger().Warnf("There are no manifests to verify in %s", manifestsDir)
+		return nil
+	}
+	verifier := &policies.Verifier{
+		PolicyDir:     o.PolicyDir,
+		AllNamespaces: o.AllNamespaces,
+		Git:           o.Git(),
+		Err:           o.Err,
+		CommandRunner: o.CommandRunner,
+	}
+	results, err := verifier.Verify(tmpDir, o.effectivePolicyConfig(), manifestsDir, files)
+	if err != nil {
+		return err
+	}
+	failures, warnings := policies.CountViolations(results)
+	policies.LogViolations(results)
+
+	pullRequest := o.PullRequest
+	if pullRequest == "" {
+		pullRequest = os.Getenv("PULL_NUMBER")
+	}
+	if !o.NoComment && pullRequest != "" && failures+warnings > 0 {
+		err = o.commentOnPullRequest(pullRequest, policies.Comment(results))
+		if err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d policy violations found in the %d manifests", failures, len(files))
+	}
+	log.Logger().Infof("The %d manifests pass the policies with %d warnings", len(files), warnings)
+	return nil
+}
+
+// manifestFiles returns the directory of the manifests to verify and the paths of their files relative to it. The
+// manifests of a plan are written to the given temporary directory
+func (o *StepVerifyPoliciesOptions) manifestFiles(tmpDir string) (string, []string, error) {
+	var files []string
+	if o.PlanFile != "" {
+		plan, err := helm_cmd.LoadPlan(o.PlanFile)
+		if err != nil {
+			return "", nil, err
+		}
+		err = plan.Verify("")
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid plan %s", o.PlanFile)
+		}
+		var manifests []policies.Manifest
+		for _, manifest := range plan.Manifests {
+			manifests = append(manifests, policies.Manifest{Path: manifest.Path, Content: manifest.Content})
+		}
+		dir := filepath.Join(tmpDir, "manifests")
+		files, err = policies.WriteManifests(dir, manifests)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid plan %s", o.PlanFile)
+		}
+		return dir, files, nil
+	}
+
+	dir := o.ManifestsDir
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to find the manifests in %s", dir)
+	}
+	return dir, files, nil
+}
+
+// effectivePolicyConfig returns the policy settings of the flags defaulting to those of the requirements of the team
+func (o *StepVerifyPoliciesOptions) effectivePolicyConfig() *config.PolicyConfig {
+	answer := &config.PolicyConfig{}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Warnf("failed to get the team settings: %s", err.Error())
+	} else {
+		requirements, err := config.GetRequirementsConfigFromTeamSettings(teamSettings)
+		if err != nil {
+			log.Logger().Warnf("failed to get the requirements from team settings: %s", err.Error())
+		} else if requirements != nil && requirements.Policies != nil {
+			answer = requirements.Policies.DeepCopy()
+		}
+	}
+	if o.PolicyGitURL != "" {
+		answer.URL = o.PolicyGitURL
+	}
+	if o.PolicyGitRef != "" {
+		answer.Ref = o.PolicyGitRef
+	}
+	if o.PolicyPath != "" {
+		answer.Path = o.PolicyPath
+	}
+	if len(o.Namespaces) > 0 {
+		answer.Namespaces = o.Namespaces
+	}
+	return answer
+}
+
+// commentOnPullRequest comments on the Pull Request of the git repository of the release
+func (o *StepVerifyPoliciesOptions) commentOnPullRequest(pullRequest string, comment string) error {
+	dir := o.Dir
+	if dir == "" {
+		dir = "."
+	}
+	gitInfo, err := o.FindGitInfo(dir)
+	if err != nil {
+		log.Logger().Warnf("failed to find git repository so cannot comment on the Pull Request: %s", err.Error())
+		return nil
+	}
+	return o.CommentOnPullRequest(gitInfo, pullRequest, policies.CommentMarker, comment)
+}
@@ -0,0 +1,67 @@
+// +build unit
+
+package verify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	helm_cmd "github.com/jenkins-x/jx/v2/pkg/cmd/step/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepVerifyPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-step-verify-policies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	planFile := filepath.Join(dir, "plan.json")
+	require.NoError(t, helm_cmd.SavePlan(&helm_cmd.HelmPlan{
+		Chart:       "env",
+		ReleaseName: "jx",
+		Namespace:   "jx-staging",
+		Manifests: []helm_cmd.PlanManifest{
+			{Path: "env/charts/myapp/templates/deployment.yaml", Content: "kind: Deployment\n"},
+		},
+	}, planFile))
+
+	output := `[{"filename":"env/charts/myapp/templates/deployment.yaml","namespace":"kubernetes","successes":1,
+"warnings":[{"msg":"Deployment myapp has no liveness probe"}],
+"failures":[{"msg":"Containers must not run as root"}]}]`
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	var commands []*util.Command
+	o := &StepVerifyPoliciesOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+			CommandRunner: func(cmd *util.Command) (string, error) {
+				commands = append(commands, cmd)
+				manifest := filepath.Join(cmd.Dir, cmd.Args[len(cmd.Args)-1])
+				data, err := ioutil.ReadFile(manifest)
+				require.NoError(t, err)
+				assert.Equal(t, "kind: Deployment\n", string(data))
+				_, err = cmd.Out.Write([]byte(output))
+				return "", err
+			},
+		},
+		PlanFile:   planFile,
+		PolicyDir:  filepath.Join(dir, "policy"),
+		Namespaces: []string{"kubernetes"},
+		NoComment:  true,
+	}
+	err = o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 policy violations")
+	require.Len(t, commands, 1)
+	assert.Equal(t, "conftest", commands[0].Name)
+	assert.Equal(t, []string{"test", "--policy", filepath.Join(dir, "policy"), "--output", "json",
+		"--namespace", "kubernetes", "env/charts/myapp/templates/deployment.yaml"}, commands[0].Args)
+
+	output = `[{"filename":"env/charts/myapp/templates/deployment.yaml","namespace":"kubernetes","successes":2,"warnings":[],"failures":[]}]`
+	require.NoError(t, o.Run())
+}
@@ -675,7 +675,11 @@ func (o *StepVerifyPreInstallOptions) gatherRequirements(requirements *config.Re
 	}
 
 	// attempt to resolve the version stream ref to a tag
-	_, ref, err := o.CloneJXVersionsRepo(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+	versionStreamURL, err := o.ResolveVersionStreamURL(requirements.VersionStream)
+	if err != nil {
+		return nil, err
+	}
+	_, ref, err := o.CloneJXVersionsRepo(versionStreamURL, requirements.VersionStream.Ref)
 	if err != nil {
 		return nil, errors.Wrapf(err, "resolving version stream ref")
 	}
@@ -15,28 +15,28 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var (
 	verifyRequirementsLong = templates.LongDesc(`
-		Verifies all the helm requirements.yaml files have a version number populated from the Version Stream.
-
+		Verifies the jx-requirements.yml file against its JSON schema reporting any invalid or unknown fields, suggesting
+		the closest known field, and any deprecated fields along with the field to migrate them to.
 
+		Then verifies all the helm requirements.yaml files have a version number populated from the Version Stream.
 
+		The JSON schema of the jx-requirements.yml file can be generated via: jx step syntax schema --requirements
 
 ` + helper.SeeAlsoText("jx create project"))
 
 	verifyRequirementsExample = templates.Examples(`
-		Verifies all the helm requirements.yaml files have a version number populated from the Version Stream
-
-		# verify packages and fail if any are not valid:
-		jx step verify packages
+		# verify the jx-requirements.yml file and the versions of all the helm requirements.yaml files
+		jx step verify requirements
 
-		# override the error if the 'jx' binary is out of range (e.g. for development)
-        export JX_DISABLE_VERIFY_JX="true"
-		jx step verify packages
+		# only verify the jx-requirements.yml file against its schema
+		jx step verify requirements --schema-only
 	`)
 )
 
@@ -44,7 +44,8 @@ var (
 type StepVerifyRequirementsOptions struct {
 	step.StepOptions
 
-	Dir string
+	Dir        string
+	SchemaOnly bool
 }
 
 // NewCmdStepVerifyRequirements creates the `jx step verify pod` command
@@ -58,7 +59,7 @@ func NewCmdStepVerifyRequirements(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd := &cobra.Command{
 		Use:     "requirements",
 		Aliases: []string{"requirement", "req"},
-		Short:   "Verifies all the helm requirements.yaml files have a version number populated from the Version Stream",
+		Short:   "Verifies the jx-requirements.yml file and that all the helm requirements.yaml files have a version number populated from the Version Stream",
 		Long:    verifyRequirementsLong,
 		Example: verifyRequirementsExample,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -69,6 +70,7 @@ func NewCmdStepVerifyRequirements(commonOpts *opts.CommonOptions) *cobra.Command
 		},
 	}
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "the directory to recursively look for 'requirements.yaml' files")
+	cmd.Flags().BoolVarP(&options.SchemaOnly, "schema-only", "", false, "only verifies the jx-requirements.yml file against its JSON schema")
 
 	return cmd
 }
@@ -82,7 +84,15 @@ func (o *StepVerifyRequirementsOptions) Run() error {
 			return err
 		}
 	}
-	requirements, _, err := config.LoadRequirementsConfig(o.Dir)
+	fileName, err := config.FindRequirementsConfigFile(o.Dir)
+	if err != nil {
+		return err
+	}
+	err = o.verifyRequirementsConfig(fileName)
+	if err != nil || o.SchemaOnly {
+		return err
+	}
+	requirements, err := config.LoadRequirementsConfigFile(fileName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to load boot requirements")
 	}
@@ -113,6 +123,29 @@ func (o *StepVerifyRequirementsOptions) Run() error {
 	return err
 }
 
+// verifyRequirementsConfig reports the issues in the requirements file failing if any fields are invalid or unknown.
+// Deprecated fields are only warned about as they are still supported
+func (o *StepVerifyRequirementsOptions) verifyRequirementsConfig(fileName string) error {
+	issues, err := config.VerifyRequirementsFile(fileName)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, issue := range issues {
+		if issue.Deprecated {
+			log.Logger().Warnf("%s: %s", util.ColorWarning(issue.Field), issue.Message)
+			continue
+		}
+		log.Logger().Errorf("%s: %s", util.ColorError(issue.Field), issue.Message)
+		invalid++
+	}
+	if invalid > 0 {
+		return fmt.Errorf("found %d invalid fields in %s", invalid, fileName)
+	}
+	log.Logger().Infof("verified %s", util.ColorInfo(fileName))
+	return nil
+}
+
 func (o *StepVerifyRequirementsOptions) verifyRequirementsYAML(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string) error {
 	req, err := helm.LoadRequirementsFile(fileName)
 	if err != nil {
@@ -193,7 +193,7 @@ func (o *StepVerifyValuesOptions) secretClient(secretStorage config.SecretStorag
 	}
 
 	location := secrets.ToSecretsLocation(string(secretStorage))
-	return o.GetSecretURLClient(location)
+	return o.GetSecretURLClient(location, o.RequirementsDir)
 }
 
 func convertYamlToJson(yml []byte) ([]byte, error) {
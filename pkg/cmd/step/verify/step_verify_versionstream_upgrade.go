@@ -0,0 +1,553 @@
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// VersionStreamUpgradeLabel the label used to find the existing version stream upgrade pull request
+	VersionStreamUpgradeLabel = "jx/versionstream-upgrade"
+
+	defaultVersionStreamUpgradeBranch = "jx_versionstream_upgrade"
+)
+
+var (
+	verifyVersionStreamUpgradeLong = templates.LongDesc(`
+		Compares the versions of the charts in the helm requirements.yaml files, the versions of the images in the
+		values and pipeline files and the versions of the packages in the pipeline files, Dockerfiles and Makefiles of
+		an environment repository with the latest versions in the Version Stream.
+
+		The version of a package is the value of a '<PACKAGE>_VERSION' variable such as 'HELM_VERSION=3.2.4' or the
+		pipeline env var '- name: HELM_VERSION' with 'value: 3.2.4', which is the version of 'packages/helm' in the
+		Version Stream. Versions which are not semantic versions are not upgraded.
+
+		If any versions are out of date the files are updated and a Pull Request is created, or the existing
+		Pull Request is updated, with a summary of the changes.
+`)
+
+	verifyVersionStreamUpgradeExample = templates.Examples(`
+		# upgrade the versions in the environment repository in the current dir and create a Pull Request
+		jx step verify versionstream-upgrade
+
+		# only report the versions which are out of date
+		jx step verify versionstream-upgrade --dry-run
+	`)
+
+	imageKeyValueRegex = regexp.MustCompile(`^(\s*(?:-\s+)?)(image|repository|tag):(\s*)(["']?)([^"'\s#]+)(["']?)(.*)$`)
+
+	packageVersionRegex  = regexp.MustCompile(`^(.*?\b)([A-Z][A-Z0-9_]*)_VERSION(\s*(?::=|\?=|[:=])\s*)(["']?)([^"'\s#$]+)(["']?)(.*)$`)
+	packageEnvNameRegex  = regexp.MustCompile(`^\s*(?:-\s+)?name:\s*(["']?)([A-Z][A-Z0-9_]*)_VERSION(["']?)\s*$`)
+	packageEnvValueRegex = regexp.MustCompile(`^(\s*(?:-\s+)?value:\s*)(["']?)([^"'\s#]+)(["']?)(.*)$`)
+)
+
+// VersionUpgrade a version of a chart or image in a file which is older than the version in the version stream
+type VersionUpgrade struct {
+	Kind        versionstream.VersionKind
+	Name        string
+	File        string
+	FromVersion string
+	ToVersion   string
+	GitURL      string
+}
+
+// StepVerifyVersionStreamUpgradeOptions contains the command line flags
+type StepVerifyVersionStreamUpgradeOptions struct {
+	step.StepOptions
+
+	Dir          string
+	ImageFiles   []string
+	PackageFiles []string
+	BranchName   string
+	Base         string
+	Labels       []string
+	NoPR         bool
+	DryRun       bool
+
+	// Upgrades the versions which were upgraded
+	Upgrades []VersionUpgrade
+	// PullRequest the created or updated pull request
+	PullRequest *gits.PullRequestInfo
+}
+
+// NewCmdStepVerifyVersionStreamUpgrade creates the `jx step verify versionstream-upgrade` command
+func NewCmdStepVerifyVersionStreamUpgrade(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyVersionStreamUpgradeOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "versionstream-upgrade",
+		Aliases: []string{"versionstream-upgrades", "vs-upgrade"},
+		Short:   "Upgrades the versions of the charts and images in an environment repository to the latest versions in the Version Stream creating a Pull Request",
+		Long:    verifyVersionStreamUpgradeLong,
+		Example: verifyVersionStreamUpgradeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "the directory of the environment repository to recursively look for 'requirements.yaml' and image files")
+	cmd.Flags().StringArrayVarP(&options.ImageFiles, "image-files", "", []string{helm.ValuesFileName, helm.ValuesTemplateFileName, "jenkins-x*.yml"}, "the file name patterns of the files to look for images in")
+	cmd.Flags().StringArrayVarP(&options.PackageFiles, "package-files", "", []string{"jenkins-x*.yml", "Dockerfile*", "Makefile"}, "the file name patterns of the files to look for the '<PACKAGE>_VERSION' versions of packages in")
+	cmd.Flags().StringVarP(&options.BranchName, "branch", "", defaultVersionStreamUpgradeBranch, "the name of the branch used for the Pull Request")
+	cmd.Flags().StringVarP(&options.Base, "base", "", "master", "the base branch to create the Pull Request into")
+	cmd.Flags().StringArrayVarP(&options.Labels, "label", "l", []string{VersionStreamUpgradeLabel}, "the labels to add to the Pull Request. The first label is used to find an existing Pull Request to update")
+	cmd.Flags().BoolVarP(&options.NoPR, "no-pr", "", false, "upgrades the versions in the files without creating a Pull Request")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "only reports the versions which are out of date without modifying any files")
+
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyVersionStreamUpgradeOptions) Run() error {
+	if o.Dir == "" {
+		var err error
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+	requirements, _, err := config.LoadRequirementsConfig(o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load boot requirements")
+	}
+	vs := requirements.VersionStream
+
+	log.Logger().Debugf("Upgrading the versions in dir: %s using version stream URL: %s and git ref: %s", o.Dir, vs.URL, vs.Ref)
+
+	resolver, err := o.CreateVersionResolver(vs.URL, vs.Ref)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create version resolver")
+	}
+
+	o.Upgrades, err = o.UpgradeVersions(resolver)
+	if err != nil {
+		return err
+	}
+	if len(o.Upgrades) == 0 {
+		log.Logger().Infof("All the versions in %s are up to date with the version stream", util.ColorInfo(o.Dir))
+		return nil
+	}
+	for _, u := range o.Upgrades {
+		log.Logger().Infof("%s %s in %s is out of date: %s => %s", string(u.Kind), util.ColorInfo(u.Name), u.File, u.FromVersion, util.ColorInfo(u.ToVersion))
+	}
+	if o.DryRun || o.NoPR {
+		return nil
+	}
+	return o.createPullRequest()
+}
+
+// UpgradeVersions upgrades the versions of the charts, images and packages in the files of the directory which are older than
+// the versions in the version stream returning the upgrades. No files are modified if --dry-run is enabled
+func (o *StepVerifyVersionStreamUpgradeOptions) UpgradeVersions(resolver *versionstream.VersionResolver) ([]VersionUpgrade, error) {
+	repoPrefixes, err := resolver.GetRepositoryPrefixes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load repository prefixes")
+	}
+
+	upgrades := []VersionUpgrade{}
+	err = filepath.Walk(o.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if name == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == helm.RequirementsFileName {
+			answer, err := o.upgradeRequirementsYAML(resolver, repoPrefixes, path)
+			upgrades = append(upgrades, answer...)
+			return err
+		}
+		matched, err := matchesFilePattern(o.ImageFiles, name)
+		if err != nil {
+			return errors.Wrap(err, "invalid image file pattern")
+		}
+		if matched {
+			answer, err := o.upgradeImages(resolver, path)
+			upgrades = append(upgrades, answer...)
+			if err != nil {
+				return err
+			}
+		}
+		matched, err = matchesFilePattern(o.PackageFiles, name)
+		if err != nil {
+			return errors.Wrap(err, "invalid package file pattern")
+		}
+		if matched {
+			answer, err := o.upgradePackages(resolver, path)
+			upgrades = append(upgrades, answer...)
+			return err
+		}
+		return nil
+	})
+	return upgrades, err
+}
+
+func matchesFilePattern(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *StepVerifyVersionStreamUpgradeOptions) upgradeRequirementsYAML(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string) ([]VersionUpgrade, error) {
+	req, err := helm.LoadRequirementsFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load %s", fileName)
+	}
+
+	upgrades := []VersionUpgrade{}
+	for _, dep := range req.Dependencies {
+		// dependencies without a version are defaulted from the version stream by 'jx step verify requirements'
+		if dep.Version == "" || dep.Repository == "" {
+			continue
+		}
+		prefix := prefixes.PrefixForURL(dep.Repository)
+		if prefix == "" {
+			log.Logger().Debugf("ignoring dependency %s in file %s as the helm repository %s has no prefix in the version stream", dep.Name, fileName, dep.Repository)
+			continue
+		}
+		fullChartName := prefix + "/" + dep.Name
+		upgrade, err := o.versionUpgrade(resolver, versionstream.KindChart, fullChartName, dep.Version, fileName)
+		if err != nil {
+			return nil, err
+		}
+		if upgrade != nil {
+			dep.Version = upgrade.ToVersion
+			upgrades = append(upgrades, *upgrade)
+		}
+	}
+
+	if len(upgrades) > 0 && !o.DryRun {
+		err = helm.SaveFile(fileName, req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to save %s", fileName)
+		}
+	}
+	return upgrades, nil
+}
+
+// upgradeImages upgrades the tags of the images in the file. Images are either specified as 'image: name:tag' or as
+// 'repository: name' and 'tag: version' entries in the same block. The lines are replaced in place so that the
+// comments and layout of the file are preserved
+func (o *StepVerifyVersionStreamUpgradeOptions) upgradeImages(resolver *versionstream.VersionResolver, fileName string) ([]VersionUpgrade, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+
+	type imageBlock struct {
+		repository string
+		tag        string
+		tagLine    int
+	}
+	blocks := map[int]*imageBlock{}
+
+	upgrades := []VersionUpgrade{}
+	lines := strings.Split(string(data), "\n")
+	upgradeTag := func(block *imageBlock) error {
+		upgrade, err := o.versionUpgrade(resolver, versionstream.KindDocker, block.repository, block.tag, fileName)
+		if err != nil || upgrade == nil {
+			return err
+		}
+		groups := imageKeyValueRegex.FindStringSubmatch(lines[block.tagLine])
+		lines[block.tagLine] = groups[1] + groups[2] + ":" + groups[3] + groups[4] + upgrade.ToVersion + groups[6] + groups[7]
+		upgrades = append(upgrades, *upgrade)
+		return nil
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " -"))
+		for k := range blocks {
+			if k > indent {
+				delete(blocks, k)
+			}
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "-") {
+			// a new list item so lets not combine it with the previous item
+			delete(blocks, indent)
+		}
+		groups := imageKeyValueRegex.FindStringSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		value := groups[5]
+		if strings.Contains(value, "{{") {
+			continue
+		}
+		block := blocks[indent]
+		if block == nil {
+			block = &imageBlock{}
+			blocks[indent] = block
+		}
+
+		switch groups[2] {
+		case "image":
+			delete(blocks, indent)
+			idx := strings.LastIndex(value, ":")
+			if idx <= strings.LastIndex(value, "/") || strings.Contains(value, "@") {
+				continue
+			}
+			name := value[:idx]
+			upgrade, err := o.versionUpgrade(resolver, versionstream.KindDocker, name, value[idx+1:], fileName)
+			if err != nil {
+				return nil, err
+			}
+			if upgrade != nil {
+				lines[i] = groups[1] + groups[2] + ":" + groups[3] + groups[4] + name + ":" + upgrade.ToVersion + groups[6] + groups[7]
+				upgrades = append(upgrades, *upgrade)
+			}
+		case "repository":
+			block.repository = value
+			if block.tag != "" {
+				err = upgradeTag(block)
+				if err != nil {
+					return nil, err
+				}
+				delete(blocks, indent)
+			}
+		case "tag":
+			block.tag = value
+			block.tagLine = i
+			if block.repository != "" {
+				err = upgradeTag(block)
+				if err != nil {
+					return nil, err
+				}
+				delete(blocks, indent)
+			}
+		}
+	}
+
+	if len(upgrades) > 0 && !o.DryRun {
+		err = ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")), util.DefaultFileWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to save %s", fileName)
+		}
+	}
+	return upgrades, nil
+}
+
+// upgradePackages upgrades the versions of the packages in the file. Packages are either specified as
+// '<PACKAGE>_VERSION' variables, e.g. 'HELM_VERSION := 3.2.4' or 'ENV HELM_VERSION=3.2.4', or as the 'name' and 'value'
+// of a pipeline env var. The lines are replaced in place so that the comments and layout of the file are preserved
+func (o *StepVerifyVersionStreamUpgradeOptions) upgradePackages(resolver *versionstream.VersionResolver, fileName string) ([]VersionUpgrade, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+
+	upgrades := []VersionUpgrade{}
+	lines := strings.Split(string(data), "\n")
+	envPackage := ""
+	envIndent := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " -"))
+		if groups := packageEnvNameRegex.FindStringSubmatch(line); groups != nil {
+			envPackage = packageName(groups[2])
+			envIndent = indent
+			continue
+		}
+		if groups := packageEnvValueRegex.FindStringSubmatch(line); groups != nil && envPackage != "" && indent == envIndent {
+			name := envPackage
+			envPackage = ""
+			upgrade, err := o.versionUpgrade(resolver, versionstream.KindPackage, name, groups[3], fileName)
+			if err != nil {
+				return nil, err
+			}
+			if upgrade != nil {
+				lines[i] = groups[1] + groups[2] + upgrade.ToVersion + groups[4] + groups[5]
+				upgrades = append(upgrades, *upgrade)
+			}
+			continue
+		}
+		if indent <= envIndent {
+			envPackage = ""
+		}
+		groups := packageVersionRegex.FindStringSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		upgrade, err := o.versionUpgrade(resolver, versionstream.KindPackage, packageName(groups[2]), groups[5], fileName)
+		if err != nil {
+			return nil, err
+		}
+		if upgrade != nil {
+			lines[i] = groups[1] + groups[2] + "_VERSION" + groups[3] + groups[4] + upgrade.ToVersion + groups[6] + groups[7]
+			upgrades = append(upgrades, *upgrade)
+		}
+	}
+
+	if len(upgrades) > 0 && !o.DryRun {
+		err = ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")), util.DefaultFileWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to save %s", fileName)
+		}
+	}
+	return upgrades, nil
+}
+
+// packageName returns the name of the package of a '<PACKAGE>_VERSION' variable, e.g. 'helm' for 'HELM_VERSION'
+func packageName(variable string) string {
+	return strings.Replace(strings.ToLower(variable), "_", "-", -1)
+}
+
+// versionUpgrade returns the upgrade if the current version is older than the version in the version stream or nil
+// if it is up to date or not in the version stream
+func (o *StepVerifyVersionStreamUpgradeOptions) versionUpgrade(resolver *versionstream.VersionResolver, kind versionstream.VersionKind, name string, currentVersion string, fileName string) (*VersionUpgrade, error) {
+	stableVersion, err := resolver.StableVersion(kind, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the version of %s %s", string(kind), name)
+	}
+	if stableVersion.Version == "" && kind == versionstream.KindDocker && strings.HasPrefix(name, "docker.io/") {
+		stableVersion, err = resolver.StableVersion(kind, strings.TrimPrefix(name, "docker.io/"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the version of %s %s", string(kind), name)
+		}
+	}
+	if !isNewerVersion(currentVersion, stableVersion.Version) {
+		return nil, nil
+	}
+	file, err := filepath.Rel(o.Dir, fileName)
+	if err != nil {
+		file = fileName
+	}
+	return &VersionUpgrade{
+		Kind:        kind,
+		Name:        name,
+		File:        file,
+		FromVersion: currentVersion,
+		ToVersion:   stableVersion.Version,
+		GitURL:      stableVersion.GitURL,
+	}, nil
+}
+
+// isNewerVersion returns true if the latest version is newer than the current version. Versions which are not
+// semantic versions, such as 'latest' or a commit, are never considered newer so they are not downgraded
+func isNewerVersion(currentVersion string, latestVersion string) bool {
+	if latestVersion == "" || latestVersion == currentVersion {
+		return false
+	}
+	current, err := semver.ParseTolerant(currentVersion)
+	if err != nil {
+		log.Logger().Debugf("not upgrading version %s as it is not a semantic version", currentVersion)
+		return false
+	}
+	latest, err := semver.ParseTolerant(latestVersion)
+	if err != nil {
+		log.Logger().Debugf("not upgrading version %s to %s as it is not a semantic version", currentVersion, latestVersion)
+		return false
+	}
+	return latest.GT(current)
+}
+
+func (o *StepVerifyVersionStreamUpgradeOptions) createPullRequest() error {
+	gitInfo, provider, _, err := o.CreateGitProvider(o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "creating git provider for directory %s", o.Dir)
+	}
+	upstreamInfo, err := provider.GetRepository(gitInfo.Organisation, gitInfo.Name)
+	if err != nil {
+		return errors.Wrapf(err, "getting repository %s/%s", gitInfo.Organisation, gitInfo.Name)
+	}
+
+	err = o.Git().CreateBranch(o.Dir, o.BranchName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create local branch %s", o.BranchName)
+	}
+	err = o.Git().Checkout(o.Dir, o.BranchName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to checkout local branch %s", o.BranchName)
+	}
+
+	details := &gits.PullRequestDetails{
+		BranchName: o.BranchName,
+		Title:      VersionUpgradesTitle(o.Upgrades),
+		Message:    VersionUpgradesChangelog(o.Upgrades),
+		Labels:     o.Labels,
+	}
+	var filter *gits.PullRequestFilter
+	if len(o.Labels) > 0 {
+		filter = &gits.PullRequestFilter{
+			Labels: o.Labels[:1],
+		}
+	}
+	o.PullRequest, err = gits.PushRepoAndCreatePullRequest(o.Dir, upstreamInfo, nil, o.Base, details, filter, true, details.Title, true, false, o.Git(), provider)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create PR for base %s and head branch %s", o.Base, details.BranchName)
+	}
+	return nil
+}
+
+// VersionUpgradesTitle returns the title of the pull request for the upgrades
+func VersionUpgradesTitle(upgrades []VersionUpgrade) string {
+	if len(upgrades) == 1 {
+		u := upgrades[0]
+		return fmt.Sprintf("chore(deps): bump %s from %s to %s", u.Name, u.FromVersion, u.ToVersion)
+	}
+	return fmt.Sprintf("chore(deps): bump %d versions from the version stream", len(upgrades))
+}
+
+// VersionUpgradesChangelog returns the markdown changelog summary of the upgrades for the pull request
+func VersionUpgradesChangelog(upgrades []VersionUpgrade) string {
+	sorted := append([]VersionUpgrade{}, upgrades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Kind != sorted[j].Kind {
+			return sorted[i].Kind < sorted[j].Kind
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	var buf strings.Builder
+	buf.WriteString("Upgrades the following versions to the latest versions in the version stream:\n\n")
+	buf.WriteString("| Kind | Name | From | To | File |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, u := range sorted {
+		name := u.Name
+		if u.GitURL != "" {
+			name = fmt.Sprintf("[%s](%s)", u.Name, u.GitURL)
+		}
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", string(u.Kind), name, u.FromVersion, u.ToVersion, u.File))
+	}
+	return buf.String()
+}
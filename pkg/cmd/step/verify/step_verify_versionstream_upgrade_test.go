@@ -0,0 +1,132 @@
+// +build unit
+
+package verify_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/verify"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepVerifyVersionStreamUpgrade(t *testing.T) {
+	t.Parallel()
+
+	testData := filepath.Join("test_data", "verify_versionstream_upgrade")
+	dir, err := ioutil.TempDir("", "test-verify-versionstream-upgrade-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, util.CopyDir(filepath.Join(testData, "env-repo"), dir, true))
+
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	o := &verify.StepVerifyVersionStreamUpgradeOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &commonOpts,
+		},
+		Dir:          dir,
+		ImageFiles:   []string{helm.ValuesFileName, "jenkins-x*.yml"},
+		PackageFiles: []string{"jenkins-x*.yml", "Makefile"},
+		DryRun:       true,
+	}
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: filepath.Join(testData, "versions"),
+	}
+
+	upgrades, err := o.UpgradeVersions(resolver)
+	require.NoError(t, err)
+	assert.Equal(t, []verify.VersionUpgrade{
+		{
+			Kind:        versionstream.KindPackage,
+			Name:        "helm",
+			File:        "Makefile",
+			FromVersion: "3.1.0",
+			ToVersion:   "3.2.4",
+			GitURL:      "https://github.com/helm/helm",
+		},
+		{
+			Kind:        versionstream.KindChart,
+			Name:        "jenkins-x/tekton",
+			File:        filepath.Join("env", "requirements.yaml"),
+			FromVersion: "0.0.50",
+			ToVersion:   "0.0.60",
+			GitURL:      "https://github.com/jenkins-x-charts/tekton",
+		},
+		{
+			Kind:        versionstream.KindDocker,
+			Name:        "gcr.io/jenkinsxio/jx-app-ui",
+			File:        filepath.Join("env", "values.yaml"),
+			FromVersion: "1.0.0",
+			ToVersion:   "1.2.0",
+			GitURL:      "https://github.com/jenkins-x/jx-app-ui",
+		},
+		{
+			Kind:        versionstream.KindDocker,
+			Name:        "gcr.io/jenkinsxio/builder-go",
+			File:        "jenkins-x.yml",
+			FromVersion: "2.0.1",
+			ToVersion:   "2.1.5",
+		},
+		{
+			Kind:        versionstream.KindPackage,
+			Name:        "kustomize",
+			File:        "jenkins-x.yml",
+			FromVersion: "3.5.4",
+			ToVersion:   "3.8.0",
+		},
+	}, upgrades, "should not downgrade lighthouse or upgrade images and packages which are not in the version stream or have no semantic version")
+
+	original, err := ioutil.ReadFile(filepath.Join(testData, "env-repo", "env", "values.yaml"))
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "env", "values.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(data), "should not modify the files in dry run mode")
+
+	o.DryRun = false
+	upgrades, err = o.UpgradeVersions(resolver)
+	require.NoError(t, err)
+	require.Len(t, upgrades, 5)
+
+	req, err := helm.LoadRequirementsFile(filepath.Join(dir, "env", "requirements.yaml"))
+	require.NoError(t, err)
+	require.Len(t, req.Dependencies, 3)
+	assert.Equal(t, "0.0.60", req.Dependencies[0].Version)
+	assert.Equal(t, "0.0.600", req.Dependencies[1].Version)
+	assert.Equal(t, "", req.Dependencies[2].Version)
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "env", "values.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `    tag: "1.2.0" # pinned by the version stream`, "should preserve the layout of the values file")
+	assert.Contains(t, string(data), "    tag: 1.0.0\n    repository: gcr.io/example/other")
+	assert.Contains(t, string(data), "  image: gcr.io/jenkinsxio/builder-go:latest\n")
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "jenkins-x.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "          image: gcr.io/jenkinsxio/builder-go:2.1.5\n")
+	assert.Contains(t, string(data), "  - name: KUSTOMIZE_VERSION\n    value: 3.8.0\n  - name: OTHER_VERSION\n    value: 1.0.0\n")
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "Makefile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "HELM_VERSION := 3.2.4\n")
+
+	assert.Equal(t, "chore(deps): bump 5 versions from the version stream", verify.VersionUpgradesTitle(upgrades))
+	assert.Equal(t, "chore(deps): bump helm from 3.1.0 to 3.2.4", verify.VersionUpgradesTitle(upgrades[:1]))
+	assert.Equal(t, `Upgrades the following versions to the latest versions in the version stream:
+
+| Kind | Name | From | To | File |
+| --- | --- | --- | --- | --- |
+| charts | [jenkins-x/tekton](https://github.com/jenkins-x-charts/tekton) | 0.0.50 | 0.0.60 | env/requirements.yaml |
+| docker | gcr.io/jenkinsxio/builder-go | 2.0.1 | 2.1.5 | jenkins-x.yml |
+| docker | [gcr.io/jenkinsxio/jx-app-ui](https://github.com/jenkins-x/jx-app-ui) | 1.0.0 | 1.2.0 | env/values.yaml |
+| packages | [helm](https://github.com/helm/helm) | 3.1.0 | 3.2.4 | Makefile |
+| packages | kustomize | 3.5.4 | 3.8.0 | jenkins-x.yml |
+`, verify.VersionUpgradesChangelog(upgrades))
+}
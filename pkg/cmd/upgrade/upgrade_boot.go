@@ -100,7 +100,11 @@ func (o *UpgradeBootOptions) Run() error {
 		return errors.Wrapf(err, "failed to load requirements config %s", requirementsFile)
 	}
 	reqsVersionStream := requirements.VersionStream
-	upgradeVersionRef, err := o.upgradeAvailable(reqsVersionStream.URL, reqsVersionStream.Ref, o.UpgradeVersionStreamRef)
+	versionStreamURL, err := o.ResolveVersionStreamURL(reqsVersionStream)
+	if err != nil {
+		return err
+	}
+	upgradeVersionRef, err := o.upgradeAvailable(versionStreamURL, reqsVersionStream.Ref, o.UpgradeVersionStreamRef)
 	if err != nil {
 		return errors.Wrap(err, "failed to get check for available update")
 	}
@@ -127,7 +131,7 @@ func (o *UpgradeBootOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "failed to update version stream ref")
 	}
-	resolver, err := o.CreateVersionResolver(reqsVersionStream.URL, o.UpgradeVersionStreamRef)
+	resolver, err := o.CreateVersionResolver(versionStreamURL, o.UpgradeVersionStreamRef)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create version resolver")
 	}
@@ -218,7 +222,7 @@ func (o UpgradeBootOptions) determineBootConfigURL(versionStreamURL string) (str
 func (o *UpgradeBootOptions) upgradeAvailable(versionStreamURL string, versionStreamRef string, upgradeRef string) (string, error) {
 	versionsDir, _, err := o.CloneJXVersionsRepo(versionStreamURL, upgradeRef)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to clone versions repo %s", versionStreamURL)
+		return "", errors.Wrapf(err, "failed to clone versions repo %s", util.SanitizeURL(versionStreamURL))
 	}
 
 	if o.LatestRelease {
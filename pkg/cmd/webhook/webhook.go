@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/spf13/cobra"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+)
+
+// WebhookOptions contains the command line options
+type WebhookOptions struct {
+	*opts.CommonOptions
+}
+
+var (
+	webhookLong = templates.LongDesc(`
+		Works with the webhook events received by the Jenkins X controllers.
+
+		The events are stored as WebhookEvent resources. The events whose pipeline failed to trigger are labelled as dead letters so you can view them via:
+
+			kubectl get webhookevents -l jenkins.io/dead-letter=true
+`)
+
+	webhookExample = templates.Examples(`
+		# replay a webhook event
+		jx webhook replay environment-controller-72d3162e-cc78-11e3-81ab-4c9367dc0958
+	`)
+)
+
+// NewCmdWebhook creates the command
+func NewCmdWebhook(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &WebhookOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "webhook",
+		Short:   "Works with the webhook events received by the Jenkins X controllers",
+		Aliases: []string{"webhooks"},
+		Long:    webhookLong,
+		Example: webhookExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.AddCommand(NewCmdWebhookReplay(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *WebhookOptions) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/webhooks"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookReplayOptions contains the command line options
+type WebhookReplayOptions struct {
+	*opts.CommonOptions
+
+	Namespace   string
+	DeadLetters bool
+	Force       bool
+}
+
+var (
+	webhookReplayLong = templates.LongDesc(`
+		Replays webhook events so that the controller which received them processes them again.
+
+		This is useful to recover pipelines which failed to trigger, e.g. because the git provider was unavailable when the event was received.
+`)
+
+	webhookReplayExample = templates.Examples(`
+		# replay a webhook event
+		jx webhook replay environment-controller-72d3162e-cc78-11e3-81ab-4c9367dc0958
+
+		# replay all the events in the dead letter queue
+		jx webhook replay --dead-letters -n jx-production
+	`)
+)
+
+// NewCmdWebhookReplay creates the command
+func NewCmdWebhookReplay(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &WebhookReplayOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "replay [id]...",
+		Short:   "Replays webhook events",
+		Long:    webhookReplayLong,
+		Example: webhookReplayExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the webhook events. Defaults to the current namespace")
+	cmd.Flags().BoolVarP(&options.DeadLetters, "dead-letters", "", false, "Replays all the events in the dead letter queue")
+	cmd.Flags().BoolVarP(&options.Force, "force", "f", false, "Replays events which are still running, e.g. if the controller was restarted while processing them")
+	return cmd
+}
+
+// Run implements this command
+func (o *WebhookReplayOptions) Run() error {
+	jxClient, ns, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the jx client")
+	}
+	if o.Namespace != "" {
+		ns = o.Namespace
+	}
+
+	names := o.Args
+	if o.DeadLetters {
+		deadLetters, err := jxClient.JenkinsV1().WebhookEvents(ns).List(metav1.ListOptions{
+			LabelSelector: webhooks.LabelDeadLetter + "=true",
+		})
+		if err != nil {
+			return errors.Wrapf(err, "listing the dead letter webhook events in namespace %s", ns)
+		}
+		for _, event := range deadLetters.Items {
+			names = append(names, event.Name)
+		}
+		if len(names) == 0 {
+			log.Logger().Infof("there are no webhook events in the dead letter queue of namespace %s", util.ColorInfo(ns))
+			return nil
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("missing the ID of the webhook event to replay or the --dead-letters flag")
+	}
+
+	errs := []error{}
+	for _, name := range names {
+		_, err := webhooks.RequestReplay(jxClient, ns, name, o.Force)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Logger().Infof("requested replay of webhook event %s", util.ColorInfo(name))
+	}
+	return util.CombineErrors(errs...)
+}
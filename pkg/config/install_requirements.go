@@ -130,10 +130,126 @@ const (
 	// SecretStorageTypeLocal specifies that we use the local file system in
 	// `~/.jx/localSecrets` to store secrets
 	SecretStorageTypeLocal SecretStorageType = "local"
+	// SecretStorageTypeAWSSecretsManager specifies that we use AWS Secrets Manager to store secrets
+	SecretStorageTypeAWSSecretsManager SecretStorageType = "aws-secrets-manager"
+	// SecretStorageTypeGCPSecretManager specifies that we use GCP Secret Manager to store secrets
+	SecretStorageTypeGCPSecretManager SecretStorageType = "gcp-secret-manager"
+	// SecretStorageTypeAzureKeyVault specifies that we use Azure Key Vault to store secrets
+	SecretStorageTypeAzureKeyVault SecretStorageType = "azure-key-vault"
+	// SecretStorageTypeExternalSecrets specifies that the secrets of the releases are applied as ExternalSecrets
+	// which the external secrets operator populates from its secret store so that the pipeline never reads them
+	SecretStorageTypeExternalSecrets SecretStorageType = "externalSecrets"
 )
 
 // SecretStorageTypeValues the string values for the secret storage
-var SecretStorageTypeValues = []string{"local", "vault"}
+var SecretStorageTypeValues = []string{"local", "vault", "aws-secrets-manager", "gcp-secret-manager", "azure-key-vault", "externalSecrets"}
+
+// SecretBackendConfig the settings of the cloud secret manager which stores the secrets
+type SecretBackendConfig struct {
+	// Region the AWS region of the Secrets Manager. Defaults to the region of the cluster
+	Region string `json:"region,omitempty"`
+	// Project the GCP project of the Secret Manager. Defaults to the project of the cluster
+	Project string `json:"project,omitempty"`
+	// KeyVault the name of the Azure Key Vault
+	KeyVault string `json:"keyVault,omitempty"`
+	// Prefix the prefix added to the names of the secrets, e.g. to share the secret manager between clusters
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// ExternalSecretsConfig the settings of the ExternalSecrets applied instead of the Secrets of the releases when
+// the secretStorage is externalSecrets
+type ExternalSecretsConfig struct {
+	// SecretStore the name of the SecretStore or ClusterSecretStore of the external secrets operator
+	SecretStore string `json:"secretStore,omitempty"`
+	// SecretStoreKind the kind of the secret store, either SecretStore or ClusterSecretStore. Defaults to
+	// ClusterSecretStore
+	SecretStoreKind string `json:"secretStoreKind,omitempty"`
+	// RefreshInterval how often the operator refreshes the secrets from the secret store. Defaults to 1h
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+// TenancyConfig the guardrails injected into the values of the charts applied to each namespace so that the
+// namespaces created for environments, previews and apps are isolated by default
+type TenancyConfig struct {
+	// Enabled whether the guardrails values are injected when applying the charts
+	Enabled bool `json:"enabled,omitempty"`
+	// NetworkPolicy the default network policy of the namespaces
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// ResourceQuota the hard limits of the ResourceQuota of the namespaces keyed by resource, e.g. 'requests.cpu'
+	ResourceQuota map[string]string `json:"resourceQuota,omitempty"`
+	// LimitRange the default requests and limits of the containers in the namespaces
+	LimitRange *LimitRangeConfig `json:"limitRange,omitempty"`
+	// Namespaces overrides the guardrails of the namespaces matching their names in order
+	Namespaces []TenancyNamespaceConfig `json:"namespaces,omitempty"`
+}
+
+// TenancyNamespaceConfig overrides the guardrails of the namespaces matching a name
+type TenancyNamespaceConfig struct {
+	// Name the name of the namespace or a glob pattern such as 'jx-preview-*'
+	Name string `json:"name"`
+	// Disabled disables the guardrails of the matching namespaces, e.g. for the ingress controller
+	Disabled bool `json:"disabled,omitempty"`
+	// NetworkPolicy replaces the default network policy
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// ResourceQuota overrides the hard limits of the default ResourceQuota
+	ResourceQuota map[string]string `json:"resourceQuota,omitempty"`
+	// LimitRange replaces the default LimitRange
+	LimitRange *LimitRangeConfig `json:"limitRange,omitempty"`
+}
+
+// NetworkPolicyConfig the network policy of a namespace
+type NetworkPolicyConfig struct {
+	// DefaultDeny denies ingress from pods in other namespaces unless they are allowed
+	DefaultDeny bool `json:"defaultDeny,omitempty"`
+	// AllowNamespaces the namespaces whose pods are allowed ingress, e.g. the namespace of the ingress controller
+	AllowNamespaces []string `json:"allowNamespaces,omitempty"`
+}
+
+// LimitRangeConfig the default requests and limits of the containers of a namespace keyed by resource, e.g. 'cpu'
+type LimitRangeConfig struct {
+	// DefaultRequest the requests of containers which do not specify them
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	// Default the limits of containers which do not specify them
+	Default map[string]string `json:"default,omitempty"`
+	// Max the maximum limits of a container
+	Max map[string]string `json:"max,omitempty"`
+}
+
+// NamespaceGuardrails returns the guardrails of the namespace after applying the overrides of the namespaces matching
+// its name or nil if tenancy is not enabled or is disabled for the namespace
+func (c *TenancyConfig) NamespaceGuardrails(ns string) *TenancyNamespaceConfig {
+	if c == nil || !c.Enabled {
+		return nil
+	}
+	answer := &TenancyNamespaceConfig{
+		Name:          ns,
+		NetworkPolicy: c.NetworkPolicy,
+		ResourceQuota: map[string]string{},
+		LimitRange:    c.LimitRange,
+	}
+	for k, v := range c.ResourceQuota {
+		answer.ResourceQuota[k] = v
+	}
+	for _, override := range c.Namespaces {
+		matched, err := path.Match(override.Name, ns)
+		if err != nil || !matched {
+			continue
+		}
+		if override.Disabled {
+			return nil
+		}
+		if override.NetworkPolicy != nil {
+			answer.NetworkPolicy = override.NetworkPolicy
+		}
+		if override.LimitRange != nil {
+			answer.LimitRange = override.LimitRange
+		}
+		for k, v := range override.ResourceQuota {
+			answer.ResourceQuota[k] = v
+		}
+	}
+	return answer
+}
 
 // WebhookType is the type of a webhook strategy
 type WebhookType string
@@ -218,6 +334,35 @@ type EnvironmentConfig struct {
 	PromotionStrategy v1.PromotionStrategyType `json:"promotionStrategy,omitempty"`
 	// URLTemplate is the template to use for your environment's exposecontroller generated URLs
 	URLTemplate string `json:"urlTemplate,omitempty"`
+	// Helm overrides the default helm settings used when applying the releases of this environment
+	Helm *HelmReleaseConfig `json:"helm,omitempty"`
+}
+
+// HelmReleaseConfig contains the helm settings used by 'jx step helm apply' when installing or upgrading the releases
+// of an environment
+type HelmReleaseConfig struct {
+	// Timeout the maximum time to wait for the release and its hooks to complete such as '30m' for long running
+	// database migrations. Defaults to 10 minutes when waiting for the release
+	Timeout string `json:"timeout,omitempty"`
+	// Atomic if enabled a failed upgrade is rolled back to the previous revision of the release
+	Atomic bool `json:"atomic,omitempty"`
+	// HookDeletePolicy the policy used to delete the helm hooks which don't specify a 'helm.sh/hook-delete-policy'
+	// annotation such as 'before-hook-creation,hook-succeeded'
+	HookDeletePolicy string `json:"hookDeletePolicy,omitempty"`
+}
+
+// ImageScanConfig contains the settings of the vulnerability scans of the images built by the pipelines with
+// 'jx step scan image'. The settings in the 'jenkins-x.yml' file of a project override the settings of the requirements
+type ImageScanConfig struct {
+	// Scanner the vulnerability scanner, either 'trivy' or 'grype'. Defaults to 'trivy'
+	Scanner string `json:"scanner,omitempty"`
+	// Severity the lowest severity of the vulnerabilities which fail the pipeline, one of 'LOW', 'MEDIUM', 'HIGH' or
+	// 'CRITICAL'. Defaults to 'CRITICAL'
+	Severity string `json:"severity,omitempty"`
+	// IgnoreUnfixed if enabled the vulnerabilities without a fixed version of the package are ignored
+	IgnoreUnfixed bool `json:"ignoreUnfixed,omitempty"`
+	// Ignore the IDs of the vulnerabilities which never fail the pipeline such as 'CVE-2019-5021'
+	Ignore []string `json:"ignore,omitempty"`
 }
 
 // IngressConfig contains dns specific requirements
@@ -311,6 +456,10 @@ type ClusterConfig struct {
 	GitPublic bool `json:"gitPublic,omitempty"`
 	// Provider the kubernetes provider (e.g. gke)
 	Provider string `json:"provider,omitempty"`
+	// ProviderValuesHierarchy the directories of the provider values dir whose values.tmpl.yaml files are merged in
+	// order to override the values so that later layers win. The ${provider}, ${region}, ${zone} and ${cluster}
+	// variables are replaced and any layer using a variable without a value is skipped. Defaults to '${provider}'
+	ProviderValuesHierarchy []string `json:"providerValuesHierarchy,omitempty"`
 	// Namespace the namespace to install the dev environment
 	Namespace string `json:"namespace,omitempty"`
 	// ProjectID the cloud project ID e.g. on GCP
@@ -351,6 +500,61 @@ type ClusterConfig struct {
 	StrictPermissions bool `json:"strictPermissions,omitempty"`
 }
 
+// PolicyConfig contains the git repository of the rego policies which 'jx step helm build' and 'jx step verify policies'
+// check the rendered manifests of the releases against
+type PolicyConfig struct {
+	// URL the git URL of the repository of the policies
+	URL string `json:"url,omitempty"`
+	// Ref the branch, tag or commit of the policies. Defaults to the default branch of the repository
+	Ref string `json:"ref,omitempty"`
+	// Path the directory of the policies in the repository. Defaults to 'policy'
+	Path string `json:"path,omitempty"`
+	// Namespaces the rego packages of the policies. Defaults to 'main'
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// RemoteClusterConfig a remote cluster which 'jx step helm apply --remote' applies the chart to
+type RemoteClusterConfig struct {
+	// Name the name of the cluster used in the apply report. Defaults to the context or kube config file
+	Name string `json:"name,omitempty"`
+	// Context the kube context of the cluster. Defaults to the current context of the kube config
+	Context string `json:"context,omitempty"`
+	// KubeConfig the kube config file used to connect to the cluster. Defaults to the current kube config
+	KubeConfig string `json:"kubeConfig,omitempty"`
+	// Namespace the namespace to apply the chart to. Defaults to the namespace of the context
+	Namespace string `json:"namespace,omitempty"`
+	// ValuesFiles the values files relative to the chart directory which override the values of the chart in this cluster
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+}
+
+// ChartRepositoryMirror an internal mirror used instead of an upstream chart repository
+type ChartRepositoryMirror struct {
+	// URL the URL of the upstream chart repository such as https://charts.helm.sh/stable
+	URL string `json:"url"`
+	// Mirror the URL of the mirror of the chart repository
+	Mirror string `json:"mirror"`
+}
+
+// MirrorChartRepositoryURL returns the URL of the mirror of the given chart repository URL or the URL itself if the
+// chart repository is not mirrored. URLs below the chart repository URL are mirrored too
+func MirrorChartRepositoryURL(mirrors []ChartRepositoryMirror, repoURL string) string {
+	for _, mirror := range mirrors {
+		upstream := strings.TrimSuffix(mirror.URL, "/")
+		mirrorURL := strings.TrimSuffix(mirror.Mirror, "/")
+		if upstream == "" || mirrorURL == "" {
+			continue
+		}
+		// lets not mirror the URL again if the mirror is below the upstream URL
+		if repoURL == mirrorURL || strings.HasPrefix(repoURL, mirrorURL+"/") {
+			return repoURL
+		}
+		if repoURL == upstream || strings.HasPrefix(repoURL, upstream+"/") {
+			return mirrorURL + strings.TrimPrefix(repoURL, upstream)
+		}
+	}
+	return repoURL
+}
+
 // VaultConfig contains Vault configuration for boot
 type VaultConfig struct {
 	// Name the name of the vault if using vault for secrets
@@ -428,6 +632,33 @@ type VersionStreamConfig struct {
 	URL string `json:"url"`
 	// Ref of the version stream to use
 	Ref string `json:"ref"`
+	// Auth the credentials used to clone a privately hosted version stream over HTTPS
+	Auth *VersionStreamAuthConfig `json:"auth,omitempty"`
+}
+
+// VersionStreamAuthConfig contains the credentials used to clone a version stream over HTTPS. The token is used as the
+// password of basic auth so it can be a personal access token or a password
+type VersionStreamAuthConfig struct {
+	// Username the user name to authenticate as. Defaults to 'oauth2' which works with tokens on GitHub and GitLab
+	Username string `json:"username,omitempty"`
+	// UsernameEnv the environment variable containing the user name
+	UsernameEnv string `json:"usernameEnv,omitempty"`
+	// TokenEnv the environment variable containing the token or password
+	TokenEnv string `json:"tokenEnv,omitempty"`
+	// Secret the kubernetes secret containing the credentials
+	Secret *VersionStreamSecretRef `json:"secret,omitempty"`
+}
+
+// VersionStreamSecretRef references the kubernetes secret containing the credentials of a version stream
+type VersionStreamSecretRef struct {
+	// Name the name of the secret
+	Name string `json:"name"`
+	// Namespace the namespace of the secret. Defaults to the current namespace
+	Namespace string `json:"namespace,omitempty"`
+	// UsernameKey the key of the user name in the secret. Defaults to 'username'
+	UsernameKey string `json:"usernameKey,omitempty"`
+	// TokenKey the key of the token or password in the secret. Defaults to 'password'
+	TokenKey string `json:"tokenKey,omitempty"`
 }
 
 // VeleroConfig contains the configuration for velero
@@ -475,32 +706,51 @@ type RequirementsConfig struct {
 	BootConfigURL string `json:"bootConfigURL,omitempty"`
 	// Cluster contains cluster specific requirements
 	Cluster ClusterConfig `json:"cluster"`
+	// Clusters the remote clusters the environment charts are applied to by 'jx step helm apply --remote'
+	Clusters []RemoteClusterConfig `json:"clusters,omitempty"`
 	// Environments the requirements for the environments
 	Environments []EnvironmentConfig `json:"environments,omitempty"`
+	// ExternalSecrets the settings of the ExternalSecrets applied when the secretStorage is externalSecrets
+	ExternalSecrets *ExternalSecretsConfig `json:"externalSecrets,omitempty"`
 	// GithubApp contains github app config
 	GithubApp *GithubAppConfig `json:"githubApp,omitempty"`
 	// GitOps if enabled we will setup a webhook in the boot configuration git repository so that we can
 	// re-run 'jx boot' when changes merge to the master branch
 	GitOps bool `json:"gitops,omitempty"`
+	// Helm the default helm settings used when applying the releases of the environments
+	Helm HelmReleaseConfig `json:"helm,omitempty"`
 	// Indicates if we are using helmfile and helm 3 to spin up environments. This is currently an experimental
 	// feature flag used to implement better Multi-Cluster support. See https://github.com/jenkins-x/jx/issues/6442
 	Helmfile bool `json:"helmfile,omitempty"`
+	// ImageScan the default settings of the vulnerability scans of the images built by the pipelines
+	ImageScan *ImageScanConfig `json:"imageScan,omitempty"`
 	// Kaniko whether to enable kaniko for building docker images
 	Kaniko bool `json:"kaniko,omitempty"`
 	// Ingress contains ingress specific requirements
 	Ingress IngressConfig `json:"ingress"`
+	// Mirrors the internal mirrors used instead of the upstream chart repositories, e.g. for air-gapped installs
+	Mirrors []ChartRepositoryMirror `json:"mirrors,omitempty"`
+	// Policies the repository of the policies the rendered manifests of the releases must pass
+	Policies *PolicyConfig `json:"policies,omitempty"`
 	// Repository specifies what kind of artifact repository you wish to use for storing artifacts (jars, tarballs, npm modules etc)
 	Repository RepositoryType `json:"repository,omitempty"`
+	// SecretBackend the settings of the cloud secret manager used when the secretStorage is not vault or local
+	SecretBackend *SecretBackendConfig `json:"secretBackend,omitempty"`
 	// SecretStorage how should we store secrets for the cluster
 	SecretStorage SecretStorageType `json:"secretStorage,omitempty"`
 	// Storage contains storage requirements
 	Storage StorageConfig `json:"storage"`
+	// Tenancy the guardrails injected into the values of the charts applied to each namespace
+	Tenancy *TenancyConfig `json:"tenancy,omitempty"`
 	// Terraform specifies if  we are managing the kubernetes cluster and cloud resources with Terraform
 	Terraform bool `json:"terraform,omitempty"`
 	// Vault the configuration for vault
 	Vault VaultConfig `json:"vault,omitempty"`
 	// Velero the configuration for running velero for backing up the cluster resources
 	Velero VeleroConfig `json:"velero,omitempty"`
+	// VerifyCharts if enabled the signatures of the charts installed or applied into the environment must be verified
+	// using their provenance files, or cosign signatures for charts in an OCI registry
+	VerifyCharts bool `json:"verifyCharts,omitempty"`
 	// VersionStream contains version stream info
 	VersionStream VersionStreamConfig `json:"versionStream"`
 	// Webhook specifies what engine we should use for webhooks
@@ -519,9 +769,19 @@ func NewRequirementsConfig() *RequirementsConfig {
 // if there is not a file called `jx-requirements.yml` in the given dir we will scan up the parent
 // directories looking for the requirements file as we often run 'jx' steps in sub directories.
 func LoadRequirementsConfig(dir string) (*RequirementsConfig, string, error) {
+	fileName, err := FindRequirementsConfigFile(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	config, err := LoadRequirementsConfigFile(fileName)
+	return config, fileName, err
+}
+
+// FindRequirementsConfigFile returns the `jx-requirements.yml` file in the given dir or its parent directories
+func FindRequirementsConfigFile(dir string) (string, error) {
 	absolute, err := filepath.Abs(dir)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "creating absolute path")
+		return "", errors.Wrap(err, "creating absolute path")
 	}
 	for absolute != "" && absolute != "." && absolute != "/" {
 		fileName := filepath.Join(absolute, RequirementsConfigFileName)
@@ -529,17 +789,14 @@ func LoadRequirementsConfig(dir string) (*RequirementsConfig, string, error) {
 
 		exists, err := util.FileExists(fileName)
 		if err != nil {
-			return nil, "", err
+			return "", err
 		}
 
-		if !exists {
-			continue
+		if exists {
+			return fileName, nil
 		}
-
-		config, err := LoadRequirementsConfigFile(fileName)
-		return config, fileName, err
 	}
-	return nil, "", errors.New("jx-requirements.yml file not found")
+	return "", errors.New("jx-requirements.yml file not found")
 }
 
 // LoadRequirementsConfigFile loads a specific project YAML configuration file
@@ -713,6 +970,26 @@ func (c *RequirementsConfig) Environment(name string) (*EnvironmentConfig, error
 	return nil, fmt.Errorf("environment %q not found", name)
 }
 
+// EnvironmentHelmConfig returns the helm settings used when applying the releases of the environment with the given
+// name. Any settings of the environment override the default helm settings of the requirements
+func (c *RequirementsConfig) EnvironmentHelmConfig(name string) HelmReleaseConfig {
+	answer := c.Helm
+	env, err := c.Environment(name)
+	if err != nil || env.Helm == nil {
+		return answer
+	}
+	if env.Helm.Timeout != "" {
+		answer.Timeout = env.Helm.Timeout
+	}
+	if env.Helm.Atomic {
+		answer.Atomic = true
+	}
+	if env.Helm.HookDeletePolicy != "" {
+		answer.HookDeletePolicy = env.Helm.HookDeletePolicy
+	}
+	return answer
+}
+
 // ToMap converts this object to a map of maps for use in helm templating
 func (c *RequirementsConfig) ToMap() (map[string]interface{}, error) {
 	m, err := util.ToObjectMap(c)
@@ -419,3 +419,63 @@ func TestLoadRequirementsConfig_load_invalid_yaml(t *testing.T) {
 	requirementsConfigPath := path.Join(absolute, config.RequirementsConfigFileName)
 	assert.EqualError(t, err, fmt.Sprintf("validation failures in YAML file %s:\nenvironments.0: Additional property namespace is not allowed", requirementsConfigPath))
 }
+
+func TestEnvironmentHelmConfig(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+helm:
+  timeout: 15m
+  hookDeletePolicy: before-hook-creation
+environments:
+- key: dev
+- key: production
+  helm:
+    timeout: 1h
+    atomic: true
+`)
+	requirements := config.NewRequirementsConfig()
+	err := yaml.Unmarshal(content, requirements)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.HelmReleaseConfig{
+		Timeout:          "15m",
+		HookDeletePolicy: "before-hook-creation",
+	}, requirements.EnvironmentHelmConfig("dev"))
+	assert.Equal(t, config.HelmReleaseConfig{
+		Timeout:          "1h",
+		Atomic:           true,
+		HookDeletePolicy: "before-hook-creation",
+	}, requirements.EnvironmentHelmConfig("production"))
+	assert.Equal(t, requirements.Helm, requirements.EnvironmentHelmConfig("does-not-exist"))
+}
+
+func TestMirrorChartRepositoryURL(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+mirrors:
+- url: https://charts.helm.sh/stable
+  mirror: https://nexus.example.com/repository/helm-stable/
+- url: https://storage.googleapis.com/chartmuseum.jenkins-x.io/
+  mirror: https://storage.googleapis.com/chartmuseum.jenkins-x.io/mirror
+`)
+	requirements := config.NewRequirementsConfig()
+	err := yaml.Unmarshal(content, requirements)
+	require.NoError(t, err)
+	require.Len(t, requirements.Mirrors, 2)
+
+	testCases := map[string]string{
+		"https://charts.helm.sh/stable":                                  "https://nexus.example.com/repository/helm-stable",
+		"https://charts.helm.sh/stable/":                                 "https://nexus.example.com/repository/helm-stable/",
+		"https://charts.helm.sh/stable/nginx-1.0.0.tgz":                  "https://nexus.example.com/repository/helm-stable/nginx-1.0.0.tgz",
+		"https://charts.helm.sh/stable-incubator":                        "https://charts.helm.sh/stable-incubator",
+		"https://storage.googleapis.com/chartmuseum.jenkins-x.io":        "https://storage.googleapis.com/chartmuseum.jenkins-x.io/mirror",
+		"https://storage.googleapis.com/chartmuseum.jenkins-x.io/mirror": "https://storage.googleapis.com/chartmuseum.jenkins-x.io/mirror",
+		"https://charts.example.com":                                     "https://charts.example.com",
+		"":                                                               "",
+	}
+	for repoURL, expected := range testCases {
+		assert.Equal(t, expected, config.MirrorChartRepositoryURL(requirements.Mirrors, repoURL), "mirror of %s", repoURL)
+	}
+}
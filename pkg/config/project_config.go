@@ -20,6 +20,10 @@ import (
 const (
 	// ProjectConfigFileName is the name of the project configuration file
 	ProjectConfigFileName = "jenkins-x.yml"
+
+	// TagPrefixEnvVar the environment variable of the prefix of the git tags of the releases of a monorepo application
+	// which defaults the --tag-prefix of the steps which tag releases
+	TagPrefixEnvVar = "JX_TAG_PREFIX"
 )
 
 // +exported
@@ -41,6 +45,22 @@ type ProjectConfig struct {
 	NoReleasePrepare    bool                        `json:"noReleasePrepare,omitempty"`
 	DockerRegistryHost  string                      `json:"dockerRegistryHost,omitempty"`
 	DockerRegistryOwner string                      `json:"dockerRegistryOwner,omitempty"`
+	ImageScan           *ImageScanConfig            `json:"imageScan,omitempty"`
+	MonorepoApp         *MonorepoAppConfig          `json:"monorepoApp,omitempty"`
+}
+
+// MonorepoAppConfig the application in a subdirectory of a monorepo which is built and released by the pipeline
+type MonorepoAppConfig struct {
+	// Name the name of the application which is used for its chart and image instead of the repository name
+	Name string `json:"name"`
+	// Dir the directory of the application relative to the root of the repository
+	Dir string `json:"dir"`
+}
+
+// TagPrefix returns the prefix of the git tags of the releases of the application so that each application of the
+// monorepo has its own versions
+func (c *MonorepoAppConfig) TagPrefix() string {
+	return c.Name + "/"
 }
 
 type PreviewEnvironmentConfig struct {
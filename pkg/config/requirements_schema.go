@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+// RequirementsDeprecation a deprecated field of the requirements file and the field which replaces it
+type RequirementsDeprecation struct {
+	// Path the dotted path of the deprecated field
+	Path string
+	// Replacement the dotted path of the field to use instead
+	Replacement string
+	// Message describes how to migrate the value if it is not just moved
+	Message string
+}
+
+// RequirementsDeprecations the deprecated fields of the requirements file
+var RequirementsDeprecations = []RequirementsDeprecation{
+	{
+		Path:        "cluster.vaultName",
+		Replacement: "vault.name",
+	},
+	{
+		Path:        "cluster.vaultSAName",
+		Replacement: "vault.serviceAccount",
+	},
+	{
+		Path:        "cluster.environmentGitPrivate",
+		Replacement: "cluster.environmentGitPublic",
+		Message:     "with the inverted value",
+	},
+}
+
+// RequirementsIssue a problem found when verifying a requirements file
+type RequirementsIssue struct {
+	// Field the dotted path of the field with the problem
+	Field string
+	// Message describes the problem and how to fix it
+	Message string
+	// Deprecated true if the field is deprecated but still supported
+	Deprecated bool
+}
+
+func (i RequirementsIssue) String() string {
+	return i.Field + ": " + i.Message
+}
+
+// RequirementsSchema returns the JSON schema of the requirements file
+func RequirementsSchema() ([]byte, error) {
+	schema := util.GenerateSchema(&RequirementsConfig{})
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the requirements schema")
+	}
+	return data, nil
+}
+
+// VerifyRequirementsFile validates the requirements file against its schema returning the invalid, unknown and
+// deprecated fields. Unknown fields suggest the closest known field and deprecated fields the field to migrate to
+func VerifyRequirementsFile(fileName string) ([]RequirementsIssue, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	dataAsJSON, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse YAML file %s", fileName)
+	}
+	schemaData, err := RequirementsSchema()
+	if err != nil {
+		return nil, err
+	}
+	schema := map[string]interface{}{}
+	err = json.Unmarshal(schemaData, &schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the requirements schema")
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schemaData), gojsonschema.NewBytesLoader(dataAsJSON))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to validate YAML file %s", fileName)
+	}
+
+	issues := []RequirementsIssue{}
+	for _, e := range result.Errors() {
+		field := e.Field()
+		if e.Type() != "additional_property_not_allowed" {
+			issues = append(issues, RequirementsIssue{Field: field, Message: e.Description()})
+			continue
+		}
+		property := fmt.Sprintf("%v", e.Details()["property"])
+		path := property
+		if field != "" && field != gojsonschema.STRING_CONTEXT_ROOT {
+			path = field + "." + property
+		}
+		if findRequirementsDeprecation(path) != nil {
+			// reported below with its migration
+			continue
+		}
+		message := "unknown field " + property
+		suggestions := util.SuggestionsFor(property, schemaPropertyNames(schema, field), util.DefaultSuggestionsMinimumDistance)
+		if len(suggestions) > 0 {
+			message += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+		}
+		issues = append(issues, RequirementsIssue{Field: path, Message: message})
+	}
+
+	values := map[string]interface{}{}
+	err = json.Unmarshal(dataAsJSON, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	for _, d := range RequirementsDeprecations {
+		if util.GetMapValueViaPath(values, d.Path) == nil {
+			continue
+		}
+		message := "deprecated, use " + d.Replacement + " instead"
+		if d.Message != "" {
+			message = "deprecated, use " + d.Replacement + " " + d.Message + " instead"
+		}
+		issues = append(issues, RequirementsIssue{Field: d.Path, Message: message, Deprecated: true})
+	}
+	return issues, nil
+}
+
+func findRequirementsDeprecation(path string) *RequirementsDeprecation {
+	for i := range RequirementsDeprecations {
+		if RequirementsDeprecations[i].Path == path {
+			return &RequirementsDeprecations[i]
+		}
+	}
+	return nil
+}
+
+// schemaPropertyNames returns the sorted names of the properties of the object at the dotted field path of the
+// validation errors in the schema
+func schemaPropertyNames(schema map[string]interface{}, field string) []string {
+	node := resolveSchemaRef(schema, schema)
+	if field != "" && field != gojsonschema.STRING_CONTEXT_ROOT {
+		for _, name := range strings.Split(field, ".") {
+			if node == nil {
+				return nil
+			}
+			if _, err := strconv.Atoi(name); err == nil {
+				node, _ = node["items"].(map[string]interface{})
+			} else {
+				properties, _ := node["properties"].(map[string]interface{})
+				node, _ = properties[name].(map[string]interface{})
+			}
+			node = resolveSchemaRef(schema, node)
+		}
+	}
+	properties, _ := node["properties"].(map[string]interface{})
+	answer := []string{}
+	for name := range properties {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// resolveSchemaRef returns the definition the node refers to or the node itself if it is not a reference
+func resolveSchemaRef(schema map[string]interface{}, node map[string]interface{}) map[string]interface{} {
+	for node != nil {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		definitions, _ := schema["definitions"].(map[string]interface{})
+		node, _ = definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+	}
+	return node
+}
@@ -0,0 +1,65 @@
+// +build unit
+
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirementsSchema(t *testing.T) {
+	t.Parallel()
+
+	data, err := config.RequirementsSchema()
+	require.NoError(t, err)
+	schema := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Contains(t, schema, "definitions")
+}
+
+func TestVerifyRequirementsFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-verify-requirements-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, config.RequirementsConfigFileName)
+	text := `cluster:
+  clusterNme: mycluster
+  vaultName: myvault
+  environmentGitPrivate: true
+environments:
+- key: dev
+  namespace: jx
+versionStream:
+  url: https://github.com/jenkins-x/jenkins-x-versions.git
+`
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(text), util.DefaultFileWritePermissions))
+
+	issues, err := config.VerifyRequirementsFile(fileName)
+	require.NoError(t, err)
+
+	messages := map[string]string{}
+	deprecated := map[string]bool{}
+	for _, issue := range issues {
+		messages[issue.Field] = issue.Message
+		deprecated[issue.Field] = issue.Deprecated
+	}
+	assert.Equal(t, "unknown field clusterNme, did you mean clusterName?", messages["cluster.clusterNme"])
+	assert.Equal(t, "unknown field namespace", messages["environments.0.namespace"])
+	assert.False(t, deprecated["cluster.clusterNme"])
+	assert.Equal(t, "deprecated, use vault.name instead", messages["cluster.vaultName"])
+	assert.True(t, deprecated["cluster.vaultName"])
+	assert.Equal(t, "deprecated, use cluster.environmentGitPublic with the inverted value instead", messages["cluster.environmentGitPrivate"])
+	assert.True(t, deprecated["cluster.environmentGitPrivate"])
+	assert.Len(t, issues, 4)
+}
@@ -241,6 +241,22 @@ func (in *ChartMuseumSecret) DeepCopy() *ChartMuseumSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartRepositoryMirror) DeepCopyInto(out *ChartRepositoryMirror) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartRepositoryMirror.
+func (in *ChartRepositoryMirror) DeepCopy() *ChartRepositoryMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartRepositoryMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChatConfig) DeepCopyInto(out *ChatConfig) {
 	*out = *in
@@ -270,6 +286,11 @@ func (in *ClusterConfig) DeepCopyInto(out *ClusterConfig) {
 		*out = new(GKEConfig)
 		**out = **in
 	}
+	if in.ProviderValuesHierarchy != nil {
+		in, out := &in.ProviderValuesHierarchy, &out.ProviderValuesHierarchy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.DevEnvApprovers != nil {
 		in, out := &in.DevEnvApprovers, &out.DevEnvApprovers
 		*out = make([]string, len(*in))
@@ -361,6 +382,22 @@ func (in *ExposeControllerConfig) DeepCopy() *ExposeControllerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretsConfig) DeepCopyInto(out *ExternalSecretsConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretsConfig.
+func (in *ExternalSecretsConfig) DeepCopy() *ExternalSecretsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GKEConfig) DeepCopyInto(out *GKEConfig) {
 	*out = *in
@@ -498,6 +535,27 @@ func (in *Image) DeepCopy() *Image {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScanConfig) DeepCopyInto(out *ImageScanConfig) {
+	*out = *in
+	if in.Ignore != nil {
+		in, out := &in.Ignore, &out.Ignore
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanConfig.
+func (in *ImageScanConfig) DeepCopy() *ImageScanConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScanConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressConfig) DeepCopyInto(out *IngressConfig) {
 	*out = *in
@@ -716,6 +774,80 @@ func (in *KanikoSecret) DeepCopy() *KanikoSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitRangeConfig) DeepCopyInto(out *LimitRangeConfig) {
+	*out = *in
+	if in.DefaultRequest != nil {
+		in, out := &in.DefaultRequest, &out.DefaultRequest
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitRangeConfig.
+func (in *LimitRangeConfig) DeepCopy() *LimitRangeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitRangeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonorepoAppConfig) DeepCopyInto(out *MonorepoAppConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonorepoAppConfig.
+func (in *MonorepoAppConfig) DeepCopy() *MonorepoAppConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MonorepoAppConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+	if in.AllowNamespaces != nil {
+		in, out := &in.AllowNamespaces, &out.AllowNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Nexus) DeepCopyInto(out *Nexus) {
 	*out = *in
@@ -748,6 +880,27 @@ func (in *PipelineSecrets) DeepCopy() *PipelineSecrets {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyConfig) DeepCopyInto(out *PolicyConfig) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyConfig.
+func (in *PolicyConfig) DeepCopy() *PolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Preview) DeepCopyInto(out *Preview) {
 	*out = *in
@@ -857,6 +1010,16 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 		*out = new(jenkinsfile.PipelineConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ImageScan != nil {
+		in, out := &in.ImageScan, &out.ImageScan
+		*out = new(ImageScanConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MonorepoApp != nil {
+		in, out := &in.MonorepoApp, &out.MonorepoApp
+		*out = new(MonorepoAppConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -896,16 +1059,46 @@ func (in *RequirementsConfig) DeepCopyInto(out *RequirementsConfig) {
 		*out = make([]EnvironmentConfig, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = new(ExternalSecretsConfig)
+		**out = **in
+	}
 	if in.GithubApp != nil {
 		in, out := &in.GithubApp, &out.GithubApp
 		*out = new(GithubAppConfig)
 		**out = **in
 	}
+	if in.ImageScan != nil {
+		in, out := &in.ImageScan, &out.ImageScan
+		*out = new(ImageScanConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Ingress = in.Ingress
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]ChartRepositoryMirror, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(PolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretBackend != nil {
+		in, out := &in.SecretBackend, &out.SecretBackend
+		*out = new(SecretBackendConfig)
+		**out = **in
+	}
 	out.Storage = in.Storage
+	if in.Tenancy != nil {
+		in, out := &in.Tenancy, &out.Tenancy
+		*out = new(TenancyConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Vault.DeepCopyInto(&out.Vault)
 	out.Velero = in.Velero
-	out.VersionStream = in.VersionStream
+	in.VersionStream.DeepCopyInto(&out.VersionStream)
 	return
 }
 
@@ -940,6 +1133,22 @@ func (in *RequirementsValues) DeepCopy() *RequirementsValues {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretBackendConfig) DeepCopyInto(out *SecretBackendConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretBackendConfig.
+func (in *SecretBackendConfig) DeepCopy() *SecretBackendConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretBackendConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	*out = *in
@@ -992,6 +1201,79 @@ func (in *TLSConfig) DeepCopy() *TLSConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenancyConfig) DeepCopyInto(out *TenancyConfig) {
+	*out = *in
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceQuota != nil {
+		in, out := &in.ResourceQuota, &out.ResourceQuota
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(LimitRangeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]TenancyNamespaceConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenancyConfig.
+func (in *TenancyConfig) DeepCopy() *TenancyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TenancyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenancyNamespaceConfig) DeepCopyInto(out *TenancyNamespaceConfig) {
+	*out = *in
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceQuota != nil {
+		in, out := &in.ResourceQuota, &out.ResourceQuota
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(LimitRangeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenancyNamespaceConfig.
+func (in *TenancyNamespaceConfig) DeepCopy() *TenancyNamespaceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TenancyNamespaceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAWSConfig) DeepCopyInto(out *VaultAWSConfig) {
 	*out = *in
@@ -1062,9 +1344,35 @@ func (in *VeleroConfig) DeepCopy() *VeleroConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionStreamAuthConfig) DeepCopyInto(out *VersionStreamAuthConfig) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(VersionStreamSecretRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionStreamAuthConfig.
+func (in *VersionStreamAuthConfig) DeepCopy() *VersionStreamAuthConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionStreamAuthConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionStreamConfig) DeepCopyInto(out *VersionStreamConfig) {
 	*out = *in
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(VersionStreamAuthConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1078,6 +1386,22 @@ func (in *VersionStreamConfig) DeepCopy() *VersionStreamConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionStreamSecretRef) DeepCopyInto(out *VersionStreamSecretRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionStreamSecretRef.
+func (in *VersionStreamSecretRef) DeepCopy() *VersionStreamSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionStreamSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WikiConfig) DeepCopyInto(out *WikiConfig) {
 	*out = *in
@@ -0,0 +1,332 @@
+package environments
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PromotePolicyFileName the name of the file in the environment git repository declaring its promotion policy
+	PromotePolicyFileName = "promote-policy.yaml"
+
+	// PromotePolicyStatusContext the context of the commit status reporting whether a promotion Pull Request
+	// satisfies the promotion policy. The status is pending until the policy is satisfied so that tide, and the git
+	// provider if the branch protection of the environment repository requires the context, does not merge it
+	PromotePolicyStatusContext = "promote-policy"
+
+	promoteWindowTimeFormat = "15:04"
+
+	// maxStatusDescriptionLength the maximum length of the description of a commit status accepted by GitHub
+	maxStatusDescriptionLength = 140
+)
+
+// PromotePolicy the policy which promotion Pull Requests on an environment must satisfy before they are merged
+type PromotePolicy struct {
+	// RequiredApprovers the users who must approve the Pull Requests
+	RequiredApprovers []string `json:"requiredApprovers,omitempty"`
+	// MinApprovals the minimum number of the required approvers, or of any users if there are no required approvers,
+	// who must approve the Pull Requests. Defaults to all the required approvers
+	MinApprovals int `json:"minApprovals,omitempty"`
+	// TimeZone the IANA time zone of the windows. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// Windows the time windows Pull Requests can be merged in. If empty they can be merged at any time
+	Windows []PromoteWindow `json:"windows,omitempty"`
+	// Freezes the periods no Pull Request can be merged in
+	Freezes []PromoteFreeze `json:"freezes,omitempty"`
+	// Canary the staged rollout of the promoted applications
+	Canary *PromoteCanary `json:"canary,omitempty"`
+}
+
+// PromoteWindow a daily time window such as 09:00 to 17:00 on week days. The window spans midnight if it ends
+// before it starts
+type PromoteWindow struct {
+	// Days the days of the week such as Mon or Monday. Defaults to every day
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// PromoteFreeze a period no Pull Request can be merged in
+type PromoteFreeze struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// PromoteCanary the percentages of traffic of the canary rollout of the promoted applications
+type PromoteCanary struct {
+	// StepWeight the percentage of traffic the canary receives more on each step of the analysis
+	StepWeight int `json:"stepWeight"`
+	// MaxWeight the percentage of traffic the canary receives before it is promoted
+	MaxWeight int `json:"maxWeight"`
+}
+
+// LoadPromotePolicy loads the promotion policy from the given directory, typically the env dir of an environment
+// repository, or from its parent directory. Returns nil if there is no policy
+func LoadPromotePolicy(dir string) (*PromotePolicy, error) {
+	dir = filepath.Clean(dir)
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		fileName := filepath.Join(d, PromotePolicyFileName)
+		exists, err := util.FileExists(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking if file %s exists", fileName)
+		}
+		if !exists {
+			continue
+		}
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading file %s", fileName)
+		}
+		policy, err := ParsePromotePolicy(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading promotion policy %s", fileName)
+		}
+		return policy, nil
+	}
+	return nil, nil
+}
+
+// ParsePromotePolicy parses and validates the YAML of a promotion policy
+func ParsePromotePolicy(data []byte) (*PromotePolicy, error) {
+	policy := &PromotePolicy{}
+	err := yaml.Unmarshal(data, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling YAML")
+	}
+	err = policy.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "validating promotion policy")
+	}
+	return policy, nil
+}
+
+// Validate validates the policy
+func (p *PromotePolicy) Validate() error {
+	if _, err := p.location(); err != nil {
+		return err
+	}
+	for _, w := range p.Windows {
+		if _, _, err := w.times(); err != nil {
+			return err
+		}
+		for _, day := range w.Days {
+			if _, err := parseWeekday(day); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range p.Freezes {
+		if !f.End.After(f.Start) {
+			return fmt.Errorf("the freeze %s ends before it starts", f.Reason)
+		}
+	}
+	if c := p.Canary; c != nil {
+		if c.StepWeight <= 0 || c.StepWeight > c.MaxWeight || c.MaxWeight > 100 {
+			return fmt.Errorf("the canary step weight %d and max weight %d must be percentages with the step weight no more than the max weight", c.StepWeight, c.MaxWeight)
+		}
+	}
+	return nil
+}
+
+// CheckPullRequest returns an error describing why the Pull Request cannot be merged at the given time or nil if it
+// satisfies the policy
+func (p *PromotePolicy) CheckPullRequest(provider gits.GitProvider, pr *gits.GitPullRequest, now time.Time) error {
+	err := p.CheckTime(now)
+	if err != nil {
+		return err
+	}
+	if len(p.RequiredApprovers) == 0 && p.MinApprovals == 0 {
+		return nil
+	}
+	approver, ok := provider.(gits.PullRequestApprover)
+	if !ok {
+		return fmt.Errorf("the %s git provider does not report the approvals of Pull Requests", provider.Kind())
+	}
+	approvers, err := approver.ListPullRequestApprovers(pr)
+	if err != nil {
+		return err
+	}
+	return p.CheckApprovals(approvers)
+}
+
+// PullRequestStatus returns the commit status of the Pull Request for the PromotePolicyStatusContext which is pending,
+// with the reason in its description, until the Pull Request satisfies the policy
+func (p *PromotePolicy) PullRequestStatus(provider gits.GitProvider, pr *gits.GitPullRequest, now time.Time) *gits.GitRepoStatus {
+	status := &gits.GitRepoStatus{
+		Context:     PromotePolicyStatusContext,
+		State:       "success",
+		Description: "The promotion policy of the environment is satisfied",
+	}
+	err := p.CheckPullRequest(provider, pr, now)
+	if err != nil {
+		status.State = "pending"
+		status.Description = err.Error()
+		if len(status.Description) > maxStatusDescriptionLength {
+			status.Description = status.Description[:maxStatusDescriptionLength-3] + "..."
+		}
+	}
+	return status
+}
+
+// CheckTime returns an error if Pull Requests cannot be merged at the given time
+func (p *PromotePolicy) CheckTime(now time.Time) error {
+	for _, f := range p.Freezes {
+		if !now.Before(f.Start) && now.Before(f.End) {
+			return fmt.Errorf("promotions are frozen until %s: %s", f.End.Format(time.RFC3339), f.Reason)
+		}
+	}
+	if len(p.Windows) == 0 {
+		return nil
+	}
+	loc, err := p.location()
+	if err != nil {
+		return err
+	}
+	now = now.In(loc)
+	for _, w := range p.Windows {
+		if w.contains(now) {
+			return nil
+		}
+	}
+	// the current time is not part of the error so that the status of a Pull Request is only updated when it changes
+	return fmt.Errorf("promotions are only allowed in the promotion windows of time zone %s", loc.String())
+}
+
+// CheckApprovals returns an error if the approvers do not satisfy the policy
+func (p *PromotePolicy) CheckApprovals(approvers []string) error {
+	count := len(approvers)
+	missing := []string{}
+	if len(p.RequiredApprovers) > 0 {
+		count = 0
+		for _, required := range p.RequiredApprovers {
+			if util.StringArrayIndex(approvers, required) >= 0 {
+				count++
+			} else {
+				missing = append(missing, required)
+			}
+		}
+	}
+	min := p.MinApprovals
+	if min == 0 {
+		min = len(p.RequiredApprovers)
+	}
+	if count >= min {
+		return nil
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d of the approvals of %s are required but only %d were given", min, strings.Join(p.RequiredApprovers, ", "), count)
+	}
+	return fmt.Errorf("%d approvals are required but only %d were given", min, count)
+}
+
+// ApplyCanary enables the canary rollout of the application in the values.yaml file of the chart in the directory
+// using the percentages of the policy
+func (p *PromotePolicy) ApplyCanary(dir string, app string) error {
+	if p.Canary == nil {
+		return nil
+	}
+	fileName := filepath.Join(dir, helm.ValuesFileName)
+	values, err := helm.LoadValuesFile(fileName)
+	if err != nil {
+		return err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	appValues, _ := values[app].(map[string]interface{})
+	if appValues == nil {
+		appValues = map[string]interface{}{}
+	}
+	canary, _ := appValues["canary"].(map[string]interface{})
+	if canary == nil {
+		canary = map[string]interface{}{}
+	}
+	analysis, _ := canary["canaryAnalysis"].(map[string]interface{})
+	if analysis == nil {
+		analysis = map[string]interface{}{}
+	}
+	analysis["stepWeight"] = p.Canary.StepWeight
+	analysis["maxWeight"] = p.Canary.MaxWeight
+	canary["enabled"] = true
+	canary["canaryAnalysis"] = analysis
+	appValues["canary"] = canary
+	appValues["rollout"] = flagger.RolloutCanary
+	values[app] = appValues
+	return helm.SaveFile(fileName, values)
+}
+
+func (p *PromotePolicy) location() (*time.Location, error) {
+	if p.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading time zone %s", p.TimeZone)
+	}
+	return loc, nil
+}
+
+// times returns the start and end of the window in minutes since midnight
+func (w *PromoteWindow) times() (int, int, error) {
+	start, err := time.Parse(promoteWindowTimeFormat, w.Start)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parsing the start %s of a promotion window", w.Start)
+	}
+	end, err := time.Parse(promoteWindowTimeFormat, w.End)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parsing the end %s of a promotion window", w.End)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+func (w *PromoteWindow) contains(t time.Time) bool {
+	start, end, err := w.times()
+	if err != nil {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if end <= start {
+		// the window spans midnight so the early hours belong to the window of the previous day
+		if minutes < end {
+			return w.onDay((day + 6) % 7)
+		}
+		return minutes >= start && w.onDay(day)
+	}
+	return minutes >= start && minutes < end && w.onDay(day)
+}
+
+func (w *PromoteWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		weekday, err := parseWeekday(d)
+		if err == nil && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(text string) (time.Weekday, error) {
+	lower := strings.ToLower(text)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if lower == name || lower == name[0:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid day of the week %s", text)
+}
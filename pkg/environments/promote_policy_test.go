@@ -0,0 +1,183 @@
+// +build unit
+
+package environments_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/environments"
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPromotePolicy = `requiredApprovers:
+- alice
+- bob
+minApprovals: 1
+timeZone: Europe/London
+windows:
+- days: [Mon, Tuesday, wed, thu, fri]
+  start: "09:00"
+  end: "17:00"
+- days: [Sat]
+  start: "22:00"
+  end: "02:00"
+freezes:
+- start: 2020-12-21T00:00:00Z
+  end: 2021-01-04T00:00:00Z
+  reason: holidays
+canary:
+  stepWeight: 10
+  maxWeight: 50
+`
+
+func TestLoadPromotePolicy(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-promote-policy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	envDir := filepath.Join(dir, "env")
+	require.NoError(t, os.MkdirAll(envDir, 0755))
+
+	policy, err := environments.LoadPromotePolicy(envDir)
+	require.NoError(t, err)
+	assert.Nil(t, policy, "should not return a policy when there is no file")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, environments.PromotePolicyFileName), []byte(testPromotePolicy), 0644))
+	policy, err = environments.LoadPromotePolicy(envDir)
+	require.NoError(t, err)
+	require.NotNil(t, policy, "should load the policy from the root of the repository")
+	assert.Equal(t, []string{"alice", "bob"}, policy.RequiredApprovers)
+	require.Len(t, policy.Windows, 2)
+	require.Len(t, policy.Freezes, 1)
+	assert.Equal(t, "holidays", policy.Freezes[0].Reason)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(envDir, environments.PromotePolicyFileName), []byte("canary:\n  stepWeight: 60\n  maxWeight: 50\n"), 0644))
+	_, err = environments.LoadPromotePolicy(envDir)
+	assert.Error(t, err, "should reject a step weight above the max weight")
+}
+
+func TestPromotePolicyCheckTime(t *testing.T) {
+	t.Parallel()
+
+	policy := &environments.PromotePolicy{
+		TimeZone: "Europe/London",
+		Windows: []environments.PromoteWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"},
+			{Days: []string{"Saturday"}, Start: "22:00", End: "02:00"},
+		},
+		Freezes: []environments.PromoteFreeze{
+			{
+				Start:  time.Date(2020, 12, 21, 0, 0, 0, 0, time.UTC),
+				End:    time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC),
+				Reason: "holidays",
+			},
+		},
+	}
+	require.NoError(t, policy.Validate())
+
+	testCases := []struct {
+		time    time.Time
+		allowed bool
+	}{
+		// Wednesday 10:00 BST
+		{time.Date(2020, 6, 10, 9, 0, 0, 0, time.UTC), true},
+		// Wednesday 08:30 BST
+		{time.Date(2020, 6, 10, 7, 30, 0, 0, time.UTC), false},
+		// Sunday 12:00 BST
+		{time.Date(2020, 6, 14, 11, 0, 0, 0, time.UTC), false},
+		// Saturday 23:00 BST
+		{time.Date(2020, 6, 13, 22, 0, 0, 0, time.UTC), true},
+		// Sunday 01:00 BST in the window of Saturday night
+		{time.Date(2020, 6, 14, 0, 0, 0, 0, time.UTC), true},
+		// Tuesday 10:00 GMT during the freeze
+		{time.Date(2020, 12, 22, 10, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range testCases {
+		err := policy.CheckTime(tc.time)
+		if tc.allowed {
+			assert.NoError(t, err, "promotions should be allowed at %s", tc.time)
+		} else {
+			assert.Error(t, err, "promotions should not be allowed at %s", tc.time)
+		}
+	}
+}
+
+func TestPromotePolicyCheckPullRequest(t *testing.T) {
+	t.Parallel()
+
+	repo, err := gits.NewFakeRepository("myorg", "environment-staging", nil, nil)
+	require.NoError(t, err)
+	provider := gits.NewFakeProvider(repo)
+	number := 1
+	pr := &gits.GitPullRequest{
+		Owner:  "myorg",
+		Repo:   "environment-staging",
+		Number: &number,
+	}
+	repo.PullRequests[number] = &gits.FakePullRequest{
+		PullRequest: pr,
+		Approvers:   []string{"carol"},
+	}
+	now := time.Now()
+
+	policy := &environments.PromotePolicy{RequiredApprovers: []string{"alice", "bob"}}
+	assert.Error(t, policy.CheckPullRequest(provider, pr, now))
+
+	repo.PullRequests[number].Approvers = []string{"carol", "alice"}
+	assert.Error(t, policy.CheckPullRequest(provider, pr, now), "should require all the required approvers")
+
+	policy.MinApprovals = 1
+	assert.NoError(t, policy.CheckPullRequest(provider, pr, now))
+
+	policy = &environments.PromotePolicy{MinApprovals: 3}
+	assert.Error(t, policy.CheckPullRequest(provider, pr, now), "should count the approvals of any users")
+
+	status := policy.PullRequestStatus(provider, pr, now)
+	assert.Equal(t, environments.PromotePolicyStatusContext, status.Context)
+	assert.Equal(t, "pending", status.State, "should block the merge until the policy is satisfied")
+	assert.Contains(t, status.Description, "3 approvals are required")
+
+	policy.MinApprovals = 2
+	status = policy.PullRequestStatus(provider, pr, now)
+	assert.Equal(t, "success", status.State)
+}
+
+func TestPromotePolicyApplyCanary(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-promote-canary")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, helm.ValuesFileName), []byte("myapp:\n  replicaCount: 2\n"), 0644))
+
+	policy := &environments.PromotePolicy{
+		Canary: &environments.PromoteCanary{StepWeight: 10, MaxWeight: 50},
+	}
+	require.NoError(t, policy.ApplyCanary(dir, "myapp"))
+
+	values, err := helm.LoadValuesFile(filepath.Join(dir, helm.ValuesFileName))
+	require.NoError(t, err)
+	appValues := values["myapp"].(map[string]interface{})
+	assert.Equal(t, float64(2), appValues["replicaCount"])
+	canary := appValues["canary"].(map[string]interface{})
+	assert.Equal(t, true, canary["enabled"])
+	analysis := canary["canaryAnalysis"].(map[string]interface{})
+	assert.Equal(t, float64(10), analysis["stepWeight"])
+	assert.Equal(t, float64(50), analysis["maxWeight"])
+
+	rollout, err := flagger.RolloutFromValues("myapp", appValues)
+	require.NoError(t, err)
+	require.NotNil(t, rollout, "should opt the app in to the canary rollout")
+	assert.Equal(t, flagger.RolloutCanary, rollout.Strategy)
+	assert.Equal(t, 10, rollout.StepWeight)
+	assert.Equal(t, 50, rollout.MaxWeight)
+}
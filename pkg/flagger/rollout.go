@@ -0,0 +1,330 @@
+package flagger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RolloutCanary the value of the rollout chart value which progressively shifts the traffic to the new version
+	RolloutCanary = "canary"
+	// RolloutBlueGreen the value of the rollout chart value which switches all the traffic to the new version once
+	// it passes its analysis
+	RolloutBlueGreen = "bluegreen"
+
+	// CanaryAPIVersion the API version of the generated Canary resources
+	CanaryAPIVersion = "flagger.app/v1beta1"
+	// LabelCanaryApp the label of the generated Canary resources containing the name of the app
+	LabelCanaryApp = "jenkins.io/canary-app"
+
+	// CanaryPhaseInitialized the phase of a Canary whose first version has been deployed without analysis
+	CanaryPhaseInitialized = "Initialized"
+	// CanaryPhaseProgressing the phase of a Canary whose new version is being analysed
+	CanaryPhaseProgressing = "Progressing"
+	// CanaryPhaseSucceeded the phase of a Canary whose new version passed the analysis and was promoted
+	CanaryPhaseSucceeded = "Succeeded"
+	// CanaryPhaseFailed the phase of a Canary whose new version failed the analysis and was rolled back
+	CanaryPhaseFailed = "Failed"
+)
+
+// CanaryResource the resource of the Flagger Canaries
+var CanaryResource = schema.GroupVersionResource{Group: "flagger.app", Version: "v1beta1", Resource: "canaries"}
+
+// Rollout the Flagger rollout of the Deployments of an app configured by the values of its chart:
+//
+//	rollout: canary
+//	canary:
+//	  canaryAnalysis:
+//	    interval: 1m
+//	    threshold: 5
+//	    stepWeight: 10
+//	    maxWeight: 50
+type Rollout struct {
+	App        string
+	Strategy   string
+	Interval   string
+	Threshold  int
+	StepWeight int
+	MaxWeight  int
+	Iterations int
+	Port       int
+}
+
+// RolloutFromValues returns the rollout of the app configured by the values of its chart or nil if the chart does
+// not opt in
+func RolloutFromValues(app string, values map[string]interface{}) (*Rollout, error) {
+	strategy, _ := values["rollout"].(string)
+	if strategy == "" {
+		return nil, nil
+	}
+	strategy = strings.ToLower(strategy)
+	if strategy != RolloutCanary && strategy != RolloutBlueGreen {
+		return nil, fmt.Errorf("invalid rollout %s of app %s, it must be %s or %s", strategy, app, RolloutCanary, RolloutBlueGreen)
+	}
+	analysis, _ := nestedValue(values, "canary.canaryAnalysis").(map[string]interface{})
+	answer := &Rollout{
+		App:        app,
+		Strategy:   strategy,
+		Interval:   "1m",
+		Threshold:  5,
+		StepWeight: 10,
+		MaxWeight:  50,
+		Iterations: 10,
+		Port:       intValue(nestedValue(values, "service.internalPort")),
+	}
+	if interval, ok := analysis["interval"].(string); ok && interval != "" {
+		answer.Interval = interval
+	}
+	for key, field := range map[string]*int{
+		"threshold":  &answer.Threshold,
+		"stepWeight": &answer.StepWeight,
+		"maxWeight":  &answer.MaxWeight,
+		"iterations": &answer.Iterations,
+	} {
+		if v := intValue(analysis[key]); v > 0 {
+			*field = v
+		}
+	}
+	return answer, nil
+}
+
+// nestedValue returns the value at the dotted path of the map or nil without modifying the map
+func nestedValue(m map[string]interface{}, path string) interface{} {
+	value, _, _ := unstructured.NestedFieldNoCopy(m, strings.Split(path, ".")...)
+	return value
+}
+
+func intValue(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// ConvertManifestFile adds a Canary to the YAML file for each of its Deployments so that Flagger rolls out their
+// changes rather than them being applied directly. Deployments which already have a Canary in the file are skipped
+func (r *Rollout) ConvertManifestFile(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	docs := helm.SplitYAMLDocuments(string(data))
+	deployments := []map[string]interface{}{}
+	targets := map[string]bool{}
+	for _, doc := range docs {
+		if !strings.Contains(doc, "Deployment") && !strings.Contains(doc, "Canary") {
+			continue
+		}
+		resource := map[string]interface{}{}
+		err = yaml.Unmarshal([]byte(doc), &resource)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse YAML file %s", fileName)
+		}
+		switch resource["kind"] {
+		case "Deployment":
+			deployments = append(deployments, resource)
+		case "Canary":
+			if name, ok := nestedValue(resource, "spec.targetRef.name").(string); ok {
+				targets[name] = true
+			}
+		}
+	}
+	modified := false
+	for _, deployment := range deployments {
+		name, _ := nestedValue(deployment, "metadata.name").(string)
+		if name == "" || targets[name] {
+			continue
+		}
+		canary, err := yaml.Marshal(r.Canary(deployment))
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal the Canary of Deployment %s", name)
+		}
+		docs = append(docs, string(canary))
+		modified = true
+	}
+	if !modified {
+		return nil
+	}
+	err = ioutil.WriteFile(fileName, []byte(strings.Join(docs, "---\n")), util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// Canary returns the Canary rolling out the Deployment
+func (r *Rollout) Canary(deployment map[string]interface{}) map[string]interface{} {
+	name, _ := nestedValue(deployment, "metadata.name").(string)
+	metadata := map[string]interface{}{
+		"name": name,
+		"labels": map[string]interface{}{
+			LabelCanaryApp: r.App,
+		},
+	}
+	if ns, ok := nestedValue(deployment, "metadata.namespace").(string); ok && ns != "" {
+		metadata["namespace"] = ns
+	}
+	analysis := map[string]interface{}{
+		"interval":  r.Interval,
+		"threshold": r.Threshold,
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"name":           "request-success-rate",
+				"thresholdRange": map[string]interface{}{"min": 99},
+				"interval":       r.Interval,
+			},
+		},
+	}
+	if r.Strategy == RolloutBlueGreen {
+		analysis["iterations"] = r.Iterations
+	} else {
+		analysis["stepWeight"] = r.StepWeight
+		analysis["maxWeight"] = r.MaxWeight
+	}
+	return map[string]interface{}{
+		"apiVersion": CanaryAPIVersion,
+		"kind":       "Canary",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       name,
+			},
+			"service": map[string]interface{}{
+				"port": r.port(deployment),
+			},
+			"analysis": analysis,
+		},
+	}
+}
+
+// port returns the configured port or the first container port of the Deployment
+func (r *Rollout) port(deployment map[string]interface{}) int {
+	if r.Port > 0 {
+		return r.Port
+	}
+	containers, _ := nestedValue(deployment, "spec.template.spec.containers").([]interface{})
+	for _, c := range containers {
+		container, _ := c.(map[string]interface{})
+		ports, _ := container["ports"].([]interface{})
+		for _, p := range ports {
+			port, _ := p.(map[string]interface{})
+			if v := intValue(port["containerPort"]); v > 0 {
+				return v
+			}
+		}
+	}
+	return 80
+}
+
+// FindAppCanaries returns the Canaries of the app in the namespace, either generated for it or named after it as
+// the Deployments of environment charts are, returning none if the Flagger resources are not installed
+func FindAppCanaries(client dynamic.Interface, ns string, app string) ([]unstructured.Unstructured, error) {
+	list, err := client.Resource(CanaryResource).Namespace(ns).List(metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "listing the Canaries in namespace %s", ns)
+	}
+	answer := []unstructured.Unstructured{}
+	for _, canary := range list.Items {
+		name := canary.GetName()
+		if canary.GetLabels()[LabelCanaryApp] == app || name == app || strings.HasSuffix(name, "-"+app) {
+			answer = append(answer, canary)
+		}
+	}
+	return answer, nil
+}
+
+// CanarySpecs returns the last applied spec of each of the Canaries of the app in the namespace by name so that the
+// rollout of the next version can be told apart from the rollout of the current one
+func CanarySpecs(client dynamic.Interface, ns string, app string) (map[string]string, error) {
+	canaries, err := FindAppCanaries(client, ns, app)
+	if err != nil {
+		return nil, err
+	}
+	answer := map[string]string{}
+	for i := range canaries {
+		applied, _, _ := unstructured.NestedString(canaries[i].Object, "status", "lastAppliedSpec")
+		answer[canaries[i].GetName()] = applied
+	}
+	return answer, nil
+}
+
+// CanaryFinished returns true if the version of the Canary rolled out after its previous applied spec has been promoted
+// or an error if it failed its analysis. Until Flagger applies a new spec or starts progressing the phase of the Canary
+// still describes the previous rollout so it is not finished. A blank previous spec is a Canary which did not exist
+// before so any version counts
+func CanaryFinished(canary *unstructured.Unstructured, previousSpec string) (bool, error) {
+	phase, _, _ := unstructured.NestedString(canary.Object, "status", "phase")
+	applied, _, _ := unstructured.NestedString(canary.Object, "status", "lastAppliedSpec")
+	promoted, _, _ := unstructured.NestedString(canary.Object, "status", "lastPromotedSpec")
+	if previousSpec != "" && applied == previousSpec && phase != CanaryPhaseProgressing {
+		return false, nil
+	}
+	switch phase {
+	case CanaryPhaseFailed:
+		return false, fmt.Errorf("the canary analysis of %s failed so it was rolled back", canary.GetName())
+	case CanaryPhaseInitialized, CanaryPhaseSucceeded:
+		return promoted == "" || promoted == applied, nil
+	}
+	return false, nil
+}
+
+// WaitForCanaries waits for the canary analysis of all the Canaries of the app in the namespace to pass for the
+// version rolled out after the given previous applied specs of the Canaries
+func WaitForCanaries(client dynamic.Interface, ns string, app string, previousSpecs map[string]string, timeout time.Duration, pollInterval time.Duration) error {
+	end := time.Now().Add(timeout)
+	logged := map[string]string{}
+	for {
+		canaries, err := FindAppCanaries(client, ns, app)
+		if err != nil {
+			return err
+		}
+		finished := true
+		for i := range canaries {
+			canary := &canaries[i]
+			done, err := CanaryFinished(canary, previousSpecs[canary.GetName()])
+			if err != nil {
+				return err
+			}
+			if !done {
+				finished = false
+				phase, _, _ := unstructured.NestedString(canary.Object, "status", "phase")
+				weight, _, _ := unstructured.NestedInt64(canary.Object, "status", "canaryWeight")
+				status := fmt.Sprintf("%s %d%%", phase, weight)
+				if logged[canary.GetName()] != status {
+					logged[canary.GetName()] = status
+					log.Logger().Infof("Canary %s is %s with %s of the traffic", util.ColorInfo(canary.GetName()), util.ColorInfo(phase), util.ColorInfo(fmt.Sprintf("%d%%", weight)))
+				}
+			}
+		}
+		if finished {
+			return nil
+		}
+		if time.Now().After(end) {
+			return fmt.Errorf("timed out after %s waiting for the canary analysis of app %s in namespace %s", timeout.String(), app, ns)
+		}
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,142 @@
+// +build unit
+
+package flagger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/flagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const testDeployments = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: jx-myapp
+  namespace: jx-staging
+spec:
+  template:
+    spec:
+      containers:
+      - name: myapp
+        ports:
+        - containerPort: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+`
+
+func TestRolloutFromValues(t *testing.T) {
+	t.Parallel()
+
+	rollout, err := flagger.RolloutFromValues("myapp", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, rollout, "should not roll out charts which do not opt in")
+
+	_, err = flagger.RolloutFromValues("myapp", map[string]interface{}{"rollout": "rainbow"})
+	assert.Error(t, err)
+
+	rollout, err = flagger.RolloutFromValues("myapp", map[string]interface{}{
+		"rollout": "Canary",
+		"canary": map[string]interface{}{
+			"canaryAnalysis": map[string]interface{}{
+				"interval":   "30s",
+				"stepWeight": float64(20),
+			},
+		},
+		"service": map[string]interface{}{
+			"internalPort": float64(9090),
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, rollout)
+	assert.Equal(t, flagger.RolloutCanary, rollout.Strategy)
+	assert.Equal(t, "30s", rollout.Interval)
+	assert.Equal(t, 20, rollout.StepWeight)
+	assert.Equal(t, 50, rollout.MaxWeight, "should default the max weight")
+	assert.Equal(t, 9090, rollout.Port)
+}
+
+func TestRolloutConvertManifestFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-flagger-rollout")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "deployment.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(testDeployments), 0644))
+
+	rollout := &flagger.Rollout{App: "myapp", Strategy: flagger.RolloutBlueGreen, Interval: "1m", Threshold: 5, Iterations: 10}
+	require.NoError(t, rollout.ConvertManifestFile(fileName))
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	docs := strings.Split(string(data), "---\n")
+	require.Len(t, docs, 3, "should add a Canary for the Deployment")
+
+	canary := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[2]), &canary))
+	u := &unstructured.Unstructured{Object: canary}
+	assert.Equal(t, "Canary", u.GetKind())
+	assert.Equal(t, "jx-myapp", u.GetName())
+	assert.Equal(t, "jx-staging", u.GetNamespace())
+	assert.Equal(t, "myapp", u.GetLabels()[flagger.LabelCanaryApp])
+	target, _, _ := unstructured.NestedString(canary, "spec", "targetRef", "name")
+	assert.Equal(t, "jx-myapp", target)
+	port, _, _ := unstructured.NestedFloat64(canary, "spec", "service", "port")
+	assert.Equal(t, float64(8080), port, "should default to the container port")
+	iterations, _, _ := unstructured.NestedFloat64(canary, "spec", "analysis", "iterations")
+	assert.Equal(t, float64(10), iterations)
+
+	require.NoError(t, rollout.ConvertManifestFile(fileName))
+	again, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again), "should not add another Canary for the Deployment")
+}
+
+func TestCanaryFinished(t *testing.T) {
+	t.Parallel()
+
+	canary := func(phase string, applied string, promoted string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetName("jx-myapp")
+		require.NoError(t, unstructured.SetNestedField(u.Object, phase, "status", "phase"))
+		require.NoError(t, unstructured.SetNestedField(u.Object, applied, "status", "lastAppliedSpec"))
+		require.NoError(t, unstructured.SetNestedField(u.Object, promoted, "status", "lastPromotedSpec"))
+		return u
+	}
+
+	done, err := flagger.CanaryFinished(canary("Progressing", "b", "a"), "a")
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	done, err = flagger.CanaryFinished(canary("Succeeded", "b", "a"), "a")
+	require.NoError(t, err)
+	assert.False(t, done, "should wait for Flagger to promote the applied spec")
+
+	done, err = flagger.CanaryFinished(canary("Succeeded", "b", "b"), "a")
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = flagger.CanaryFinished(canary("Succeeded", "a", "a"), "a")
+	require.NoError(t, err)
+	assert.False(t, done, "should wait for Flagger to detect the new version")
+
+	_, err = flagger.CanaryFinished(canary("Failed", "a", "a"), "a")
+	assert.NoError(t, err, "should ignore the failure of the previous rollout")
+
+	done, err = flagger.CanaryFinished(canary("Initialized", "a", ""), "")
+	require.NoError(t, err)
+	assert.True(t, done, "should accept the first version of a new Canary")
+
+	_, err = flagger.CanaryFinished(canary("Failed", "b", "a"), "a")
+	assert.Error(t, err)
+}
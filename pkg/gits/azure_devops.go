@@ -0,0 +1,1283 @@
+package gits
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AzureDevOpsWebHookSecretHeader the header the service hooks send the webhook secret in as Azure DevOps does not
+	// sign the payloads
+	AzureDevOpsWebHookSecretHeader = "X-Jx-Webhook-Secret"
+
+	azureDevOpsAPIVersion        = "6.0"
+	azureDevOpsPreviewAPIVersion = "6.0-preview.1"
+	azureDevOpsPageSize          = 100
+	azureDevOpsProfileURL        = "https://app.vssps.visualstudio.com"
+)
+
+// azureDevOpsWebHookEvents the events of the service hooks created for a repository
+var azureDevOpsWebHookEvents = []string{
+	"git.push",
+	"git.pullrequest.created",
+	"git.pullrequest.updated",
+	"git.pullrequest.merged",
+	"ms.vss-code.git-pullrequest-comment-event",
+}
+
+// AzureDevOpsProvider a git provider for the repositories of Azure Repos.
+//
+// The owner of a repository is the Azure DevOps organisation optionally followed by the project, e.g. myorg or
+// myorg/myproject. The project is looked up from the repository name if it is omitted.
+type AzureDevOpsProvider struct {
+	Username string
+	Client   *http.Client
+
+	Server auth.AuthServer
+	User   auth.UserAuth
+	Git    Gitter
+}
+
+// NewAzureDevOpsProvider creates a git provider for Azure DevOps authenticating with the personal access token of
+// the user or its OAuth bearer token if it has one
+func NewAzureDevOpsProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
+	if user.ApiToken == "" && user.BearerToken == "" {
+		return nil, fmt.Errorf("no personal access token or OAuth token found for user %s on Azure DevOps server %s", user.Username, server.URL)
+	}
+	return WithAzureDevOpsClient(server, user, WithRateLimits(nil), git)
+}
+
+// WithAzureDevOpsClient creates a git provider for Azure DevOps using the HTTP client, e.g. of a test server
+func WithAzureDevOpsClient(server *auth.AuthServer, user *auth.UserAuth, client *http.Client, git Gitter) (GitProvider, error) {
+	provider := &AzureDevOpsProvider{
+		Server:   *server,
+		User:     *user,
+		Username: user.Username,
+		Client:   client,
+		Git:      git,
+	}
+	if provider.Server.URL == "" {
+		provider.Server.URL = AzureDevOpsURL
+	}
+	return provider, nil
+}
+
+// AzureDevOpsAccessTokenURL returns the URL to create personal access tokens
+func AzureDevOpsAccessTokenURL(url string) string {
+	return util.UrlJoin(url, "_usersSettings/tokens")
+}
+
+type azureDevOpsProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type azureDevOpsRepository struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	URL           string             `json:"url"`
+	RemoteURL     string             `json:"remoteUrl"`
+	SSHURL        string             `json:"sshUrl"`
+	WebURL        string             `json:"webUrl"`
+	DefaultBranch string             `json:"defaultBranch"`
+	IsDisabled    bool               `json:"isDisabled"`
+	Project       azureDevOpsProject `json:"project"`
+}
+
+type azureDevOpsIdentity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+type azureDevOpsCommitRef struct {
+	CommitID string `json:"commitId"`
+}
+
+type azureDevOpsLabel struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Active bool   `json:"active,omitempty"`
+}
+
+type azureDevOpsPullRequest struct {
+	PullRequestID         int                    `json:"pullRequestId"`
+	Status                string                 `json:"status,omitempty"`
+	Title                 string                 `json:"title,omitempty"`
+	Description           string                 `json:"description,omitempty"`
+	SourceRefName         string                 `json:"sourceRefName,omitempty"`
+	TargetRefName         string                 `json:"targetRefName,omitempty"`
+	MergeStatus           string                 `json:"mergeStatus,omitempty"`
+	IsDraft               bool                   `json:"isDraft,omitempty"`
+	CreatedBy             *azureDevOpsIdentity   `json:"createdBy,omitempty"`
+	CreationDate          *time.Time             `json:"creationDate,omitempty"`
+	ClosedDate            *time.Time             `json:"closedDate,omitempty"`
+	LastMergeSourceCommit *azureDevOpsCommitRef  `json:"lastMergeSourceCommit,omitempty"`
+	LastMergeCommit       *azureDevOpsCommitRef  `json:"lastMergeCommit,omitempty"`
+	Labels                []azureDevOpsLabel     `json:"labels,omitempty"`
+	Reviewers             []*azureDevOpsIdentity `json:"reviewers,omitempty"`
+	CompletionOptions     map[string]interface{} `json:"completionOptions,omitempty"`
+}
+
+type azureDevOpsStatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+type azureDevOpsStatus struct {
+	ID          int                      `json:"id,omitempty"`
+	State       string                   `json:"state"`
+	Description string                   `json:"description,omitempty"`
+	TargetURL   string                   `json:"targetUrl,omitempty"`
+	URL         string                   `json:"url,omitempty"`
+	Context     azureDevOpsStatusContext `json:"context"`
+}
+
+type azureDevOpsGitUser struct {
+	Name  string     `json:"name"`
+	Email string     `json:"email"`
+	Date  *time.Time `json:"date,omitempty"`
+}
+
+type azureDevOpsCommit struct {
+	CommitID  string              `json:"commitId"`
+	Comment   string              `json:"comment"`
+	Author    *azureDevOpsGitUser `json:"author,omitempty"`
+	Committer *azureDevOpsGitUser `json:"committer,omitempty"`
+	RemoteURL string              `json:"remoteUrl"`
+}
+
+type azureDevOpsRef struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+	IsLocked bool   `json:"isLocked"`
+}
+
+type azureDevOpsSubscription struct {
+	ID               string            `json:"id,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+// do invokes the REST API decoding the JSON response into the result if it is not nil
+func (p *AzureDevOpsProvider) do(method string, u string, body interface{}, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal the body of %s %s", method, u)
+		}
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request %s %s", method, u)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.User.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.User.BearerToken)
+	} else {
+		req.SetBasicAuth("", p.User.ApiToken)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to invoke %s %s", method, u)
+	}
+	defer resp.Body.Close()
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the response of %s %s", method, u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("%s %s returned status %d: %s", method, u, resp.StatusCode, string(data))
+	}
+	if result != nil && len(data) > 0 {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the response of %s %s", method, u)
+		}
+	}
+	return nil
+}
+
+// apiURL returns the URL of the REST API with the path segments and query parameters
+func (p *AzureDevOpsProvider) apiURL(query url.Values, paths ...string) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	if query.Get("api-version") == "" {
+		query.Set("api-version", azureDevOpsAPIVersion)
+	}
+	escaped := []string{p.Server.URL}
+	for _, path := range paths {
+		for _, segment := range strings.Split(path, "/") {
+			if segment != "" {
+				escaped = append(escaped, url.PathEscape(segment))
+			}
+		}
+	}
+	return util.UrlJoin(escaped...) + "?" + query.Encode()
+}
+
+// splitAzureDevOpsOwner splits the owner into the organisation and the optional project
+func splitAzureDevOpsOwner(owner string) (string, string) {
+	paths := strings.SplitN(strings.Trim(owner, "/"), "/", 2)
+	if len(paths) == 2 {
+		return paths[0], paths[1]
+	}
+	return paths[0], ""
+}
+
+// repository finds the repository of the owner and returns it with its organisation
+func (p *AzureDevOpsProvider) repository(owner string, name string) (*azureDevOpsRepository, string, error) {
+	org, project := splitAzureDevOpsOwner(owner)
+	if org == "" {
+		return nil, "", fmt.Errorf("no Azure DevOps organisation specified for repository %s", name)
+	}
+	repos, err := p.listRepositories(org, project)
+	if err != nil {
+		return nil, org, err
+	}
+	for _, repo := range repos {
+		if strings.EqualFold(repo.Name, name) {
+			return repo, org, nil
+		}
+	}
+	return nil, org, fmt.Errorf("no repository found with name %s in %s", name, owner)
+}
+
+func (p *AzureDevOpsProvider) listRepositories(org string, project string) ([]*azureDevOpsRepository, error) {
+	result := struct {
+		Value []*azureDevOpsRepository `json:"value"`
+	}{}
+	err := p.do(http.MethodGet, p.apiURL(nil, org, project, "_apis/git/repositories"), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing repositories of %s", org)
+	}
+	return result.Value, nil
+}
+
+func (p *AzureDevOpsProvider) listProjects(org string) ([]*azureDevOpsProject, error) {
+	result := struct {
+		Value []*azureDevOpsProject `json:"value"`
+	}{}
+	err := p.do(http.MethodGet, p.apiURL(nil, org, "_apis/projects"), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing projects of %s", org)
+	}
+	return result.Value, nil
+}
+
+// repositoryPath returns the org scoped path of the REST API of the repository
+func repositoryPath(org string, repo *azureDevOpsRepository, paths ...string) string {
+	return strings.Join(append([]string{org, "_apis/git/repositories", repo.ID}, paths...), "/")
+}
+
+func (p *AzureDevOpsProvider) toGitRepository(org string, repo *azureDevOpsRepository) *GitRepository {
+	return &GitRepository{
+		ID:           0,
+		Name:         repo.Name,
+		HTMLURL:      repo.WebURL,
+		CloneURL:     repo.RemoteURL,
+		SSHURL:       repo.SSHURL,
+		URL:          repo.WebURL,
+		Organisation: org,
+		Project:      repo.Project.Name,
+		Private:      true,
+		Archived:     repo.IsDisabled,
+	}
+}
+
+// ListOrganisations lists the Azure DevOps organisations of the user
+func (p *AzureDevOpsProvider) ListOrganisations() ([]GitOrganisation, error) {
+	answer := []GitOrganisation{}
+	if strings.TrimSuffix(p.Server.URL, "/") != AzureDevOpsURL {
+		// Azure DevOps Server has a single collection in its URL
+		return answer, nil
+	}
+	profile := struct {
+		ID string `json:"id"`
+	}{}
+	err := p.do(http.MethodGet, azureDevOpsProfileURL+"/_apis/profile/profiles/me?api-version="+azureDevOpsAPIVersion, nil, &profile)
+	if err != nil {
+		return answer, errors.Wrap(err, "getting the profile of the current user")
+	}
+	accounts := struct {
+		Value []struct {
+			AccountName string `json:"accountName"`
+		} `json:"value"`
+	}{}
+	u := azureDevOpsProfileURL + "/_apis/accounts?memberId=" + url.QueryEscape(profile.ID) + "&api-version=" + azureDevOpsAPIVersion
+	err = p.do(http.MethodGet, u, nil, &accounts)
+	if err != nil {
+		return answer, errors.Wrap(err, "listing the organisations of the current user")
+	}
+	for _, account := range accounts.Value {
+		answer = append(answer, GitOrganisation{Login: account.AccountName})
+	}
+	return answer, nil
+}
+
+// ListRepositories lists the repositories of the organisation
+func (p *AzureDevOpsProvider) ListRepositories(org string) ([]*GitRepository, error) {
+	organisation, project := splitAzureDevOpsOwner(org)
+	repos, err := p.listRepositories(organisation, project)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitRepository{}
+	for _, repo := range repos {
+		answer = append(answer, p.toGitRepository(organisation, repo))
+	}
+	return answer, nil
+}
+
+// CreateRepository creates the repository in the project of the owner. If the owner has no project the project of
+// the same name as the repository or the only project of the organisation is used. Azure Repos are always private
+func (p *AzureDevOpsProvider) CreateRepository(org string, name string, private bool) (*GitRepository, error) {
+	organisation, projectName := splitAzureDevOpsOwner(org)
+	projects, err := p.listProjects(organisation)
+	if err != nil {
+		return nil, err
+	}
+	var project *azureDevOpsProject
+	for _, pr := range projects {
+		if (projectName != "" && strings.EqualFold(pr.Name, projectName)) || (projectName == "" && strings.EqualFold(pr.Name, name)) {
+			project = pr
+		}
+	}
+	if project == nil && projectName == "" && len(projects) == 1 {
+		project = projects[0]
+	}
+	if project == nil {
+		return nil, fmt.Errorf("could not find the project to create repository %s in, please specify the owner as %s/<project>", name, organisation)
+	}
+	body := map[string]interface{}{
+		"name":    name,
+		"project": map[string]string{"id": project.ID},
+	}
+	repo := &azureDevOpsRepository{}
+	err = p.do(http.MethodPost, p.apiURL(nil, organisation, project.ID, "_apis/git/repositories"), body, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating repository %s in %s/%s", name, organisation, project.Name)
+	}
+	return p.toGitRepository(organisation, repo), nil
+}
+
+// GetRepository gets the repository
+func (p *AzureDevOpsProvider) GetRepository(org string, name string) (*GitRepository, error) {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.toGitRepository(organisation, repo), nil
+}
+
+// DeleteRepository deletes the repository
+func (p *AzureDevOpsProvider) DeleteRepository(org string, name string) error {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return err
+	}
+	err = p.do(http.MethodDelete, p.apiURL(nil, repositoryPath(organisation, repo)), nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "deleting repository %s/%s", org, name)
+	}
+	return nil
+}
+
+// ForkRepository is not supported as the pull requests of Azure Repos are created from branches
+func (p *AzureDevOpsProvider) ForkRepository(originalOrg string, name string, destinationOrg string) (*GitRepository, error) {
+	return nil, fmt.Errorf("forking repositories is not supported for Azure DevOps")
+}
+
+// RenameRepository renames the repository
+func (p *AzureDevOpsProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	updated := &azureDevOpsRepository{}
+	err = p.do(http.MethodPatch, p.apiURL(nil, repositoryPath(organisation, repo)), map[string]string{"name": newName}, updated)
+	if err != nil {
+		return nil, errors.Wrapf(err, "renaming repository %s/%s to %s", org, name, newName)
+	}
+	return p.toGitRepository(organisation, updated), nil
+}
+
+// ValidateRepositoryName fails if the repository already exists
+func (p *AzureDevOpsProvider) ValidateRepositoryName(org string, name string) error {
+	organisation, project := splitAzureDevOpsOwner(org)
+	repos, err := p.listRepositories(organisation, project)
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if strings.EqualFold(repo.Name, name) {
+			return fmt.Errorf("repository %s already exists", p.Git.RepoName(org, name))
+		}
+	}
+	return nil
+}
+
+func azureDevOpsBranchRef(branch string) string {
+	if branch == "" || strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+func (p *AzureDevOpsProvider) toPullRequest(owner string, repo *azureDevOpsRepository, pr *azureDevOpsPullRequest) *GitPullRequest {
+	number := pr.PullRequestID
+	state := "open"
+	merged := false
+	answer := &GitPullRequest{
+		URL:    util.UrlJoin(repo.WebURL, "pullrequest", strconv.Itoa(number)),
+		Owner:  owner,
+		Repo:   repo.Name,
+		Number: &number,
+		Title:  pr.Title,
+		Body:   pr.Description,
+		Draft:  &pr.IsDraft,
+	}
+	switch pr.Status {
+	case "completed":
+		state = "closed"
+		merged = true
+		answer.ClosedAt = pr.ClosedDate
+		answer.MergedAt = pr.ClosedDate
+	case "abandoned":
+		state = "closed"
+		answer.ClosedAt = pr.ClosedDate
+	}
+	answer.State = &state
+	answer.Merged = &merged
+	switch pr.MergeStatus {
+	case "succeeded":
+		flag := true
+		answer.Mergeable = &flag
+	case "conflicts", "failure", "rejectedByPolicy":
+		flag := false
+		answer.Mergeable = &flag
+	}
+	headRef := strings.TrimPrefix(pr.SourceRefName, "refs/heads/")
+	answer.HeadRef = &headRef
+	if pr.LastMergeSourceCommit != nil {
+		answer.LastCommitSha = pr.LastMergeSourceCommit.CommitID
+	}
+	if pr.LastMergeCommit != nil && merged {
+		answer.MergeCommitSHA = &pr.LastMergeCommit.CommitID
+	}
+	if pr.CreatedBy != nil {
+		answer.Author = toAzureDevOpsUser(pr.CreatedBy)
+	}
+	for _, reviewer := range pr.Reviewers {
+		answer.RequestedReviewers = append(answer.RequestedReviewers, toAzureDevOpsUser(reviewer))
+	}
+	for _, label := range pr.Labels {
+		name := label.Name
+		answer.Labels = append(answer.Labels, &Label{Name: &name})
+	}
+	return answer
+}
+
+func toAzureDevOpsUser(identity *azureDevOpsIdentity) *GitUser {
+	return &GitUser{
+		Login:     identity.UniqueName,
+		Name:      identity.DisplayName,
+		Email:     identity.UniqueName,
+		AvatarURL: identity.ImageURL,
+	}
+}
+
+// CreatePullRequest creates a pull request from the head branch to the base branch
+func (p *AzureDevOpsProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
+	owner := data.GitRepository.Organisation
+	repo, org, err := p.repository(owner, data.GitRepository.Name)
+	if err != nil {
+		return nil, err
+	}
+	body := &azureDevOpsPullRequest{
+		Title:         data.Title,
+		Description:   data.Body,
+		SourceRefName: azureDevOpsBranchRef(data.Head),
+		TargetRefName: azureDevOpsBranchRef(data.Base),
+	}
+	if body.TargetRefName == "" {
+		body.TargetRefName = repo.DefaultBranch
+	}
+	pr := &azureDevOpsPullRequest{}
+	err = p.do(http.MethodPost, p.apiURL(nil, repositoryPath(org, repo, "pullrequests")), body, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating pull request on %s/%s", owner, repo.Name)
+	}
+	return p.toPullRequest(owner, repo, pr), nil
+}
+
+// UpdatePullRequest updates pull request with number using data
+func (p *AzureDevOpsProvider) UpdatePullRequest(data *GitPullRequestArguments, number int) (*GitPullRequest, error) {
+	owner := data.GitRepository.Organisation
+	repo, org, err := p.repository(owner, data.GitRepository.Name)
+	if err != nil {
+		return nil, err
+	}
+	body := &azureDevOpsPullRequest{
+		Title:         data.Title,
+		Description:   data.Body,
+		TargetRefName: azureDevOpsBranchRef(data.Base),
+	}
+	pr := &azureDevOpsPullRequest{}
+	err = p.do(http.MethodPatch, p.apiURL(nil, repositoryPath(org, repo, "pullrequests", strconv.Itoa(number))), body, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating pull request %d on %s/%s", number, owner, repo.Name)
+	}
+	return p.toPullRequest(owner, repo, pr), nil
+}
+
+func (p *AzureDevOpsProvider) getPullRequest(owner string, name string, number int) (*azureDevOpsRepository, string, *azureDevOpsPullRequest, error) {
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	pr := &azureDevOpsPullRequest{}
+	err = p.do(http.MethodGet, p.apiURL(nil, repositoryPath(org, repo, "pullrequests", strconv.Itoa(number))), nil, pr)
+	if err != nil {
+		return nil, "", nil, errors.Wrapf(err, "getting pull request %d on %s/%s", number, owner, name)
+	}
+	return repo, org, pr, nil
+}
+
+// UpdatePullRequestStatus reloads the pull request
+func (p *AzureDevOpsProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
+	if pr.Number == nil {
+		return fmt.Errorf("missing Number for GitPullRequest %#v", pr)
+	}
+	repo, _, source, err := p.getPullRequest(pr.Owner, pr.Repo, *pr.Number)
+	if err != nil {
+		return err
+	}
+	*pr = *p.toPullRequest(pr.Owner, repo, source)
+	return nil
+}
+
+// GetPullRequest gets the pull request
+func (p *AzureDevOpsProvider) GetPullRequest(owner string, repo *GitRepository, number int) (*GitPullRequest, error) {
+	pr := &GitPullRequest{
+		Owner:  owner,
+		Repo:   repo.Name,
+		Number: &number,
+	}
+	err := p.UpdatePullRequestStatus(pr)
+	return pr, err
+}
+
+// ListOpenPullRequests lists the active pull requests
+func (p *AzureDevOpsProvider) ListOpenPullRequests(owner string, name string) ([]*GitPullRequest, error) {
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitPullRequest{}
+	for skip := 0; ; skip += azureDevOpsPageSize {
+		query := url.Values{}
+		query.Set("searchCriteria.status", "active")
+		query.Set("$top", strconv.Itoa(azureDevOpsPageSize))
+		query.Set("$skip", strconv.Itoa(skip))
+		result := struct {
+			Value []*azureDevOpsPullRequest `json:"value"`
+		}{}
+		err = p.do(http.MethodGet, p.apiURL(query, repositoryPath(org, repo, "pullrequests")), nil, &result)
+		if err != nil {
+			return answer, errors.Wrapf(err, "listing pull requests on %s/%s", owner, name)
+		}
+		for _, pr := range result.Value {
+			answer = append(answer, p.toPullRequest(owner, repo, pr))
+		}
+		if len(result.Value) < azureDevOpsPageSize {
+			break
+		}
+	}
+	return answer, nil
+}
+
+func toAzureDevOpsCommit(commit *azureDevOpsCommit) *GitCommit {
+	answer := &GitCommit{
+		SHA:     commit.CommitID,
+		Message: commit.Comment,
+		URL:     commit.RemoteURL,
+	}
+	if commit.Author != nil {
+		answer.Author = &GitUser{Name: commit.Author.Name, Email: commit.Author.Email}
+	}
+	if commit.Committer != nil {
+		answer.Committer = &GitUser{Name: commit.Committer.Name, Email: commit.Committer.Email}
+	}
+	return answer
+}
+
+// GetPullRequestCommits gets the commits of the pull request
+func (p *AzureDevOpsProvider) GetPullRequestCommits(owner string, repository *GitRepository, number int) ([]*GitCommit, error) {
+	repo, org, err := p.repository(owner, repository.Name)
+	if err != nil {
+		return nil, err
+	}
+	result := struct {
+		Value []*azureDevOpsCommit `json:"value"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(nil, repositoryPath(org, repo, "pullrequests", strconv.Itoa(number), "commits")), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the commits of pull request %d on %s/%s", number, owner, repository.Name)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range result.Value {
+		answer = append(answer, toAzureDevOpsCommit(commit))
+	}
+	return answer, nil
+}
+
+// PullRequestLastCommitStatus returns the combined state of the statuses of the last commit of the pull request
+func (p *AzureDevOpsProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string, error) {
+	ref := pr.LastCommitSha
+	if ref == "" {
+		return "", fmt.Errorf("missing String for LastCommitSha %#v", pr)
+	}
+	statuses, err := p.ListCommitStatus(pr.Owner, pr.Repo, ref)
+	if err != nil {
+		return "", err
+	}
+	if len(statuses) == 0 {
+		return "", fmt.Errorf("could not find a status for repository %s/%s with ref %s", pr.Owner, pr.Repo, ref)
+	}
+	return combinedCommitStatus(statuses), nil
+}
+
+// azureDevOpsStates maps the states of commit statuses to the states of Azure DevOps
+var azureDevOpsStates = map[string]string{
+	"success": "succeeded",
+	"failure": "failed",
+	"error":   "error",
+	"pending": "pending",
+}
+
+func fromAzureDevOpsStatus(status *azureDevOpsStatus) *GitRepoStatus {
+	state := status.State
+	for k, v := range azureDevOpsStates {
+		if v == status.State {
+			state = k
+		}
+	}
+	if status.State == "notApplicable" {
+		state = "success"
+	}
+	context := status.Context.Name
+	if status.Context.Genre != "" {
+		context = status.Context.Genre + "/" + context
+	}
+	return &GitRepoStatus{
+		ID:          strconv.Itoa(status.ID),
+		Context:     context,
+		URL:         status.URL,
+		TargetURL:   status.TargetURL,
+		State:       state,
+		Description: status.Description,
+	}
+}
+
+// ListCommitStatus lists the latest status of each context of the commit
+func (p *AzureDevOpsProvider) ListCommitStatus(org string, name string, sha string) ([]*GitRepoStatus, error) {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("latestOnly", "true")
+	result := struct {
+		Value []*azureDevOpsStatus `json:"value"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(query, repositoryPath(organisation, repo, "commits", sha, "statuses")), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the statuses of commit %s on %s/%s", sha, org, name)
+	}
+	var statuses []*GitRepoStatus
+	for _, status := range result.Value {
+		if status.State == "notSet" {
+			continue
+		}
+		statuses = append(statuses, fromAzureDevOpsStatus(status))
+	}
+	return statuses, nil
+}
+
+// UpdateCommitStatus adds the status to the commit. Any genre of the status is the text before the last / of its
+// context
+func (p *AzureDevOpsProvider) UpdateCommitStatus(org string, name string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	state, ok := azureDevOpsStates[status.State]
+	if !ok {
+		return nil, fmt.Errorf("unsupported commit status state %s", status.State)
+	}
+	context := azureDevOpsStatusContext{Name: status.Context}
+	if i := strings.LastIndex(status.Context, "/"); i > 0 {
+		context.Genre = status.Context[:i]
+		context.Name = status.Context[i+1:]
+	}
+	body := &azureDevOpsStatus{
+		State:       state,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+		Context:     context,
+	}
+	result := &azureDevOpsStatus{}
+	err = p.do(http.MethodPost, p.apiURL(nil, repositoryPath(organisation, repo, "commits", sha, "statuses")), body, result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating status %s of commit %s on %s/%s", status.Context, sha, org, name)
+	}
+	return fromAzureDevOpsStatus(result), nil
+}
+
+// ListCommits lists the commits of the repository
+func (p *AzureDevOpsProvider) ListCommits(owner string, name string, opt *ListCommitsArguments) ([]*GitCommit, error) {
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if opt.SHA != "" {
+		query.Set("searchCriteria.itemVersion.version", opt.SHA)
+		query.Set("searchCriteria.itemVersion.versionType", "commit")
+	}
+	if opt.Path != "" {
+		query.Set("searchCriteria.itemPath", opt.Path)
+	}
+	if opt.Author != "" {
+		query.Set("searchCriteria.author", opt.Author)
+	}
+	if !opt.Since.IsZero() {
+		query.Set("searchCriteria.fromDate", opt.Since.Format(time.RFC3339))
+	}
+	if !opt.Until.IsZero() {
+		query.Set("searchCriteria.toDate", opt.Until.Format(time.RFC3339))
+	}
+	if opt.PerPage > 0 {
+		query.Set("searchCriteria.$top", strconv.Itoa(opt.PerPage))
+		if opt.Page > 1 {
+			query.Set("searchCriteria.$skip", strconv.Itoa((opt.Page-1)*opt.PerPage))
+		}
+	}
+	result := struct {
+		Value []*azureDevOpsCommit `json:"value"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(query, repositoryPath(org, repo, "commits")), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing commits on %s/%s", owner, name)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range result.Value {
+		answer = append(answer, toAzureDevOpsCommit(commit))
+	}
+	return answer, nil
+}
+
+// MergePullRequest completes the pull request at its last commit
+func (p *AzureDevOpsProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("missing Number for GitPullRequest %#v", pr)
+	}
+	repo, org, source, err := p.getPullRequest(pr.Owner, pr.Repo, *pr.Number)
+	if err != nil {
+		return err
+	}
+	if source.IsDraft {
+		return fmt.Errorf("cannot merge pull request %s as it is a draft", pr.URL)
+	}
+	lastCommit := source.LastMergeSourceCommit
+	if pr.LastCommitSha != "" {
+		lastCommit = &azureDevOpsCommitRef{CommitID: pr.LastCommitSha}
+	}
+	body := &azureDevOpsPullRequest{
+		Status:                "completed",
+		LastMergeSourceCommit: lastCommit,
+		CompletionOptions: map[string]interface{}{
+			"mergeCommitMessage": message,
+		},
+	}
+	result := &azureDevOpsPullRequest{}
+	err = p.do(http.MethodPatch, p.apiURL(nil, repositoryPath(org, repo, "pullrequests", strconv.Itoa(*pr.Number))), body, result)
+	if err != nil {
+		return errors.Wrapf(err, "merging pull request %s", pr.URL)
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) listSubscriptions(org string, repo *azureDevOpsRepository) ([]*azureDevOpsSubscription, error) {
+	result := struct {
+		Value []*azureDevOpsSubscription `json:"value"`
+	}{}
+	err := p.do(http.MethodGet, p.apiURL(nil, org, "_apis/hooks/subscriptions"), nil, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the service hooks of %s", org)
+	}
+	answer := []*azureDevOpsSubscription{}
+	for _, s := range result.Value {
+		if s.ConsumerID == "webHooks" && s.PublisherInputs["repository"] == repo.ID {
+			answer = append(answer, s)
+		}
+	}
+	return answer, nil
+}
+
+func azureDevOpsConsumerInputs(data *GitWebHookArguments) map[string]string {
+	inputs := map[string]string{
+		"url": data.URL,
+	}
+	if data.Secret != "" {
+		inputs["httpHeaders"] = AzureDevOpsWebHookSecretHeader + ":" + data.Secret
+	}
+	if data.InsecureSSL {
+		inputs["acceptUntrustedCerts"] = "true"
+	}
+	return inputs
+}
+
+// CreateWebHook creates the service hooks of the repository posting its push, pull request and pull request
+// comment events to the URL
+func (p *AzureDevOpsProvider) CreateWebHook(data *GitWebHookArguments) error {
+	owner := data.Owner
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("missing property Repo")
+	}
+	if data.URL == "" {
+		return fmt.Errorf("missing property URL")
+	}
+	repo, org, err := p.repository(owner, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := p.listSubscriptions(org, repo)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, s := range subscriptions {
+		if s.ConsumerInputs["url"] == data.URL {
+			existing[s.EventType] = true
+		}
+	}
+	for _, event := range azureDevOpsWebHookEvents {
+		if existing[event] {
+			log.Logger().Warnf("Already has a service hook registered for %s events to %s", event, data.URL)
+			continue
+		}
+		subscription := &azureDevOpsSubscription{
+			PublisherID:      "tfs",
+			EventType:        event,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			PublisherInputs: map[string]string{
+				"projectId":  repo.Project.ID,
+				"repository": repo.ID,
+			},
+			ConsumerInputs: azureDevOpsConsumerInputs(data),
+		}
+		log.Logger().Infof("Creating Azure DevOps service hook for %s events of %s/%s for url %s", util.ColorInfo(event), util.ColorInfo(owner), util.ColorInfo(repo.Name), util.ColorInfo(data.URL))
+		err = p.do(http.MethodPost, p.apiURL(nil, org, "_apis/hooks/subscriptions"), subscription, nil)
+		if err != nil {
+			return errors.Wrapf(err, "creating service hook for %s events of %s/%s", event, owner, repo.Name)
+		}
+	}
+	return nil
+}
+
+// ListWebHooks lists the URLs of the service hooks of the repository. Their IDs are always 0 as the service hooks
+// have UUIDs
+func (p *AzureDevOpsProvider) ListWebHooks(owner string, name string) ([]*GitWebHookArguments, error) {
+	webHooks := []*GitWebHookArguments{}
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return webHooks, err
+	}
+	subscriptions, err := p.listSubscriptions(org, repo)
+	if err != nil {
+		return webHooks, err
+	}
+	urls := map[string]bool{}
+	for _, s := range subscriptions {
+		u := s.ConsumerInputs["url"]
+		if u != "" && !urls[u] {
+			urls[u] = true
+			webHooks = append(webHooks, &GitWebHookArguments{
+				Owner: owner,
+				URL:   u,
+			})
+		}
+	}
+	return webHooks, nil
+}
+
+// UpdateWebHook updates the service hooks of the repository posting to the existing URL
+func (p *AzureDevOpsProvider) UpdateWebHook(data *GitWebHookArguments) error {
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("missing property Repo")
+	}
+	repo, org, err := p.repository(data.Owner, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := p.listSubscriptions(org, repo)
+	if err != nil {
+		return err
+	}
+	existingURL := data.ExistingURL
+	if existingURL == "" {
+		existingURL = data.URL
+	}
+	updated := false
+	for _, s := range subscriptions {
+		if s.ConsumerInputs["url"] != existingURL {
+			continue
+		}
+		s.ConsumerInputs = azureDevOpsConsumerInputs(data)
+		log.Logger().Infof("Updating Azure DevOps service hook for %s events of %s/%s for url %s", util.ColorInfo(s.EventType), util.ColorInfo(data.Owner), util.ColorInfo(repo.Name), util.ColorInfo(data.URL))
+		err = p.do(http.MethodPut, p.apiURL(nil, org, "_apis/hooks/subscriptions", s.ID), s, nil)
+		if err != nil {
+			return errors.Wrapf(err, "updating service hook %s of %s/%s", s.ID, data.Owner, repo.Name)
+		}
+		updated = true
+	}
+	if !updated {
+		log.Logger().Warn("No webhooks found to update")
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) IsGitHub() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsGitea() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsBitbucketCloud() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsBitbucketServer() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) IsGerrit() bool {
+	return false
+}
+
+func (p *AzureDevOpsProvider) Kind() string {
+	return KindAzureDevOps
+}
+
+// GetIssue is not supported as the work items of Azure Boards are not issues of the repository
+func (p *AzureDevOpsProvider) GetIssue(org string, name string, number int) (*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking")
+	return nil, nil
+}
+
+// IssueURL returns the URL of the pull request or of the work item
+func (p *AzureDevOpsProvider) IssueURL(org string, name string, number int, isPull bool) string {
+	organisation, _ := splitAzureDevOpsOwner(org)
+	if !isPull {
+		return util.UrlJoin(p.Server.URL, organisation, "_workitems/edit", strconv.Itoa(number))
+	}
+	repo, _, err := p.repository(org, name)
+	if err != nil {
+		log.Logger().Warnf("failed to find repository %s/%s: %s", org, name, err.Error())
+		return ""
+	}
+	return util.UrlJoin(repo.WebURL, "pullrequest", strconv.Itoa(number))
+}
+
+func (p *AzureDevOpsProvider) SearchIssues(org string, name string, query string) ([]*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) SearchIssuesClosedSince(org string, name string, t time.Time) ([]*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) CreateIssue(owner string, repo string, issue *GitIssue) (*GitIssue, error) {
+	log.Logger().Warn("Azure DevOps does not support issue tracking")
+	return nil, nil
+}
+
+func (p *AzureDevOpsProvider) HasIssues() bool {
+	return false
+}
+
+// AddPRComment adds the comment as a new thread of the pull request
+func (p *AzureDevOpsProvider) AddPRComment(pr *GitPullRequest, comment string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("missing Number for GitPullRequest %#v", pr)
+	}
+	repo, org, err := p.repository(pr.Owner, pr.Repo)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{
+				"parentCommentId": 0,
+				"content":         comment,
+				"commentType":     1,
+			},
+		},
+		"status": 1,
+	}
+	err = p.do(http.MethodPost, p.apiURL(nil, repositoryPath(org, repo, "pullrequests", strconv.Itoa(*pr.Number), "threads")), body, nil)
+	if err != nil {
+		return errors.Wrapf(err, "commenting on pull request %s", pr.URL)
+	}
+	return nil
+}
+
+// CreateIssueComment comments on the pull request of the number as there are no issues
+func (p *AzureDevOpsProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
+	return p.AddPRComment(&GitPullRequest{Owner: owner, Repo: repo, Number: &number}, comment)
+}
+
+// AddLabelsToIssue adds the labels to the pull request of the number
+func (p *AzureDevOpsProvider) AddLabelsToIssue(owner string, name string, number int, labels []string) error {
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("api-version", azureDevOpsPreviewAPIVersion)
+	for _, label := range labels {
+		err = p.do(http.MethodPost, p.apiURL(query, repositoryPath(org, repo, "pullrequests", strconv.Itoa(number), "labels")), &azureDevOpsLabel{Name: label}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add label %s to pull request on %s/%s with ID %v", label, owner, name, number)
+		}
+	}
+	return nil
+}
+
+// UpdateRelease is not supported as Azure Repos has no releases
+func (p *AzureDevOpsProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	log.Logger().Debugf("Azure DevOps does not support releases so not updating release %s of %s/%s", tag, owner, repo)
+	return nil
+}
+
+// UpdateReleaseStatus is not supported as Azure Repos has no releases
+func (p *AzureDevOpsProvider) UpdateReleaseStatus(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	return nil
+}
+
+// ListReleases returns no releases as Azure Repos has no releases
+func (p *AzureDevOpsProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
+	return nil, nil
+}
+
+// GetRelease returns no release as Azure Repos has no releases
+func (p *AzureDevOpsProvider) GetRelease(org string, name string, tag string) (*GitRelease, error) {
+	return nil, nil
+}
+
+// UploadReleaseAsset is not supported as Azure Repos has no releases
+func (p *AzureDevOpsProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
+	return nil, fmt.Errorf("release assets are not supported on Azure DevOps")
+}
+
+// GetLatestRelease returns no release as Azure Repos has no releases
+func (p *AzureDevOpsProvider) GetLatestRelease(org string, name string) (*GitRelease, error) {
+	return nil, nil
+}
+
+// GetContent returns the base64 encoded content of the file at the ref
+func (p *AzureDevOpsProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
+	repo, organisation, err := p.repository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("includeContent", "true")
+	if ref != "" {
+		query.Set("versionDescriptor.version", ref)
+	}
+	item := struct {
+		ObjectID string `json:"objectId"`
+		Path     string `json:"path"`
+		URL      string `json:"url"`
+		Content  string `json:"content"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(query, repositoryPath(organisation, repo, "items")), nil, &item)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s on %s/%s", path, org, name)
+	}
+	return &GitFileContent{
+		Type:     "file",
+		Encoding: "base64",
+		Size:     len(item.Content),
+		Name:     item.Path[strings.LastIndex(item.Path, "/")+1:],
+		Path:     item.Path,
+		Content:  base64.StdEncoding.EncodeToString([]byte(item.Content)),
+		Sha:      item.ObjectID,
+		Url:      item.URL,
+	}, nil
+}
+
+func (p *AzureDevOpsProvider) JenkinsWebHookPath(gitURL string, secret string) string {
+	return "/generic-webhook-trigger/invoke?token=" + secret
+}
+
+func (p *AzureDevOpsProvider) Label() string {
+	return p.Server.Label()
+}
+
+func (p *AzureDevOpsProvider) ServerURL() string {
+	return p.Server.URL
+}
+
+// BranchArchiveURL returns the URL of the ZIP archive of the branch
+func (p *AzureDevOpsProvider) BranchArchiveURL(org string, name string, branch string) string {
+	organisation, project := splitAzureDevOpsOwner(org)
+	query := url.Values{}
+	query.Set("path", "/")
+	query.Set("versionDescriptor.version", branch)
+	query.Set("$format", "zip")
+	query.Set("download", "true")
+	return p.apiURL(query, organisation, project, "_apis/git/repositories", name, "items")
+}
+
+func (p *AzureDevOpsProvider) CurrentUsername() string {
+	return p.Username
+}
+
+func (p *AzureDevOpsProvider) UserAuth() auth.UserAuth {
+	return p.User
+}
+
+func (p *AzureDevOpsProvider) UserInfo(username string) *GitUser {
+	return &GitUser{
+		Login: username,
+	}
+}
+
+// AddCollaborator is not supported as the permissions of Azure Repos are managed by the project
+func (p *AzureDevOpsProvider) AddCollaborator(user string, organisation string, repo string) error {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps. Please add user: %v as a contributor to the project.", user)
+	return nil
+}
+
+func (p *AzureDevOpsProvider) ListInvitations() ([]*github.RepositoryInvitation, *github.Response, error) {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.")
+	return []*github.RepositoryInvitation{}, &github.Response{}, nil
+}
+
+func (p *AzureDevOpsProvider) AcceptInvitation(ID int64) (*github.Response, error) {
+	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.")
+	return &github.Response{}, nil
+}
+
+// ShouldForkForPullRequest returns false as the pull requests are created from branches of the repository
+func (p *AzureDevOpsProvider) ShouldForkForPullRequest(originalOwner string, repoName string, username string) bool {
+	return false
+}
+
+// GetBranch returns the branch including the commit at its tip. It is protected if it has any enabled branch
+// policies
+func (p *AzureDevOpsProvider) GetBranch(owner string, name string, branch string) (*GitBranch, error) {
+	repo, org, err := p.repository(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("filter", "heads/"+branch)
+	refs := struct {
+		Value []*azureDevOpsRef `json:"value"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(query, repositoryPath(org, repo, "refs")), nil, &refs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting branch %s on %s/%s", branch, owner, name)
+	}
+	var ref *azureDevOpsRef
+	for _, r := range refs.Value {
+		if r.Name == azureDevOpsBranchRef(branch) {
+			ref = r
+		}
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("no branch %s found on %s/%s", branch, owner, name)
+	}
+
+	query = url.Values{}
+	query.Set("repositoryId", repo.ID)
+	query.Set("refName", ref.Name)
+	policies := struct {
+		Value []struct {
+			IsEnabled bool `json:"isEnabled"`
+		} `json:"value"`
+	}{}
+	err = p.do(http.MethodGet, p.apiURL(query, org, repo.Project.ID, "_apis/policy/configurations"), nil, &policies)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the policies of branch %s on %s/%s", branch, owner, name)
+	}
+	protected := ref.IsLocked
+	for _, policy := range policies.Value {
+		if policy.IsEnabled {
+			protected = true
+		}
+	}
+	return &GitBranch{
+		Name:      branch,
+		Protected: protected,
+		Commit: &GitCommit{
+			SHA:    ref.ObjectID,
+			Branch: branch,
+		},
+	}, nil
+}
+
+// GetProjects returns no projects as the boards of Azure DevOps are not supported
+func (p *AzureDevOpsProvider) GetProjects(owner string, repo string) ([]GitProject, error) {
+	return nil, nil
+}
+
+// IsWikiEnabled returns false as the wikis of Azure DevOps are not supported
+func (p *AzureDevOpsProvider) IsWikiEnabled(owner string, repo string) (bool, error) {
+	return false, nil
+}
+
+// ConfigureFeatures is not supported as the features are configured for the project
+func (p *AzureDevOpsProvider) ConfigureFeatures(owner string, repo string, issues *bool, projects *bool, wikis *bool) (*GitRepository, error) {
+	return p.GetRepository(owner, repo)
+}
@@ -0,0 +1,230 @@
+// +build unit
+
+package gits_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	azureDevOpsRepoPath  = "/myorg/_apis/git/repositories/5febef5a-833d-4e14-b9c0-14cb638f91e6"
+	azureDevOpsCommitSHA = "b60280bc6e62e2f880f1b63c1e24987664d3bda3"
+)
+
+type AzureDevOpsProviderTestSuite struct {
+	suite.Suite
+	mux           *http.ServeMux
+	server        *httptest.Server
+	provider      gits.GitProvider
+	subscriptions []map[string]interface{}
+}
+
+var azureDevOpsRouter = util.Router{
+	"/myorg/_apis/git/repositories": util.MethodMap{
+		"GET": "repositories.json",
+	},
+	"/myorg/myproject/_apis/git/repositories": util.MethodMap{
+		"GET": "repositories.json",
+	},
+	azureDevOpsRepoPath + "/pullrequests": util.MethodMap{
+		"POST": "pullrequest.json",
+	},
+	azureDevOpsRepoPath + "/pullrequests/22": util.MethodMap{
+		"GET":   "pullrequest.json",
+		"PATCH": "pullrequest.json",
+	},
+	azureDevOpsRepoPath + "/pullrequests/22/threads": util.MethodMap{
+		"POST": "thread.json",
+	},
+	azureDevOpsRepoPath + "/pullrequests/22/labels": util.MethodMap{
+		"POST": "label.json",
+	},
+	azureDevOpsRepoPath + "/commits/" + azureDevOpsCommitSHA + "/statuses": util.MethodMap{
+		"GET":  "statuses.json",
+		"POST": "status.json",
+	},
+	azureDevOpsRepoPath + "/items": util.MethodMap{
+		"GET": "item.json",
+	},
+	azureDevOpsRepoPath + "/refs": util.MethodMap{
+		"GET": "refs.json",
+	},
+	"/myorg/6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c/_apis/policy/configurations": util.MethodMap{
+		"GET": "policies.json",
+	},
+}
+
+func (suite *AzureDevOpsProviderTestSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+	for path, methodMap := range azureDevOpsRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/azure_devops", methodMap))
+	}
+	subscriptions := util.GetMockAPIResponseFromFile("test_data/azure_devops", util.MethodMap{
+		"GET":  "subscriptions.json",
+		"POST": "subscription.json",
+	})
+	suite.mux.HandleFunc("/myorg/_apis/hooks/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			subscription := map[string]interface{}{}
+			suite.Require().NoError(json.NewDecoder(r.Body).Decode(&subscription))
+			suite.subscriptions = append(suite.subscriptions, subscription)
+		}
+		subscriptions(w, r)
+	})
+	suite.server = httptest.NewServer(suite.mux)
+
+	as := auth.AuthServer{
+		URL:         suite.server.URL,
+		Name:        "Test Azure DevOps",
+		Kind:        gits.KindAzureDevOps,
+		CurrentUser: "test-user",
+	}
+	ua := auth.UserAuth{
+		Username: "test-user",
+		ApiToken: "0123456789abdef",
+	}
+	provider, err := gits.WithAzureDevOpsClient(&as, &ua, suite.server.Client(), gits.NewGitCLI())
+	suite.Require().NoError(err)
+	suite.provider = provider
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetRepository() {
+	for _, owner := range []string{"myorg", "myorg/myproject"} {
+		repo, err := suite.provider.GetRepository(owner, "MyRepo")
+		suite.Require().NoError(err)
+		suite.Equal("myrepo", repo.Name)
+		suite.Equal("myorg", repo.Organisation)
+		suite.Equal("myproject", repo.Project)
+		suite.Equal("https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", repo.CloneURL)
+		suite.Equal("https://dev.azure.com/myorg/myproject/_git/myrepo", repo.HTMLURL)
+	}
+
+	_, err := suite.provider.GetRepository("myorg", "missing")
+	suite.Error(err)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListRepositories() {
+	repos, err := suite.provider.ListRepositories("myorg")
+	suite.Require().NoError(err)
+	suite.Require().Len(repos, 2)
+	suite.False(repos[0].Archived)
+	suite.True(repos[1].Archived)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetPullRequest() {
+	pr, err := suite.provider.GetPullRequest("myorg", &gits.GitRepository{Name: "myrepo"}, 22)
+	suite.Require().NoError(err)
+	suite.Equal("https://dev.azure.com/myorg/myproject/_git/myrepo/pullrequest/22", pr.URL)
+	suite.Equal("my new feature", pr.Title)
+	suite.Equal("open", *pr.State)
+	suite.False(*pr.Merged)
+	suite.True(*pr.Mergeable)
+	suite.False(*pr.Draft)
+	suite.Equal("feature", *pr.HeadRef)
+	suite.Equal(azureDevOpsCommitSHA, pr.LastCommitSha)
+	suite.Equal("fabrikamfiber16@hotmail.com", pr.Author.Login)
+	suite.Require().Len(pr.RequestedReviewers, 1)
+	suite.Require().Len(pr.Labels, 1)
+	suite.Equal("enhancement", *pr.Labels[0].Name)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreatePullRequest() {
+	pr, err := suite.provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		GitRepository: &gits.GitRepository{Organisation: "myorg", Name: "myrepo"},
+		Title:         "my new feature",
+		Head:          "feature",
+		Base:          "master",
+	})
+	suite.Require().NoError(err)
+	suite.Equal(22, *pr.Number)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestMergePullRequest() {
+	number := 22
+	err := suite.provider.MergePullRequest(&gits.GitPullRequest{Owner: "myorg", Repo: "myrepo", Number: &number}, "merged")
+	suite.NoError(err)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus("myorg", "myrepo", azureDevOpsCommitSHA)
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 2)
+	suite.Equal("jx/build", statuses[0].Context)
+	suite.Equal("success", statuses[0].State)
+	suite.Equal("tests", statuses[1].Context)
+	suite.Equal("pending", statuses[1].State)
+
+	state, err := suite.provider.PullRequestLastCommitStatus(&gits.GitPullRequest{Owner: "myorg", Repo: "myrepo", LastCommitSha: azureDevOpsCommitSHA})
+	suite.Require().NoError(err)
+	suite.Equal("pending", state)
+
+	status, err := suite.provider.UpdateCommitStatus("myorg", "myrepo", azureDevOpsCommitSHA, &gits.GitRepoStatus{
+		State:   "failure",
+		Context: "jx/build",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("failure", status.State)
+	suite.Equal("jx/build", status.Context)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestWebHooks() {
+	hooks, err := suite.provider.ListWebHooks("myorg", "myrepo")
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 1)
+	suite.Equal("https://hook.jx.example.com/hook", hooks[0].URL)
+
+	suite.subscriptions = nil
+	err = suite.provider.CreateWebHook(&gits.GitWebHookArguments{
+		Owner:  "myorg",
+		Repo:   &gits.GitRepository{Name: "myrepo"},
+		URL:    "https://hook.jx.example.com/hook",
+		Secret: "mysecret",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(suite.subscriptions, 4, "should not recreate the existing git.push service hook")
+	for _, subscription := range suite.subscriptions {
+		suite.NotEqual("git.push", subscription["eventType"])
+		suite.Equal("webHooks", subscription["consumerId"])
+		inputs := subscription["consumerInputs"].(map[string]interface{})
+		suite.Equal("https://hook.jx.example.com/hook", inputs["url"])
+		suite.Equal(gits.AzureDevOpsWebHookSecretHeader+":mysecret", inputs["httpHeaders"])
+	}
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("myorg", "myrepo", "jenkins-x.yml", "master")
+	suite.Require().NoError(err)
+	suite.Equal("jenkins-x.yml", content.Name)
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().NoError(err)
+	suite.Equal("buildPack: go\n", string(data))
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetBranch() {
+	branch, err := suite.provider.GetBranch("myorg", "myrepo", "master")
+	suite.Require().NoError(err)
+	suite.Equal("master", branch.Name)
+	suite.True(branch.Protected)
+	suite.Equal(azureDevOpsCommitSHA, branch.Commit.SHA)
+}
+
+func TestAzureDevOpsProviderTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAzureDevOpsProviderTestSuite in short mode")
+	} else {
+		suite.Run(t, new(AzureDevOpsProviderTestSuite))
+	}
+}
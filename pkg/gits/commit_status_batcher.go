@@ -0,0 +1,101 @@
+package gits
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
+
+// CommitStatusBatcher batches the commit status updates so that only the latest status of each context of a commit
+// is reported when the batch is flushed, saving the API requests of the intermediate states of busy pipelines
+type CommitStatusBatcher struct {
+	lock    sync.Mutex
+	pending map[commitStatusKey]*pendingCommitStatus
+	order   []commitStatusKey
+}
+
+type commitStatusKey struct {
+	server  string
+	owner   string
+	repo    string
+	sha     string
+	context string
+}
+
+type pendingCommitStatus struct {
+	provider GitProvider
+	status   *GitRepoStatus
+}
+
+// NewCommitStatusBatcher creates a new batcher
+func NewCommitStatusBatcher() *CommitStatusBatcher {
+	return &CommitStatusBatcher{
+		pending: map[commitStatusKey]*pendingCommitStatus{},
+	}
+}
+
+// Add adds the status of the commit to the batch replacing any pending status of the same context
+func (b *CommitStatusBatcher) Add(provider GitProvider, owner string, repo string, sha string, status *GitRepoStatus) {
+	key := commitStatusKey{
+		server:  provider.ServerURL(),
+		owner:   owner,
+		repo:    repo,
+		sha:     sha,
+		context: status.Context,
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, ok := b.pending[key]; !ok {
+		b.order = append(b.order, key)
+	}
+	b.pending[key] = &pendingCommitStatus{
+		provider: provider,
+		status:   status,
+	}
+}
+
+// Len returns the number of pending statuses
+func (b *CommitStatusBatcher) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.order)
+}
+
+// Flush reports the pending statuses in the order they were first added returning the combined errors of the
+// updates which failed
+func (b *CommitStatusBatcher) Flush() error {
+	b.lock.Lock()
+	pending := b.pending
+	order := b.order
+	b.pending = map[commitStatusKey]*pendingCommitStatus{}
+	b.order = nil
+	b.lock.Unlock()
+
+	errs := []error{}
+	for _, key := range order {
+		p := pending[key]
+		_, err := p.provider.UpdateCommitStatus(key.owner, key.repo, key.sha, p.status)
+		if err != nil {
+			log.Logger().Warnf("failed to report git status %s of commit %s on %s/%s: %s", key.context, key.sha, key.owner, key.repo, err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// Run flushes the batch every interval until the stop channel is closed, then flushes it a final time
+func (b *CommitStatusBatcher) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush() //nolint:errcheck
+		case <-stop:
+			b.Flush() //nolint:errcheck
+			return
+		}
+	}
+}
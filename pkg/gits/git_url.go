@@ -143,6 +143,10 @@ func ParseGitURL(text string) (*GitRepository, error) {
 		t = strings.TrimSuffix(t, ".git")
 
 		arr := util.RegexpSplit(t, ":|/")
+		// Azure DevOps SSH URLs are of the form git@ssh.dev.azure.com:v3/<org>/<project>/<repo>
+		if len(arr) >= 5 && arr[1] == "v3" {
+			arr = append(arr[:1], arr[2:]...)
+		}
 		if len(arr) >= 3 {
 			answer.Scheme = "git"
 			answer.Host = arr[0]
@@ -249,6 +253,8 @@ func SaasGitKind(gitServiceUrl string) string {
 		return KindBitBucketCloud
 	case BitbucketCloudURL:
 		return KindBitBucketCloud
+	case AzureDevOpsURL:
+		return KindAzureDevOps
 	case "http://fake.git", FakeGitURL:
 		return KindGitFake
 	default:
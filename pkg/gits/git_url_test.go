@@ -118,6 +118,12 @@ func TestParseGitURL(t *testing.T) {
 		{
 			"https://gitlab.com/bar/subgroup/foo", "gitlab.com", "bar", "foo",
 		},
+		{
+			"https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", "dev.azure.com", "myorg", "myrepo",
+		},
+		{
+			"git@ssh.dev.azure.com:v3/myorg/myproject/myrepo", "ssh.dev.azure.com", "myorg", "myrepo",
+		},
 		{
 			"https://gitlab.com/bar/subgroup/overview", "gitlab.com", "bar", "overview",
 		},
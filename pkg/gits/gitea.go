@@ -1,7 +1,6 @@
 package gits
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -17,6 +16,9 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/util"
 )
 
+// giteaWebHookEvents the events sent to the webhooks so that pull request comments can trigger ChatOps commands
+var giteaWebHookEvents = []string{"create", "push", "pull_request", "issues", "issue_comment", "release"}
+
 type GiteaProvider struct {
 	Username string
 	Client   *gitea.Client
@@ -28,6 +30,7 @@ type GiteaProvider struct {
 
 func NewGiteaProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	client := gitea.NewClient(server.URL, user.ApiToken)
+	client.SetHTTPClient(WithRateLimits(nil))
 
 	provider := GiteaProvider{
 		Client:   client,
@@ -246,7 +249,7 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	hook := gitea.CreateHookOption{
 		Type:   "gitea",
 		Config: config,
-		Events: []string{"create", "push", "pull_request"},
+		Events: giteaWebHookEvents,
 		Active: true,
 	}
 	log.Logger().Infof("Creating Gitea webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(webhookUrl))
@@ -257,13 +260,81 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	return err
 }
 
+// ListWebHooks lists the webhooks of the repository
 func (p *GiteaProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	if owner == "" {
+		owner = p.Username
+	}
+	if repo == "" {
+		return webHooks, fmt.Errorf("Missing property Repo")
+	}
+	hooks, err := p.Client.ListRepoHooks(owner, repo)
+	if err != nil {
+		return webHooks, errors2.Wrapf(err, "listing webhooks for %s/%s", owner, repo)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    hook.ID,
+			Owner: owner,
+			URL:   hook.Config["url"],
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook updates the webhook with the ID or the existing URL of the arguments
 func (p *GiteaProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	owner := data.Owner
+	if owner == "" {
+		owner = p.Username
+	}
+	repo := data.Repo.Name
+	if repo == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	webhookUrl := data.URL
+	if webhookUrl == "" {
+		return fmt.Errorf("Missing property URL")
+	}
+
+	dataId := data.ID
+	if dataId == 0 {
+		hooks, err := p.Client.ListRepoHooks(owner, repo)
+		if err != nil {
+			return errors2.Wrapf(err, "listing webhooks for %s/%s", owner, repo)
+		}
+		for _, hook := range hooks {
+			if hook.Config["url"] == data.ExistingURL {
+				log.Logger().Warnf("Found existing webhook for url %s", data.ExistingURL)
+				dataId = hook.ID
+			}
+		}
+	}
+	if dataId == 0 {
+		log.Logger().Warn("No webhooks found to update")
+		return nil
+	}
+
+	config := map[string]string{
+		"url":          webhookUrl,
+		"content_type": "json",
+	}
+	if data.Secret != "" {
+		config["secret"] = data.Secret
+	}
+	active := true
+	hook := gitea.EditHookOption{
+		Config: config,
+		Events: giteaWebHookEvents,
+		Active: &active,
+	}
+	log.Logger().Infof("Updating Gitea webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(webhookUrl))
+	err := p.Client.EditRepoHook(owner, repo, dataId, hook)
+	if err != nil {
+		return errors2.Wrapf(err, "updating webhook %d for %s/%s", dataId, owner, repo)
+	}
+	return nil
 }
 
 func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
@@ -305,7 +376,17 @@ func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPu
 
 // UpdatePullRequest updates pull request with number using data
 func (p *GiteaProvider) UpdatePullRequest(data *GitPullRequestArguments, number int) (*GitPullRequest, error) {
-	return nil, errors2.Errorf("Not yet implemented for gitea")
+	owner := data.GitRepository.Organisation
+	repo := data.GitRepository.Name
+	config := gitea.EditPullRequestOption{
+		Title: data.Title,
+		Body:  data.Body,
+	}
+	pr, err := p.Client.EditPullRequest(owner, repo, int64(number), config)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "updating pull request %s/%s #%d", owner, repo, number)
+	}
+	return p.toPullRequest(owner, repo, pr), nil
 }
 
 func (p *GiteaProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
@@ -338,17 +419,18 @@ func (p *GiteaProvider) updatePullRequest(pr *GitPullRequest, source *gitea.Pull
 	head := source.Head
 	if head != nil {
 		pr.LastCommitSha = head.Sha
+		pr.HeadRef = &head.Ref
 	} else {
 		pr.LastCommitSha = ""
 	}
-	/*
-		TODO
-
-		pr.ClosedAt = source.Closed
-		pr.StatusesURL = source.StatusesURL
-		pr.IssueURL = source.IssueURL
-		pr.DiffURL = source.DiffURL
-	*/
+	if source.HTMLURL != "" {
+		pr.URL = source.HTMLURL
+	}
+	pr.UpdatedAt = source.Updated
+	// Gitea does not return when a pull request was closed so lets use its last update
+	if source.State == gitea.StateClosed {
+		pr.ClosedAt = source.Updated
+	}
 }
 
 func (p *GiteaProvider) toPullRequest(owner string, repo string, pr *gitea.PullRequest) *GitPullRequest {
@@ -533,17 +615,14 @@ func (p *GiteaProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string,
 	if ref == "" {
 		return "", fmt.Errorf("Missing String for LastCommitSha %#v", pr)
 	}
-	results, err := p.Client.ListStatuses(pr.Owner, pr.Repo, ref, gitea.ListStatusesOption{})
+	result, err := p.Client.GetCombinedStatus(pr.Owner, pr.Repo, ref)
 	if err != nil {
 		return "", err
 	}
-	for _, result := range results {
-		text := string(result.State)
-		if text != "" {
-			return text, nil
-		}
+	if result == nil || result.TotalCount == 0 || result.State == "" {
+		return "", fmt.Errorf("Could not find a status for repository %s/%s with ref %s", pr.Owner, pr.Repo, ref)
 	}
-	return "", fmt.Errorf("Could not find a status for repository %s/%s with ref %s", pr.Owner, pr.Repo, ref)
+	return string(result.State), nil
 }
 
 func (p *GiteaProvider) AddPRComment(pr *GitPullRequest, comment string) error {
@@ -577,7 +656,7 @@ func (p *GiteaProvider) ListCommitStatus(org string, repo string, sha string) ([
 	}
 	for _, result := range results {
 		status := &GitRepoStatus{
-			ID:          string(result.ID),
+			ID:          strconv.FormatInt(result.ID, 10),
 			Context:     result.Context,
 			URL:         result.URL,
 			TargetURL:   result.TargetURL,
@@ -589,8 +668,26 @@ func (p *GiteaProvider) ListCommitStatus(org string, repo string, sha string) ([
 	return answer, nil
 }
 
-func (b *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+// UpdateCommitStatus creates the status of the commit with the context of the status
+func (p *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	opt := gitea.CreateStatusOption{
+		State:       gitea.StatusState(status.State),
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+		Context:     status.Context,
+	}
+	result, err := p.Client.CreateStatus(org, repo, sha, opt)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "updating status %s of commit %s on %s/%s", status.Context, sha, org, repo)
+	}
+	return &GitRepoStatus{
+		ID:          strconv.FormatInt(result.ID, 10),
+		Context:     result.Context,
+		URL:         result.URL,
+		TargetURL:   result.TargetURL,
+		State:       string(result.State),
+		Description: result.Description,
+	}, nil
 }
 
 func (p *GiteaProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
@@ -767,8 +864,14 @@ func (p *GiteaProvider) UserInfo(username string) *GitUser {
 	}
 }
 
+// AddCollaborator adds the user as a collaborator with write permission so that the pipeline user can push. If the
+// user cannot be added it has to be added manually so the failure is only logged
 func (p *GiteaProvider) AddCollaborator(user string, organisation string, repo string) error {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Gitea. Please add user: %v as a collaborator to this project.", user)
+	permission := "write"
+	err := p.Client.AddCollaborator(organisation, repo, user, gitea.AddCollaboratorOption{Permission: &permission})
+	if err != nil {
+		log.Logger().Infof("Failed to automatically add the pipeline user as a collaborator to %s/%s: %s. Please add user: %v as a collaborator to this project.", organisation, repo, err.Error(), user)
+	}
 	return nil
 }
 
@@ -798,7 +901,29 @@ func (p *GiteaProvider) ListCommits(owner, repo string, opt *ListCommitsArgument
 
 // AddLabelsToIssue adds labels to issues or pullrequests
 func (p *GiteaProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	return fmt.Errorf("Getting content not supported on gitea")
+	repoLabels, err := p.Client.ListRepoLabels(owner, repo)
+	if err != nil {
+		return errors2.Wrapf(err, "listing labels for %s/%s", owner, repo)
+	}
+	ids := []int64{}
+	for _, name := range labels {
+		found := false
+		for _, label := range repoLabels {
+			if label.Name == name {
+				ids = append(ids, label.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no label %s found in %s/%s", name, owner, repo)
+		}
+	}
+	_, err = p.Client.AddIssueLabels(owner, repo, int64(number), gitea.IssueLabelsOption{Labels: ids})
+	if err != nil {
+		return errors2.Wrapf(err, "failed to add labels to issue on %s/%s with ID %v", owner, repo, number)
+	}
+	return nil
 }
 
 // GetLatestRelease fetches the latest release from the git provider for org and name
@@ -807,17 +932,50 @@ func (p *GiteaProvider) GetLatestRelease(org string, name string) (*GitRelease,
 	if err != nil {
 		return nil, errors2.Wrapf(err, "getting releases for %s/%s", org, name)
 	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("no releases found for %s/%s", org, name)
+	}
 	return toGiteaRelease(org, name, releases[0]), nil
 }
 
 // UploadReleaseAsset will upload an asset to org/repo to a release with id, giving it a name, it will return the release asset from the git provider
 func (p *GiteaProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
-	return nil, nil
+	attachment, err := p.Client.CreateReleaseAttachment(org, repo, id, asset, name)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "uploading asset %s to release %d in %s/%s", name, id, org, repo)
+	}
+	return &GitReleaseAsset{
+		ID:                 attachment.ID,
+		Name:               attachment.Name,
+		BrowserDownloadURL: attachment.DownloadURL,
+	}, nil
 }
 
 // GetBranch returns the branch information for an owner/repo, including the commit at the tip
 func (p *GiteaProvider) GetBranch(owner string, repo string, branch string) (*GitBranch, error) {
-	return nil, nil
+	b, err := p.Client.GetRepoBranch(owner, repo, branch)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting branch %s on %s/%s", branch, owner, repo)
+	}
+	answer := &GitBranch{
+		Name: b.Name,
+	}
+	if b.Commit != nil {
+		answer.Commit = &GitCommit{
+			SHA:     b.Commit.ID,
+			Message: b.Commit.Message,
+			URL:     b.Commit.URL,
+			Branch:  b.Name,
+		}
+		if b.Commit.Author != nil {
+			answer.Commit.Author = &GitUser{
+				Login: b.Commit.Author.UserName,
+				Name:  b.Commit.Author.Name,
+				Email: b.Commit.Author.Email,
+			}
+		}
+	}
+	return answer, nil
 }
 
 // GetProjects returns all the git projects in owner/repo
@@ -0,0 +1,241 @@
+// +build unit
+
+package gits_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	giteaUserName      = "testperson"
+	giteaOrgName       = "testorg"
+	giteaRepoName      = "test-repo"
+	giteaEmptyRepoName = "empty-repo"
+	giteaPullRequestID = 7
+	giteaCommitSHA     = "8888888888888888888888888888888888888888"
+)
+
+type GiteaProviderSuite struct {
+	suite.Suite
+	mux      *http.ServeMux
+	server   *httptest.Server
+	provider *gits.GiteaProvider
+
+	// editedHook the body of the last request which edited a webhook
+	editedHook map[string]interface{}
+}
+
+func (suite *GiteaProviderSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+	suite.configureGiteaMock()
+	suite.server = httptest.NewServer(suite.mux)
+
+	userAuth := &auth.UserAuth{
+		Username: giteaUserName,
+		ApiToken: "test",
+	}
+	authServer := &auth.AuthServer{
+		URL:   suite.server.URL,
+		Users: []*auth.UserAuth{userAuth},
+	}
+	provider, err := gits.NewGiteaProvider(authServer, userAuth, gits.NewGitCLI())
+	suite.Require().NoError(err)
+	suite.provider = provider.(*gits.GiteaProvider)
+}
+
+func (suite *GiteaProviderSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *GiteaProviderSuite) configureGiteaMock() {
+	repoPath := fmt.Sprintf("/api/v1/repos/%s/%s", giteaOrgName, giteaRepoName)
+	giteaRouter := util.Router{
+		repoPath + "/hooks": util.MethodMap{
+			"GET": "hooks.json",
+		},
+		fmt.Sprintf("%s/pulls/%d", repoPath, giteaPullRequestID): util.MethodMap{
+			"PATCH": "pull-request.json",
+		},
+		fmt.Sprintf("%s/commits/%s/status", repoPath, giteaCommitSHA): util.MethodMap{
+			"GET": "combined-status.json",
+		},
+		fmt.Sprintf("%s/commits/%s/statuses", repoPath, giteaCommitSHA): util.MethodMap{
+			"GET": "statuses.json",
+		},
+		fmt.Sprintf("%s/statuses/%s", repoPath, giteaCommitSHA): util.MethodMap{
+			"POST": "create-status.json",
+		},
+		repoPath + "/labels": util.MethodMap{
+			"GET": "labels.json",
+		},
+		fmt.Sprintf("%s/issues/%d/labels", repoPath, giteaPullRequestID): util.MethodMap{
+			"POST": "issue-labels.json",
+		},
+		repoPath + "/releases": util.MethodMap{
+			"GET": "releases.json",
+		},
+		fmt.Sprintf("/api/v1/repos/%s/%s/releases", giteaOrgName, giteaEmptyRepoName): util.MethodMap{
+			"GET": "empty-releases.json",
+		},
+		repoPath + "/branches/master": util.MethodMap{
+			"GET": "branch.json",
+		},
+	}
+	for path, methodMap := range giteaRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitea", methodMap))
+	}
+
+	suite.mux.HandleFunc(repoPath+"/hooks/3", func(w http.ResponseWriter, r *http.Request) {
+		suite.Require().Equal("PATCH", r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		suite.Require().NoError(err)
+		suite.editedHook = map[string]interface{}{}
+		suite.Require().NoError(json.Unmarshal(body, &suite.editedHook))
+
+		src, err := ioutil.ReadFile("test_data/gitea/hook.json")
+		suite.Require().NoError(err)
+		w.Write(src)
+	})
+
+	suite.mux.HandleFunc(repoPath+"/collaborators/cannot-be-added", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+}
+
+func (suite *GiteaProviderSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks(giteaOrgName, giteaRepoName)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 1)
+	suite.Require().Equal(int64(3), hooks[0].ID)
+	suite.Require().Equal(giteaOrgName, hooks[0].Owner)
+	suite.Require().Equal("http://hook.jx.example.com/hook", hooks[0].URL)
+}
+
+func (suite *GiteaProviderSuite) TestUpdateWebHook() {
+	err := suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		Owner:       giteaOrgName,
+		Repo:        &gits.GitRepository{Name: giteaRepoName},
+		URL:         "http://hook.jx.example.com/new-hook",
+		ExistingURL: "http://hook.jx.example.com/hook",
+		Secret:      "shh",
+	})
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(suite.editedHook, "the existing webhook should be edited")
+	suite.Require().Equal(map[string]interface{}{
+		"url":          "http://hook.jx.example.com/new-hook",
+		"content_type": "json",
+		"secret":       "shh",
+	}, suite.editedHook["config"])
+	suite.Require().Contains(suite.editedHook["events"], "issue_comment", "pull request comments should trigger the webhook")
+}
+
+func (suite *GiteaProviderSuite) TestUpdatePullRequest() {
+	args := gits.GitPullRequestArguments{
+		GitRepository: &gits.GitRepository{Name: giteaRepoName, Organisation: giteaOrgName},
+		Title:         "Update Test Pull Request",
+		Body:          "updated body",
+	}
+	pr, err := suite.provider.UpdatePullRequest(&args, giteaPullRequestID)
+
+	suite.Require().NoError(err)
+	suite.Require().Equal(giteaPullRequestID, *pr.Number)
+	suite.Require().Equal(giteaOrgName, pr.Owner)
+	suite.Require().Equal(giteaRepoName, pr.Repo)
+	suite.Require().Equal("Update Test Pull Request", pr.Title)
+	suite.Require().Equal(giteaUserName, pr.Author.Login)
+	suite.Require().Equal("feature", *pr.HeadRef)
+	suite.Require().Equal(giteaCommitSHA, pr.LastCommitSha)
+	suite.Require().Equal("http://gitea.example.com/testorg/test-repo/pulls/7", pr.URL)
+	suite.Require().NotNil(pr.ClosedAt, "closed pull requests should have a closed time")
+	suite.Require().Equal(*pr.UpdatedAt, *pr.ClosedAt)
+}
+
+func (suite *GiteaProviderSuite) TestPullRequestLastCommitStatus() {
+	pr := &gits.GitPullRequest{Owner: giteaOrgName, Repo: giteaRepoName, LastCommitSha: giteaCommitSHA}
+	status, err := suite.provider.PullRequestLastCommitStatus(pr)
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("failure", status)
+}
+
+func (suite *GiteaProviderSuite) TestListCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus(giteaOrgName, giteaRepoName, giteaCommitSHA)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 2)
+	suite.Require().Equal("11", statuses[0].ID)
+	suite.Require().Equal("lint", statuses[0].Context)
+	suite.Require().Equal("success", statuses[0].State)
+	suite.Require().Equal("failure", statuses[1].State)
+}
+
+func (suite *GiteaProviderSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus(giteaOrgName, giteaRepoName, giteaCommitSHA, &gits.GitRepoStatus{
+		State:       "pending",
+		Context:     "pr-build",
+		Description: "pr-build is running",
+		TargetURL:   "http://jx.example.com/pr-build",
+	})
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("13", status.ID)
+	suite.Require().Equal("pending", status.State)
+	suite.Require().Equal("pr-build", status.Context)
+}
+
+func (suite *GiteaProviderSuite) TestAddLabelsToIssue() {
+	err := suite.provider.AddLabelsToIssue(giteaOrgName, giteaRepoName, giteaPullRequestID, []string{"updatebot"})
+	suite.Require().NoError(err)
+
+	err = suite.provider.AddLabelsToIssue(giteaOrgName, giteaRepoName, giteaPullRequestID, []string{"missing"})
+	suite.Require().Error(err, "labels which do not exist in the repository should fail")
+}
+
+func (suite *GiteaProviderSuite) TestGetLatestRelease() {
+	release, err := suite.provider.GetLatestRelease(giteaOrgName, giteaRepoName)
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("v1.2.0", release.TagName)
+	suite.Require().Equal("the latest release", release.Body)
+	suite.Require().Equal(3, release.DownloadCount)
+
+	release, err = suite.provider.GetLatestRelease(giteaOrgName, giteaEmptyRepoName)
+	suite.Require().Error(err, "repositories without releases should fail")
+	suite.Require().Nil(release)
+}
+
+func (suite *GiteaProviderSuite) TestGetBranch() {
+	branch, err := suite.provider.GetBranch(giteaOrgName, giteaRepoName, "master")
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("master", branch.Name)
+	suite.Require().Equal("7b5c3cc8be40ee161ae89a06bba6229da1032a0c", branch.Commit.SHA)
+	suite.Require().Equal("john@example.com", branch.Commit.Author.Email)
+}
+
+func (suite *GiteaProviderSuite) TestAddCollaboratorDoesNotFail() {
+	err := suite.provider.AddCollaborator("cannot-be-added", giteaOrgName, giteaRepoName)
+	suite.Require().NoError(err, "users which cannot be added as collaborators should be added manually")
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestGiteaProviderSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestGiteaProviderSuite in short mode")
+	} else {
+		suite.Run(t, new(GiteaProviderSuite))
+	}
+}
@@ -33,6 +33,9 @@ type GitHubProvider struct {
 	Server auth.AuthServer
 	User   auth.UserAuth
 	Git    Gitter
+
+	// appTokenSource the installation tokens if authenticating as a GitHub App
+	appTokenSource oauth2.TokenSource
 }
 
 func NewGitHubProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
@@ -49,7 +52,20 @@ func NewGitHubProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter)
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: user.ApiToken},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	if user.IsGitHubApp() {
+		appTokenSource, err := NewGitHubAppTokenSourceForServer(server.URL, user)
+		if err != nil {
+			return nil, err
+		}
+		token, err := appTokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		provider.User.ApiToken = token.AccessToken
+		provider.appTokenSource = appTokenSource
+		ts = appTokenSource
+	}
+	tc := WithRateLimits(oauth2.NewClient(ctx, ts))
 
 	traceGitHubAPI := os.Getenv("TRACE_GITHUB_API")
 	if traceGitHubAPI == "1" || traceGitHubAPI == "on" {
@@ -70,7 +86,7 @@ func NewAnonymousGitHubProvider(server *auth.AuthServer, git Gitter) (GitProvide
 		Git:     git,
 	}
 
-	return newGitHubProviderFromOauthClient(nil, provider)
+	return newGitHubProviderFromOauthClient(WithRateLimits(nil), provider)
 }
 
 func newGitHubProviderFromOauthClient(tc *http.Client, provider GitHubProvider) (GitProvider, error) {
@@ -901,6 +917,47 @@ func (p *GitHubProvider) MergePullRequest(pr *GitPullRequest, message string) er
 	return nil
 }
 
+// ListPullRequestApprovers returns the users whose latest review of the pull request approved it
+func (p *GitHubProvider) ListPullRequestApprovers(pr *GitPullRequest) ([]string, error) {
+	if pr.Number == nil {
+		return nil, fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	states := map[string]string{}
+	users := []string{}
+	options := &github.ListOptions{
+		PerPage: pageSize,
+	}
+	for {
+		reviews, resp, err := p.Client.PullRequests.ListReviews(p.Context, pr.Owner, pr.Repo, *pr.Number, options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the reviews of pull request %s", pr.URL)
+		}
+		for _, review := range reviews {
+			user := review.GetUser().GetLogin()
+			state := review.GetState()
+			// comments do not change the approval of a reviewer
+			if user == "" || state == "COMMENTED" {
+				continue
+			}
+			if _, ok := states[user]; !ok {
+				users = append(users, user)
+			}
+			states[user] = state
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	answer := []string{}
+	for _, user := range users {
+		if states[user] == "APPROVED" {
+			answer = append(answer, user)
+		}
+	}
+	return answer, nil
+}
+
 func (p *GitHubProvider) AddPRComment(pr *GitPullRequest, comment string) error {
 	if pr.Number == nil {
 		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
@@ -917,6 +974,46 @@ func (p *GitHubProvider) AddPRComment(pr *GitPullRequest, comment string) error
 	return nil
 }
 
+// UpdatePRComment replaces the latest comment of the pull request containing the marker with the comment, adding the
+// comment if there is none
+func (p *GitHubProvider) UpdatePRComment(pr *GitPullRequest, marker string, comment string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	n := *pr.Number
+	opt := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: pageSize,
+		},
+	}
+	var existing *github.IssueComment
+	for {
+		comments, resp, err := p.Client.Issues.ListComments(p.Context, pr.Owner, pr.Repo, n, opt)
+		if err != nil {
+			return errors.Wrapf(err, "listing the comments of pull request #%d of %s/%s", n, pr.Owner, pr.Repo)
+		}
+		for _, c := range comments {
+			if strings.Contains(c.GetBody(), marker) && (existing == nil || c.GetID() > existing.GetID()) {
+				existing = c
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	if existing == nil {
+		return p.AddPRComment(pr, comment)
+	}
+	_, _, err := p.Client.Issues.EditComment(p.Context, pr.Owner, pr.Repo, existing.GetID(), &github.IssueComment{
+		Body: &comment,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating comment %d of pull request #%d of %s/%s", existing.GetID(), n, pr.Owner, pr.Repo)
+	}
+	return nil
+}
+
 func (p *GitHubProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
 	issueComment := &github.IssueComment{
 		Body: &comment,
@@ -1370,8 +1467,19 @@ func (p *GitHubProvider) CurrentUsername() string {
 	return p.Username
 }
 
+// UserAuth returns the user auth including the current installation token if authenticating as a GitHub App. The
+// provider is not modified so that it is safe to call concurrently
 func (p *GitHubProvider) UserAuth() auth.UserAuth {
-	return p.User
+	user := p.User
+	if p.appTokenSource != nil {
+		token, err := p.appTokenSource.Token()
+		if err != nil {
+			log.Logger().Warnf("failed to refresh the GitHub App installation token: %s", err.Error())
+		} else {
+			user.ApiToken = token.AccessToken
+		}
+	}
+	return user
 }
 
 func (p *GitHubProvider) UserInfo(username string) *GitUser {
@@ -0,0 +1,206 @@
+package gits
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// gitHubAppJWTDuration how long the JWTs authenticating as the GitHub App are valid for. GitHub allows at most 10
+	// minutes
+	gitHubAppJWTDuration = 9 * time.Minute
+
+	// gitHubAppTokenExpiryDelta how long before they expire the installation tokens are refreshed
+	gitHubAppTokenExpiryDelta = 5 * time.Minute
+
+	// GitHubAppTokenUsername the user name used with the installation tokens of a GitHub App by git over HTTPS
+	GitHubAppTokenUsername = "x-access-token"
+)
+
+// GitHubAppTokenSource an oauth2.TokenSource returning the installation tokens of a GitHub App, creating a new one
+// shortly before the current one expires
+type GitHubAppTokenSource struct {
+	APIURL         string
+	AppID          int64
+	InstallationID int64
+	Client         *http.Client
+
+	key   *rsa.PrivateKey
+	lock  sync.Mutex
+	token *oauth2.Token
+	now   func() time.Time
+}
+
+// NewGitHubAppTokenSource creates a token source for the installation of the GitHub App using its PEM encoded private
+// key and the GitHub API URL, e.g. https://api.github.com/
+func NewGitHubAppTokenSource(apiURL string, appID int64, installationID int64, privateKey []byte) (*GitHubAppTokenSource, error) {
+	key, err := parseGitHubAppPrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the private key of GitHub App %d", appID)
+	}
+	return &GitHubAppTokenSource{
+		APIURL:         apiURL,
+		AppID:          appID,
+		InstallationID: installationID,
+		Client:         util.GetClient(),
+		key:            key,
+	}, nil
+}
+
+// NewGitHubAppTokenSourceForServer creates a token source for the GitHub App user of the GitHub or GitHub Enterprise
+// server
+func NewGitHubAppTokenSourceForServer(serverURL string, user *auth.UserAuth) (*GitHubAppTokenSource, error) {
+	apiURL := "https://api.github.com/"
+	if !IsGitHubServerURL(serverURL) {
+		apiURL = GitHubEnterpriseApiEndpointURL(serverURL)
+	}
+	return NewGitHubAppTokenSource(apiURL, user.GitHubAppID, user.GitHubAppInstallationID, []byte(user.GitHubAppPrivateKey))
+}
+
+// CreateGitHubAppInstallationToken creates an installation token for the GitHub App user of the server so that it
+// can be used as the password of git over HTTPS
+func CreateGitHubAppInstallationToken(serverURL string, user *auth.UserAuth) (string, error) {
+	ts, err := NewGitHubAppTokenSourceForServer(serverURL, user)
+	if err != nil {
+		return "", err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return "", errors.Wrapf(err, "creating an installation token for GitHub App %d", user.GitHubAppID)
+	}
+	return token.AccessToken, nil
+}
+
+func parseGitHubAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token returns the current installation token or a new one if it is about to expire
+func (s *GitHubAppTokenSource) Token() (*oauth2.Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.token != nil && s.currentTime().Add(gitHubAppTokenExpiryDelta).Before(s.token.Expiry) {
+		return s.token, nil
+	}
+	token, err := s.createInstallationToken()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+func (s *GitHubAppTokenSource) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// JWT returns a JSON Web Token authenticating as the GitHub App
+func (s *GitHubAppTokenSource) JWT() (string, error) {
+	now := s.currentTime()
+	header := map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	}
+	claims := map[string]interface{}{
+		// allow for the clock drift of the GitHub servers
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(gitHubAppJWTDuration).Unix(),
+		"iss": strconv.FormatInt(s.AppID, 10),
+	}
+	encode := func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(data), nil
+	}
+	h, err := encode(header)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding the JWT header")
+	}
+	c, err := encode(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding the JWT claims")
+	}
+	unsigned := h + "." + c
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrapf(err, "signing the JWT of GitHub App %d", s.AppID)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (s *GitHubAppTokenSource) createInstallationToken() (*oauth2.Token, error) {
+	jwt, err := s.JWT()
+	if err != nil {
+		return nil, err
+	}
+	u := util.UrlJoin(s.APIURL, "app/installations", strconv.FormatInt(s.InstallationID, 10), "access_tokens")
+	req, err := http.NewRequest(http.MethodPost, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating request %s", u)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating an installation token for installation %d of GitHub App %d", s.InstallationID, s.AppID)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the installation token of installation %d of GitHub App %d", s.InstallationID, s.AppID)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.Errorf("creating an installation token for installation %d of GitHub App %d returned status %d: %s", s.InstallationID, s.AppID, resp.StatusCode, string(data))
+	}
+	result := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling the installation token of installation %d of GitHub App %d", s.InstallationID, s.AppID)
+	}
+	return &oauth2.Token{
+		AccessToken: result.Token,
+		TokenType:   "token",
+		Expiry:      result.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,105 @@
+// +build unit
+
+package gits_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubAppTokenSource(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokens := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/app/installations/5678/access_tokens", r.URL.Path)
+
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		require.Len(t, parts, 3)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature), "the JWT should be signed by the private key")
+		data, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		claims := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &claims))
+		assert.Equal(t, "1234", claims["iss"])
+
+		tokens++
+		w.WriteHeader(http.StatusCreated)
+		// the first token is about to expire so that the next one is created
+		expiry := time.Now().Add(time.Minute)
+		if tokens > 1 {
+			expiry = time.Now().Add(time.Hour)
+		}
+		fmt.Fprintf(w, `{"token": "v1.token%d", "expires_at": "%s"}`, tokens, expiry.UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	_, err = gits.NewGitHubAppTokenSource(server.URL, 1234, 5678, []byte("not a key"))
+	assert.Error(t, err)
+
+	ts, err := gits.NewGitHubAppTokenSource(server.URL, 1234, 5678, privateKey)
+	require.NoError(t, err)
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.token1", token.AccessToken)
+
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.token2", token.AccessToken, "should refresh a token about to expire")
+
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.token2", token.AccessToken, "should reuse the current token")
+	assert.Equal(t, 2, tokens)
+}
+
+func TestCreateGitHubAppInstallationToken(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/app/installations/5678/access_tokens", r.URL.Path, "should use the GitHub Enterprise API")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "v1.installation", "expires_at": "%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	user := &auth.UserAuth{
+		GitHubAppID:             1234,
+		GitHubAppInstallationID: 5678,
+		GitHubAppPrivateKey:     string(privateKey),
+	}
+	token, err := gits.CreateGitHubAppInstallationToken(server.URL, user)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.installation", token)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -30,7 +32,7 @@ type GitlabProvider struct {
 
 func NewGitlabProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	u := server.URL
-	c := gitlab.NewClient(nil, user.ApiToken)
+	c := gitlab.NewClient(WithRateLimits(nil), user.ApiToken)
 	if !IsGitLabServerURL(u) {
 		if err := c.SetBaseURL(u); err != nil {
 			return nil, err
@@ -75,9 +77,24 @@ func (g *GitlabProvider) ListReleases(org string, name string) ([]*GitRelease, e
 	return answer, nil
 }
 
-// GetRelease returns the release info for the org, repo name and tag
+// GetRelease returns the release info for the org, repo name and tag or nil if there is no release for the tag
 func (g *GitlabProvider) GetRelease(org string, name string, tag string) (*GitRelease, error) {
-	return nil, nil
+	pid, err := g.projectId(org, g.Username, name)
+	if err != nil {
+		return nil, err
+	}
+	release, response, err := g.Client.Releases.GetRelease(pid, tag)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors2.Wrapf(err, "getting release %s of %s/%s", tag, org, name)
+	}
+	return &GitRelease{
+		Name:    release.Name,
+		TagName: release.TagName,
+		Body:    release.Description,
+	}, nil
 }
 
 func getRepositories(g *gitlab.Client, username string, org string, searchFilter string) ([]*gitlab.Project, *gitlab.Response, error) {
@@ -335,6 +352,16 @@ func fromMergeRequest(mr *gitlab.MergeRequest, owner, repo string) *GitPullReque
 	for _, a := range mr.Assignees {
 		assignees = append(assignees, convertUser(a))
 	}
+	var mergeable *bool
+	switch mr.MergeStatus {
+	case "can_be_merged":
+		flag := true
+		mergeable = &flag
+	case "cannot_be_merged":
+		flag := false
+		mergeable = &flag
+	}
+	draft := mr.WorkInProgress
 	return &GitPullRequest{
 		Author:         convertUser(mr.Author),
 		Assignees:      assignees,
@@ -351,6 +378,10 @@ func fromMergeRequest(mr *gitlab.MergeRequest, owner, repo string) *GitPullReque
 		LastCommitSha:  mr.SHA,
 		MergedAt:       mr.MergedAt,
 		ClosedAt:       mr.ClosedAt,
+		UpdatedAt:      mr.UpdatedAt,
+		HeadRef:        &mr.SourceBranch,
+		Mergeable:      mergeable,
+		Draft:          &draft,
 	}
 }
 
@@ -446,48 +477,66 @@ func (g *GitlabProvider) GetPullRequestCommits(owner string, repository *GitRepo
 	return answer, nil
 }
 
+// PullRequestLastCommitStatus returns the combined state of the latest status of each context of the last commit
+// of the merge request
 func (g *GitlabProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string, error) {
-	owner := pr.Owner
-	repo := pr.Repo
-
 	ref := pr.LastCommitSha
 	if ref == "" {
 		return "", fmt.Errorf("missing String for LastCommitSha %#v", pr)
 	}
 
-	pid, err := g.projectId(owner, g.Username, repo)
+	statuses, err := g.ListCommitStatus(pr.Owner, pr.Repo, ref)
 	if err != nil {
 		return "", err
 	}
-	c, _, err := g.Client.Commits.GetCommitStatuses(pid, ref, nil)
-	if err != nil {
-		return "", err
+	if len(statuses) == 0 {
+		return "", fmt.Errorf("could not find a status for repository %s/%s with ref %s", pr.Owner, pr.Repo, ref)
 	}
+	return combinedCommitStatus(statuses), nil
+}
 
-	for _, result := range c {
-		if result.Status != "" {
-			return result.Status, nil
-		}
-	}
-	return "", fmt.Errorf("could not find a status for repository %s with ref %s", pid, ref)
+// gitlabCommitStatus a GitLab commit status including whether the failure of its job is allowed
+type gitlabCommitStatus struct {
+	gitlab.CommitStatus
+	AllowFailure bool `json:"allow_failure"`
 }
 
+// ListCommitStatus lists the latest status of each context of the commit. The failed statuses of the jobs which are
+// allowed to fail are successful as they do not fail the pipeline
 func (g *GitlabProvider) ListCommitStatus(org string, repo string, sha string) ([]*GitRepoStatus, error) {
 	pid, err := g.projectId(org, g.Username, repo)
 	if err != nil {
 		return nil, err
 	}
-	c, _, err := g.Client.Commits.GetCommitStatuses(pid, sha, nil)
-	if err != nil {
-		return nil, err
+	opt := &gitlab.GetCommitStatusesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: pageSize,
+		},
 	}
-
+	u := fmt.Sprintf("projects/%s/repository/commits/%s/statuses", url.PathEscape(pid), sha)
 	var statuses []*GitRepoStatus
-
-	for _, result := range c {
-		statuses = append(statuses, fromCommitStatus(result))
+	for {
+		req, err := g.Client.NewRequest("GET", u, opt, nil)
+		if err != nil {
+			return nil, err
+		}
+		var c []*gitlabCommitStatus
+		resp, err := g.Client.Do(req, &c)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range c {
+			status := fromCommitStatus(&result.CommitStatus)
+			if result.AllowFailure && status.IsFailed() {
+				status.State = "success"
+			}
+			statuses = append(statuses, status)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
-
 	return statuses, nil
 }
 
@@ -498,7 +547,7 @@ func (g *GitlabProvider) UpdateCommitStatus(owner string, repo string, sha strin
 		return nil, err
 	}
 	glState := gitlab.BuildStateValue(status.State)
-	if status.State == "failure" {
+	if status.State == "failure" || status.State == "error" {
 		glState = gitlab.Failed
 	}
 	statusOptions := &gitlab.SetCommitStatusOptions{
@@ -512,20 +561,21 @@ func (g *GitlabProvider) UpdateCommitStatus(owner string, repo string, sha strin
 	if err != nil {
 		return nil, err
 	}
-	return &GitRepoStatus{
-		ID:          strconv.Itoa(c.ID),
-		Description: c.Description,
-		State:       c.Status,
-		Context:     c.Name,
-		URL:         c.TargetURL,
-		TargetURL:   c.TargetURL,
-	}, err
+	return fromCommitStatus(c), nil
 }
 
+// fromCommitStatus converts the GitLab status using the states of GitHub
 func fromCommitStatus(status *gitlab.CommitStatus) *GitRepoStatus {
 	jxState := status.Status
-	if status.Status == "failed" {
+	switch status.Status {
+	case "failed":
 		jxState = "failure"
+	case "canceled":
+		jxState = "error"
+	case "created", "running", "manual":
+		jxState = "pending"
+	case "skipped":
+		jxState = "success"
 	}
 	return &GitRepoStatus{
 		ID:          strconv.Itoa(status.ID),
@@ -537,16 +587,86 @@ func fromCommitStatus(status *gitlab.CommitStatus) *GitRepoStatus {
 	}
 }
 
+// combinedCommitStatus returns failure or error if any status failed, pending if any are still running and
+// success otherwise
+func combinedCommitStatus(statuses []*GitRepoStatus) string {
+	answer := "success"
+	for _, status := range statuses {
+		switch status.State {
+		case "failure", "error":
+			return status.State
+		case "pending":
+			answer = "pending"
+		}
+	}
+	return answer
+}
+
+// ListPullRequestApprovers returns the users who approved the merge request
+func (g *GitlabProvider) ListPullRequestApprovers(pr *GitPullRequest) ([]string, error) {
+	if pr.Number == nil {
+		return nil, fmt.Errorf("missing Number for GitPullRequest %#v", pr)
+	}
+	pid, err := g.projectId(pr.Owner, g.Username, pr.Repo)
+	if err != nil {
+		return nil, err
+	}
+	approvals, _, err := g.Client.MergeRequests.GetMergeRequestApprovals(pid, *pr.Number)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting the approvals of merge request %s", pr.URL)
+	}
+	answer := []string{}
+	for _, approver := range approvals.ApprovedBy {
+		if approver != nil && approver.User != nil {
+			answer = append(answer, approver.User.Username)
+		}
+	}
+	return answer, nil
+}
+
+// MergePullRequest merges the merge request once it is approved using the squash setting of the merge request
 func (g *GitlabProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("missing Number for GitPullRequest %#v", pr)
+	}
 	pid, err := g.projectId(pr.Owner, g.Username, pr.Repo)
 	if err != nil {
 		return err
 	}
+	n := *pr.Number
+	mr, _, err := g.Client.MergeRequests.GetMergeRequest(pid, n, nil, nil)
+	if err != nil {
+		return errors2.Wrapf(err, "getting merge request %s", pr.URL)
+	}
+	if mr.WorkInProgress {
+		return fmt.Errorf("cannot merge merge request %s as it is a draft", pr.URL)
+	}
+	// older GitLab servers without merge request approvals fail to return them so only block on known approvals
+	approvals, _, err := g.Client.MergeRequests.GetMergeRequestApprovals(pid, n)
+	if err != nil {
+		log.Logger().Debugf("failed to get the approvals of merge request %s: %s", pr.URL, err.Error())
+	} else if approvals.ApprovalsLeft > 0 {
+		return fmt.Errorf("cannot merge merge request %s as it requires %d more approvals", pr.URL, approvals.ApprovalsLeft)
+	}
 
-	opt := &gitlab.AcceptMergeRequestOptions{MergeCommitMessage: &message}
-
-	_, _, err = g.Client.MergeRequests.AcceptMergeRequest(pid, *pr.Number, opt)
-	return err
+	opt := &gitlab.AcceptMergeRequestOptions{
+		MergeCommitMessage: &message,
+		Squash:             &mr.Squash,
+	}
+	if pr.LastCommitSha != "" {
+		opt.SHA = &pr.LastCommitSha
+	}
+	if mr.Squash {
+		opt.SquashCommitMessage = &message
+	}
+	result, _, err := g.Client.MergeRequests.AcceptMergeRequest(pid, n, opt)
+	if err != nil {
+		return err
+	}
+	if result.State != "merged" {
+		return fmt.Errorf("failed to merge merge request %s as its state is %s", pr.URL, result.State)
+	}
+	return nil
 }
 
 func (g *GitlabProvider) CreateWebHook(data *GitWebHookArguments) error {
@@ -722,12 +842,48 @@ func (g *GitlabProvider) AddPRComment(pr *GitPullRequest, comment string) error
 
 	pid, err := g.projectId(owner, g.Username, repo)
 	if err != nil {
-		return nil
+		return err
 	}
 	_, _, err = g.Client.Notes.CreateMergeRequestNote(pid, *pr.Number, opt)
 	return err
 }
 
+// UpdatePRComment replaces the latest note of the merge request containing the marker with the comment, adding the
+// comment if there is none
+func (g *GitlabProvider) UpdatePRComment(pr *GitPullRequest, marker string, comment string) error {
+	pid, err := g.projectId(pr.Owner, g.Username, pr.Repo)
+	if err != nil {
+		return err
+	}
+	opt := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: pageSize},
+	}
+	var existing *gitlab.Note
+	for {
+		notes, resp, err := g.Client.Notes.ListMergeRequestNotes(pid, *pr.Number, opt)
+		if err != nil {
+			return errors2.Wrapf(err, "listing the notes of merge request !%d of %s/%s", *pr.Number, pr.Owner, pr.Repo)
+		}
+		for _, n := range notes {
+			if !n.System && strings.Contains(n.Body, marker) && (existing == nil || n.ID > existing.ID) {
+				existing = n
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	if existing == nil {
+		return g.AddPRComment(pr, comment)
+	}
+	_, _, err = g.Client.Notes.UpdateMergeRequestNote(pid, *pr.Number, existing.ID, &gitlab.UpdateMergeRequestNoteOptions{Body: &comment})
+	if err != nil {
+		return errors2.Wrapf(err, "updating note %d of merge request !%d of %s/%s", existing.ID, *pr.Number, pr.Owner, pr.Repo)
+	}
+	return nil
+}
+
 func (g *GitlabProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
 	opt := &gitlab.CreateIssueNoteOptions{Body: &comment}
 
@@ -946,9 +1102,67 @@ func (g *GitlabProvider) UploadReleaseAsset(org string, repo string, id int64, n
 	return nil, nil
 }
 
+// UploadReleaseAssetForTag uploads the asset to the project and links it to the release of the tag, as GitLab releases
+// have no ID
+func (g *GitlabProvider) UploadReleaseAssetForTag(org string, repo string, tag string, name string, asset *os.File) (*GitReleaseAsset, error) {
+	defer asset.Close()
+	pid, err := g.projectId(org, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	project, _, err := g.Client.Projects.GetProject(pid, nil, nil)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting project %s/%s", org, repo)
+	}
+	file, _, err := g.Client.Projects.UploadFile(pid, asset.Name())
+	if err != nil {
+		return nil, errors2.Wrapf(err, "uploading %s to project %s/%s", asset.Name(), org, repo)
+	}
+	url := strings.TrimSuffix(project.WebURL, "/") + file.URL
+	link, _, err := g.Client.ReleaseLinks.CreateReleaseLink(pid, tag, &gitlab.CreateReleaseLinkOptions{
+		Name: gitlab.String(name),
+		URL:  gitlab.String(url),
+	})
+	if err != nil {
+		return nil, errors2.Wrapf(err, "linking %s to release %s of %s/%s", url, tag, org, repo)
+	}
+	return &GitReleaseAsset{
+		ID:                 int64(link.ID),
+		BrowserDownloadURL: link.URL,
+		Name:               link.Name,
+	}, nil
+}
+
 // GetBranch returns the branch information for an owner/repo, including the commit at the tip
 func (g *GitlabProvider) GetBranch(owner string, repo string, branch string) (*GitBranch, error) {
-	return nil, nil
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	b, _, err := g.Client.Branches.GetBranch(pid, branch)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting branch %s on %s/%s", branch, owner, repo)
+	}
+	answer := &GitBranch{
+		Name:      b.Name,
+		Protected: b.Protected,
+	}
+	if b.Commit != nil {
+		answer.Commit = &GitCommit{
+			SHA:     b.Commit.ID,
+			Message: b.Commit.Message,
+			Branch:  b.Name,
+			Author: &GitUser{
+				Name:  b.Commit.AuthorName,
+				Email: b.Commit.AuthorEmail,
+			},
+			Committer: &GitUser{
+				Name:  b.Commit.CommitterName,
+				Email: b.Commit.CommitterEmail,
+			},
+		}
+	}
+	return answer, nil
 }
 
 // GetProjects returns all the git projects in owner/repo
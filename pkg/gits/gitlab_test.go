@@ -24,6 +24,7 @@ const (
 	gitlabProjectName    = "test-project"
 	gitlabProjectID      = "5690870"
 	gitlabMergeRequestID = 12
+	gitlabCommitSHA      = "8888888888888888888888888888888888888888"
 )
 
 type GitlabProviderSuite struct {
@@ -114,6 +115,18 @@ func configureGitlabMock(suite *GitlabProviderSuite, mux *http.ServeMux) {
 		fmt.Sprintf("/api/v4/users"): util.MethodMap{
 			"GET": "list-users.json",
 		},
+		fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/approvals", gitlabProjectID, gitlabMergeRequestID): util.MethodMap{
+			"GET": "merge-request-approvals.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/merge", gitlabProjectID, gitlabMergeRequestID): util.MethodMap{
+			"PUT": "create-merge-request.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/commits/%s/statuses", gitlabProjectID, gitlabCommitSHA): util.MethodMap{
+			"GET": "commit-statuses.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/branches/master", gitlabProjectID): util.MethodMap{
+			"GET": "branch.json",
+		},
 	}
 	for path, methodMap := range gitlabRouter {
 		mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitlab", methodMap))
@@ -198,6 +211,50 @@ func (suite *GitlabProviderSuite) TestGetPullRequest() {
 
 	suite.Require().Nil(err)
 	suite.Require().Equal(*pr.Number, gitlabMergeRequestID)
+	suite.Require().NotNil(pr.Mergeable)
+	suite.Require().True(*pr.Mergeable)
+	suite.Require().NotNil(pr.Draft)
+	suite.Require().False(*pr.Draft)
+	suite.Require().Equal("test1", *pr.HeadRef)
+	suite.Require().Equal(gitlabCommitSHA, pr.LastCommitSha)
+}
+
+func (suite *GitlabProviderSuite) TestListCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus(gitlabUserName, gitlabProjectName, gitlabCommitSHA)
+
+	suite.Require().Nil(err)
+	suite.Require().Len(statuses, 4)
+	states := map[string]string{}
+	for _, status := range statuses {
+		states[status.Context] = status.State
+	}
+	suite.Require().Equal(map[string]string{"pr-build": "pending", "lint": "success", "integration": "failure", "optional-scan": "success"}, states, "the jobs which are allowed to fail should not fail")
+}
+
+func (suite *GitlabProviderSuite) TestPullRequestLastCommitStatus() {
+	pr := &gits.GitPullRequest{Owner: gitlabUserName, Repo: gitlabProjectName, LastCommitSha: gitlabCommitSHA}
+	status, err := suite.provider.PullRequestLastCommitStatus(pr)
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("failure", status)
+}
+
+func (suite *GitlabProviderSuite) TestGetBranch() {
+	branch, err := suite.provider.GetBranch(gitlabUserName, gitlabProjectName, "master")
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("master", branch.Name)
+	suite.Require().True(branch.Protected)
+	suite.Require().Equal("7b5c3cc8be40ee161ae89a06bba6229da1032a0c", branch.Commit.SHA)
+	suite.Require().Equal("john@example.com", branch.Commit.Author.Email)
+}
+
+func (suite *GitlabProviderSuite) TestMergePullRequest() {
+	number := gitlabMergeRequestID
+	pr := &gits.GitPullRequest{Owner: gitlabUserName, Repo: gitlabProjectName, Number: &number, LastCommitSha: gitlabCommitSHA}
+	err := suite.provider.MergePullRequest(pr, "merged by test")
+
+	suite.Require().Nil(err)
 }
 
 func (suite *GitlabProviderSuite) TestCreatePullRequest() {
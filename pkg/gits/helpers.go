@@ -722,7 +722,7 @@ func configureJxAsGitCredentialHelper(dir string, gitter Gitter, repoOwner strin
 	if err != nil {
 		return errors.Wrapf(err, "unable to determine jx binary location")
 	}
-	config := []string{"--local", "credential.helper", fmt.Sprintf("%s step git credentials --credential-helper --repo-owner %s", jxProcessBinary, repoOwner)}
+	config := []string{"--local", "credential.helper", fmt.Sprintf("%s step git credentials --credential-helper --repo-owner %s", util.ShellQuotePath(jxProcessBinary), repoOwner)}
 	log.Logger().Debugf("setting git config to: %s", strings.Join(config, " "))
 	return gitter.Config(dir, config...)
 }
@@ -23,6 +23,26 @@ type OrganisationChecker interface {
 	IsUserInOrganisation(user string, organisation string) (bool, error)
 }
 
+// PullRequestApprover returns the users who approved a pull request. It is only implemented by the git
+// providers whose API reports the approvals of pull requests
+type PullRequestApprover interface {
+	ListPullRequestApprovers(pr *GitPullRequest) ([]string, error)
+}
+
+// ReleaseAssetTagUploader uploads an asset to the release of a tag. It is only implemented by the git providers whose
+// releases are identified by their tag rather than an ID
+type ReleaseAssetTagUploader interface {
+	UploadReleaseAssetForTag(org string, repo string, tag string, name string, asset *os.File) (*GitReleaseAsset, error)
+}
+
+// PullRequestCommentUpdater updates a previous comment on a pull request rather than adding a new one each time. It is
+// only implemented by the git providers whose API can edit the comments of pull requests
+type PullRequestCommentUpdater interface {
+	// UpdatePRComment replaces the latest comment of the pull request containing the marker with the comment, adding
+	// the comment if there is none
+	UpdatePRComment(pr *GitPullRequest, marker string, comment string) error
+}
+
 // GitProvider is the interface for abstracting use of different git provider APIs
 //go:generate pegomock generate github.com/jenkins-x/jx/v2/pkg/gits GitProvider -o mocks/git_provider.go
 type GitProvider interface {
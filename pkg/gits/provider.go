@@ -65,6 +65,7 @@ type GitPullRequest struct {
 	Labels             []*Label
 	UpdatedAt          *time.Time
 	HeadOwner          *string // HeadOwner is the string the PR is created from
+	Draft              *bool   // Draft is true if the PR is a draft or work in progress so cannot be merged yet. nil if the provider does not report drafts
 }
 
 // Label represents a label on an Issue
@@ -273,6 +274,8 @@ func CreateProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (G
 		return NewGiteaProvider(server, user, git)
 	} else if server.Kind == KindGitlab {
 		return NewGitlabProvider(server, user, git)
+	} else if server.Kind == KindAzureDevOps {
+		return NewAzureDevOpsProvider(server, user, git)
 	} else if server.Kind == KindGitFake {
 		return NewFakeProvider(), nil
 	} else {
@@ -307,6 +310,8 @@ func ProviderAccessTokenURL(kind string, url string, username string) string {
 		return GiteaAccessTokenURL(url)
 	case KindGitlab:
 		return GitlabAccessTokenURL(url)
+	case KindAzureDevOps:
+		return AzureDevOpsAccessTokenURL(url)
 	default:
 		return GitHubAccessTokenURL(url)
 	}
@@ -54,6 +54,7 @@ type FakePullRequest struct {
 	PullRequest *GitPullRequest
 	Commits     []*FakeCommit
 	Comment     string
+	Approvers   []string
 }
 
 type FakeIssue struct {
@@ -467,6 +468,20 @@ func (f *FakeProvider) UpdateCommitStatus(org string, repo string, sha string, s
 
 }
 
+// ListPullRequestApprovers returns the approvers of the fake pull request
+func (f *FakeProvider) ListPullRequestApprovers(pr *GitPullRequest) ([]string, error) {
+	for _, r := range f.Repositories[pr.Owner] {
+		if r.GitRepo.Name == pr.Repo && pr.Number != nil {
+			fakePR, ok := r.PullRequests[*pr.Number]
+			if !ok {
+				return nil, fmt.Errorf("pull request with id '%d' not found", *pr.Number)
+			}
+			return fakePR.Approvers, nil
+		}
+	}
+	return nil, fmt.Errorf("repository '%s/%s' not found", pr.Owner, pr.Repo)
+}
+
 func (f *FakeProvider) MergePullRequest(pr *GitPullRequest, message string) error {
 	owner := pr.Owner
 	repos, ok := f.Repositories[owner]
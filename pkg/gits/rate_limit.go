@@ -0,0 +1,274 @@
+package gits
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultRateLimitMinRemaining the number of remaining requests below which the requests wait for the rate limit
+	// to reset
+	DefaultRateLimitMinRemaining = 10
+
+	// DefaultRateLimitMaxWait the maximum duration a request waits for the rate limit to reset
+	DefaultRateLimitMaxWait = 5 * time.Minute
+)
+
+// RateLimit the rate limit of the API of a git server as reported by its last response
+type RateLimit struct {
+	Host      string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Updated   time.Time
+}
+
+var (
+	rateLimits     = map[string]*RateLimit{}
+	rateLimitsLock sync.Mutex
+)
+
+// RateLimitKey returns the key of the rate limit of the request which is the host and a digest of the credential of
+// the request as the git servers limit each user separately
+func RateLimitKey(req *http.Request) string {
+	credential := req.Header.Get("Authorization")
+	if credential == "" {
+		credential = req.Header.Get("Private-Token")
+	}
+	if credential == "" {
+		return req.URL.Host
+	}
+	sum := sha256.Sum256([]byte(credential))
+	return req.URL.Host + "/" + hex.EncodeToString(sum[:8])
+}
+
+// GetRateLimit returns a copy of the last reported rate limit of the key or nil if none was reported
+func GetRateLimit(key string) *RateLimit {
+	rateLimitsLock.Lock()
+	defer rateLimitsLock.Unlock()
+	limit := rateLimits[key]
+	if limit == nil {
+		return nil
+	}
+	answer := *limit
+	return &answer
+}
+
+// GetRateLimits returns the last reported rate limits of the hosts and credentials sorted by host
+func GetRateLimits() []RateLimit {
+	rateLimitsLock.Lock()
+	defer rateLimitsLock.Unlock()
+	answer := []RateLimit{}
+	for _, limit := range rateLimits {
+		answer = append(answer, *limit)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Host < answer[j].Host
+	})
+	return answer
+}
+
+// RateLimitTransport a http.RoundTripper recording the rate limit headers of the responses of a git server which
+// waits for the rate limit to reset when the remaining requests drop below MinRemaining and retries the requests
+// rejected for exceeding the rate limit
+type RateLimitTransport struct {
+	Base         http.RoundTripper
+	MinRemaining int
+	MaxWait      time.Duration
+
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// NewRateLimitTransport creates a transport with the default budget wrapping the base transport or the default
+// transport if it is nil
+func NewRateLimitTransport(base http.RoundTripper) *RateLimitTransport {
+	return &RateLimitTransport{
+		Base:         base,
+		MinRemaining: DefaultRateLimitMinRemaining,
+		MaxWait:      DefaultRateLimitMaxWait,
+	}
+}
+
+// WithRateLimits returns a copy of the client using a RateLimitTransport or of the default client if it is nil. The
+// RateLimitTransport of an OAuth client wraps the base of its transport so that it can see the credential of the requests
+func WithRateLimits(client *http.Client) *http.Client {
+	if client == nil {
+		client = util.GetClient()
+	}
+	answer := *client
+	switch transport := client.Transport.(type) {
+	case *RateLimitTransport:
+		return client
+	case *oauth2.Transport:
+		if _, ok := transport.Base.(*RateLimitTransport); ok {
+			return client
+		}
+		answer.Transport = &oauth2.Transport{
+			Source: transport.Source,
+			Base:   NewRateLimitTransport(transport.Base),
+		}
+	default:
+		answer.Transport = NewRateLimitTransport(client.Transport)
+	}
+	return &answer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := RateLimitKey(req)
+	limit := GetRateLimit(key)
+	if limit != nil && limit.Remaining <= t.MinRemaining {
+		err := t.wait(req.Context(), limit.Host, limit.Reset.Sub(t.currentTime()), "only %d of its %d requests remain", limit.Remaining, limit.Limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.record(key, req.URL.Host, resp)
+
+	// only retry requests whose body can be replayed
+	if !isRateLimited(resp) || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	retry := req
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry = req.Clone(req.Context())
+		retry.Body = body
+	}
+	d := t.retryAfter(key, resp)
+	resp.Body.Close()
+	err = t.wait(req.Context(), req.URL.Host, d, "the rate limit was exceeded")
+	if err != nil {
+		return nil, err
+	}
+	resp, err = t.base().RoundTrip(retry)
+	if err == nil {
+		t.record(key, req.URL.Host, resp)
+	}
+	return resp, err
+}
+
+func (t *RateLimitTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RateLimitTransport) currentTime() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// wait sleeps for the duration capped at MaxWait returning the error of the context if it is done first
+func (t *RateLimitTransport) wait(ctx context.Context, host string, d time.Duration, reason string, args ...interface{}) error {
+	if d <= 0 {
+		return nil
+	}
+	if t.MaxWait > 0 && d > t.MaxWait {
+		d = t.MaxWait
+	}
+	log.Logger().Warnf("Waiting %s for the rate limit of git server %s to reset as "+reason, append([]interface{}{util.ColorInfo(d.String()), host}, args...)...)
+	if t.sleep != nil {
+		t.sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// record records the rate limit headers of the response
+func (t *RateLimitTransport) record(key string, host string, resp *http.Response) {
+	answer := ParseRateLimit(host, resp.Header, t.currentTime())
+	if answer == nil {
+		return
+	}
+	rateLimitsLock.Lock()
+	rateLimits[key] = answer
+	rateLimitsLock.Unlock()
+}
+
+// ParseRateLimit returns the rate limit of the host reported by the headers of a response or nil if they do not report
+// one. GitHub, Gitea and Azure DevOps use the X-RateLimit prefix whereas GitLab uses no prefix
+func ParseRateLimit(host string, header http.Header, now time.Time) *RateLimit {
+	limit, ok := headerInt(header, "X-RateLimit-Limit", "RateLimit-Limit")
+	remaining, ok2 := headerInt(header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if !ok && !ok2 {
+		return nil
+	}
+	answer := &RateLimit{
+		Host:      host,
+		Limit:     limit,
+		Remaining: remaining,
+		Updated:   now,
+	}
+	if reset, ok := headerInt(header, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		answer.Reset = time.Unix(int64(reset), 0)
+	}
+	return answer
+}
+
+// retryAfter returns how long to wait before retrying a rate limited request using the Retry-After header or the
+// reset of the rate limit
+func (t *RateLimitTransport) retryAfter(key string, resp *http.Response) time.Duration {
+	if seconds, ok := headerInt(resp.Header, "Retry-After"); ok {
+		return time.Duration(seconds) * time.Second
+	}
+	if limit := GetRateLimit(key); limit != nil && !limit.Reset.IsZero() {
+		return limit.Reset.Sub(t.currentTime())
+	}
+	return time.Minute
+}
+
+// isRateLimited returns true if the request was rejected for exceeding the rate limit
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	remaining, ok := headerInt(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	return ok && remaining == 0
+}
+
+func headerInt(header http.Header, names ...string) (int, bool) {
+	for _, name := range names {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		i, err := strconv.Atoi(value)
+		if err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
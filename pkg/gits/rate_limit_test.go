@@ -0,0 +1,180 @@
+// +build unit
+
+package gits
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	now := time.Unix(1586520000, 0)
+	reset := now.Add(time.Minute)
+	remaining := 12
+	limited := false
+	requests := 0
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(data))
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if limited {
+			limited = false
+			remaining = 5000
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		remaining -= 2
+	}))
+	defer server.Close()
+
+	slept := []time.Duration{}
+	transport := NewRateLimitTransport(nil)
+	transport.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+	transport.now = func() time.Time {
+		return now
+	}
+	client := &http.Client{Transport: transport}
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, slept, "should not wait while the quota remains")
+	limit := GetRateLimit(u.Host)
+	require.NotNil(t, limit)
+	assert.Equal(t, 5000, limit.Limit)
+	assert.Equal(t, 12, limit.Remaining)
+	assert.Equal(t, reset.Unix(), limit.Reset.Unix())
+	assert.Contains(t, GetRateLimits(), *limit)
+
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 10, GetRateLimit(u.Host).Remaining)
+	assert.Empty(t, slept)
+
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("hello"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []time.Duration{time.Minute}, slept, "should wait for the reset once the quota drops to the minimum")
+
+	slept = nil
+	limited = true
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("again"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "should retry the rate limited request")
+	assert.Equal(t, []time.Duration{time.Minute, time.Minute}, slept)
+	assert.Equal(t, 5000, GetRateLimit(u.Host).Remaining)
+	assert.Equal(t, 5, requests)
+	assert.Equal(t, []string{"", "", "hello", "again", "again"}, bodies, "should replay the body of the retried request")
+}
+
+func TestRateLimitTransportKeysByCredential(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		if r.Header.Get("Authorization") == "token exhausted" {
+			w.Header().Set("X-RateLimit-Remaining", "1")
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRateLimitTransport(nil)}
+	get := func(ctx context.Context, token string) (*http.Request, error) {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		require.NoError(t, err)
+		req = req.WithContext(ctx)
+		req.Header.Set("Authorization", "token "+token)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return req, err
+	}
+
+	exhausted, err := get(context.Background(), "exhausted")
+	require.NoError(t, err)
+	other, err := get(context.Background(), "other")
+	require.NoError(t, err)
+	assert.NotEqual(t, RateLimitKey(exhausted), RateLimitKey(other))
+	assert.Equal(t, 1, GetRateLimit(RateLimitKey(exhausted)).Remaining)
+	assert.Equal(t, 4000, GetRateLimit(RateLimitKey(other)).Remaining, "the quota of another credential on the same host should not be shared")
+
+	_, err = get(context.Background(), "other")
+	require.NoError(t, err, "should not wait for the quota of another credential")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = get(ctx, "exhausted")
+	assert.Error(t, err, "should stop waiting for the rate limit to reset once the request is cancelled")
+}
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	assert.Nil(t, ParseRateLimit("gitlab.com", http.Header{}, now), "should return nil when the headers are missing")
+
+	header := http.Header{}
+	header.Set("RateLimit-Limit", "600")
+	header.Set("RateLimit-Remaining", "599")
+	header.Set("RateLimit-Reset", "1586520000")
+	limit := ParseRateLimit("gitlab.com", header, now)
+	require.NotNil(t, limit)
+	assert.Equal(t, RateLimit{Host: "gitlab.com", Limit: 600, Remaining: 599, Reset: time.Unix(1586520000, 0), Updated: now}, *limit)
+}
+
+type recordingStatusProvider struct {
+	*FakeProvider
+	statuses []*GitRepoStatus
+}
+
+func (p *recordingStatusProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	p.statuses = append(p.statuses, status)
+	return status, nil
+}
+
+func TestCommitStatusBatcher(t *testing.T) {
+	t.Parallel()
+
+	provider := &recordingStatusProvider{FakeProvider: NewFakeProvider()}
+
+	batcher := NewCommitStatusBatcher()
+	batcher.Add(provider, "myorg", "myrepo", "abc", &GitRepoStatus{Context: "build", State: "pending"})
+	batcher.Add(provider, "myorg", "myrepo", "abc", &GitRepoStatus{Context: "lint", State: "pending"})
+	batcher.Add(provider, "myorg", "myrepo", "abc", &GitRepoStatus{Context: "build", State: "success"})
+	assert.Equal(t, 2, batcher.Len())
+
+	require.NoError(t, batcher.Flush())
+	assert.Equal(t, 0, batcher.Len())
+	statuses := provider.statuses
+	require.Len(t, statuses, 2, "should only report the latest status of each context")
+	assert.Equal(t, "build", statuses[0].Context)
+	assert.Equal(t, "success", statuses[0].State)
+	assert.Equal(t, "lint", statuses[1].Context)
+}
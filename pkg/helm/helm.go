@@ -27,3 +27,14 @@ type ReleaseSummary struct {
 	AppVersion    string
 	Namespace     string
 }
+
+// ReleaseRevision is the information about a revision in the history of a release in Helm
+type ReleaseRevision struct {
+	Revision     int    `json:"revision"`
+	Updated      string `json:"updated"`
+	Status       string `json:"status"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"-"`
+	AppVersion   string `json:"app_version,omitempty"`
+	Description  string `json:"description"`
+}
@@ -1,6 +1,7 @@
 package helm
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -26,7 +27,9 @@ type HelmCLI struct {
 	CWD        string
 	Runner     util.Commander
 	Debug      bool
-	kuber      kube.Kuber
+	// Atomic rolls back a failed upgrade to the previous revision of the release
+	Atomic bool
+	kuber  kube.Kuber
 }
 
 // NewHelmCLIWithRunner creates a new HelmCLI interface for the given runner
@@ -443,6 +446,9 @@ func (h *HelmCLI) UpgradeChart(chart string, releaseName string, ns string, vers
 	if wait {
 		args = append(args, "--wait")
 	}
+	if h.Atomic {
+		args = append(args, "--atomic")
+	}
 	if force {
 		args = append(args, "--force")
 	}
@@ -609,6 +615,69 @@ func (h *HelmCLI) StatusReleaseWithOutput(ns string, releaseName string, outputF
 	return h.runHelmWithOutput("status", releaseName, "--output", outputFormat)
 }
 
+// History returns the most recent revisions of the release in ns with the oldest revision first
+func (h *HelmCLI) History(ns string, releaseName string, max int) ([]ReleaseRevision, error) {
+	args := []string{"history", releaseName, "--output", "json"}
+	if max > 0 {
+		args = append(args, "--max", strconv.Itoa(max))
+	}
+	if h.BinVersion == V3 {
+		args = append(args, "--namespace", ns)
+	}
+	output, err := h.runHelmWithOutput(args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "running helm %s", strings.Join(args, " "))
+	}
+	revisions := []ReleaseRevision{}
+	err = json.Unmarshal([]byte(output), &revisions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the history of release %s", releaseName)
+	}
+	for i, revision := range revisions {
+		revisions[i].Chart, revisions[i].ChartVersion = splitChartVersion(revision.Chart)
+		revisions[i].Status = strings.ToUpper(revision.Status)
+	}
+	return revisions, nil
+}
+
+// splitChartVersion splits the name and version of a chart such as 'mychart-1.0.0-rc.1' at the first dash followed by
+// a digit which starts a semantic version as both the name and the pre-release of the version may contain dashes
+func splitChartVersion(chart string) (string, string) {
+	for i := 1; i < len(chart)-1; i++ {
+		if chart[i] != '-' || chart[i+1] < '0' || chart[i+1] > '9' {
+			continue
+		}
+		if _, err := semver.Parse(chart[i+1:]); err == nil {
+			return chart[:i], chart[i+1:]
+		}
+	}
+	return chart, ""
+}
+
+// GetValues returns the YAML of the user supplied values of the given revision of the release in ns
+func (h *HelmCLI) GetValues(ns string, releaseName string, revision int) (string, error) {
+	args := []string{"get", "values", releaseName, "--revision", strconv.Itoa(revision)}
+	if h.BinVersion == V3 {
+		args = append(args, "--namespace", ns)
+	}
+	return h.runHelmWithOutput(args...)
+}
+
+// Rollback rolls back the release in ns to the given revision
+func (h *HelmCLI) Rollback(ns string, releaseName string, revision int, wait bool, force bool) error {
+	args := []string{"rollback", releaseName, strconv.Itoa(revision)}
+	if wait {
+		args = append(args, "--wait")
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	if h.BinVersion == V3 {
+		args = append(args, "--namespace", ns)
+	}
+	return h.runHelm(args...)
+}
+
 // Lint lints the helm chart from the current working directory and returns the warnings in the output
 func (h *HelmCLI) Lint(valuesFiles []string) (string, error) {
 	args := []string{"lint",
@@ -223,6 +223,19 @@ func TestUpgradeChart(t *testing.T) {
 	verifyArgs(t, helm, runner, expectedArgs...)
 }
 
+func TestUpgradeChartAtomic(t *testing.T) {
+	timeout := 1800
+	expectedArgs := []string{"upgrade", "--namespace", namespace, "--install", "--wait", "--atomic",
+		"--timeout", fmt.Sprintf("%d", timeout), releaseName, chart}
+	helm, runner := createHelm(t, nil, "")
+	helm.Atomic = true
+
+	err := helm.UpgradeChart(chart, releaseName, namespace, "", true, timeout, false, true, nil, nil, nil, "", "", "")
+
+	assert.NoError(t, err, "should upgrade the chart without any error")
+	verifyArgs(t, helm, runner, expectedArgs...)
+}
+
 func TestDeleteRelaese(t *testing.T) {
 	expectedArgs := []string{"delete", "--purge", releaseName}
 	helm, runner := createHelm(t, nil, "")
@@ -307,6 +320,68 @@ func TestStatusReleasesForHelm3(t *testing.T) {
 	}
 }
 
+func TestHistory(t *testing.T) {
+	expectedArgs := []string{"history", releaseName, "--output", "json", "--max", "5"}
+	helm, runner := createHelm(t, nil, `[{"revision":1,"updated":"Mon Jul  2 16:16:20 2018","status":"SUPERSEDED","chart":"env-0.0.1","description":"Install complete"},{"revision":2,"updated":"Tue Jul  3 09:10:11 2018","status":"DEPLOYED","chart":"env-0.0.2","description":"Upgrade complete"}]`)
+
+	revisions, err := helm.History("default", releaseName, 5)
+
+	assert.NoError(t, err, "should get the history of the release without any error")
+	verifyArgs(t, helm, runner, expectedArgs...)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[1].Revision)
+	assert.Equal(t, "env", revisions[1].Chart)
+	assert.Equal(t, "0.0.2", revisions[1].ChartVersion)
+	assert.Equal(t, "DEPLOYED", revisions[1].Status)
+}
+
+func TestHistoryForHelm3(t *testing.T) {
+	expectedArgs := []string{"history", releaseName, "--output", "json", "--namespace", "default"}
+	helm, runner := createHelmWithVersion(t, helm.V3, nil, `[{"revision":3,"updated":"2020-05-11T10:13:53.576296+01:00","status":"deployed","chart":"nginx-ingress-1.3.1","app_version":"0.30.0","description":"Rollback to 1"}]`)
+
+	revisions, err := helm.History("default", releaseName, 0)
+
+	assert.NoError(t, err, "should get the history of the release without any error")
+	verifyArgs(t, helm, runner, expectedArgs...)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "nginx-ingress", revisions[0].Chart)
+	assert.Equal(t, "1.3.1", revisions[0].ChartVersion)
+	assert.Equal(t, "0.30.0", revisions[0].AppVersion)
+	assert.Equal(t, "DEPLOYED", revisions[0].Status)
+}
+
+func TestHistoryWithPreReleaseChartVersion(t *testing.T) {
+	helm, _ := createHelmWithVersion(t, helm.V3, nil, `[{"revision":1,"status":"deployed","chart":"my-2fa-chart-1.0.0-rc.1"}]`)
+
+	revisions, err := helm.History("default", releaseName, 0)
+
+	assert.NoError(t, err, "should get the history of the release without any error")
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "my-2fa-chart", revisions[0].Chart)
+	assert.Equal(t, "1.0.0-rc.1", revisions[0].ChartVersion)
+}
+
+func TestGetValues(t *testing.T) {
+	expectedArgs := []string{"get", "values", releaseName, "--revision", "2"}
+	helm, runner := createHelm(t, nil, "foo: bar\n")
+
+	values, err := helm.GetValues("default", releaseName, 2)
+
+	assert.NoError(t, err, "should get the values of the revision without any error")
+	verifyArgs(t, helm, runner, expectedArgs...)
+	assert.Equal(t, "foo: bar\n", values)
+}
+
+func TestRollback(t *testing.T) {
+	expectedArgs := []string{"rollback", releaseName, "2", "--wait", "--namespace", "default"}
+	helm, runner := createHelmWithVersion(t, helm.V3, nil, "")
+
+	err := helm.Rollback("default", releaseName, 2, true, false)
+
+	assert.NoError(t, err, "should roll back the release without any error")
+	verifyArgs(t, helm, runner, expectedArgs...)
+}
+
 func TestLint(t *testing.T) {
 	expectedArgs := []string{"lint",
 		"--set", "tags.jx-lint=true",
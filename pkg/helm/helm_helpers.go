@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -260,6 +261,28 @@ func LoadChartFile(fileName string) (*chart.Metadata, error) {
 	return &chart.Metadata{}, nil
 }
 
+// LoadChartArchive loads the chart file in the root directory of the given chart archive
+func LoadChartArchive(fileName string) (*chart.Metadata, error) {
+	var data string
+	err := scanChartArchive(fileName, func(name string, text string) {
+		parts := strings.Split(name, "/")
+		if len(parts) == 2 && parts[1] == ChartFileName {
+			data = text
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, fmt.Errorf("no %s found in chart archive %s", ChartFileName, fileName)
+	}
+	metadata, err := LoadChart([]byte(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the %s in chart archive %s", ChartFileName, fileName)
+	}
+	return metadata, nil
+}
+
 // LoadValuesFile loads the values file or creates empty map if the file does not exist
 func LoadValuesFile(fileName string) (map[string]interface{}, error) {
 	exists, err := util.FileExists(fileName)
@@ -461,7 +484,7 @@ func CombineValueFilesToFile(outFile string, inputFiles []string, chartName stri
 
 // IsLocal returns whether this chart is being installed from the local filesystem or not
 func IsLocal(chart string) bool {
-	b := strings.HasPrefix(chart, "/") || strings.HasPrefix(chart, ".") || strings.Count(chart, "/") > 1
+	b := filepath.IsAbs(chart) || strings.HasPrefix(chart, "/") || strings.HasPrefix(chart, ".") || strings.Count(chart, "/") > 1
 	if !b {
 		// check if file exists, then it's local
 		exists, err := util.FileExists(chart)
@@ -621,7 +644,12 @@ func DecorateWithSecrets(valueFiles []string, secretURLClient secreturl.Client)
 			}
 		}
 		for _, valueFile := range valueFiles {
-			newValuesFile, err := ioutil.TempFile("", "values.yaml")
+			// decrypted SOPS values must never be written to disk so lets keep them in their memory backed directory
+			tempDir := ""
+			if IsDecryptedSopsValuesFile(valueFile) {
+				tempDir = filepath.Dir(valueFile)
+			}
+			newValuesFile, err := ioutil.TempFile(tempDir, "values.yaml")
 			if err != nil {
 				return nil, cleanup, errors.Wrapf(err, "creating temp file for %s", valueFile)
 			}
@@ -832,6 +860,26 @@ func SetValuesToMap(setValues []string) map[string]interface{} {
 	return answer
 }
 
+// SetJSONValuesToMap converts the set of values of the form "foo.bar=<json>" into a helm values.yaml map structure
+// where each value is parsed as JSON so that arrays and nested objects can be specified
+func SetJSONValuesToMap(setJSONValues []string) (map[string]interface{}, error) {
+	answer := map[string]interface{}{}
+	for _, setValue := range setJSONValues {
+		tokens := strings.SplitN(setValue, "=", 2)
+		if len(tokens) != 2 || tokens[0] == "" {
+			return answer, fmt.Errorf("invalid JSON value %s: should be of the form path=<json>", setValue)
+		}
+		path := tokens[0]
+		var value interface{}
+		err := json.Unmarshal([]byte(tokens[1]), &value)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to parse the JSON value for %s", path)
+		}
+		util.SetMapValueViaPath(answer, path, value)
+	}
+	return answer, nil
+}
+
 // PromptForRepoCredsIfNeeded will prompt for repo credentials if required. It first checks the existing cred (
 // if any) and then prompts for new credentials up to 3 times, trying each set.
 func PromptForRepoCredsIfNeeded(repo string, cred *HelmRepoCredential, handles util.IOFileHandles) error {
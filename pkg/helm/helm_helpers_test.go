@@ -102,6 +102,35 @@ func TestSetValuesToMap(t *testing.T) {
 	assert.Equal(t, actual, expected, "setValuesToMap for values %s", strings.Join(setValues, ", "))
 }
 
+func TestSetJSONValuesToMap(t *testing.T) {
+	t.Parallel()
+
+	setValues := []string{`foo.hosts=["a.com","b.com"]`, `foo.resources={"limits":{"cpu":"100m"}}`, "cheese=3"}
+	actual, err := helm.SetJSONValuesToMap(setValues)
+	assert2.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"cheese": float64(3),
+		"foo": map[string]interface{}{
+			"hosts": []interface{}{"a.com", "b.com"},
+			"resources": map[string]interface{}{
+				"limits": map[string]interface{}{
+					"cpu": "100m",
+				},
+			},
+		},
+	}
+	assert.Equal(t, actual, expected, "setJSONValuesToMap for values %s", strings.Join(setValues, ", "))
+}
+
+func TestSetJSONValuesToMapInvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := helm.SetJSONValuesToMap([]string{`foo.hosts=["a.com"`})
+	require.Error(t, err)
+	assert2.Contains(t, err.Error(), "foo.hosts")
+}
+
 func TestStoreCredentials(t *testing.T) {
 	pegomock.RegisterMockTestingT(t)
 	vaultClient := secreturl_test.NewMockClient()
@@ -0,0 +1,19 @@
+// +build unit
+// +build windows
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLocalWindowsPaths(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, helm.IsLocal(`C:\charts\myapp`))
+	assert.True(t, helm.IsLocal(`.\charts\myapp`))
+	assert.False(t, helm.IsLocal("jenkins-x/myapp"))
+}
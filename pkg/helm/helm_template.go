@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"k8s.io/helm/pkg/proto/hapi/chart"
 
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
@@ -33,6 +35,18 @@ const (
 	AnnotationAppDescription = "jenkins.io/chart-description"
 	// AnnotationAppRepository stores the chart's app repository
 	AnnotationAppRepository = "jenkins.io/chart-repository"
+	// AnnotationChartVersion stores the name and version of the chart or dependency a resource was rendered from
+	AnnotationChartVersion = "jenkins.io/chart-version"
+	// AnnotationGitSHA stores the git commit of the chart which applied a resource
+	AnnotationGitSHA = "jenkins.io/git-sha"
+	// AnnotationBuildNumber stores the build number of the pipeline which applied a resource
+	AnnotationBuildNumber = "jenkins.io/build-number"
+	// AnnotationProwJobID stores the ID of the prow job which applied a resource
+	AnnotationProwJobID = "jenkins.io/prow-job-id"
+	// AnnotationAppliedChartVersion stores the version of the chart which applied a resource
+	AnnotationAppliedChartVersion = "jenkins.io/applied-chart-version"
+	// AnnotationJXVersion stores the version of jx which applied a resource
+	AnnotationJXVersion = "jenkins.io/jx-version"
 
 	// LabelReleaseName stores the chart release name
 	LabelReleaseName = "jenkins.io/chart-release"
@@ -47,8 +61,11 @@ const (
 	// LabelAppVersion stores the chart's app version
 	LabelAppVersion = "jenkins.io/app-version"
 
-	hookFailed    = "hook-failed"
-	hookSucceeded = "hook-succeeded"
+	hookFailed             = "hook-failed"
+	hookSucceeded          = "hook-succeeded"
+	hookBeforeHookCreation = "before-hook-creation"
+
+	defaultHookTimeout = 30 * time.Minute
 
 	// resourcesSeparator is used to separate multiple objects stored in the same YAML file
 	resourcesSeparator = "---"
@@ -65,6 +82,21 @@ type HelmTemplate struct {
 	KubectlValidate bool
 	KubeClient      kubernetes.Interface
 	Namespace       string
+	// AnnotateVersions annotates the rendered resources with the chart or dependency version they came from
+	AnnotateVersions bool
+	// HookDeletePolicy the deletion policy of the helm hooks which don't specify a 'helm.sh/hook-delete-policy'
+	HookDeletePolicy string
+	// HookTimeout the maximum time to wait for a helm hook Job to complete. Defaults to 30 minutes
+	HookTimeout time.Duration
+	// KustomizeDir the directory of the kustomization used to post render the manifests before they are applied
+	KustomizeDir string
+	// Kustomizer the kustomize client used to post render the manifests
+	Kustomizer kustomize.Kustomizer
+	// Annotations the annotations added to every resource, including the hooks, once the manifests are post rendered
+	Annotations map[string]string
+	// ConvertManifest if not nil converts every manifest file, including the hooks, once they are post rendered and
+	// before the annotations are added, e.g. to replace the Secrets with ExternalSecrets
+	ConvertManifest func(fileName string) error
 }
 
 // NewHelmTemplate creates a new HelmTemplate instance configured to the given client side Helmer
@@ -265,7 +297,12 @@ func (h *HelmTemplate) InstallChart(chart string, releaseName string, ns string,
 		return err
 	}
 
-	helmHooks, err := h.addLabelsToFiles(chart, releaseName, versionText, metadata, ns)
+	helmHooks, err := h.addLabelsToFiles(chart, chartDir, releaseName, versionText, metadata, ns)
+	if err != nil {
+		return err
+	}
+	defaultHookDeletePolicies(helmHooks, h.HookDeletePolicy)
+	err = h.postRender(outputDir, helmHooks)
 	if err != nil {
 		return err
 	}
@@ -354,7 +391,12 @@ func (h *HelmTemplate) UpgradeChart(chart string, releaseName string, ns string,
 		return err
 	}
 
-	helmHooks, err := h.addLabelsToFiles(chart, releaseName, versionText, metadata, ns)
+	helmHooks, err := h.addLabelsToFiles(chart, chartDir, releaseName, versionText, metadata, ns)
+	if err != nil {
+		return err
+	}
+	defaultHookDeletePolicies(helmHooks, h.HookDeletePolicy)
+	err = h.postRender(outputDir, helmHooks)
 	if err != nil {
 		return err
 	}
@@ -672,12 +714,22 @@ func (h *HelmTemplate) fetchChart(chart string, version string, dir string, repo
 	return answer, nil
 }
 
-func (h *HelmTemplate) addLabelsToFiles(chart string, releaseName string, version string, metadata *chart.Metadata, ns string) ([]*HelmHook, error) {
+func (h *HelmTemplate) addLabelsToFiles(chart string, chartDir string, releaseName string, version string, metadata *chart.Metadata, ns string) ([]*HelmHook, error) {
 	dir, helmHookDir, _, err := h.getDirectories(releaseName)
 	if err != nil {
 		return nil, err
 	}
-	return addLabelsToChartYaml(dir, helmHookDir, chart, releaseName, version, metadata, ns)
+	var versions *chartVersions
+	if h.AnnotateVersions {
+		if !filepath.IsAbs(chartDir) {
+			chartDir = filepath.Join(h.Runner.CurrentDir(), chartDir)
+		}
+		versions, err = loadChartVersions(chartDir, chart, version, metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return addLabelsToChartYaml(dir, helmHookDir, chart, releaseName, version, metadata, ns, versions)
 }
 
 func splitObjectsInFiles(inputFile string, baseDir string, relativePath, defaultNamespace string) ([]string, error) {
@@ -801,7 +853,7 @@ func writeObjectInFile(buf io.WriterTo, baseDir string, relativePath, namespace
 	return absFile, nil
 }
 
-func addLabelsToChartYaml(basedir string, hooksDir string, chart string, releaseName string, version string, metadata *chart.Metadata, ns string) ([]*HelmHook, error) {
+func addLabelsToChartYaml(basedir string, hooksDir string, chart string, releaseName string, version string, metadata *chart.Metadata, ns string, versions *chartVersions) ([]*HelmHook, error) {
 	helmHooks := []*HelmHook{}
 
 	log.Logger().Debugf("Searching for yaml files from basedir %s", basedir)
@@ -843,7 +895,7 @@ func addLabelsToChartYaml(basedir string, hooksDir string, chart string, release
 					}
 					helmHooks = append(helmHooks, helmHook)
 				} else {
-					err := processChartResource(partFile, data, kind, ns, releaseName, &m, metadata, version, chart)
+					err := processChartResource(partFile, data, kind, ns, releaseName, &m, metadata, version, chart, versions.annotationValue(relativePath))
 					if err != nil {
 						return errors.Wrap(err, fmt.Sprintf("when processing chart resource '%s'", partFile))
 					}
@@ -888,7 +940,7 @@ func getHelmHookFromFile(basedir string, path string, hooksDir string, helmHook
 	return NewHelmHook(kind, name, hookFile, helmHook, helmDeletePolicy), nil
 }
 
-func processChartResource(partFile string, data []byte, kind string, ns string, releaseName string, m *yaml.MapSlice, metadata *chart.Metadata, version string, chart string) error {
+func processChartResource(partFile string, data []byte, kind string, ns string, releaseName string, m *yaml.MapSlice, metadata *chart.Metadata, version string, chart string, chartVersion string) error {
 	err := setYamlValue(m, releaseName, "metadata", "labels", LabelReleaseName)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to modify YAML of partFile %s", partFile)
@@ -922,6 +974,13 @@ func processChartResource(partFile string, data []byte, kind string, ns string,
 	if err != nil {
 		return errors.Wrapf(err, "Failed to modify YAML of partFile %s", partFile)
 	}
+	// lets not replace an annotation the chart sets itself
+	if chartVersion != "" && getYamlValueString(m, "metadata", "annotations", AnnotationChartVersion) == "" {
+		err = setYamlValue(m, chartVersion, "metadata", "annotations", AnnotationChartVersion)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to modify YAML of partFile %s", partFile)
+		}
+	}
 
 	data, err = yaml.Marshal(m)
 	if err != nil {
@@ -934,6 +993,63 @@ func processChartResource(partFile string, data []byte, kind string, ns string,
 	return nil
 }
 
+// chartVersions the versions of a chart and its direct dependencies used to annotate the rendered resources
+type chartVersions struct {
+	Chart        string
+	Dependencies map[string]string
+}
+
+// loadChartVersions loads the versions of the chart and the dependencies in its requirements file
+func loadChartVersions(chartDir string, chart string, version string, metadata *chart.Metadata) (*chartVersions, error) {
+	name := chart
+	if metadata != nil && metadata.GetName() != "" {
+		name = metadata.GetName()
+	}
+	answer := &chartVersions{
+		Chart:        name + "-" + version,
+		Dependencies: map[string]string{},
+	}
+	fileName := filepath.Join(chartDir, RequirementsFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return answer, nil
+	}
+	req, err := LoadRequirementsFile(fileName)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to load %s", fileName)
+	}
+	for _, dep := range req.Dependencies {
+		value := dep.Name + "-" + dep.Version
+		answer.Dependencies[dep.Name] = value
+		if dep.Alias != "" {
+			answer.Dependencies[dep.Alias] = value
+		}
+	}
+	return answer, nil
+}
+
+// annotationValue returns the chart version annotation for a file rendered at the given path relative to the
+// output directory. e.g. 'mychart/charts/foo/templates/svc.yaml' is annotated with the version of the 'foo' dependency
+func (c *chartVersions) annotationValue(relativePath string) string {
+	if c == nil {
+		return ""
+	}
+	segments := strings.Split(filepath.ToSlash(relativePath), "/")
+	for i := 0; i < len(segments)-2; i++ {
+		if segments[i] == "charts" {
+			value := c.Dependencies[segments[i+1]]
+			if value != "" {
+				return value
+			}
+			break
+		}
+	}
+	return c.Chart
+}
+
 func getYamlValueString(mapSlice *yaml.MapSlice, keys ...string) string {
 	value := getYamlValue(mapSlice, keys...)
 	answer, ok := value.(string)
@@ -1090,6 +1206,13 @@ func (h *HelmTemplate) getChart(chartDir string, version string) (*chart.Metadat
 func (h *HelmTemplate) runHooks(hooks []*HelmHook, hookPhase string, ns string, chart string, releaseName string, wait bool, create bool, force bool) error {
 	matchingHooks := MatchingHooks(hooks, hookPhase, "")
 	for _, hook := range matchingHooks {
+		if util.StringArrayIndex(hook.HookDeletePolicies, hookBeforeHookCreation) >= 0 {
+			log.Logger().Debugf("Deleting the previous helm %s hook resources in file: %s", hookPhase, hook.File)
+			err := h.runKubectl("delete", "-f", hook.File, "--namespace", ns, "--ignore-not-found", "--wait")
+			if err != nil {
+				return err
+			}
+		}
 		err := h.kubectlApplyFile(ns, hookPhase, wait, create, force, hook.File)
 		if err != nil {
 			return err
@@ -1106,7 +1229,7 @@ func (h *HelmTemplate) deleteHooks(hooks []*HelmHook, hookPhase string, hookDele
 		name := hook.Name
 		if kind == "Job" && name != "" {
 			log.Logger().Debugf("Waiting for helm %s hook Job %s to complete before removing it", hookPhase, name)
-			err := kube.WaitForJobToComplete(h.KubeClient, ns, name, time.Minute*30, false)
+			err := kube.WaitForJobToComplete(h.KubeClient, ns, name, h.hookTimeout(), false)
 			if err != nil {
 				log.Logger().Warnf("Job %s has not yet terminated for helm hook phase %s due to: %s so removing it anyway", name, hookPhase, err)
 			}
@@ -1125,6 +1248,135 @@ func (h *HelmTemplate) deleteHooks(hooks []*HelmHook, hookPhase string, hookDele
 	return nil
 }
 
+// postRender runs the kustomization in the KustomizeDir over the manifests in the output directory if specified then
+// adds the Annotations to the manifests and hooks
+func (h *HelmTemplate) postRender(outputDir string, hooks []*HelmHook) error {
+	if h.KustomizeDir != "" {
+		kustomizer := h.Kustomizer
+		if kustomizer == nil {
+			kustomizer = kustomize.NewKustomizeCLI()
+		}
+		err := kustomize.PostRender(kustomizer, h.KustomizeDir, outputDir)
+		if err != nil {
+			return err
+		}
+	}
+	if len(h.Annotations) == 0 && h.ConvertManifest == nil {
+		return nil
+	}
+	files := []string{}
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if !info.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the manifests in %s", outputDir)
+	}
+	for _, hook := range hooks {
+		files = append(files, hook.File)
+	}
+	for _, file := range files {
+		if h.ConvertManifest != nil {
+			err = h.ConvertManifest(file)
+			if err != nil {
+				return err
+			}
+		}
+		if len(h.Annotations) > 0 {
+			err = AnnotateManifestFile(file, h.Annotations)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AnnotateManifestFile adds the annotations to each resource of the YAML file which may contain many documents
+func AnnotateManifestFile(fileName string, annotations map[string]string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	keys := []string{}
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buffer strings.Builder
+	for i, doc := range SplitYAMLDocuments(string(data)) {
+		if i > 0 {
+			buffer.WriteString(resourcesSeparator + "\n")
+		}
+		m := yaml.MapSlice{}
+		err = yaml.Unmarshal([]byte(doc), &m)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse YAML of file %s", fileName)
+		}
+		if getYamlValueString(&m, "kind") == "" {
+			buffer.WriteString(doc)
+			continue
+		}
+		for _, k := range keys {
+			err = setYamlValue(&m, annotations[k], "metadata", "annotations", k)
+			if err != nil {
+				return errors.Wrapf(err, "failed to annotate the YAML of file %s", fileName)
+			}
+		}
+		out, err := yaml.Marshal(m)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal YAML of file %s", fileName)
+		}
+		buffer.Write(out)
+	}
+	err = ioutil.WriteFile(fileName, []byte(buffer.String()), util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// SplitYAMLDocuments splits the text into the YAML documents separated by '---' lines
+func SplitYAMLDocuments(text string) []string {
+	answer := []string{}
+	var doc strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if strings.TrimSpace(line) == resourcesSeparator {
+			answer = append(answer, doc.String())
+			doc.Reset()
+			continue
+		}
+		doc.WriteString(line)
+	}
+	return append(answer, doc.String())
+}
+
+func (h *HelmTemplate) hookTimeout() time.Duration {
+	if h.HookTimeout > 0 {
+		return h.HookTimeout
+	}
+	return defaultHookTimeout
+}
+
+// defaultHookDeletePolicies sets the deletion policies of the hooks which don't specify any
+func defaultHookDeletePolicies(hooks []*HelmHook, hookDeletePolicy string) {
+	if hookDeletePolicy == "" {
+		return
+	}
+	for _, hook := range hooks {
+		if strings.TrimSpace(strings.Join(hook.HookDeletePolicies, "")) == "" {
+			hook.HookDeletePolicies = strings.Split(hookDeletePolicy, ",")
+		}
+	}
+}
+
 // NewHelmHook returns a newly created HelmHook
 func NewHelmHook(kind string, name string, file string, hook string, hookDeletePolicy string) *HelmHook {
 	return &HelmHook{
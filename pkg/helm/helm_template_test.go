@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -47,7 +48,7 @@ func TestAddYamlLabels(t *testing.T) {
 	namespacesDir := filepath.Join(outDir, "namespaces", "jx")
 	hooksDir := path.Join(baseDir, "hooks", "namespaces", "jx")
 
-	helmHooks, err := addLabelsToChartYaml(outDir, hooksDir, expectedChartName, expectedChartRelease, expectedChartVersion, chartMetadata, expectedNamespace)
+	helmHooks, err := addLabelsToChartYaml(outDir, hooksDir, expectedChartName, expectedChartRelease, expectedChartVersion, chartMetadata, expectedNamespace, nil)
 	assert.NoError(t, err, "Failed to add labels to YAML")
 
 	err = filepath.Walk(namespacesDir, func(path string, f os.FileInfo, err error) error {
@@ -106,6 +107,55 @@ func assertNoLabelValue(t *testing.T, labels map[string]string, key string, path
 	return true
 }
 
+func TestAddYamlLabelsAnnotateVersions(t *testing.T) {
+	t.Parallel()
+
+	baseDir, err := ioutil.TempDir("", "test-annotate-versions")
+	require.NoError(t, err)
+
+	testData := path.Join("test_data", "annotate_versions")
+	outDir := path.Join(baseDir, "output")
+	err = util.CopyDir(path.Join(testData, "output"), outDir, true)
+	require.NoError(t, err)
+
+	chartMetadata := &chart.Metadata{
+		Name:    "mychart",
+		Version: "1.2.3",
+	}
+	versions, err := loadChartVersions(path.Join(testData, "chart"), "mychart", "1.2.3", chartMetadata)
+	require.NoError(t, err, "failed to load the chart versions")
+
+	hooksDir := path.Join(baseDir, "hooks", "namespaces", "jx")
+	_, err = addLabelsToChartYaml(outDir, hooksDir, "mychart", "cheese", "1.2.3", chartMetadata, "jx", versions)
+	require.NoError(t, err, "Failed to add labels to YAML")
+
+	namespacesDir := filepath.Join(outDir, "namespaces", "jx")
+	testCases := map[string]map[string]string{
+		filepath.Join("mychart", "templates", "part0-service.yaml"): {
+			AnnotationChartVersion: "mychart-1.2.3",
+			"abc":                  "def",
+		},
+		filepath.Join("mychart", "templates", "part0-configmap.yaml"): {
+			AnnotationChartVersion: "custom",
+		},
+		filepath.Join("mychart", "charts", "db", "templates", "part0-service.yaml"): {
+			AnnotationChartVersion: "db-2.0.0",
+		},
+	}
+	for file, expected := range testCases {
+		fileName := filepath.Join(namespacesDir, file)
+		data, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err, "Failed to load YAML %s", fileName)
+		svc := &corev1.Service{}
+		err = yaml.Unmarshal(data, svc)
+		require.NoError(t, err, "Failed to parse YAML %s", fileName)
+		for key, value := range expected {
+			assert.Equal(t, value, svc.Annotations[key], "annotation %s on YAML %s", key, fileName)
+		}
+		assert.Equal(t, "mychart", svc.Annotations[AnnotationChartName], "annotation %s on YAML %s", AnnotationChartName, fileName)
+	}
+}
+
 func TestSplitObjectsInFiles(t *testing.T) {
 	t.Parallel()
 
@@ -207,3 +257,66 @@ func TestSplitObjectsInFilesWithHooks(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultHookDeletePolicies(t *testing.T) {
+	t.Parallel()
+
+	hooks := []*HelmHook{
+		NewHelmHook("Job", "migrate", "migrate.yaml", "pre-upgrade", ""),
+		NewHelmHook("Job", "cleanup", "cleanup.yaml", "post-upgrade", "hook-failed"),
+	}
+	defaultHookDeletePolicies(hooks, "before-hook-creation,hook-succeeded")
+
+	assert.Equal(t, []string{"before-hook-creation", "hook-succeeded"}, hooks[0].HookDeletePolicies)
+	assert.Equal(t, []string{"hook-failed"}, hooks[1].HookDeletePolicies, "should keep the policy of the hook")
+	assert.Len(t, MatchingHooks(hooks, "pre-upgrade", hookSucceeded), 1)
+
+	h := &HelmTemplate{}
+	assert.Equal(t, defaultHookTimeout, h.hookTimeout())
+	h.HookTimeout = time.Hour
+	assert.Equal(t, time.Hour, h.hookTimeout())
+}
+
+func TestPostRenderAnnotations(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-template-annotations-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	outputDir := filepath.Join(dir, "output")
+	require.NoError(t, os.MkdirAll(outputDir, util.DefaultWritePermissions))
+	files := map[string]string{
+		filepath.Join(outputDir, "part0-configmap.yaml"): "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  annotations:\n    foo: bar\n",
+		filepath.Join(outputDir, "kustomized.yaml"):      "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: secret\n",
+		filepath.Join(dir, "job.yaml"):                   "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n",
+	}
+	for fileName, text := range files {
+		require.NoError(t, ioutil.WriteFile(fileName, []byte(text), util.DefaultFileWritePermissions))
+	}
+
+	h := &HelmTemplate{
+		Annotations: map[string]string{
+			AnnotationGitSHA:      "abc123",
+			AnnotationBuildNumber: "7",
+		},
+	}
+	hooks := []*HelmHook{NewHelmHook("Job", "migrate", filepath.Join(dir, "job.yaml"), "pre-upgrade", "")}
+	require.NoError(t, h.postRender(outputDir, hooks))
+
+	annotations := func(fileName string) []map[string]interface{} {
+		data, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+		answer := []map[string]interface{}{}
+		for _, doc := range strings.Split(string(data), "---\n") {
+			m := map[string]interface{}{}
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &m))
+			metadata := m["metadata"].(map[string]interface{})
+			answer = append(answer, metadata["annotations"].(map[string]interface{}))
+		}
+		return answer
+	}
+	expected := map[string]interface{}{AnnotationGitSHA: "abc123", AnnotationBuildNumber: "7"}
+	assert.Equal(t, []map[string]interface{}{{"foo": "bar", AnnotationGitSHA: "abc123", AnnotationBuildNumber: "7"}}, annotations(filepath.Join(outputDir, "part0-configmap.yaml")), "should keep the annotations of the chart")
+	assert.Equal(t, []map[string]interface{}{expected, expected}, annotations(filepath.Join(outputDir, "kustomized.yaml")), "should annotate each document")
+	assert.Equal(t, []map[string]interface{}{expected}, annotations(filepath.Join(dir, "job.yaml")), "should annotate the hooks")
+}
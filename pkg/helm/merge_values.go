@@ -0,0 +1,74 @@
+package helm
+
+import (
+	"sort"
+	"strings"
+)
+
+// FindRemovedValues merges the overrides on top of the base values in order, like helm does with multiple values
+// files, and returns the sorted leaf paths of the base values which are null or absent after the merge. e.g. due to an
+// override setting a key to null or replacing a map with a scalar value
+func FindRemovedValues(base map[string]interface{}, overrides ...map[string]interface{}) []string {
+	merged := copyValues(base)
+	for _, override := range overrides {
+		mergeValues(merged, override)
+	}
+	answer := []string{}
+	for _, path := range valuesLeafPaths("", base) {
+		if lookupValuesPath(base, path) == nil {
+			// the base value was already null
+			continue
+		}
+		if lookupValuesPath(merged, path) == nil {
+			answer = append(answer, path)
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// mergeValues merges the override into the destination values where a null override removes the key
+func mergeValues(dest map[string]interface{}, override map[string]interface{}) {
+	for key, value := range override {
+		if value == nil {
+			delete(dest, key)
+			continue
+		}
+		overrideMap, ok := value.(map[string]interface{})
+		if ok {
+			destMap, ok := dest[key].(map[string]interface{})
+			if ok {
+				mergeValues(destMap, overrideMap)
+				continue
+			}
+			value = copyValues(overrideMap)
+		}
+		dest[key] = value
+	}
+}
+
+// copyValues returns a deep copy of the nested maps of the values
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	answer := map[string]interface{}{}
+	for key, value := range values {
+		m, ok := value.(map[string]interface{})
+		if ok {
+			value = copyValues(m)
+		}
+		answer[key] = value
+	}
+	return answer
+}
+
+// lookupValuesPath returns the value at the given dotted path or nil if it does not exist
+func lookupValuesPath(values map[string]interface{}, path string) interface{} {
+	var value interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
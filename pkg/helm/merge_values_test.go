@@ -0,0 +1,48 @@
+// +build unit
+
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRemovedValues(t *testing.T) {
+	t.Parallel()
+
+	base, err := helm.LoadValues([]byte(`expose:
+  config:
+    domain: acme.com
+    tls: true
+image:
+  repository: acme/app
+  tag: 1.0.0
+ingress:
+  annotations:
+    foo: bar
+optional: null
+`))
+	require.NoError(t, err)
+	override, err := helm.LoadValues([]byte(`expose:
+  config:
+    tls: null
+image:
+  tag: 1.1.0
+ingress: disabled
+optional: 1
+`))
+	require.NoError(t, err)
+	secrets, err := helm.LoadValues([]byte(`image:
+  repository: null
+`))
+	require.NoError(t, err)
+
+	removed := helm.FindRemovedValues(base, override, secrets)
+	assert.Equal(t, []string{"expose.config.tls", "image.repository", "ingress.annotations.foo"}, removed)
+
+	assert.Empty(t, helm.FindRemovedValues(base), "no overrides should not remove anything")
+	assert.Equal(t, "acme/app", base["image"].(map[string]interface{})["repository"], "the base values should not be modified")
+}
@@ -0,0 +1,92 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// ChartIndex is a minimal representation of a chart repository 'index.yaml' file
+type ChartIndex struct {
+	APIVersion string                        `json:"apiVersion,omitempty"`
+	Entries    map[string][]*ChartIndexEntry `json:"entries"`
+}
+
+// ChartIndexEntry represents a single version of a chart in a chart repository index
+type ChartIndexEntry struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	AppVersion  string   `json:"appVersion,omitempty"`
+	KubeVersion string   `json:"kubeVersion,omitempty"`
+	Description string   `json:"description,omitempty"`
+	URLs        []string `json:"urls,omitempty"`
+}
+
+// LoadChartIndex loads the chart repository index from the given data
+func LoadChartIndex(data []byte) (*ChartIndex, error) {
+	index := &ChartIndex{}
+	err := yaml.Unmarshal(data, index)
+	if err != nil {
+		return index, errors.Wrap(err, "failed to unmarshal chart repository index")
+	}
+	if index.Entries == nil {
+		index.Entries = map[string][]*ChartIndexEntry{}
+	}
+	return index, nil
+}
+
+// ChartIndexURL returns the URL of the 'index.yaml' file for the given chart repository URL
+func ChartIndexURL(repoURL string) string {
+	return fmt.Sprintf("%s/index.yaml", strings.TrimSuffix(repoURL, "/"))
+}
+
+// FetchChartIndex downloads and parses the 'index.yaml' file of the given chart repository
+func FetchChartIndex(httpClient *http.Client, repoURL string) (*ChartIndex, error) {
+	u := ChartIndexURL(repoURL)
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GET %s", u)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to GET %s due to status %s", u, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the response body of %s", u)
+	}
+	index, err := LoadChartIndex(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", u)
+	}
+	return index, nil
+}
+
+// Versions returns the semantic versions of the given chart in the index sorted from lowest to highest.
+// Versions which are not valid semantic versions are ignored
+func (i *ChartIndex) Versions(name string) []semver.Version {
+	answer := []semver.Version{}
+	for _, entry := range i.Entries[name] {
+		v, err := semver.ParseTolerant(entry.Version)
+		if err == nil {
+			answer = append(answer, v)
+		}
+	}
+	semver.Sort(answer)
+	return answer
+}
+
+// Entry returns the index entry for the given chart name and version or nil if it does not exist
+func (i *ChartIndex) Entry(name string, version string) *ChartIndexEntry {
+	for _, entry := range i.Entries[name] {
+		if entry.Version == version {
+			return entry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package helm
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+)
+
+const (
+	// DefaultRetries the default number of attempts of helm and chart repository operations which fail transiently.
+	// Retries are disabled by default
+	DefaultRetries = 0
+	// DefaultRetryBackoff the default delay before the first retry which doubles for each subsequent retry
+	DefaultRetryBackoff = 2 * time.Second
+)
+
+var (
+	// retryableErrorMessages the lower case error messages of transient network and chart repository failures
+	retryableErrorMessages = []string{
+		"bad gateway",
+		"service unavailable",
+		"gateway timeout",
+		"too many requests",
+		"timeout",
+		"timed out",
+		"connection reset",
+		"connection refused",
+		"broken pipe",
+		"temporary failure",
+		"unexpected eof",
+		"tls handshake",
+	}
+
+	// nonRetryableErrorMessages the lower case error messages which are never retried even if they look transient
+	nonRetryableErrorMessages = []string{
+		// the resources of a release did not become ready so retrying would only wait again
+		"timed out waiting for the condition",
+	}
+
+	retryableStatusCodeRegex = regexp.MustCompile(`\b(429|502|503|504)\b`)
+)
+
+// IsRetryableError returns true if the error looks like a transient network or chart repository failure, such as a
+// 502 from chartmuseum or a registry timeout, which is worth retrying
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	text := strings.ToLower(err.Error())
+	for _, message := range nonRetryableErrorMessages {
+		if strings.Contains(text, message) {
+			return false
+		}
+	}
+	for _, message := range retryableErrorMessages {
+		if strings.Contains(text, message) {
+			return true
+		}
+	}
+	return retryableStatusCodeRegex.MatchString(text)
+}
+
+// RetryPolicy configures retrying helm and chart repository operations with exponential backoff
+type RetryPolicy struct {
+	// Attempts the maximum number of attempts. Values less than 2 disable retries
+	Attempts int
+	// Backoff the delay before the first retry which doubles for each subsequent retry
+	Backoff time.Duration
+	// IsRetryable classifies the errors which are retried. Defaults to IsRetryableError
+	IsRetryable func(err error) bool
+}
+
+// Retry calls the function until it succeeds, fails with an error which is not retryable or the attempts are
+// exhausted. The description is used in the log messages. e.g. 'fetch chart foo'
+func (p *RetryPolicy) Retry(description string, f func() error) error {
+	if p == nil || p.Attempts < 2 {
+		return f()
+	}
+	isRetryable := p.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsRetryableError
+	}
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = p.Backoff
+	if bo.InitialInterval <= 0 {
+		bo.InitialInterval = DefaultRetryBackoff
+	}
+	bo.Multiplier = 2.0
+	bo.MaxElapsedTime = 0
+	bo.Reset()
+
+	attempt := 0
+	operation := func() error {
+		attempt++
+		err := f()
+		if err != nil && !isRetryable(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	notify := func(err error, delay time.Duration) {
+		log.Logger().Warnf("failed to %s on attempt %d of %d so retrying in %s: %s", description, attempt, p.Attempts, delay.Round(time.Millisecond).String(), err.Error())
+	}
+	return backoff.RetryNotify(operation, backoff.WithMaxRetries(bo, uint64(p.Attempts-1)), notify)
+}
+
+// RetryHelmer wraps a Helmer retrying the operations which talk to chart repositories or the cluster and fail with a
+// transient error
+type RetryHelmer struct {
+	Helmer
+	Policy *RetryPolicy
+}
+
+// Unwrap returns the Helmer wrapped by a RetryHelmer or else the given Helmer so that the implementation specific
+// features of the Helmer can be used
+func Unwrap(helmer Helmer) Helmer {
+	if retryHelmer, ok := helmer.(*RetryHelmer); ok {
+		return retryHelmer.Helmer
+	}
+	return helmer
+}
+
+// NewRetryHelmer creates a Helmer retrying the operations of the given Helmer using the policy
+func NewRetryHelmer(helmer Helmer, policy *RetryPolicy) *RetryHelmer {
+	return &RetryHelmer{
+		Helmer: helmer,
+		Policy: policy,
+	}
+}
+
+// AddRepo adds a chart repository retrying transient failures
+func (h *RetryHelmer) AddRepo(repo, URL, username, password string) error {
+	return h.Policy.Retry("add chart repository "+URL, func() error {
+		return h.Helmer.AddRepo(repo, URL, username, password)
+	})
+}
+
+// UpdateRepo updates the chart repositories retrying transient failures
+func (h *RetryHelmer) UpdateRepo() error {
+	return h.Policy.Retry("update the chart repositories", h.Helmer.UpdateRepo)
+}
+
+// BuildDependency builds the chart dependencies retrying transient failures
+func (h *RetryHelmer) BuildDependency() error {
+	return h.Policy.Retry("build the chart dependencies", h.Helmer.BuildDependency)
+}
+
+// InstallChart installs a chart retrying transient failures
+func (h *RetryHelmer) InstallChart(chart string, releaseName string, ns string, version string, timeout int,
+	values []string, valueStrings []string, valueFiles []string, repo string, username string, password string) error {
+	return h.Policy.Retry("install chart "+chart, func() error {
+		return h.Helmer.InstallChart(chart, releaseName, ns, version, timeout, values, valueStrings, valueFiles, repo, username, password)
+	})
+}
+
+// UpgradeChart upgrades a chart retrying transient failures
+func (h *RetryHelmer) UpgradeChart(chart string, releaseName string, ns string, version string, install bool, timeout int, force bool, wait bool,
+	values []string, valueStrings []string, valueFiles []string, repo string, username string, password string) error {
+	return h.Policy.Retry("upgrade chart "+chart, func() error {
+		return h.Helmer.UpgradeChart(chart, releaseName, ns, version, install, timeout, force, wait, values, valueStrings, valueFiles, repo, username, password)
+	})
+}
+
+// FetchChart fetches a chart retrying transient failures
+func (h *RetryHelmer) FetchChart(chart string, version string, untar bool, untardir string, repo string, username string,
+	password string) error {
+	return h.Policy.Retry("fetch chart "+chart, func() error {
+		return h.Helmer.FetchChart(chart, version, untar, untardir, repo, username, password)
+	})
+}
+
+// SearchCharts searches the chart repositories retrying transient failures
+func (h *RetryHelmer) SearchCharts(filter string, allVersions bool) ([]ChartSummary, error) {
+	var answer []ChartSummary
+	err := h.Policy.Retry("search charts "+filter, func() error {
+		var err error
+		answer, err = h.Helmer.SearchCharts(filter, allVersions)
+		return err
+	})
+	return answer, err
+}
@@ -0,0 +1,106 @@
+// +build unit
+
+package helm_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	helm_test "github.com/jenkins-x/jx/v2/pkg/helm/mocks"
+	"github.com/petergtz/pegomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("Failed to post chart to http://chartmuseum/api/charts due to response 502: Bad Gateway"), true},
+		{errors.New("failed to fetch https://charts/index.yaml : 503 Service Unavailable"), true},
+		{errors.Wrap(errors.New("read tcp 10.0.0.1:443: connection reset by peer"), "failed to fetch chart"), true},
+		{errors.New("net/http: TLS handshake timeout"), true},
+		{errors.New("Error: release jx-staging failed: timed out waiting for the condition"), false},
+		{errors.New("chart \"foo\" version \"1.2.3\" not found in https://charts repository"), false},
+		{errors.New("Error: UPGRADE FAILED: YAML parse error on deployment.yaml"), false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, helm.IsRetryableError(tc.err), "IsRetryableError(%v)", tc.err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &helm.RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+	}
+
+	calls := 0
+	err := policy.Retry("succeed after transient failures", func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("response %d: Bad Gateway", 502)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "should have retried the transient failures")
+
+	calls = 0
+	err = policy.Retry("exhaust the attempts", func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	require.Error(t, err)
+	assert.Equal(t, 3, calls, "should have stopped after the maximum attempts")
+
+	calls = 0
+	err = policy.Retry("fail permanently", func() error {
+		calls++
+		return errors.New("YAML parse error")
+	})
+	require.EqualError(t, err, "YAML parse error")
+	assert.Equal(t, 1, calls, "should not have retried an error which is not retryable")
+
+	calls = 0
+	var nilPolicy *helm.RetryPolicy
+	err = nilPolicy.Retry("no policy", func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls, "a nil policy should not retry")
+}
+
+func TestRetryHelmerFetchChart(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+
+	mockHelmer := helm_test.NewMockHelmer()
+	pegomock.When(mockHelmer.FetchChart("jenkins-x/foo", "1.2.3", true, "/tmp/foo", "", "", "")).ThenReturn(
+		errors.New("failed to fetch https://charts/foo-1.2.3.tgz : 504 Gateway Timeout")).ThenReturn(nil)
+
+	helmer := helm.NewRetryHelmer(mockHelmer, &helm.RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+	})
+	err := helmer.FetchChart("jenkins-x/foo", "1.2.3", true, "/tmp/foo", "", "", "")
+	require.NoError(t, err)
+	mockHelmer.VerifyWasCalled(pegomock.Times(2)).FetchChart("jenkins-x/foo", "1.2.3", true, "/tmp/foo", "", "", "")
+}
+
+func TestUnwrap(t *testing.T) {
+	t.Parallel()
+
+	helmer := &helm.HelmTemplate{}
+	assert.Equal(t, helmer, helm.Unwrap(helm.NewRetryHelmer(helmer, &helm.RetryPolicy{Attempts: 3})))
+	assert.Equal(t, helmer, helm.Unwrap(helmer), "should return a Helmer which is not wrapped")
+}
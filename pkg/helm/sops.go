@@ -0,0 +1,144 @@
+package helm
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SopsFileSuffix the suffix of the SOPS encrypted values files. e.g. 'secrets.sops.yaml'
+	SopsFileSuffix = ".sops.yaml"
+
+	// SopsValuesDirEnvVar the environment variable of the memory backed directory holding the decrypted SOPS values
+	SopsValuesDirEnvVar = "JX_SOPS_VALUES_DIR"
+
+	// DefaultSopsValuesDir the default memory backed directory holding the decrypted SOPS values
+	DefaultSopsValuesDir = "/dev/shm"
+
+	sopsValuesDirPrefix = "jx-sops-values-"
+)
+
+// IsSopsFile returns true if the values file is encrypted with SOPS based on its name
+func IsSopsFile(fileName string) bool {
+	name := filepath.Base(fileName)
+	return strings.HasSuffix(name, SopsFileSuffix) || strings.HasSuffix(name, ".sops.yml")
+}
+
+// DecryptSopsFile decrypts the SOPS encrypted values file in memory using the 'sops' executable which uses the age,
+// KMS or GPG keys recorded in the file. The decrypted values are never written to disk
+func DecryptSopsFile(fileName string, runner func(cmd *util.Command) (string, error)) ([]byte, error) {
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	cmd := &util.Command{
+		Name: "sops",
+		Args: []string{"--decrypt", "--input-type", "yaml", "--output-type", "yaml", fileName},
+		Out:  out,
+		Err:  errOut,
+	}
+	if runner == nil {
+		runner = func(cmd *util.Command) (string, error) {
+			return cmd.RunWithoutRetry()
+		}
+	}
+	text, err := runner(cmd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt the SOPS values file %s: %s", fileName, strings.TrimSpace(errOut.String()))
+	}
+	if out.Len() > 0 {
+		return out.Bytes(), nil
+	}
+	return []byte(text), nil
+}
+
+// SopsValues holds the decrypted values of SOPS encrypted values files in a private directory on a memory backed file
+// system so that helm can read them like any other values file without the decrypted values being written to disk
+type SopsValues struct {
+	dir string
+}
+
+// NewSopsValues creates the private directory for the decrypted values in the memory backed directory specified by
+// $JX_SOPS_VALUES_DIR which defaults to '/dev/shm'
+func NewSopsValues() (*SopsValues, error) {
+	root := os.Getenv(SopsValuesDirEnvVar)
+	if root == "" {
+		root = DefaultSopsValuesDir
+	}
+	return NewSopsValuesInDir(root)
+}
+
+// NewSopsValuesInDir creates the private directory for the decrypted values in the given memory backed directory
+func NewSopsValuesInDir(root string) (*SopsValues, error) {
+	exists, err := util.DirExists(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if dir exists %s", root)
+	}
+	if !exists {
+		return nil, errors.Errorf("there is no memory backed directory %s to hold the decrypted SOPS values so please set $%s to a tmpfs directory", root, SopsValuesDirEnvVar)
+	}
+	dir, err := ioutil.TempDir(root, sopsValuesDirPrefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a directory for the decrypted SOPS values in %s", root)
+	}
+	return &SopsValues{dir: dir}, nil
+}
+
+// DecryptValuesFiles returns the values files replacing each SOPS encrypted values file with a file containing its
+// decrypted values in the memory backed directory. The order of the values files is preserved
+func (s *SopsValues) DecryptValuesFiles(valuesFiles []string, runner func(cmd *util.Command) (string, error)) ([]string, error) {
+	answer := []string{}
+	for _, valuesFile := range valuesFiles {
+		if !IsSopsFile(valuesFile) {
+			answer = append(answer, valuesFile)
+			continue
+		}
+		data, err := DecryptSopsFile(valuesFile, runner)
+		if err != nil {
+			return nil, err
+		}
+		_, err = LoadValues(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid YAML in the decrypted SOPS values file %s", valuesFile)
+		}
+
+		// lets keep the name of the values file so that errors reported by helm are easy to understand but without
+		// the '.sops' part as the file contains the decrypted values
+		name := strings.Replace(filepath.Base(valuesFile), ".sops.", ".", 1)
+		f, err := ioutil.TempFile(s.dir, "*-"+name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create a file for the decrypted SOPS values file %s", valuesFile)
+		}
+		_, err = f.Write(data)
+		if err == nil {
+			err = f.Close()
+		} else {
+			f.Close() //nolint:errcheck
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to write the decrypted SOPS values file %s", valuesFile)
+		}
+		log.Logger().Debugf("decrypted the SOPS values file %s", valuesFile)
+		answer = append(answer, f.Name())
+	}
+	return answer, nil
+}
+
+// Close removes the decrypted values
+func (s *SopsValues) Close() error {
+	if s == nil {
+		return nil
+	}
+	return os.RemoveAll(s.dir)
+}
+
+// IsDecryptedSopsValuesFile returns true if the file contains decrypted SOPS values in a memory backed directory so
+// that any copies of it should also be kept in that directory
+func IsDecryptedSopsValuesFile(fileName string) bool {
+	return strings.HasPrefix(filepath.Base(filepath.Dir(fileName)), sopsValuesDirPrefix)
+}
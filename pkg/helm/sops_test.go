@@ -0,0 +1,66 @@
+// +build unit
+
+package helm_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSopsValuesDecryptValuesFiles(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "test-helm-sops-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	commands := [][]string{}
+	runner := func(cmd *util.Command) (string, error) {
+		commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+		_, err := cmd.Out.Write([]byte("db:\n  password: s3cr3t\n"))
+		return "", err
+	}
+
+	sopsValues, err := helm.NewSopsValuesInDir(root)
+	require.NoError(t, err)
+
+	encrypted := filepath.Join("test_data", "secrets.sops.yaml")
+	valuesFiles, err := sopsValues.DecryptValuesFiles([]string{"values.yaml", encrypted, "myvalues.yaml"}, runner)
+	require.NoError(t, err)
+	require.Len(t, valuesFiles, 3)
+	assert.Equal(t, "values.yaml", valuesFiles[0])
+	assert.Equal(t, "myvalues.yaml", valuesFiles[2], "should preserve the order of the values files")
+	assert.Equal(t, [][]string{{"sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", encrypted}}, commands)
+
+	decrypted := valuesFiles[1]
+	assert.Equal(t, root, filepath.Dir(filepath.Dir(decrypted)), "the decrypted values should be in the memory backed dir")
+	assert.True(t, helm.IsDecryptedSopsValuesFile(decrypted))
+	assert.False(t, helm.IsSopsFile(decrypted), "the decrypted values file %s should not look encrypted", decrypted)
+	data, err := ioutil.ReadFile(decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "db:\n  password: s3cr3t\n", string(data))
+
+	err = sopsValues.Close()
+	require.NoError(t, err)
+	_, err = os.Stat(decrypted)
+	assert.True(t, os.IsNotExist(err), "should have removed the decrypted values")
+
+	_, err = helm.NewSopsValuesInDir(filepath.Join(root, "does-not-exist"))
+	require.Error(t, err, "should fail if there is no memory backed directory")
+}
+
+func TestIsSopsFile(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, helm.IsSopsFile("env/secrets.sops.yaml"))
+	assert.True(t, helm.IsSopsFile("values.sops.yml"))
+	assert.False(t, helm.IsSopsFile("secrets.yaml"))
+	assert.False(t, helm.IsSopsFile("sops.yaml"))
+}
@@ -0,0 +1,209 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+var valuesReferenceRegex = regexp.MustCompile(`\.Values((?:\.[A-Za-z0-9_\-]+)*)`)
+
+// FindUnusedValues returns the leaf paths of the given values which do not appear to be referenced by any template
+// in the chart directory or its sub charts (either exploded or as '.tgz' archives).
+//
+// This is a best effort static scan of the `.Values.foo.bar` expressions in the templates; values accessed
+// dynamically (e.g. via `index` or `$key`) will be reported as unused.
+func FindUnusedValues(chartDir string, values map[string]interface{}) ([]string, error) {
+	aliases, err := subChartAliases(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	references := map[string]bool{}
+	addReferences := func(prefixes []string, text string) {
+		for _, prefix := range prefixes {
+			for _, match := range valuesReferenceRegex.FindAllStringSubmatch(text, -1) {
+				path := match[1]
+				// global values are shared with all the sub charts
+				if path == ".global" || strings.HasPrefix(path, ".global.") {
+					references[strings.TrimPrefix(path, ".")] = true
+				}
+				references[strings.TrimPrefix(prefix+path, ".")] = true
+			}
+		}
+	}
+
+	err = filepath.Walk(chartDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(chartDir, path)
+		if err != nil {
+			return err
+		}
+		segments := strings.Split(rel, string(os.PathSeparator))
+		if strings.HasSuffix(path, ".tgz") {
+			// the archive entries start with the sub chart name so replace the archive file name with them
+			dirSegments := segments[:len(segments)-1]
+			return scanChartArchive(path, func(name string, text string) {
+				prefix, ok := templateValuesPrefix(append(append([]string{}, dirSegments...), strings.Split(name, "/")...))
+				if ok {
+					addReferences(aliasPrefixes(prefix, aliases), text)
+				}
+			})
+		}
+		prefix, ok := templateValuesPrefix(segments)
+		if !ok {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read template %s", path)
+		}
+		addReferences(aliasPrefixes(prefix, aliases), string(data))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan templates in %s", chartDir)
+	}
+
+	unused := []string{}
+	for _, leaf := range valuesLeafPaths("", values) {
+		if !isValuesPathReferenced(leaf, references) {
+			unused = append(unused, leaf)
+		}
+	}
+	sort.Strings(unused)
+	return unused, nil
+}
+
+// templateValuesPrefix returns the values path prefix for a template file given the path segments relative to the
+// top level chart directory. e.g. `templates/foo.yaml` has no prefix whereas `charts/bar/templates/foo.yaml` has the
+// prefix `.bar`. Returns false if the segments do not refer to a template file
+func templateValuesPrefix(segments []string) (string, bool) {
+	prefix := ""
+	for i := 0; i < len(segments); i++ {
+		switch segments[i] {
+		case ".", "":
+			continue
+		case TemplatesDirName:
+			return prefix, i < len(segments)-1
+		case "charts":
+			if i+1 >= len(segments) {
+				return "", false
+			}
+			i++
+			prefix += "." + segments[i]
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// scanChartArchive invokes the callback for each file inside a chart archive with the path of the file relative to
+// the root of the archive, which starts with the chart name
+func scanChartArchive(fileName string, fn func(name string, text string)) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open chart archive %s", fileName)
+	}
+	defer f.Close() //nolint:errcheck
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to uncompress chart archive %s", fileName)
+	}
+	defer gz.Close() //nolint:errcheck
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read chart archive %s", fileName)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s in chart archive %s", header.Name, fileName)
+		}
+		fn(header.Name, string(data))
+	}
+}
+
+// subChartAliases returns the aliases of the dependencies of the chart indexed by the dependency name
+func subChartAliases(chartDir string) (map[string][]string, error) {
+	answer := map[string][]string{}
+	fileName := filepath.Join(chartDir, RequirementsFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return answer, err
+	}
+	req, err := LoadRequirementsFile(fileName)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to load %s", fileName)
+	}
+	for _, dep := range req.Dependencies {
+		if dep.Alias != "" {
+			answer[dep.Name] = append(answer[dep.Name], dep.Alias)
+		}
+	}
+	return answer, nil
+}
+
+// aliasPrefixes returns the prefixes a sub chart's values may be found under taking into account dependency aliases
+func aliasPrefixes(prefix string, aliases map[string][]string) []string {
+	parts := strings.SplitN(strings.TrimPrefix(prefix, "."), ".", 2)
+	names := aliases[parts[0]]
+	if prefix == "" || len(names) == 0 {
+		return []string{prefix}
+	}
+	answer := []string{}
+	for _, name := range names {
+		p := "." + name
+		if len(parts) > 1 {
+			p += "." + parts[1]
+		}
+		answer = append(answer, p)
+	}
+	return answer
+}
+
+// valuesLeafPaths returns the dotted paths of all the non map values in the given values
+func valuesLeafPaths(prefix string, values map[string]interface{}) []string {
+	answer := []string{}
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		m, ok := value.(map[string]interface{})
+		if ok && len(m) > 0 {
+			answer = append(answer, valuesLeafPaths(path, m)...)
+		} else {
+			answer = append(answer, path)
+		}
+	}
+	return answer
+}
+
+// isValuesPathReferenced returns true if the path, one of its parents or one of its children is referenced
+func isValuesPathReferenced(path string, references map[string]bool) bool {
+	for ref := range references {
+		if ref == "" || ref == path || strings.HasPrefix(path, ref+".") || strings.HasPrefix(ref, path+".") {
+			return true
+		}
+	}
+	return false
+}
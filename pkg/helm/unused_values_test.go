@@ -0,0 +1,39 @@
+// +build unit
+
+package helm_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUnusedValues(t *testing.T) {
+	t.Parallel()
+
+	values, err := helm.LoadValues([]byte(`name: app
+replicaCount: 2
+unusedKey: true
+image:
+  repository: acme/app
+  tag: 1.0.0
+  pullPolicy: Always
+resources:
+  limits:
+    cpu: 100m
+global:
+  domain: acme.com
+db:
+  serviceName: db
+  password: secret
+`))
+	require.NoError(t, err)
+
+	unused, err := helm.FindUnusedValues(path.Join("test_data", "unused_values"), values)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"db.password", "image.pullPolicy", "unusedKey"}, unused)
+}
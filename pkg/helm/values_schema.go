@@ -0,0 +1,98 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValuesSchemaFileName the name of the JSON schema file a chart can provide to validate its values
+const ValuesSchemaFileName = "values.schema.json"
+
+// ValuesSchemaViolation a single key of the values which does not conform to the schema
+type ValuesSchemaViolation struct {
+	Path        string
+	Description string
+}
+
+// ValuesSchemaError the error returned when the values do not conform to the schema
+type ValuesSchemaError struct {
+	SchemaFile string
+	Violations []ValuesSchemaViolation
+}
+
+// Error lists each of the violations of the schema on its own line
+func (e *ValuesSchemaError) Error() string {
+	lines := []string{fmt.Sprintf("the values do not conform to the schema %s:", e.SchemaFile)}
+	for _, v := range e.Violations {
+		lines = append(lines, fmt.Sprintf("  - %s: %s", v.Path, v.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MergeValuesFiles loads the values files and merges them in order, like helm does, so that later files override
+// earlier ones and a null value removes the key
+func MergeValuesFiles(valuesFiles ...string) (map[string]interface{}, error) {
+	answer := map[string]interface{}{}
+	for _, valuesFile := range valuesFiles {
+		values, err := LoadValuesFile(valuesFile)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to load values file %s", valuesFile)
+		}
+		mergeValues(answer, values)
+	}
+	return answer, nil
+}
+
+// ValidateValuesSchema validates the values against the JSON schema file returning a *ValuesSchemaError listing each
+// of the keys which violate the schema
+func ValidateValuesSchema(schemaFile string, values map[string]interface{}) error {
+	data, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load schema file %s", schemaFile)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(data), gojsonschema.NewGoLoader(values))
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate the values against the schema %s", schemaFile)
+	}
+	if result.Valid() {
+		return nil
+	}
+	answer := &ValuesSchemaError{
+		SchemaFile: schemaFile,
+	}
+	for _, e := range result.Errors() {
+		answer.Violations = append(answer.Violations, ValuesSchemaViolation{
+			Path:        e.Field(),
+			Description: e.Description(),
+		})
+	}
+	sort.SliceStable(answer.Violations, func(i, j int) bool {
+		return answer.Violations[i].Path < answer.Violations[j].Path
+	})
+	return answer
+}
+
+// ValidateChartValuesSchema validates the merged values files against the 'values.schema.json' file in the chart
+// directory. Charts without a schema file are not validated
+func ValidateChartValuesSchema(chartDir string, valuesFiles ...string) error {
+	schemaFile := filepath.Join(chartDir, ValuesSchemaFileName)
+	exists, err := util.FileExists(schemaFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", schemaFile)
+	}
+	if !exists {
+		return nil
+	}
+	values, err := MergeValuesFiles(valuesFiles...)
+	if err != nil {
+		return err
+	}
+	return ValidateValuesSchema(schemaFile, values)
+}
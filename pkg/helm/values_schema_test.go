@@ -0,0 +1,54 @@
+// +build unit
+
+package helm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeValuesFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("test_data", "values_schema")
+	values, err := helm.MergeValuesFiles(filepath.Join(dir, "values.yaml"), filepath.Join(dir, "myvalues.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": float64(1),
+		"replicacount": float64(2),
+		"image": map[string]interface{}{
+			"repository": "acme/app",
+			"tag":        float64(2),
+		},
+	}, values)
+}
+
+func TestValidateChartValuesSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("test_data", "values_schema")
+	err := helm.ValidateChartValuesSchema(dir, filepath.Join(dir, "values.yaml"))
+	assert.NoError(t, err, "the chart values should conform to the schema")
+
+	err = helm.ValidateChartValuesSchema(dir, filepath.Join(dir, "values.yaml"), filepath.Join(dir, "myvalues.yaml"))
+	require.Error(t, err)
+	schemaErr, ok := err.(*helm.ValuesSchemaError)
+	require.True(t, ok, "should have returned a *helm.ValuesSchemaError but got %#v", err)
+	require.Len(t, schemaErr.Violations, 2)
+	assert.Contains(t, schemaErr.Violations[0].Description, "replicacount")
+	assert.Equal(t, "image.tag", schemaErr.Violations[1].Path)
+	assert.Contains(t, err.Error(), "image.tag")
+}
+
+func TestValidateChartValuesSchemaWithoutSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("test_data", "unused_values")
+	err := helm.ValidateChartValuesSchema(dir, filepath.Join("test_data", "values_schema", "myvalues.yaml"))
+	assert.NoError(t, err, "charts without a schema should not be validated")
+}
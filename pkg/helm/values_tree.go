@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -27,6 +29,8 @@ var DefaultValuesTreeIgnores = []string{
 	"templates/*",
 }
 
+var unknownFieldRegex = regexp.MustCompile(`can't evaluate field (\w+) in type (\S+)`)
+
 // GenerateValues will generate a values.yaml file in dir. It scans all subdirectories for values.yaml files,
 // and merges them into the values.yaml in the root directory,
 // creating a nested key structure that matches the directory structure.
@@ -34,6 +38,12 @@ var DefaultValuesTreeIgnores = []string{
 // and have empty values) will be inlined as block scalars.
 // Standard UNIX glob patterns can be passed to IgnoreFile directories.
 func GenerateValues(requirements *config.RequirementsConfig, funcMap template.FuncMap, dir string, ignores []string, verbose bool, secretURLClient secreturl.Client) ([]byte, chartutil.Values, error) {
+	return GenerateEnvironmentValues(requirements, "", funcMap, dir, ignores, verbose, secretURLClient)
+}
+
+// GenerateEnvironmentValues generates the values.yaml file in dir in the same way as GenerateValues rendering the
+// templates with the environment of the given key as the '.Environment' of the ValuesTemplateContext
+func GenerateEnvironmentValues(requirements *config.RequirementsConfig, envKey string, funcMap template.FuncMap, dir string, ignores []string, verbose bool, secretURLClient secreturl.Client) ([]byte, chartutil.Values, error) {
 	info, err := os.Stat(dir)
 	if err != nil {
 		return nil, nil, err
@@ -70,7 +80,7 @@ func GenerateValues(requirements *config.RequirementsConfig, funcMap template.Fu
 			if rDir != "" {
 				// If it's values.tmpl.yaml, then evalate it as a go template and parse it
 				if file == ValuesTemplateFileName {
-					b, err := ReadValuesYamlFileTemplateOutput(path, params, funcMap, requirements)
+					b, err := readValuesYamlFileTemplateOutput(path, params, funcMap, requirements, envKey)
 					if err != nil {
 						return err
 					}
@@ -126,7 +136,7 @@ func GenerateValues(requirements *config.RequirementsConfig, funcMap template.Fu
 		return nil, params, errors.Wrapf(err, "failed to find %s", rootValuesFileName)
 	}
 	if exists {
-		rootData, err = ReadValuesYamlFileTemplateOutput(rootValuesFileName, params, funcMap, requirements)
+		rootData, err = readValuesYamlFileTemplateOutput(rootValuesFileName, params, funcMap, requirements, envKey)
 		if err != nil {
 			return nil, params, errors.Wrapf(err, "failed to render template of file %s", rootValuesFileName)
 		}
@@ -223,30 +233,141 @@ func NewFunctionMap() template.FuncMap {
 
 // ReadValuesYamlFileTemplateOutput evaluates the given values.yaml file as a go template and returns the output data
 func ReadValuesYamlFileTemplateOutput(templateFile string, params chartutil.Values, funcMap template.FuncMap, requirements *config.RequirementsConfig) ([]byte, error) {
+	return readValuesYamlFileTemplateOutput(templateFile, params, funcMap, requirements, "")
+}
+
+func readValuesYamlFileTemplateOutput(templateFile string, params chartutil.Values, funcMap template.FuncMap, requirements *config.RequirementsConfig, envKey string) ([]byte, error) {
 	tmpl, err := template.New(ValuesTemplateFileName).Option("missingkey=error").Funcs(funcMap).ParseFiles(templateFile)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse Secrets template: %s", templateFile)
 	}
+	return executeValuesTemplate(tmpl, templateFile, params, requirements, envKey)
+}
 
-	requirementsMap, err := requirements.ToMap()
+// ReadValuesYamlTemplateOutput evaluates the given values template text, e.g. loaded from a ConfigMap, with the given
+// parameters and requirements in the same way as ReadValuesYamlFileTemplateOutput. The name is used in error messages
+func ReadValuesYamlTemplateOutput(name string, text string, params chartutil.Values, funcMap template.FuncMap, requirements *config.RequirementsConfig) ([]byte, error) {
+	tmpl, err := template.New(ValuesTemplateFileName).Option("missingkey=error").Funcs(funcMap).Parse(text)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed turn requirements into a map: %v", requirements)
+		return nil, errors.Wrapf(err, "failed to parse Secrets template: %s", name)
 	}
+	return executeValuesTemplate(tmpl, name, params, requirements, "")
+}
 
-	templateData := map[string]interface{}{
-		"Parameters":   params,
-		"Requirements": chartutil.Values(requirementsMap),
-		"Environments": chartutil.Values(requirements.EnvironmentMap()),
+func executeValuesTemplate(tmpl *template.Template, name string, params chartutil.Values, requirements *config.RequirementsConfig, envKey string) ([]byte, error) {
+	templateContext, err := NewValuesTemplateContext(params, requirements, envKey)
+	if err != nil {
+		return nil, err
 	}
 	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, templateData)
+	err = tmpl.Execute(&buf, templateContext)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to execute Secrets template: %s", templateFile)
+		return nil, errors.Wrapf(unknownFieldError(err), "failed to execute Secrets template: %s", name)
 	}
 	data := buf.Bytes()
 	return data, nil
 }
 
+// ValuesTemplateContext the data the values.tmpl.yaml templates are rendered with. The maps keyed by the JSON names
+// such as '{{ .Requirements.cluster.clusterName }}' are kept for existing templates while the typed fields such as
+// '{{ .Cluster.ClusterName }}' are checked when the template is rendered
+type ValuesTemplateContext struct {
+	// Parameters the parameters of the chart loaded from the parameters.yaml file
+	Parameters chartutil.Values
+	// Requirements the requirements as a map keyed by the JSON names of the fields
+	Requirements chartutil.Values
+	// Environments the environments of the requirements keyed by their key
+	Environments chartutil.Values
+	// Cluster the cluster requirements
+	Cluster config.ClusterConfig
+	// Environment the environment being applied or the dev environment if none is specified
+	Environment config.EnvironmentConfig
+	// GitOps whether the boot configuration is applied via GitOps
+	GitOps bool
+	// VersionStream the version stream of the requirements
+	VersionStream config.VersionStreamConfig
+}
+
+// NewValuesTemplateContext creates the template context for the given parameters and requirements using the environment
+// with the given key or the dev environment if the key is blank. The environment is empty if the requirements do not
+// contain it
+func NewValuesTemplateContext(params chartutil.Values, requirements *config.RequirementsConfig, envKey string) (*ValuesTemplateContext, error) {
+	requirementsMap, err := requirements.ToMap()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed turn requirements into a map: %v", requirements)
+	}
+	if envKey == "" {
+		envKey = "dev"
+	}
+	templateContext := &ValuesTemplateContext{
+		Parameters:    params,
+		Requirements:  chartutil.Values(requirementsMap),
+		Environments:  chartutil.Values(requirements.EnvironmentMap()),
+		Cluster:       requirements.Cluster,
+		GitOps:        requirements.GitOps,
+		VersionStream: requirements.VersionStream,
+	}
+	env, err := requirements.Environment(envKey)
+	if err == nil && env != nil {
+		templateContext.Environment = *env
+	}
+	return templateContext, nil
+}
+
+// unknownFieldError returns a more helpful error if the template used a field which does not exist in the typed
+// template context listing the fields of the type and the field which was probably meant
+func unknownFieldError(err error) error {
+	match := unknownFieldRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	field := match[1]
+	t := templateContextTypes()[match[2]]
+	if t == nil {
+		return err
+	}
+	names := []string{}
+	suggestion := ""
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		names = append(names, f.Name)
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		if strings.EqualFold(f.Name, field) || strings.EqualFold(jsonName, field) {
+			suggestion = f.Name
+		}
+	}
+	message := fmt.Sprintf("unknown field %s of %s which has the fields %s", field, match[2], strings.Join(names, ", "))
+	if suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+	return errors.Wrap(err, message)
+}
+
+// templateContextTypes returns the struct types which are reachable from the fields of the template context keyed
+// by the name text/template uses in its errors
+func templateContextTypes() map[string]reflect.Type {
+	answer := map[string]reflect.Type{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || answer[t.String()] != nil {
+			return
+		}
+		answer[t.String()] = t
+		answer[reflect.PtrTo(t).String()] = t
+		for i := 0; i < t.NumField(); i++ {
+			walk(t.Field(i).Type)
+		}
+	}
+	walk(reflect.TypeOf(ValuesTemplateContext{}))
+	return answer
+}
+
 // HandleExternalFileRefs recursively scans the element map structure,
 // looking for nested maps. If it finds keys that match any key-value pair in possibles it will call the handler.
 // The jsonPath is used for referencing the path in the map structure when reporting errors.
@@ -10,6 +10,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/helm"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl/localvault"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var expectedTemplatedValuesTree = `JenkinsXGitHub:
@@ -36,3 +37,39 @@ func TestValuesTreeTemplates(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTemplatedValuesTree, string(result))
 }
+
+func TestValuesTemplateContext(t *testing.T) {
+	t.Parallel()
+
+	requirements := config.NewRequirementsConfig()
+	requirements.Cluster.ClusterName = "mycluster"
+	requirements.GitOps = true
+	requirements.VersionStream.Ref = "v1.0.100"
+	requirements.Environments = []config.EnvironmentConfig{
+		{Key: "dev", Repository: "environment-dev"},
+		{Key: "staging", Repository: "environment-staging"},
+	}
+	text := `cluster: {{ .Cluster.ClusterName }}
+legacy: {{ .Requirements.cluster.clusterName }}
+env: {{ .Environment.Repository }}
+gitops: {{ .GitOps }}
+ref: {{ .VersionStream.Ref | upper }}
+`
+	data, err := helm.ReadValuesYamlTemplateOutput("values.tmpl.yaml", text, nil, helm.NewFunctionMap(), requirements)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster: mycluster\nlegacy: mycluster\nenv: environment-dev\ngitops: true\nref: V1.0.100\n", string(data))
+
+	templateContext, err := helm.NewValuesTemplateContext(nil, requirements, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, "environment-staging", templateContext.Environment.Repository)
+
+	_, err = helm.ReadValuesYamlTemplateOutput("values.tmpl.yaml", "cluster: {{ .Cluster.clusterName }}\n", nil, helm.NewFunctionMap(), requirements)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field clusterName of config.ClusterConfig which has the fields ")
+	assert.Contains(t, err.Error(), ", did you mean ClusterName?")
+
+	_, err = helm.ReadValuesYamlTemplateOutput("values.tmpl.yaml", "ingress: {{ .Environment.Ingress.Domian }}\n", nil, helm.NewFunctionMap(), requirements)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field Domian of config.IngressConfig which has the fields ExternalDNS, CloudDNSSecretName, Domain")
+	assert.NotContains(t, err.Error(), "did you mean")
+}
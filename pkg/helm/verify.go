@@ -0,0 +1,253 @@
+package helm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ProvenanceFileExtension the extension of the provenance file signing a chart archive
+	ProvenanceFileExtension = ".prov"
+
+	// OCIChartPrefix the prefix of chart references in an OCI registry
+	OCIChartPrefix = "oci://"
+)
+
+// ChartVerifier verifies the signatures of charts using their provenance files and a keyring or, for charts in an
+// OCI registry, using cosign
+type ChartVerifier struct {
+	// HelmBinary the helm executable used to verify the provenance files
+	HelmBinary string
+	// Keyring the optional keyring containing the public keys. Defaults to the default keyring of helm
+	Keyring string
+	// CosignKey the optional public key used to verify the cosign signatures of OCI charts. If blank keyless
+	// verification is used
+	CosignKey string
+	// Runner runs the commands. Defaults to running them without retries
+	Runner func(cmd *util.Command) (string, error)
+}
+
+// VerifyChartArchive verifies the chart archive using the provenance file next to it
+func (v *ChartVerifier) VerifyChartArchive(tarball string) error {
+	provFile := tarball + ProvenanceFileExtension
+	exists, err := util.FileExists(provFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", provFile)
+	}
+	if !exists {
+		return errors.Errorf("the chart archive %s is not signed as there is no provenance file %s", tarball, provFile)
+	}
+	args := append([]string{"verify", tarball}, v.keyringArgs()...)
+	_, err = v.run(&util.Command{
+		Name: v.helmBinary(),
+		Args: args,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify the signature of chart archive %s", tarball)
+	}
+	log.Logger().Infof("Verified the signature of chart archive %s", util.ColorInfo(tarball))
+	return nil
+}
+
+// VerifyChartDependencies verifies each of the dependency archives in the 'charts' directory of the chart directory
+func (v *ChartVerifier) VerifyChartDependencies(dir string) error {
+	tarballs, err := filepath.Glob(filepath.Join(dir, "charts", "*.tgz"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the dependency archives of chart %s", dir)
+	}
+	sort.Strings(tarballs)
+	errs := []error{}
+	for _, tarball := range tarballs {
+		errs = append(errs, v.VerifyChartArchive(tarball))
+	}
+	return util.CombineErrors(errs...)
+}
+
+// FetchProvenanceFiles downloads the provenance file of each dependency archive in the 'charts' directory of the chart
+// directory which has none from the chart repository of the dependency so that the archives can be verified
+func FetchProvenanceFiles(httpClient *http.Client, dir string, deps []*Dependency) error {
+	indexes := map[string]*ChartIndex{}
+	errs := []error{}
+	for _, dep := range deps {
+		repo := dep.Repository
+		if !strings.HasPrefix(repo, "http://") && !strings.HasPrefix(repo, "https://") {
+			continue
+		}
+		index := indexes[repo]
+		if index == nil {
+			var err error
+			index, err = FetchChartIndex(httpClient, repo)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			indexes[repo] = index
+		}
+		for _, entry := range index.Entries[dep.Name] {
+			if len(entry.URLs) == 0 {
+				continue
+			}
+			chartURL, err := resolveChartURL(repo, entry.URLs[0])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			tarball := filepath.Join(dir, "charts", path.Base(chartURL.Path))
+			exists, err := util.FileExists(tarball)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to check if file exists %s", tarball))
+				continue
+			}
+			if !exists {
+				continue
+			}
+			provFile := tarball + ProvenanceFileExtension
+			exists, err = util.FileExists(provFile)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to check if file exists %s", provFile))
+				continue
+			}
+			if exists {
+				continue
+			}
+			chartURL.Path += ProvenanceFileExtension
+			errs = append(errs, downloadFile(httpClient, chartURL.String(), provFile))
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
+// resolveChartURL resolves the URL of a chart archive in a chart repository index which may be relative to the
+// repository URL
+func resolveChartURL(repo string, chartURL string) (*url.URL, error) {
+	base, err := url.Parse(strings.TrimSuffix(repo, "/") + "/")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse chart repository URL %s", repo)
+	}
+	u, err := base.Parse(chartURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse chart URL %s of chart repository %s", chartURL, repo)
+	}
+	return u, nil
+}
+
+func downloadFile(httpClient *http.Client, u string, fileName string) error {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return errors.Wrapf(err, "failed to GET %s", u)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to GET %s due to status %s", u, resp.Status)
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file %s", fileName)
+	}
+	defer f.Close() //nolint:errcheck
+	_, err = io.Copy(f, resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s to %s", u, fileName)
+	}
+	return nil
+}
+
+// VerifyChart verifies the chart which may be a local chart archive or directory, an OCI chart reference or a chart
+// in a chart repository which is fetched with its provenance file to verify it
+func (v *ChartVerifier) VerifyChart(chart string, version string, repo string, username string, password string) error {
+	if strings.HasPrefix(chart, OCIChartPrefix) {
+		return v.VerifyOCIChart(chart, version)
+	}
+	info, err := os.Stat(chart)
+	if err == nil {
+		if info.IsDir() {
+			return v.VerifyChartDependencies(chart)
+		}
+		return v.VerifyChartArchive(chart)
+	}
+
+	dir, err := ioutil.TempDir("", "jx-helm-verify-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory to fetch the chart")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	args := []string{"fetch", chart, "--verify", "--destination", dir}
+	args = append(args, v.keyringArgs()...)
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if repo != "" {
+		args = append(args, "--repo", repo)
+	}
+	if username != "" {
+		args = append(args, "--username", username)
+	}
+	if password != "" {
+		args = append(args, "--password", password)
+	}
+	_, err = v.run(&util.Command{
+		Name: v.helmBinary(),
+		Args: args,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify the signature of chart %s", chart)
+	}
+	log.Logger().Infof("Verified the signature of chart %s", util.ColorInfo(chart))
+	return nil
+}
+
+// VerifyOCIChart verifies the cosign signature of the chart in an OCI registry
+func (v *ChartVerifier) VerifyOCIChart(chart string, version string) error {
+	ref := strings.TrimPrefix(chart, OCIChartPrefix)
+	if version != "" && !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		ref += ":" + version
+	}
+	args := []string{"verify"}
+	if v.CosignKey != "" {
+		args = append(args, "--key", v.CosignKey)
+	}
+	args = append(args, ref)
+	_, err := v.run(&util.Command{
+		Name: "cosign",
+		Args: args,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify the cosign signature of chart %s", chart)
+	}
+	log.Logger().Infof("Verified the cosign signature of chart %s", util.ColorInfo(chart))
+	return nil
+}
+
+func (v *ChartVerifier) keyringArgs() []string {
+	if v.Keyring == "" {
+		return nil
+	}
+	return []string{"--keyring", v.Keyring}
+}
+
+func (v *ChartVerifier) helmBinary() string {
+	if v.HelmBinary == "" {
+		return "helm"
+	}
+	return v.HelmBinary
+}
+
+func (v *ChartVerifier) run(cmd *util.Command) (string, error) {
+	if v.Runner != nil {
+		return v.Runner(cmd)
+	}
+	return cmd.RunWithoutRetry()
+}
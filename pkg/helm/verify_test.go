@@ -0,0 +1,120 @@
+// +build unit
+
+package helm_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartVerifier(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-helm-verify-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chartsDir := filepath.Join(dir, "charts")
+	require.NoError(t, os.MkdirAll(chartsDir, util.DefaultWritePermissions))
+	for _, name := range []string{"foo-1.0.0.tgz", "foo-1.0.0.tgz.prov", "bar-2.0.0.tgz", "bar-2.0.0.tgz.prov"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(chartsDir, name), []byte("test"), util.DefaultWritePermissions))
+	}
+
+	commands := [][]string{}
+	verifier := &helm.ChartVerifier{
+		HelmBinary: "helm3",
+		Keyring:    "/keys/pubring.gpg",
+		CosignKey:  "cosign.pub",
+		Runner: func(cmd *util.Command) (string, error) {
+			commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+			if util.StringArrayIndex(cmd.Args, "jenkins-x/unsigned") >= 0 {
+				return "", errors.New("Error: failed to fetch provenance")
+			}
+			return "", nil
+		},
+	}
+
+	err = verifier.VerifyChartDependencies(dir)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"helm3", "verify", filepath.Join(chartsDir, "bar-2.0.0.tgz"), "--keyring", "/keys/pubring.gpg"},
+		{"helm3", "verify", filepath.Join(chartsDir, "foo-1.0.0.tgz"), "--keyring", "/keys/pubring.gpg"},
+	}, commands)
+
+	require.NoError(t, os.Remove(filepath.Join(chartsDir, "foo-1.0.0.tgz.prov")))
+	err = verifier.VerifyChartDependencies(dir)
+	require.Error(t, err, "should fail if a dependency has no provenance file")
+	assert.Contains(t, err.Error(), "foo-1.0.0.tgz is not signed")
+
+	commands = nil
+	err = verifier.VerifyChart("jenkins-x/foo", "1.0.0", "", "", "")
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	assert.Equal(t, []string{"helm3", "fetch", "jenkins-x/foo", "--verify"}, commands[0][:4])
+	assert.Equal(t, []string{"--keyring", "/keys/pubring.gpg", "--version", "1.0.0"}, commands[0][6:])
+
+	err = verifier.VerifyChart("jenkins-x/unsigned", "1.0.0", "", "", "")
+	require.Error(t, err, "should fail if the chart cannot be verified")
+
+	commands = nil
+	err = verifier.VerifyChart("oci://gcr.io/myproject/charts/foo", "1.0.0", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"cosign", "verify", "--key", "cosign.pub", "gcr.io/myproject/charts/foo:1.0.0"}}, commands)
+}
+
+func TestFetchProvenanceFiles(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`apiVersion: v1
+entries:
+  foo:
+  - name: foo
+    version: 1.0.0
+    urls:
+    - charts/foo-1.0.0.tgz
+  - name: foo
+    version: 0.9.0
+    urls:
+    - charts/foo-0.9.0.tgz
+`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/charts/foo-1.0.0.tgz.prov", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("signature")) //nolint:errcheck
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "test-helm-fetch-prov-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chartsDir := filepath.Join(dir, "charts")
+	require.NoError(t, os.MkdirAll(chartsDir, util.DefaultWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(chartsDir, "foo-1.0.0.tgz"), []byte("test"), util.DefaultWritePermissions))
+
+	deps := []*helm.Dependency{
+		{Name: "foo", Version: "1.0.0", Repository: server.URL},
+		{Name: "bar", Version: "1.0.0", Repository: "file://../bar"},
+	}
+	err = helm.FetchProvenanceFiles(server.Client(), dir, deps)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(chartsDir, "foo-1.0.0.tgz.prov"))
+	require.NoError(t, err)
+	assert.Equal(t, "signature", string(data))
+	exists, err := util.FileExists(filepath.Join(chartsDir, "foo-0.9.0.tgz.prov"))
+	require.NoError(t, err)
+	assert.False(t, exists, "should only fetch the provenance files of the fetched archives")
+}
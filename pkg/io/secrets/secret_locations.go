@@ -24,6 +24,15 @@ const (
 	KubeLocationKind SecretsLocationKind = "kube"
 	// AutoLocationKind indicates that secrets location needs to be dynamically determine
 	AutoLocationKind SecretsLocationKind = "auto"
+	// AWSSecretsManagerLocationKind indicates that secrets location is AWS Secrets Manager
+	AWSSecretsManagerLocationKind SecretsLocationKind = "aws-secrets-manager"
+	// GCPSecretManagerLocationKind indicates that secrets location is GCP Secret Manager
+	GCPSecretManagerLocationKind SecretsLocationKind = "gcp-secret-manager"
+	// AzureKeyVaultLocationKind indicates that secrets location is Azure Key Vault
+	AzureKeyVaultLocationKind SecretsLocationKind = "azure-key-vault"
+	// ExternalSecretsLocationKind indicates that secrets are applied as ExternalSecrets populated by the external
+	// secrets operator
+	ExternalSecretsLocationKind SecretsLocationKind = "externalSecrets"
 )
 
 // SecretLocation interfaces to identify where is the secrets location
@@ -98,6 +107,14 @@ func ToSecretsLocation(location string) SecretsLocationKind {
 		return VaultLocationKind
 	case "kube":
 		return KubeLocationKind
+	case "aws-secrets-manager":
+		return AWSSecretsManagerLocationKind
+	case "gcp-secret-manager":
+		return GCPSecretManagerLocationKind
+	case "azure-key-vault":
+		return AzureKeyVaultLocationKind
+	case "externalSecrets":
+		return ExternalSecretsLocationKind
 	default:
 		return AutoLocationKind
 	}
@@ -122,6 +122,13 @@ type PipelineConfig struct {
 	Environment      string            `json:"environment,omitempty"`
 	Pipelines        Pipelines         `json:"pipelines,omitempty"`
 	ContainerOptions *corev1.Container `json:"containerOptions,omitempty"`
+	Caches           []string          `json:"caches,omitempty"`
+	// Platforms the platforms such as linux/amd64 and linux/arm64 the image of the project is built for, which with
+	// more than one builds a multi-arch image with a manifest list of the image of each platform
+	Platforms []string `json:"platforms,omitempty"`
+	// CNBBuilder the Cloud Native Buildpacks builder image, such as paketobuildpacks/builder, the image of the project
+	// is built with via 'jx step build pack cnb' instead of its Dockerfile
+	CNBBuilder string `json:"cnbBuilder,omitempty"`
 }
 
 // CreateJenkinsfileArguments contains the arguents to generate a Jenkinsfiles dynamically
@@ -631,6 +638,13 @@ func (c *PipelineConfig) ExtendPipeline(base *PipelineConfig, clearContainer boo
 	base.defaultContainerAndDir()
 	c.defaultContainerAndDir()
 	c.Env = syntax.CombineEnv(c.Env, base.Env)
+	c.Caches = syntax.CombineCaches(base.Caches, c.Caches)
+	if len(c.Platforms) == 0 {
+		c.Platforms = base.Platforms
+	}
+	if c.CNBBuilder == "" {
+		c.CNBBuilder = base.CNBBuilder
+	}
 	err = c.Pipelines.Extend(&base.Pipelines)
 	if err != nil {
 		return err
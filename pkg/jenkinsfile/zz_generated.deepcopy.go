@@ -131,6 +131,16 @@ func (in *PipelineConfig) DeepCopyInto(out *PipelineConfig) {
 		*out = new(v1.Container)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Caches != nil {
+		in, out := &in.Caches, &out.Caches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Platforms != nil {
+		in, out := &in.Platforms, &out.Platforms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
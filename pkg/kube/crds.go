@@ -62,6 +62,10 @@ func RegisterAllCRDs(apiClient apiextensionsclientset.Interface) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to register the User CRD")
 	}
+	err = RegisterWebhookEventCRD(apiClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to register the Webhook Event CRD")
+	}
 	err = RegisterWorkflowCRD(apiClient)
 	if err != nil {
 		return errors.Wrap(err, "failed to register the Workflow CRD")
@@ -551,6 +555,58 @@ func RegisterTeamCRD(apiClient apiextensionsclientset.Interface) error {
 	return RegisterCRD(apiClient, name, names, columns, jenkinsio.GroupName, jenkinsio.Package, jenkinsio.Version)
 }
 
+// RegisterWebhookEventCRD ensures that the CRD is registered for WebhookEvent
+func RegisterWebhookEventCRD(apiClient apiextensionsclientset.Interface) error {
+	name := "webhookevents." + jenkinsio.GroupName
+	names := &v1beta1.CustomResourceDefinitionNames{
+		Kind:       "WebhookEvent",
+		ListKind:   "WebhookEventList",
+		Plural:     "webhookevents",
+		Singular:   "webhookevent",
+		ShortNames: []string{"hook"},
+		Categories: []string{"all"},
+	}
+	columns := []v1beta1.CustomResourceColumnDefinition{
+		{
+			Name:        "Source",
+			Type:        "string",
+			Description: "The controller which received the event",
+			JSONPath:    ".spec.source",
+		},
+		{
+			Name:        "Type",
+			Type:        "string",
+			Description: "The type of the event",
+			JSONPath:    ".spec.eventType",
+		},
+		{
+			Name:        "Revision",
+			Type:        "string",
+			Description: "The git commit of the event",
+			JSONPath:    ".spec.revision",
+		},
+		{
+			Name:        "Phase",
+			Type:        "string",
+			Description: "The phase of the processing of the event",
+			JSONPath:    ".status.phase",
+		},
+		{
+			Name:        "Received",
+			Type:        "date",
+			Description: "When the event was received",
+			JSONPath:    ".spec.receivedTimestamp",
+		},
+		{
+			Name:        "Message",
+			Type:        "string",
+			Description: "The error of the last failed attempt",
+			JSONPath:    ".status.message",
+		},
+	}
+	return RegisterCRD(apiClient, name, names, columns, jenkinsio.GroupName, jenkinsio.Package, jenkinsio.Version)
+}
+
 // RegisterWorkflowCRD ensures that the CRD is registered for Environments
 func RegisterWorkflowCRD(apiClient apiextensionsclientset.Interface) error {
 	name := "workflows." + jenkinsio.GroupName
@@ -1,16 +1,41 @@
 package kube
 
 import (
+	"path"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// MaskedValue the text which replaces secret values in logs
+	MaskedValue = "****"
+
+	// minimumMaskedLineLength the minimum length of the values in the lines of multi line secrets which are masked on
+	// their own so that short values of JSON and YAML files are not masked throughout the logs
+	minimumMaskedLineLength = 8
+)
+
+// SecretReader lists and reads the secrets of a secret store such as Vault
+type SecretReader interface {
+	// List lists the secrets and folders, which end with a /, under the specified path
+	List(path string) ([]string, error)
+	// Read reads a named secret
+	Read(secretName string) (map[string]interface{}, error)
+}
+
 // LogMasker replaces words in a log from a set of secrets
 type LogMasker struct {
 	ReplaceWords map[string]string
+
+	// words the keys of ReplaceWords with the longest first so that secrets containing other secrets are fully masked
+	words []string
 }
 
 // NewLogMasker creates a new LogMasker loading secrets from the given namespace
@@ -59,17 +84,46 @@ func (m *LogMasker) LoadSecret(secret *corev1.Secret) {
 				// key := string(k)
 				value := string(v)
 
-				m.ReplaceWords[value] = m.replaceValue(value)
+				m.addWord(value)
+			}
+		}
+	}
+}
+
+// LoadVaultSecrets loads the values of the secrets under the path of the secret store, such as the Vault secrets
+// which are resolved into the pipelines without being stored as Secrets, into the log masker
+func (m *LogMasker) LoadVaultSecrets(client SecretReader, dir string) error {
+	if m.ReplaceWords == nil {
+		m.ReplaceWords = map[string]string{}
+	}
+	names, err := client.List(dir)
+	if err != nil {
+		return errors.Wrapf(err, "listing the secrets under %q", dir)
+	}
+	for _, name := range names {
+		secretPath := path.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			err = m.LoadVaultSecrets(client, secretPath)
+			if err != nil {
+				return err
 			}
+			continue
 		}
+		values, err := client.Read(secretPath)
+		if err != nil {
+			return errors.Wrapf(err, "reading the secret %q", secretPath)
+		}
+		m.replaceMapValues(values)
 	}
+	return nil
 }
 
-// MaskLog returns the text with all of the secrets masked out
+// MaskLog returns the text with all of the secrets masked out. It is safe to mask logs concurrently once the secrets
+// are loaded
 func (m *LogMasker) MaskLog(text string) string {
 	answer := text
-	for k, v := range m.ReplaceWords {
-		answer = strings.Replace(answer, k, v, -1)
+	for _, k := range m.words {
+		answer = strings.Replace(answer, k, m.ReplaceWords[k], -1)
 	}
 	return answer
 }
@@ -90,11 +144,87 @@ func (m *LogMasker) replaceMapValues(values map[string]interface{}) {
 		}
 		text, ok := value.(string)
 		if ok {
-			m.ReplaceWords[text] = m.replaceValue(text)
+			m.addWord(text)
 		}
 	}
 }
 
-func (m *LogMasker) replaceValue(value string) string {
-	return strings.Repeat("*", len(value))
+// addWord adds the secret value to the replacer words. As logs are masked a line at a time the secret material in
+// each line of a multi line value such as a private key or a JSON credentials file is also masked whereas its
+// structural lines such as the PEM armour or the keys and short values of the JSON file are not
+func (m *LogMasker) addWord(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	m.addReplaceWord(value)
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			for _, text := range lineValues(line) {
+				if isSecretMaterial(text) {
+					m.addReplaceWord(text)
+				}
+			}
+		}
+	}
+}
+
+// addReplaceWord adds the word keeping the words sorted with the longest first so that secrets containing other
+// secrets are fully masked
+func (m *LogMasker) addReplaceWord(word string) {
+	if _, ok := m.ReplaceWords[word]; ok {
+		return
+	}
+	m.ReplaceWords[word] = MaskedValue
+	i := sort.Search(len(m.words), func(i int) bool {
+		w := m.words[i]
+		if len(w) != len(word) {
+			return len(w) < len(word)
+		}
+		return w > word
+	})
+	m.words = append(m.words, "")
+	copy(m.words[i+1:], m.words[i:])
+	m.words[i] = word
+}
+
+// lineValues returns the values of a line of a multi line secret. The values of JSON and YAML key value lines are
+// returned without the key, quotes and trailing comma and the escaped new lines of JSON values are split
+func lineValues(line string) []string {
+	text := strings.TrimSpace(line)
+	if i := strings.Index(text, `": `); i >= 0 && strings.HasPrefix(text, `"`) {
+		text = text[i+3:]
+	} else if i := strings.Index(text, ": "); i > 0 && !strings.ContainsAny(text[:i], " \t\"") {
+		text = text[i+2:]
+	}
+	text = strings.TrimSuffix(strings.TrimSpace(text), ",")
+	text = strings.Trim(text, `"'`)
+	answer := []string{}
+	for _, value := range strings.Split(text, `\n`) {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			answer = append(answer, value)
+		}
+	}
+	return answer
+}
+
+// isSecretMaterial returns true if the value looks like a token, key or password rather than a word or the PEM armour
+// of a key: it is long enough, contains no whitespace and mixes letters and digits
+func isSecretMaterial(value string) bool {
+	if len(value) < minimumMaskedLineLength || strings.Contains(value, "://") {
+		return false
+	}
+	letters := false
+	digits := false
+	for _, r := range value {
+		switch {
+		case unicode.IsSpace(r):
+			return false
+		case unicode.IsLetter(r):
+			letters = true
+		case unicode.IsDigit(r):
+			digits = true
+		}
+	}
+	return letters && digits
 }
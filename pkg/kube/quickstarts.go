@@ -45,3 +45,15 @@ func IsDefaultQuickstartLocation(location v1.QuickStartLocation) bool {
 	}
 	return false
 }
+
+// GetQuickstartCatalogs returns the catalogs of additional quickstarts of the team
+func GetQuickstartCatalogs(jxClient versioned.Interface, ns string) ([]v1.QuickstartCatalog, error) {
+	env, err := EnsureDevEnvironmentSetup(jxClient, ns)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		return nil, fmt.Errorf("No Development environment found for namespace %s", ns)
+	}
+	return env.Spec.TeamSettings.QuickstartCatalogs, nil
+}
@@ -40,6 +40,15 @@ func (k *KustomizeCLI) Version(extraArgs ...string) (string, error) {
 	return extractSemanticVersion(version)
 }
 
+// Build executes the Kustomize build command on the kustomization in the given directory returning the manifests
+func (k *KustomizeCLI) Build(dir string) (string, error) {
+	output, err := k.runKustomizeWithOutput("build", dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to build the kustomization in %s", dir)
+	}
+	return output, nil
+}
+
 func (k *KustomizeCLI) runKustomizeWithOutput(args ...string) (string, error) {
 	k.Runner.SetArgs(args)
 	return k.Runner.RunWithoutRetry()
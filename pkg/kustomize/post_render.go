@@ -0,0 +1,139 @@
+package kustomize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// KustomizationFileName the name of the kustomization file
+	KustomizationFileName = "kustomization.yaml"
+
+	// RenderedManifestsFileName the resource of the kustomization containing the manifests being post rendered
+	RenderedManifestsFileName = "helm-rendered.yaml"
+
+	// KustomizedManifestsFileName the file the post rendered manifests are written to
+	KustomizedManifestsFileName = "kustomized.yaml"
+)
+
+// PostRender runs the kustomization in the kustomize directory over the YAML manifests in the manifests directory
+// replacing them with the output of 'kustomize build'. The manifests are added to the kustomization as the
+// RenderedManifestsFileName resource so the kustomization can patch them. The kustomization is built in place so that
+// its relative paths to other directories such as '../base' resolve and is restored once it has been built
+func PostRender(k Kustomizer, kustomizeDir string, manifestsDir string) error {
+	manifestFiles := []string{}
+	var buffer strings.Builder
+	err := filepath.Walk(manifestsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		buffer.WriteString("---\n")
+		buffer.Write(data)
+		buffer.WriteString("\n")
+		manifestFiles = append(manifestFiles, path)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the rendered manifests in %s", manifestsDir)
+	}
+	if len(manifestFiles) == 0 {
+		return nil
+	}
+
+	kustomizationFile := filepath.Join(kustomizeDir, KustomizationFileName)
+	original, err := ioutil.ReadFile(kustomizationFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the kustomization %s", kustomizationFile)
+	}
+	renderedFile := filepath.Join(kustomizeDir, RenderedManifestsFileName)
+	exists, err := util.FileExists(renderedFile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("the kustomize dir %s already contains the rendered manifests file %s", kustomizeDir, RenderedManifestsFileName)
+	}
+	defer restoreKustomization(kustomizationFile, original, renderedFile)
+	err = ioutil.WriteFile(renderedFile, []byte(buffer.String()), util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the rendered manifests to %s", kustomizeDir)
+	}
+	err = addRenderedManifestsResource(kustomizationFile)
+	if err != nil {
+		return err
+	}
+
+	output, err := k.Build(kustomizeDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post render the manifests in %s with the kustomization in %s", manifestsDir, kustomizeDir)
+	}
+	for _, file := range manifestFiles {
+		err = os.Remove(file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove the rendered manifest %s", file)
+		}
+	}
+	fileName := filepath.Join(manifestsDir, KustomizedManifestsFileName)
+	err = ioutil.WriteFile(fileName, []byte(output), util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the post rendered manifests to %s", fileName)
+	}
+	return nil
+}
+
+// restoreKustomization restores the original kustomization file and removes the rendered manifests after building it
+func restoreKustomization(kustomizationFile string, original []byte, renderedFile string) {
+	err := ioutil.WriteFile(kustomizationFile, original, util.DefaultFileWritePermissions)
+	if err != nil {
+		log.Logger().Warnf("failed to restore the kustomization %s: %s", kustomizationFile, err.Error())
+	}
+	err = os.Remove(renderedFile)
+	if err != nil && !os.IsNotExist(err) {
+		log.Logger().Warnf("failed to remove the rendered manifests %s: %s", renderedFile, err.Error())
+	}
+}
+
+// addRenderedManifestsResource adds the RenderedManifestsFileName to the resources of the kustomization file if it is
+// not already a resource
+func addRenderedManifestsResource(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the kustomization %s", fileName)
+	}
+	kustomization := map[string]interface{}{}
+	err = yaml.Unmarshal(data, &kustomization)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the kustomization %s", fileName)
+	}
+	resources, _ := kustomization["resources"].([]interface{})
+	for _, resource := range resources {
+		if resource == RenderedManifestsFileName {
+			return nil
+		}
+	}
+	kustomization["resources"] = append(resources, RenderedManifestsFileName)
+	data, err = yaml.Marshal(kustomization)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the kustomization %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the kustomization %s", fileName)
+	}
+	return nil
+}
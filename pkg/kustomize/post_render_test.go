@@ -0,0 +1,87 @@
+// +build unit
+
+package kustomize_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKustomizer records the kustomization and rendered manifests of each build
+type fakeKustomizer struct {
+	kustomize.KustomizeCLI
+
+	dir           string
+	kustomization map[string]interface{}
+	rendered      string
+	output        string
+}
+
+func (k *fakeKustomizer) Build(dir string) (string, error) {
+	k.dir = dir
+	data, err := ioutil.ReadFile(filepath.Join(dir, kustomize.KustomizationFileName))
+	if err != nil {
+		return "", err
+	}
+	k.kustomization = map[string]interface{}{}
+	err = yaml.Unmarshal(data, &k.kustomization)
+	if err != nil {
+		return "", err
+	}
+	data, err = ioutil.ReadFile(filepath.Join(dir, kustomize.RenderedManifestsFileName))
+	if err != nil {
+		return "", err
+	}
+	k.rendered = string(data)
+	return k.output, nil
+}
+
+func TestPostRender(t *testing.T) {
+	t.Parallel()
+
+	manifestsDir, err := ioutil.TempDir("", "test-kustomize-post-render-")
+	require.NoError(t, err)
+	defer os.RemoveAll(manifestsDir)
+	templatesDir := filepath.Join(manifestsDir, "myapp", "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, util.DefaultWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(templatesDir, "deployment.yaml"), []byte("kind: Deployment\n"), util.DefaultFileWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(templatesDir, "service.yaml"), []byte("kind: Service\n"), util.DefaultFileWritePermissions))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(templatesDir, "NOTES.txt"), []byte("notes\n"), util.DefaultFileWritePermissions))
+
+	kustomizeDir := filepath.Join("test_data", "post_render")
+	original, err := ioutil.ReadFile(filepath.Join(kustomizeDir, kustomize.KustomizationFileName))
+	require.NoError(t, err)
+
+	k := &fakeKustomizer{
+		output: "kind: Deployment\nmetadata:\n  labels:\n    team: platform\n",
+	}
+	err = kustomize.PostRender(k, kustomizeDir, manifestsDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, kustomizeDir, k.dir, "should build the kustomization in place so that its relative paths resolve")
+	assert.Equal(t, []interface{}{kustomize.RenderedManifestsFileName}, k.kustomization["resources"], "the rendered manifests should be added to the resources")
+	assert.Equal(t, []interface{}{"tolerations.yaml"}, k.kustomization["patchesStrategicMerge"])
+	assert.Equal(t, "---\nkind: Deployment\n\n---\nkind: Service\n\n", k.rendered)
+
+	data, err := ioutil.ReadFile(filepath.Join(kustomizeDir, kustomize.KustomizationFileName))
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(data), "should restore the kustomization")
+	_, err = os.Stat(filepath.Join(kustomizeDir, kustomize.RenderedManifestsFileName))
+	assert.True(t, os.IsNotExist(err), "should have removed the rendered manifests from the kustomize dir")
+
+	_, err = os.Stat(filepath.Join(templatesDir, "deployment.yaml"))
+	assert.True(t, os.IsNotExist(err), "should have removed the rendered manifests")
+	_, err = os.Stat(filepath.Join(templatesDir, "NOTES.txt"))
+	assert.NoError(t, err, "should not remove files which are not manifests")
+	data, err = ioutil.ReadFile(filepath.Join(manifestsDir, kustomize.KustomizedManifestsFileName))
+	require.NoError(t, err)
+	assert.Equal(t, k.output, string(data))
+}
@@ -51,6 +51,13 @@ type TektonLogger struct {
 	errorsChannel     chan error
 	wg                *sync.WaitGroup
 	FailIfPodFails    bool
+	// Structured streams the logs of all the running stages at once with each line tagged with its stage and step
+	Structured bool
+	// NoFollow only writes the logs the containers have written so far rather than following them
+	NoFollow bool
+	// Stage if not blank only the logs of this stage are written
+	Stage   string
+	streams *stageStreams
 }
 
 // LogWriter is an interface that can be implemented to define different ways to stream / write logs
@@ -69,6 +76,10 @@ type retrieverFunc func(pod *corev1.Pod, container *corev1.Container) (io.Reader
 type LogLine struct {
 	Line       string
 	ShouldMask bool
+	// Build, Stage and Step are only populated by the structured streaming of the logs
+	Build string
+	Stage string
+	Step  string
 }
 
 // GetTektonPipelinesWithActivePipelineActivity returns list of all PipelineActivities with corresponding Tekton PipelineRuns ordered by the PipelineRun creation timestamp and a map to obtain its reference once a name has been selected
@@ -222,6 +233,15 @@ func getPipelineRunsForActivity(pa *v1.PipelineActivity, tektonClient tektonclie
 
 // GetRunningBuildLogs obtains the logs of the provided PipelineActivity and streams the running build pods' logs using the provided LogWriter
 func (t TektonLogger) GetRunningBuildLogs(pa *v1.PipelineActivity, buildName string, noWaitForRuns bool) error {
+	if !t.Structured {
+		return t.getRunningBuildLogs(pa, buildName, noWaitForRuns)
+	}
+	t.startStageStreams()
+	err := t.getRunningBuildLogs(pa, buildName, noWaitForRuns)
+	return util.CombineErrors(err, t.stopStageStreams())
+}
+
+func (t TektonLogger) getRunningBuildLogs(pa *v1.PipelineActivity, buildName string, noWaitForRuns bool) error {
 	loggedAllRunsForActivity := false
 	foundLogs := false
 
@@ -290,20 +310,34 @@ func (t TektonLogger) GetRunningBuildLogs(pa *v1.PipelineActivity, buildName str
 						strings.ToLower(params.Branch) == strings.ToLower(pa.Spec.GitBranch) && params.Build == pa.Spec.Build {
 						stagesSeen[stageName] = true
 						foundLogs = true
+						if t.Stage != "" && naming.ToValidName(t.Stage) != naming.ToValidName(stageName) {
+							continue
+						}
+						if t.Structured {
+							t.streamStageLogsAsync(pod, pa.Namespace, buildName, stageName)
+							continue
+						}
 						err := t.getContainerLogsFromPod(pod, pa, buildName, stageName)
 						if err != nil {
 							return errors.Wrapf(err, "failed to obtain the logs for build %s and stage %s", buildName, stageName)
 						}
 					}
 				}
-				if !foundLogs {
+				if !foundLogs || t.NoFollow {
 					break
 				}
+				// the stages are streamed in the background so wait a little before looking for the pods of the next ones
+				if t.Structured && stagesToCheckCount > len(stagesSeen) {
+					time.Sleep(time.Second)
+				}
 			}
 		}
 		if !foundLogs {
 			break
 		}
+		if t.NoFollow {
+			loggedAllRunsForActivity = true
+		}
 
 		// Flag used for testing - don't loop forever waiting for the build run if it's pending
 		if noWaitForRuns && !tekton.PipelineRunIsNotPending(&buildPr) {
@@ -325,6 +359,9 @@ func (t *TektonLogger) getContainerLogsFromPod(pod *corev1.Pod, pa *v1.PipelineA
 	containers, _, _ := kube.GetContainersWithStatusAndIsInit(pod)
 	t.initializeLoggingRoutine()
 	for i, ic := range containers {
+		if t.NoFollow && !kube.HasContainerStarted(pod, i) {
+			break
+		}
 		pod, err := t.waitForContainerToStart(pa.Namespace, pod, i, stageName)
 		err = t.LogWriter.WriteLog(LogLine{
 			Line: fmt.Sprintf("\nShowing logs for build %v stage %s and container %s",
@@ -429,10 +466,13 @@ func (t TektonLogger) waitForContainerToStart(ns string, pod *corev1.Pod, idx in
 	// This method will be executed by both the CLI and the UI, we don't know if the UI has color enabled, so we are using a local instance instead of the global one
 	c := color.New(color.FgGreen)
 	c.EnableColor()
-	if err := t.LogWriter.WriteLog(LogLine{
-		Line: fmt.Sprintf("\nwaiting for stage %s : container %s to start...\n", c.Sprintf(stageName), c.Sprintf(containerName)),
-	}, t.logsChannel); err != nil {
-		log.Logger().Warn("There was a problem writing a single line into the writeFN")
+	// the structured logs of the other stages keep streaming in the meantime so don't interleave a waiting message
+	if !t.Structured {
+		if err := t.LogWriter.WriteLog(LogLine{
+			Line: fmt.Sprintf("\nwaiting for stage %s : container %s to start...\n", c.Sprintf(stageName), c.Sprintf(containerName)),
+		}, t.logsChannel); err != nil {
+			log.Logger().Warn("There was a problem writing a single line into the writeFN")
+		}
 	}
 	for {
 		time.Sleep(time.Second)
@@ -488,8 +528,10 @@ func (t *TektonLogger) StreamPipelinePersistentLogs(logsURL string, jxClient ver
 			Line: "The build pods for this build have been garbage collected and we couldn't find the any stored log file",
 		})
 	}
+	// lets mask the stored logs in case they were stored before their secrets were masked
 	return t.writeBlockingLine(LogLine{
-		Line: string(logBytes),
+		Line:       string(logBytes),
+		ShouldMask: true,
 	})
 }
 
@@ -497,7 +539,8 @@ func (t *TektonLogger) streamPipedLogs(scanner *bufio.Scanner, logsURL string) e
 	for scanner.Scan() {
 		text := scanner.Text()
 		err := t.LogWriter.WriteLog(LogLine{
-			Line: text,
+			Line:       text,
+			ShouldMask: true,
 		}, t.logsChannel)
 
 		if err != nil {
@@ -546,7 +589,7 @@ func (t *TektonLogger) writeBlockingLine(line LogLine) error {
 func (t TektonLogger) retrieveLogsFromPod(pod *corev1.Pod, container *corev1.Container) (io.Reader, func(), error) {
 	options := &corev1.PodLogOptions{
 		Container: container.Name,
-		Follow:    true,
+		Follow:    !t.NoFollow,
 	}
 	bytesLimit := t.LogWriter.BytesLimit()
 	if bytesLimit > 0 {
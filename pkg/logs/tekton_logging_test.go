@@ -33,6 +33,7 @@ import (
 type TestWriter struct {
 	StreamLinesLogged []string
 	SingleLinesLogged []string
+	LogLines          []LogLine
 }
 
 const (
@@ -477,6 +478,60 @@ func TestGetRunningBuildLogsWithMultipleStages(t *testing.T) {
 		stripansi.Strip(firstLine), "'build' should be the first stage logged")
 }
 
+func TestGetRunningBuildLogsStructuredWithMultipleStages(t *testing.T) {
+	testCaseDir := path.Join("test_data", "multiple_stages")
+	_, _, _, _, ns := getFakeClientsAndNs(t)
+
+	podsList := tekton_helpers_test.AssertLoadPods(t, testCaseDir)
+	pipelineRuns := tekton_helpers_test.AssertLoadSinglePipelineRun(t, testCaseDir)
+	structures := tekton_helpers_test.AssertLoadSinglePipelineStructure(t, testCaseDir)
+	pa := &v1.PipelineActivity{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      "abayer-js-test-repo-master-1",
+			Namespace: ns,
+		},
+		Spec: v1.PipelineActivitySpec{
+			Build:         "1",
+			GitBranch:     "master",
+			GitRepository: "js-test-repo",
+			GitOwner:      "abayer",
+		},
+	}
+	containers1, _, _ := kube.GetContainersWithStatusAndIsInit(&podsList.Items[0])
+	containers2, _, _ := kube.GetContainersWithStatusAndIsInit(&podsList.Items[1])
+
+	for _, stage := range []string{"", "second"} {
+		tl := TektonLogger{
+			KubeClient:        kubeMocks.NewSimpleClientset(podsList),
+			JXClient:          jxfake.NewSimpleClientset(structures),
+			TektonClient:      tektonMocks.NewSimpleClientset(pipelineRuns),
+			Namespace:         ns,
+			LogWriter:         &TestWriter{},
+			LogsRetrieverFunc: LogsProvider,
+			Structured:        true,
+			Stage:             stage,
+		}
+
+		err := tl.GetRunningBuildLogs(pa, "abayer/js-test-repo/master/1", false)
+		assert.NoError(t, err)
+
+		lines := tl.LogWriter.(*TestWriter).LogLines
+		if stage == "" {
+			assert.Len(t, lines, len(containers1)+len(containers2), "should only log the lines of the containers")
+		} else {
+			assert.Len(t, lines, len(containers2), "should only log the lines of stage %s", stage)
+		}
+		for _, l := range lines {
+			assert.Equal(t, "abayer/js-test-repo/master/1", l.Build)
+			assert.NotEmpty(t, l.Stage)
+			assert.Contains(t, l.Line, "container step-"+l.Step, "should tag the line with its step")
+			if stage != "" {
+				assert.Equal(t, stage, l.Stage)
+			}
+		}
+	}
+}
+
 func TestGetRunningBuildLogsWithMultipleStagesWithFailureInFirstStage(t *testing.T) {
 	testCaseDir := path.Join("test_data", "multiple_stages_with_failure_in_first_stage")
 	_, _, _, _, ns := getFakeClientsAndNs(t)
@@ -668,6 +723,7 @@ func (w *TestWriter) StreamLog(lch <-chan LogLine, ech <-chan error) error {
 				return nil
 			}
 			w.StreamLinesLogged = append(w.StreamLinesLogged, l.Line)
+			w.LogLines = append(w.LogLines, l)
 			log.Logger().Info(l.Line)
 		}
 	}
@@ -0,0 +1,171 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/fatih/color"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// stageColors the colors of the prefixes of the structured logs assigned to the stages in the order they are logged
+var stageColors = []color.Attribute{color.FgCyan, color.FgMagenta, color.FgYellow, color.FgBlue, color.FgGreen, color.FgHiCyan, color.FgHiMagenta, color.FgHiYellow}
+
+// stageStreams tracks the stages streaming their logs concurrently in structured mode
+type stageStreams struct {
+	wg     sync.WaitGroup
+	lock   sync.Mutex
+	errors []error
+}
+
+// StructuredLogRecord the NDJSON record of a line of the logs of a step of a pipeline stage
+type StructuredLogRecord struct {
+	Time    string `json:"time"`
+	Build   string `json:"build,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+}
+
+// StructuredFormatter formats the structured log lines either as text prefixed with their stage and step in a color
+// per stage or as NDJSON records for log shippers
+type StructuredFormatter struct {
+	JSON   bool
+	Now    func() time.Time
+	colors map[string]*color.Color
+}
+
+// NewStructuredFormatter creates a formatter of the structured log lines
+func NewStructuredFormatter(jsonOutput bool) *StructuredFormatter {
+	return &StructuredFormatter{
+		JSON:   jsonOutput,
+		Now:    time.Now,
+		colors: map[string]*color.Color{},
+	}
+}
+
+// Format returns the text to write for the log line
+func (f *StructuredFormatter) Format(line LogLine) (string, error) {
+	if f.JSON {
+		data, err := json.Marshal(StructuredLogRecord{
+			Time:    f.Now().UTC().Format(time.RFC3339Nano),
+			Build:   line.Build,
+			Stage:   line.Stage,
+			Step:    line.Step,
+			Message: stripansi.Strip(line.Line),
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal the log line")
+		}
+		return string(data), nil
+	}
+	if line.Stage == "" {
+		return line.Line, nil
+	}
+	prefix := line.Stage
+	if line.Step != "" {
+		prefix += "/" + line.Step
+	}
+	return fmt.Sprintf("%s %s", f.stageColor(line.Stage).Sprintf("[%s]", prefix), line.Line), nil
+}
+
+func (f *StructuredFormatter) stageColor(stage string) *color.Color {
+	c, ok := f.colors[stage]
+	if !ok {
+		c = color.New(stageColors[len(f.colors)%len(stageColors)])
+		f.colors[stage] = c
+	}
+	return c
+}
+
+// startStageStreams starts writing the logs which all the stages share
+func (t *TektonLogger) startStageStreams() {
+	if t.LogsRetrieverFunc == nil {
+		t.LogsRetrieverFunc = t.retrieveLogsFromPod
+	}
+	t.streams = &stageStreams{}
+	t.initializeLoggingRoutine()
+}
+
+// stopStageStreams waits for all the stages to finish streaming and for their lines to be written
+func (t *TektonLogger) stopStageStreams() error {
+	t.streams.wg.Wait()
+	t.closeLoggingChannels()
+	t.wg.Wait()
+	return util.CombineErrors(t.streams.errors...)
+}
+
+// streamStageLogsAsync streams the logs of the stage in the background so that the stages running in parallel are
+// streamed at the same time
+func (t *TektonLogger) streamStageLogsAsync(pod *corev1.Pod, ns string, buildName string, stageName string) {
+	t.streams.wg.Add(1)
+	go func() {
+		defer t.streams.wg.Done()
+		err := t.streamStageLogs(pod, ns, buildName, stageName)
+		if err != nil {
+			t.streams.lock.Lock()
+			t.streams.errors = append(t.streams.errors, errors.Wrapf(err, "failed to obtain the logs for build %s and stage %s", buildName, stageName))
+			t.streams.lock.Unlock()
+		}
+	}()
+}
+
+// streamStageLogs streams the logs of the steps of the pod of a stage tagging each line with its stage and step
+func (t *TektonLogger) streamStageLogs(pod *corev1.Pod, ns string, buildName string, stageName string) error {
+	containers, _, _ := kube.GetContainersWithStatusAndIsInit(pod)
+	for i := range containers {
+		container := containers[i]
+		if t.NoFollow && !kube.HasContainerStarted(pod, i) {
+			return nil
+		}
+		pod, err := t.waitForContainerToStart(ns, pod, i, stageName)
+		if err != nil {
+			return err
+		}
+		step := strings.TrimPrefix(container.Name, "step-")
+		reader, cleanFN, err := t.LogsRetrieverFunc(pod, &container)
+		if err != nil {
+			return err
+		}
+		err = writeStageStreamLines(reader, t.logsChannel, LogLine{Build: buildName, Stage: stageName, Step: step, ShouldMask: true})
+		cleanFN()
+		if err != nil {
+			return err
+		}
+		if hasStepFailed(pod, i, t.KubeClient, ns) {
+			message := fmt.Sprintf("Pipeline failed on stage '%s' : container '%s'. The execution of the pipeline has stopped.", stageName, container.Name)
+			t.logsChannel <- LogLine{Line: message, Build: buildName, Stage: stageName, Step: step}
+			if t.FailIfPodFails {
+				return errors.New(message)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// writeStageStreamLines sends each line of the reader to the channel tagged like the template line
+func writeStageStreamLines(reader io.Reader, logCh chan<- LogLine, template LogLine) error {
+	buffReader := bufio.NewReader(reader)
+	for {
+		line, _, err := buffReader.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read stream")
+		}
+		l := template
+		l.Line = string(line)
+		logCh <- l
+	}
+}
@@ -0,0 +1,36 @@
+// +build unit
+
+package logs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/jenkins-x/jx/v2/pkg/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredFormatter(t *testing.T) {
+	t.Parallel()
+
+	line := logs.LogLine{Line: "npm install", Build: "myorg/myapp/master #1", Stage: "build", Step: "install"}
+
+	text, err := logs.NewStructuredFormatter(false).Format(line)
+	require.NoError(t, err)
+	assert.Equal(t, "[build/install] npm install", stripansi.Strip(text))
+
+	text, err = logs.NewStructuredFormatter(false).Format(logs.LogLine{Line: "Build logs for myapp"})
+	require.NoError(t, err)
+	assert.Equal(t, "Build logs for myapp", text, "should not prefix the lines without a stage")
+
+	formatter := logs.NewStructuredFormatter(true)
+	formatter.Now = func() time.Time {
+		return time.Date(2020, 6, 10, 9, 0, 0, 0, time.UTC)
+	}
+	line.Line = "\x1b[32mok\x1b[0m"
+	text, err = formatter.Format(line)
+	require.NoError(t, err)
+	assert.Equal(t, `{"time":"2020-06-10T09:00:00Z","build":"myorg/myapp/master #1","stage":"build","step":"install","message":"ok"}`, text)
+}
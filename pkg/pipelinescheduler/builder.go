@@ -78,6 +78,14 @@ func Build(schedulers []*jenkinsv1.SchedulerSpec) (*jenkinsv1.SchedulerSpec, err
 			if answer.Attachments == nil {
 				answer.Attachments = parent.Attachments
 			}
+			if answer.ChatOpsCommands == nil {
+				answer.ChatOpsCommands = parent.ChatOpsCommands
+			} else if !answer.ChatOpsCommands.Replace && parent.ChatOpsCommands != nil {
+				err := applyToChatOpsCommands(parent.ChatOpsCommands, answer.ChatOpsCommands)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
 		}
 	}
 	return answer, nil
@@ -493,6 +501,55 @@ func applyToPreSubmits(parentPresubmits *jenkinsv1.Presubmits, childPresubmits *
 	return nil
 }
 
+func applyToChatOpsCommands(parentCommands *jenkinsv1.ChatOpsCommands, childCommands *jenkinsv1.ChatOpsCommands) error {
+	if childCommands.Items == nil {
+		childCommands.Items = make([]*jenkinsv1.ChatOpsCommand, 0)
+	}
+	// Work through each of the commands in the parent. If we can find a name based match in child,
+	// we apply it to the child, otherwise we append it
+	for _, parent := range parentCommands.Items {
+		var found []*jenkinsv1.ChatOpsCommand
+		for _, command := range childCommands.Items {
+			if command.Name != nil && parent.Name != nil && *command.Name == *parent.Name {
+				found = append(found, command)
+			}
+		}
+		if len(found) > 1 {
+			return errors.Errorf("more than one ChatOps command with name %v in %s", *parent.Name, spew.Sdump(childCommands))
+		} else if len(found) == 1 {
+			child := found[0]
+			if child.Trigger == nil {
+				child.Trigger = parent.Trigger
+			}
+			if child.Command == nil {
+				child.Command = parent.Command
+			}
+			if child.Report == nil {
+				child.Report = parent.Report
+			}
+			if child.Arguments == nil {
+				child.Arguments = parent.Arguments
+			}
+			if child.Parameters == nil {
+				child.Parameters = parent.Parameters
+			} else if !child.Parameters.Replace && parent.Parameters != nil {
+				if child.Parameters.Items == nil {
+					child.Parameters.Items = make(map[string]string)
+				}
+				// Add any parameters that are missing
+				for pk, pv := range parent.Parameters.Items {
+					if _, ok := child.Parameters.Items[pk]; !ok {
+						child.Parameters.Items[pk] = pv
+					}
+				}
+			}
+		} else {
+			childCommands.Items = append(childCommands.Items, parent)
+		}
+	}
+	return nil
+}
+
 func applyToProtectionPolicies(parent *jenkinsv1.ProtectionPolicies,
 	child *jenkinsv1.ProtectionPolicies) {
 	if child.ProtectionPolicy == nil {
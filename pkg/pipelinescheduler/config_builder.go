@@ -2,10 +2,13 @@ package pipelinescheduler
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/prow"
 	"github.com/pkg/errors"
 	"github.com/rollout/rox-go/core/utils"
 	"k8s.io/test-infra/prow/config"
@@ -338,6 +341,12 @@ func buildJobConfig(jobConfig *config.JobConfig, prowConfig *config.ProwConfig,
 			return errors.Wrapf(err, "building Presubmits from %v", scheduler)
 		}
 	}
+	if scheduler.ChatOpsCommands != nil && len(scheduler.ChatOpsCommands.Items) > 0 {
+		err := buildChatOpsCommands(jobConfig, scheduler.ChatOpsCommands.Items, org, repo)
+		if err != nil {
+			return errors.Wrapf(err, "building ChatOps commands from %v", scheduler)
+		}
+	}
 	if scheduler.Periodics != nil && len(scheduler.Periodics.Items) > 0 {
 		err := buildPeriodics(jobConfig, scheduler.Periodics)
 		if err != nil {
@@ -486,6 +495,121 @@ func buildPresubmits(jobConfig *config.JobConfig, prowConfig *config.ProwConfig,
 	return nil
 }
 
+// chatOpsJobNameRegex the regular expression of valid job names
+var chatOpsJobNameRegex = regexp.MustCompile(`^[A-Za-z0-9-._]+$`)
+
+// buildChatOpsCommands adds an optional Presubmit for each custom ChatOps command, or for each combination of the
+// values of its arguments, which is only triggered by comments matching the trigger phrase of the command. The
+// parameters and arguments of the command are passed to the pipeline as job labels
+func buildChatOpsCommands(jobConfig *config.JobConfig, items []*jenkinsv1.ChatOpsCommand, orgName string, repoName string) error {
+	if jobConfig.Presubmits == nil {
+		jobConfig.Presubmits = make(map[string][]config.Presubmit)
+	}
+	orgSlashRepo := orgSlashRepo(orgName, repoName)
+	jobNames := map[string]bool{}
+	for _, presubmit := range jobConfig.Presubmits[orgSlashRepo] {
+		jobNames[presubmit.Name] = true
+	}
+	for _, command := range items {
+		if command.Name == nil || *command.Name == "" {
+			return errors.Errorf("ChatOps command without a name in %s", orgSlashRepo)
+		}
+		presubmits, err := buildChatOpsCommandPresubmits(command)
+		if err != nil {
+			return errors.Wrapf(err, "building ChatOps command %s of %s", *command.Name, orgSlashRepo)
+		}
+		for _, c := range presubmits {
+			if jobNames[c.Name] {
+				return errors.Errorf("the job %s of ChatOps command %s has the same name as another job of %s", c.Name, *command.Name, orgSlashRepo)
+			}
+			jobNames[c.Name] = true
+			jobConfig.Presubmits[orgSlashRepo] = append(jobConfig.Presubmits[orgSlashRepo], c)
+		}
+	}
+	return nil
+}
+
+// buildChatOpsCommandPresubmits returns the Presubmit of the ChatOps command or, if it has arguments, a Presubmit for
+// each combination of their values
+func buildChatOpsCommandPresubmits(command *jenkinsv1.ChatOpsCommand) ([]config.Presubmit, error) {
+	name := *command.Name
+	if len(command.Arguments) > 0 && (command.Trigger != nil || command.Command != nil) {
+		return nil, errors.New("the trigger and command of a ChatOps command with arguments are generated from its arguments")
+	}
+	combinations := [][]string{{}}
+	for _, argument := range command.Arguments {
+		if argument == nil || argument.Name == "" || len(argument.Values) == 0 {
+			return nil, errors.New("ChatOps argument without a name or values")
+		}
+		next := [][]string{}
+		for _, values := range combinations {
+			for _, value := range argument.Values {
+				if value == "" {
+					return nil, errors.Errorf("empty value of ChatOps argument %s", argument.Name)
+				}
+				next = append(next, append(append([]string{}, values...), value))
+			}
+		}
+		combinations = next
+	}
+
+	answer := []config.Presubmit{}
+	for _, values := range combinations {
+		parameters := map[string]string{}
+		if command.Parameters != nil {
+			for k, v := range command.Parameters.Items {
+				parameters[k] = v
+			}
+		}
+		words := []string{"/" + name}
+		triggerWords := []string{regexp.QuoteMeta("/" + name)}
+		for i, value := range values {
+			parameters[command.Arguments[i].Name] = value
+			words = append(words, value)
+			triggerWords = append(triggerWords, regexp.QuoteMeta(value))
+		}
+		jobName := strings.Join(append([]string{name}, values...), "-")
+		if !chatOpsJobNameRegex.MatchString(jobName) {
+			return nil, errors.Errorf("invalid job name %s which must match %s", jobName, chatOpsJobNameRegex.String())
+		}
+		c := config.Presubmit{
+			JobBase: config.JobBase{
+				Name:  jobName,
+				Agent: DefaultAgent,
+			},
+			Context:      jobName,
+			Optional:     true,
+			Trigger:      fmt.Sprintf(`(?m)^%s\s*$`, strings.Join(triggerWords, `\s+`)),
+			RerunCommand: strings.Join(words, " "),
+		}
+		if command.Trigger != nil {
+			c.Trigger = *command.Trigger
+		}
+		if command.Command != nil {
+			c.RerunCommand = *command.Command
+		}
+		trigger, err := regexp.Compile(c.Trigger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trigger %s", c.Trigger)
+		}
+		if !trigger.MatchString(c.RerunCommand) {
+			return nil, errors.Errorf("the command %s does not match the trigger %s", c.RerunCommand, c.Trigger)
+		}
+		if command.Report != nil {
+			c.SkipReport = !*command.Report
+		}
+		if len(parameters) > 0 {
+			labels, err := prow.ChatOpsParameterLabels(parameters)
+			if err != nil {
+				return nil, errors.Wrapf(err, "building the parameters of job %s", jobName)
+			}
+			c.Labels = labels
+		}
+		answer = append(answer, c)
+	}
+	return answer, nil
+}
+
 func buildGlobalBranchProtection(answer *config.BranchProtection,
 	globalProtectionPolicy *jenkinsv1.GlobalProtectionPolicy) error {
 	if globalProtectionPolicy.ProtectTested != nil {
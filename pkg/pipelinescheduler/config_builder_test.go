@@ -8,9 +8,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/pipelinescheduler"
 	"github.com/jenkins-x/jx/v2/pkg/pipelinescheduler/testhelpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/pborman/uuid"
 )
@@ -182,3 +184,112 @@ func TestOnlyPluginsMixFromParentAndRepo(t *testing.T) {
 			},
 		})
 }
+
+func TestChatOpsCommandsWithParent(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	testhelpers.BuildAndValidateProwConfig(t, filepath.Join(wd, "test_data", "chatops_commands"), "config.yaml",
+		"", []testhelpers.SchedulerFile{
+			{
+				Filenames: []string{"parent.yaml", "repo.yaml"},
+				Org:       "acme",
+				Repo:      "dummy",
+			},
+		})
+}
+
+func TestMonorepoApps(t *testing.T) {
+	name := "serverless-jenkins"
+	context := "serverless-jenkins"
+	alwaysRun := true
+	scheduler := &v1.SchedulerSpec{
+		Presubmits: &v1.Presubmits{
+			Items: []*v1.Presubmit{
+				{
+					JobBase:   &v1.JobBase{Name: &name},
+					Context:   &context,
+					AlwaysRun: &alwaysRun,
+				},
+			},
+		},
+		Postsubmits: &v1.Postsubmits{
+			Items: []*v1.Postsubmit{
+				{
+					JobBase: &v1.JobBase{Name: &name},
+					Context: &context,
+				},
+			},
+		},
+	}
+	apps := []v1.SourceRepositoryApp{
+		{Name: "foo", Path: "services/foo"},
+		{Name: "bar", Path: "./services/bar/"},
+	}
+	leaves := []*pipelinescheduler.SchedulerLeaf{
+		{
+			Org:           "acme",
+			Repo:          "monorepo",
+			SchedulerSpec: pipelinescheduler.ApplyMonorepoApps(scheduler, apps),
+		},
+	}
+	cfg, _, err := pipelinescheduler.BuildProwConfig(leaves)
+	require.NoError(t, err)
+
+	presubmits := cfg.Presubmits["acme/monorepo"]
+	require.Len(t, presubmits, 2)
+	assert.Equal(t, "serverless-jenkins-foo", presubmits[0].Name)
+	assert.Equal(t, "foo", presubmits[0].Context)
+	assert.Equal(t, `^(services/foo/|jenkins-x-foo\.yml)`, presubmits[0].RunIfChanged)
+	assert.Equal(t, "/test foo", presubmits[0].RerunCommand)
+	assert.False(t, presubmits[0].AlwaysRun)
+	assert.Equal(t, `^(services/bar/|jenkins-x-bar\.yml)`, presubmits[1].RunIfChanged)
+
+	postsubmits := cfg.Postsubmits["acme/monorepo"]
+	require.Len(t, postsubmits, 2)
+	assert.Equal(t, "serverless-jenkins-bar", postsubmits[1].Name)
+	assert.Equal(t, "bar", postsubmits[1].Context)
+	assert.Equal(t, `^(services/bar/|jenkins-x-bar\.yml)`, postsubmits[1].RunIfChanged)
+
+	assert.Equal(t, "serverless-jenkins", *scheduler.Presubmits.Items[0].Name, "the shared scheduler should not be modified")
+	assert.Nil(t, scheduler.Presubmits.Items[0].RegexpChangeMatcher)
+}
+
+func TestChatOpsCommandsAreValidated(t *testing.T) {
+	name := "integration"
+	invalidTrigger := "(?m)^/loadtest("
+	loadtest := "loadtest"
+	command := "/loadtest"
+	tests := map[string]*v1.SchedulerSpec{
+		"the same name as a presubmit": {
+			Presubmits:      &v1.Presubmits{Items: []*v1.Presubmit{{JobBase: &v1.JobBase{Name: &name}}}},
+			ChatOpsCommands: &v1.ChatOpsCommands{Items: []*v1.ChatOpsCommand{{Name: &name}}},
+		},
+		"an invalid trigger": {
+			ChatOpsCommands: &v1.ChatOpsCommands{Items: []*v1.ChatOpsCommand{{Name: &loadtest, Trigger: &invalidTrigger}}},
+		},
+		"a trigger and arguments": {
+			ChatOpsCommands: &v1.ChatOpsCommands{Items: []*v1.ChatOpsCommand{{
+				Name:      &loadtest,
+				Command:   &command,
+				Arguments: []*v1.ChatOpsArgument{{Name: "USERS", Values: []string{"100"}}},
+			}}},
+		},
+	}
+	for description, scheduler := range tests {
+		_, _, err := pipelinescheduler.BuildProwConfig([]*pipelinescheduler.SchedulerLeaf{{
+			Org:           "acme",
+			Repo:          "dummy",
+			SchedulerSpec: scheduler,
+		}})
+		assert.Error(t, err, "ChatOps commands with %s should fail", description)
+	}
+
+	_, _, err := pipelinescheduler.BuildProwConfig([]*pipelinescheduler.SchedulerLeaf{{
+		Org:  "acme",
+		Repo: "dummy",
+		SchedulerSpec: &v1.SchedulerSpec{
+			ChatOpsCommands: &v1.ChatOpsCommands{Items: []*v1.ChatOpsCommand{{Name: &loadtest}}},
+		},
+	}})
+	assert.NoError(t, err)
+}
@@ -68,6 +68,7 @@ func GenerateProw(gitOps bool, autoApplyConfigUpdater bool, jxClient versioned.I
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "building scheduler")
 		}
+		merged = ApplyMonorepoApps(merged, sourceRepo.Spec.Apps)
 		leaves = append(leaves, &SchedulerLeaf{
 			Repo:          sourceRepo.Spec.Repo,
 			Org:           sourceRepo.Spec.Org,
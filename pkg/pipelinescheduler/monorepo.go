@@ -0,0 +1,102 @@
+package pipelinescheduler
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+)
+
+// ApplyMonorepoApps returns a copy of the scheduler with a presubmit and postsubmit job per application of the
+// monorepo which is only triggered when the files of the application change. The context of each job is the name of
+// the application so that its pipeline is loaded from the jenkins-x-<app>.yml file in the root of the repository
+func ApplyMonorepoApps(scheduler *jenkinsv1.SchedulerSpec, apps []jenkinsv1.SourceRepositoryApp) *jenkinsv1.SchedulerSpec {
+	if scheduler == nil || len(apps) == 0 {
+		return scheduler
+	}
+	// the merged scheduler may be shared with other repositories so lets not modify it
+	answer := *scheduler
+	if scheduler.Presubmits != nil {
+		presubmits := &jenkinsv1.Presubmits{Replace: scheduler.Presubmits.Replace}
+		for _, presubmit := range scheduler.Presubmits.Items {
+			for i, app := range apps {
+				presubmits.Items = append(presubmits.Items, monorepoPresubmit(presubmit, app, i == 0))
+			}
+		}
+		answer.Presubmits = presubmits
+	}
+	if scheduler.Postsubmits != nil {
+		postsubmits := &jenkinsv1.Postsubmits{Replace: scheduler.Postsubmits.Replace}
+		for _, postsubmit := range scheduler.Postsubmits.Items {
+			for _, app := range apps {
+				postsubmits.Items = append(postsubmits.Items, monorepoPostsubmit(postsubmit, app))
+			}
+		}
+		answer.Postsubmits = postsubmits
+	}
+	return &answer
+}
+
+// MonorepoRunIfChanged returns the regular expression matching the changed files which trigger the pipelines of the
+// application
+func MonorepoRunIfChanged(app jenkinsv1.SourceRepositoryApp) string {
+	dir := strings.Trim(path.Clean(strings.Replace(app.Path, "\\", "/", -1)), "/")
+	return fmt.Sprintf("^(%s/|%s)", regexp.QuoteMeta(dir), regexp.QuoteMeta(MonorepoPipelineFileName(app.Name)))
+}
+
+// MonorepoPipelineFileName returns the name of the pipeline file of the application in the root of the monorepo
+func MonorepoPipelineFileName(appName string) string {
+	return fmt.Sprintf("jenkins-x-%s.yml", appName)
+}
+
+// monorepoPresubmit copies the presubmit for the application. The tide queries and branch protection are only kept
+// on the first application as they apply to the whole repository
+func monorepoPresubmit(presubmit *jenkinsv1.Presubmit, app jenkinsv1.SourceRepositoryApp, first bool) *jenkinsv1.Presubmit {
+	answer := presubmit.DeepCopy()
+	if answer.JobBase == nil {
+		answer.JobBase = &jenkinsv1.JobBase{}
+	}
+	answer.Name = monorepoJobName(answer.Name, app)
+	answer.Context = &app.Name
+	runIfChanged := MonorepoRunIfChanged(app)
+	answer.RegexpChangeMatcher = &jenkinsv1.RegexpChangeMatcher{
+		RunIfChanged: &runIfChanged,
+	}
+	// prow does not allow jobs which always run to be filtered by the changed files
+	alwaysRun := false
+	answer.AlwaysRun = &alwaysRun
+	trigger := fmt.Sprintf("(?m)^/test( all| this| %s),?(\\s+|$)", regexp.QuoteMeta(app.Name))
+	rerunCommand := "/test " + app.Name
+	answer.Trigger = &trigger
+	answer.RerunCommand = &rerunCommand
+	if !first {
+		answer.Queries = nil
+		answer.Policy = nil
+		answer.MergeType = nil
+	}
+	return answer
+}
+
+func monorepoPostsubmit(postsubmit *jenkinsv1.Postsubmit, app jenkinsv1.SourceRepositoryApp) *jenkinsv1.Postsubmit {
+	answer := postsubmit.DeepCopy()
+	if answer.JobBase == nil {
+		answer.JobBase = &jenkinsv1.JobBase{}
+	}
+	answer.Name = monorepoJobName(answer.Name, app)
+	answer.Context = &app.Name
+	runIfChanged := MonorepoRunIfChanged(app)
+	answer.RegexpChangeMatcher = &jenkinsv1.RegexpChangeMatcher{
+		RunIfChanged: &runIfChanged,
+	}
+	return answer
+}
+
+func monorepoJobName(name *string, app jenkinsv1.SourceRepositoryApp) *string {
+	answer := app.Name
+	if name != nil && *name != "" {
+		answer = *name + "-" + app.Name
+	}
+	return &answer
+}
@@ -0,0 +1,218 @@
+package policies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath the default directory of the policies in their git repository
+	DefaultPath = "policy"
+	// DefaultNamespace the default rego package of the policies
+	DefaultNamespace = "main"
+	// CommentMarker the marker of the Pull Request comment of the policy violations so that it is updated by each build
+	CommentMarker = "<!-- jx policies -->"
+)
+
+// Result the results of the policies of a rego namespace for a manifest reported by conftest
+type Result struct {
+	Filename  string    `json:"filename"`
+	Namespace string    `json:"namespace"`
+	Warnings  []Message `json:"warnings"`
+	Failures  []Message `json:"failures"`
+}
+
+// Message the message of a warn or deny rule which fired
+type Message struct {
+	Msg string `json:"msg"`
+}
+
+// Manifest a rendered manifest and its path relative to the directory of the manifests
+type Manifest struct {
+	Path    string
+	Content string
+}
+
+// Verifier verifies rendered manifests pass the rego policies of a git repository using conftest
+type Verifier struct {
+	// PolicyDir a local directory of the policies to use instead of cloning their git repository
+	PolicyDir string
+	// AllNamespaces verifies the policies of all the rego packages
+	AllNamespaces bool
+	Git           gits.Gitter
+	Err           io.Writer
+	CommandRunner func(*util.Command) (string, error)
+}
+
+// DefaultConfig returns a copy of the policy settings with the default path and namespaces
+func DefaultConfig(policyConfig *config.PolicyConfig) *config.PolicyConfig {
+	answer := &config.PolicyConfig{}
+	if policyConfig != nil {
+		answer = policyConfig.DeepCopy()
+	}
+	if answer.Path == "" {
+		answer.Path = DefaultPath
+	}
+	if len(answer.Namespaces) == 0 {
+		answer.Namespaces = []string{DefaultNamespace}
+	}
+	return answer
+}
+
+// WriteManifests writes the manifests into the directory returning their paths relative to it
+func WriteManifests(dir string, manifests []Manifest) ([]string, error) {
+	var files []string
+	for _, manifest := range manifests {
+		fileName := filepath.Join(dir, filepath.FromSlash(manifest.Path))
+		if !strings.HasPrefix(fileName, dir+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("the manifest %s is outside of the directory %s", manifest.Path, dir)
+		}
+		err := os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the directory of manifest %s", manifest.Path)
+		}
+		err = ioutil.WriteFile(fileName, []byte(manifest.Content), util.DefaultFileWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to write manifest %s", manifest.Path)
+		}
+		files = append(files, manifest.Path)
+	}
+	return files, nil
+}
+
+// Verify verifies the manifest files, relative to the manifests directory, pass the policies returning their results.
+// Unless the verifier has a local policy directory the policies are cloned into the temporary directory
+func (v *Verifier) Verify(tmpDir string, policyConfig *config.PolicyConfig, manifestsDir string, files []string) ([]Result, error) {
+	policyConfig = DefaultConfig(policyConfig)
+	policyDir, err := v.policyDir(tmpDir, policyConfig)
+	if err != nil {
+		return nil, err
+	}
+	return v.runConftest(manifestsDir, policyDir, policyConfig.Namespaces, files)
+}
+
+// policyDir returns the local policy directory or clones the git repository of the policies into the temporary directory
+func (v *Verifier) policyDir(tmpDir string, policyConfig *config.PolicyConfig) (string, error) {
+	if v.PolicyDir != "" {
+		return v.PolicyDir, nil
+	}
+	if policyConfig.URL == "" {
+		return "", util.MissingOption("policy-git-url")
+	}
+	dir := filepath.Join(tmpDir, "policies")
+	log.Logger().Infof("Cloning the policies %s", util.ColorInfo(policyConfig.URL))
+	var err error
+	if policyConfig.Ref == "" {
+		err = v.Git.Clone(policyConfig.URL, dir)
+	} else {
+		err = v.Git.ShallowClone(dir, policyConfig.URL, policyConfig.Ref, "")
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to clone the policies %s", policyConfig.URL)
+	}
+	return filepath.Join(dir, policyConfig.Path), nil
+}
+
+// runConftest runs conftest on the manifest files returning the results of their policies. Conftest exits with an
+// error if any deny rule fires so the results are parsed before checking for an error
+func (v *Verifier) runConftest(dir string, policyDir string, namespaces []string, files []string) ([]Result, error) {
+	args := []string{"test", "--policy", policyDir, "--output", "json"}
+	if v.AllNamespaces {
+		args = append(args, "--all-namespaces")
+	} else {
+		for _, ns := range namespaces {
+			args = append(args, "--namespace", ns)
+		}
+	}
+	args = append(args, files...)
+	runner := v.CommandRunner
+	if runner == nil {
+		runner = (*util.Command).RunWithoutRetry
+	}
+	var out bytes.Buffer
+	_, err := runner(&util.Command{
+		Dir:  dir,
+		Name: "conftest",
+		Args: args,
+		Out:  &out,
+		Err:  v.Err,
+	})
+	results, parseErr := ParseConftestResults(out.Bytes())
+	if parseErr != nil {
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify the policies %s", policyDir)
+		}
+		return nil, parseErr
+	}
+	return results, nil
+}
+
+// ParseConftestResults parses the JSON output of conftest
+func ParseConftestResults(data []byte) ([]Result, error) {
+	var results []Result
+	err := json.Unmarshal(data, &results)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the conftest results")
+	}
+	return results, nil
+}
+
+// CountViolations returns the number of deny and warn rules which fired
+func CountViolations(results []Result) (int, int) {
+	failures := 0
+	warnings := 0
+	for _, r := range results {
+		failures += len(r.Failures)
+		warnings += len(r.Warnings)
+	}
+	return failures, warnings
+}
+
+// LogViolations logs the deny and warn rules which fired
+func LogViolations(results []Result) {
+	for _, r := range results {
+		for _, m := range r.Failures {
+			log.Logger().Errorf("%s: %s", r.Filename, m.Msg)
+		}
+		for _, m := range r.Warnings {
+			log.Logger().Warnf("%s: %s", r.Filename, m.Msg)
+		}
+	}
+}
+
+// Comment returns the markdown of the Pull Request comment listing the policy violations
+func Comment(results []Result) string {
+	failures, warnings := CountViolations(results)
+	var sb strings.Builder
+	sb.WriteString(CommentMarker + "\n")
+	if failures > 0 {
+		sb.WriteString(fmt.Sprintf(":x: **%d policy violations and %d warnings in the rendered manifests**\n\n", failures, warnings))
+	} else {
+		sb.WriteString(fmt.Sprintf(":warning: **%d policy warnings in the rendered manifests**\n\n", warnings))
+	}
+	sb.WriteString("| Result | Manifest | Namespace | Message |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, r := range results {
+		for _, m := range r.Failures {
+			sb.WriteString(fmt.Sprintf("| deny | `%s` | %s | %s |\n", r.Filename, r.Namespace, m.Msg))
+		}
+	}
+	for _, r := range results {
+		for _, m := range r.Warnings {
+			sb.WriteString(fmt.Sprintf("| warn | `%s` | %s | %s |\n", r.Filename, r.Namespace, m.Msg))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+// +build unit
+
+package policies_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/policies"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConftestOutput = `[{"filename":"env/charts/myapp/templates/deployment.yaml","namespace":"kubernetes","successes":1,
+"warnings":[{"msg":"Deployment myapp has no liveness probe"}],
+"failures":[{"msg":"Containers must not run as root"}]}]`
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-policies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	manifestsDir := filepath.Join(dir, "manifests")
+	files, err := policies.WriteManifests(manifestsDir, []policies.Manifest{
+		{Path: "env/charts/myapp/templates/deployment.yaml", Content: "kind: Deployment\n"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env/charts/myapp/templates/deployment.yaml"}, files)
+
+	var commands []*util.Command
+	verifier := &policies.Verifier{
+		PolicyDir: filepath.Join(dir, "policy"),
+		CommandRunner: func(cmd *util.Command) (string, error) {
+			commands = append(commands, cmd)
+			data, err := ioutil.ReadFile(filepath.Join(cmd.Dir, cmd.Args[len(cmd.Args)-1]))
+			require.NoError(t, err)
+			assert.Equal(t, "kind: Deployment\n", string(data))
+			_, err = cmd.Out.Write([]byte(testConftestOutput))
+			return "", err
+		},
+	}
+	results, err := verifier.Verify(dir, &config.PolicyConfig{}, manifestsDir, files)
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	assert.Equal(t, []string{"test", "--policy", filepath.Join(dir, "policy"), "--output", "json",
+		"--namespace", policies.DefaultNamespace, "env/charts/myapp/templates/deployment.yaml"}, commands[0].Args)
+
+	failures, warnings := policies.CountViolations(results)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 1, warnings)
+
+	_, err = policies.WriteManifests(manifestsDir, []policies.Manifest{{Path: "../escaped.yaml", Content: "kind: Secret\n"}})
+	assert.Error(t, err, "manifests outside of the directory should fail")
+}
+
+func TestComment(t *testing.T) {
+	results, err := policies.ParseConftestResults([]byte(testConftestOutput))
+	require.NoError(t, err)
+
+	comment := policies.Comment(results)
+	assert.True(t, strings.HasPrefix(comment, policies.CommentMarker), "the comment should start with its marker so it is updated by later builds")
+	assert.Contains(t, comment, "| deny | `env/charts/myapp/templates/deployment.yaml` | kubernetes | Containers must not run as root |")
+	assert.Contains(t, comment, "| warn | `env/charts/myapp/templates/deployment.yaml` | kubernetes | Deployment myapp has no liveness probe |")
+}
@@ -0,0 +1,246 @@
+package projecttemplates
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadTemplateConfig loads the configuration of the project template in the given directory defaulting the directory
+// containing the files of the project
+func LoadTemplateConfig(dir string) (*TemplateConfig, error) {
+	answer := &TemplateConfig{}
+	fileName := filepath.Join(dir, TemplateConfigFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file %s exists", fileName)
+	}
+	if exists {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+		}
+		err = yaml.Unmarshal(data, answer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal YAML in file %s", fileName)
+		}
+	}
+	if answer.Dir == "" {
+		exists, err = util.DirExists(filepath.Join(dir, DefaultTemplateDir))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			answer.Dir = DefaultTemplateDir
+		} else {
+			answer.Dir = "."
+		}
+	}
+	return answer, nil
+}
+
+// LoadValuesSchema returns the JSON schema of the values of the project template in the given directory or nil if
+// the template does not prompt for any values
+func LoadValuesSchema(dir string) ([]byte, error) {
+	fileName := filepath.Join(dir, ValuesSchemaFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file %s exists", fileName)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	return data, nil
+}
+
+// Generate renders the files of the project template in the given directory into the output directory. The paths and
+// contents of the files are go templates which can access the project name via .Name and the values via .Values.
+// Files whose path renders to an empty file or directory name are not generated. Symbolic links are not followed so
+// that a template cannot copy files from outside of it and paths which render outside of the output directory fail
+func (c *TemplateConfig) Generate(dir string, outDir string, data *TemplateData) error {
+	srcDir := filepath.Join(dir, c.Dir)
+	if !insideDir(dir, srcDir) {
+		return fmt.Errorf("the template directory %s is not inside the project template", c.Dir)
+	}
+	exists, err := util.DirExists(srcDir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("the template directory %s does not exist", c.Dir)
+	}
+	return filepath.Walk(srcDir, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			log.Logger().Warnf("not generating %s as it is a symbolic link", fileName)
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, fileName)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if srcDir == filepath.Clean(dir) && (rel == TemplateConfigFileName || rel == ValuesSchemaFileName) {
+			return nil
+		}
+		outPath, err := render(rel, rel, data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render the path of %s", rel)
+		}
+		if !validPath(outPath) {
+			log.Logger().Debugf("not generating %s as its path rendered to %s", rel, outPath)
+			return nil
+		}
+		outFile := filepath.Join(outDir, filepath.FromSlash(outPath))
+		if !insideDir(outDir, outFile) {
+			return fmt.Errorf("the path of %s rendered to %s which is outside of the project", rel, outPath)
+		}
+		err = os.MkdirAll(filepath.Dir(outFile), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the directory of %s", outFile)
+		}
+
+		if c.copyOnly(rel) {
+			err = util.CopyFile(fileName, outFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to copy %s to %s", rel, outFile)
+			}
+			return nil
+		}
+		source, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", fileName)
+		}
+		text, err := render(rel, string(source), data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s", rel)
+		}
+		err = ioutil.WriteFile(outFile, []byte(text), info.Mode())
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", outFile)
+		}
+		return nil
+	})
+}
+
+// HookCommands returns the commands of the hooks of the project template which run in the generated project directory
+// writing their output to the given writers
+func (c *TemplateConfig) HookCommands(dir string, data *TemplateData, out io.Writer, errOut io.Writer) ([]*util.Command, error) {
+	answer := []*util.Command{}
+	for _, hook := range c.Hooks {
+		name := hook.Name
+		if name == "" {
+			name = hook.Command
+		}
+		args := []string{}
+		for _, arg := range hook.Args {
+			text, err := render(name, arg, data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to render the arguments of hook %s", name)
+			}
+			args = append(args, text)
+		}
+		answer = append(answer, &util.Command{
+			Dir:  dir,
+			Name: hook.Command,
+			Args: args,
+			Out:  out,
+			Err:  errOut,
+		})
+	}
+	return answer, nil
+}
+
+// RunHooks runs the commands of the hooks of a project template stopping at the first one which fails
+func RunHooks(commands []*util.Command, commandRunner func(*util.Command) (string, error)) error {
+	for _, cmd := range commands {
+		log.Logger().Infof("running hook %s", util.ColorInfo(cmd.String()))
+		_, err := commandRunner(cmd)
+		if err != nil {
+			return errors.Wrapf(err, "failed to run hook %s", cmd.String())
+		}
+	}
+	return nil
+}
+
+// copyOnly returns true if the file matches one of the patterns of files which are not rendered. Patterns ending in
+// /** match all the files in a directory
+func (c *TemplateConfig) copyOnly(rel string) bool {
+	for _, pattern := range c.CopyOnly {
+		if strings.HasSuffix(pattern, "/**") && strings.HasPrefix(rel, strings.TrimSuffix(pattern, "**")) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(rel)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func render(name string, text string, data *TemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// insideDir returns true if the file is the directory or inside of it
+func insideDir(dir string, fileName string) bool {
+	rel, err := filepath.Rel(dir, fileName)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// validPath returns false if any of the file or directory names of the path are empty
+func validPath(rel string) bool {
+	for _, name := range strings.Split(rel, "/") {
+		if strings.TrimSpace(name) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": strings.Title,
+	"trim":  strings.TrimSpace,
+	"replace": func(old string, new string, text string) string {
+		return strings.Replace(text, old, new, -1)
+	},
+}
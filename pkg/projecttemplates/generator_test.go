@@ -0,0 +1,142 @@
+// +build unit
+
+package projecttemplates_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/projecttemplates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplateConfig = `copyOnly:
+- charts/**
+hooks:
+- name: init
+  command: make
+  args:
+  - init
+  - NAME={{ .Name }}
+`
+
+func TestGenerateProjectFromTemplate(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-project-template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templateDir := filepath.Join(dir, "my-template")
+	files := map[string]string{
+		projecttemplates.TemplateConfigFileName:               testTemplateConfig,
+		projecttemplates.ValuesSchemaFileName:                 `{}`,
+		"template/README.md":                                  "# {{ .Name }}\n\nOwned by {{ .Values.team | upper }}\n",
+		"template/cmd/{{ .Name }}/main.go":                    "package main\n",
+		"template/{{ if .Values.docker }}Dockerfile{{ end }}": "FROM scratch\n",
+		"template/charts/{{ .Name }}/values.yaml":             "image: {{ .Values.image }}\n",
+	}
+	for name, text := range files {
+		fileName := filepath.Join(templateDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions))
+		require.NoError(t, ioutil.WriteFile(fileName, []byte(text), util.DefaultFileWritePermissions))
+	}
+
+	config, err := projecttemplates.LoadTemplateConfig(templateDir)
+	require.NoError(t, err)
+	assert.Equal(t, projecttemplates.DefaultTemplateDir, config.Dir)
+
+	schema, err := projecttemplates.LoadValuesSchema(templateDir)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(schema))
+
+	outDir := filepath.Join(dir, "myapp")
+	data := &projecttemplates.TemplateData{
+		Name: "myapp",
+		Values: map[string]interface{}{
+			"team":   "platform",
+			"docker": false,
+		},
+	}
+	err = config.Generate(templateDir, outDir, data)
+	require.NoError(t, err)
+
+	assertFileContents(t, filepath.Join(outDir, "README.md"), "# myapp\n\nOwned by PLATFORM\n")
+	assertFileContents(t, filepath.Join(outDir, "cmd", "myapp", "main.go"), "package main\n")
+	assertFileContents(t, filepath.Join(outDir, "charts", "myapp", "values.yaml"), "image: {{ .Values.image }}\n")
+	exists, err := util.FileExists(filepath.Join(outDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.False(t, exists, "files whose path renders to an empty name should not be generated")
+
+	hooks, err := config.HookCommands(outDir, data, ioutil.Discard, ioutil.Discard)
+	require.NoError(t, err)
+	commands := [][]string{}
+	err = projecttemplates.RunHooks(hooks, func(cmd *util.Command) (string, error) {
+		assert.Equal(t, outDir, cmd.Dir)
+		commands = append(commands, append([]string{cmd.Name}, cmd.Args...))
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"make", "init", "NAME=myapp"}}, commands)
+}
+
+func TestGenerateRejectsPathsOutsideOfProject(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-project-template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templateDir := filepath.Join(dir, "my-template")
+	secretFile := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(secretFile, []byte("secret\n"), util.DefaultFileWritePermissions))
+	require.NoError(t, os.MkdirAll(filepath.Join(templateDir, "template"), util.DefaultWritePermissions))
+	require.NoError(t, os.Symlink(secretFile, filepath.Join(templateDir, "template", "link")))
+
+	outDir := filepath.Join(dir, "myapp")
+	data := &projecttemplates.TemplateData{Name: "myapp"}
+	config := &projecttemplates.TemplateConfig{Dir: projecttemplates.DefaultTemplateDir}
+	err = config.Generate(templateDir, outDir, data)
+	require.NoError(t, err)
+	exists, err := util.FileExists(filepath.Join(outDir, "link"))
+	require.NoError(t, err)
+	assert.False(t, exists, "symbolic links should not be generated")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(templateDir, "template", "{{ .Name }}"), []byte("escaped\n"), util.DefaultFileWritePermissions))
+	data.Name = "../escaped"
+	err = config.Generate(templateDir, outDir, data)
+	assert.Error(t, err, "paths which render outside of the project should fail")
+	exists, err = util.FileExists(filepath.Join(dir, "escaped"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	config.Dir = ".."
+	err = config.Generate(templateDir, outDir, data)
+	assert.Error(t, err, "the template directory should be inside the project template")
+}
+
+func TestLoadTemplateConfigDefaultsToRootDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-project-template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config, err := projecttemplates.LoadTemplateConfig(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ".", config.Dir)
+
+	schema, err := projecttemplates.LoadValuesSchema(dir)
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+}
+
+func assertFileContents(t *testing.T, fileName string, expected string) {
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err, "failed to load file %s", fileName)
+	assert.Equal(t, expected, string(data), "contents of file %s", fileName)
+}
@@ -0,0 +1,43 @@
+package projecttemplates
+
+const (
+	// TemplateConfigFileName the name of the file in the root of a project template which configures how projects are
+	// generated from it
+	TemplateConfigFileName = "jx-template.yml"
+
+	// ValuesSchemaFileName the name of the file in the root of a project template containing the JSON schema of the
+	// values prompted for when generating a project
+	ValuesSchemaFileName = "values.schema.json"
+
+	// DefaultTemplateDir the default directory of a project template containing the files of the generated project
+	DefaultTemplateDir = "template"
+)
+
+// TemplateConfig the configuration of a project template
+type TemplateConfig struct {
+	// Dir the directory of the template containing the files of the generated project. Defaults to the template
+	// directory if it exists otherwise the root of the template
+	Dir string `json:"dir,omitempty"`
+
+	// CopyOnly the patterns of the files which are copied without being rendered such as binary files
+	CopyOnly []string `json:"copyOnly,omitempty"`
+
+	// Hooks the commands which are run in the generated project before it is imported
+	Hooks []Hook `json:"hooks,omitempty"`
+}
+
+// Hook a command which is run in the generated project. The arguments are rendered with the template values
+type Hook struct {
+	Name    string   `json:"name,omitempty"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// TemplateData the data available to the paths and contents of the files of a project template
+type TemplateData struct {
+	// Name the name of the generated project
+	Name string
+
+	// Values the values of the template which are either prompted for or passed via --set
+	Values map[string]interface{}
+}
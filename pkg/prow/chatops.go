@@ -0,0 +1,40 @@
+package prow
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ChatOpsParameterLabelPrefix the prefix of the labels of the jobs of custom ChatOps commands which pass the parameters
+// of the command to its pipeline as environment variables
+const ChatOpsParameterLabelPrefix = "chatops.jenkins-x.io/"
+
+// ChatOpsParameterLabels returns the job labels of the parameters of a custom ChatOps command. The parameter names and
+// values must be valid label names and values so they can be passed through the scheduler to the pipeline
+func ChatOpsParameterLabels(parameters map[string]string) (map[string]string, error) {
+	answer := map[string]string{}
+	for name, value := range parameters {
+		key := ChatOpsParameterLabelPrefix + name
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid ChatOps parameter name %s: %s", name, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %q of ChatOps parameter %s: %s", value, name, strings.Join(errs, "; "))
+		}
+		answer[key] = value
+	}
+	return answer, nil
+}
+
+// ChatOpsParameters returns the parameters of a custom ChatOps command from the labels of its job
+func ChatOpsParameters(labels map[string]string) map[string]string {
+	answer := map[string]string{}
+	for key, value := range labels {
+		if strings.HasPrefix(key, ChatOpsParameterLabelPrefix) {
+			answer[strings.TrimPrefix(key, ChatOpsParameterLabelPrefix)] = value
+		}
+	}
+	return answer
+}
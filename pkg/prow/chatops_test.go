@@ -0,0 +1,28 @@
+// +build unit
+
+package prow_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/prow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatOpsParameterLabels(t *testing.T) {
+	labels, err := prow.ChatOpsParameterLabels(map[string]string{"ENVIRONMENT": "staging", "USERS": "100"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"chatops.jenkins-x.io/ENVIRONMENT": "staging",
+		"chatops.jenkins-x.io/USERS":       "100",
+	}, labels)
+
+	labels["prowJobName"] = "aa0e6d6b-1b4c-11ea-8a5f-0a580a300414"
+	assert.Equal(t, map[string]string{"ENVIRONMENT": "staging", "USERS": "100"}, prow.ChatOpsParameters(labels))
+
+	_, err = prow.ChatOpsParameterLabels(map[string]string{"ENVIRONMENT": "staging and production"})
+	assert.Error(t, err)
+	_, err = prow.ChatOpsParameterLabels(map[string]string{"TARGET ENV": "staging"})
+	assert.Error(t, err)
+}
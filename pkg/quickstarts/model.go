@@ -2,6 +2,7 @@ package quickstarts
 
 import (
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -223,6 +224,64 @@ func (model *QuickstartModel) LoadQuickStarts(quickstarts *versionstream.QuickSt
 	return nil
 }
 
+// LoadCatalogQuickStarts loads the quickstarts from the index of a catalog git repository. The quickstarts hosted on
+// the git server of the catalog are downloaded using its git provider so that they can be in private repositories.
+// Quickstarts with the same ID as one already in the model are ignored so that a catalog cannot replace them
+func (model *QuickstartModel) LoadCatalogQuickStarts(quickstarts *versionstream.QuickStarts, provider gits.GitProvider, gitServer string, gitKind string) error {
+	for _, q := range quickstarts.QuickStarts {
+		if q.DownloadZipURL == "" && provider != nil && q.Name != "" {
+			owner := q.Owner
+			if owner == "" {
+				owner = quickstarts.DefaultOwner
+			}
+			q.DownloadZipURL = provider.BranchArchiveURL(owner, q.Name, q.GetBranch())
+		}
+	}
+	quickstarts.DefaultMissingValues()
+	added := &versionstream.QuickStarts{DefaultOwner: quickstarts.DefaultOwner}
+	for _, q := range quickstarts.QuickStarts {
+		if model.Quickstarts[q.ID] != nil {
+			log.Logger().Warnf("ignoring quickstart %s of the catalog on %s as there is already a quickstart with the same ID", q.ID, gitServer)
+			continue
+		}
+		added.QuickStarts = append(added.QuickStarts, q)
+	}
+	err := model.LoadQuickStarts(added)
+	if err != nil {
+		return err
+	}
+	for _, from := range added.QuickStarts {
+		to := model.Quickstarts[from.ID]
+		if to == nil {
+			continue
+		}
+		to.GitServer = gitServer
+		to.GitKind = gitKind
+		if provider != nil && isGitProviderDownload(to.DownloadZipURL, provider.BranchArchiveURL(to.Owner, to.Name, from.GetBranch()), gitServer) {
+			to.GitProvider = provider
+		}
+	}
+	return nil
+}
+
+// isGitProviderDownload returns true if the download URL is on the git server or the host of its archives so that
+// the credentials of the git provider are never sent to any other host
+func isGitProviderDownload(downloadURL string, archiveURL string, gitServer string) bool {
+	host := urlHost(downloadURL)
+	if host == "" {
+		return false
+	}
+	return host == urlHost(archiveURL) || host == urlHost(gitServer)
+}
+
+func urlHost(text string) string {
+	u, err := url.Parse(text)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
 func (model *QuickstartModel) convertToQuickStart(from *versionstream.QuickStart, to *Quickstart) error {
 	s := func(text string, override string) string {
 		if override != "" {
@@ -247,6 +306,7 @@ func (model *QuickstartModel) convertToQuickStart(from *versionstream.QuickStart
 	to.DownloadZipURL = s(to.DownloadZipURL, from.DownloadZipURL)
 	to.Framework = s(to.Framework, from.Framework)
 	to.Language = s(to.Language, from.Language)
+	to.License = s(to.License, from.License)
 	to.Tags = ss(to.Tags, from.Tags)
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/quickstarts"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -237,3 +239,95 @@ func TestQuickstartCreateVersion(t *testing.T) {
 	require.NoError(t, err, "failed to parse semantic version %s for quickstart", v)
 	t.Logf("parsed semantic version %s for quickstart", sv.String())
 }
+
+func TestQuickstartModelFilterLicense(t *testing.T) {
+	t.Parallel()
+
+	quickstart1 := &quickstarts.Quickstart{
+		ID:      "myorg/go-http",
+		Name:    "go-http",
+		License: "Apache-2.0",
+	}
+	quickstart2 := &quickstarts.Quickstart{
+		ID:      "myorg/go-grpc",
+		Name:    "go-grpc",
+		License: "MIT",
+	}
+
+	quickstartModel := &quickstarts.QuickstartModel{
+		Quickstarts: map[string]*quickstarts.Quickstart{
+			quickstart1.ID: quickstart1,
+			quickstart2.ID: quickstart2,
+		},
+	}
+
+	results := quickstartModel.Filter(&quickstarts.QuickstartFilter{
+		License: "apache-2.0",
+	})
+
+	assert.Equal(t, 1, len(results))
+	assert.Contains(t, results, quickstart1)
+}
+
+func TestQuickstartModelLoadCatalogQuickStarts(t *testing.T) {
+	t.Parallel()
+
+	quickstartModel := quickstarts.NewQuickstartModel()
+	quickstartModel.Add(&quickstarts.Quickstart{
+		ID:       "jenkins-x-quickstarts/golang-http",
+		Owner:    "jenkins-x-quickstarts",
+		Name:     "golang-http",
+		Language: "go",
+	})
+
+	provider := gits.NewFakeProvider()
+	index := &versionstream.QuickStarts{
+		DefaultOwner: "myorg",
+		QuickStarts: []*versionstream.QuickStart{
+			{
+				Name:     "go-http",
+				Language: "go",
+				License:  "Apache-2.0",
+			},
+			{
+				Owner:          "otherorg",
+				Name:           "node-http",
+				Language:       "javascript",
+				DownloadZipURL: "https://example.com/node-http.zip",
+			},
+			{
+				Owner:  "myorg",
+				Name:   "spring-boot-http",
+				Branch: "main",
+			},
+			{
+				Owner:          "jenkins-x-quickstarts",
+				Name:           "golang-http",
+				DownloadZipURL: "https://example.com/golang-http.zip",
+			},
+		},
+	}
+	err := quickstartModel.LoadCatalogQuickStarts(index, provider, "https://mygit.server.com", gits.KindGitlab)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"jenkins-x-quickstarts/golang-http", "myorg/go-http", "myorg/spring-boot-http", "otherorg/node-http"}, quickstartModel.SortedNames())
+
+	q := quickstartModel.Quickstarts["myorg/go-http"]
+	assert.Equal(t, "Apache-2.0", q.License)
+	assert.Equal(t, provider.BranchArchiveURL("myorg", "go-http", "master"), q.DownloadZipURL)
+	assert.Equal(t, "https://mygit.server.com", q.GitServer)
+	assert.Equal(t, gits.KindGitlab, q.GitKind)
+	assert.Equal(t, provider, q.GitProvider)
+
+	q = quickstartModel.Quickstarts["otherorg/node-http"]
+	assert.Equal(t, "https://example.com/node-http.zip", q.DownloadZipURL)
+	assert.Nil(t, q.GitProvider, "the credentials of the catalog should not be used to download from other hosts")
+
+	q = quickstartModel.Quickstarts["myorg/spring-boot-http"]
+	assert.Equal(t, provider.BranchArchiveURL("myorg", "spring-boot-http", "main"), q.DownloadZipURL)
+
+	q = quickstartModel.Quickstarts["jenkins-x-quickstarts/golang-http"]
+	assert.Nil(t, q.GitProvider, "the quickstarts of other catalogs should not be modified")
+	assert.Empty(t, q.DownloadZipURL, "a catalog should not replace an existing quickstart")
+	assert.Empty(t, q.GitServer)
+}
@@ -34,6 +34,10 @@ func (f *QuickstartFilter) Matches(q *Quickstart) bool {
 	if framework != "" && strings.ToLower(q.Framework) != framework {
 		return false
 	}
+	license := strings.ToLower(f.License)
+	if license != "" && strings.ToLower(q.License) != license {
+		return false
+	}
 	if !f.AllowML && util.StartsWith(q.Name, "ML-") {
 		return false
 	}
@@ -13,6 +13,7 @@ type Quickstart struct {
 	Framework      string
 	Tags           []string
 	DownloadZipURL string
+	License        string
 	GitServer      string
 	GitKind        string
 	GitProvider    gits.GitProvider
@@ -29,6 +30,7 @@ type QuickstartFilter struct {
 	Text        string
 	ProjectName string
 	Tags        []string
+	License     string
 	AllowML     bool
 }
 
@@ -0,0 +1,86 @@
+package awssecretsmanager
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/jenkins-x/jx/v2/pkg/cloud/amazon/session"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+)
+
+// Backend stores each secret as a JSON object in the SecretString of an AWS Secrets Manager secret
+type Backend struct {
+	API    secretsmanageriface.SecretsManagerAPI
+	Prefix string
+}
+
+// NewClient creates a secret URL client for AWS Secrets Manager in the given region, which defaults to the region
+// of the AWS profile
+func NewClient(region string, prefix string) (secreturl.Client, error) {
+	sess, err := session.NewAwsSession("", region)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the AWS session for region %s", region)
+	}
+	backend := &Backend{
+		API:    secretsmanager.New(sess),
+		Prefix: prefix,
+	}
+	return secreturl.NewBackendClient(backend, secreturl.AWSSecretsManagerScheme), nil
+}
+
+// Read reads the keys and values of the named secret
+func (b *Backend) Read(secretName string) (map[string]interface{}, error) {
+	name := b.secretID(secretName)
+	output, err := b.API.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the value of secret %s", name)
+	}
+	data := map[string]interface{}{}
+	err = json.Unmarshal([]byte(aws.StringValue(output.SecretString)), &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the value of secret %s as a JSON object", name)
+	}
+	return data, nil
+}
+
+// Write writes the keys and values of the named secret, creating it if it does not exist
+func (b *Backend) Write(secretName string, data map[string]interface{}) error {
+	name := b.secretID(secretName)
+	value, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the value of secret %s", name)
+	}
+	_, err = b.API.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(string(value)),
+	})
+	if err == nil {
+		return nil
+	}
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != secretsmanager.ErrCodeResourceNotFoundException {
+		return errors.Wrapf(err, "failed to put the value of secret %s", name)
+	}
+	_, err = b.API.CreateSecret(&secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(string(value)),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create secret %s", name)
+	}
+	return nil
+}
+
+// secretID returns the name of the secret which can contain slashes so the path is used as is
+func (b *Backend) secretID(secretName string) string {
+	if b.Prefix == "" {
+		return secretName
+	}
+	return path.Join(b.Prefix, secretName)
+}
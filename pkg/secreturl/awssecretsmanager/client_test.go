@@ -0,0 +1,59 @@
+// +build unit
+
+package awssecretsmanager_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/awssecretsmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func (f *fakeSecretsManager) PutSecretValue(input *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	if _, ok := f.secrets[aws.StringValue(input.SecretId)]; !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	f.secrets[aws.StringValue(input.SecretId)] = aws.StringValue(input.SecretString)
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (f *fakeSecretsManager) CreateSecret(input *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	f.secrets[aws.StringValue(input.Name)] = aws.StringValue(input.SecretString)
+	return &secretsmanager.CreateSecretOutput{}, nil
+}
+
+func TestBackend(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeSecretsManager{secrets: map[string]string{}}
+	backend := &awssecretsmanager.Backend{API: api, Prefix: "jx"}
+
+	require.NoError(t, backend.Write("mycluster/admin", map[string]interface{}{"password": "s3cr3t"}))
+	assert.Equal(t, `{"password":"s3cr3t"}`, api.secrets["jx/mycluster/admin"], "should create the secret with the prefix")
+	require.NoError(t, backend.Write("mycluster/admin", map[string]interface{}{"password": "changed"}))
+
+	data, err := backend.Read("mycluster/admin")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "changed"}, data)
+
+	_, err = backend.Read("mycluster/missing")
+	assert.Error(t, err)
+}
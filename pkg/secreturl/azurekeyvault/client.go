@@ -0,0 +1,93 @@
+package azurekeyvault
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Backend stores each secret as a JSON object in the value of an Azure Key Vault secret using the az CLI
+type Backend struct {
+	// KeyVault the name of the key vault
+	KeyVault string
+	// Prefix the optional prefix of the secret names
+	Prefix string
+	// Runner runs the az commands. Defaults to running them without retries
+	Runner func(cmd *util.Command) (string, error)
+}
+
+// NewClient creates a secret URL client for the given Azure Key Vault
+func NewClient(keyVault string, prefix string) (secreturl.Client, error) {
+	if keyVault == "" {
+		return nil, errors.Errorf("the name of the Azure Key Vault must be specified with secretBackend.keyVault in the requirements")
+	}
+	backend := &Backend{
+		KeyVault: keyVault,
+		Prefix:   prefix,
+	}
+	return secreturl.NewBackendClient(backend, secreturl.AzureKeyVaultScheme), nil
+}
+
+// Read reads the keys and values of the named secret
+func (b *Backend) Read(secretName string) (map[string]interface{}, error) {
+	name := b.secretName(secretName)
+	text, err := b.az("keyvault", "secret", "show", "--vault-name", b.KeyVault, "--name", name, "--query", "value", "--output", "tsv")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to show secret %s in key vault %s", name, b.KeyVault)
+	}
+	data := map[string]interface{}{}
+	err = json.Unmarshal([]byte(text), &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the value of secret %s as a JSON object", name)
+	}
+	return data, nil
+}
+
+// Write sets the value of the named secret which creates it if it does not exist
+func (b *Backend) Write(secretName string, data map[string]interface{}) error {
+	name := b.secretName(secretName)
+	value, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the value of secret %s", name)
+	}
+	// lets pass the value in a file only readable by the user so that it is not visible in the arguments of the process
+	file, err := ioutil.TempFile("", "jx-azure-key-vault-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary file")
+	}
+	fileName := file.Name()
+	defer util.DestroyFile(fileName) //nolint:errcheck
+	_, err = file.Write(value)
+	file.Close() //nolint:errcheck
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the value of secret %s to %s", name, fileName)
+	}
+	_, err = b.az("keyvault", "secret", "set", "--vault-name", b.KeyVault, "--name", name, "--file", fileName, "--encoding", "utf-8", "--output", "none")
+	if err != nil {
+		return errors.Wrapf(err, "failed to set secret %s in key vault %s", name, b.KeyVault)
+	}
+	return nil
+}
+
+func (b *Backend) az(args ...string) (string, error) {
+	cmd := &util.Command{
+		Name: "az",
+		Args: args,
+	}
+	if b.Runner != nil {
+		return b.Runner(cmd)
+	}
+	return cmd.RunWithoutRetry()
+}
+
+// secretName returns the name of the secret escaping the slashes of the path which are not allowed in secret names
+func (b *Backend) secretName(secretName string) string {
+	name := secreturl.EscapeSecretName(secretName, '-', "")
+	if b.Prefix != "" {
+		name = secreturl.EscapeSecretName(b.Prefix, '-', "") + "-" + name
+	}
+	return name
+}
@@ -0,0 +1,117 @@
+package secreturl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AWSSecretsManagerScheme the URI scheme of secrets stored in AWS Secrets Manager
+	AWSSecretsManagerScheme = "aws-secrets-manager"
+	// GCPSecretManagerScheme the URI scheme of secrets stored in GCP Secret Manager
+	GCPSecretManagerScheme = "gcp-secret-manager"
+	// AzureKeyVaultScheme the URI scheme of secrets stored in Azure Key Vault
+	AzureKeyVaultScheme = "azure-key-vault"
+)
+
+// URISchemes the schemes of the secret URIs which can be used in values files
+var URISchemes = []string{"vault", "local", AWSSecretsManagerScheme, GCPSecretManagerScheme, AzureKeyVaultScheme}
+
+// Backend the storage of a secret manager which stores each secret as a map of keys to values. Any Backend can
+// be used to resolve the secret URIs of its scheme by wrapping it with NewBackendClient
+type Backend interface {
+	// Read reads the keys and values of the named secret
+	Read(secretName string) (map[string]interface{}, error)
+
+	// Write writes the keys and values of the named secret, creating it if it does not exist
+	Write(secretName string, data map[string]interface{}) error
+}
+
+// BackendClient a Client which stores the secrets in a Backend
+type BackendClient struct {
+	Backend Backend
+	Scheme  string
+	regex   *regexp.Regexp
+}
+
+// NewBackendClient creates a Client for the backend which replaces the URIs of the given scheme
+func NewBackendClient(backend Backend, scheme string) Client {
+	return &BackendClient{
+		Backend: backend,
+		Scheme:  scheme,
+		regex:   regexp.MustCompile(fmt.Sprintf(`:[\s"]*%s:[-_.\w\/:]*`, regexp.QuoteMeta(scheme))),
+	}
+}
+
+// Read reads a named secret from the backend
+func (c *BackendClient) Read(secretName string) (map[string]interface{}, error) {
+	return c.Backend.Read(secretName)
+}
+
+// ReadObject reads a generic named object from the backend.
+// The secret _must_ be serializable to JSON.
+func (c *BackendClient) ReadObject(secretName string, secret interface{}) error {
+	m, err := c.Read(secretName)
+	if err != nil {
+		return errors.Wrapf(err, "reading the secret %q from %s", secretName, c.Scheme)
+	}
+	err = util.ToStructFromMapStringInterface(m, secret)
+	if err != nil {
+		return errors.Wrapf(err, "deserializing the secret %q from %s", secretName, c.Scheme)
+	}
+	return nil
+}
+
+// Write writes a named secret to the backend
+func (c *BackendClient) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	err := c.Backend.Write(secretName, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "writing the secret %q to %s", secretName, c.Scheme)
+	}
+	return data, nil
+}
+
+// WriteObject writes a generic named object to the backend.
+// The secret _must_ be serializable to JSON.
+func (c *BackendClient) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	data, err := util.ToMapStringInterfaceFromStruct(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing the secret %q", secretName)
+	}
+	return c.Write(secretName, data)
+}
+
+// ReplaceURIs will replace any URIs of the scheme of the backend in a string
+func (c *BackendClient) ReplaceURIs(s string) (string, error) {
+	return ReplaceURIs(s, c, c.regex, c.Scheme+":")
+}
+
+// EscapeSecretName returns the name of a secret for a secret manager which only allows letters, digits and the given
+// punctuation in its names. Any other character, and the escape character itself, is replaced by the escape character
+// followed by the hex code of each of its bytes so that different names never map to the same escaped name
+func EscapeSecretName(name string, escape byte, punctuation string) string {
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != escape && (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(punctuation, c) >= 0) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%c%02x", escape, c))
+	}
+	return sb.String()
+}
+
+// IsURI returns true if the value is a secret URI of one of the URISchemes
+func IsURI(value string) bool {
+	for _, scheme := range URISchemes {
+		if strings.HasPrefix(value, scheme+":") {
+			return true
+		}
+	}
+	return false
+}
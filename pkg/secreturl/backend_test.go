@@ -0,0 +1,75 @@
+// +build unit
+
+package secreturl_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	secrets map[string]map[string]interface{}
+}
+
+func (b *fakeBackend) Read(secretName string) (map[string]interface{}, error) {
+	data, ok := b.secrets[secretName]
+	if !ok {
+		return nil, errors.Errorf("secret %s not found", secretName)
+	}
+	return data, nil
+}
+
+func (b *fakeBackend) Write(secretName string, data map[string]interface{}) error {
+	b.secrets[secretName] = data
+	return nil
+}
+
+func TestBackendClient(t *testing.T) {
+	t.Parallel()
+
+	backend := &fakeBackend{secrets: map[string]map[string]interface{}{}}
+	client := secreturl.NewBackendClient(backend, secreturl.AWSSecretsManagerScheme)
+
+	type admin struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	_, err := client.WriteObject("mycluster/admin", admin{Username: "admin", Password: "s3cr3t"})
+	require.NoError(t, err)
+	actual := admin{}
+	require.NoError(t, client.ReadObject("mycluster/admin", &actual))
+	assert.Equal(t, "s3cr3t", actual.Password)
+
+	text, err := client.ReplaceURIs(`password: aws-secrets-manager:mycluster/admin:password
+token: "vault:mycluster/token:value"
+user: "aws-secrets-manager:mycluster/admin:username"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, `password: s3cr3t
+token: "vault:mycluster/token:value"
+user: admin
+`, text, "should only replace the URIs of the scheme of the backend")
+
+	_, err = client.ReplaceURIs("password: aws-secrets-manager:mycluster/missing:password")
+	assert.Error(t, err)
+}
+
+func TestIsURI(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, secreturl.IsURI("vault:mycluster/admin:password"))
+	assert.True(t, secreturl.IsURI("azure-key-vault:mycluster/admin:password"))
+	assert.False(t, secreturl.IsURI("s3cr3t"))
+}
+
+func TestEscapeSecretName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "mycluster_2fadmin", secreturl.EscapeSecretName("mycluster/admin", '_', "-"))
+	assert.Equal(t, "mycluster_5fadmin", secreturl.EscapeSecretName("mycluster_admin", '_', "-"), "should escape the escape character")
+	assert.Equal(t, "my-2dcluster-2fadmin", secreturl.EscapeSecretName("my-cluster/admin", '-', ""))
+}
@@ -21,3 +21,12 @@ type Client interface {
 	// ReplaceURIs will replace any vault: URIs in a string (or whatever URL scheme the secret URL client supports
 	ReplaceURIs(text string) (string, error)
 }
+
+// VersionedClient is a Client which can also read the secrets in a KV v2 mount of a namespace, pinned to a
+// metadata version if the version is not zero
+type VersionedClient interface {
+	Client
+
+	// ReadVersion reads a named secret from the mount in the namespace
+	ReadVersion(namespace string, mount string, secretName string, version int) (map[string]interface{}, error)
+}
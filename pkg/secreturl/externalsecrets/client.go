@@ -0,0 +1,301 @@
+package externalsecrets
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion the API version of the generated ExternalSecrets
+	APIVersion = "external-secrets.io/v1beta1"
+	// Kind the kind of the generated resources
+	Kind = "ExternalSecret"
+	// DefaultSecretStoreKind the kind of the secret store used if none is configured
+	DefaultSecretStoreKind = "ClusterSecretStore"
+	// DefaultRefreshInterval how often the secrets are refreshed if no interval is configured
+	DefaultRefreshInterval = "1h"
+
+	placeholderPrefix = "jx-external-secret-"
+)
+
+var placeholderRegex = regexp.MustCompile(placeholderPrefix + `[0-9a-f]{16}`)
+
+// Ref the remote reference of a secret value in the secret store of the external secrets operator
+type Ref struct {
+	// Key the path of the secret in the secret store. Any vault namespace and mount of the URI are configured by the
+	// secret store instead
+	Key string
+	// Property the key of the value in the secret
+	Property string
+	// Version the version of the secret or blank for the latest
+	Version string
+}
+
+// Client a secret URL client which never reads the secrets. Instead it replaces the secret URIs with placeholders
+// so that the Secrets rendered from them can be converted into ExternalSecrets referencing the secret store
+type Client struct {
+	Config config.ExternalSecretsConfig
+
+	lock sync.Mutex
+	refs map[string]Ref
+}
+
+// NewClient creates a client for the ExternalSecrets settings of the requirements
+func NewClient(externalSecrets *config.ExternalSecretsConfig) (*Client, error) {
+	c := &Client{refs: map[string]Ref{}}
+	if externalSecrets != nil {
+		c.Config = *externalSecrets
+	}
+	if c.Config.SecretStore == "" {
+		return nil, errors.Errorf("the externalSecrets.secretStore of the requirements must be specified when the secretStorage is %s", config.SecretStorageTypeExternalSecrets)
+	}
+	if c.Config.SecretStoreKind == "" {
+		c.Config.SecretStoreKind = DefaultSecretStoreKind
+	}
+	if c.Config.RefreshInterval == "" {
+		c.Config.RefreshInterval = DefaultRefreshInterval
+	}
+	return c, nil
+}
+
+// Read fails as the secrets are only read by the external secrets operator
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	return nil, errors.Errorf("cannot read secret %s as the secrets are populated by the external secrets operator", secretName)
+}
+
+// ReadObject fails as the secrets are only read by the external secrets operator
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	_, err := c.Read(secretName)
+	return err
+}
+
+// Write fails as the secrets are written to the secret store of the external secrets operator directly
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	return nil, errors.Errorf("cannot write secret %s as the secrets are populated by the external secrets operator", secretName)
+}
+
+// WriteObject fails as the secrets are written to the secret store of the external secrets operator directly
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	return c.Write(secretName, nil)
+}
+
+// ReplaceURIs replaces the secret URIs of any scheme with placeholders recording their remote references
+func (c *Client) ReplaceURIs(text string) (string, error) {
+	var err error
+	for _, scheme := range secreturl.URISchemes {
+		if err != nil {
+			break
+		}
+		schemePrefix := scheme + ":"
+		r := regexp.MustCompile(`:[\s"]*` + regexp.QuoteMeta(schemePrefix) + `[-_.\w\/:#?=]*`)
+		text = r.ReplaceAllStringFunc(text, func(found string) string {
+			if err != nil {
+				return found
+			}
+			i := strings.Index(found, schemePrefix)
+			pathAndKey := strings.Trim(found[i+len(schemePrefix):], "\"")
+			uri, err1 := secreturl.ParseURI(pathAndKey)
+			if err1 != nil {
+				err = err1
+				return found
+			}
+			ref := Ref{Key: uri.Path, Property: uri.Key}
+			if uri.Version > 0 {
+				ref.Version = fmt.Sprintf("%d", uri.Version)
+			}
+			return found[:i] + c.placeholder(schemePrefix+pathAndKey, ref)
+		})
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "replacing secret URIs with external secrets")
+	}
+	return text, nil
+}
+
+// placeholder returns the placeholder of the secret URI which is the same every time so that the rendered chart
+// only changes if the URIs change
+func (c *Client) placeholder(uri string, ref Ref) string {
+	hash := sha256.Sum256([]byte(uri))
+	answer := placeholderPrefix + hex.EncodeToString(hash[:])[:16]
+	c.lock.Lock()
+	c.refs[answer] = ref
+	c.lock.Unlock()
+	return answer
+}
+
+func (c *Client) ref(placeholder string) (Ref, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ref, ok := c.refs[placeholder]
+	return ref, ok
+}
+
+// ConvertManifestFile replaces the Secrets in the YAML file which contain placeholders with ExternalSecrets
+// which template the same data from the secret store
+func (c *Client) ConvertManifestFile(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	docs := helm.SplitYAMLDocuments(string(data))
+	modified := false
+	for i, doc := range docs {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		converted, err := c.convertSecret(doc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert the Secrets of file %s", fileName)
+		}
+		if converted != "" {
+			docs[i] = converted
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+	err = ioutil.WriteFile(fileName, []byte(strings.Join(docs, "---\n")), util.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// convertSecret returns the ExternalSecret of the YAML document if it is a Secret containing placeholders or a
+// blank string otherwise. Placeholders in any other kind of resource fail as their secret values cannot be templated
+// by an ExternalSecret
+func (c *Client) convertSecret(doc string) (string, error) {
+	secret := map[string]interface{}{}
+	err := yaml.Unmarshal([]byte(doc), &secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse YAML")
+	}
+	if secret["kind"] != "Secret" {
+		for _, placeholder := range placeholderRegex.FindAllString(doc, -1) {
+			if ref, ok := c.ref(placeholder); ok {
+				return "", fmt.Errorf("the secret %s is used by a %v rather than a Secret", ref.Key, secret["kind"])
+			}
+		}
+		return "", nil
+	}
+	values := map[string]string{}
+	if stringData, ok := secret["stringData"].(map[string]interface{}); ok {
+		for k, v := range stringData {
+			values[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	if data, ok := secret["data"].(map[string]interface{}); ok {
+		for k, v := range data {
+			decoded, err := base64.StdEncoding.DecodeString(fmt.Sprintf("%v", v))
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to decode key %s", k)
+			}
+			values[k] = string(decoded)
+		}
+	}
+
+	found := false
+	refs := map[string]Ref{}
+	templateData := map[string]interface{}{}
+	for k, v := range values {
+		// the template data is a go template so lets escape any existing actions
+		text := strings.Replace(v, "{{", `{{ "{{" }}`, -1)
+		text = placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+			ref, ok := c.ref(placeholder)
+			if !ok {
+				return placeholder
+			}
+			found = true
+			secretKey := "jx_" + strings.TrimPrefix(placeholder, placeholderPrefix)
+			refs[secretKey] = ref
+			return "{{ ." + secretKey + " }}"
+		})
+		templateData[k] = text
+	}
+	if !found {
+		return "", nil
+	}
+
+	metadata, _ := secret["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	externalMetadata := map[string]interface{}{"name": name}
+	templateMetadata := map[string]interface{}{}
+	for _, k := range []string{"labels", "annotations"} {
+		if v, ok := metadata[k]; ok {
+			externalMetadata[k] = v
+			templateMetadata[k] = v
+		}
+	}
+	if ns, ok := metadata["namespace"]; ok {
+		externalMetadata["namespace"] = ns
+	}
+	template := map[string]interface{}{
+		"data": templateData,
+	}
+	if secretType, ok := secret["type"]; ok {
+		template["type"] = secretType
+	}
+	if len(templateMetadata) > 0 {
+		template["metadata"] = templateMetadata
+	}
+
+	secretKeys := []string{}
+	for k := range refs {
+		secretKeys = append(secretKeys, k)
+	}
+	sort.Strings(secretKeys)
+	remoteData := []interface{}{}
+	for _, k := range secretKeys {
+		ref := refs[k]
+		remoteRef := map[string]interface{}{"key": ref.Key}
+		if ref.Property != "" {
+			remoteRef["property"] = ref.Property
+		}
+		if ref.Version != "" {
+			remoteRef["version"] = ref.Version
+		}
+		remoteData = append(remoteData, map[string]interface{}{
+			"secretKey": k,
+			"remoteRef": remoteRef,
+		})
+	}
+
+	externalSecret := map[string]interface{}{
+		"apiVersion": APIVersion,
+		"kind":       Kind,
+		"metadata":   externalMetadata,
+		"spec": map[string]interface{}{
+			"refreshInterval": c.Config.RefreshInterval,
+			"secretStoreRef": map[string]interface{}{
+				"name": c.Config.SecretStore,
+				"kind": c.Config.SecretStoreKind,
+			},
+			"target": map[string]interface{}{
+				"name":           name,
+				"creationPolicy": "Owner",
+				"template":       template,
+			},
+			"data": remoteData,
+		},
+	}
+	out, err := yaml.Marshal(externalSecret)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the ExternalSecret %s", name)
+	}
+	return string(out), nil
+}
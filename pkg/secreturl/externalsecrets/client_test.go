@@ -0,0 +1,117 @@
+// +build unit
+
+package externalsecrets_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/externalsecrets"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestNewClientRequiresSecretStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := externalsecrets.NewClient(nil)
+	assert.Error(t, err)
+
+	client, err := externalsecrets.NewClient(&config.ExternalSecretsConfig{SecretStore: "vault"})
+	require.NoError(t, err)
+	assert.Equal(t, externalsecrets.DefaultSecretStoreKind, client.Config.SecretStoreKind)
+	assert.Equal(t, externalsecrets.DefaultRefreshInterval, client.Config.RefreshInterval)
+}
+
+func TestConvertManifestFile(t *testing.T) {
+	t.Parallel()
+
+	client, err := externalsecrets.NewClient(&config.ExternalSecretsConfig{SecretStore: "vault", SecretStoreKind: "SecretStore"})
+	require.NoError(t, err)
+
+	values, err := client.ReplaceURIs("password: vault:cluster/admin:password\ntoken: \"vault:team-a/kv/pipeline#token?version=2\"\n")
+	require.NoError(t, err)
+	assert.NotContains(t, values, "vault:", "should not leave any secret URIs in the values")
+	m := map[string]string{}
+	require.NoError(t, yaml.Unmarshal([]byte(values), &m))
+	again, err := client.ReplaceURIs("password: vault:cluster/admin:password\n")
+	require.NoError(t, err)
+	assert.Equal(t, "password: "+m["password"]+"\n", again, "should use the same placeholder for the same URI")
+
+	dir, err := ioutil.TempDir("", "test-external-secrets-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "secret.yaml")
+	manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: jenkins
+  namespace: jx
+  labels:
+    app: jenkins
+type: Opaque
+data:
+  password: ` + base64.StdEncoding.EncodeToString([]byte(m["password"])) + `
+  user: ` + base64.StdEncoding.EncodeToString([]byte("admin")) + `
+stringData:
+  config: "token: ` + m["token"] + `"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: unchanged
+data:
+  user: ` + base64.StdEncoding.EncodeToString([]byte("admin")) + `
+`
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(manifest), util.DefaultFileWritePermissions))
+
+	require.NoError(t, client.ConvertManifestFile(fileName))
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	docs := strings.Split(string(data), "---\n")
+	require.Len(t, docs, 2)
+	assert.NotContains(t, docs[0], "jx-external-secret-", "should not leave any placeholders")
+
+	externalSecret := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[0]), &externalSecret))
+	assert.Equal(t, externalsecrets.APIVersion, externalSecret["apiVersion"])
+	assert.Equal(t, externalsecrets.Kind, externalSecret["kind"])
+	assert.Equal(t, "jx", util.GetMapValueAsStringViaPath(externalSecret, "metadata.namespace"))
+	assert.Equal(t, "vault", util.GetMapValueAsStringViaPath(externalSecret, "spec.secretStoreRef.name"))
+	assert.Equal(t, "SecretStore", util.GetMapValueAsStringViaPath(externalSecret, "spec.secretStoreRef.kind"))
+	assert.Equal(t, "jenkins", util.GetMapValueAsStringViaPath(externalSecret, "spec.target.name"))
+	assert.Equal(t, "Opaque", util.GetMapValueAsStringViaPath(externalSecret, "spec.target.template.type"))
+	assert.Equal(t, "jenkins", util.GetMapValueAsStringViaPath(externalSecret, "spec.target.template.metadata.labels.app"))
+	assert.Equal(t, "admin", util.GetMapValueAsStringViaPath(externalSecret, "spec.target.template.data.user"))
+	assert.Regexp(t, `^\{\{ \.jx_[0-9a-f]{16} \}\}$`, util.GetMapValueAsStringViaPath(externalSecret, "spec.target.template.data.password"))
+	assert.Regexp(t, `^token: \{\{ \.jx_[0-9a-f]{16} \}\}$`, util.GetMapValueAsStringViaPath(externalSecret, "spec.target.template.data.config"))
+
+	remoteRefs := map[string]map[string]interface{}{}
+	for _, d := range externalSecret["spec"].(map[string]interface{})["data"].([]interface{}) {
+		ref := d.(map[string]interface{})["remoteRef"].(map[string]interface{})
+		remoteRefs[ref["key"].(string)] = ref
+	}
+	assert.Equal(t, map[string]map[string]interface{}{
+		"cluster/admin": {"key": "cluster/admin", "property": "password"},
+		"pipeline":      {"key": "pipeline", "property": "token", "version": "2"},
+	}, remoteRefs)
+
+	assert.Contains(t, docs[1], "name: unchanged", "should leave the Secrets without placeholders")
+
+	configMap := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  password: ` + m["password"] + `
+`
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(configMap), util.DefaultFileWritePermissions))
+	assert.Error(t, client.ConvertManifestFile(fileName), "should fail on placeholders outside of a Secret")
+}
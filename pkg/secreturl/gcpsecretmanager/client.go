@@ -0,0 +1,92 @@
+package gcpsecretmanager
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Backend stores each secret as a JSON object in the latest version of a GCP Secret Manager secret using gcloud
+type Backend struct {
+	// Project the GCP project of the secrets. Defaults to the project of the gcloud configuration
+	Project string
+	// Prefix the optional prefix of the secret IDs
+	Prefix string
+	// Runner runs the gcloud commands. Defaults to running them without retries
+	Runner func(cmd *util.Command) (string, error)
+}
+
+// NewClient creates a secret URL client for GCP Secret Manager in the given project
+func NewClient(project string, prefix string) secreturl.Client {
+	backend := &Backend{
+		Project: project,
+		Prefix:  prefix,
+	}
+	return secreturl.NewBackendClient(backend, secreturl.GCPSecretManagerScheme)
+}
+
+// Read reads the keys and values of the named secret
+func (b *Backend) Read(secretName string) (map[string]interface{}, error) {
+	id := b.secretID(secretName)
+	text, err := b.gcloud(nil, "secrets", "versions", "access", "latest", "--secret", id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to access the latest version of secret %s", id)
+	}
+	data := map[string]interface{}{}
+	err = json.Unmarshal([]byte(text), &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the value of secret %s as a JSON object", id)
+	}
+	return data, nil
+}
+
+// Write adds a new version of the named secret, creating it if it does not exist
+func (b *Backend) Write(secretName string, data map[string]interface{}) error {
+	id := b.secretID(secretName)
+	value, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the value of secret %s", id)
+	}
+	_, err = b.gcloud(nil, "secrets", "describe", id)
+	if err != nil {
+		_, err = b.gcloud(nil, "secrets", "create", id, "--replication-policy", "automatic")
+		if err != nil {
+			return errors.Wrapf(err, "failed to create secret %s", id)
+		}
+	}
+	// lets pass the value on stdin so that it is not visible in the arguments of the process
+	_, err = b.gcloud(strings.NewReader(string(value)), "secrets", "versions", "add", id, "--data-file", "-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to add a version of secret %s", id)
+	}
+	return nil
+}
+
+func (b *Backend) gcloud(in *strings.Reader, args ...string) (string, error) {
+	if b.Project != "" {
+		args = append(args, "--project", b.Project)
+	}
+	cmd := &util.Command{
+		Name: "gcloud",
+		Args: args,
+	}
+	if in != nil {
+		cmd.In = in
+	}
+	if b.Runner != nil {
+		return b.Runner(cmd)
+	}
+	return cmd.RunWithoutRetry()
+}
+
+// secretID returns the ID of the secret escaping the slashes of the path which are not allowed in secret IDs
+func (b *Backend) secretID(secretName string) string {
+	id := secreturl.EscapeSecretName(secretName, '_', "-")
+	if b.Prefix != "" {
+		id = secreturl.EscapeSecretName(b.Prefix, '_', "-") + "_" + id
+	}
+	return id
+}
@@ -0,0 +1,59 @@
+// +build unit
+
+package gcpsecretmanager_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/secreturl/gcpsecretmanager"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend(t *testing.T) {
+	t.Parallel()
+
+	secrets := map[string]string{}
+	commands := []string{}
+	backend := &gcpsecretmanager.Backend{
+		Project: "myproject",
+		Runner: func(cmd *util.Command) (string, error) {
+			args := strings.Join(cmd.Args, " ")
+			commands = append(commands, args)
+			id := cmd.Args[2]
+			switch cmd.Args[1] {
+			case "describe":
+				if _, ok := secrets[id]; !ok {
+					return "", fmt.Errorf("NOT_FOUND")
+				}
+			case "create":
+				secrets[id] = ""
+			case "versions":
+				if cmd.Args[2] == "access" {
+					return secrets[cmd.Args[5]], nil
+				}
+				id = cmd.Args[3]
+				data, err := ioutil.ReadAll(cmd.In)
+				require.NoError(t, err)
+				secrets[id] = string(data)
+			}
+			return "", nil
+		},
+	}
+
+	require.NoError(t, backend.Write("mycluster/admin", map[string]interface{}{"password": "s3cr3t"}))
+	assert.Equal(t, []string{
+		"secrets describe mycluster_2fadmin --project myproject",
+		"secrets create mycluster_2fadmin --replication-policy automatic --project myproject",
+		"secrets versions add mycluster_2fadmin --data-file - --project myproject",
+	}, commands)
+
+	data, err := backend.Read("mycluster/admin")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "s3cr3t"}, data)
+	assert.NotContains(t, strings.Join(commands, "\n"), "s3cr3t", "should not pass the secret in the arguments")
+}
@@ -2,7 +2,9 @@ package secreturl
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -20,19 +22,19 @@ func ReplaceURIs(s string, client Client, r *regexp.Regexp, schemePrefix string)
 		if err == nil {
 			prefix, found := trimBeforePrefix(found, schemePrefix)
 			pathAndKey := strings.Trim(strings.TrimPrefix(found, schemePrefix), "\"")
-			parts := strings.Split(pathAndKey, ":")
-			if len(parts) != 2 {
-				err = errors.Errorf("cannot parse %q as path:key", pathAndKey)
+			uri, err1 := ParseURI(pathAndKey)
+			if err1 != nil {
+				err = err1
 				return ""
 			}
-			secret, err1 := client.Read(parts[0])
+			secret, err1 := readURI(client, uri)
 			if err1 != nil {
-				err = errors.Wrapf(err1, "reading %q from vault", parts[0])
+				err = errors.Wrapf(err1, "reading %q from vault", uri.Path)
 				return ""
 			}
-			v, ok := secret[parts[1]]
+			v, ok := secret[uri.Key]
 			if !ok {
-				err = errors.Errorf("unable to find %q in secret at %q", parts[1], parts[0])
+				err = errors.Errorf("unable to find %q in secret at %q", uri.Key, uri.Path)
 				return ""
 			}
 			result, err1 := util.AsString(v)
@@ -50,6 +52,66 @@ func ReplaceURIs(s string, client Client, r *regexp.Regexp, schemePrefix string)
 	return answer, nil
 }
 
+// URI the location of a secret value either as `path:key` or, for secrets in a KV v2 mount of a vault namespace, as
+// `namespace/mount/path#key?version=N` where the namespace may be blank and the version is optional
+type URI struct {
+	Namespace string
+	Mount     string
+	Path      string
+	Key       string
+	Version   int
+}
+
+// ParseURI parses the path and key of a secret URI without its scheme
+func ParseURI(pathAndKey string) (*URI, error) {
+	if !strings.Contains(pathAndKey, "#") {
+		parts := strings.Split(pathAndKey, ":")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("cannot parse %q as path:key", pathAndKey)
+		}
+		return &URI{Path: parts[0], Key: parts[1]}, nil
+	}
+	uri := &URI{}
+	text := pathAndKey
+	if i := strings.Index(text, "?"); i >= 0 {
+		query, err := url.ParseQuery(text[i+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse the query of %q", pathAndKey)
+		}
+		version := query.Get("version")
+		if version != "" {
+			uri.Version, err = strconv.Atoi(version)
+			if err != nil || uri.Version < 1 {
+				return nil, errors.Errorf("the version of %q should be a positive number but was %q", pathAndKey, version)
+			}
+		}
+		text = text[:i]
+	}
+	i := strings.Index(text, "#")
+	uri.Key = text[i+1:]
+	parts := strings.SplitN(text[:i], "/", 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" || uri.Key == "" {
+		return nil, errors.Errorf("cannot parse %q as namespace/mount/path#key", pathAndKey)
+	}
+	uri.Namespace = parts[0]
+	uri.Mount = parts[1]
+	uri.Path = parts[2]
+	return uri, nil
+}
+
+// readURI reads the secret of the URI using the client. Secrets in a namespace, a mount or of a version can only be
+// read by a VersionedClient
+func readURI(client Client, uri *URI) (map[string]interface{}, error) {
+	if uri.Mount == "" {
+		return client.Read(uri.Path)
+	}
+	versioned, ok := client.(VersionedClient)
+	if !ok {
+		return nil, errors.Errorf("the secret client does not support reading from namespace %q and mount %q", uri.Namespace, uri.Mount)
+	}
+	return versioned.ReadVersion(uri.Namespace, uri.Mount, uri.Path, uri.Version)
+}
+
 // trimBeforePrefix remove any chars before the given prefix
 func trimBeforePrefix(s string, prefix string) (string, string) {
 	i := strings.Index(s, prefix)
@@ -149,3 +149,62 @@ password: %s
 	assert.NoError(t, err, "should replace the URIs without error")
 	assert.EqualValues(t, fmt.Sprintf(testString, testValue), result, "should replace the URIs")
 }
+
+func TestParseURI(t *testing.T) {
+	testCases := []struct {
+		pathAndKey string
+		expected   *secreturl.URI
+		fail       bool
+	}{
+		{pathAndKey: "cluster/admin:password", expected: &secreturl.URI{Path: "cluster/admin", Key: "password"}},
+		{pathAndKey: "team-a/kv/cluster/admin#password", expected: &secreturl.URI{Namespace: "team-a", Mount: "kv", Path: "cluster/admin", Key: "password"}},
+		{pathAndKey: "/kv/cluster/admin#password?version=3", expected: &secreturl.URI{Mount: "kv", Path: "cluster/admin", Key: "password", Version: 3}},
+		{pathAndKey: "cluster/admin", fail: true},
+		{pathAndKey: "kv/admin#password", fail: true},
+		{pathAndKey: "team-a/kv/admin#", fail: true},
+		{pathAndKey: "team-a/kv/admin#password?version=latest", fail: true},
+	}
+	for _, tc := range testCases {
+		uri, err := secreturl.ParseURI(tc.pathAndKey)
+		if tc.fail {
+			assert.Error(t, err, "should fail to parse %s", tc.pathAndKey)
+			continue
+		}
+		require.NoError(t, err, "failed to parse %s", tc.pathAndKey)
+		assert.Equal(t, tc.expected, uri, "parsing %s", tc.pathAndKey)
+	}
+}
+
+type versionedClient struct {
+	secreturl.Client
+	versions map[string]map[string]interface{}
+}
+
+func (c *versionedClient) ReadVersion(namespace string, mount string, secretName string, version int) (map[string]interface{}, error) {
+	return c.versions[fmt.Sprintf("%s/%s/%s@%d", namespace, mount, secretName, version)], nil
+}
+
+func TestReplaceURIsWithNamespaceAndVersion(t *testing.T) {
+	secretClient := &versionedClient{
+		Client: fakevault.NewFakeClient(),
+		versions: map[string]map[string]interface{}{
+			"team-a/kv/cluster/admin@0": {"password": "latest"},
+			"team-a/kv/cluster/admin@2": {"password": "pinned"},
+		},
+	}
+	versionedRegexp := regexp.MustCompile(`:[\s"]*vault:[-_.\w\/:#?=]*`)
+
+	testString := `
+latest: vault:team-a/kv/cluster/admin#password
+pinned: "vault:team-a/kv/cluster/admin#password?version=2"
+`
+	result, err := secreturl.ReplaceURIs(testString, secretClient, versionedRegexp, schemaPrefix)
+	require.NoError(t, err, "should replace the URIs without error")
+	assert.Equal(t, `
+latest: latest
+pinned: "pinned"
+`, result)
+
+	_, err = secreturl.ReplaceURIs(testString, fakevault.NewFakeClient(), versionedRegexp, schemaPrefix)
+	assert.Error(t, err, "should fail when the client cannot read from a namespace")
+}
@@ -0,0 +1,165 @@
+package syntax
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultCacheClaimName the name of the PersistentVolumeClaim the caches are stored in if there is no cache bucket
+	DefaultCacheClaimName = "jx-build-cache"
+
+	// CacheVolumeName the name of the volume of the cache PersistentVolumeClaim in the Tasks
+	CacheVolumeName = "build-cache"
+
+	// CacheMountPath the path the cache PersistentVolumeClaim is mounted at in the cache steps
+	CacheMountPath = "/build-cache"
+
+	restoreCacheStepName = "restore-cache"
+	saveCacheStepName    = "save-cache"
+)
+
+// CacheStorage where the caches of the pipelines are stored, either in a bucket or a PersistentVolumeClaim which
+// should be ReadWriteMany so that the Tasks of parallel stages can mount it at the same time
+type CacheStorage struct {
+	BucketURL string
+	ClaimName string
+	// Key the folder of the bucket or volume the caches are stored in, usually the owner and name of the repository
+	// followed by the kind of the pipeline and the branch
+	Key string
+	// RestoreOnly if the caches are restored but never saved such as for the untrusted builds of pull requests
+	RestoreOnly bool
+}
+
+// CombineCaches returns the parent caches followed by the child caches which are not already in the parent
+func CombineCaches(parent []string, child []string) []string {
+	answer := append([]string{}, parent...)
+	for _, c := range child {
+		found := false
+		for _, p := range answer {
+			if p == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			answer = append(answer, c)
+		}
+	}
+	if len(answer) == 0 {
+		return nil
+	}
+	return answer
+}
+
+// ValidateCaches returns an error if any of the caches is not a relative directory or a directory of the home directory
+func ValidateCaches(caches []string) error {
+	for _, c := range caches {
+		p := strings.TrimPrefix(c, "~/")
+		if c == "" || path.IsAbs(p) || strings.HasPrefix(path.Clean(p), "..") || strings.ContainsAny(c, "'\"$`") {
+			return errors.Errorf("invalid cache %s, it must be a directory relative to the source directory or starting with ~/", c)
+		}
+	}
+	return nil
+}
+
+// cacheName returns the name of the folder the cache is stored in
+func cacheName(cache string) string {
+	if strings.HasPrefix(cache, "~/") {
+		cache = "home/" + strings.TrimPrefix(cache, "~/")
+	} else {
+		cache = "source/" + cache
+	}
+	return strings.Trim(strings.Replace(path.Clean(cache), "/", "_", -1), "_")
+}
+
+// shellPath returns the cache path as a shell word, expanding ~/ to the home directory
+func shellPath(cache string) string {
+	if strings.HasPrefix(cache, "~/") {
+		return fmt.Sprintf("\"$HOME/%s\"", strings.TrimPrefix(cache, "~/"))
+	}
+	return fmt.Sprintf("'%s'", cache)
+}
+
+// addCacheSteps adds a step restoring the caches before the steps of the Task and a step saving them after them unless
+// the caches are restore only. Each build saves a cache in the volume to its own directory which then atomically
+// replaces the symlink to the previous one so that concurrent builds cannot corrupt the cache
+func (c *CacheStorage) addCacheSteps(t *tektonv1alpha1.Task, caches []string, container *corev1.Container, workingDir string) {
+	var restore, save []string
+	for _, cache := range caches {
+		name := cacheName(cache)
+		dir := shellPath(cache)
+		var restoreCommand, saveCommand string
+		if c.BucketURL != "" {
+			archive := fmt.Sprintf("/tmp/%s.tgz", name)
+			url := fmt.Sprintf("%s/%s/%s.tgz", strings.TrimSuffix(c.BucketURL, "/"), c.Key, name)
+			restoreCommand = fmt.Sprintf("if jx step unstash -u %s -o %s; then mkdir -p %s && tar -xzf %s -C %s; fi", url, archive, dir, archive, dir)
+			saveCommand = fmt.Sprintf("if [ -d %s ]; then tar -czf %s -C %s . && jx step stash -c cache -p %s --basedir /tmp --bucket-url %s -t %s; fi",
+				dir, archive, dir, archive, c.BucketURL, c.Key)
+		} else {
+			parent := fmt.Sprintf("'%s/%s'", CacheMountPath, c.Key)
+			stored := fmt.Sprintf("'%s/%s/%s'", CacheMountPath, c.Key, name)
+			version := fmt.Sprintf("'%s'.\"$HOSTNAME\"", name)
+			build := fmt.Sprintf("%s/%s", parent, version)
+			restoreCommand = fmt.Sprintf("if [ -d %s ]; then mkdir -p %s && cp -a %s/. %s/; fi", stored, dir, stored, dir)
+			saveCommand = fmt.Sprintf("if [ -d %s ]; then rm -rf %s && mkdir -p %s && cp -a %s/. %s/ && "+
+				"old=$(readlink %s || true) && if [ -d %s ] && [ ! -L %s ]; then rm -rf %s; fi && "+
+				"ln -sfn %s %s.link && mv -Tf %s.link %s && if [ -n \"$old\" ]; then rm -rf %s/\"$old\"; fi; fi",
+				dir, build, build, dir, build,
+				stored, stored, stored, stored,
+				version, build, build, stored, parent)
+		}
+		// a cache which cannot be restored or saved only slows the build down so it must not fail it
+		restore = append(restore, fmt.Sprintf("(%s) || echo 'failed to restore cache %s'", restoreCommand, cache))
+		save = append(save, fmt.Sprintf("(%s) || echo 'failed to save cache %s'", saveCommand, cache))
+	}
+	restoreStep := cacheStep(restoreCacheStepName, container, workingDir, restore)
+	saveStep := cacheStep(saveCacheStepName, container, workingDir, save)
+	if c.BucketURL == "" {
+		mount := corev1.VolumeMount{Name: CacheVolumeName, MountPath: CacheMountPath, ReadOnly: c.RestoreOnly}
+		restoreStep.VolumeMounts = append(restoreStep.VolumeMounts, mount)
+		saveStep.VolumeMounts = append(saveStep.VolumeMounts, mount)
+	}
+
+	// the default git merge step has to run before the caches of the source directory are restored
+	index := 0
+	if len(t.Spec.Steps) > 0 && t.Spec.Steps[0].Name == "git-merge" {
+		index = 1
+	}
+	steps := append([]tektonv1alpha1.Step{}, t.Spec.Steps[:index]...)
+	steps = append(steps, restoreStep)
+	steps = append(steps, t.Spec.Steps[index:]...)
+	if !c.RestoreOnly {
+		steps = append(steps, saveStep)
+	}
+	t.Spec.Steps = steps
+}
+
+// cacheVolume returns the volume of the cache PersistentVolumeClaim or nil if the caches are stored in a bucket
+func (c *CacheStorage) cacheVolume() *corev1.Volume {
+	if c.BucketURL != "" {
+		return nil
+	}
+	return &corev1.Volume{
+		Name: CacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: c.ClaimName,
+			},
+		},
+	}
+}
+
+func cacheStep(name string, container *corev1.Container, workingDir string, commands []string) tektonv1alpha1.Step {
+	c := container.DeepCopy()
+	c.Name = name
+	c.Command = []string{"/bin/sh", "-c"}
+	c.Args = []string{strings.Join(commands, "\n")}
+	c.WorkingDir = workingDir
+	return tektonv1alpha1.Step{Container: *c}
+}
@@ -0,0 +1,117 @@
+// +build unit
+
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineCaches(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, syntax.CombineCaches(nil, nil))
+	assert.Equal(t, []string{"~/.m2", "node_modules"}, syntax.CombineCaches([]string{"~/.m2"}, []string{"node_modules", "~/.m2"}))
+}
+
+func TestValidateCaches(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, syntax.ValidateCaches([]string{"~/.m2", "node_modules", "web/node_modules"}))
+	for _, cache := range []string{"", "/root/.m2", "../other", "~/../etc", "$HOME/.m2"} {
+		assert.Error(t, syntax.ValidateCaches([]string{cache}), "cache %s should be invalid", cache)
+	}
+}
+
+func TestGenerateCRDsWithCaches(t *testing.T) {
+	t.Parallel()
+
+	parsed := &syntax.ParsedPipeline{
+		Agent: &syntax.Agent{
+			Image: "maven",
+		},
+		Options: &syntax.RootOptions{
+			Caches: []string{"~/.m2"},
+		},
+		Stages: []syntax.Stage{
+			{
+				Name: "build",
+				Options: &syntax.StageOptions{
+					RootOptions: &syntax.RootOptions{
+						Caches: []string{"node_modules"},
+					},
+				},
+				Steps: []syntax.Step{
+					{
+						Name:    "mvn",
+						Command: "mvn install",
+					},
+				},
+			},
+		},
+	}
+	params := syntax.CRDsFromPipelineParams{
+		PipelineIdentifier: "somepipeline",
+		BuildIdentifier:    "1",
+		Namespace:          "jx",
+		SourceDir:          "source",
+		DefaultImage:       "gcr.io/jenkinsxio/builder-jx:0.1.1",
+		CacheStorage: &syntax.CacheStorage{
+			ClaimName: syntax.DefaultCacheClaimName,
+			Key:       "myorg/myapp/release/master",
+		},
+	}
+
+	_, tasks, _, err := parsed.GenerateCRDs(params)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	steps := tasks[0].Spec.Steps
+	require.Len(t, steps, 4)
+	assert.Equal(t, "git-merge", steps[0].Name)
+	assert.Equal(t, "restore-cache", steps[1].Name)
+	assert.Equal(t, "mvn", steps[2].Name)
+	assert.Equal(t, "save-cache", steps[3].Name)
+
+	restore := steps[1]
+	assert.Equal(t, "gcr.io/jenkinsxio/builder-jx:0.1.1", restore.Image)
+	assert.Equal(t, "/workspace/source", restore.WorkingDir)
+	require.Len(t, restore.Args, 1)
+	assert.Contains(t, restore.Args[0], "cp -a '/build-cache/myorg/myapp/release/master/home_.m2'/. \"$HOME/.m2\"/")
+	assert.Contains(t, restore.Args[0], "'/build-cache/myorg/myapp/release/master/source_node_modules'", "should restore the caches of the stage")
+	require.Len(t, restore.VolumeMounts, 1)
+	assert.Equal(t, syntax.CacheMountPath, restore.VolumeMounts[0].MountPath)
+
+	found := false
+	for _, v := range tasks[0].Spec.Volumes {
+		if v.Name == syntax.CacheVolumeName {
+			found = true
+			require.NotNil(t, v.PersistentVolumeClaim)
+			assert.Equal(t, syntax.DefaultCacheClaimName, v.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	assert.True(t, found, "should add the cache volume to the Task")
+
+	save := steps[3]
+	require.Len(t, save.Args, 1)
+	assert.Contains(t, save.Args[0], "cp -a \"$HOME/.m2\"/. '/build-cache/myorg/myapp/release/master'/'home_.m2'.\"$HOSTNAME\"/", "should save to a directory of the build")
+	assert.Contains(t, save.Args[0], "mv -Tf '/build-cache/myorg/myapp/release/master'/'home_.m2'.\"$HOSTNAME\".link '/build-cache/myorg/myapp/release/master/home_.m2'", "should atomically replace the cache")
+
+	params.CacheStorage.RestoreOnly = true
+	_, tasks, _, err = parsed.GenerateCRDs(params)
+	require.NoError(t, err)
+	steps = tasks[0].Spec.Steps
+	require.Len(t, steps, 3, "should not save restore only caches")
+	assert.Equal(t, "restore-cache", steps[1].Name)
+	require.Len(t, steps[1].VolumeMounts, 1)
+	assert.True(t, steps[1].VolumeMounts[0].ReadOnly)
+
+	params.CacheStorage = &syntax.CacheStorage{BucketURL: "gs://my-cache", Key: "myorg/myapp/release/master"}
+	_, tasks, _, err = parsed.GenerateCRDs(params)
+	require.NoError(t, err)
+	save = tasks[0].Spec.Steps[3]
+	assert.Contains(t, save.Args[0], "jx step stash -c cache -p /tmp/home_.m2.tgz --basedir /tmp --bucket-url gs://my-cache -t myorg/myapp/release/master")
+	assert.Empty(t, save.VolumeMounts, "should not mount a volume when using a bucket")
+}
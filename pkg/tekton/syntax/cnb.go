@@ -0,0 +1,19 @@
+package syntax
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+)
+
+// cnbStep replaces the skaffold build with 'jx step build pack cnb' building the image of the project with the Cloud
+// Native Buildpacks builder rather than its Dockerfile. The image has the same name and tag as the one built with
+// Kaniko so that the release and promotion of the project use it as is
+func (s *Step) cnbStep(params StepPlaceholderReplacementArgs) {
+	destination := params.DockerRegistry + "/" + params.DockerRegistryOrg + "/" + naming.ToValidName(params.GitName)
+	s.Command = "jx"
+	s.Arguments = []string{"step", "build", "pack", "cnb",
+		"--dir=" + params.WorkspaceDir,
+		"--image=" + destination,
+		"--version=" + versionPlaceholder,
+		"--builder=" + params.CNBBuilder,
+	}
+}
@@ -0,0 +1,56 @@
+// +build unit
+
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplacePlaceholdersWithCNBBuilder(t *testing.T) {
+	t.Parallel()
+
+	parsed := &syntax.ParsedPipeline{
+		Stages: []syntax.Stage{
+			{
+				Name: "build",
+				Steps: []syntax.Step{
+					{
+						Name:    "build-container-build",
+						Image:   "gcr.io/jenkinsxio/builder-go",
+						Command: "skaffold build -f skaffold.yaml",
+					},
+					{
+						Name:    "helm-build",
+						Command: "make build",
+					},
+				},
+			},
+		},
+	}
+	parsed.ReplacePlaceholdersInStepAndStageDirs(syntax.StepPlaceholderReplacementArgs{
+		WorkspaceDir:      "/workspace/source",
+		GitName:           "myapp",
+		GitOrg:            "myorg",
+		DockerRegistry:    "10.0.0.1:5000",
+		DockerRegistryOrg: "myorg",
+		KanikoImage:       syntax.KanikoDockerImage,
+		UseKaniko:         true,
+		CNBBuilder:        "paketobuildpacks/builder:0.0.35-base@sha256:abc",
+	})
+
+	steps := parsed.Stages[0].Steps
+	require.Len(t, steps, 2)
+	assert.Equal(t, "gcr.io/jenkinsxio/builder-go", steps[0].Image, "the image of the step should have jx and pack")
+	assert.Equal(t, "jx", steps[0].Command)
+	assert.Equal(t, []string{"step", "build", "pack", "cnb",
+		"--dir=/workspace/source",
+		"--image=10.0.0.1:5000/myorg/myapp",
+		"--version=${inputs.params.version}",
+		"--builder=paketobuildpacks/builder:0.0.35-base@sha256:abc",
+	}, steps[0].Arguments)
+	assert.Equal(t, "make build", steps[1].Command)
+}
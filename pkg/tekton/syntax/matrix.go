@@ -0,0 +1,196 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/knative/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isMatrixAxisName checks the axis can be used as the name of an environment variable in the shell
+var isMatrixAxisName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`).MatchString
+
+// Matrix the axes of a stage whose steps or stages are run in a parallel stage for every combination of the values of
+// the axes, with the value of each axis in an environment variable named after it
+type Matrix struct {
+	Axes []MatrixAxis `json:"axes"`
+	// Exclude the combinations which are not run, each matching the combinations with all of its axis values
+	Exclude []map[string]string `json:"exclude,omitempty"`
+}
+
+// MatrixAxis an axis of a matrix such as the versions of a language or the operating systems to build on
+type MatrixAxis struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Combinations returns the combinations of the values of the axes which are not excluded, with the values of the first
+// axis varying slowest
+func (m *Matrix) Combinations() []map[string]string {
+	if m == nil || len(m.Axes) == 0 {
+		return nil
+	}
+	answer := []map[string]string{{}}
+	for _, axis := range m.Axes {
+		var next []map[string]string
+		for _, combination := range answer {
+			for _, value := range axis.Values {
+				c := map[string]string{}
+				for k, v := range combination {
+					c[k] = v
+				}
+				c[axis.Name] = value
+				next = append(next, c)
+			}
+		}
+		answer = next
+	}
+	var included []map[string]string
+	for _, combination := range answer {
+		if !m.isExcluded(combination) {
+			included = append(included, combination)
+		}
+	}
+	return included
+}
+
+func (m *Matrix) isExcluded(combination map[string]string) bool {
+	for _, exclude := range m.Exclude {
+		matches := len(exclude) > 0
+		for k, v := range exclude {
+			if combination[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// combinationSuffix returns the suffix of the names of the stages of a combination of the matrix
+func (m *Matrix) combinationSuffix(combination map[string]string) string {
+	var values []string
+	for _, axis := range m.Axes {
+		values = append(values, combination[axis.Name])
+	}
+	return " " + strings.Join(values, " ")
+}
+
+// combinationEnv returns the environment variables of the axis values of a combination
+func (m *Matrix) combinationEnv(combination map[string]string) []corev1.EnvVar {
+	var answer []corev1.EnvVar
+	for _, axis := range m.Axes {
+		answer = append(answer, corev1.EnvVar{Name: axis.Name, Value: combination[axis.Name]})
+	}
+	return answer
+}
+
+// expandMatrix returns the stage with a parallel stage for each combination of its matrix, which is a copy of the
+// stage with the axis values added to its environment variables and to the names of it and its nested stages
+func (s *Stage) expandMatrix() Stage {
+	expanded := Stage{
+		Name: s.Name,
+	}
+	for _, combination := range s.Matrix.Combinations() {
+		c := s.DeepCopy()
+		c.Matrix = nil
+		c.Env = CombineEnv(s.Matrix.combinationEnv(combination), c.GetEnv())
+		c.Environment = nil
+		c.addNameSuffix(s.Matrix.combinationSuffix(combination))
+		expanded.Parallel = append(expanded.Parallel, *c)
+	}
+	return expanded
+}
+
+// addNameSuffix adds the suffix to the names of the stage and its nested stages so they are unique in the pipeline
+func (s *Stage) addNameSuffix(suffix string) {
+	s.Name += suffix
+	for i := range s.Stages {
+		s.Stages[i].addNameSuffix(suffix)
+	}
+	for i := range s.Parallel {
+		s.Parallel[i].addNameSuffix(suffix)
+	}
+}
+
+func validateMatrix(m *Matrix) *apis.FieldError {
+	if len(m.Axes) == 0 {
+		return apis.ErrMissingField("axes")
+	}
+	values := map[string][]string{}
+	for i, axis := range m.Axes {
+		if !isMatrixAxisName(axis.Name) {
+			return (&apis.FieldError{
+				Message: fmt.Sprintf("Matrix axis name %s must be a valid environment variable name", axis.Name),
+				Paths:   []string{"name"},
+			}).ViaFieldIndex("axes", i)
+		}
+		if _, exists := values[axis.Name]; exists {
+			return (&apis.FieldError{
+				Message: fmt.Sprintf("Matrix axis %s is defined more than once", axis.Name),
+				Paths:   []string{"name"},
+			}).ViaFieldIndex("axes", i)
+		}
+		if len(axis.Values) == 0 {
+			return apis.ErrMissingField("values").ViaFieldIndex("axes", i)
+		}
+		for j, v := range axis.Values {
+			for _, previous := range axis.Values[:j] {
+				if v == previous {
+					return (&apis.FieldError{
+						Message: fmt.Sprintf("Matrix axis %s has the value %s more than once", axis.Name, v),
+						Paths:   []string{"values"},
+					}).ViaFieldIndex("axes", i)
+				}
+			}
+		}
+		values[axis.Name] = axis.Values
+	}
+	for i, exclude := range m.Exclude {
+		if len(exclude) == 0 {
+			return apis.ErrMissingField(apis.CurrentField).ViaFieldIndex("exclude", i)
+		}
+		// Avoid nondeterminism in error messages
+		var keys []string
+		for k := range exclude {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := exclude[k]
+			axisValues, exists := values[k]
+			if !exists {
+				return (&apis.FieldError{
+					Message: fmt.Sprintf("Matrix exclude refers to axis %s which is not defined", k),
+					Paths:   []string{k},
+				}).ViaFieldIndex("exclude", i)
+			}
+			found := false
+			for _, value := range axisValues {
+				if value == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return (&apis.FieldError{
+					Message: fmt.Sprintf("Matrix exclude refers to value %s which is not a value of axis %s", v, k),
+					Paths:   []string{k},
+				}).ViaFieldIndex("exclude", i)
+			}
+		}
+	}
+	if len(m.Combinations()) == 0 {
+		return &apis.FieldError{
+			Message: "Matrix excludes all of its combinations",
+			Paths:   []string{"exclude"},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// +build unit
+
+package syntax_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestMatrixCombinations(t *testing.T) {
+	t.Parallel()
+
+	matrix := &syntax.Matrix{
+		Axes: []syntax.MatrixAxis{
+			{Name: "GO_VERSION", Values: []string{"1.12", "1.13"}},
+			{Name: "GOOS", Values: []string{"linux", "windows"}},
+		},
+		Exclude: []map[string]string{
+			{"GO_VERSION": "1.12", "GOOS": "windows"},
+		},
+	}
+	assert.Equal(t, []map[string]string{
+		{"GO_VERSION": "1.12", "GOOS": "linux"},
+		{"GO_VERSION": "1.13", "GOOS": "linux"},
+		{"GO_VERSION": "1.13", "GOOS": "windows"},
+	}, matrix.Combinations())
+}
+
+func TestGenerateCRDsWithMatrix(t *testing.T) {
+	t.Parallel()
+
+	parsed := &syntax.ParsedPipeline{
+		Agent: &syntax.Agent{
+			Image: "golang",
+		},
+		Stages: []syntax.Stage{
+			{
+				Name: "build",
+				Env:  []corev1.EnvVar{{Name: "GOOS", Value: "darwin"}, {Name: "CGO_ENABLED", Value: "0"}},
+				Matrix: &syntax.Matrix{
+					Axes: []syntax.MatrixAxis{
+						{Name: "GO_VERSION", Values: []string{"1.12", "1.13"}},
+						{Name: "GOOS", Values: []string{"linux"}},
+					},
+				},
+				Steps: []syntax.Step{
+					{
+						Name:    "make",
+						Command: "make build",
+					},
+				},
+			},
+			{
+				Name: "release",
+				Steps: []syntax.Step{
+					{
+						Command: "make release",
+					},
+				},
+			},
+		},
+	}
+	require.Nil(t, parsed.Validate(context.Background()))
+
+	pipeline, tasks, structure, err := parsed.GenerateCRDs(syntax.CRDsFromPipelineParams{
+		PipelineIdentifier: "somepipeline",
+		BuildIdentifier:    "1",
+		Namespace:          "jx",
+		SourceDir:          "source",
+		DefaultImage:       "gcr.io/jenkinsxio/builder-jx:0.1.1",
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "somepipeline-build-1-12-linux-1", tasks[0].Name)
+	assert.Equal(t, "somepipeline-build-1-13-linux-1", tasks[1].Name)
+	assert.Equal(t, "somepipeline-release-1", tasks[2].Name)
+
+	env := map[string]string{}
+	for _, e := range tasks[1].Spec.Steps[1].Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "1.13", env["GO_VERSION"])
+	assert.Equal(t, "linux", env["GOOS"], "the axis value should override the env var of the stage")
+	assert.Equal(t, "0", env["CGO_ENABLED"])
+
+	require.Len(t, pipeline.Spec.Tasks, 3)
+	assert.Empty(t, pipeline.Spec.Tasks[0].RunAfter)
+	assert.Empty(t, pipeline.Spec.Tasks[1].RunAfter, "the combinations should run in parallel")
+	assert.Equal(t, []string{"build-1-12-linux", "build-1-13-linux"}, pipeline.Spec.Tasks[2].RunAfter)
+
+	var stageNames []string
+	for _, s := range structure.Stages {
+		stageNames = append(stageNames, s.Name)
+	}
+	assert.Equal(t, []string{"build", "build 1.12 linux", "build 1.13 linux", "release"}, stageNames)
+}
@@ -131,6 +131,9 @@ type RootOptions struct {
 	DistributeParallelAcrossNodes bool                `json:"distributeParallelAcrossNodes,omitempty"`
 	Tolerations                   []corev1.Toleration `json:"tolerations,omitempty"`
 	PodLabels                     map[string]string   `json:"podLabels,omitempty"`
+	// Caches are the directories, relative to the source directory or starting with ~/ for the home directory, which
+	// are restored at the start of each stage and saved at its end so that they are reused by the following builds
+	Caches []string `json:"caches,omitempty"`
 }
 
 // Stash defines files to be saved for use in a later stage, marked with a name
@@ -218,6 +221,7 @@ type Stage struct {
 	Steps      []Step          `json:"steps,omitempty"`
 	Stages     []Stage         `json:"stages,omitempty"`
 	Parallel   []Stage         `json:"parallel,omitempty"`
+	Matrix     *Matrix         `json:"matrix,omitempty"`
 	Post       []Post          `json:"post,omitempty"`
 	WorkingDir *string         `json:"dir,omitempty"`
 
@@ -654,6 +658,15 @@ func validateStage(s Stage, parentAgent *Agent, parentVolumes []*corev1.Volume,
 		}
 	}
 
+	if s.Matrix != nil {
+		if len(s.Parallel) > 0 {
+			return apis.ErrMultipleOneOf("matrix", "parallel")
+		}
+		if err := validateMatrix(s.Matrix).ViaField("matrix"); err != nil {
+			return err
+		}
+	}
+
 	if len(s.Stages) > 0 {
 		if len(s.Parallel) > 0 {
 			return apis.ErrMultipleOneOf("steps", "stages", "parallel")
@@ -813,6 +826,13 @@ func validateRootOptions(o *RootOptions, volumes []*corev1.Volume, kubeClient ku
 			}
 		}
 
+		if err := ValidateCaches(o.Caches); err != nil {
+			return &apis.FieldError{
+				Message: err.Error(),
+				Paths:   []string{"caches"},
+			}
+		}
+
 		return validateContainerOptions(o.ContainerOptions, volumes).ViaField("containerOptions")
 	}
 
@@ -1105,6 +1125,12 @@ type StepPlaceholderReplacementArgs struct {
 	ProjectID         string
 	KanikoImage       string
 	UseKaniko         bool
+	// Platforms the platforms such as linux/amd64 and linux/arm64 to build a multi-arch image for
+	Platforms         []string
+	ManifestToolImage string
+	// CNBBuilder the Cloud Native Buildpacks builder image the image of the project is built with instead of its
+	// Dockerfile if specified
+	CNBBuilder string
 }
 
 func (p *StepPlaceholderReplacementArgs) workingDirAsPointer() *string {
@@ -1140,7 +1166,7 @@ func (s *Stage) replacePlaceholdersInStage(parentDir *string, args StepPlacehold
 	s.WorkingDir = replacePlaceholdersInDir(s.WorkingDir, args)
 	for _, step := range s.Steps {
 		step.replacePlaceholdersInStep(args)
-		steps = append(steps, step)
+		steps = append(steps, step.platformSteps(args)...)
 	}
 	for _, nested := range s.Stages {
 		nested.replacePlaceholdersInStage(s.WorkingDir, args)
@@ -1200,10 +1226,12 @@ func (s *Step) replacePlaceholdersInStep(args StepPlaceholderReplacementArgs) {
 
 // modifyStep allows a container step to be modified to do something different
 func (s *Step) modifyStep(params StepPlaceholderReplacementArgs) {
-	if params.UseKaniko {
-		if strings.HasPrefix(s.GetCommand(), "skaffold build") ||
-			(len(s.Arguments) > 0 && strings.HasPrefix(strings.Join(s.Arguments[1:], " "), "skaffold build")) ||
-			commandIsSkaffoldRegex.MatchString(s.GetCommand()) {
+	if params.CNBBuilder != "" {
+		if s.isSkaffoldBuild() {
+			s.cnbStep(params)
+		}
+	} else if params.UseKaniko {
+		if s.isSkaffoldBuild() {
 
 			sourceDir := params.WorkspaceDir
 			dockerfile := filepath.Join(sourceDir, "Dockerfile")
@@ -1224,11 +1252,18 @@ func (s *Step) modifyStep(params StepPlaceholderReplacementArgs) {
 				args = append(args, "--insecure")
 			}
 
+			// images for several platforms are built by a step per platform, see platformSteps
+			if len(params.Platforms) == 1 {
+				args = append(args, "--customPlatform="+params.Platforms[0])
+			}
+
 			s.Command = "/kaniko/executor"
 			s.Arguments = args
 
 			s.Image = params.KanikoImage
 		}
+	} else if len(params.Platforms) > 0 && s.isSkaffoldBuild() {
+		s.buildxStep(params)
 	}
 }
 
@@ -1412,6 +1447,7 @@ type stageToTaskParams struct {
 	parentWorkspace      string
 	parentContainer      *corev1.Container
 	parentVolumes        []*corev1.Volume
+	parentCaches         []string
 	depth                int8
 	enclosingStage       *transformedStage
 	previousSiblingStage *transformedStage
@@ -1421,9 +1457,13 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 	if len(params.stage.Post) != 0 {
 		return nil, errors.New("post on stages not yet supported")
 	}
+	if params.stage.Matrix != nil {
+		params.stage = params.stage.expandMatrix()
+	}
 
 	stageContainer := &corev1.Container{}
 	var stageVolumes []*corev1.Volume
+	var stageCaches []string
 
 	if params.stage.Options != nil {
 		o := params.stage.Options
@@ -1437,6 +1477,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				stageContainer = o.ContainerOptions
 			}
 			stageVolumes = o.Volumes
+			stageCaches = o.Caches
 		}
 		if o.Stash != nil {
 			return nil, errors.New("Stash on stage not yet supported")
@@ -1459,6 +1500,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 		stageContainer = merged
 	}
 	stageVolumes = append(stageVolumes, params.parentVolumes...)
+	stageCaches = CombineCaches(params.parentCaches, stageCaches)
 
 	env := scopedEnv(params.stage.GetEnv(), params.parentEnv)
 
@@ -1538,6 +1580,22 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 			}
 		}
 
+		cacheStorage := params.parentParams.CacheStorage
+		if cacheStorage != nil && len(stageCaches) > 0 {
+			image, err := resolveBuilderImage(params.parentParams.DefaultImage, params.parentParams.VersionsDir)
+			if err != nil {
+				return nil, err
+			}
+			cacheContainer, err := MergeContainers(stageContainer, &corev1.Container{Image: image, Env: env})
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error merging stage container into the cache steps: %s", err)
+			}
+			cacheStorage.addCacheSteps(t, stageCaches, cacheContainer, filepath.Join(WorkingDirRoot, params.parentParams.SourceDir))
+			if v := cacheStorage.cacheVolume(); v != nil {
+				volumes[v.Name] = *v
+			}
+		}
+
 		// Avoid nondeterministic results by sorting the keys and appending volumes in that order.
 		var volNames []string
 		for k := range volumes {
@@ -1572,6 +1630,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				parentWorkspace:      *ts.Stage.Options.Workspace,
 				parentContainer:      stageContainer,
 				parentVolumes:        stageVolumes,
+				parentCaches:         stageCaches,
 				depth:                params.depth + 1,
 				enclosingStage:       &ts,
 				previousSiblingStage: nestedPreviousSibling,
@@ -1601,6 +1660,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				parentWorkspace: *ts.Stage.Options.Workspace,
 				parentContainer: stageContainer,
 				parentVolumes:   stageVolumes,
+				parentCaches:    stageCaches,
 				depth:           params.depth + 1,
 				enclosingStage:  &ts,
 			})
@@ -1867,6 +1927,8 @@ type CRDsFromPipelineParams struct {
 	Labels             map[string]string
 	DefaultImage       string
 	InterpretMode      bool
+	// CacheStorage where the caches of the pipeline are restored from and saved to, the caches are ignored if nil
+	CacheStorage *CacheStorage
 }
 
 // GenerateCRDs translates the Pipeline structure into the corresponding Pipeline and Task CRDs
@@ -1877,6 +1939,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 
 	var parentContainer *corev1.Container
 	var parentVolumes []*corev1.Volume
+	var parentCaches []string
 
 	baseWorkingDir := j.WorkingDir
 
@@ -1887,6 +1950,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 		}
 		parentContainer = o.ContainerOptions
 		parentVolumes = o.Volumes
+		parentCaches = o.Caches
 	}
 
 	p := &tektonv1alpha1.Pipeline{
@@ -1939,6 +2003,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 			parentWorkspace:      "default",
 			parentContainer:      parentContainer,
 			parentVolumes:        parentVolumes,
+			parentCaches:         parentCaches,
 			depth:                0,
 			previousSiblingStage: previousStage,
 		})
@@ -2164,6 +2229,10 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 		for _, stage := range stages {
 			*stageNames = append(*stageNames, stage.Name)
+			if stage.Matrix != nil {
+				validate(stage.expandMatrix().Parallel, stageNames)
+				continue
+			}
 			if len(stage.Stages) > 0 {
 				validate(stage.Stages, stageNames)
 			}
@@ -2179,18 +2248,23 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 	return
 }
 
+// resolveBuilderImage returns the image containing jx of the steps generated for the Tasks
+func resolveBuilderImage(defaultImage string, versionsDir string) (string, error) {
+	if defaultImage != "" {
+		return defaultImage, nil
+	}
+	image := os.Getenv("BUILDER_JX_IMAGE")
+	if image != "" {
+		return image, nil
+	}
+	return versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
+}
+
 // todo JR lets remove this when we switch tekton to using git merge type pipelineresources
 func getDefaultTaskSpec(envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string, versionsDir string) (tektonv1alpha1.TaskSpec, error) {
-	var err error
-	image := defaultImage
-	if image == "" {
-		image = os.Getenv("BUILDER_JX_IMAGE")
-		if image == "" {
-			image, err = versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
-			if err != nil {
-				return tektonv1alpha1.TaskSpec{}, err
-			}
-		}
+	image, err := resolveBuilderImage(defaultImage, versionsDir)
+	if err != nil {
+		return tektonv1alpha1.TaskSpec{}, err
 	}
 
 	childContainer := &corev1.Container{
@@ -1774,6 +1774,35 @@ func TestFailedValidation(t *testing.T) {
 				Paths:   []string{"claimName"},
 			}).ViaFieldIndex("volumes", 0).ViaField("options"),
 		},
+		{
+			name:          "matrix_without_axes",
+			expectedError: apis.ErrMissingField("axes").ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_axis_invalid_name",
+			expectedError: (&apis.FieldError{
+				Message: "Matrix axis name go-version must be a valid environment variable name",
+				Paths:   []string{"name"},
+			}).ViaFieldIndex("axes", 0).ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_exclude_unknown_axis",
+			expectedError: (&apis.FieldError{
+				Message: "Matrix exclude refers to axis GOOS which is not defined",
+				Paths:   []string{"GOOS"},
+			}).ViaFieldIndex("exclude", 0).ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name:          "matrix_and_parallel",
+			expectedError: apis.ErrMultipleOneOf("matrix", "parallel").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_stage_name_duplicates",
+			expectedError: &apis.FieldError{
+				Message: "Stage names must be unique",
+				Details: "The following stage names are used more than once: 'Build 1.13'",
+			},
+		},
 	}
 
 	for _, tt := range tests {
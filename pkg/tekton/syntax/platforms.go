@@ -0,0 +1,138 @@
+package syntax
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManifestToolDockerImage - the default image used to push the manifest lists of multi-arch images built with Kaniko
+	ManifestToolDockerImage = "mplatform/manifest-tool:alpine-v2.0.3"
+	// ManifestToolStepSuffix - the suffix of the name of the step pushing the manifest list of a multi-arch image
+	ManifestToolStepSuffix = "-manifest"
+
+	kanikoExecutor     = "/kaniko/executor"
+	destinationArg     = "--destination="
+	versionPlaceholder = "${inputs.params.version}"
+)
+
+// isPlatform checks the platform is of the form os/arch or os/arch/variant, e.g. linux/arm64 or linux/arm/v7
+var isPlatform = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`).MatchString
+
+// ValidatePlatforms returns an error if any of the platforms the images are built for is not of the form os/arch or
+// os/arch/variant or is listed more than once
+func ValidatePlatforms(platforms []string) error {
+	for i, p := range platforms {
+		if !isPlatform(p) {
+			return errors.Errorf("invalid platform %s, it must be of the form os/arch or os/arch/variant such as linux/arm64", p)
+		}
+		for _, previous := range platforms[:i] {
+			if previous == p {
+				return errors.Errorf("the platform %s is listed more than once", p)
+			}
+		}
+	}
+	return nil
+}
+
+// platformTag returns the suffix of the tag of the image of a platform, which has to match the template of the
+// manifest list pushed by manifest-tool, e.g. linux-arm64 or linux-armv7
+func platformTag(platform string) string {
+	parts := strings.SplitN(platform, "/", 3)
+	tag := parts[0] + "-" + parts[1]
+	if len(parts) == 3 {
+		tag += parts[2]
+	}
+	return tag
+}
+
+// isSkaffoldBuild checks if the step builds the image of the project with skaffold
+func (s *Step) isSkaffoldBuild() bool {
+	return strings.HasPrefix(s.GetCommand(), "skaffold build") ||
+		(len(s.Arguments) > 0 && strings.HasPrefix(strings.Join(s.Arguments[1:], " "), "skaffold build")) ||
+		commandIsSkaffoldRegex.MatchString(s.GetCommand())
+}
+
+// buildxStep replaces the skaffold build with a docker buildx build pushing a multi-arch image for the platforms, which
+// needs a buildx builder supporting all of them on the docker daemon of the step
+func (s *Step) buildxStep(params StepPlaceholderReplacementArgs) {
+	sourceDir := params.WorkspaceDir
+	dockerfile := filepath.Join(sourceDir, "Dockerfile")
+	destination := params.DockerRegistry + "/" + params.DockerRegistryOrg + "/" + naming.ToValidName(params.GitName)
+
+	s.Command = "docker"
+	s.Arguments = []string{"buildx", "build",
+		"--platform=" + strings.Join(params.Platforms, ","),
+		"--push",
+		"--tag=" + destination + ":" + versionPlaceholder,
+		"--file=" + dockerfile,
+		sourceDir,
+	}
+}
+
+// platformSteps returns a Kaniko step for each of the platforms whose image is tagged with the platform, followed by
+// a step pushing the manifest list of the images with the version tag. Returns just the step if it is not a Kaniko
+// build or the images are not built for more than one platform
+func (s *Step) platformSteps(params StepPlaceholderReplacementArgs) []Step {
+	if len(params.Platforms) < 2 || s.GetCommand() != kanikoExecutor {
+		return []Step{*s}
+	}
+	destinationIndex := -1
+	for i, arg := range s.Arguments {
+		if strings.HasPrefix(arg, destinationArg) && strings.HasSuffix(arg, ":"+versionPlaceholder) {
+			destinationIndex = i
+		}
+	}
+	if destinationIndex < 0 {
+		return []Step{*s}
+	}
+	destination := strings.TrimSuffix(strings.TrimPrefix(s.Arguments[destinationIndex], destinationArg), ":"+versionPlaceholder)
+	name := s.Name
+	if name == "" {
+		name = "build-container"
+	}
+
+	var answer []Step
+	for _, platform := range params.Platforms {
+		step := *s
+		step.Name = fmt.Sprintf("%s-%s", name, platformTag(platform))
+		step.Arguments = append([]string{}, s.Arguments...)
+		step.Arguments[destinationIndex] = fmt.Sprintf("%s%s:%s-%s", destinationArg, destination, versionPlaceholder, platformTag(platform))
+		step.Arguments = append(step.Arguments, "--customPlatform="+platform)
+		answer = append(answer, step)
+	}
+
+	image := params.ManifestToolImage
+	if image == "" {
+		image = ManifestToolDockerImage
+	}
+	manifestArgs := []string{"push", "from-args",
+		"--platforms", strings.Join(params.Platforms, ","),
+		"--template", destination + ":" + versionPlaceholder + "-OS-ARCHVARIANT",
+		"--target", destination + ":" + versionPlaceholder,
+	}
+	if util.StringArrayIndex(s.Arguments, "--insecure") >= 0 {
+		manifestArgs = append([]string{"--insecure"}, manifestArgs...)
+	}
+	// manifest-tool reads the docker config so lets write the Kaniko service account key, if any, into a docker config
+	// using shell builtins so that the key is never in the arguments of a process
+	registry := strings.SplitN(destination, "/", 2)[0]
+	login := `if [ -f "$GOOGLE_APPLICATION_CREDENTIALS" ]; then DOCKER_CFG="$(mktemp -d)"; ` +
+		`printf '{"auths":{"%s":{"auth":"%s"}}}' "` + registry + `" "$(printf '_json_key:%s' "$(cat "$GOOGLE_APPLICATION_CREDENTIALS")" | base64 | tr -d '\n')" > "$DOCKER_CFG/config.json"; ` +
+		`set -- --docker-cfg "$DOCKER_CFG"; fi;`
+	answer = append(answer, Step{
+		Name:      name + ManifestToolStepSuffix,
+		Image:     image,
+		Command:   login + " manifest-tool \"$@\"",
+		Arguments: manifestArgs,
+		Dir:       s.Dir,
+		Env:       s.Env,
+	})
+	return answer
+}
@@ -0,0 +1,105 @@
+// +build unit
+
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePlatforms(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, syntax.ValidatePlatforms([]string{"linux/amd64", "linux/arm64", "linux/arm/v7"}))
+	for _, platforms := range [][]string{{"arm64"}, {"linux/arm64/v8/extra"}, {"Linux/AMD64"}, {"linux/amd64", "linux/amd64"}} {
+		assert.Error(t, syntax.ValidatePlatforms(platforms), "platforms %v should be invalid", platforms)
+	}
+}
+
+func TestReplacePlaceholdersWithPlatforms(t *testing.T) {
+	t.Parallel()
+
+	newPipeline := func() *syntax.ParsedPipeline {
+		return &syntax.ParsedPipeline{
+			Stages: []syntax.Stage{
+				{
+					Name: "build",
+					Steps: []syntax.Step{
+						{
+							Name:    "build-container-build",
+							Command: "skaffold build -f skaffold.yaml",
+						},
+						{
+							Name:    "helm-build",
+							Command: "make build",
+						},
+					},
+				},
+			},
+		}
+	}
+	args := syntax.StepPlaceholderReplacementArgs{
+		WorkspaceDir:      "/workspace/source",
+		GitName:           "myapp",
+		GitOrg:            "myorg",
+		DockerRegistry:    "10.0.0.1:5000",
+		DockerRegistryOrg: "myorg",
+		ProjectID:         "myproject",
+		KanikoImage:       syntax.KanikoDockerImage,
+		UseKaniko:         true,
+		Platforms:         []string{"linux/amd64", "linux/arm64"},
+		ManifestToolImage: "mplatform/manifest-tool:alpine-v2.0.3@sha256:abc",
+	}
+
+	parsed := newPipeline()
+	parsed.ReplacePlaceholdersInStepAndStageDirs(args)
+	steps := parsed.Stages[0].Steps
+	require.Len(t, steps, 4)
+	assert.Equal(t, "build-container-build-linux-amd64", steps[0].Name)
+	assert.Equal(t, "/kaniko/executor", steps[0].Command)
+	assert.Contains(t, steps[0].Arguments, "--destination=10.0.0.1:5000/myorg/myapp:${inputs.params.version}-linux-amd64")
+	assert.Contains(t, steps[0].Arguments, "--customPlatform=linux/amd64")
+	assert.Equal(t, "build-container-build-linux-arm64", steps[1].Name)
+	assert.Contains(t, steps[1].Arguments, "--destination=10.0.0.1:5000/myorg/myapp:${inputs.params.version}-linux-arm64")
+	assert.Contains(t, steps[1].Arguments, "--customPlatform=linux/arm64")
+
+	manifest := steps[2]
+	assert.Equal(t, "build-container-build-manifest", manifest.Name)
+	assert.Equal(t, "mplatform/manifest-tool:alpine-v2.0.3@sha256:abc", manifest.Image)
+	assert.Contains(t, manifest.Command, "manifest-tool")
+	assert.Contains(t, manifest.Command, `"auths":{"%s"`)
+	assert.NotContains(t, manifest.Command, "--password", "should not pass the registry credentials in the arguments")
+	assert.Equal(t, []string{"--insecure", "push", "from-args",
+		"--platforms", "linux/amd64,linux/arm64",
+		"--template", "10.0.0.1:5000/myorg/myapp:${inputs.params.version}-OS-ARCHVARIANT",
+		"--target", "10.0.0.1:5000/myorg/myapp:${inputs.params.version}",
+	}, manifest.Arguments)
+	assert.Equal(t, "helm-build", steps[3].Name)
+
+	args.Platforms = []string{"linux/arm64"}
+	parsed = newPipeline()
+	parsed.ReplacePlaceholdersInStepAndStageDirs(args)
+	steps = parsed.Stages[0].Steps
+	require.Len(t, steps, 2)
+	assert.Equal(t, "build-container-build", steps[0].Name)
+	assert.Contains(t, steps[0].Arguments, "--destination=10.0.0.1:5000/myorg/myapp:${inputs.params.version}")
+	assert.Contains(t, steps[0].Arguments, "--customPlatform=linux/arm64")
+
+	args.UseKaniko = false
+	args.Platforms = []string{"linux/amd64", "linux/arm64"}
+	parsed = newPipeline()
+	parsed.ReplacePlaceholdersInStepAndStageDirs(args)
+	steps = parsed.Stages[0].Steps
+	require.Len(t, steps, 2)
+	assert.Equal(t, "docker", steps[0].Command)
+	assert.Equal(t, []string{"buildx", "build",
+		"--platform=linux/amd64,linux/arm64",
+		"--push",
+		"--tag=10.0.0.1:5000/myorg/myapp:${inputs.params.version}",
+		"--file=/workspace/source/Dockerfile",
+		"/workspace/source",
+	}, steps[0].Arguments)
+}
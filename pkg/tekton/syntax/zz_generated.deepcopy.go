@@ -57,6 +57,11 @@ func (in *CRDsFromPipelineParams) DeepCopyInto(out *CRDsFromPipelineParams) {
 			(*out)[key] = val
 		}
 	}
+	if in.CacheStorage != nil {
+		in, out := &in.CacheStorage, &out.CacheStorage
+		*out = new(CacheStorage)
+		**out = **in
+	}
 	return
 }
 
@@ -70,6 +75,22 @@ func (in *CRDsFromPipelineParams) DeepCopy() *CRDsFromPipelineParams {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheStorage) DeepCopyInto(out *CacheStorage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheStorage.
+func (in *CacheStorage) DeepCopy() *CacheStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Loop) DeepCopyInto(out *Loop) {
 	*out = *in
@@ -98,6 +119,63 @@ func (in *Loop) DeepCopy() *Loop {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Matrix) DeepCopyInto(out *Matrix) {
+	*out = *in
+	if in.Axes != nil {
+		in, out := &in.Axes, &out.Axes
+		*out = make([]MatrixAxis, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Matrix.
+func (in *Matrix) DeepCopy() *Matrix {
+	if in == nil {
+		return nil
+	}
+	out := new(Matrix)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatrixAxis) DeepCopyInto(out *MatrixAxis) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatrixAxis.
+func (in *MatrixAxis) DeepCopy() *MatrixAxis {
+	if in == nil {
+		return nil
+	}
+	out := new(MatrixAxis)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParsedPipeline) DeepCopyInto(out *ParsedPipeline) {
 	*out = *in
@@ -299,6 +377,11 @@ func (in *RootOptions) DeepCopyInto(out *RootOptions) {
 			(*out)[key] = val
 		}
 	}
+	if in.Caches != nil {
+		in, out := &in.Caches, &out.Caches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -353,6 +436,11 @@ func (in *Stage) DeepCopyInto(out *Stage) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		*out = new(Matrix)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Post != nil {
 		in, out := &in.Post, &out.Post
 		*out = make([]Post, len(*in))
@@ -496,6 +584,11 @@ func (in *Step) DeepCopy() *Step {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepPlaceholderReplacementArgs) DeepCopyInto(out *StepPlaceholderReplacementArgs) {
 	*out = *in
+	if in.Platforms != nil {
+		in, out := &in.Platforms, &out.Platforms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
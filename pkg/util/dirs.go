@@ -19,14 +19,12 @@ func HomeDir() string {
 	return h
 }
 
-// GitCredentialsFile returns the location of the git credentials file
+// GitCredentialsFile returns the location of the git credentials file which git reads on all platforms:
+// '$XDG_CONFIG_HOME/git/credentials' or '~/.config/git/credentials' if XDG_CONFIG_HOME is not set
 func GitCredentialsFile() string {
 	cfgHome := os.Getenv("XDG_CONFIG_HOME")
 	if cfgHome == "" {
-		cfgHome = util.HomeDir()
-	}
-	if cfgHome == "" {
-		cfgHome = "."
+		cfgHome = filepath.Join(util.HomeDir(), ".config")
 	}
 	return filepath.Join(cfgHome, "git", "credentials")
 }
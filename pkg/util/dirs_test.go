@@ -46,3 +46,19 @@ func stubFunction(str string, err error) func() (string, error) {
 		return str, err
 	}
 }
+
+func TestGitCredentialsFile(t *testing.T) {
+	xdg, hasXDG := os.LookupEnv("XDG_CONFIG_HOME")
+	if hasXDG {
+		defer os.Setenv("XDG_CONFIG_HOME", xdg)
+	} else {
+		defer os.Unsetenv("XDG_CONFIG_HOME")
+	}
+
+	require.NoError(t, os.Unsetenv("XDG_CONFIG_HOME"))
+	assert.Equal(t, filepath.Join(HomeDir(), ".config", "git", "credentials"), GitCredentialsFile())
+
+	xdgDir := filepath.Join("home", "xdg")
+	require.NoError(t, os.Setenv("XDG_CONFIG_HOME", xdgDir))
+	assert.Equal(t, filepath.Join(xdgDir, "git", "credentials"), GitCredentialsFile())
+}
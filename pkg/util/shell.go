@@ -1,6 +1,9 @@
 package util
 
-import "runtime"
+import (
+	"runtime"
+	"strings"
+)
 
 // GetSh returns the default sh path.
 // Windows returns sh, other platform returns /bin/sh.
@@ -12,3 +15,16 @@ func GetSh() string {
 
 	return shell
 }
+
+// ShellQuotePath returns the path in the form expected by the POSIX shell which git runs its helpers with, which on
+// Windows is the shell of git for windows. Backslashes are replaced by forward slashes and the path is quoted if it
+// contains spaces or other characters the shell would interpret
+func ShellQuotePath(path string) string {
+	if runtime.GOOS == "windows" {
+		path = strings.ReplaceAll(path, "\\", "/")
+	}
+	if !strings.ContainsAny(path, " \t'\"$`\\&;()|<>*?") {
+		return path
+	}
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
@@ -0,0 +1,19 @@
+// +build unit
+// +build !windows
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellQuotePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/usr/local/bin/jx", util.ShellQuotePath("/usr/local/bin/jx"))
+	assert.Equal(t, "'/opt/my tools/jx'", util.ShellQuotePath("/opt/my tools/jx"))
+	assert.Equal(t, `'/opt/it'\''s/jx'`, util.ShellQuotePath("/opt/it's/jx"))
+}
@@ -0,0 +1,32 @@
+// +build unit
+// +build windows
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuotePathWindows(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "C:/jx/bin/jx.exe", util.ShellQuotePath(`C:\jx\bin\jx.exe`))
+	assert.Equal(t, "'C:/Program Files/jx/jx.exe'", util.ShellQuotePath(`C:\Program Files\jx\jx.exe`))
+}
+
+func TestCommandFindsWindowsExecutables(t *testing.T) {
+	t.Parallel()
+
+	// the executable is found without its '.exe' suffix via PATHEXT
+	cmd := util.Command{
+		Name: "cmd",
+		Args: []string{"/C", "echo", "hello"},
+	}
+	out, err := cmd.RunWithoutRetry()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", out)
+}
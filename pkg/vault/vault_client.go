@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/jenkins-x/jx/v2/pkg/secreturl"
@@ -16,7 +17,7 @@ const (
 	yamlDataKey = "yaml"
 )
 
-var vaultURIRegex = regexp.MustCompile(`:[\s"]*vault:[-_.\w\/:]*`)
+var vaultURIRegex = regexp.MustCompile(`:[\s"]*vault:[-_.\w\/:#?=]*`)
 
 // Client is an interface for interacting with Vault
 //go:generate pegomock generate github.com/jenkins-x/jx/v2/pkg/vault Client -o mocks/vault_client.go
@@ -95,6 +96,38 @@ func (v *client) Read(secretName string) (map[string]interface{}, error) {
 	return nil, fmt.Errorf("no data found on secret %q", secretName)
 }
 
+// ReadVersion reads a named secret from the KV v2 mount in the vault namespace. If the version is not zero then that
+// metadata version of the secret is read which must not have been deleted or destroyed
+func (v *client) ReadVersion(namespace string, mount string, secretName string, version int) (map[string]interface{}, error) {
+	path := mountSecretPath(namespace, mount, secretName)
+	var data map[string][]string
+	if version > 0 {
+		data = map[string][]string{
+			"version": {strconv.Itoa(version)},
+		}
+	}
+	secret, err := v.client.Logical().ReadWithData(path, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading secret %q from vault", path)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no secret %q version %d found in vault", path, version)
+	}
+	if metadata, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		if destroyed, _ := metadata["destroyed"].(bool); destroyed {
+			return nil, fmt.Errorf("version %v of secret %q has been destroyed", metadata["version"], path)
+		}
+		if deleted, _ := metadata["deletion_time"].(string); deleted != "" {
+			return nil, fmt.Errorf("version %v of secret %q was deleted at %s", metadata["version"], path, deleted)
+		}
+	}
+	answer, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data type for secret %q", path)
+	}
+	return answer, nil
+}
+
 // WriteObject writes a generic named object to the vault. The secret _must_ be serializable to JSON
 func (v *client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
 	// Convert the secret into a saveable map[string]interface{} format
@@ -1,5 +1,7 @@
 package vault
 
+import "strings"
+
 // secretPath generates a secret path from the secret path for storing in vault
 // this just makes sure it gets stored under /secret
 func secretPath(path string) string {
@@ -11,6 +13,17 @@ func secretMetadataPath(path string) string {
 	return "secret/metadata/" + path
 }
 
+// mountSecretPath generates the path of a secret in a KV v2 mount prefixed by its vault namespace if it has one,
+// which vault enterprise accepts instead of the namespace header
+func mountSecretPath(namespace string, mount string, path string) string {
+	answer := strings.Trim(mount, "/") + "/data/" + strings.TrimPrefix(path, "/")
+	namespace = strings.Trim(namespace, "/")
+	if namespace != "" {
+		answer = namespace + "/" + answer
+	}
+	return answer
+}
+
 // AdminSecretPath returns the admin secret path for a given admin secret
 func AdminSecretPath(secret AdminSecret) string {
 	return AdminSecretsPath + string(secret)
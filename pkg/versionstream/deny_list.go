@@ -0,0 +1,68 @@
+package versionstream
+
+import (
+	"io/ioutil"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// DenyList maps chart names (e.g. `jenkins-x/foo`) to versions which have been yanked and must never be deployed
+// even if the version stream still refers to them
+type DenyList struct {
+	Charts map[string][]string `json:"charts,omitempty"`
+}
+
+// LoadDenyList loads the deny list from the given YAML file
+func LoadDenyList(path string) (*DenyList, error) {
+	answer := &DenyList{}
+	exists, err := util.FileExists(path)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return answer, errors.Errorf("deny list file %s does not exist", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	err = yaml.Unmarshal(data, answer)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to unmarshal YAML in file %s", path)
+	}
+	return answer, nil
+}
+
+// IsDenied returns true if the given version of the chart is in the deny list
+func (d *DenyList) IsDenied(name string, version string) bool {
+	if d == nil {
+		return false
+	}
+	return util.StringArrayIndex(d.Charts[name], version) >= 0
+}
+
+// FallbackVersion returns the highest stable version from the candidates which is lower than the denied version and
+// is not itself denied. The candidate is returned as written, e.g. with any 'v' prefix, so that it matches the chart
+// repository. Returns an empty string if there is no such version
+func (d *DenyList) FallbackVersion(name string, denied string, candidates []string) string {
+	deniedVersion, err := semver.ParseTolerant(denied)
+	if err != nil {
+		return ""
+	}
+	answer := ""
+	var best semver.Version
+	for _, candidate := range candidates {
+		v, err := semver.ParseTolerant(candidate)
+		if err != nil || len(v.Pre) > 0 || v.GTE(deniedVersion) || d.IsDenied(name, candidate) {
+			continue
+		}
+		if answer == "" || v.GT(best) {
+			best = v
+			answer = candidate
+		}
+	}
+	return answer
+}
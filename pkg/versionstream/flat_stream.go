@@ -0,0 +1,115 @@
+package versionstream
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// FlatVersionsFileName the name of the single file used by flat version streams
+const FlatVersionsFileName = "versions.yaml"
+
+// FlatVersionStream a lightweight version stream which keeps all of the stable versions in a single file
+// rather than a file per name inside a directory per kind
+type FlatVersionStream struct {
+	Charts       map[string]*StableVersion `json:"charts,omitempty"`
+	Packages     map[string]*StableVersion `json:"packages,omitempty"`
+	Docker       map[string]*StableVersion `json:"docker,omitempty"`
+	Git          map[string]*StableVersion `json:"git,omitempty"`
+	Repositories []RepositoryURLs          `json:"repositories,omitempty"`
+}
+
+// IsFlatVersionStream returns true if the directory contains a flat version stream file and does not use the
+// directory per kind layout
+func IsFlatVersionStream(dir string) (bool, error) {
+	fileName := filepath.Join(dir, FlatVersionsFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return false, nil
+	}
+	for _, kind := range Kinds {
+		kindDir := filepath.Join(dir, string(kind))
+		exists, err = util.DirExists(kindDir)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check if dir exists %s", kindDir)
+		}
+		if exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// LoadFlatVersionStream loads the flat version stream file from the given directory
+func LoadFlatVersionStream(dir string) (*FlatVersionStream, error) {
+	answer := &FlatVersionStream{}
+	fileName := filepath.Join(dir, FlatVersionsFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return answer, errors.Errorf("flat version stream file %s does not exist", fileName)
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	err = yaml.Unmarshal(data, answer)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to unmarshal YAML in file %s", fileName)
+	}
+	return answer, nil
+}
+
+// StableVersions returns the stable versions of the given kind indexed by name
+func (f *FlatVersionStream) StableVersions(kind VersionKind) map[string]*StableVersion {
+	switch kind {
+	case KindChart:
+		return f.Charts
+	case KindPackage:
+		return f.Packages
+	case KindDocker:
+		return f.Docker
+	case KindGit:
+		return f.Git
+	default:
+		return nil
+	}
+}
+
+// StableVersion returns the stable version of the given kind and name returning an empty object if there is none
+func (f *FlatVersionStream) StableVersion(kind VersionKind, name string) *StableVersion {
+	if kind == KindGit {
+		name = GitURLToName(name)
+	}
+	version := f.StableVersions(kind)[name]
+	if version == nil {
+		return &StableVersion{}
+	}
+	return version
+}
+
+// StableVersionNames returns the sorted names of all the stable versions of the given kind
+func (f *FlatVersionStream) StableVersionNames(kind VersionKind) []string {
+	answer := []string{}
+	for name := range f.StableVersions(kind) {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// RepositoryPrefixes returns the chart repository prefixes of the version stream
+func (f *FlatVersionStream) RepositoryPrefixes() *RepositoryPrefixes {
+	return &RepositoryPrefixes{
+		Repositories: f.Repositories,
+	}
+}
@@ -0,0 +1,76 @@
+package versionstream
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ChartNameTransforms the transforms applied to the names of the charts in a chart repository before looking them up
+// in the version stream. e.g. for mirror repositories which prepend a registry path to the chart names
+type ChartNameTransforms struct {
+	Repositories []ChartNameTransform `json:"repositories,omitempty"`
+}
+
+// ChartNameTransform transforms the chart names of a single chart repository. The prefix is stripped first and then
+// any regular expression replacement is applied
+type ChartNameTransform struct {
+	URL         string `json:"url"`
+	StripPrefix string `json:"stripPrefix,omitempty"`
+	Replace     string `json:"replace,omitempty"`
+	With        string `json:"with,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// LoadChartNameTransforms loads the chart name transforms from the given YAML file
+func LoadChartNameTransforms(path string) (*ChartNameTransforms, error) {
+	answer := &ChartNameTransforms{}
+	exists, err := util.FileExists(path)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return answer, errors.Errorf("chart name transforms file %s does not exist", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	err = yaml.Unmarshal(data, answer)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to unmarshal YAML in file %s", path)
+	}
+	for i := range answer.Repositories {
+		t := &answer.Repositories[i]
+		if t.Replace != "" {
+			t.regex, err = regexp.Compile(t.Replace)
+			if err != nil {
+				return answer, errors.Wrapf(err, "invalid replace expression %s for repository %s in file %s", t.Replace, t.URL, path)
+			}
+		}
+	}
+	return answer, nil
+}
+
+// Transform returns the chart name to use in the version stream for the given chart repository and chart name
+func (t *ChartNameTransforms) Transform(repository string, name string) string {
+	if t == nil {
+		return name
+	}
+	repository = strings.TrimSuffix(repository, "/")
+	for _, transform := range t.Repositories {
+		if strings.TrimSuffix(transform.URL, "/") != repository {
+			continue
+		}
+		name = strings.TrimPrefix(name, transform.StripPrefix)
+		if transform.regex != nil {
+			name = transform.regex.ReplaceAllString(name, transform.With)
+		}
+	}
+	return name
+}
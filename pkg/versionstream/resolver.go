@@ -2,6 +2,8 @@ package versionstream
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -10,6 +12,35 @@ import (
 // VersionResolver resolves versions of charts, packages or docker images
 type VersionResolver struct {
 	VersionsDir string
+	// Flat forces the use of a flat single file version stream layout; otherwise the layout is detected
+	Flat bool
+
+	flatStream *FlatVersionStream
+}
+
+// FlatVersionStream returns the flat version stream if the versions directory uses the flat single file layout
+// or nil if it uses the directory per kind layout
+func (v *VersionResolver) FlatVersionStream() (*FlatVersionStream, error) {
+	if v.flatStream != nil {
+		return v.flatStream, nil
+	}
+	flat := v.Flat
+	if !flat {
+		var err error
+		flat, err = IsFlatVersionStream(v.VersionsDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !flat {
+		return nil, nil
+	}
+	var err error
+	v.flatStream, err = LoadFlatVersionStream(v.VersionsDir)
+	if err != nil {
+		return nil, err
+	}
+	return v.flatStream, nil
 }
 
 // ResolveDockerImage ensures the given docker image has a valid version if there is one in the version stream
@@ -17,16 +48,113 @@ func (v *VersionResolver) ResolveDockerImage(image string) (string, error) {
 	return ResolveDockerImage(v.VersionsDir, image)
 }
 
+// ResolveDockerImageDigest returns the full reference of the given docker image pinned to the version and digest in
+// the version stream. e.g. 'gcr.io/foo/bar:1.2.3@sha256:...'. If there is no digest in the version stream the image is
+// only pinned to the version. If the image already has a digest, or a version other than the one in the version
+// stream, it is returned as is
+func (v *VersionResolver) ResolveDockerImageDigest(image string) (string, error) {
+	image = strings.TrimSuffix(strings.TrimSpace(image), ":")
+	if strings.Contains(image, "@") {
+		return image, nil
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		// the digest of a multi-arch image is the digest of its manifest list so it pins the image on every platform
+		_, data, err := v.stableDockerImage(image[:i])
+		if err != nil {
+			return image, err
+		}
+		if data.Version == image[i+1:] && data.Digest != "" {
+			return image + "@" + data.Digest, nil
+		}
+		return image, nil
+	}
+	name, data, err := v.stableDockerImage(image)
+	if err != nil {
+		return image, err
+	}
+	if data.Version == "" {
+		log.Logger().Warnf("could not find a stable version for Docker image: %s in %s", image, v.VersionsDir)
+		return image, nil
+	}
+	answer := name + ":" + data.Version
+	if data.Digest != "" {
+		answer += "@" + data.Digest
+	}
+	return answer, nil
+}
+
+// StableVersionDigest returns the digest of the given kind and name in the version stream. e.g. the 'sha256:...'
+// digest of a docker image or the checksum of a package. Returns a blank string if there is no digest
+func (v *VersionResolver) StableVersionDigest(kind VersionKind, name string) (string, error) {
+	if kind == KindDocker {
+		_, data, err := v.stableDockerImage(name)
+		if err != nil {
+			return "", err
+		}
+		return data.Digest, nil
+	}
+	data, err := v.StableVersion(kind, name)
+	if err != nil {
+		return "", err
+	}
+	return data.Digest, nil
+}
+
+// stableDockerImage returns the stable version of the docker image along with the name it is found with in the
+// version stream, trying without any 'docker.io/' prefix if need be
+func (v *VersionResolver) stableDockerImage(image string) (string, *StableVersion, error) {
+	data, err := v.StableVersion(KindDocker, image)
+	if err != nil {
+		return image, data, err
+	}
+	prefix := "docker.io/"
+	if data.Version == "" && strings.HasPrefix(image, prefix) {
+		image = strings.TrimPrefix(image, prefix)
+		data, err = v.StableVersion(KindDocker, image)
+	}
+	return image, data, err
+}
+
 // StableVersion returns the stable version of the given kind name
 func (v *VersionResolver) StableVersion(kind VersionKind, name string) (*StableVersion, error) {
+	flat, err := v.FlatVersionStream()
+	if err != nil {
+		return nil, err
+	}
+	if flat != nil {
+		return flat.StableVersion(kind, name), nil
+	}
 	return LoadStableVersion(v.VersionsDir, kind, name)
 }
 
 // StableVersionNumber returns the stable version number of the given kind name
 func (v *VersionResolver) StableVersionNumber(kind VersionKind, name string) (string, error) {
+	flat, err := v.FlatVersionStream()
+	if err != nil {
+		return "", err
+	}
+	if flat != nil {
+		version := flat.StableVersion(kind, name).Version
+		if version == "" && !(kind == KindChart && name == ".") {
+			log.Logger().Warnf("could not find a stable version from %s of %s in %s", string(kind), name, filepath.Join(v.VersionsDir, FlatVersionsFileName))
+		}
+		return version, nil
+	}
 	return LoadStableVersionNumber(v.VersionsDir, kind, name)
 }
 
+// StableVersionNames returns the names of all the stable versions of the given kind
+func (v *VersionResolver) StableVersionNames(kind VersionKind) ([]string, error) {
+	flat, err := v.FlatVersionStream()
+	if err != nil {
+		return nil, err
+	}
+	if flat != nil {
+		return flat.StableVersionNames(kind), nil
+	}
+	return LoadStableVersionNames(v.VersionsDir, kind)
+}
+
 // ResolveGitVersion resolves the version to use for the given git repository using the version stream
 func (v *VersionResolver) ResolveGitVersion(gitURL string) (string, error) {
 	answer, err := v.StableVersionNumber(KindGit, gitURL)
@@ -61,7 +189,7 @@ func (v *VersionResolver) VerifyPackages(packages map[string]string) error {
 
 // VerifyPackage verifies the package is of a sufficient version
 func (v *VersionResolver) VerifyPackage(name string, currentVersion string) error {
-	data, err := LoadStableVersion(v.VersionsDir, KindPackage, name)
+	data, err := v.StableVersion(KindPackage, name)
 	if err != nil {
 		return err
 	}
@@ -70,5 +198,12 @@ func (v *VersionResolver) VerifyPackage(name string, currentVersion string) erro
 
 // GetRepositoryPrefixes loads the repository prefixes for the version stream
 func (v *VersionResolver) GetRepositoryPrefixes() (*RepositoryPrefixes, error) {
+	flat, err := v.FlatVersionStream()
+	if err != nil {
+		return nil, err
+	}
+	if flat != nil {
+		return flat.RepositoryPrefixes(), nil
+	}
 	return GetRepositoryPrefixes(v.VersionsDir)
 }
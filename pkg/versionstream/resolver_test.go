@@ -9,6 +9,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVersionGitRepository(t *testing.T) {
@@ -33,3 +34,92 @@ func TestVersionGitRepository(t *testing.T) {
 		}
 	}
 }
+
+func TestFlatVersionStream(t *testing.T) {
+	t.Parallel()
+
+	versionsDir := path.Join("test_data", "flat-versions")
+	assert.DirExists(t, versionsDir)
+
+	flat, err := versionstream.IsFlatVersionStream(versionsDir)
+	require.NoError(t, err)
+	assert.True(t, flat, "should detect a flat version stream in %s", versionsDir)
+
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: versionsDir,
+	}
+
+	testData := map[string]string{
+		"jenkins-x/tekton":      "0.0.56",
+		"stable/nginx-ingress":  "1.26.2",
+		"stable/does-not-exist": "",
+	}
+	for name, expected := range testData {
+		actual, err := resolver.StableVersionNumber(versionstream.KindChart, name)
+		if assert.NoError(t, err, "resolving chart version %s", name) {
+			assert.Equal(t, expected, actual, "resolving chart version %s", name)
+		}
+	}
+
+	gitVersion, err := resolver.ResolveGitVersion("https://github.com/jenkins-x/jenkins-x-boot-config.git")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", gitVersion, "resolving git version")
+
+	names, err := resolver.StableVersionNames(versionstream.KindChart)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jenkins-x/tekton", "stable/nginx-ingress"}, names)
+
+	prefixes, err := resolver.GetRepositoryPrefixes()
+	require.NoError(t, err)
+	assert.Equal(t, "stable", prefixes.PrefixForURL("https://kubernetes-charts.storage.googleapis.com"))
+	assert.Equal(t, []string{"http://chartmuseum.jenkins-x.io"}, prefixes.URLsForPrefix("jenkins-x"))
+}
+
+func TestDirectoryVersionStreamIsNotFlat(t *testing.T) {
+	t.Parallel()
+
+	flat, err := versionstream.IsFlatVersionStream(path.Join("test_data", "jenkins-x-versions"))
+	require.NoError(t, err)
+	assert.False(t, flat, "should not detect a flat version stream")
+}
+
+func TestResolveDockerImageDigest(t *testing.T) {
+	t.Parallel()
+
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: path.Join("test_data", "flat-versions"),
+	}
+	builderDigest := "sha256:4bdce6e4a6e6e8d4b8a0b4f4a6c2f2e4d4bd0e1c0a1bcbd6b2a0e8b0c2e1f3a5"
+	testData := map[string]string{
+		"gcr.io/jenkinsxio/builder-go":                  "gcr.io/jenkinsxio/builder-go:2.0.1028-359@" + builderDigest,
+		"gcr.io/jenkinsxio/builder-go:1.0.0":            "gcr.io/jenkinsxio/builder-go:1.0.0",
+		"gcr.io/jenkinsxio/builder-go:2.0.1028-359":     "gcr.io/jenkinsxio/builder-go:2.0.1028-359@" + builderDigest,
+		"gcr.io/jenkinsxio/builder-go@" + builderDigest: "gcr.io/jenkinsxio/builder-go@" + builderDigest,
+		"gcr.io/jenkinsxio/does-not-exist":              "gcr.io/jenkinsxio/does-not-exist",
+	}
+	for image, expected := range testData {
+		actual, err := resolver.ResolveDockerImageDigest(image)
+		if assert.NoError(t, err, "resolving image %s", image) {
+			assert.Equal(t, expected, actual, "resolving image %s", image)
+		}
+	}
+	digest, err := resolver.StableVersionDigest(versionstream.KindDocker, "gcr.io/jenkinsxio/builder-go")
+	require.NoError(t, err)
+	assert.Equal(t, builderDigest, digest)
+
+	resolver = &versionstream.VersionResolver{
+		VersionsDir: path.Join("test_data", "jenkins-x-versions"),
+	}
+	fubarDigest := "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	actual, err := resolver.ResolveDockerImageDigest("docker.io/fubar")
+	require.NoError(t, err)
+	assert.Equal(t, "fubar:2.0.0@"+fubarDigest, actual)
+
+	actual, err = resolver.ResolveDockerImageDigest("gcr.io/jenkinsxio/builder-jx")
+	require.NoError(t, err)
+	assert.Equal(t, "gcr.io/jenkinsxio/builder-jx:1.0.0", actual, "should only pin the version if there is no digest")
+
+	digest, err = resolver.StableVersionDigest(versionstream.KindPackage, "helm")
+	require.NoError(t, err)
+	assert.Equal(t, "", digest)
+}
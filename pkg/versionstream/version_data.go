@@ -3,6 +3,7 @@ package versionstream
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/blang/semver"
 	"github.com/jenkins-x/jx/v2/pkg/log"
@@ -36,6 +37,9 @@ const (
 
 	// KindGit represents a git repository (e.g. for jx boot configuration or a build pack)
 	KindGit VersionKind = "git"
+
+	// QuickStartsFileName the name of the file containing the index of quickstarts
+	QuickStartsFileName = "quickstarts.yml"
 )
 
 var (
@@ -65,6 +69,9 @@ type StableVersion struct {
 	// e.g. for packages we could use: `{ version: "1.10.1", upperLimit: "1.14.0"}` which would mean these
 	// versions are all valid `["1.11.5", "1.13.1234"]` but these are invalid `["1.14.0", "1.14.1"]`
 	UpperLimit string `json:"upperLimit,omitempty"`
+	// Digest the optional digest of the version used to pin it. e.g. the 'sha256:...' digest of a docker image, which
+	// for a multi-arch image is the digest of its manifest list
+	Digest string `json:"digest,omitempty"`
 	// GitURL the URL to the source code
 	GitURL string `json:"gitUrl,omitempty"`
 	// Component is the component inside the git URL
@@ -217,6 +224,39 @@ func LoadStableVersionNumber(wrkDir string, kind VersionKind, name string) (stri
 	return version, err
 }
 
+// LoadStableVersionNames returns the names of all the stable versions of the given kind in the version configuration
+// directory
+func LoadStableVersionNames(wrkDir string, kind VersionKind) ([]string, error) {
+	answer := []string{}
+	kindDir := filepath.Join(wrkDir, string(kind))
+	exists, err := util.DirExists(kindDir)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to check if dir exists %s", kindDir)
+	}
+	if !exists {
+		return answer, nil
+	}
+	err = filepath.Walk(kindDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".yml" {
+			return err
+		}
+		if kind == KindChart && path == filepath.Join(kindDir, "repositories.yml") {
+			return nil
+		}
+		name, err := NameFromPath(kindDir, path)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, filepath.ToSlash(name))
+		return nil
+	})
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to find the %s names in %s", string(kind), kindDir)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
 // SaveStableVersion saves the version file
 func SaveStableVersion(wrkDir string, kind VersionKind, name string, stableVersion *StableVersion) error {
 	path := filepath.Join(wrkDir, string(kind), name+".yml")
@@ -351,8 +391,12 @@ func GetRepositoryPrefixes(dir string) (*RepositoryPrefixes, error) {
 
 // GetQuickStarts loads the quickstarts from the version stream
 func GetQuickStarts(dir string) (*QuickStarts, error) {
+	return LoadQuickStartsFile(filepath.Join(dir, QuickStartsFileName))
+}
+
+// LoadQuickStartsFile loads the quickstarts from the given index file returning no quickstarts if it does not exist
+func LoadQuickStartsFile(fileName string) (*QuickStarts, error) {
 	answer := &QuickStarts{}
-	fileName := filepath.Join(dir, "quickstarts.yml")
 	exists, err := util.FileExists(fileName)
 	if err != nil {
 		return answer, errors.Wrapf(err, "failed to find file %s", fileName)
@@ -377,7 +421,7 @@ func SaveQuickStarts(dir string, qs *QuickStarts) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal quickstarts to YAML")
 	}
-	fileName := filepath.Join(dir, "quickstarts.yml")
+	fileName := filepath.Join(dir, QuickStartsFileName)
 	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
 	if err != nil {
 		return errors.Wrapf(err, "failed to save file %s", fileName)
@@ -390,6 +434,7 @@ type RepositoryPrefixes struct {
 	Repositories []RepositoryURLs    `json:"repositories"`
 	urlToPrefix  map[string]string   `json:"-"`
 	prefixToURLs map[string][]string `json:"-"`
+	lock         sync.Mutex
 }
 
 // RepositoryURLs contains the prefix and URLS for a repository
@@ -408,6 +453,9 @@ type QuickStart struct {
 	Framework      string   `json:"framework,omitempty"`
 	Tags           []string `json:"tags,omitempty"`
 	DownloadZipURL string   `json:"downloadZipURL,omitempty"`
+	License        string   `json:"license,omitempty"`
+	// Branch the git branch the quickstart is downloaded from if there is no downloadZipURL. Defaults to master
+	Branch string `json:"branch,omitempty"`
 }
 
 // QuickStarts the configuration of a the quickstarts in the version stream
@@ -462,12 +510,30 @@ func (q *QuickStart) defaultMissingValues(qs *QuickStarts) {
 		q.ID = fmt.Sprintf("%s/%s", q.Owner, q.Name)
 	}
 	if q.DownloadZipURL == "" {
-		q.DownloadZipURL = fmt.Sprintf("https://codeload.github.com/%s/%s/zip/master", q.Owner, q.Name)
+		q.DownloadZipURL = fmt.Sprintf("https://codeload.github.com/%s/%s/zip/%s", q.Owner, q.Name, q.GetBranch())
+	}
+}
+
+// GetBranch returns the git branch the quickstart is downloaded from
+func (q *QuickStart) GetBranch() string {
+	if q.Branch == "" {
+		return "master"
 	}
+	return q.Branch
 }
 
-// PrefixForURL returns the repository prefix for the given URL
+// PrefixForURL returns the repository prefix for the given URL. It is safe to call concurrently
 func (p *RepositoryPrefixes) PrefixForURL(u string) string {
+	prefix, _ := p.LookupPrefixForURL(u)
+	return prefix
+}
+
+// LookupPrefixForURL returns the repository prefix for the given URL and whether the URL is in the repositories so
+// that a repository configured with an empty prefix can be told apart from an unknown one. It is safe to call
+// concurrently
+func (p *RepositoryPrefixes) LookupPrefixForURL(u string) (string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if p.urlToPrefix == nil {
 		p.urlToPrefix = map[string]string{}
 
@@ -477,11 +543,14 @@ func (p *RepositoryPrefixes) PrefixForURL(u string) string {
 			}
 		}
 	}
-	return p.urlToPrefix[u]
+	prefix, ok := p.urlToPrefix[u]
+	return prefix, ok
 }
 
-// URLsForPrefix returns the repository URLs for the given prefix
+// URLsForPrefix returns the repository URLs for the given prefix. It is safe to call concurrently
 func (p *RepositoryPrefixes) URLsForPrefix(prefix string) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if p.prefixToURLs == nil {
 		p.prefixToURLs = make(map[string][]string)
 		for _, repo := range p.Repositories {
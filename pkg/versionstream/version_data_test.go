@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/jenkins-x/jx/v2/pkg/log"
@@ -60,6 +61,24 @@ func TestRepositories(t *testing.T) {
 	}
 }
 
+// TestRepositoriesConcurrent tests the lazily created repository prefix maps can be used concurrently which is
+// verified by running with -race
+func TestRepositoriesConcurrent(t *testing.T) {
+	prefixes, err := GetRepositoryPrefixes(dataDir)
+	require.NoError(t, err, "GetRepositoryPrefixes() failed on dir %s", dataDir)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "stable", prefixes.PrefixForURL("https://kubernetes-charts.storage.googleapis.com"))
+			assert.NotEmpty(t, prefixes.URLsForPrefix("jenkins-x"))
+		}()
+	}
+	wg.Wait()
+}
+
 // TestExactPackageVersionRange tests ranges of packages
 func TestExactPackageVersionRange(t *testing.T) {
 	resolver := &VersionResolver{
@@ -158,3 +177,30 @@ func TestConvertToVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadStableVersionNames(t *testing.T) {
+	names, err := LoadStableVersionNames(dataDir, KindPackage)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git", "helm", "kubectl", "skaffold"}, names)
+
+	names, err = LoadStableVersionNames(dataDir, KindChart)
+	require.NoError(t, err)
+	assert.Contains(t, names, "jenkins-x/knative-build")
+	assert.NotContains(t, names, "repositories")
+}
+
+func TestChartNameTransforms(t *testing.T) {
+	transforms := &ChartNameTransforms{
+		Repositories: []ChartNameTransform{
+			{
+				URL:         "https://mirror.acme.com/charts",
+				StripPrefix: "registry-",
+			},
+		},
+	}
+	assert.Equal(t, "foo", transforms.Transform("https://mirror.acme.com/charts/", "registry-foo"))
+	assert.Equal(t, "registry-foo", transforms.Transform("https://other.acme.com/charts", "registry-foo"))
+
+	var noTransforms *ChartNameTransforms
+	assert.Equal(t, "registry-foo", noTransforms.Transform("https://mirror.acme.com/charts", "registry-foo"))
+}
@@ -0,0 +1,183 @@
+package versionstreamrepo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CacheDirName the name of the directory inside the jx config dir, e.g. '~/.jx', holding the cached version streams
+	CacheDirName = "versionstream-cache"
+
+	// cacheEntryFileName the file in each cache entry recording where and when the version stream was fetched
+	cacheEntryFileName = "cache.yaml"
+
+	// cacheRepoDirName the directory in each cache entry containing the clone of the version stream
+	cacheRepoDirName = "repo"
+)
+
+// CacheEntry records the version stream cloned into a cache entry
+type CacheEntry struct {
+	URL         string    `json:"url"`
+	Ref         string    `json:"ref"`
+	ResolvedRef string    `json:"resolvedRef,omitempty"`
+	Fetched     time.Time `json:"fetched"`
+}
+
+// Cache caches clones of version streams on the local disk keyed by the URL and git ref so that they are only fetched
+// again once they are older than the TTL
+type Cache struct {
+	// Dir the directory containing the cache entries
+	Dir string
+	// TTL how long a cached version stream is used before it is fetched again
+	TTL time.Duration
+	// Offline uses the cached version stream regardless of its age and fails rather than fetching it if there is none
+	Offline bool
+
+	now   func() time.Time
+	clone func(wrkDir string, versionRepository string, versionRef string) (string, error)
+}
+
+// NewCache creates a cache in the 'versionstream-cache' directory of the jx config dir
+func NewCache(ttl time.Duration, offline bool) (*Cache, error) {
+	configDir, err := util.ConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("error determining config dir %v", err)
+	}
+	return &Cache{
+		Dir:     filepath.Join(configDir, CacheDirName),
+		TTL:     ttl,
+		Offline: offline,
+	}, nil
+}
+
+// CloneJXVersionsRepo returns the directory of the cached clone of the version stream and its resolved git ref. The
+// version stream is only fetched if it is not cached or the cached clone is older than the TTL. If fetching fails then
+// any stale cached clone is used instead
+func (c *Cache) CloneJXVersionsRepo(versionRepository string, versionRef string, settings *v1.TeamSettings, gitter gits.Gitter, batchMode bool, advancedMode bool, handles util.IOFileHandles) (string, string, error) {
+	versionRepository, versionRef = defaultVersionsRepo(versionRepository, versionRef, settings)
+	// lets not log any credentials of a privately hosted version stream or store them in the cache entry. They are
+	// removed from the remote of the cached clone after it is cloned
+	sanitizedURL := util.SanitizeURL(versionRepository)
+	entryDir := c.entryDir(sanitizedURL, versionRef)
+	repoDir := filepath.Join(entryDir, cacheRepoDirName)
+
+	entry, err := loadCacheEntry(entryDir)
+	if err != nil {
+		return "", "", err
+	}
+	if entry != nil {
+		// lets ignore an entry whose clone has been removed
+		exists, err := util.DirExists(repoDir)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to check if dir exists %s", repoDir)
+		}
+		if !exists {
+			entry = nil
+		}
+	}
+	if entry != nil {
+		age := c.currentTime().Sub(entry.Fetched)
+		if c.Offline || age < c.TTL {
+			log.Logger().Debugf("using the version stream %s ref %s cached %s ago in %s", sanitizedURL, versionRef, age.Round(time.Second).String(), repoDir)
+			return repoDir, entry.ResolvedRef, nil
+		}
+	}
+	if c.Offline {
+		return "", "", fmt.Errorf("the version stream %s ref %s is not cached in %s so it cannot be used offline", sanitizedURL, versionRef, c.Dir)
+	}
+
+	clone := c.clone
+	if clone == nil {
+		clone = func(wrkDir string, versionRepository string, versionRef string) (string, error) {
+			_, versionRef, err := cloneJXVersionsRepoToDir(wrkDir, versionRepository, versionRef, gitter, batchMode, advancedMode, handles)
+			if err != nil {
+				return "", err
+			}
+			return resolveRefToTag(wrkDir, versionRef, gitter)
+		}
+	}
+	resolved, err := clone(repoDir, versionRepository, versionRef)
+	if err != nil {
+		if entry != nil {
+			log.Logger().Warnf("failed to fetch the version stream %s ref %s so using the version stream cached at %s: %s", sanitizedURL, versionRef, entry.Fetched.Format(time.RFC3339), err.Error())
+			return repoDir, entry.ResolvedRef, nil
+		}
+		return "", "", errors.Wrapf(err, "failed to clone the version stream %s ref %s", sanitizedURL, versionRef)
+	}
+	entry = &CacheEntry{
+		URL:         sanitizedURL,
+		Ref:         versionRef,
+		ResolvedRef: resolved,
+		Fetched:     c.currentTime(),
+	}
+	err = saveCacheEntry(entryDir, entry)
+	if err != nil {
+		return "", "", err
+	}
+	return repoDir, resolved, nil
+}
+
+// entryDir returns the directory of the cache entry for the version stream URL and git ref
+func (c *Cache) entryDir(versionRepository string, versionRef string) string {
+	hash := sha256.Sum256([]byte(versionRepository + "#" + versionRef))
+	return filepath.Join(c.Dir, fmt.Sprintf("%x", hash[:8]))
+}
+
+func (c *Cache) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// loadCacheEntry loads the cache entry in the given directory returning nil if there is none
+func loadCacheEntry(dir string) (*CacheEntry, error) {
+	fileName := filepath.Join(dir, cacheEntryFileName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	entry := &CacheEntry{}
+	err = yaml.Unmarshal(data, entry)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML in file %s", fileName)
+	}
+	return entry, nil
+}
+
+// saveCacheEntry saves the cache entry in the given directory
+func saveCacheEntry(dir string, entry *CacheEntry) error {
+	err := os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the version stream cache entry to YAML")
+	}
+	fileName := filepath.Join(dir, cacheEntryFileName)
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
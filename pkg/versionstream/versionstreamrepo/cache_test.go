@@ -0,0 +1,80 @@
+// +build unit
+
+package versionstreamrepo
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheCloneJXVersionsRepo(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-versionstream-cache-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	clones := 0
+	var cloneErr error
+	cache := &Cache{
+		Dir: dir,
+		TTL: time.Hour,
+		now: func() time.Time {
+			return now
+		},
+		clone: func(wrkDir string, versionRepository string, versionRef string) (string, error) {
+			clones++
+			if cloneErr != nil {
+				return "", cloneErr
+			}
+			err := os.MkdirAll(wrkDir, util.DefaultWritePermissions)
+			require.NoError(t, err)
+			return "v1.0.1", nil
+		},
+	}
+	const url = "https://github.com/jenkins-x/jenkins-x-versions.git"
+
+	repoDir, resolved, err := cache.CloneJXVersionsRepo(url, "master", nil, nil, true, false, util.IOFileHandles{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, clones, "should have cloned the version stream")
+	assert.Equal(t, "v1.0.1", resolved)
+	assert.True(t, strings.HasPrefix(repoDir, dir), "the clone %s should be in the cache dir %s", repoDir, dir)
+
+	now = now.Add(30 * time.Minute)
+	cachedDir, resolved, err := cache.CloneJXVersionsRepo(url, "master", nil, nil, true, false, util.IOFileHandles{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, clones, "should have used the cached version stream within the TTL")
+	assert.Equal(t, repoDir, cachedDir)
+	assert.Equal(t, "v1.0.1", resolved)
+
+	otherDir, _, err := cache.CloneJXVersionsRepo(url, "v1.0.0", nil, nil, true, false, util.IOFileHandles{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, clones, "should have cloned a different ref")
+	assert.NotEqual(t, repoDir, otherDir)
+
+	now = now.Add(time.Hour)
+	cloneErr = errors.New("dial tcp: lookup github.com: no such host")
+	cachedDir, _, err = cache.CloneJXVersionsRepo(url, "master", nil, nil, true, false, util.IOFileHandles{})
+	require.NoError(t, err, "should have used the stale cached version stream if fetching fails")
+	assert.Equal(t, 3, clones, "should have fetched the expired version stream")
+	assert.Equal(t, repoDir, cachedDir)
+
+	cache.Offline = true
+	cachedDir, _, err = cache.CloneJXVersionsRepo(url, "master", nil, nil, true, false, util.IOFileHandles{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, clones, "should not fetch the version stream offline")
+	assert.Equal(t, repoDir, cachedDir)
+
+	_, _, err = cache.CloneJXVersionsRepo(url, "v2.0.0", nil, nil, true, false, util.IOFileHandles{})
+	require.Error(t, err, "should fail offline if the version stream is not cached")
+	assert.Equal(t, 3, clones)
+}
@@ -2,6 +2,7 @@ package versionstreamrepo
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -35,13 +36,21 @@ func CloneJXVersionsRepo(versionRepository string, versionRef string, settings *
 }
 
 func cloneJXVersionsRepo(versionRepository string, versionRef string, settings *v1.TeamSettings, gitter gits.Gitter, batchMode bool, advancedMode bool, handles util.IOFileHandles) (string, string, error) {
-	surveyOpts := survey.WithStdio(handles.In, handles.Out, handles.Err)
 	configDir, err := util.ConfigDir()
 	if err != nil {
 		return "", "", fmt.Errorf("error determining config dir %v", err)
 	}
 	wrkDir := filepath.Join(configDir, "jenkins-x-versions")
 
+	versionRepository, versionRef = defaultVersionsRepo(versionRepository, versionRef, settings)
+
+	log.Logger().Debugf("Current configuration dir: %s", configDir)
+	return cloneJXVersionsRepoToDir(wrkDir, versionRepository, versionRef, gitter, batchMode, advancedMode, handles)
+}
+
+// defaultVersionsRepo defaults the version stream URL and git ref from the team settings and then the default
+// version stream
+func defaultVersionsRepo(versionRepository string, versionRef string, settings *v1.TeamSettings) (string, string) {
 	if settings != nil {
 		if versionRepository == "" {
 			versionRepository = settings.VersionStreamURL
@@ -56,9 +65,14 @@ func cloneJXVersionsRepo(versionRepository string, versionRef string, settings *
 	if versionRef == "" {
 		versionRef = config.DefaultVersionsRef
 	}
+	return versionRepository, versionRef
+}
 
-	log.Logger().Debugf("Current configuration dir: %s", configDir)
-	log.Logger().Debugf("VersionRepository: %s git ref: %s", versionRepository, versionRef)
+// cloneJXVersionsRepoToDir clones the version stream into the working dir or pulls the latest if it has already been
+// cloned
+func cloneJXVersionsRepoToDir(wrkDir string, versionRepository string, versionRef string, gitter gits.Gitter, batchMode bool, advancedMode bool, handles util.IOFileHandles) (string, string, error) {
+	surveyOpts := survey.WithStdio(handles.In, handles.Out, handles.Err)
+	log.Logger().Debugf("VersionRepository: %s git ref: %s", util.SanitizeURL(versionRepository), versionRef)
 
 	// If the repo already exists let's try to fetch the latest version
 	if exists, err := util.DirExists(wrkDir); err == nil && exists {
@@ -140,19 +154,49 @@ func cloneJXVersionsRepo(versionRepository string, versionRef string, settings *
 
 func deleteAndReClone(wrkDir string, versionRepository string, referenceName string, gitter gits.Gitter) (string, error) {
 	log.Logger().Debug("Deleting and cloning the Jenkins X versions repo")
-	err := os.RemoveAll(wrkDir)
+	// lets clone into a sibling temporary directory and then rename it over the cached clone so that concurrent
+	// readers of the cache never see a partial clone and a failed clone leaves the previous one in place
+	parentDir := filepath.Dir(wrkDir)
+	err := os.MkdirAll(parentDir, util.DefaultWritePermissions)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to delete dir %s: %s\n", wrkDir, err.Error())
+		return "", errors.Wrapf(err, "failed to ensure directory is created %s", parentDir)
 	}
-	err = os.MkdirAll(wrkDir, util.DefaultWritePermissions)
+	tmpDir, err := ioutil.TempDir(parentDir, filepath.Base(wrkDir)+".tmp-")
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to ensure directory is created %s", wrkDir)
+		return "", errors.Wrapf(err, "failed to create a temporary directory in %s", parentDir)
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck
+	_, err = clone(tmpDir, versionRepository, referenceName, gitter)
+	if err != nil {
+		return "", err
 	}
-	_, err = clone(wrkDir, versionRepository, referenceName, gitter)
+	err = removeRemoteCredentials(tmpDir, versionRepository, gitter)
 	if err != nil {
 		return "", err
 	}
-	return wrkDir, err
+	err = os.RemoveAll(wrkDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to delete dir %s: %s\n", wrkDir, err.Error())
+	}
+	err = os.Rename(tmpDir, wrkDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to rename %s to %s", tmpDir, wrkDir)
+	}
+	return wrkDir, nil
+}
+
+// removeRemoteCredentials replaces the origin URL of the clone with one without any credentials so that the token of
+// a privately hosted version stream is not stored in the .git/config file. Later fetches always pass the URL
+func removeRemoteCredentials(wrkDir string, versionRepository string, gitter gits.Gitter) error {
+	sanitizedURL := util.SanitizeURL(versionRepository)
+	if sanitizedURL == versionRepository {
+		return nil
+	}
+	err := gitter.SetRemoteURL(wrkDir, "origin", sanitizedURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove the credentials from the remote of the version stream %s in dir %s", sanitizedURL, wrkDir)
+	}
+	return nil
 }
 
 func clone(wrkDir string, versionRepository string, referenceName string, gitter gits.Gitter) (string, error) {
@@ -162,14 +206,14 @@ func clone(wrkDir string, versionRepository string, referenceName string, gitter
 		if strings.HasPrefix(referenceName, "PR-") {
 			prNumber := strings.TrimPrefix(referenceName, "PR-")
 
-			log.Logger().Debugf("Cloning the Jenkins X versions repo %s with PR: %s to %s", util.ColorInfo(versionRepository), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
+			log.Logger().Debugf("Cloning the Jenkins X versions repo %s with PR: %s to %s", util.ColorInfo(util.SanitizeURL(versionRepository)), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
 			return "", shallowCloneGitRepositoryToDir(wrkDir, versionRepository, prNumber, "", gitter)
 		}
-		log.Logger().Debugf("Cloning the Jenkins X versions repo %s with revision %s to %s", util.ColorInfo(versionRepository), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
+		log.Logger().Debugf("Cloning the Jenkins X versions repo %s with revision %s to %s", util.ColorInfo(util.SanitizeURL(versionRepository)), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
 
 		err := gitter.Clone(versionRepository, wrkDir)
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to clone repository: %s to dir %s", versionRepository, wrkDir)
+			return "", errors.Wrapf(err, "failed to clone repository: %s to dir %s", util.SanitizeURL(versionRepository), wrkDir)
 		}
 		cmd := util.Command{
 			Dir:  wrkDir,
@@ -178,7 +222,7 @@ func clone(wrkDir string, versionRepository string, referenceName string, gitter
 		}
 		_, err = cmd.RunWithoutRetry()
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to git fetch origin %s for repo: %s in dir %s", referenceName, versionRepository, wrkDir)
+			return "", errors.Wrapf(err, "failed to git fetch origin %s for repo: %s in dir %s", referenceName, util.SanitizeURL(versionRepository), wrkDir)
 		}
 		isBranch, err := gits.RefIsBranch(wrkDir, referenceName, gitter)
 		if err != nil {
@@ -187,17 +231,17 @@ func clone(wrkDir string, versionRepository string, referenceName string, gitter
 		if isBranch {
 			err = gitter.Checkout(wrkDir, referenceName)
 			if err != nil {
-				return "", errors.Wrapf(err, "failed to checkout %s of repo: %s in dir %s", referenceName, versionRepository, wrkDir)
+				return "", errors.Wrapf(err, "failed to checkout %s of repo: %s in dir %s", referenceName, util.SanitizeURL(versionRepository), wrkDir)
 			}
 			return "", nil
 		}
 		err = gitter.Checkout(wrkDir, "FETCH_HEAD")
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to checkout FETCH_HEAD of repo: %s in dir %s", versionRepository, wrkDir)
+			return "", errors.Wrapf(err, "failed to checkout FETCH_HEAD of repo: %s in dir %s", util.SanitizeURL(versionRepository), wrkDir)
 		}
 		return "", nil
 	}
-	log.Logger().Infof("Cloning the Jenkins X versions repo %s with ref %s to %s", util.ColorInfo(versionRepository), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
+	log.Logger().Infof("Cloning the Jenkins X versions repo %s with ref %s to %s", util.ColorInfo(util.SanitizeURL(versionRepository)), util.ColorInfo(referenceName), util.ColorInfo(wrkDir))
 	// TODO: Change this to use gitter instead, but need to understand exactly what it's doing first.
 	_, err := git.PlainClone(wrkDir, false, &git.CloneOptions{
 		URL:           versionRepository,
@@ -213,26 +257,26 @@ func clone(wrkDir string, versionRepository string, referenceName string, gitter
 
 func shallowCloneGitRepositoryToDir(dir string, gitURL string, pullRequestNumber string, revision string, gitter gits.Gitter) error {
 	if pullRequestNumber != "" {
-		log.Logger().Infof("shallow cloning pull request %s of repository %s to temp dir %s", gitURL,
+		log.Logger().Infof("shallow cloning pull request %s of repository %s to temp dir %s", util.SanitizeURL(gitURL),
 			pullRequestNumber, dir)
 		err := gitter.ShallowClone(dir, gitURL, "", pullRequestNumber)
 		if err != nil {
-			return errors.Wrapf(err, "shallow cloning pull request %s of repository %s to temp dir %s\n", gitURL,
+			return errors.Wrapf(err, "shallow cloning pull request %s of repository %s to temp dir %s\n", util.SanitizeURL(gitURL),
 				pullRequestNumber, dir)
 		}
 	} else if revision != "" {
-		log.Logger().Infof("shallow cloning revision %s of repository %s to temp dir %s", gitURL,
+		log.Logger().Infof("shallow cloning revision %s of repository %s to temp dir %s", util.SanitizeURL(gitURL),
 			revision, dir)
 		err := gitter.ShallowClone(dir, gitURL, revision, "")
 		if err != nil {
-			return errors.Wrapf(err, "shallow cloning revision %s of repository %s to temp dir %s\n", gitURL,
+			return errors.Wrapf(err, "shallow cloning revision %s of repository %s to temp dir %s\n", util.SanitizeURL(gitURL),
 				revision, dir)
 		}
 	} else {
-		log.Logger().Infof("shallow cloning master of repository %s to temp dir %s", gitURL, dir)
+		log.Logger().Infof("shallow cloning master of repository %s to temp dir %s", util.SanitizeURL(gitURL), dir)
 		err := gitter.ShallowClone(dir, gitURL, "", "")
 		if err != nil {
-			return errors.Wrapf(err, "shallow cloning master of repository %s to temp dir %s\n", gitURL, dir)
+			return errors.Wrapf(err, "shallow cloning master of repository %s to temp dir %s\n", util.SanitizeURL(gitURL), dir)
 		}
 	}
 
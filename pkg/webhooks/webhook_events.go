@@ -0,0 +1,273 @@
+package webhooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// LabelSource the label of the name of the controller which received a webhook event
+	LabelSource = "jenkins.io/webhook-source"
+	// LabelDeadLetter the label of the webhook events whose handler failed
+	LabelDeadLetter = "jenkins.io/dead-letter"
+
+	// DefaultStaleTimeout the default duration after which an event which is still running can be claimed again
+	DefaultStaleTimeout = 30 * time.Minute
+
+	updateRetries = 3
+)
+
+// Handler processes a webhook event
+type Handler func(event *v1.WebhookEvent) error
+
+// Ingester persists the webhook deliveries received by a controller as WebhookEvent resources so that redeliveries
+// are ignored, events can be replayed and the events whose handler failed are kept in a dead letter queue
+type Ingester struct {
+	JXClient  versioned.Interface
+	Namespace string
+	Source    string
+	Handler   Handler
+	// StaleTimeout the duration after which an event which is still running can be claimed again as its controller
+	// most likely stopped while processing it. Defaults to DefaultStaleTimeout
+	StaleTimeout time.Duration
+}
+
+// EventName returns the name of the WebhookEvent of a delivery. If the git provider does not send a delivery ID the
+// hash of the payload is used instead so that redeliveries are still detected
+func EventName(source string, deliveryID string, payload []byte) string {
+	id := deliveryID
+	if id == "" {
+		sum := sha256.Sum256(payload)
+		id = hex.EncodeToString(sum[:])[0:16]
+	}
+	return naming.ToValidNameTruncated(source+"-"+id, 63)
+}
+
+// Ingest persists a webhook delivery of the given git commit returning the event and whether it should be processed.
+// The event is created as pending and then claimed so that it is replayed if the controller stops before processing
+// it. Redeliveries of events which are running or have succeeded are ignored whereas redeliveries of failed or stale
+// events are retried
+func (i *Ingester) Ingest(eventType string, deliveryID string, revision string, payload []byte) (*v1.WebhookEvent, bool, error) {
+	now := metav1.Now()
+	sum := sha256.Sum256(payload)
+	event := &v1.WebhookEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: EventName(i.Source, deliveryID, payload),
+			Labels: map[string]string{
+				LabelSource: i.Source,
+			},
+		},
+		Spec: v1.WebhookEventSpec{
+			Source:            i.Source,
+			EventType:         eventType,
+			DeliveryID:        deliveryID,
+			PayloadDigest:     hex.EncodeToString(sum[:]),
+			ReceivedTimestamp: &now,
+			Revision:          revision,
+		},
+		Status: v1.WebhookEventStatus{
+			Phase: v1.WebhookEventPhasePending,
+		},
+	}
+	events := i.JXClient.JenkinsV1().WebhookEvents(i.Namespace)
+	created, err := events.Create(event)
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, false, errors.Wrapf(err, "creating WebhookEvent %s in namespace %s", event.Name, i.Namespace)
+		}
+		created, err = events.Get(event.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "getting WebhookEvent %s in namespace %s", event.Name, i.Namespace)
+		}
+		if created.Status.Phase != v1.WebhookEventPhaseFailed && !i.claimable(created) {
+			log.Logger().Infof("ignoring redelivery of webhook event %s which is %s", created.Name, created.Status.Phase)
+			return created, false, nil
+		}
+	}
+	claimed, err := i.claim(created)
+	if claimed == nil {
+		return created, false, err
+	}
+	return claimed, true, nil
+}
+
+// Process invokes the handler of an event which has been claimed by Ingest or Replay and records the attempt.
+// If the handler fails the event is labelled as a dead letter
+func (i *Ingester) Process(event *v1.WebhookEvent, replay bool) error {
+	started := metav1.Now()
+	handlerErr := i.Handler(event)
+	completed := metav1.Now()
+	attempt := v1.WebhookEventAttempt{
+		StartedTimestamp:   &started,
+		CompletedTimestamp: &completed,
+		Replay:             replay,
+	}
+	if handlerErr != nil {
+		attempt.Error = handlerErr.Error()
+	}
+	err := i.updateEvent(event.Name, func(e *v1.WebhookEvent) {
+		e.Status.Attempts = append(e.Status.Attempts, attempt)
+		if e.Labels == nil {
+			e.Labels = map[string]string{}
+		}
+		if handlerErr != nil {
+			e.Status.Phase = v1.WebhookEventPhaseFailed
+			e.Status.Message = handlerErr.Error()
+			e.Labels[LabelDeadLetter] = "true"
+		} else {
+			e.Status.Phase = v1.WebhookEventPhaseSucceeded
+			e.Status.Message = ""
+			delete(e.Labels, LabelDeadLetter)
+		}
+	})
+	if handlerErr != nil {
+		if err != nil {
+			log.Logger().Warnf("failed to record the failure of webhook event %s: %s", event.Name, err)
+		}
+		return errors.Wrapf(handlerErr, "processing webhook event %s", event.Name)
+	}
+	return err
+}
+
+// Replay processes an event of this controller which is pending, e.g. as its replay has been requested, or whose
+// processing is stale unless it has already been claimed
+func (i *Ingester) Replay(event *v1.WebhookEvent) error {
+	if event.Spec.Source != i.Source || !i.claimable(event) {
+		return nil
+	}
+	claimed, err := i.claim(event)
+	if err != nil || claimed == nil {
+		return err
+	}
+	log.Logger().Infof("replaying webhook event %s", claimed.Name)
+	return i.Process(claimed, true)
+}
+
+// WatchReplays replays the pending and stale events of this controller until the stop channel is closed. The events
+// are checked again every resync so that stale events are claimed once they time out
+func (i *Ingester) WatchReplays(stop <-chan struct{}) {
+	listWatch := cache.NewListWatchFromClient(i.JXClient.JenkinsV1().RESTClient(), "webhookevents", i.Namespace, fields.Everything())
+	kube.SortListWatchByName(listWatch)
+	_, controller := cache.NewInformer(
+		listWatch,
+		&v1.WebhookEvent{},
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				i.onWebhookEvent(obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				i.onWebhookEvent(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+			},
+		},
+	)
+	controller.Run(stop)
+}
+
+func (i *Ingester) onWebhookEvent(obj interface{}) {
+	event, ok := obj.(*v1.WebhookEvent)
+	if !ok {
+		log.Logger().Warnf("unexpected type %#v", obj)
+		return
+	}
+	if event.Spec.Source != i.Source || !i.claimable(event) {
+		return
+	}
+	go func() {
+		err := i.Replay(event)
+		if err != nil {
+			log.Logger().Errorf("failed to replay webhook event %s: %s", event.Name, err)
+		}
+	}()
+}
+
+// claimable returns true if the event is pending or is still running long after it was claimed
+func (i *Ingester) claimable(event *v1.WebhookEvent) bool {
+	switch event.Status.Phase {
+	case v1.WebhookEventPhasePending:
+		return true
+	case v1.WebhookEventPhaseRunning:
+		claimed := event.Status.ClaimedTimestamp
+		if claimed == nil {
+			claimed = event.Spec.ReceivedTimestamp
+		}
+		timeout := i.StaleTimeout
+		if timeout <= 0 {
+			timeout = DefaultStaleTimeout
+		}
+		return claimed == nil || time.Since(claimed.Time) > timeout
+	}
+	return false
+}
+
+// claim marks an event as running so that it is only processed once. It returns nil if the event was changed
+// concurrently, e.g. by another request claiming it first
+func (i *Ingester) claim(event *v1.WebhookEvent) (*v1.WebhookEvent, error) {
+	now := metav1.Now()
+	copy := event.DeepCopy()
+	copy.Status.Phase = v1.WebhookEventPhaseRunning
+	copy.Status.ClaimedTimestamp = &now
+	answer, err := i.JXClient.JenkinsV1().WebhookEvents(i.Namespace).Update(copy)
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "claiming WebhookEvent %s in namespace %s", event.Name, i.Namespace)
+	}
+	return answer, nil
+}
+
+func (i *Ingester) updateEvent(name string, fn func(event *v1.WebhookEvent)) error {
+	events := i.JXClient.JenkinsV1().WebhookEvents(i.Namespace)
+	var err error
+	for attempt := 0; attempt < updateRetries; attempt++ {
+		var event *v1.WebhookEvent
+		event, err = events.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting WebhookEvent %s in namespace %s", name, i.Namespace)
+		}
+		fn(event)
+		_, err = events.Update(event)
+		if err == nil || !apierrors.IsConflict(err) {
+			break
+		}
+	}
+	return errors.Wrapf(err, "updating WebhookEvent %s in namespace %s", name, i.Namespace)
+}
+
+// RequestReplay marks an event as pending so that the controller which received it processes it again for the same
+// commit. Events which are running can only be replayed if force is true, e.g. if the controller was restarted while
+// processing them before they became stale
+func RequestReplay(jxClient versioned.Interface, ns string, name string, force bool) (*v1.WebhookEvent, error) {
+	events := jxClient.JenkinsV1().WebhookEvents(ns)
+	event, err := events.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting WebhookEvent %s in namespace %s", name, ns)
+	}
+	phase := event.Status.Phase
+	if phase == v1.WebhookEventPhasePending || (phase == v1.WebhookEventPhaseRunning && !force) {
+		return nil, fmt.Errorf("webhook event %s is already %s", name, strings.ToLower(string(phase)))
+	}
+	event.Status.Phase = v1.WebhookEventPhasePending
+	answer, err := events.Update(event)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating WebhookEvent %s in namespace %s", name, ns)
+	}
+	return answer, nil
+}
@@ -0,0 +1,113 @@
+// +build unit
+
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/webhooks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIngester(t *testing.T) {
+	ns := "jx-production"
+	jxClient := fake.NewSimpleClientset()
+	var handlerErr error
+	var handled []string
+	ingester := &webhooks.Ingester{
+		JXClient:  jxClient,
+		Namespace: ns,
+		Source:    "environment-controller",
+		Handler: func(event *v1.WebhookEvent) error {
+			handled = append(handled, event.Spec.Revision)
+			return handlerErr
+		},
+	}
+
+	event, process, err := ingester.Ingest("push", "72d3162e-cc78-11e3-81ab-4c9367dc0958", "abc123", []byte(`{"ref":"refs/heads/master"}`))
+	require.NoError(t, err)
+	require.True(t, process)
+	assert.Equal(t, "environment-controller-72d3162e-cc78-11e3-81ab-4c9367dc0958", event.Name)
+	assert.Equal(t, v1.WebhookEventPhaseRunning, event.Status.Phase)
+	assert.NotNil(t, event.Status.ClaimedTimestamp)
+	assert.Len(t, event.Spec.PayloadDigest, 64, "should store the digest rather than the payload")
+	require.NoError(t, ingester.Process(event, false))
+
+	_, process, err = ingester.Ingest("push", "72d3162e-cc78-11e3-81ab-4c9367dc0958", "abc123", []byte(`{"ref":"refs/heads/master"}`))
+	require.NoError(t, err)
+	assert.False(t, process, "redelivery of a succeeded event should be ignored")
+	assert.Len(t, handled, 1)
+
+	handlerErr = errors.New("failed to clone the environment")
+	event, process, err = ingester.Ingest("push", "", "def456", []byte(`{"ref":"refs/heads/master","after":"def456"}`))
+	require.NoError(t, err)
+	require.True(t, process)
+	assert.Error(t, ingester.Process(event, false))
+
+	deadLetters, err := jxClient.JenkinsV1().WebhookEvents(ns).List(metav1.ListOptions{LabelSelector: webhooks.LabelDeadLetter + "=true"})
+	require.NoError(t, err)
+	require.Len(t, deadLetters.Items, 1)
+	deadLetter := deadLetters.Items[0]
+	assert.Equal(t, v1.WebhookEventPhaseFailed, deadLetter.Status.Phase)
+	assert.Equal(t, "failed to clone the environment", deadLetter.Status.Message)
+
+	handlerErr = nil
+	pending, err := webhooks.RequestReplay(jxClient, ns, deadLetter.Name, false)
+	require.NoError(t, err)
+	assert.Equal(t, v1.WebhookEventPhasePending, pending.Status.Phase)
+	_, err = webhooks.RequestReplay(jxClient, ns, deadLetter.Name, false)
+	assert.Error(t, err, "replay should not be requested twice")
+
+	require.NoError(t, ingester.Replay(pending))
+	replayed, err := jxClient.JenkinsV1().WebhookEvents(ns).Get(deadLetter.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.WebhookEventPhaseSucceeded, replayed.Status.Phase)
+	assert.NotContains(t, replayed.Labels, webhooks.LabelDeadLetter)
+	require.Len(t, replayed.Status.Attempts, 2)
+	assert.False(t, replayed.Status.Attempts[0].Replay)
+	assert.True(t, replayed.Status.Attempts[1].Replay)
+	assert.Equal(t, []string{"abc123", "def456", "def456"}, handled, "should replay the commit of the event")
+}
+
+func TestIngesterReclaimsStaleEvents(t *testing.T) {
+	ns := "jx-production"
+	jxClient := fake.NewSimpleClientset()
+	handled := 0
+	ingester := &webhooks.Ingester{
+		JXClient:  jxClient,
+		Namespace: ns,
+		Source:    "environment-controller",
+		Handler: func(event *v1.WebhookEvent) error {
+			handled++
+			return nil
+		},
+	}
+	payload := []byte(`{"ref":"refs/heads/master"}`)
+	event, process, err := ingester.Ingest("push", "1234", "abc123", payload)
+	require.NoError(t, err)
+	require.True(t, process)
+
+	_, process, err = ingester.Ingest("push", "1234", "abc123", payload)
+	require.NoError(t, err)
+	assert.False(t, process, "redelivery of a running event should be ignored")
+	require.NoError(t, ingester.Replay(event))
+	assert.Equal(t, 0, handled, "should not replay an event which is still running")
+
+	// lets simulate the controller stopping while processing the event a long time ago
+	stale := metav1.NewTime(time.Now().Add(-2 * webhooks.DefaultStaleTimeout))
+	event.Status.ClaimedTimestamp = &stale
+	event, err = jxClient.JenkinsV1().WebhookEvents(ns).Update(event)
+	require.NoError(t, err)
+
+	require.NoError(t, ingester.Replay(event))
+	assert.Equal(t, 1, handled, "should reclaim a stale running event")
+	processed, err := jxClient.JenkinsV1().WebhookEvents(ns).Get(event.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.WebhookEventPhaseSucceeded, processed.Status.Phase)
+}